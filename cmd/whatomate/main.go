@@ -17,6 +17,7 @@ import (
 	"github.com/shridarpatil/whatomate/internal/handlers"
 	"github.com/shridarpatil/whatomate/internal/middleware"
 	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/shridarpatil/whatomate/internal/storage"
 	"github.com/shridarpatil/whatomate/internal/websocket"
 	"github.com/shridarpatil/whatomate/internal/worker"
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
@@ -68,6 +69,8 @@ Server Options:
   -config string    Path to config file (default "config.toml")
   -migrate          Run database migrations on startup
   -workers int      Number of embedded workers (0 to disable) (default 1)
+  -webhook-workers int
+                    Number of inbound webhook workers (0 to disable) (default 4)
 
 Worker Options:
   -config string    Path to config file (default "config.toml")
@@ -95,6 +98,7 @@ func runServer(args []string) {
 	configPath := serverFlags.String("config", "config.toml", "Path to config file")
 	migrate := serverFlags.Bool("migrate", false, "Run database migrations")
 	numWorkers := serverFlags.Int("workers", 1, "Number of workers to run (0 to disable embedded workers)")
+	numWebhookWorkers := serverFlags.Int("webhook-workers", 4, "Number of inbound webhook workers (0 to disable)")
 	_ = serverFlags.Parse(args)
 
 	// Initialize logger
@@ -172,6 +176,13 @@ func runServer(args []string) {
 	go wsHub.Run()
 	lo.Info("WebSocket hub started")
 
+	// Initialize media storage
+	mediaStorage, err := storage.New(&cfg.Storage)
+	if err != nil {
+		lo.Fatal("Failed to initialize media storage", "error", err)
+	}
+	lo.Info("Media storage initialized", "type", cfg.Storage.Type)
+
 	// Initialize app with dependencies
 	// Shared HTTP client with connection pooling for external API calls
 	httpClient := &http.Client{
@@ -184,15 +195,32 @@ func runServer(args []string) {
 		},
 	}
 
+	// AI requests use the settings' timeout and may reach trusted self-hosted model servers
+	aiHTTPClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext:         handlers.SSRFSafeDialerAllowing(cfg.AI.PrivateHosts),
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+
 	app := &handlers.App{
-		Config:     cfg,
-		DB:         db,
-		Redis:      rdb,
-		Log:        lo,
-		WhatsApp:   waClient,
-		WSHub:      wsHub,
-		Queue:      jobQueue,
-		HTTPClient: httpClient,
+		Config:       cfg,
+		DB:           db,
+		Redis:        rdb,
+		Log:          lo,
+		WhatsApp:     waClient,
+		WSHub:        wsHub,
+		Queue:        jobQueue,
+		Storage:      mediaStorage,
+		HTTPClient:   httpClient,
+		AIHTTPClient: aiHTTPClient,
+	}
+
+	// Relay WebSocket broadcasts through Redis so every API instance reaches its clients
+	if err := app.StartWSRelay(); err != nil {
+		lo.Error("Failed to start websocket relay", "error", err)
 	}
 
 	// Start campaign stats subscriber for real-time WebSocket updates from worker
@@ -200,6 +228,11 @@ func runServer(args []string) {
 		lo.Error("Failed to start campaign stats subscriber", "error", err)
 	}
 
+	// Start inbound webhook workers (process Meta webhooks queued by WebhookHandler)
+	if err := app.StartWebhookWorkers(*numWebhookWorkers); err != nil {
+		lo.Fatal("Failed to start webhook workers", "error", err)
+	}
+
 	// Parse allowed origins for CORS
 	allowedOrigins := middleware.ParseAllowedOrigins(cfg.Server.AllowedOrigins)
 
@@ -217,7 +250,9 @@ func runServer(args []string) {
 		Handler:      corsWrapper(g.Handler(), allowedOrigins),
 		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
-		Name:         "Whatomate",
+		// Room for 20MB knowledge base documents and 16MB media uploads
+		MaxRequestBodySize: 24 << 20,
+		Name:               "Whatomate",
 	}
 
 	// Start server in goroutine
@@ -235,6 +270,24 @@ func runServer(args []string) {
 	go slaProcessor.Start(slaCtx)
 	lo.Info("SLA processor started")
 
+	// Start campaign scheduler (dispatches scheduled campaigns when due)
+	campaignScheduler := handlers.NewCampaignScheduler(app, 30*time.Second)
+	schedulerCtx, schedulerCancel := context.WithCancel(context.Background())
+	go campaignScheduler.Start(schedulerCtx)
+	lo.Info("Campaign scheduler started")
+
+	// Start flow wait processor (resumes chatbot flows paused on a wait step)
+	flowWaitProcessor := handlers.NewFlowWaitProcessor(app, 15*time.Second)
+	flowWaitCtx, flowWaitCancel := context.WithCancel(context.Background())
+	go flowWaitProcessor.Start(flowWaitCtx)
+	lo.Info("Flow wait processor started")
+
+	// Start webhook retrier (retries failed webhook deliveries with backoff)
+	webhookRetrier := handlers.NewWebhookRetrier(app, 15*time.Second)
+	retrierCtx, retrierCancel := context.WithCancel(context.Background())
+	go webhookRetrier.Start(retrierCtx)
+	lo.Info("Webhook retrier started")
+
 	// Start embedded workers
 	var workers []*worker.Worker
 	var workerCancel context.CancelFunc
@@ -274,12 +327,33 @@ func runServer(args []string) {
 	app.StopCampaignStatsSubscriber()
 	lo.Info("Campaign stats subscriber stopped")
 
+	// Stop websocket relay
+	app.StopWSRelay()
+
 	// Stop SLA processor
 	lo.Info("Stopping SLA processor...")
 	slaCancel()
 	slaProcessor.Stop()
 	lo.Info("SLA processor stopped")
 
+	// Stop campaign scheduler
+	lo.Info("Stopping campaign scheduler...")
+	schedulerCancel()
+	campaignScheduler.Stop()
+	lo.Info("Campaign scheduler stopped")
+
+	// Stop flow wait processor
+	lo.Info("Stopping flow wait processor...")
+	flowWaitCancel()
+	flowWaitProcessor.Stop()
+	lo.Info("Flow wait processor stopped")
+
+	// Stop webhook retrier
+	lo.Info("Stopping webhook retrier...")
+	retrierCancel()
+	webhookRetrier.Stop()
+	lo.Info("Webhook retrier stopped")
+
 	// Stop workers first
 	if workerCancel != nil {
 		lo.Info("Stopping workers...", "count", len(workers))
@@ -290,6 +364,11 @@ func runServer(args []string) {
 		lo.Info("Workers stopped")
 	}
 
+	// Stop webhook workers
+	lo.Info("Stopping webhook workers...")
+	app.StopWebhookWorkers()
+	lo.Info("Webhook workers stopped")
+
 	// Then stop server
 	lo.Info("Stopping server...")
 	if err := server.Shutdown(); err != nil {
@@ -480,6 +559,10 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 		if len(path) >= 28 && path[:28] == "/api/custom-actions/redirect" {
 			return r
 		}
+		// Skip auth for notification rule webhooks (the URL token identifies the rule)
+		if len(path) > 30 && path[:30] == "/api/notification-rules/hooks/" {
+			return r
+		}
 		// Apply auth for all other /api routes (supports both JWT and API key)
 		if len(path) > 4 && path[:4] == "/api" {
 			return middleware.AuthWithDB(app.Config.JWT.Secret, app.DB)(r)
@@ -514,6 +597,9 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.GET("/api/users/{id}", app.GetUser)
 	g.PUT("/api/users/{id}", app.UpdateUser)
 	g.DELETE("/api/users/{id}", app.DeleteUser)
+	g.GET("/api/users/{id}/skills", app.GetUserSkills)
+	g.PUT("/api/users/{id}/skills", app.UpdateUserSkills)
+	g.GET("/api/skills", app.ListSkills)
 
 	// Roles & Permissions (admin only - enforced by middleware)
 	g.GET("/api/roles", app.ListRoles)
@@ -550,6 +636,11 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.PUT("/api/contacts/{id}/tags", app.UpdateContactTags)
 	g.GET("/api/contacts/{id}/session-data", app.GetContactSessionData)
 
+	// Suppression list (opted-out contacts)
+	g.GET("/api/suppressions", app.ListSuppressions)
+	g.POST("/api/suppressions", app.CreateSuppression)
+	g.DELETE("/api/suppressions/{id}", app.DeleteSuppression)
+
 	// Generic Import/Export
 	g.POST("/api/export", app.ExportData)
 	g.POST("/api/import", app.ImportData)
@@ -576,6 +667,17 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.POST("/api/contacts/{id}/notes", app.CreateConversationNote)
 	g.PUT("/api/contacts/{id}/notes/{note_id}", app.UpdateConversationNote)
 	g.DELETE("/api/contacts/{id}/notes/{note_id}", app.DeleteConversationNote)
+	g.GET("/api/mentions", app.ListMentionSuggestions)
+
+	// Notifications inbox (current user)
+	g.GET("/api/notifications", app.ListNotifications)
+	g.GET("/api/notifications/unread-count", app.GetUnreadNotificationCount)
+	g.PUT("/api/notifications/read-all", app.MarkAllNotificationsRead)
+	g.PUT("/api/notifications/{id}/read", app.MarkNotificationRead)
+
+	// Conversation lifecycle
+	g.GET("/api/contacts/{id}/conversation", app.GetConversation)
+	g.PUT("/api/contacts/{id}/conversation/status", app.UpdateConversationStatus)
 
 	// Media (serves media files for messages, auth-protected)
 	g.GET("/api/media/{message_id}", app.ServeMedia)
@@ -605,6 +707,9 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	// Bulk Campaigns
 	g.GET("/api/campaigns", app.ListCampaigns)
 	g.POST("/api/campaigns", app.CreateCampaign)
+	g.GET("/api/campaigns/dead-letters", app.ListDeadLetters)
+	g.POST("/api/campaigns/dead-letters/{id}/requeue", app.RequeueDeadLetter)
+	g.DELETE("/api/campaigns/dead-letters/{id}", app.DeleteDeadLetter)
 	g.GET("/api/campaigns/{id}", app.GetCampaign)
 	g.PUT("/api/campaigns/{id}", app.UpdateCampaign)
 	g.DELETE("/api/campaigns/{id}", app.DeleteCampaign)
@@ -626,6 +731,7 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	// Keyword Rules
 	g.GET("/api/chatbot/keywords", app.ListKeywordRules)
 	g.POST("/api/chatbot/keywords", app.CreateKeywordRule)
+	g.POST("/api/chatbot/keywords/test", app.TestKeywordMatch)
 	g.GET("/api/chatbot/keywords/{id}", app.GetKeywordRule)
 	g.PUT("/api/chatbot/keywords/{id}", app.UpdateKeywordRule)
 	g.DELETE("/api/chatbot/keywords/{id}", app.DeleteKeywordRule)
@@ -636,6 +742,12 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.GET("/api/chatbot/flows/{id}", app.GetChatbotFlow)
 	g.PUT("/api/chatbot/flows/{id}", app.UpdateChatbotFlow)
 	g.DELETE("/api/chatbot/flows/{id}", app.DeleteChatbotFlow)
+	g.POST("/api/chatbot/flows/{id}/simulate", app.SimulateChatbotFlow)
+	g.POST("/api/chatbot/flows/{id}/publish", app.PublishChatbotFlow)
+	g.GET("/api/chatbot/flows/{id}/versions", app.ListChatbotFlowVersions)
+	g.GET("/api/chatbot/flows/{id}/versions/{version}", app.GetChatbotFlowVersion)
+	g.POST("/api/chatbot/flows/{id}/versions/{version}/rollback", app.RollbackChatbotFlow)
+	g.GET("/api/chatbot/flows/{id}/diff", app.DiffChatbotFlowVersions)
 
 	// AI Contexts
 	g.GET("/api/chatbot/ai-contexts", app.ListAIContexts)
@@ -644,6 +756,15 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.PUT("/api/chatbot/ai-contexts/{id}", app.UpdateAIContext)
 	g.DELETE("/api/chatbot/ai-contexts/{id}", app.DeleteAIContext)
 
+	// Knowledge Base
+	g.GET("/api/chatbot/knowledge-base", app.ListKnowledgeDocuments)
+	g.POST("/api/chatbot/knowledge-base", app.UploadKnowledgeDocument)
+	g.POST("/api/chatbot/knowledge-base/search", app.SearchKnowledgeBase)
+	g.GET("/api/chatbot/knowledge-base/{id}", app.GetKnowledgeDocument)
+	g.PUT("/api/chatbot/knowledge-base/{id}", app.UpdateKnowledgeDocument)
+	g.DELETE("/api/chatbot/knowledge-base/{id}", app.DeleteKnowledgeDocument)
+	g.POST("/api/chatbot/knowledge-base/{id}/reindex", app.ReindexKnowledgeDocument)
+
 	// Agent Transfers
 	g.GET("/api/chatbot/transfers", app.ListAgentTransfers)
 	g.POST("/api/chatbot/transfers", app.CreateAgentTransfer)
@@ -722,6 +843,9 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.PUT("/api/webhooks/{id}", app.UpdateWebhook)
 	g.DELETE("/api/webhooks/{id}", app.DeleteWebhook)
 	g.POST("/api/webhooks/{id}/test", app.TestWebhook)
+	g.GET("/api/webhooks/{id}/deliveries", app.ListWebhookDeliveries)
+	g.GET("/api/webhooks/{id}/deliveries/{delivery_id}", app.GetWebhookDelivery)
+	g.POST("/api/webhooks/{id}/deliveries/{delivery_id}/replay", app.ReplayWebhookDelivery)
 
 	// Custom Actions
 	g.GET("/api/custom-actions", app.ListCustomActions)
@@ -732,6 +856,15 @@ func setupRoutes(g *fastglue.Fastglue, app *handlers.App, lo logf.Logger, basePa
 	g.POST("/api/custom-actions/{id}/execute", app.ExecuteCustomAction)
 	g.GET("/api/custom-actions/redirect/{token}", app.CustomActionRedirect)
 
+	// Notification Rules
+	g.GET("/api/notification-rules", app.ListNotificationRules)
+	g.POST("/api/notification-rules", app.CreateNotificationRule)
+	g.GET("/api/notification-rules/{id}", app.GetNotificationRule)
+	g.PUT("/api/notification-rules/{id}", app.UpdateNotificationRule)
+	g.DELETE("/api/notification-rules/{id}", app.DeleteNotificationRule)
+	g.POST("/api/notification-rules/{id}/trigger", app.TriggerNotificationRule)
+	g.POST("/api/notification-rules/hooks/{token}", app.NotificationRuleWebhook)
+
 	// Catalogs
 	g.GET("/api/catalogs", app.ListCatalogs)
 	g.POST("/api/catalogs", app.CreateCatalog)
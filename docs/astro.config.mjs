@@ -51,6 +51,8 @@ export default defineConfig({
             { label: 'Chatbot', slug: 'api-reference/chatbot' },
             { label: 'Canned Responses', slug: 'api-reference/canned-responses' },
             { label: 'Custom Actions', slug: 'api-reference/custom-actions' },
+            { label: 'Notification Rules', slug: 'api-reference/notification-rules' },
+            { label: 'Notifications', slug: 'api-reference/notifications' },
             { label: 'Webhooks', slug: 'api-reference/webhooks' },
             { label: 'Analytics', slug: 'api-reference/analytics' },
           ],
@@ -18,7 +18,8 @@ import {
   Zap,
   Shield,
   LineChart,
-  Tags
+  Tags,
+  BookOpen
 } from 'lucide-vue-next'
 import type { Component } from 'vue'
 
@@ -54,7 +55,8 @@ export const navigationItems: NavItem[] = [
       { name: 'nav.overview', path: '/chatbot', icon: Bot, permission: 'settings.chatbot' },
       { name: 'nav.keywords', path: '/chatbot/keywords', icon: Key, permission: 'chatbot.keywords' },
       { name: 'nav.flows', path: '/chatbot/flows', icon: Workflow, permission: 'flows.chatbot' },
-      { name: 'nav.aiContexts', path: '/chatbot/ai', icon: Sparkles, permission: 'chatbot.ai' }
+      { name: 'nav.aiContexts', path: '/chatbot/ai', icon: Sparkles, permission: 'chatbot.ai' },
+      { name: 'nav.knowledgeBase', path: '/chatbot/knowledge-base', icon: BookOpen, permission: 'chatbot.ai' }
     ]
   },
   {
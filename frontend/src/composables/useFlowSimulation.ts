@@ -178,6 +178,27 @@ export function useFlowSimulation(
     // Interpolate variables
     messageContent = interpolateVariables(messageContent, state.variables)
 
+    // Wait steps elapse immediately in the preview
+    if (step.message_type === 'wait') {
+      if (messageContent) addMessage('bot', messageContent, { stepName: step.step_name })
+      const wait = step.wait_config || {}
+      addMessage('system', wait.duration_minutes
+        ? `Waiting ${wait.duration_minutes} min (skipped in preview)`
+        : `Waiting until ${wait.until || '?'} (skipped in preview)`)
+      await moveToNextStep(step)
+      return
+    }
+
+    // Actions change the contact, so the preview only lists them
+    if (step.message_type === 'action') {
+      for (const action of step.actions || []) {
+        addMessage('system', `Action: ${action.type} (skipped in preview)`)
+      }
+      if (messageContent) addMessage('bot', messageContent, { stepName: step.step_name })
+      await moveToNextStep(step)
+      return
+    }
+
     // Add bot message
     addMessage('bot', messageContent || 'No message configured', {
       stepName: step.step_name,
@@ -261,14 +282,22 @@ export function useFlowSimulation(
 
       log('validation_pass', step.step_name, { input })
 
+      // Add user message
+      addMessage('user', input)
+
+      // The model isn't called in the preview, so nothing is stored
+      if (step.message_type === 'ai') {
+        addMessage('system', `AI ${step.ai_config?.mode === 'extract' ? 'extraction' : 'reply'} (skipped in preview)`)
+        state.currentRetryCount = 0
+        await moveToNextStep(step)
+        return
+      }
+
       // Store value
       if (step.store_as) {
         setVariable(step.store_as, input)
       }
 
-      // Add user message
-      addMessage('user', input)
-
       state.currentRetryCount = 0
       await moveToNextStep(step)
     } else {
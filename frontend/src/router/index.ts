@@ -122,6 +122,12 @@ const router = createRouter({
           component: () => import('@/views/chatbot/AIContextsView.vue'),
           meta: { permission: 'chatbot.ai' }
         },
+        {
+          path: 'chatbot/knowledge-base',
+          name: 'chatbot-knowledge-base',
+          component: () => import('@/views/chatbot/KnowledgeBaseView.vue'),
+          meta: { permission: 'chatbot.ai' }
+        },
         {
           path: 'chatbot/transfers',
           name: 'chatbot-transfers',
@@ -237,7 +243,8 @@ const navigationOrder = [
     { path: '/chatbot', permission: 'settings.chatbot' },
     { path: '/chatbot/keywords', permission: 'chatbot.keywords' },
     { path: '/chatbot/flows', permission: 'flows.chatbot' },
-    { path: '/chatbot/ai', permission: 'chatbot.ai' }
+    { path: '/chatbot/ai', permission: 'chatbot.ai' },
+    { path: '/chatbot/knowledge-base', permission: 'chatbot.ai' }
   ]},
   { path: '/chatbot/transfers', permission: 'transfers' },
   { path: '/analytics/agents', permission: 'analytics.agents' },
@@ -104,6 +104,10 @@ export const usersService = {
   update: (id: string, data: { email?: string; password?: string; full_name?: string; role_id?: string; is_active?: boolean }) =>
     api.put(`/users/${id}`, data),
   delete: (id: string) => api.delete(`/users/${id}`),
+  getSkills: (id: string) => api.get(`/users/${id}/skills`),
+  updateSkills: (id: string, skills: { skill: string; proficiency: number }[]) =>
+    api.put(`/users/${id}/skills`, { skills }),
+  listSkills: () => api.get('/skills'),
   me: () => api.get('/me'),
   updateSettings: (data: { email_notifications: boolean; new_message_alerts: boolean; campaign_updates: boolean }) =>
     api.put('/me/settings', data),
@@ -131,7 +135,7 @@ export const accountsService = {
 }
 
 export const contactsService = {
-  list: (params?: { search?: string; page?: number; limit?: number; tags?: string }) =>
+  list: (params?: { search?: string; page?: number; limit?: number; tags?: string; conversation_status?: string }) =>
     api.get('/contacts', { params }),
   get: (id: string) => api.get(`/contacts/${id}`),
   create: (data: any) => api.post('/contacts', data),
@@ -141,7 +145,10 @@ export const contactsService = {
     api.put(`/contacts/${id}/assign`, { user_id: userId }),
   updateTags: (id: string, tags: string[]) =>
     api.put(`/contacts/${id}/tags`, { tags }),
-  getSessionData: (id: string) => api.get(`/contacts/${id}/session-data`)
+  getSessionData: (id: string) => api.get(`/contacts/${id}/session-data`),
+  getConversation: (id: string) => api.get(`/contacts/${id}/conversation`),
+  updateConversationStatus: (id: string, data: { status: string; snoozed_until?: string; resolution_reason?: string }) =>
+    api.put(`/contacts/${id}/conversation/status`, data)
 }
 
 // Generic Import/Export Service
@@ -280,6 +287,43 @@ export const campaignsService = {
     api.get(`/campaigns/${campaignId}/media`, { responseType: 'arraybuffer' })
 }
 
+export interface KeywordMatchTestResult {
+  matched: boolean
+  rule_id?: string
+  rule_name?: string
+  match_type?: string
+  response_type?: string
+  keyword?: string
+  score: number
+  method?: 'local' | 'ai'
+  intents: { rule_id: string; rule_name: string; phrase: string; score: number }[]
+}
+
+export interface KnowledgeDocument {
+  id: string
+  name: string
+  file_name: string
+  source_type: 'pdf' | 'markdown' | 'html' | 'csv' | 'text'
+  file_size: number
+  whatsapp_account: string
+  status: 'processing' | 'ready' | 'failed'
+  error?: string
+  chunk_count: number
+  embedding_model: string
+  enabled: boolean
+  created_at: string
+  updated_at: string
+}
+
+export interface KnowledgeSearchResult {
+  id: string
+  document_id: string
+  document_name: string
+  chunk_index: number
+  content: string
+  score: number
+}
+
 export const chatbotService = {
   // Settings
   getSettings: () => api.get('/chatbot/settings'),
@@ -292,6 +336,8 @@ export const chatbotService = {
   createKeyword: (data: any) => api.post('/chatbot/keywords', data),
   updateKeyword: (id: string, data: any) => api.put(`/chatbot/keywords/${id}`, data),
   deleteKeyword: (id: string) => api.delete(`/chatbot/keywords/${id}`),
+  testKeyword: (message: string, whatsappAccount?: string) =>
+    api.post<KeywordMatchTestResult>('/chatbot/keywords/test', { message, whatsapp_account: whatsappAccount }),
 
   // Flows
   listFlows: (params?: { search?: string; page?: number; limit?: number }) =>
@@ -300,6 +346,13 @@ export const chatbotService = {
   createFlow: (data: any) => api.post('/chatbot/flows', data),
   updateFlow: (id: string, data: any) => api.put(`/chatbot/flows/${id}`, data),
   deleteFlow: (id: string) => api.delete(`/chatbot/flows/${id}`),
+  publishFlow: (id: string, notes?: string) => api.post(`/chatbot/flows/${id}/publish`, { notes }),
+  listFlowVersions: (id: string, params?: { page?: number; limit?: number }) =>
+    api.get<{ versions: any[]; total?: number }>(`/chatbot/flows/${id}/versions`, { params }),
+  getFlowVersion: (id: string, version: number) => api.get(`/chatbot/flows/${id}/versions/${version}`),
+  diffFlow: (id: string, params?: { from?: number | 'draft'; to?: number | 'draft' }) =>
+    api.get(`/chatbot/flows/${id}/diff`, { params }),
+  rollbackFlow: (id: string, version: number) => api.post(`/chatbot/flows/${id}/versions/${version}/rollback`),
 
   // AI Contexts
   listAIContexts: (params?: { search?: string; page?: number; limit?: number }) =>
@@ -309,6 +362,29 @@ export const chatbotService = {
   updateAIContext: (id: string, data: any) => api.put(`/chatbot/ai-contexts/${id}`, data),
   deleteAIContext: (id: string) => api.delete(`/chatbot/ai-contexts/${id}`),
 
+  // Knowledge Base
+  listKnowledgeDocuments: (params?: { search?: string; page?: number; limit?: number }) =>
+    api.get<{ documents: KnowledgeDocument[]; total?: number }>('/chatbot/knowledge-base', { params }),
+  uploadKnowledgeDocument: (file: File, name?: string, whatsappAccount?: string) => {
+    const formData = new FormData()
+    formData.append('file', file)
+    if (name) {
+      formData.append('name', name)
+    }
+    if (whatsappAccount) {
+      formData.append('whatsapp_account', whatsappAccount)
+    }
+    return api.post<KnowledgeDocument>('/chatbot/knowledge-base', formData, {
+      headers: { 'Content-Type': 'multipart/form-data' }
+    })
+  },
+  updateKnowledgeDocument: (id: string, data: { name?: string; whatsapp_account?: string; enabled?: boolean }) =>
+    api.put<KnowledgeDocument>(`/chatbot/knowledge-base/${id}`, data),
+  deleteKnowledgeDocument: (id: string) => api.delete(`/chatbot/knowledge-base/${id}`),
+  reindexKnowledgeDocument: (id: string) => api.post<KnowledgeDocument>(`/chatbot/knowledge-base/${id}/reindex`),
+  searchKnowledgeBase: (query: string, whatsappAccount?: string) =>
+    api.post<{ results: KnowledgeSearchResult[] }>('/chatbot/knowledge-base/search', { query, whatsapp_account: whatsappAccount }),
+
   // Sessions
   listSessions: (params?: { status?: string; contact_id?: string }) =>
     api.get('/chatbot/sessions', { params }),
@@ -666,6 +742,7 @@ export interface Team {
   name: string
   description: string
   assignment_strategy: 'round_robin' | 'load_balanced' | 'manual'
+  max_concurrent_transfers: number
   is_active: boolean
   member_count: number
   created_at: string
@@ -753,6 +830,9 @@ export interface CustomAction {
   }
   is_active: boolean
   display_order: number
+  ai_tool: boolean
+  ai_tool_description: string
+  ai_tool_parameters: Record<string, any> | null
   created_at: string
   updated_at: string
 }
@@ -780,6 +860,9 @@ export const customActionsService = {
     config: Record<string, any>
     is_active?: boolean
     display_order?: number
+    ai_tool?: boolean
+    ai_tool_description?: string
+    ai_tool_parameters?: Record<string, any>
   }) => api.post<CustomAction>('/custom-actions', data),
   update: (id: string, data: {
     name?: string
@@ -788,6 +871,9 @@ export const customActionsService = {
     config?: Record<string, any>
     is_active?: boolean
     display_order?: number
+    ai_tool?: boolean
+    ai_tool_description?: string
+    ai_tool_parameters?: Record<string, any>
   }) => api.put<CustomAction>(`/custom-actions/${id}`, data),
   delete: (id: string) => api.delete(`/custom-actions/${id}`),
   execute: (id: string, contactId: string) =>
@@ -870,4 +956,40 @@ export const notesService = {
     api.delete(`/contacts/${contactId}/notes/${noteId}`)
 }
 
+// Mentions in notes
+export interface MentionSuggestion {
+  id: string
+  name: string
+}
+
+export const mentionsService = {
+  search: (search: string) =>
+    api.get<{ users: MentionSuggestion[]; teams: MentionSuggestion[] }>('/mentions', { params: { search } })
+}
+
+// Notifications inbox
+export interface AppNotification {
+  id: string
+  type: 'mention'
+  actor_id?: string
+  actor_name: string
+  contact_id?: string
+  contact_name: string
+  note_id?: string
+  team_id?: string
+  team_name?: string
+  body: string
+  is_read: boolean
+  read_at?: string
+  created_at: string
+}
+
+export const notificationsService = {
+  list: (params?: { unread?: boolean; page?: number; limit?: number }) =>
+    api.get<{ notifications: AppNotification[]; total: number; unread_count: number }>('/notifications', { params }),
+  unreadCount: () => api.get<{ unread_count: number }>('/notifications/unread-count'),
+  markRead: (id: string) => api.put<{ unread_count: number }>(`/notifications/${id}/read`),
+  markAllRead: () => api.put<{ unread_count: number }>('/notifications/read-all')
+}
+
 export default api
@@ -2,6 +2,7 @@ import { useContactsStore } from '@/stores/contacts'
 import { useTransfersStore } from '@/stores/transfers'
 import { useAuthStore } from '@/stores/auth'
 import { useNotesStore } from '@/stores/notes'
+import { useNotificationsStore } from '@/stores/notifications'
 import { toast } from 'vue-sonner'
 import router from '@/router'
 
@@ -67,6 +68,13 @@ const WS_TYPE_CONVERSATION_NOTE_CREATED = 'conversation_note_created'
 const WS_TYPE_CONVERSATION_NOTE_UPDATED = 'conversation_note_updated'
 const WS_TYPE_CONVERSATION_NOTE_DELETED = 'conversation_note_deleted'
 
+// Conversation lifecycle types
+const WS_TYPE_CONVERSATION_STATUS_CHANGED = 'conversation_status_changed'
+
+// Notification types (sent to the recipient only)
+const WS_TYPE_NOTIFICATION_CREATED = 'notification_created'
+const WS_TYPE_NOTIFICATIONS_UPDATED = 'notifications_updated'
+
 interface WSMessage {
   type: string
   payload: any
@@ -196,6 +204,15 @@ class WebSocketService {
         case WS_TYPE_CONVERSATION_NOTE_DELETED:
           useNotesStore().onNoteDeleted(message.payload.id)
           break
+        case WS_TYPE_CONVERSATION_STATUS_CHANGED:
+          this.handleConversationStatusChanged(message.payload)
+          break
+        case WS_TYPE_NOTIFICATION_CREATED:
+          this.handleNotificationCreated(message.payload)
+          break
+        case WS_TYPE_NOTIFICATIONS_UPDATED:
+          useNotificationsStore().onNotificationsUpdated(message.payload.unread_count)
+          break
         default:
           // Unknown message type, ignore
           break
@@ -296,6 +313,7 @@ class WebSocketService {
       agent_id: payload.agent_id,
       team_id: payload.team_id,
       notes: payload.notes,
+      required_skills: payload.required_skills,
       transferred_at: payload.transferred_at,
       // Default SLA values - will be updated on next fetch
       sla_breached: false,
@@ -338,6 +356,34 @@ class WebSocketService {
     }
   }
 
+  private handleNotificationCreated(payload: any) {
+    const notification = payload.notification
+    useNotificationsStore().onNotificationCreated(notification, payload.unread_count)
+
+    if (notification.type === 'mention' && notification.contact_id) {
+      const title = notification.team_name
+        ? `${notification.actor_name} mentioned ${notification.team_name}`
+        : `${notification.actor_name} mentioned you`
+      showNotification(title, notification.body, notification.contact_id)
+      playNotificationSound()
+    }
+  }
+
+  private handleConversationStatusChanged(payload: any) {
+    const conversation = payload.conversation
+    useContactsStore().updateContactConversation(conversation)
+
+    const transfersStore = useTransfersStore()
+    for (const transfer of transfersStore.transfers) {
+      if (transfer.contact_id === conversation.contact_id && transfer.whatsapp_account === conversation.whatsapp_account) {
+        transfersStore.updateTransfer(transfer.id, {
+          conversation_status: conversation.status,
+          snoozed_until: conversation.snoozed_until
+        })
+      }
+    }
+  }
+
   private handleAgentTransferAssign(payload: any) {
     const transfersStore = useTransfersStore()
     const authStore = useAuthStore()
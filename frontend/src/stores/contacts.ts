@@ -11,13 +11,33 @@ export interface Contact {
   status: string
   tags: string[]
   metadata: Record<string, any>
+  language?: string
+  language_source?: string
   last_message_at?: string
   unread_count: number
   assigned_user_id?: string
+  conversation_status?: ConversationStatus
+  snoozed_until?: string
   created_at: string
   updated_at: string
 }
 
+export type ConversationStatus = 'open' | 'pending' | 'snoozed' | 'resolved'
+
+export interface Conversation {
+  id: string
+  contact_id: string
+  whatsapp_account: string
+  status: ConversationStatus
+  snoozed_until?: string
+  resolution_reason?: string
+  resolved_at?: string
+  resolved_by_id?: string
+  resolved_by_name?: string
+  status_changed_at: string
+  status_changed_by_id?: string
+}
+
 export interface ReplyPreview {
   id: string
   content: any
@@ -75,6 +95,7 @@ export const useContactsStore = defineStore('contacts', () => {
   const hasMoreMessages = ref(false)
   const searchQuery = ref('')
   const selectedTags = ref<string[]>([])
+  const selectedConversationStatus = ref<ConversationStatus | ''>('')
   const replyingTo = ref<Message | null>(null)
 
   // Contacts pagination
@@ -102,7 +123,7 @@ export const useContactsStore = defineStore('contacts', () => {
     })
   })
 
-  async function fetchContacts(params?: { search?: string; page?: number; limit?: number; tags?: string }) {
+  async function fetchContacts(params?: { search?: string; page?: number; limit?: number; tags?: string; conversation_status?: string }) {
     isLoading.value = true
     try {
       const tagsParam = selectedTags.value.length > 0 ? selectedTags.value.join(',') : undefined
@@ -110,6 +131,7 @@ export const useContactsStore = defineStore('contacts', () => {
         page: 1,
         limit: contactsLimit.value,
         tags: tagsParam,
+        conversation_status: selectedConversationStatus.value || undefined,
         ...params
       })
       // API returns { status: "success", data: { contacts: [...], total: number } }
@@ -138,7 +160,8 @@ export const useContactsStore = defineStore('contacts', () => {
       const response = await contactsService.list({
         page: nextPage,
         limit: contactsLimit.value,
-        tags: tagsParam
+        tags: tagsParam,
+        conversation_status: selectedConversationStatus.value || undefined
       })
       const data = response.data.data || response.data
       const newContacts = data.contacts || []
@@ -306,6 +329,20 @@ export const useContactsStore = defineStore('contacts', () => {
     }
   }
 
+  function updateContactConversation(conversation: Conversation) {
+    const fields = {
+      conversation_status: conversation.status,
+      snoozed_until: conversation.snoozed_until
+    }
+    const contact = contacts.value.find(c => c.id === conversation.contact_id)
+    if (contact) {
+      Object.assign(contact, fields)
+    }
+    if (currentContact.value?.id === conversation.contact_id) {
+      currentContact.value = { ...currentContact.value, ...fields }
+    }
+  }
+
   return {
     contacts,
     currentContact,
@@ -316,6 +353,7 @@ export const useContactsStore = defineStore('contacts', () => {
     hasMoreMessages,
     searchQuery,
     selectedTags,
+    selectedConversationStatus,
     replyingTo,
     filteredContacts,
     sortedContacts,
@@ -340,6 +378,7 @@ export const useContactsStore = defineStore('contacts', () => {
     setReplyingTo,
     clearReplyingTo,
     updateMessageReactions,
-    updateContactTags
+    updateContactTags,
+    updateContactConversation
   }
 })
@@ -0,0 +1,78 @@
+import { defineStore } from 'pinia'
+import { ref } from 'vue'
+import { notificationsService, type AppNotification } from '@/services/api'
+
+export const useNotificationsStore = defineStore('notifications', () => {
+  const notifications = ref<AppNotification[]>([])
+  const unreadCount = ref(0)
+  const isLoading = ref(false)
+
+  async function fetchNotifications() {
+    isLoading.value = true
+    try {
+      const response = await notificationsService.list({ limit: 30 })
+      const data = (response.data as any).data || response.data
+      notifications.value = data.notifications || []
+      unreadCount.value = data.unread_count ?? 0
+    } catch {
+      notifications.value = []
+    } finally {
+      isLoading.value = false
+    }
+  }
+
+  async function fetchUnreadCount() {
+    try {
+      const response = await notificationsService.unreadCount()
+      const data = (response.data as any).data || response.data
+      unreadCount.value = data.unread_count ?? 0
+    } catch {
+      // ignore
+    }
+  }
+
+  async function markRead(id: string) {
+    const notification = notifications.value.find(n => n.id === id)
+    if (notification?.is_read) return
+    const response = await notificationsService.markRead(id)
+    const data = (response.data as any).data || response.data
+    if (notification) {
+      notification.is_read = true
+    }
+    unreadCount.value = data.unread_count ?? unreadCount.value
+  }
+
+  async function markAllRead() {
+    const response = await notificationsService.markAllRead()
+    const data = (response.data as any).data || response.data
+    notifications.value.forEach(n => { n.is_read = true })
+    unreadCount.value = data.unread_count ?? 0
+  }
+
+  // WebSocket event handlers
+  function onNotificationCreated(notification: AppNotification, count: number) {
+    if (!notifications.value.some(n => n.id === notification.id)) {
+      notifications.value.unshift(notification)
+    }
+    unreadCount.value = count
+  }
+
+  function onNotificationsUpdated(count: number) {
+    unreadCount.value = count
+    if (count === 0) {
+      notifications.value.forEach(n => { n.is_read = true })
+    }
+  }
+
+  return {
+    notifications,
+    unreadCount,
+    isLoading,
+    fetchNotifications,
+    fetchUnreadCount,
+    markRead,
+    markAllRead,
+    onNotificationCreated,
+    onNotificationsUpdated
+  }
+})
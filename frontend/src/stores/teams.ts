@@ -6,12 +6,14 @@ export interface CreateTeamData {
   name: string
   description?: string
   assignment_strategy?: 'round_robin' | 'load_balanced' | 'manual'
+  max_concurrent_transfers?: number
 }
 
 export interface UpdateTeamData {
   name?: string
   description?: string
   assignment_strategy?: 'round_robin' | 'load_balanced' | 'manual'
+  max_concurrent_transfers?: number
   is_active?: boolean
 }
 
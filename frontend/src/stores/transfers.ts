@@ -17,6 +17,9 @@ export interface AgentTransfer {
   transferred_by?: string
   transferred_by_name?: string
   notes?: string
+  required_skills?: string[]
+  conversation_status?: 'open' | 'pending' | 'snoozed' | 'resolved'
+  snoozed_until?: string
   transferred_at: string
   resumed_at?: string
   resumed_by?: string
@@ -33,12 +36,17 @@ export interface AgentTransfer {
 }
 
 // Helper to determine SLA status
-export type SLAStatus = 'ok' | 'warning' | 'breached' | 'expired'
+export type SLAStatus = 'ok' | 'warning' | 'breached' | 'expired' | 'paused'
 
 export function getSLAStatus(transfer: AgentTransfer): SLAStatus {
   if (transfer.status === 'expired') return 'expired'
   if (transfer.sla_breached) return 'breached'
 
+  // SLA clocks are stopped while waiting on the customer
+  if (transfer.conversation_status === 'pending' || transfer.conversation_status === 'snoozed') {
+    return 'paused'
+  }
+
   // Check deadline status (even if backend hasn't marked as breached yet)
   if (transfer.sla_response_deadline && !transfer.picked_up_at) {
     const deadline = new Date(transfer.sla_response_deadline)
@@ -18,6 +18,7 @@ export interface User {
   is_active: boolean
   is_super_admin?: boolean
   is_member?: boolean
+  max_concurrent_transfers?: number
   organization_id: string
   created_at: string
   updated_at: string
@@ -29,6 +30,7 @@ export interface CreateUserData {
   full_name: string
   role_id?: string
   is_super_admin?: boolean
+  max_concurrent_transfers?: number
 }
 
 export interface UpdateUserData {
@@ -38,6 +40,7 @@ export interface UpdateUserData {
   role_id?: string
   is_active?: boolean
   is_super_admin?: boolean
+  max_concurrent_transfers?: number
 }
 
 export interface FetchUsersParams {
@@ -26,12 +26,15 @@ export interface FlowStep {
   step_name: string
   step_order: number
   message: string
-  message_type: 'text' | 'buttons' | 'api_fetch' | 'whatsapp_flow' | 'transfer'
+  message_type: 'text' | 'buttons' | 'api_fetch' | 'whatsapp_flow' | 'transfer' | 'wait' | 'action' | 'ai'
   input_type: 'none' | 'text' | 'number' | 'email' | 'phone' | 'date' | 'select'
   input_config: Record<string, any>
   api_config: ApiConfig
   buttons: ButtonConfig[]
   transfer_config: TransferConfig
+  wait_config?: { duration_minutes?: number; until?: string }
+  actions?: Array<{ type: string; [key: string]: any }>
+  ai_config?: { prompt?: string; mode?: 'reply' | 'extract'; schema?: Record<string, any>; include_history?: boolean }
   validation_regex: string
   validation_error: string
   store_as: string
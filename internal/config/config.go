@@ -68,13 +68,18 @@ type JWTConfig struct {
 type WhatsAppConfig struct {
 	WebhookVerifyToken string `koanf:"webhook_verify_token"`
 	APIVersion         string `koanf:"api_version"`
-	BaseURL            string `koanf:"base_url"` // Meta Graph API base URL
+	BaseURL            string `koanf:"base_url"`            // Meta Graph API base URL
+	MessagesPerSecond  int    `koanf:"messages_per_second"` // Default campaign send rate per account
+	DailyMessageLimit  int    `koanf:"daily_message_limit"` // Default campaign messages per account per day (0 = unlimited)
 }
 
 type AIConfig struct {
 	OpenAIKey    string `koanf:"openai_key"`
 	AnthropicKey string `koanf:"anthropic_key"`
 	GoogleKey    string `koanf:"google_key"`
+	// PrivateHosts are hosts ("host" or "host:port") that AI provider base URLs may use
+	// even though they resolve to private addresses, e.g. a self-hosted vLLM or Ollama server
+	PrivateHosts []string `koanf:"private_hosts"`
 }
 
 type StorageConfig struct {
@@ -84,6 +89,9 @@ type StorageConfig struct {
 	S3Region  string `koanf:"s3_region"`
 	S3Key     string `koanf:"s3_key"`
 	S3Secret  string `koanf:"s3_secret"`
+	// S3Endpoint overrides the AWS endpoint for S3-compatible servers (e.g. MinIO)
+	S3Endpoint  string `koanf:"s3_endpoint"`
+	S3PathStyle bool   `koanf:"s3_path_style"`
 }
 
 type DefaultAdminConfig struct {
@@ -186,6 +194,9 @@ func setDefaults(cfg *Config) {
 	if cfg.WhatsApp.BaseURL == "" {
 		cfg.WhatsApp.BaseURL = "https://graph.facebook.com"
 	}
+	if cfg.WhatsApp.MessagesPerSecond == 0 {
+		cfg.WhatsApp.MessagesPerSecond = 80
+	}
 	if cfg.Storage.Type == "" {
 		cfg.Storage.Type = "local"
 	}
@@ -0,0 +1,78 @@
+package contactutil
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NormalizePhone trims whitespace and a leading "+" so that phone numbers
+// compare equal regardless of how they were entered.
+func NormalizePhone(phone string) string {
+	return strings.TrimPrefix(strings.TrimSpace(phone), "+")
+}
+
+// IsSuppressed reports whether a phone number is on the organization's suppression list
+func IsSuppressed(db *gorm.DB, orgID uuid.UUID, phoneNumber string) (bool, error) {
+	var count int64
+	err := db.Model(&models.ContactSuppression{}).
+		Where("organization_id = ? AND phone_number = ?", orgID, NormalizePhone(phoneNumber)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SuppressedPhones returns the normalized phone numbers among phoneNumbers that
+// are on the organization's suppression list.
+func SuppressedPhones(db *gorm.DB, orgID uuid.UUID, phoneNumbers []string) (map[string]bool, error) {
+	suppressed := make(map[string]bool)
+	if len(phoneNumbers) == 0 {
+		return suppressed, nil
+	}
+
+	normalized := make([]string, len(phoneNumbers))
+	for i, p := range phoneNumbers {
+		normalized[i] = NormalizePhone(p)
+	}
+
+	// Stay well below the Postgres bind parameter limit
+	const batchSize = 5000
+	for start := 0; start < len(normalized); start += batchSize {
+		end := min(start+batchSize, len(normalized))
+		var phones []string
+		if err := db.Model(&models.ContactSuppression{}).
+			Where("organization_id = ? AND phone_number IN ?", orgID, normalized[start:end]).
+			Pluck("phone_number", &phones).Error; err != nil {
+			return nil, err
+		}
+		for _, p := range phones {
+			suppressed[p] = true
+		}
+	}
+	return suppressed, nil
+}
+
+// Suppress adds a phone number to the organization's suppression list. Numbers
+// that are already suppressed keep their original entry. Returns whether a new
+// entry was created.
+func Suppress(db *gorm.DB, orgID uuid.UUID, phoneNumber string, source models.SuppressionSource, reason string, createdByID *uuid.UUID) (bool, error) {
+	entry := models.ContactSuppression{
+		OrganizationID: orgID,
+		PhoneNumber:    NormalizePhone(phoneNumber),
+		Source:         source,
+		Reason:         reason,
+		CreatedByID:    createdByID,
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Unsuppress removes a phone number from the organization's suppression list.
+// Returns whether an entry was removed.
+func Unsuppress(db *gorm.DB, orgID uuid.UUID, phoneNumber string) (bool, error) {
+	result := db.Where("organization_id = ? AND phone_number = ?", orgID, NormalizePhone(phoneNumber)).
+		Delete(&models.ContactSuppression{})
+	return result.RowsAffected > 0, result.Error
+}
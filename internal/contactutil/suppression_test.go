@@ -0,0 +1,59 @@
+package contactutil
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	assert.Equal(t, "1234567890", NormalizePhone(" +1234567890 "))
+	assert.Equal(t, "1234567890", NormalizePhone("1234567890"))
+	assert.Equal(t, "", NormalizePhone("  "))
+}
+
+func TestSuppress_AndUnsuppress(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	uid := uuid.New().String()[:8]
+	org := models.Organization{BaseModel: models.BaseModel{ID: uuid.New()}, Name: "test-" + uid, Slug: "test-" + uid}
+	require.NoError(t, db.Create(&org).Error)
+
+	created, err := Suppress(db, org.ID, "+1234567890", models.SuppressionSourceKeyword, `Replied "STOP"`, nil)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	// Already suppressed numbers keep their original entry
+	created, err = Suppress(db, org.ID, "1234567890", models.SuppressionSourceManual, "again", nil)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	var entry models.ContactSuppression
+	require.NoError(t, db.Where("organization_id = ?", org.ID).First(&entry).Error)
+	assert.Equal(t, "1234567890", entry.PhoneNumber)
+	assert.Equal(t, models.SuppressionSourceKeyword, entry.Source)
+
+	suppressed, err := IsSuppressed(db, org.ID, "+1234567890")
+	require.NoError(t, err)
+	assert.True(t, suppressed)
+
+	phones, err := SuppressedPhones(db, org.ID, []string{"+1234567890", "5555555555"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"1234567890": true}, phones)
+
+	// Suppression is per organization
+	suppressed, err = IsSuppressed(db, uuid.New(), "1234567890")
+	require.NoError(t, err)
+	assert.False(t, suppressed)
+
+	removed, err := Unsuppress(db, org.ID, "+1234567890")
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	suppressed, err = IsSuppressed(db, org.ID, "1234567890")
+	require.NoError(t, err)
+	assert.False(t, suppressed)
+}
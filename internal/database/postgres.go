@@ -63,12 +63,16 @@ func GetMigrationModels() []MigrationModel {
 		{"UserOrganization", &models.UserOrganization{}},
 		{"Team", &models.Team{}},
 		{"TeamMember", &models.TeamMember{}},
+		{"AgentSkill", &models.AgentSkill{}},
 		{"APIKey", &models.APIKey{}},
 		{"SSOProvider", &models.SSOProvider{}},
 		{"Webhook", &models.Webhook{}},
+		{"WebhookDelivery", &models.WebhookDelivery{}},
+		{"WebhookDeliveryAttempt", &models.WebhookDeliveryAttempt{}},
 		{"CustomAction", &models.CustomAction{}},
 		{"WhatsAppAccount", &models.WhatsAppAccount{}},
 		{"Contact", &models.Contact{}},
+		{"ContactSuppression", &models.ContactSuppression{}},
 		{"Tag", &models.Tag{}},
 		{"Message", &models.Message{}},
 		{"Template", &models.Template{}},
@@ -84,9 +88,12 @@ func GetMigrationModels() []MigrationModel {
 		{"KeywordRule", &models.KeywordRule{}},
 		{"ChatbotFlow", &models.ChatbotFlow{}},
 		{"ChatbotFlowStep", &models.ChatbotFlowStep{}},
+		{"ChatbotFlowVersion", &models.ChatbotFlowVersion{}},
 		{"ChatbotSession", &models.ChatbotSession{}},
 		{"ChatbotSessionMessage", &models.ChatbotSessionMessage{}},
 		{"AIContext", &models.AIContext{}},
+		{"KnowledgeDocument", &models.KnowledgeDocument{}},
+		{"KnowledgeChunk", &models.KnowledgeChunk{}},
 		{"AgentTransfer", &models.AgentTransfer{}},
 
 		// User tracking
@@ -104,6 +111,15 @@ func GetMigrationModels() []MigrationModel {
 
 		// Conversation Notes
 		{"ConversationNote", &models.ConversationNote{}},
+
+		// Conversations
+		{"Conversation", &models.Conversation{}},
+
+		// Satisfaction surveys
+		{"CSATSurvey", &models.CSATSurvey{}},
+
+		// User notifications
+		{"Notification", &models.Notification{}},
 	}
 }
 
@@ -243,6 +259,8 @@ func getIndexes() []string {
 		`CREATE UNIQUE INDEX IF NOT EXISTS idx_canned_responses_org_name ON canned_responses(organization_id, name)`,
 		`CREATE INDEX IF NOT EXISTS idx_canned_responses_active ON canned_responses(organization_id, is_active, usage_count DESC)`,
 		`CREATE INDEX IF NOT EXISTS idx_webhooks_org_active ON webhooks(organization_id, is_active)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending'`,
 		`CREATE INDEX IF NOT EXISTS idx_availability_logs_user_time ON user_availability_logs(user_id, started_at DESC)`,
 		`CREATE INDEX IF NOT EXISTS idx_availability_logs_org_time ON user_availability_logs(organization_id, started_at DESC)`,
 		`CREATE UNIQUE INDEX IF NOT EXISTS idx_sso_providers_org_provider ON sso_providers(organization_id, provider)`,
@@ -261,6 +279,13 @@ func getIndexes() []string {
 		`CREATE UNIQUE INDEX IF NOT EXISTS idx_user_org_unique ON user_organizations(user_id, organization_id) WHERE deleted_at IS NULL`,
 		// Conversation notes
 		`CREATE INDEX IF NOT EXISTS idx_conversation_notes_contact ON conversation_notes(organization_id, contact_id, created_at DESC)`,
+		// Conversations
+		`CREATE INDEX IF NOT EXISTS idx_conversations_snoozed ON conversations(snoozed_until) WHERE status = 'snoozed'`,
+		// Notifications inbox
+		`CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(organization_id, user_id) WHERE read_at IS NULL AND deleted_at IS NULL`,
+		// Knowledge base full-text search
+		`CREATE INDEX IF NOT EXISTS idx_knowledge_chunks_fts ON knowledge_chunks USING GIN (to_tsvector('english', content))`,
+		`CREATE INDEX IF NOT EXISTS idx_knowledge_documents_account ON knowledge_documents(organization_id, whats_app_account, is_enabled)`,
 	}
 }
 
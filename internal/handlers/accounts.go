@@ -16,6 +16,9 @@ import (
 	"github.com/zerodha/fastglue"
 )
 
+// maxMessagesPerSecond is the highest send rate Meta allows for a phone number
+const maxMessagesPerSecond = 1000
+
 // AccountRequest represents the request body for creating/updating an account
 type AccountRequest struct {
 	Name               string `json:"name" validate:"required"`
@@ -29,6 +32,8 @@ type AccountRequest struct {
 	IsDefaultIncoming  bool   `json:"is_default_incoming"`
 	IsDefaultOutgoing  bool   `json:"is_default_outgoing"`
 	AutoReadReceipt    bool   `json:"auto_read_receipt"`
+	MessagesPerSecond  *int   `json:"messages_per_second"` // 0 = server default
+	DailyMessageLimit  *int   `json:"daily_message_limit"` // 0 = server default
 }
 
 // AccountResponse represents the response for an account (without sensitive data)
@@ -43,6 +48,8 @@ type AccountResponse struct {
 	IsDefaultIncoming  bool      `json:"is_default_incoming"`
 	IsDefaultOutgoing  bool      `json:"is_default_outgoing"`
 	AutoReadReceipt    bool      `json:"auto_read_receipt"`
+	MessagesPerSecond  int       `json:"messages_per_second"`
+	DailyMessageLimit  int       `json:"daily_message_limit"`
 	Status             string    `json:"status"`
 	HasAccessToken     bool      `json:"has_access_token"`
 	HasAppSecret       bool      `json:"has_app_secret"`
@@ -92,6 +99,9 @@ func (a *App) CreateAccount(r *fastglue.Request) error {
 	if req.Name == "" || req.PhoneID == "" || req.BusinessID == "" || req.AccessToken == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Name, phone_id, business_id, and access_token are required", nil, "")
 	}
+	if msg := validateSendLimits(req); msg != "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, msg, nil, "")
+	}
 
 	// Generate webhook verify token if not provided
 	webhookVerifyToken := req.WebhookVerifyToken
@@ -132,6 +142,12 @@ func (a *App) CreateAccount(r *fastglue.Request) error {
 		AutoReadReceipt:    req.AutoReadReceipt,
 		Status:             "active",
 	}
+	if req.MessagesPerSecond != nil {
+		account.MessagesPerSecond = *req.MessagesPerSecond
+	}
+	if req.DailyMessageLimit != nil {
+		account.DailyMessageLimit = *req.DailyMessageLimit
+	}
 
 	// If this is set as default, unset other defaults
 	if req.IsDefaultIncoming {
@@ -195,6 +211,9 @@ func (a *App) UpdateAccount(r *fastglue.Request) error {
 	if err := a.decodeRequest(r, &req); err != nil {
 		return nil
 	}
+	if msg := validateSendLimits(req); msg != "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, msg, nil, "")
+	}
 
 	// Update fields if provided
 	if req.Name != "" {
@@ -232,6 +251,12 @@ func (a *App) UpdateAccount(r *fastglue.Request) error {
 		account.APIVersion = req.APIVersion
 	}
 	account.AutoReadReceipt = req.AutoReadReceipt
+	if req.MessagesPerSecond != nil {
+		account.MessagesPerSecond = *req.MessagesPerSecond
+	}
+	if req.DailyMessageLimit != nil {
+		account.DailyMessageLimit = *req.DailyMessageLimit
+	}
 
 	// Handle default flags
 	if req.IsDefaultIncoming && !account.IsDefaultIncoming {
@@ -387,6 +412,8 @@ func accountToResponse(acc models.WhatsAppAccount) AccountResponse {
 		IsDefaultIncoming:  acc.IsDefaultIncoming,
 		IsDefaultOutgoing:  acc.IsDefaultOutgoing,
 		AutoReadReceipt:    acc.AutoReadReceipt,
+		MessagesPerSecond:  acc.MessagesPerSecond,
+		DailyMessageLimit:  acc.DailyMessageLimit,
 		Status:             acc.Status,
 		HasAccessToken:     acc.AccessToken != "",
 		HasAppSecret:       acc.AppSecret != "",
@@ -395,6 +422,18 @@ func accountToResponse(acc models.WhatsAppAccount) AccountResponse {
 	}
 }
 
+// validateSendLimits checks the optional campaign send limits of an account request
+// and returns an error message, or "" if they are valid
+func validateSendLimits(req AccountRequest) string {
+	if req.MessagesPerSecond != nil && (*req.MessagesPerSecond < 0 || *req.MessagesPerSecond > maxMessagesPerSecond) {
+		return fmt.Sprintf("messages_per_second must be between 0 and %d", maxMessagesPerSecond)
+	}
+	if req.DailyMessageLimit != nil && *req.DailyMessageLimit < 0 {
+		return "daily_message_limit must not be negative"
+	}
+	return ""
+}
+
 func generateVerifyToken() string {
 	bytes := make([]byte, 32)
 	_, _ = rand.Read(bytes)
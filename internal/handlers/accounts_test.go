@@ -458,6 +458,57 @@ func TestApp_UpdateAccount_PartialUpdate(t *testing.T) {
 	assert.Equal(t, account.APIVersion, resp.Data.APIVersion)
 }
 
+func TestApp_UpdateAccount_SendLimits(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+
+	req := testutil.NewJSONRequest(t, map[string]interface{}{
+		"messages_per_second": 20,
+		"daily_message_limit": 1000,
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", account.ID.String())
+
+	require.NoError(t, app.UpdateAccount(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.AccountResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, 20, resp.Data.MessagesPerSecond)
+	assert.Equal(t, 1000, resp.Data.DailyMessageLimit)
+
+	t.Run("out of range", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, map[string]interface{}{
+			"messages_per_second": 5000,
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", account.ID.String())
+
+		require.NoError(t, app.UpdateAccount(req))
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("omitted limits are kept", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, map[string]interface{}{
+			"name": "Renamed",
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", account.ID.String())
+
+		require.NoError(t, app.UpdateAccount(req))
+		var updated models.WhatsAppAccount
+		require.NoError(t, app.DB.Where("id = ?", account.ID).First(&updated).Error)
+		assert.Equal(t, 20, updated.MessagesPerSecond)
+		assert.Equal(t, 1000, updated.DailyMessageLimit)
+	})
+}
+
 func TestApp_UpdateAccount_NotFound(t *testing.T) {
 	t.Parallel()
 
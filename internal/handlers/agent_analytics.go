@@ -19,6 +19,9 @@ type AgentAnalyticsSummary struct {
 	TransfersBySource     map[string]int64 `json:"transfers_by_source"`
 	TotalBreakTimeMins    float64          `json:"total_break_time_mins"`
 	BreakCount            int64            `json:"break_count"`
+	CSATResponses         int64            `json:"csat_responses"`
+	AvgCSATRating         float64          `json:"avg_csat_rating"`
+	CSATScore             float64          `json:"csat_score"` // percentage of ratings of 4 or 5
 }
 
 // AgentPerformanceStats represents performance metrics for an agent
@@ -34,6 +37,9 @@ type AgentPerformanceStats struct {
 	BreakCount           int64    `json:"break_count"`
 	IsAvailable          bool     `json:"is_available"`
 	CurrentBreakStart    *string  `json:"current_break_start,omitempty"`
+	CSATResponses        int64    `json:"csat_responses"`
+	AvgCSATRating        float64  `json:"avg_csat_rating"`
+	CSATScore            float64  `json:"csat_score"` // percentage of ratings of 4 or 5
 }
 
 // TrendPoint represents a data point for time-series charts
@@ -268,6 +274,10 @@ func (a *App) calculateSummaryStats(orgID uuid.UUID, start, end time.Time, summa
 	for _, sc := range sourceCounts {
 		summary.TransfersBySource[sc.Source] = sc.Count
 	}
+
+	// Customer satisfaction
+	csat := a.calculateCSATStats(orgID, nil, start, end)
+	summary.CSATResponses, summary.AvgCSATRating, summary.CSATScore = csat.Responses, csat.AvgRating, csat.Score()
 }
 
 func (a *App) calculateAgentSummaryStats(orgID, agentID uuid.UUID, start, end time.Time, summary *AgentAnalyticsSummary) {
@@ -312,6 +322,10 @@ func (a *App) calculateAgentSummaryStats(orgID, agentID uuid.UUID, start, end ti
 
 	// Calculate break time
 	summary.TotalBreakTimeMins, summary.BreakCount = a.calculateBreakTime(agentID, start, end)
+
+	// Customer satisfaction with this agent
+	csat := a.calculateCSATStats(orgID, &agentID, start, end)
+	summary.CSATResponses, summary.AvgCSATRating, summary.CSATScore = csat.Responses, csat.AvgRating, csat.Score()
 }
 
 func (a *App) calculateAgentStats(orgID, agentID uuid.UUID, start, end time.Time) AgentPerformanceStats {
@@ -359,6 +373,10 @@ func (a *App) calculateAgentStats(orgID, agentID uuid.UUID, start, end time.Time
 	// Calculate break time from availability logs
 	stats.TotalBreakTimeMins, stats.BreakCount = a.calculateBreakTime(agentID, start, end)
 
+	// Customer satisfaction from surveys of the agent's transfers
+	csat := a.calculateCSATStats(orgID, &agentID, start, end)
+	stats.CSATResponses, stats.AvgCSATRating, stats.CSATScore = csat.Responses, csat.AvgRating, csat.Score()
+
 	// Check if currently on break and get break start time
 	if !stats.IsAvailable {
 		var currentBreak models.UserAvailabilityLog
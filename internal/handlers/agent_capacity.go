@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// agentCapacity is an agent's active transfers and the most they can handle
+type agentCapacity struct {
+	Load int64
+	Max  int // 0 for no limit
+}
+
+// Full reports whether the agent can't take another transfer
+func (c agentCapacity) Full() bool {
+	return c.Max > 0 && c.Load >= int64(c.Max)
+}
+
+// Free returns how many more transfers the agent can take, or -1 for no limit
+func (c agentCapacity) Free() int {
+	if c.Max == 0 {
+		return -1
+	}
+	if c.Full() {
+		return 0
+	}
+	return c.Max - int(c.Load)
+}
+
+// agentCapacities returns the capacity of each agent in the organization.
+// An agent's own limit applies, otherwise the lowest limit of their teams.
+func (a *App) agentCapacities(orgID uuid.UUID, userIDs []uuid.UUID) map[uuid.UUID]agentCapacity {
+	return queryAgentCapacities(a.DB, orgID, userIDs)
+}
+
+// queryAgentCapacities is agentCapacities on the given connection, so it can
+// be read within a transaction
+func queryAgentCapacities(db *gorm.DB, orgID uuid.UUID, userIDs []uuid.UUID) map[uuid.UUID]agentCapacity {
+	capacities := make(map[uuid.UUID]agentCapacity, len(userIDs))
+	if len(userIDs) == 0 {
+		return capacities
+	}
+
+	type agentLoad struct {
+		AgentID uuid.UUID `gorm:"column:agent_id"`
+		Count   int64     `gorm:"column:count"`
+	}
+	var loads []agentLoad
+	db.Model(&models.AgentTransfer{}).
+		Select("agent_id, COUNT(*) as count").
+		Where("organization_id = ? AND agent_id IN ? AND status = ?", orgID, userIDs, models.TransferStatusActive).
+		Group("agent_id").
+		Scan(&loads)
+	for _, l := range loads {
+		capacities[l.AgentID] = agentCapacity{Load: l.Count}
+	}
+
+	type agentLimit struct {
+		UserID uuid.UUID `gorm:"column:user_id"`
+		Max    int       `gorm:"column:max"`
+	}
+	var teamLimits []agentLimit
+	db.Model(&models.TeamMember{}).
+		Select("team_members.user_id, MIN(teams.max_concurrent_transfers) AS max").
+		Joins("JOIN teams ON teams.id = team_members.team_id AND teams.deleted_at IS NULL").
+		Where("teams.organization_id = ? AND teams.is_active = ? AND teams.max_concurrent_transfers > 0 AND team_members.user_id IN ?", orgID, true, userIDs).
+		Group("team_members.user_id").
+		Scan(&teamLimits)
+	var userLimits []agentLimit
+	db.Model(&models.UserOrganization{}).
+		Select("user_id, max_concurrent_transfers AS max").
+		Where("organization_id = ? AND user_id IN ? AND max_concurrent_transfers > 0", orgID, userIDs).
+		Scan(&userLimits)
+
+	// Team limits first so the agent's own limit overrides them
+	for _, l := range append(teamLimits, userLimits...) {
+		c := capacities[l.UserID]
+		c.Max = l.Max
+		capacities[l.UserID] = c
+	}
+	return capacities
+}
+
+// agentHasCapacity checks whether an agent can take another transfer. It only
+// helps choose an agent; the assignment itself goes through assignWithinCapacity.
+func (a *App) agentHasCapacity(orgID, agentID uuid.UUID) bool {
+	return !a.agentCapacities(orgID, []uuid.UUID{agentID})[agentID].Full()
+}
+
+// agentAtCapacityError is returned when an agent can't take another transfer
+type agentAtCapacityError struct {
+	max int
+}
+
+func (e *agentAtCapacityError) Error() string {
+	return fmt.Sprintf("agent has reached their limit of %d active transfers", e.max)
+}
+
+// lockAgentCapacity locks the agent's row until tx ends and returns their
+// capacity counted under the lock. Every assignment takes the lock before
+// setting agent_id, so concurrent assignments to one agent run one after the
+// other and can't take them over their limit.
+func lockAgentCapacity(tx *gorm.DB, orgID, agentID uuid.UUID) (agentCapacity, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", agentID).
+		First(&models.User{}).Error; err != nil {
+		return agentCapacity{}, err
+	}
+	return queryAgentCapacities(tx, orgID, []uuid.UUID{agentID})[agentID], nil
+}
+
+// assignWithinCapacity runs assign in a transaction holding the agent's lock,
+// or returns an *agentAtCapacityError if they can't take another transfer
+func (a *App) assignWithinCapacity(orgID, agentID uuid.UUID, assign func(tx *gorm.DB) error) error {
+	return a.DB.Transaction(func(tx *gorm.DB) error {
+		c, err := lockAgentCapacity(tx, orgID, agentID)
+		if err != nil {
+			return err
+		}
+		if c.Full() {
+			return &agentAtCapacityError{max: c.Max}
+		}
+		return assign(tx)
+	})
+}
+
+// createTransfer saves a new transfer. A transfer with an agent is marked as
+// picked up and its contact assigned to the agent, within assignWithinCapacity.
+// If an agent chosen automatically reached their limit in the meantime, the
+// transfer goes to the queue when queueIfFull is set and fails otherwise.
+func (a *App) createTransfer(transfer *models.AgentTransfer, queueIfFull bool) error {
+	if transfer.AgentID != nil {
+		agentID := *transfer.AgentID
+		err := a.assignWithinCapacity(transfer.OrganizationID, agentID, func(tx *gorm.DB) error {
+			a.UpdateSLAOnPickup(transfer)
+			if err := tx.Create(transfer).Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.Contact{}).Where("id = ?", transfer.ContactID).Update("assigned_user_id", agentID).Error
+		})
+		var atCapacity *agentAtCapacityError
+		if !queueIfFull || !errors.As(err, &atCapacity) {
+			return err
+		}
+		a.Log.Info("Agent reached their transfer limit, queueing transfer", "user_id", agentID, "contact_id", transfer.ContactID)
+		transfer.AgentID = nil
+	}
+	return a.DB.Create(transfer).Error
+}
+
+// assignQueuedTransfers assigns queued transfers to an agent with free capacity,
+// oldest first. Only transfers that would have been assigned automatically are
+// taken: those requiring skills the agent has, and those in the agent's teams
+// with an automatic assignment strategy. An agent without a limit takes a
+// single transfer, so one agent becoming available doesn't drain the queue.
+// Returns the number assigned.
+func (a *App) assignQueuedTransfers(orgID, agentID uuid.UUID) int {
+	var agent models.User
+	if err := a.DB.Where("id = ? AND is_active = ? AND is_available = ?", agentID, true, true).First(&agent).Error; err != nil {
+		return 0
+	}
+	if !a.agentHasCapacity(orgID, agentID) {
+		return 0
+	}
+
+	// Teams assigning transfers to the agent, and teams leaving them in the queue
+	var autoTeamIDs, manualTeamIDs []uuid.UUID
+	a.DB.Model(&models.TeamMember{}).
+		Joins("JOIN teams ON teams.id = team_members.team_id AND teams.deleted_at IS NULL").
+		Where("team_members.user_id = ? AND team_members.role = ? AND teams.organization_id = ? AND teams.is_active = ? AND teams.assignment_strategy != ?",
+			agentID, models.TeamRoleAgent, orgID, true, models.AssignmentStrategyManual).
+		Pluck("teams.id", &autoTeamIDs)
+	a.DB.Model(&models.Team{}).
+		Where("organization_id = ? AND assignment_strategy = ?", orgID, models.AssignmentStrategyManual).
+		Pluck("id", &manualTeamIDs)
+
+	var skills []string
+	a.DB.Model(&models.AgentSkill{}).Where("organization_id = ? AND user_id = ?", orgID, agentID).Pluck("skill", &skills)
+	skillsJSON, _ := json.Marshal(append([]string{}, skills...))
+
+	eligible := a.DB.Where("jsonb_array_length(COALESCE(required_skills, '[]'::jsonb)) > 0 AND required_skills <@ ?::jsonb", string(skillsJSON))
+	if len(autoTeamIDs) > 0 {
+		eligible = eligible.Or("jsonb_array_length(COALESCE(required_skills, '[]'::jsonb)) = 0 AND team_id IN ?", autoTeamIDs)
+	}
+
+	var transfers []models.AgentTransfer
+	err := a.DB.Transaction(func(tx *gorm.DB) error {
+		capacity, err := lockAgentCapacity(tx, orgID, agentID)
+		if err != nil {
+			return err
+		}
+		limit := capacity.Free()
+		if limit == 0 {
+			return nil
+		}
+		if limit < 0 {
+			limit = 1
+		}
+
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("organization_id = ? AND status = ? AND agent_id IS NULL", orgID, models.TransferStatusActive).
+			Where(eligible).
+			Order("transferred_at ASC").
+			Limit(limit)
+		if len(manualTeamIDs) > 0 {
+			query = query.Where("team_id IS NULL OR team_id NOT IN ?", manualTeamIDs)
+		}
+		if err := query.Find(&transfers).Error; err != nil {
+			return err
+		}
+
+		for i := range transfers {
+			transfer := &transfers[i]
+			transfer.AgentID = &agentID
+			if transfer.SLA.PickedUpAt == nil {
+				a.UpdateSLAOnPickup(transfer)
+			}
+			if err := tx.Save(transfer).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Contact{}).Where("id = ?", transfer.ContactID).Update("assigned_user_id", agentID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		a.Log.Error("Failed to assign queued transfers", "error", err, "user_id", agentID)
+		return 0
+	}
+
+	for i := range transfers {
+		a.broadcastTransferAssigned(&transfers[i])
+	}
+	if len(transfers) > 0 {
+		a.Log.Info("Assigned queued transfers to agent with free capacity", "user_id", agentID, "count", len(transfers))
+	}
+	return len(transfers)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentCapacity(t *testing.T) {
+	assert.False(t, agentCapacity{Load: 40}.Full(), "no limit")
+	assert.Equal(t, -1, agentCapacity{Load: 40}.Free())
+	assert.False(t, agentCapacity{Load: 2, Max: 3}.Full())
+	assert.Equal(t, 1, agentCapacity{Load: 2, Max: 3}.Free())
+	assert.True(t, agentCapacity{Load: 3, Max: 3}.Full())
+	assert.Equal(t, 0, agentCapacity{Load: 5, Max: 3}.Free(), "over a lowered limit")
+}
+
+func TestAssignToTeam_SkipsAgentsAtCapacity(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	busy := testutil.CreateTestUser(t, app.DB, org.ID)
+	idle := testutil.CreateTestUser(t, app.DB, org.ID)
+	for _, strategy := range []models.AssignmentStrategy{models.AssignmentStrategyRoundRobin, models.AssignmentStrategyLoadBalanced} {
+		team := &models.Team{
+			BaseModel:              models.BaseModel{ID: uuid.New()},
+			OrganizationID:         org.ID,
+			Name:                   "Support " + string(strategy),
+			AssignmentStrategy:     strategy,
+			MaxConcurrentTransfers: 2,
+			IsActive:               true,
+		}
+		require.NoError(t, app.DB.Create(team).Error)
+		for _, user := range []*models.User{busy, idle} {
+			require.NoError(t, app.DB.Create(&models.TeamMember{
+				BaseModel: models.BaseModel{ID: uuid.New()},
+				TeamID:    team.ID,
+				UserID:    user.ID,
+				Role:      models.TeamRoleAgent,
+			}).Error)
+		}
+
+		// Both agents are at the team's limit
+		for _, user := range []*models.User{busy, busy, idle, idle} {
+			require.NoError(t, app.DB.Create(&models.AgentTransfer{
+				BaseModel:       models.BaseModel{ID: uuid.New()},
+				OrganizationID:  org.ID,
+				ContactID:       contact.ID,
+				WhatsAppAccount: account.Name,
+				Status:          models.TransferStatusActive,
+				AgentID:         &user.ID,
+			}).Error)
+		}
+		assert.Nil(t, app.assignToTeam(team.ID, org.ID), strategy)
+
+		// Raising one agent's own limit makes room for them
+		require.NoError(t, app.DB.Model(&models.UserOrganization{}).
+			Where("user_id = ? AND organization_id = ?", idle.ID, org.ID).
+			Update("max_concurrent_transfers", 10).Error)
+		agentID := app.assignToTeam(team.ID, org.ID)
+		require.NotNil(t, agentID, strategy)
+		assert.Equal(t, idle.ID, *agentID, strategy)
+
+		require.NoError(t, app.DB.Model(&models.UserOrganization{}).
+			Where("user_id = ? AND organization_id = ?", idle.ID, org.ID).
+			Update("max_concurrent_transfers", 0).Error)
+		require.NoError(t, app.DB.Where("organization_id = ?", org.ID).Delete(&models.AgentTransfer{}).Error)
+	}
+}
+
+func TestAssignQueuedTransfers_RespectsLimit(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	agent := testutil.CreateTestUser(t, app.DB, org.ID)
+
+	team := &models.Team{
+		BaseModel:          models.BaseModel{ID: uuid.New()},
+		OrganizationID:     org.ID,
+		Name:               "Support",
+		AssignmentStrategy: models.AssignmentStrategyRoundRobin,
+		IsActive:           true,
+	}
+	require.NoError(t, app.DB.Create(team).Error)
+	require.NoError(t, app.DB.Create(&models.TeamMember{
+		BaseModel: models.BaseModel{ID: uuid.New()},
+		TeamID:    team.ID,
+		UserID:    agent.ID,
+		Role:      models.TeamRoleAgent,
+	}).Error)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, app.DB.Create(&models.AgentTransfer{
+			BaseModel:       models.BaseModel{ID: uuid.New()},
+			OrganizationID:  org.ID,
+			ContactID:       contact.ID,
+			WhatsAppAccount: account.Name,
+			Status:          models.TransferStatusActive,
+			TeamID:          &team.ID,
+		}).Error)
+	}
+
+	// Without a limit the agent takes one transfer, not the whole queue
+	assert.Equal(t, 1, app.assignQueuedTransfers(org.ID, agent.ID))
+
+	// With a limit they take transfers up to it
+	require.NoError(t, app.DB.Model(&models.UserOrganization{}).
+		Where("user_id = ? AND organization_id = ?", agent.ID, org.ID).
+		Update("max_concurrent_transfers", 3).Error)
+	assert.Equal(t, 2, app.assignQueuedTransfers(org.ID, agent.ID))
+	assert.Equal(t, 0, app.assignQueuedTransfers(org.ID, agent.ID))
+
+	var queued int64
+	app.DB.Model(&models.AgentTransfer{}).Where("organization_id = ? AND agent_id IS NULL", org.ID).Count(&queued)
+	assert.Equal(t, int64(1), queued)
+}
+
+func TestCreateTransfer_ConcurrentAssignmentsRespectLimit(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	agent := testutil.CreateTestUser(t, app.DB, org.ID)
+	require.NoError(t, app.DB.Model(&models.UserOrganization{}).
+		Where("user_id = ? AND organization_id = ?", agent.ID, org.ID).
+		Update("max_concurrent_transfers", 2).Error)
+
+	newTransfer := func() *models.AgentTransfer {
+		contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+		return &models.AgentTransfer{
+			BaseModel:       models.BaseModel{ID: uuid.New()},
+			OrganizationID:  org.ID,
+			ContactID:       contact.ID,
+			WhatsAppAccount: account.Name,
+			Status:          models.TransferStatusActive,
+			AgentID:         &agent.ID,
+		}
+	}
+
+	// Each assignment checks the limit under the agent's lock
+	transfers := make([]*models.AgentTransfer, 6)
+	errs := make([]error, len(transfers))
+	var wg sync.WaitGroup
+	for i := range transfers {
+		transfers[i] = newTransfer()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = app.createTransfer(transfers[i], false)
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		var atCapacity *agentAtCapacityError
+		assert.ErrorAs(t, err, &atCapacity)
+	}
+	assert.Equal(t, 2, created)
+	assert.Equal(t, int64(2), app.agentCapacities(org.ID, []uuid.UUID{agent.ID})[agent.ID].Load)
+
+	// A transfer routed to an agent who filled up goes to the queue
+	queued := newTransfer()
+	require.NoError(t, app.createTransfer(queued, true))
+	assert.Nil(t, queued.AgentID)
+	assert.Nil(t, queued.SLA.PickedUpAt)
+	var contact models.Contact
+	require.NoError(t, app.DB.First(&contact, queued.ContactID).Error)
+	assert.Nil(t, contact.AssignedUserID)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// AgentSkillRequest is a skill of an agent with their proficiency in it
+type AgentSkillRequest struct {
+	Skill       string `json:"skill"`
+	Proficiency int    `json:"proficiency"` // 1 (basic) to 5 (expert), defaults to 3
+}
+
+// AgentSkillResponse represents an agent skill in API responses
+type AgentSkillResponse struct {
+	Skill       string `json:"skill"`
+	Proficiency int    `json:"proficiency"`
+}
+
+// SkillSummary is a skill used in the organization with the number of agents having it
+type SkillSummary struct {
+	Skill  string `json:"skill"`
+	Agents int64  `json:"agents"`
+}
+
+// ListSkills returns the skills of the organization's agents
+func (a *App) ListSkills(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	skills := []SkillSummary{}
+	if err := a.DB.Model(&models.AgentSkill{}).
+		Select("skill, COUNT(DISTINCT user_id) AS agents").
+		Where("organization_id = ?", orgID).
+		Group("skill").
+		Order("skill ASC").
+		Scan(&skills).Error; err != nil {
+		a.Log.Error("Failed to list skills", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list skills", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{"skills": skills})
+}
+
+// GetUserSkills returns the skills of a user in the organization
+func (a *App) GetUserSkills(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "user")
+	if err != nil {
+		return nil
+	}
+
+	// Users can view their own skills, others need users:read permission
+	if userID != id && !a.HasPermission(userID, models.ResourceUsers, models.ActionRead, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Insufficient permissions", nil, "")
+	}
+	if !a.isOrgMember(orgID, id) {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "User not found", nil, "")
+	}
+
+	skills, err := a.userSkills(orgID, id)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load skills", nil, "")
+	}
+	return r.SendEnvelope(map[string]any{"skills": skills})
+}
+
+// UpdateUserSkills replaces the skills of a user in the organization
+func (a *App) UpdateUserSkills(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceUsers, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "user")
+	if err != nil {
+		return nil
+	}
+	if !a.isOrgMember(orgID, id) {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "User not found", nil, "")
+	}
+
+	var req struct {
+		Skills []AgentSkillRequest `json:"skills"`
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
+	}
+
+	skills, err := normalizeAgentSkills(req.Skills)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("organization_id = ? AND user_id = ?", orgID, id).Delete(&models.AgentSkill{}).Error; err != nil {
+			return err
+		}
+		for _, s := range skills {
+			skill := models.AgentSkill{
+				BaseModel:      models.BaseModel{ID: uuid.New()},
+				OrganizationID: orgID,
+				UserID:         id,
+				Skill:          s.Skill,
+				Proficiency:    s.Proficiency,
+			}
+			if err := tx.Create(&skill).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		a.Log.Error("Failed to update skills", "error", err, "user_id", id)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update skills", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{"skills": skills})
+}
+
+// userSkills returns the skills of a user in the organization, most proficient first
+func (a *App) userSkills(orgID, userID uuid.UUID) ([]AgentSkillResponse, error) {
+	var skills []models.AgentSkill
+	if err := a.DB.Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Order("proficiency DESC, skill ASC").
+		Find(&skills).Error; err != nil {
+		return nil, err
+	}
+	result := make([]AgentSkillResponse, len(skills))
+	for i, s := range skills {
+		result[i] = AgentSkillResponse{Skill: s.Skill, Proficiency: s.Proficiency}
+	}
+	return result, nil
+}
+
+// isOrgMember checks whether a user belongs to the organization
+func (a *App) isOrgMember(orgID, userID uuid.UUID) bool {
+	var count int64
+	a.DB.Model(&models.UserOrganization{}).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Count(&count)
+	return count > 0
+}
+
+// normalizeAgentSkills normalizes skill names and validates proficiencies.
+// A skill listed twice keeps its last proficiency.
+func normalizeAgentSkills(skills []AgentSkillRequest) ([]AgentSkillResponse, error) {
+	result := make([]AgentSkillResponse, 0, len(skills))
+	index := make(map[string]int, len(skills))
+	for _, s := range skills {
+		names, err := normalizeSkills([]string{s.Skill})
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("skill name is required")
+		}
+		proficiency := s.Proficiency
+		if proficiency == 0 {
+			proficiency = defaultSkillProficiency
+		}
+		if proficiency < minSkillProficiency || proficiency > maxSkillProficiency {
+			return nil, fmt.Errorf("proficiency of %s must be between %d and %d", names[0], minSkillProficiency, maxSkillProficiency)
+		}
+		if i, ok := index[names[0]]; ok {
+			result[i].Proficiency = proficiency
+			continue
+		}
+		index[names[0]] = len(result)
+		result = append(result, AgentSkillResponse{Skill: names[0], Proficiency: proficiency})
+	}
+	return result, nil
+}
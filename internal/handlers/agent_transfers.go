@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +13,7 @@ import (
 	"github.com/shridarpatil/whatomate/internal/websocket"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
@@ -26,6 +29,7 @@ type agentTransferRow struct {
 	Source                models.TransferSource `gorm:"column:source"`
 	AgentID               *uuid.UUID `gorm:"column:agent_id"`
 	TeamID                *uuid.UUID `gorm:"column:team_id"`
+	RequiredSkills        models.StringArray `gorm:"column:required_skills"`
 	TransferredByUserID   *uuid.UUID `gorm:"column:transferred_by_user_id"`
 	Notes                 string     `gorm:"column:notes"`
 	TransferredAt         time.Time  `gorm:"column:transferred_at"`
@@ -46,6 +50,10 @@ type agentTransferRow struct {
 	TeamName          *string `gorm:"column:team_name"`
 	TransferredByName *string `gorm:"column:transferred_by_name"`
 	ResumedByName     *string `gorm:"column:resumed_by_name"`
+
+	// Conversation fields
+	ConversationStatus *models.ConversationStatus `gorm:"column:conversation_status"`
+	SnoozedUntil       *time.Time                 `gorm:"column:snoozed_until"`
 }
 
 // CreateAgentTransferRequest represents the request to create an agent transfer
@@ -54,6 +62,7 @@ type CreateAgentTransferRequest struct {
 	WhatsAppAccount string               `json:"whatsapp_account"`
 	AgentID         *string              `json:"agent_id"`
 	TeamID          *string              `json:"team_id"` // Optional team queue
+	Skills          []string             `json:"skills"`  // Skills the agent needs
 	Notes           string               `json:"notes"`
 	Source          models.TransferSource `json:"source"` // manual, flow, keyword
 }
@@ -77,6 +86,7 @@ type AgentTransferResponse struct {
 	AgentName         *string              `json:"agent_name,omitempty"`
 	TeamID            *string              `json:"team_id,omitempty"`
 	TeamName          *string              `json:"team_name,omitempty"`
+	RequiredSkills    []string             `json:"required_skills,omitempty"`
 	TransferredBy     *string              `json:"transferred_by,omitempty"`
 	TransferredByName *string              `json:"transferred_by_name,omitempty"`
 	Notes             string               `json:"notes"`
@@ -94,6 +104,10 @@ type AgentTransferResponse struct {
 	EscalatedAt           *string `json:"escalated_at,omitempty"`
 	PickedUpAt            *string `json:"picked_up_at,omitempty"`
 	ExpiresAt             *string `json:"expires_at,omitempty"`
+
+	// Conversation fields
+	ConversationStatus models.ConversationStatus `json:"conversation_status,omitempty"`
+	SnoozedUntil       *string                   `json:"snoozed_until,omitempty"`
 }
 
 // ListAgentTransfers lists agent transfers for the organization
@@ -110,6 +124,7 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 	// Query params
 	status := string(r.RequestCtx.QueryArgs().Peek("status"))
 	teamIDStr := string(r.RequestCtx.QueryArgs().Peek("team_id"))
+	conversationStatus := string(r.RequestCtx.QueryArgs().Peek("conversation_status"))
 
 	// Pagination params
 	limit := 100 // Default limit
@@ -137,7 +152,7 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 	}
 
 	// Build SELECT clause based on what relations are needed
-	selectCols := []string{"agent_transfers.*"}
+	selectCols := []string{"agent_transfers.*", "COALESCE(conversations.status, 'open') AS conversation_status", "conversations.snoozed_until AS snoozed_until"}
 	if includeAll || includeSet["contact"] {
 		selectCols = append(selectCols, "contacts.profile_name AS contact_name")
 	}
@@ -157,6 +172,7 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 	// Build query with conditional JOINs for better performance
 	query := a.DB.Table("agent_transfers").
 		Select(strings.Join(selectCols, ", ")).
+		Joins(transferConversationJoin).
 		Where("agent_transfers.organization_id = ?", orgID).
 		Order("agent_transfers.transferred_at ASC") // FIFO
 
@@ -182,6 +198,11 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 		query = query.Where("agent_transfers.status = ?", status)
 	}
 
+	// Filter by conversation status if provided
+	if conversationStatus != "" {
+		query = query.Where("COALESCE(conversations.status, 'open') = ?", conversationStatus)
+	}
+
 	// Filter by team if provided
 	if teamIDStr != "" {
 		if teamIDStr == "general" {
@@ -220,10 +241,13 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 
 	// Get total count before pagination (for frontend to know if more exist)
 	var totalCount int64
-	countQuery := a.DB.Table("agent_transfers").Where("agent_transfers.organization_id = ?", orgID)
+	countQuery := a.DB.Table("agent_transfers").Joins(transferConversationJoin).Where("agent_transfers.organization_id = ?", orgID)
 	if status != "" {
 		countQuery = countQuery.Where("agent_transfers.status = ?", status)
 	}
+	if conversationStatus != "" {
+		countQuery = countQuery.Where("COALESCE(conversations.status, 'open') = ?", conversationStatus)
+	}
 	if teamIDStr != "" {
 		if teamIDStr == "general" {
 			countQuery = countQuery.Where("agent_transfers.team_id IS NULL")
@@ -302,6 +326,7 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 			WhatsAppAccount: t.WhatsAppAccount,
 			Status:          t.Status,
 			Source:          t.Source,
+			RequiredSkills:  t.RequiredSkills,
 			Notes:           t.Notes,
 			TransferredAt:   t.TransferredAt.Format(time.RFC3339),
 		}
@@ -371,6 +396,15 @@ func (a *App) ListAgentTransfers(r *fastglue.Request) error {
 			resp.ExpiresAt = &expiresAt
 		}
 
+		// Conversation fields
+		if t.ConversationStatus != nil {
+			resp.ConversationStatus = *t.ConversationStatus
+		}
+		if t.SnoozedUntil != nil {
+			snoozedUntil := t.SnoozedUntil.Format(time.RFC3339)
+			resp.SnoozedUntil = &snoozedUntil
+		}
+
 		response[i] = resp
 	}
 
@@ -439,6 +473,12 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 		teamID = &parsedTeamID
 	}
 
+	requested, err := normalizeSkills(req.Skills)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+	skills := transferSkills(settings, contact, requested)
+
 	// Determine agent assignment
 	var agentID *uuid.UUID
 
@@ -458,17 +498,22 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 		}
 		agentID = &parsedAgentID
 	} else if teamID != nil {
-		// Apply team's assignment strategy
-		agentID = a.assignToTeam(*teamID, orgID)
-	} else if settings != nil && settings.AgentAssignment.AssignToSameAgent && contact.AssignedUserID != nil {
-		// Auto-assign to contact's existing assigned agent (if setting enabled and agent is available)
-		var assignedAgent models.User
-		if a.DB.Where("id = ?", contact.AssignedUserID).First(&assignedAgent).Error == nil && assignedAgent.IsAvailable {
-			agentID = contact.AssignedUserID
+		// Route by skills or apply team's assignment strategy
+		agentID = a.assignTransfer(orgID, teamID, skills)
+	} else {
+		if settings != nil && settings.AgentAssignment.AssignToSameAgent && contact.AssignedUserID != nil {
+			// Auto-assign to contact's existing assigned agent (if setting enabled, agent is available, has the skills and capacity)
+			var assignedAgent models.User
+			if a.DB.Where("id = ?", contact.AssignedUserID).First(&assignedAgent).Error == nil && assignedAgent.IsAvailable &&
+				a.agentHasSkills(orgID, assignedAgent.ID, skills) && a.agentHasCapacity(orgID, assignedAgent.ID) {
+				agentID = contact.AssignedUserID
+			}
+		}
+		if agentID == nil {
+			// Route by skills, otherwise agentID remains nil (goes to queue)
+			agentID = a.assignTransfer(orgID, nil, skills)
 		}
-		// If agent is not available, falls through to queue (agentID remains nil)
 	}
-	// Otherwise, agentID remains nil (goes to queue)
 
 	// Determine source
 	source := req.Source
@@ -487,6 +532,7 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 		Source:              source,
 		AgentID:             agentID,
 		TeamID:              teamID,
+		RequiredSkills:      skills,
 		TransferredByUserID: &userID,
 		Notes:               req.Notes,
 		TransferredAt:       time.Now(),
@@ -497,21 +543,18 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 		a.SetSLADeadlines(&transfer, settings)
 	}
 
-	// If agent is already assigned, mark as picked up
-	if agentID != nil {
-		a.UpdateSLAOnPickup(&transfer)
-	}
-
-	if err := a.DB.Create(&transfer).Error; err != nil {
+	// An agent picked by routing who filled up meanwhile leaves the transfer queued;
+	// an explicitly requested agent at their limit is an error
+	explicitAgent := req.AgentID != nil && *req.AgentID != ""
+	if err := a.createTransfer(&transfer, !explicitAgent); err != nil {
+		var atCapacity *agentAtCapacityError
+		if errors.As(err, &atCapacity) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
 		a.Log.Error("Failed to create agent transfer", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create transfer", nil, "")
 	}
 
-	// Update contact assignment if agent assigned
-	if agentID != nil {
-		a.DB.Model(contact).Update("assigned_user_id", agentID)
-	}
-
 	// End any active chatbot session
 	a.DB.Model(&models.ChatbotSession{}).
 		Where("organization_id = ? AND contact_id = ? AND status = ?", orgID, contactID, models.SessionStatusActive).
@@ -523,6 +566,9 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 	// Broadcast WebSocket notification
 	a.broadcastTransferCreated(&transfer, contact)
 
+	// An agent is taking the conversation on
+	a.reopenConversation(orgID, contact.ID, transfer.WhatsAppAccount)
+
 	// Dispatch webhook for transfer created
 	var agentIDStr *string
 	var agentName *string
@@ -562,6 +608,7 @@ func (a *App) CreateAgentTransfer(r *fastglue.Request) error {
 		WhatsAppAccount: transfer.WhatsAppAccount,
 		Status:          transfer.Status,
 		Source:          transfer.Source,
+		RequiredSkills:  transfer.RequiredSkills,
 		Notes:           transfer.Notes,
 		TransferredAt:   transfer.TransferredAt.Format(time.RFC3339),
 	}
@@ -637,19 +684,42 @@ func (a *App) ResumeFromTransfer(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Transfer is not active", nil, "")
 	}
 
-	// Update transfer
+	if err := a.resumeTransfer(orgID, userID, transfer); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to resume transfer", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"message": "Transfer resumed, chatbot is now active for this contact",
+	})
+}
+
+// resumeTransfer hands an active transfer back to the chatbot
+func (a *App) resumeTransfer(orgID, userID uuid.UUID, transfer *models.AgentTransfer) error {
 	now := time.Now()
 	transfer.Status = models.TransferStatusResumed
 	transfer.ResumedAt = &now
 	transfer.ResumedBy = &userID
 
 	if err := a.DB.Save(transfer).Error; err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to resume transfer", nil, "")
+		return err
 	}
 
 	// Clear chatbot tracking so client inactivity SLA doesn't trigger after transfer is closed
 	a.ClearContactChatbotTracking(transfer.ContactID)
 
+	// The conversation is done unless another transfer of the contact is still open
+	var otherActive int64
+	a.DB.Model(&models.AgentTransfer{}).
+		Where("organization_id = ? AND contact_id = ? AND whats_app_account = ? AND status = ?",
+			orgID, transfer.ContactID, transfer.WhatsAppAccount, models.TransferStatusActive).
+		Count(&otherActive)
+	if otherActive == 0 {
+		a.resolveConversation(orgID, transfer.ContactID, transfer.WhatsAppAccount, transferResumedResolution, &userID)
+	}
+
+	// Ask the customer how it went
+	a.scheduleCSATSurvey(transfer)
+
 	// Get chatbot settings to check AssignToSameAgent (use cache)
 	settings, _ := a.getChatbotSettingsCached(orgID, transfer.WhatsAppAccount)
 
@@ -663,6 +733,11 @@ func (a *App) ResumeFromTransfer(r *fastglue.Request) error {
 	// Broadcast WebSocket notification
 	a.broadcastTransferResumed(transfer)
 
+	// The agent has room for a queued transfer
+	if transfer.AgentID != nil {
+		a.assignQueuedTransfers(orgID, *transfer.AgentID)
+	}
+
 	// Get contact for webhook data
 	var contact models.Contact
 	a.DB.Where("id = ?", transfer.ContactID).First(&contact)
@@ -677,9 +752,7 @@ func (a *App) ResumeFromTransfer(r *fastglue.Request) error {
 		WhatsAppAccount: transfer.WhatsAppAccount,
 	})
 
-	return r.SendEnvelope(map[string]any{
-		"message": "Transfer resumed, chatbot is now active for this contact",
-	})
+	return nil
 }
 
 // AssignAgentTransfer assigns a transfer to a specific agent
@@ -740,6 +813,8 @@ func (a *App) AssignAgentTransfer(r *fastglue.Request) error {
 		targetAgentID = &userID
 	}
 
+	previousAgentID := transfer.AgentID
+
 	// Handle team reassignment (requires write permission)
 	if req.TeamID != nil {
 		if !hasWriteAccess {
@@ -764,29 +839,51 @@ func (a *App) AssignAgentTransfer(r *fastglue.Request) error {
 		}
 	}
 
-	// Update transfer
-	transfer.AgentID = targetAgentID
+	save := func(tx *gorm.DB) error {
+		transfer.AgentID = targetAgentID
 
-	// Update SLA tracking if being assigned
-	if targetAgentID != nil && transfer.SLA.PickedUpAt == nil {
-		a.UpdateSLAOnPickup(&transfer)
-	}
+		// Update SLA tracking if being assigned
+		if targetAgentID != nil && transfer.SLA.PickedUpAt == nil {
+			a.UpdateSLAOnPickup(&transfer)
+		}
 
-	if err := a.DB.Save(&transfer).Error; err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to assign transfer", nil, "")
+		if err := tx.Save(&transfer).Error; err != nil {
+			return err
+		}
+
+		// Update contact assignment
+		if targetAgentID != nil && transfer.Contact != nil {
+			return tx.Model(transfer.Contact).Update("assigned_user_id", targetAgentID).Error
+		} else if targetAgentID == nil && transfer.Contact != nil {
+			// Clear assignment when unassigning
+			return tx.Model(transfer.Contact).Update("assigned_user_id", nil).Error
+		}
+		return nil
 	}
 
-	// Update contact assignment
-	if targetAgentID != nil && transfer.Contact != nil {
-		a.DB.Model(transfer.Contact).Update("assigned_user_id", targetAgentID)
-	} else if targetAgentID == nil && transfer.Contact != nil {
-		// Clear assignment when unassigning
-		a.DB.Model(transfer.Contact).Update("assigned_user_id", nil)
+	// Agents at their limit can't take more transfers
+	if targetAgentID != nil && (previousAgentID == nil || *previousAgentID != *targetAgentID) {
+		err = a.assignWithinCapacity(orgID, *targetAgentID, save)
+	} else {
+		err = a.DB.Transaction(save)
+	}
+	if err != nil {
+		transfer.AgentID = previousAgentID
+		var atCapacity *agentAtCapacityError
+		if errors.As(err, &atCapacity) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to assign transfer", nil, "")
 	}
 
 	// Broadcast WebSocket notification
 	a.broadcastTransferAssigned(&transfer)
 
+	// The previous agent has room for a queued transfer
+	if previousAgentID != nil && (targetAgentID == nil || *previousAgentID != *targetAgentID) {
+		a.assignQueuedTransfers(orgID, *previousAgentID)
+	}
+
 	// Dispatch webhook for transfer assigned
 	var agentIDStr *string
 	var agentName *string
@@ -867,10 +964,30 @@ func (a *App) PickNextTransfer(r *fastglue.Request) error {
 		}
 	}()
 
+	// Agents at their limit can't pick more transfers
+	capacity, err := lockAgentCapacity(tx, orgID, userID)
+	if err != nil {
+		tx.Rollback()
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to pick transfer", nil, "")
+	}
+	if capacity.Full() {
+		tx.Rollback()
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("You have reached your limit of %d active transfers", capacity.Max), nil, "")
+	}
+
+	// Transfers the user has the skills for are picked first
+	var userSkills []string
+	a.DB.Model(&models.AgentSkill{}).Where("organization_id = ? AND user_id = ?", orgID, userID).Pluck("skill", &userSkills)
+	userSkillsJSON, _ := json.Marshal(append([]string{}, userSkills...))
+
 	// Build query for picking transfer with row-level locking
 	query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
 		Where("organization_id = ? AND status = ? AND agent_id IS NULL", orgID, models.TransferStatusActive).
-		Order("transferred_at ASC")
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "COALESCE(required_skills, '[]'::jsonb) <@ ?::jsonb DESC, transferred_at ASC",
+			Vars:               []interface{}{string(userSkillsJSON)},
+			WithoutParentheses: true,
+		}})
 
 	if teamIDStr != "" {
 		// Pick from specific team
@@ -971,6 +1088,7 @@ func (a *App) PickNextTransfer(r *fastglue.Request) error {
 		WhatsAppAccount: transfer.WhatsAppAccount,
 		Status:          transfer.Status,
 		Source:          transfer.Source,
+		RequiredSkills:  transfer.RequiredSkills,
 		Notes:           transfer.Notes,
 		TransferredAt:   transfer.TransferredAt.Format(time.RFC3339),
 	}
@@ -1074,6 +1192,9 @@ func (a *App) broadcastTransferCreated(transfer *models.AgentTransfer, contact *
 	if transfer.TeamID != nil {
 		payload["team_id"] = transfer.TeamID.String()
 	}
+	if len(transfer.RequiredSkills) > 0 {
+		payload["required_skills"] = transfer.RequiredSkills
+	}
 
 	a.WSHub.BroadcastToOrg(transfer.OrganizationID, websocket.WSMessage{
 		Type:    websocket.TypeAgentTransfer,
@@ -1134,8 +1255,9 @@ func (a *App) broadcastTransferAssigned(transfer *models.AgentTransfer) {
 	})
 }
 
-// createTransferToQueue creates an unassigned agent transfer that goes to the queue
-func (a *App) createTransferToQueue(account *models.WhatsAppAccount, contact *models.Contact, source models.TransferSource) {
+// createTransferToQueue creates an agent transfer to the general queue. Transfers
+// requiring skills are assigned to the best matching agent instead.
+func (a *App) createTransferToQueue(account *models.WhatsAppAccount, contact *models.Contact, source models.TransferSource, requested []string) {
 	// Check for existing active transfer
 	var existingCount int64
 	a.DB.Model(&models.AgentTransfer{}).
@@ -1150,7 +1272,10 @@ func (a *App) createTransferToQueue(account *models.WhatsAppAccount, contact *mo
 	// Get chatbot settings for SLA (use cache)
 	settings, _ := a.getChatbotSettingsCached(account.OrganizationID, account.Name)
 
-	// Create unassigned transfer (goes to queue)
+	// Transfers requiring skills go to the best matching agent, others to the queue
+	skills := transferSkills(settings, contact, requested)
+	agentID := a.assignTransfer(account.OrganizationID, nil, skills)
+
 	transfer := models.AgentTransfer{
 		BaseModel:       models.BaseModel{ID: uuid.New()},
 		OrganizationID:  account.OrganizationID,
@@ -1159,7 +1284,8 @@ func (a *App) createTransferToQueue(account *models.WhatsAppAccount, contact *mo
 		PhoneNumber:     contact.PhoneNumber,
 		Status:          models.TransferStatusActive,
 		Source:          source,
-		AgentID:         nil, // Unassigned - goes to queue
+		AgentID:         agentID, // nil when unassigned - goes to queue
+		RequiredSkills:  skills,
 		TransferredAt:   time.Now(),
 	}
 
@@ -1168,19 +1294,19 @@ func (a *App) createTransferToQueue(account *models.WhatsAppAccount, contact *mo
 		a.SetSLADeadlines(&transfer, settings)
 	}
 
-	if err := a.DB.Create(&transfer).Error; err != nil {
+	if err := a.createTransfer(&transfer, true); err != nil {
 		a.Log.Error("Failed to create transfer to queue", "error", err, "contact_id", contact.ID, "source", string(source))
 		return
 	}
 
-	a.Log.Info("Transfer created to agent queue", "transfer_id", transfer.ID, "contact_id", contact.ID, "source", source)
+	a.Log.Info("Transfer created to agent queue", "transfer_id", transfer.ID, "contact_id", contact.ID, "source", source, "skills", skills)
 
 	// Broadcast to WebSocket
 	a.broadcastTransferCreated(&transfer, contact)
 }
 
 // createTransferFromKeyword creates an agent transfer triggered by a keyword rule
-func (a *App) createTransferFromKeyword(account *models.WhatsAppAccount, contact *models.Contact) {
+func (a *App) createTransferFromKeyword(account *models.WhatsAppAccount, contact *models.Contact, requested []string) {
 	// Check for existing active transfer
 	var existingCount int64
 	a.DB.Model(&models.AgentTransfer{}).
@@ -1196,25 +1322,29 @@ func (a *App) createTransferFromKeyword(account *models.WhatsAppAccount, contact
 	settings, _ := a.getChatbotSettingsCached(account.OrganizationID, account.Name)
 
 	// Check business hours - if outside hours, send out of hours message instead of transfer
-	if settings != nil && settings.BusinessHours.Enabled && len(settings.BusinessHours.Hours) > 0 {
-		if !a.isWithinBusinessHours(settings.BusinessHours.Hours) {
-			a.Log.Info("Outside business hours, sending out of hours message instead of transfer", "contact_id", contact.ID)
-			if settings.BusinessHours.OutOfHoursMessage != "" {
-				_ = a.sendAndSaveTextMessage(account, contact, settings.BusinessHours.OutOfHoursMessage)
-			}
-			return
+	if open, outOfHoursMessage := a.checkBusinessHours(settings); !open {
+		a.Log.Info("Outside business hours, sending out of hours message instead of transfer", "contact_id", contact.ID)
+		if outOfHoursMessage != "" {
+			_ = a.sendAndSaveTextMessage(account, contact, localized(settings.Translations, contactLanguage(contact), "out_of_hours_message", outOfHoursMessage))
 		}
+		return
 	}
 
+	skills := transferSkills(settings, contact, requested)
+
 	// Determine agent assignment
 	var agentID *uuid.UUID
 	if settings != nil && settings.AgentAssignment.AssignToSameAgent && contact.AssignedUserID != nil {
-		// Check if the assigned agent is available
+		// Check if the assigned agent is available, has the skills and capacity
 		var assignedAgent models.User
-		if a.DB.Where("id = ?", contact.AssignedUserID).First(&assignedAgent).Error == nil && assignedAgent.IsAvailable {
+		if a.DB.Where("id = ?", contact.AssignedUserID).First(&assignedAgent).Error == nil && assignedAgent.IsAvailable &&
+			a.agentHasSkills(account.OrganizationID, assignedAgent.ID, skills) && a.agentHasCapacity(account.OrganizationID, assignedAgent.ID) {
 			agentID = contact.AssignedUserID
 		}
-		// If agent is not available, falls through to queue (agentID remains nil)
+	}
+	if agentID == nil {
+		// Route by skills, otherwise falls through to queue (agentID remains nil)
+		agentID = a.assignTransfer(account.OrganizationID, nil, skills)
 	}
 
 	// Create transfer
@@ -1227,6 +1357,7 @@ func (a *App) createTransferFromKeyword(account *models.WhatsAppAccount, contact
 		Status:          models.TransferStatusActive,
 		Source:          models.TransferSourceKeyword,
 		AgentID:         agentID,
+		RequiredSkills:  skills,
 		TransferredAt:   time.Now(),
 	}
 
@@ -1235,21 +1366,11 @@ func (a *App) createTransferFromKeyword(account *models.WhatsAppAccount, contact
 		a.SetSLADeadlines(&transfer, settings)
 	}
 
-	// If agent is already assigned, mark as picked up
-	if agentID != nil {
-		a.UpdateSLAOnPickup(&transfer)
-	}
-
-	if err := a.DB.Create(&transfer).Error; err != nil {
+	if err := a.createTransfer(&transfer, true); err != nil {
 		a.Log.Error("Failed to create keyword-triggered transfer", "error", err, "contact_id", contact.ID)
 		return
 	}
 
-	// Update contact assignment if agent assigned
-	if agentID != nil {
-		a.DB.Model(&contact).Update("assigned_user_id", agentID)
-	}
-
 	// End any active chatbot session
 	a.DB.Model(&models.ChatbotSession{}).
 		Where("organization_id = ? AND contact_id = ? AND status = ?", account.OrganizationID, contact.ID, models.SessionStatusActive).
@@ -1259,8 +1380,8 @@ func (a *App) createTransferFromKeyword(account *models.WhatsAppAccount, contact
 		})
 
 	var agentIDStr string
-	if agentID != nil {
-		agentIDStr = agentID.String()
+	if transfer.AgentID != nil {
+		agentIDStr = transfer.AgentID.String()
 	}
 	a.Log.Info("Agent transfer created from keyword rule",
 		"transfer_id", transfer.ID,
@@ -1312,12 +1433,28 @@ func (a *App) assignToTeamRoundRobin(teamID uuid.UUID, orgID uuid.UUID) *uuid.UU
 		return nil
 	}
 
-	// Pick the first agent (least recently assigned)
-	selectedMember := members[0]
+	memberIDs := make([]uuid.UUID, len(members))
+	for i, m := range members {
+		memberIDs[i] = m.UserID
+	}
+	capacities := a.agentCapacities(orgID, memberIDs)
+
+	// Pick the least recently assigned agent below their limit
+	var selectedMember *models.TeamMember
+	for i := range members {
+		if !capacities[members[i].UserID].Full() {
+			selectedMember = &members[i]
+			break
+		}
+	}
+	if selectedMember == nil {
+		a.Log.Debug("All available agents in team are at capacity", "team_id", teamID)
+		return nil
+	}
 
 	// Update last_assigned_at
 	now := time.Now()
-	a.DB.Model(&selectedMember).Update("last_assigned_at", now)
+	a.DB.Model(selectedMember).Update("last_assigned_at", now)
 
 	a.Log.Debug("Round-robin assigned to agent", "team_id", teamID, "user_id", selectedMember.UserID)
 	return &selectedMember.UserID
@@ -1344,29 +1481,18 @@ func (a *App) assignToTeamLoadBalanced(teamID uuid.UUID, orgID uuid.UUID) *uuid.
 		memberIDs[i] = m.UserID
 	}
 
-	// Count active transfers for all members in a single query (optimized from N+1)
-	type AgentLoad struct {
-		AgentID uuid.UUID `gorm:"column:agent_id"`
-		Count   int64     `gorm:"column:count"`
-	}
-	var loads []AgentLoad
-	a.DB.Model(&models.AgentTransfer{}).
-		Select("agent_id, COUNT(*) as count").
-		Where("organization_id = ? AND agent_id IN ? AND status = ?", orgID, memberIDs, models.TransferStatusActive).
-		Group("agent_id").
-		Scan(&loads)
-
-	// Build a map of agent loads
-	loadMap := make(map[uuid.UUID]int64)
-	for _, l := range loads {
-		loadMap[l.AgentID] = l.Count
-	}
+	// Count active transfers and limits for all members in a single pass (optimized from N+1)
+	capacities := a.agentCapacities(orgID, memberIDs)
 
-	// Find agent with lowest load (agents with 0 transfers won't be in loadMap)
+	// Find agent with lowest load below their limit (agents with 0 transfers won't be in capacities)
 	var lowestUserID *uuid.UUID
 	var lowestCount int64 = -1
 	for _, m := range members {
-		count := loadMap[m.UserID] // Will be 0 if not in map (no active transfers)
+		capacity := capacities[m.UserID]
+		if capacity.Full() {
+			continue
+		}
+		count := capacity.Load
 		if lowestCount < 0 || count < lowestCount {
 			lowestCount = count
 			userID := m.UserID
@@ -1375,6 +1501,7 @@ func (a *App) assignToTeamLoadBalanced(teamID uuid.UUID, orgID uuid.UUID) *uuid.
 	}
 
 	if lowestUserID == nil {
+		a.Log.Debug("All available agents in team are at capacity", "team_id", teamID)
 		return nil
 	}
 
@@ -1383,7 +1510,7 @@ func (a *App) assignToTeamLoadBalanced(teamID uuid.UUID, orgID uuid.UUID) *uuid.
 }
 
 // createTransferToTeam creates an agent transfer to a specific team with appropriate assignment
-func (a *App) createTransferToTeam(account *models.WhatsAppAccount, contact *models.Contact, teamID uuid.UUID, notes string, source models.TransferSource) {
+func (a *App) createTransferToTeam(account *models.WhatsAppAccount, contact *models.Contact, teamID uuid.UUID, notes string, source models.TransferSource, requested []string) {
 	// Check for existing active transfer
 	var existingCount int64
 	a.DB.Model(&models.AgentTransfer{}).
@@ -1398,8 +1525,9 @@ func (a *App) createTransferToTeam(account *models.WhatsAppAccount, contact *mod
 	// Get chatbot settings for SLA (use cache)
 	settings, _ := a.getChatbotSettingsCached(account.OrganizationID, account.Name)
 
-	// Apply team's assignment strategy
-	agentID := a.assignToTeam(teamID, account.OrganizationID)
+	// Route by skills or apply team's assignment strategy
+	skills := transferSkills(settings, contact, requested)
+	agentID := a.assignTransfer(account.OrganizationID, &teamID, skills)
 
 	// Create transfer
 	transfer := models.AgentTransfer{
@@ -1412,6 +1540,7 @@ func (a *App) createTransferToTeam(account *models.WhatsAppAccount, contact *mod
 		Source:          source,
 		AgentID:         agentID,
 		TeamID:          &teamID,
+		RequiredSkills:  skills,
 		Notes:           notes,
 		TransferredAt:   time.Now(),
 	}
@@ -1421,21 +1550,11 @@ func (a *App) createTransferToTeam(account *models.WhatsAppAccount, contact *mod
 		a.SetSLADeadlines(&transfer, settings)
 	}
 
-	// If agent is already assigned, mark as picked up
-	if agentID != nil {
-		a.UpdateSLAOnPickup(&transfer)
-	}
-
-	if err := a.DB.Create(&transfer).Error; err != nil {
+	if err := a.createTransfer(&transfer, true); err != nil {
 		a.Log.Error("Failed to create team transfer", "error", err, "contact_id", contact.ID, "team_id", teamID)
 		return
 	}
 
-	// Update contact assignment if agent assigned
-	if agentID != nil {
-		a.DB.Model(&contact).Update("assigned_user_id", agentID)
-	}
-
 	// End any active chatbot session
 	a.DB.Model(&models.ChatbotSession{}).
 		Where("organization_id = ? AND contact_id = ? AND status = ?", account.OrganizationID, contact.ID, models.SessionStatusActive).
@@ -1445,8 +1564,8 @@ func (a *App) createTransferToTeam(account *models.WhatsAppAccount, contact *mod
 		})
 
 	var agentIDStrLog string
-	if agentID != nil {
-		agentIDStrLog = agentID.String()
+	if transfer.AgentID != nil {
+		agentIDStrLog = transfer.AgentID.String()
 	}
 	a.Log.Info("Agent transfer created to team",
 		"transfer_id", transfer.ID,
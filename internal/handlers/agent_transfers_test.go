@@ -113,6 +113,66 @@ func TestApp_ListAgentTransfers_Success(t *testing.T) {
 	assert.Equal(t, models.TransferStatusActive, result.Data.Transfers[0].Status)
 }
 
+func TestApp_ListAgentTransfers_ConversationStatus(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+
+	pendingContact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	openContact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	pending := createTestTransfer(t, app, org.ID, pendingContact.ID, account.Name, models.TransferStatusActive, nil)
+	_ = createTestTransfer(t, app, org.ID, openContact.ID, account.Name, models.TransferStatusActive, nil)
+
+	require.NoError(t, app.DB.Create(&models.Conversation{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		ContactID:       pendingContact.ID,
+		WhatsAppAccount: account.Name,
+		Status:          models.ConversationStatusPending,
+		StatusChangedAt: time.Now(),
+	}).Error)
+
+	list := func(conversationStatus string) ([]handlers.AgentTransferResponse, int64) {
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		if conversationStatus != "" {
+			testutil.SetQueryParam(req, "conversation_status", conversationStatus)
+		}
+		require.NoError(t, app.ListAgentTransfers(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var result struct {
+			Data struct {
+				Transfers  []handlers.AgentTransferResponse `json:"transfers"`
+				TotalCount int64                            `json:"total_count"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &result))
+		return result.Data.Transfers, result.Data.TotalCount
+	}
+
+	transfers, total := list("")
+	assert.Equal(t, int64(2), total)
+	require.Len(t, transfers, 2)
+	statuses := map[string]models.ConversationStatus{}
+	for _, tr := range transfers {
+		statuses[tr.ID] = tr.ConversationStatus
+	}
+	assert.Equal(t, models.ConversationStatusPending, statuses[pending.ID.String()])
+
+	transfers, total = list("pending")
+	assert.Equal(t, int64(1), total)
+	require.Len(t, transfers, 1)
+	assert.Equal(t, pending.ID.String(), transfers[0].ID)
+
+	transfers, total = list("open")
+	assert.Equal(t, int64(1), total)
+	require.Len(t, transfers, 1)
+	assert.Equal(t, models.ConversationStatusOpen, transfers[0].ConversationStatus)
+}
+
 func TestApp_ListAgentTransfers_FilterByStatus(t *testing.T) {
 	app := newTestApp(t)
 	org := testutil.CreateTestOrganization(t, app.DB)
@@ -864,3 +924,114 @@ func TestApp_ReturnAgentTransfersToQueue(t *testing.T) {
 	assert.Nil(t, updatedTransfer1.AgentID)
 	assert.Nil(t, updatedTransfer2.AgentID)
 }
+
+// --- Capacity Tests ---
+
+// setTransferLimit sets a user's limit of active transfers in the organization.
+func setTransferLimit(t *testing.T, app *handlers.App, orgID, userID uuid.UUID, limit int) {
+	t.Helper()
+	require.NoError(t, app.DB.Model(&models.UserOrganization{}).
+		Where("user_id = ? AND organization_id = ?", userID, orgID).
+		Update("max_concurrent_transfers", limit).Error)
+}
+
+func TestApp_PickNextTransfer_AtCapacity(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+	agent := createTestAgent(t, app, org.ID)
+	setTransferLimit(t, app, org.ID, agent.ID, 1)
+
+	createTestTransfer(t, app, org.ID, contact.ID, account.Name, models.TransferStatusActive, &agent.ID)
+	queued := createTestTransfer(t, app, org.ID, contact.ID, account.Name, models.TransferStatusActive, nil)
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, agent.ID)
+
+	err := app.PickNextTransfer(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	assert.Contains(t, string(testutil.GetResponseBody(req)), "limit of 1 active transfers")
+
+	var updatedTransfer models.AgentTransfer
+	require.NoError(t, app.DB.First(&updatedTransfer, queued.ID).Error)
+	assert.Nil(t, updatedTransfer.AgentID)
+}
+
+func TestApp_AssignAgentTransfer_AtCapacity(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+	agent := createTestAgent(t, app, org.ID)
+	team := createTestTeam(t, app, org.ID, agent.ID)
+	require.NoError(t, app.DB.Model(team).Update("max_concurrent_transfers", 1).Error)
+
+	createTestTransfer(t, app, org.ID, contact.ID, account.Name, models.TransferStatusActive, &agent.ID)
+	transfer := createTestTransfer(t, app, org.ID, contact.ID, account.Name, models.TransferStatusActive, nil)
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"agent_id": agent.ID.String(),
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", transfer.ID.String())
+
+	err := app.AssignAgentTransfer(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+
+	// The agent's own limit overrides the team's
+	setTransferLimit(t, app, org.ID, agent.ID, 2)
+
+	req = testutil.NewJSONRequest(t, map[string]any{
+		"agent_id": agent.ID.String(),
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", transfer.ID.String())
+
+	err = app.AssignAgentTransfer(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+}
+
+func TestApp_ResumeFromTransfer_AssignsQueuedTransfer(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+	agent := createTestAgent(t, app, org.ID)
+	team := createTestTeam(t, app, org.ID, agent.ID)
+	setTransferLimit(t, app, org.ID, agent.ID, 1)
+
+	active := createTestTransfer(t, app, org.ID, contact.ID, account.Name, models.TransferStatusActive, &agent.ID)
+	queued := createTestTransfer(t, app, org.ID, contact.ID, account.Name, models.TransferStatusActive, nil)
+	require.NoError(t, app.DB.Model(queued).Update("team_id", team.ID).Error)
+	general := createTestTransfer(t, app, org.ID, contact.ID, account.Name, models.TransferStatusActive, nil)
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", active.ID.String())
+
+	err := app.ResumeFromTransfer(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	// The team transfer waiting for capacity goes to the agent
+	var updatedTransfer models.AgentTransfer
+	require.NoError(t, app.DB.First(&updatedTransfer, queued.ID).Error)
+	require.NotNil(t, updatedTransfer.AgentID)
+	assert.Equal(t, agent.ID, *updatedTransfer.AgentID)
+	assert.NotNil(t, updatedTransfer.SLA.PickedUpAt)
+
+	// General queue transfers are left for pickup
+	require.NoError(t, app.DB.First(&updatedTransfer, general.ID).Error)
+	assert.Nil(t, updatedTransfer.AgentID)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/redis/go-redis/v9"
 	"github.com/shridarpatil/whatomate/internal/config"
 	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/shridarpatil/whatomate/internal/storage"
 	"github.com/shridarpatil/whatomate/internal/websocket"
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
 	"github.com/valyala/fasthttp"
@@ -27,11 +28,20 @@ type App struct {
 	WhatsApp          *whatsapp.Client
 	WSHub             *websocket.Hub
 	Queue             queue.Queue
+	Storage           storage.Storage
 	CampaignSubCancel context.CancelFunc
+	WSRelayCancel     context.CancelFunc
+	// WebhookWorkersCancel stops the inbound webhook workers
+	WebhookWorkersCancel context.CancelFunc
 	// HTTPClient is a shared HTTP client with connection pooling for external API calls
 	HTTPClient *http.Client
+	// AIHTTPClient is used for AI provider requests. Unlike HTTPClient it may reach the
+	// private hosts listed in ai.private_hosts, for self-hosted models. Falls back to HTTPClient.
+	AIHTTPClient *http.Client
 	// wg tracks background goroutines for graceful shutdown
 	wg sync.WaitGroup
+	// webhookWorkers tracks the inbound webhook workers
+	webhookWorkers sync.WaitGroup
 }
 
 // WaitForBackgroundTasks blocks until all background goroutines complete.
@@ -139,16 +149,22 @@ func (a *App) StartCampaignStatsSubscriber() error {
 			"sent", update.SentCount,
 		)
 
-		// Broadcast to organization via WebSocket
-		a.WSHub.BroadcastToOrg(update.OrganizationID, websocket.WSMessage{
-			Type: websocket.TypeCampaignStatsUpdate,
-			Payload: map[string]interface{}{
-				"campaign_id":     update.CampaignID,
-				"status":          update.Status,
-				"sent_count":      update.SentCount,
-				"delivered_count": update.DeliveredCount,
-				"read_count":      update.ReadCount,
-				"failed_count":    update.FailedCount,
+		// Broadcast to organization via WebSocket. Every instance receives the
+		// update from Redis, so it is delivered to local clients only.
+		a.WSHub.Broadcast(websocket.BroadcastMessage{
+			OrgID: update.OrganizationID,
+			Local: true,
+			Message: websocket.WSMessage{
+				Type: websocket.TypeCampaignStatsUpdate,
+				Payload: map[string]interface{}{
+					"campaign_id":     update.CampaignID,
+					"status":          update.Status,
+					"sent_count":      update.SentCount,
+					"delivered_count": update.DeliveredCount,
+					"read_count":      update.ReadCount,
+					"failed_count":    update.FailedCount,
+					"throttled_until": update.ThrottledUntil,
+				},
 			},
 		})
 	})
@@ -169,6 +185,35 @@ func (a *App) StopCampaignStatsSubscriber() {
 	}
 }
 
+// StartWSRelay relays WebSocket broadcasts through Redis pub/sub so that
+// clients connected to any API instance receive them
+func (a *App) StartWSRelay() error {
+	if a.WSHub == nil || a.Redis == nil {
+		a.Log.Warn("WebSocket hub or Redis not initialized, skipping websocket relay")
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.WSRelayCancel = cancel
+
+	subscriber := queue.NewSubscriber(a.Redis, a.Log)
+	if err := subscriber.SubscribeWSBroadcast(ctx, a.WSHub.HandleRelayed); err != nil {
+		cancel()
+		return err
+	}
+	a.WSHub.EnableRelay(ctx, queue.NewPublisher(a.Redis, a.Log))
+
+	a.Log.Info("WebSocket relay started")
+	return nil
+}
+
+// StopWSRelay stops relaying WebSocket broadcasts between instances
+func (a *App) StopWSRelay() {
+	if a.WSRelayCancel != nil {
+		a.WSRelayCancel()
+	}
+}
+
 // getOrgAndUserID extracts both organization ID and user ID from the request context.
 // Returns an error if either is missing or invalid.
 func (a *App) getOrgAndUserID(r *fastglue.Request) (orgID, userID uuid.UUID, err error) {
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+	// Embed the IANA timezone database so business hours work on hosts without zoneinfo
+	_ "time/tzdata"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// timeInterval is a span within a day in HH:MM, inclusive of both ends
+type timeInterval struct {
+	Start string
+	End   string
+}
+
+// checkBusinessHours reports whether the business is currently open according to
+// settings, and the out-of-hours message to send when it is not. Holidays may
+// override the default message. Settings without business hours are always open.
+func (a *App) checkBusinessHours(settings *models.ChatbotSettings) (bool, string) {
+	if settings == nil || !settings.BusinessHours.Enabled || len(settings.BusinessHours.Hours) == 0 {
+		return true, ""
+	}
+	now := time.Now().In(a.businessHoursLocation(settings))
+	return isWithinBusinessHours(settings.BusinessHours, now)
+}
+
+// businessHoursLocation returns the timezone business hours are evaluated in:
+// the settings timezone, then the organization timezone, then the server's local zone.
+func (a *App) businessHoursLocation(settings *models.ChatbotSettings) *time.Location {
+	name := settings.BusinessHours.Timezone
+	if name == "" {
+		var org models.Organization
+		if err := a.DB.Select("settings").Where("id = ?", settings.OrganizationID).First(&org).Error; err == nil {
+			name = getStringFromMap(org.Settings, "timezone")
+		}
+	}
+	if name == "" {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		a.Log.Warn("Invalid business hours timezone, using server time", "timezone", name, "error", err)
+		return time.Local
+	}
+	return loc
+}
+
+// isWithinBusinessHours checks whether now (already in the business timezone) falls
+// within the configured hours. A holiday matching today's date replaces the weekly
+// schedule: without hours the business is closed all day, otherwise only the
+// holiday's hours apply. Returns the out-of-hours message to use when closed.
+func isWithinBusinessHours(cfg models.BusinessHoursConfig, now time.Time) (bool, string) {
+	if holiday := findHoliday(cfg.Holidays, now); holiday != nil {
+		message := cfg.OutOfHoursMessage
+		if m := getStringFromMap(holiday, "out_of_hours_message"); m != "" {
+			message = m
+		}
+		return withinIntervals(parseIntervals(holiday["hours"]), now), message
+	}
+
+	currentDay := int(now.Weekday()) // 0 = Sunday, 1 = Monday, etc.
+	for _, bh := range cfg.Hours {
+		bhMap, ok := bh.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// Get day (0-6, Sunday-Saturday)
+		day, ok := bhMap["day"].(float64)
+		if !ok || int(day) != currentDay {
+			continue
+		}
+
+		// Check if enabled for this day
+		enabled, ok := bhMap["enabled"].(bool)
+		if !ok || !enabled {
+			return false, cfg.OutOfHoursMessage
+		}
+
+		return withinIntervals(dayIntervals(bhMap), now), cfg.OutOfHoursMessage
+	}
+
+	// If no matching day found, assume outside business hours
+	return false, cfg.OutOfHoursMessage
+}
+
+// findHoliday returns the holiday entry for now's date, if any. Recurring
+// holidays match the same month and day every year.
+func findHoliday(holidays models.JSONBArray, now time.Time) map[string]interface{} {
+	today := now.Format("2006-01-02")
+	for _, h := range holidays {
+		hMap, ok := h.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		date := getStringFromMap(hMap, "date")
+		if len(date) != len(today) {
+			continue
+		}
+		if date == today {
+			return hMap
+		}
+		if recurring, _ := hMap["recurring"].(bool); recurring && date[5:] == today[5:] {
+			return hMap
+		}
+	}
+	return nil
+}
+
+// dayIntervals returns the open intervals of a weekly schedule entry. Entries may
+// list several intervals (e.g. around a lunch break) or a single start/end time.
+func dayIntervals(bhMap map[string]interface{}) []timeInterval {
+	if intervals := parseIntervals(bhMap["intervals"]); len(intervals) > 0 {
+		return intervals
+	}
+	start := getStringFromMap(bhMap, "start_time")
+	end := getStringFromMap(bhMap, "end_time")
+	if start == "" || end == "" {
+		return nil
+	}
+	return []timeInterval{{Start: start, End: end}}
+}
+
+// parseIntervals converts [{start_time, end_time}] into intervals, skipping invalid entries
+func parseIntervals(v interface{}) []timeInterval {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	intervals := make([]timeInterval, 0, len(list))
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		start := getStringFromMap(m, "start_time")
+		end := getStringFromMap(m, "end_time")
+		if start == "" || end == "" {
+			continue
+		}
+		intervals = append(intervals, timeInterval{Start: start, End: end})
+	}
+	return intervals
+}
+
+// withinIntervals checks now's wall-clock time against the intervals
+func withinIntervals(intervals []timeInterval, now time.Time) bool {
+	currentTime := now.Format("15:04")
+	for _, iv := range intervals {
+		// Compare times (simple string comparison works for HH:MM format)
+		if currentTime >= iv.Start && currentTime <= iv.End {
+			return true
+		}
+	}
+	return false
+}
+
+// validateBusinessHoursTimezone checks that name is a known IANA timezone
+func validateBusinessHoursTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("invalid business hours timezone: %s", name)
+	}
+	return nil
+}
+
+// validateHolidays checks holiday dates and hours
+func validateHolidays(holidays []map[string]interface{}) error {
+	for _, h := range holidays {
+		date := getStringFromMap(h, "date")
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("invalid holiday date %q, expected YYYY-MM-DD", date)
+		}
+		if raw, ok := h["hours"]; ok && raw != nil {
+			list, ok := raw.([]interface{})
+			if !ok {
+				return fmt.Errorf("holiday hours for %s must be a list", date)
+			}
+			for _, item := range list {
+				m, _ := item.(map[string]interface{})
+				if !isValidClockTime(getStringFromMap(m, "start_time")) || !isValidClockTime(getStringFromMap(m, "end_time")) {
+					return fmt.Errorf("holiday hours for %s must use HH:MM times", date)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isValidClockTime checks for a zero-padded 24h HH:MM time
+func isValidClockTime(s string) bool {
+	if len(s) != 5 {
+		return false
+	}
+	_, err := time.Parse("15:04", s)
+	return err == nil
+}
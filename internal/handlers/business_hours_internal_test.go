@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// weekdayHours returns a business hours entry for the given weekday
+func weekdayHours(day time.Weekday, enabled bool, start, end string) map[string]interface{} {
+	return map[string]interface{}{
+		"day":        float64(day),
+		"enabled":    enabled,
+		"start_time": start,
+		"end_time":   end,
+	}
+}
+
+// 2025-03-12 is a Wednesday
+func at(t *testing.T, loc *time.Location, clock string) time.Time {
+	t.Helper()
+	ts, err := time.ParseInLocation("2006-01-02 15:04", "2025-03-12 "+clock, loc)
+	require.NoError(t, err)
+	return ts
+}
+
+func TestIsWithinBusinessHours_WithinHours(t *testing.T) {
+	cfg := models.BusinessHoursConfig{
+		Hours: models.JSONBArray{weekdayHours(time.Wednesday, true, "00:00", "23:59")},
+	}
+
+	open, _ := isWithinBusinessHours(cfg, at(t, time.UTC, "12:00"))
+	assert.True(t, open)
+}
+
+func TestIsWithinBusinessHours_OutsideHours(t *testing.T) {
+	cfg := models.BusinessHoursConfig{
+		Hours:             models.JSONBArray{weekdayHours(time.Wednesday, true, "00:00", "00:01")},
+		OutOfHoursMessage: "We're closed",
+	}
+
+	open, message := isWithinBusinessHours(cfg, at(t, time.UTC, "12:00"))
+	assert.False(t, open)
+	assert.Equal(t, "We're closed", message)
+}
+
+func TestIsWithinBusinessHours_DayDisabled(t *testing.T) {
+	cfg := models.BusinessHoursConfig{
+		Hours: models.JSONBArray{weekdayHours(time.Wednesday, false, "00:00", "23:59")},
+	}
+
+	open, _ := isWithinBusinessHours(cfg, at(t, time.UTC, "12:00"))
+	assert.False(t, open)
+}
+
+func TestIsWithinBusinessHours_NoMatchingDay(t *testing.T) {
+	cfg := models.BusinessHoursConfig{
+		Hours: models.JSONBArray{weekdayHours(time.Thursday, true, "00:00", "23:59")},
+	}
+
+	open, _ := isWithinBusinessHours(cfg, at(t, time.UTC, "12:00"))
+	assert.False(t, open)
+}
+
+func TestIsWithinBusinessHours_EmptyHours(t *testing.T) {
+	open, _ := isWithinBusinessHours(models.BusinessHoursConfig{}, at(t, time.UTC, "12:00"))
+	assert.False(t, open)
+}
+
+func TestIsWithinBusinessHours_Timezone(t *testing.T) {
+	ist, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	cfg := models.BusinessHoursConfig{
+		Hours: models.JSONBArray{weekdayHours(time.Wednesday, true, "09:00", "18:00")},
+	}
+
+	// 04:00 UTC is 09:30 IST: open in India, closed by the server clock
+	now := at(t, time.UTC, "04:00")
+	open, _ := isWithinBusinessHours(cfg, now)
+	assert.False(t, open)
+	open, _ = isWithinBusinessHours(cfg, now.In(ist))
+	assert.True(t, open)
+
+	// 13:00 UTC is 18:30 IST: closed in India
+	open, _ = isWithinBusinessHours(cfg, at(t, time.UTC, "13:00").In(ist))
+	assert.False(t, open)
+}
+
+func TestIsWithinBusinessHours_LunchBreak(t *testing.T) {
+	day := weekdayHours(time.Wednesday, true, "", "")
+	day["intervals"] = []interface{}{
+		map[string]interface{}{"start_time": "09:00", "end_time": "13:00"},
+		map[string]interface{}{"start_time": "14:00", "end_time": "18:00"},
+	}
+	cfg := models.BusinessHoursConfig{Hours: models.JSONBArray{day}}
+
+	tests := map[string]bool{
+		"08:59": false,
+		"09:00": true,
+		"12:30": true,
+		"13:30": false,
+		"14:00": true,
+		"18:00": true,
+		"18:01": false,
+	}
+	for clock, want := range tests {
+		open, _ := isWithinBusinessHours(cfg, at(t, time.UTC, clock))
+		assert.Equal(t, want, open, clock)
+	}
+}
+
+func TestIsWithinBusinessHours_Holidays(t *testing.T) {
+	base := models.BusinessHoursConfig{
+		Hours:             models.JSONBArray{weekdayHours(time.Wednesday, true, "09:00", "18:00")},
+		OutOfHoursMessage: "We're closed",
+	}
+
+	t.Run("ClosedAllDayWithCustomMessage", func(t *testing.T) {
+		cfg := base
+		cfg.Holidays = models.JSONBArray{
+			map[string]interface{}{"date": "2025-03-12", "name": "Foundation Day", "out_of_hours_message": "Closed for Foundation Day"},
+		}
+
+		open, message := isWithinBusinessHours(cfg, at(t, time.UTC, "12:00"))
+		assert.False(t, open)
+		assert.Equal(t, "Closed for Foundation Day", message)
+	})
+
+	t.Run("ReducedHours", func(t *testing.T) {
+		cfg := base
+		cfg.Holidays = models.JSONBArray{
+			map[string]interface{}{
+				"date":  "2025-03-12",
+				"hours": []interface{}{map[string]interface{}{"start_time": "10:00", "end_time": "13:00"}},
+			},
+		}
+
+		open, _ := isWithinBusinessHours(cfg, at(t, time.UTC, "11:00"))
+		assert.True(t, open)
+		open, message := isWithinBusinessHours(cfg, at(t, time.UTC, "15:00"))
+		assert.False(t, open)
+		assert.Equal(t, "We're closed", message)
+	})
+
+	t.Run("Recurring", func(t *testing.T) {
+		cfg := base
+		cfg.Holidays = models.JSONBArray{
+			map[string]interface{}{"date": "2020-03-12", "recurring": true},
+		}
+
+		open, _ := isWithinBusinessHours(cfg, at(t, time.UTC, "12:00"))
+		assert.False(t, open)
+	})
+
+	t.Run("OtherDateIgnored", func(t *testing.T) {
+		cfg := base
+		cfg.Holidays = models.JSONBArray{
+			map[string]interface{}{"date": "2020-03-12"},
+			map[string]interface{}{"date": "2025-12-25", "recurring": true},
+		}
+
+		open, _ := isWithinBusinessHours(cfg, at(t, time.UTC, "12:00"))
+		assert.True(t, open)
+	})
+
+	t.Run("DateInBusinessTimezone", func(t *testing.T) {
+		ist, err := time.LoadLocation("Asia/Kolkata")
+		require.NoError(t, err)
+
+		cfg := base
+		cfg.Hours = models.JSONBArray{
+			weekdayHours(time.Tuesday, true, "00:00", "23:59"),
+			weekdayHours(time.Wednesday, true, "00:00", "23:59"),
+		}
+		cfg.Holidays = models.JSONBArray{map[string]interface{}{"date": "2025-03-12"}}
+
+		// 20:00 UTC on the 11th is already the 12th in India
+		now := time.Date(2025, 3, 11, 20, 0, 0, 0, time.UTC)
+		open, _ := isWithinBusinessHours(cfg, now)
+		assert.True(t, open)
+		open, _ = isWithinBusinessHours(cfg, now.In(ist))
+		assert.False(t, open)
+	})
+}
+
+func TestCheckBusinessHours_DisabledIsOpen(t *testing.T) {
+	app := &App{}
+
+	open, message := app.checkBusinessHours(nil)
+	assert.True(t, open)
+	assert.Empty(t, message)
+
+	open, _ = app.checkBusinessHours(&models.ChatbotSettings{
+		BusinessHours: models.BusinessHoursConfig{
+			Enabled:  false,
+			Timezone: "Asia/Kolkata",
+			Hours:    models.JSONBArray{weekdayHours(time.Wednesday, false, "09:00", "18:00")},
+		},
+	})
+	assert.True(t, open)
+}
+
+func TestValidateHolidays(t *testing.T) {
+	assert.NoError(t, validateHolidays(nil))
+	assert.NoError(t, validateHolidays([]map[string]interface{}{
+		{"date": "2025-12-25", "recurring": true},
+		{"date": "2025-12-24", "hours": []interface{}{map[string]interface{}{"start_time": "09:00", "end_time": "13:00"}}},
+	}))
+
+	assert.Error(t, validateHolidays([]map[string]interface{}{{"date": "25-12-2025"}}))
+	assert.Error(t, validateHolidays([]map[string]interface{}{{"date": "2025-12-24", "hours": "09:00-13:00"}}))
+	assert.Error(t, validateHolidays([]map[string]interface{}{
+		{"date": "2025-12-24", "hours": []interface{}{map[string]interface{}{"start_time": "9:00", "end_time": "13:00"}}},
+	}))
+}
+
+func TestValidateBusinessHoursTimezone(t *testing.T) {
+	assert.NoError(t, validateBusinessHoursTimezone(""))
+	assert.NoError(t, validateBusinessHoursTimezone("Asia/Kolkata"))
+	assert.Error(t, validateBusinessHoursTimezone("Mars/Olympus_Mons"))
+}
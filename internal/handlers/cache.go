@@ -29,6 +29,7 @@ const (
 	// Cache key prefixes
 	settingsCachePrefix        = "chatbot:settings:"
 	flowsCachePrefix           = "chatbot:flows:"
+	flowVersionCachePrefix     = "chatbot:flow_version:"
 	keywordRulesCachePrefix    = "chatbot:keywords:"
 	whatsappAccountCachePrefix = "whatsapp:account:"
 	webhooksCachePrefix        = "webhooks:"
@@ -108,9 +109,33 @@ func (a *App) getChatbotFlowsCached(orgID uuid.UUID) ([]models.ChatbotFlow, erro
 		return nil, err
 	}
 
+	// Published flows run their live version; never-published flows run the draft rows.
+	// A flow whose published version can't be loaded is left out rather than running
+	// its unpublished draft, and the incomplete list isn't cached.
+	live := flows[:0]
+	complete := true
+	for _, flow := range flows {
+		if flow.PublishedVersion == 0 {
+			live = append(live, flow)
+			continue
+		}
+		published, err := loadChatbotFlowRevision(a.DB, orgID, flow.ID, flow.PublishedVersion)
+		if err != nil {
+			a.Log.Error("Failed to load published flow version", "error", err, "flow_id", flow.ID, "version", flow.PublishedVersion)
+			complete = false
+			continue
+		}
+		published.IsEnabled = flow.IsEnabled
+		published.HasDraftChanges = flow.HasDraftChanges
+		live = append(live, *published)
+	}
+	flows = live
+
 	// Cache the result
-	if data, err := json.Marshal(flows); err == nil {
-		a.Redis.Set(ctx, cacheKey, data, flowsCacheTTL)
+	if complete {
+		if data, err := json.Marshal(flows); err == nil {
+			a.Redis.Set(ctx, cacheKey, data, flowsCacheTTL)
+		}
 	}
 
 	return flows, nil
@@ -132,6 +157,54 @@ func (a *App) getChatbotFlowByIDCached(orgID uuid.UUID, flowID uuid.UUID) (*mode
 	return nil, gorm.ErrRecordNotFound
 }
 
+// getChatbotFlowVersionCached retrieves a published flow version from cache or database.
+// Versions are immutable, so the cache entry is never invalidated.
+func (a *App) getChatbotFlowVersionCached(orgID, flowID uuid.UUID, version int) (*models.ChatbotFlow, error) {
+	ctx := context.Background()
+	cacheKey := fmt.Sprintf("%s%s:%d", flowVersionCachePrefix, flowID.String(), version)
+
+	// Try cache first
+	cached, err := a.Redis.Get(ctx, cacheKey).Result()
+	if err == nil && cached != "" {
+		var flow models.ChatbotFlow
+		if err := json.Unmarshal([]byte(cached), &flow); err == nil && flow.OrganizationID == orgID {
+			return &flow, nil
+		}
+	}
+
+	// Cache miss - fetch from database
+	flow, err := loadChatbotFlowRevision(a.DB, orgID, flowID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the result
+	if data, err := json.Marshal(flow); err == nil {
+		a.Redis.Set(ctx, cacheKey, data, flowsCacheTTL)
+	}
+
+	return flow, nil
+}
+
+// getPinnedChatbotFlow retrieves the flow version a session started on.
+// The flow must still be enabled; version 0 (sessions started before versioning) uses the live version.
+func (a *App) getPinnedChatbotFlow(orgID, flowID uuid.UUID, version int) (*models.ChatbotFlow, error) {
+	flow, err := a.getChatbotFlowByIDCached(orgID, flowID)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 || version == flow.PublishedVersion {
+		return flow, nil
+	}
+
+	pinned, err := a.getChatbotFlowVersionCached(orgID, flowID, version)
+	if err != nil {
+		return nil, err
+	}
+	pinned.IsEnabled = flow.IsEnabled
+	return pinned, nil
+}
+
 // getKeywordRulesCached retrieves keyword rules from cache or database
 func (a *App) getKeywordRulesCached(orgID uuid.UUID, whatsAppAccount string) ([]models.KeywordRule, error) {
 	ctx := context.Background()
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+)
+
+// campaignSchedulerBatchSize limits how many due campaigns are dispatched per tick
+const campaignSchedulerBatchSize = 50
+
+// CampaignScheduler periodically dispatches scheduled campaigns whose
+// scheduled_at has passed. Campaigns are claimed with a conditional status
+// update, so several replicas can run the scheduler without double-sending.
+type CampaignScheduler struct {
+	app      *App
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewCampaignScheduler creates a new campaign scheduler
+func NewCampaignScheduler(app *App, interval time.Duration) *CampaignScheduler {
+	return &CampaignScheduler{
+		app:      app,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the campaign scheduling loop
+func (s *CampaignScheduler) Start(ctx context.Context) {
+	s.app.Log.Info("Campaign scheduler started", "interval", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.app.Log.Info("Campaign scheduler stopped by context")
+			return
+		case <-s.stopCh:
+			s.app.Log.Info("Campaign scheduler stopped")
+			return
+		case <-ticker.C:
+			s.dispatchDueCampaigns(ctx)
+		}
+	}
+}
+
+// Stop stops the campaign scheduler
+func (s *CampaignScheduler) Stop() {
+	close(s.stopCh)
+}
+
+// dispatchDueCampaigns finds scheduled campaigns that are due and dispatches them
+func (s *CampaignScheduler) dispatchDueCampaigns(ctx context.Context) {
+	now := time.Now()
+
+	var campaigns []models.BulkMessageCampaign
+	if err := s.app.DB.Where("status = ? AND scheduled_at IS NOT NULL AND scheduled_at <= ?", models.CampaignStatusScheduled, now).
+		Order("scheduled_at ASC").
+		Limit(campaignSchedulerBatchSize).
+		Find(&campaigns).Error; err != nil {
+		s.app.Log.Error("Failed to find due campaigns", "error", err)
+		return
+	}
+
+	for i := range campaigns {
+		s.dispatchCampaign(ctx, &campaigns[i], now)
+	}
+}
+
+// dispatchCampaign claims a single due campaign and enqueues its pending recipients.
+// The claim moves the campaign from scheduled to queued; the worker moves it to
+// processing once it picks up the first job.
+func (s *CampaignScheduler) dispatchCampaign(ctx context.Context, campaign *models.BulkMessageCampaign, now time.Time) {
+	result := s.app.DB.Model(&models.BulkMessageCampaign{}).
+		Where("id = ? AND status = ?", campaign.ID, models.CampaignStatusScheduled).
+		Updates(map[string]interface{}{
+			"status":     models.CampaignStatusQueued,
+			"started_at": now,
+		})
+	if result.Error != nil {
+		s.app.Log.Error("Failed to claim scheduled campaign", "error", result.Error, "campaign_id", campaign.ID)
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Another replica claimed it, or it was paused/cancelled in the meantime
+		return
+	}
+
+	var recipients []models.BulkMessageRecipient
+	if err := s.app.DB.Where("campaign_id = ? AND status = ?", campaign.ID, models.MessageStatusPending).Find(&recipients).Error; err != nil {
+		s.app.Log.Error("Failed to load recipients for scheduled campaign", "error", err, "campaign_id", campaign.ID)
+		s.revertCampaign(campaign, models.CampaignStatusScheduled)
+		return
+	}
+
+	if len(recipients) == 0 {
+		s.app.Log.Warn("Scheduled campaign has no pending recipients, moving back to draft", "campaign_id", campaign.ID)
+		s.revertCampaign(campaign, models.CampaignStatusDraft)
+		return
+	}
+
+	jobs := buildRecipientJobs(campaign.ID, campaign.OrganizationID, recipients)
+	if err := s.app.Queue.EnqueueRecipients(ctx, jobs); err != nil {
+		s.app.Log.Error("Failed to enqueue scheduled campaign", "error", err, "campaign_id", campaign.ID)
+		// Put it back so the next tick retries
+		s.revertCampaign(campaign, models.CampaignStatusScheduled)
+		return
+	}
+
+	s.app.Log.Info("Scheduled campaign dispatched", "campaign_id", campaign.ID, "scheduled_at", campaign.ScheduledAt, "recipients", len(jobs))
+	s.broadcastCampaignStatus(campaign, models.CampaignStatusQueued)
+}
+
+// revertCampaign moves a claimed campaign back out of the queued state
+func (s *CampaignScheduler) revertCampaign(campaign *models.BulkMessageCampaign, status models.CampaignStatus) {
+	if err := s.app.DB.Model(&models.BulkMessageCampaign{}).
+		Where("id = ? AND status = ?", campaign.ID, models.CampaignStatusQueued).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"started_at": nil,
+		}).Error; err != nil {
+		s.app.Log.Error("Failed to revert scheduled campaign", "error", err, "campaign_id", campaign.ID, "status", status)
+	}
+}
+
+// broadcastCampaignStatus notifies the organization about a campaign status change
+func (s *CampaignScheduler) broadcastCampaignStatus(campaign *models.BulkMessageCampaign, status models.CampaignStatus) {
+	if s.app.WSHub == nil {
+		return
+	}
+
+	s.app.WSHub.BroadcastToOrg(campaign.OrganizationID, websocket.WSMessage{
+		Type: websocket.TypeCampaignStatsUpdate,
+		Payload: map[string]interface{}{
+			"campaign_id":     campaign.ID.String(),
+			"status":          status,
+			"sent_count":      campaign.SentCount,
+			"delivered_count": campaign.DeliveredCount,
+			"read_count":      campaign.ReadCount,
+			"failed_count":    campaign.FailedCount,
+		},
+	})
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createScheduledTestCampaign creates a scheduled campaign with the given number of pending recipients.
+func createScheduledTestCampaign(t *testing.T, app *App, scheduledAt time.Time, recipients int) *models.BulkMessageCampaign {
+	t.Helper()
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+	campaign := &models.BulkMessageCampaign{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "Scheduled " + uuid.New().String()[:8],
+		TemplateID:      template.ID,
+		Status:          models.CampaignStatusScheduled,
+		ScheduledAt:     &scheduledAt,
+		CreatedBy:       user.ID,
+	}
+	require.NoError(t, app.DB.Create(campaign).Error)
+
+	for i := 0; i < recipients; i++ {
+		require.NoError(t, app.DB.Create(&models.BulkMessageRecipient{
+			BaseModel:   models.BaseModel{ID: uuid.New()},
+			CampaignID:  campaign.ID,
+			PhoneNumber: "+1555000" + uuid.New().String()[:4],
+			Status:      models.MessageStatusPending,
+		}).Error)
+	}
+	return campaign
+}
+
+func reloadCampaign(t *testing.T, app *App, id uuid.UUID) models.BulkMessageCampaign {
+	t.Helper()
+	var c models.BulkMessageCampaign
+	require.NoError(t, app.DB.Where("id = ?", id).First(&c).Error)
+	return c
+}
+
+func TestCampaignScheduler_DispatchesDueCampaign(t *testing.T) {
+	app := newSLATestApp(t)
+	mockQueue := testutil.NewMockQueue()
+	app.Queue = mockQueue
+
+	campaign := createScheduledTestCampaign(t, app, time.Now().Add(-time.Minute), 3)
+
+	s := NewCampaignScheduler(app, time.Minute)
+	s.dispatchCampaign(context.Background(), campaign, time.Now())
+
+	updated := reloadCampaign(t, app, campaign.ID)
+	assert.Equal(t, models.CampaignStatusQueued, updated.Status)
+	assert.NotNil(t, updated.StartedAt)
+	assert.Equal(t, 3, mockQueue.JobCount())
+}
+
+func TestCampaignScheduler_IgnoresFutureCampaign(t *testing.T) {
+	app := newSLATestApp(t)
+	mockQueue := testutil.NewMockQueue()
+	app.Queue = mockQueue
+
+	campaign := createScheduledTestCampaign(t, app, time.Now().Add(time.Hour), 2)
+
+	s := NewCampaignScheduler(app, time.Minute)
+	s.dispatchDueCampaigns(context.Background())
+
+	updated := reloadCampaign(t, app, campaign.ID)
+	assert.Equal(t, models.CampaignStatusScheduled, updated.Status)
+	for _, job := range mockQueue.Jobs {
+		assert.NotEqual(t, campaign.ID, job.CampaignID)
+	}
+}
+
+func TestCampaignScheduler_ClaimIsExclusive(t *testing.T) {
+	app := newSLATestApp(t)
+	mockQueue := testutil.NewMockQueue()
+	app.Queue = mockQueue
+
+	campaign := createScheduledTestCampaign(t, app, time.Now().Add(-time.Minute), 2)
+
+	// Two replicas racing for the same campaign: only one may enqueue
+	first := NewCampaignScheduler(app, time.Minute)
+	second := NewCampaignScheduler(app, time.Minute)
+	first.dispatchCampaign(context.Background(), campaign, time.Now())
+	second.dispatchCampaign(context.Background(), campaign, time.Now())
+
+	assert.Equal(t, 2, mockQueue.JobCount())
+}
+
+func TestCampaignScheduler_NoRecipientsMovesToDraft(t *testing.T) {
+	app := newSLATestApp(t)
+	app.Queue = testutil.NewMockQueue()
+
+	campaign := createScheduledTestCampaign(t, app, time.Now().Add(-time.Minute), 0)
+
+	s := NewCampaignScheduler(app, time.Minute)
+	s.dispatchCampaign(context.Background(), campaign, time.Now())
+
+	updated := reloadCampaign(t, app, campaign.ID)
+	assert.Equal(t, models.CampaignStatusDraft, updated.Status)
+	assert.Nil(t, updated.StartedAt)
+}
+
+func TestCampaignScheduler_EnqueueFailureKeepsScheduled(t *testing.T) {
+	app := newSLATestApp(t)
+	mockQueue := testutil.NewMockQueue()
+	mockQueue.Error = errors.New("redis down")
+	app.Queue = mockQueue
+
+	campaign := createScheduledTestCampaign(t, app, time.Now().Add(-time.Minute), 1)
+
+	s := NewCampaignScheduler(app, time.Minute)
+	s.dispatchCampaign(context.Background(), campaign, time.Now())
+
+	updated := reloadCampaign(t, app, campaign.ID)
+	assert.Equal(t, models.CampaignStatusScheduled, updated.Status, "campaign should be retried on the next tick")
+}
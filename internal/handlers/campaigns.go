@@ -1,15 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/contactutil"
 	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/shridarpatil/whatomate/internal/queue"
 	"github.com/shridarpatil/whatomate/internal/websocket"
@@ -47,6 +49,7 @@ type CampaignResponse struct {
 	ScheduledAt     *time.Time           `json:"scheduled_at,omitempty"`
 	StartedAt       *time.Time           `json:"started_at,omitempty"`
 	CompletedAt     *time.Time           `json:"completed_at,omitempty"`
+	ThrottledUntil  *time.Time           `json:"throttled_until,omitempty"`
 	CreatedAt       time.Time            `json:"created_at"`
 	UpdatedAt       time.Time            `json:"updated_at"`
 }
@@ -124,6 +127,7 @@ func (a *App) ListCampaigns(r *fastglue.Request) error {
 			ScheduledAt:         c.ScheduledAt,
 			StartedAt:           c.StartedAt,
 			CompletedAt:         c.CompletedAt,
+			ThrottledUntil:      c.ThrottledUntil,
 			CreatedAt:           c.CreatedAt,
 			UpdatedAt:           c.UpdatedAt,
 		}
@@ -242,6 +246,7 @@ func (a *App) GetCampaign(r *fastglue.Request) error {
 		ScheduledAt:         campaign.ScheduledAt,
 		StartedAt:           campaign.StartedAt,
 		CompletedAt:         campaign.CompletedAt,
+		ThrottledUntil:      campaign.ThrottledUntil,
 		CreatedAt:           campaign.CreatedAt,
 		UpdatedAt:           campaign.UpdatedAt,
 	}
@@ -269,9 +274,9 @@ func (a *App) UpdateCampaign(r *fastglue.Request) error {
 		return nil
 	}
 
-	// Only allow updates to draft campaigns
-	if campaign.Status != models.CampaignStatusDraft {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Can only update draft campaigns", nil, "")
+	// Only allow updates to draft and scheduled campaigns
+	if campaign.Status != models.CampaignStatusDraft && campaign.Status != models.CampaignStatusScheduled {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Can only update draft or scheduled campaigns", nil, "")
 	}
 
 	var req CampaignRequest
@@ -285,6 +290,11 @@ func (a *App) UpdateCampaign(r *fastglue.Request) error {
 		"scheduled_at": req.ScheduledAt,
 	}
 
+	// Clearing the schedule of a scheduled campaign puts it back into draft
+	if campaign.Status == models.CampaignStatusScheduled && req.ScheduledAt == nil {
+		updates["status"] = models.CampaignStatusDraft
+	}
+
 	if req.TemplateID != "" {
 		templateID, err := uuid.Parse(req.TemplateID)
 		if err != nil {
@@ -411,8 +421,25 @@ func (a *App) StartCampaign(r *fastglue.Request) error {
 		}
 	}
 
-	// Update status to processing
+	// A draft with a future scheduled_at is handed to the campaign scheduler
+	// instead of being sent right away.
 	now := time.Now()
+	if campaign.Status == models.CampaignStatusDraft && campaign.ScheduledAt != nil && campaign.ScheduledAt.After(now) {
+		if err := a.DB.Model(campaign).Update("status", models.CampaignStatusScheduled).Error; err != nil {
+			a.Log.Error("Failed to schedule campaign", "error", err)
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to schedule campaign", nil, "")
+		}
+
+		a.Log.Info("Campaign scheduled", "campaign_id", id, "scheduled_at", campaign.ScheduledAt, "recipients", len(recipients))
+
+		return r.SendEnvelope(map[string]interface{}{
+			"message":      "Campaign scheduled",
+			"status":       models.CampaignStatusScheduled,
+			"scheduled_at": campaign.ScheduledAt,
+		})
+	}
+
+	// Update status to processing
 	updates := map[string]interface{}{
 		"status":     models.CampaignStatusProcessing,
 		"started_at": now,
@@ -426,17 +453,7 @@ func (a *App) StartCampaign(r *fastglue.Request) error {
 	a.Log.Info("Campaign started", "campaign_id", id, "recipients", len(recipients))
 
 	// Enqueue all recipients as individual jobs for parallel processing
-	jobs := make([]*queue.RecipientJob, len(recipients))
-	for i, recipient := range recipients {
-		jobs[i] = &queue.RecipientJob{
-			CampaignID:     id,
-			RecipientID:    recipient.ID,
-			OrganizationID: orgID,
-			PhoneNumber:    recipient.PhoneNumber,
-			RecipientName:  recipient.RecipientName,
-			TemplateParams: recipient.TemplateParams,
-		}
-	}
+	jobs := buildRecipientJobs(id, orgID, recipients)
 
 	if err := a.Queue.EnqueueRecipients(r.RequestCtx, jobs); err != nil {
 		a.Log.Error("Failed to enqueue recipients", "error", err)
@@ -587,17 +604,7 @@ func (a *App) RetryFailed(r *fastglue.Request) error {
 	a.Log.Info("Retrying failed messages", "campaign_id", id, "failed_count", len(failedRecipients))
 
 	// Enqueue failed recipients as individual jobs for parallel processing
-	jobs := make([]*queue.RecipientJob, len(failedRecipients))
-	for i, recipient := range failedRecipients {
-		jobs[i] = &queue.RecipientJob{
-			CampaignID:     id,
-			RecipientID:    recipient.ID,
-			OrganizationID: orgID,
-			PhoneNumber:    recipient.PhoneNumber,
-			RecipientName:  recipient.RecipientName,
-			TemplateParams: recipient.TemplateParams,
-		}
-	}
+	jobs := buildRecipientJobs(id, orgID, failedRecipients)
 
 	if err := a.Queue.EnqueueRecipients(r.RequestCtx, jobs); err != nil {
 		a.Log.Error("Failed to enqueue recipients for retry", "error", err)
@@ -630,8 +637,8 @@ func (a *App) ImportRecipients(r *fastglue.Request) error {
 		return nil
 	}
 
-	if campaign.Status != models.CampaignStatusDraft {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Can only add recipients to draft campaigns", nil, "")
+	if campaign.Status != models.CampaignStatusDraft && campaign.Status != models.CampaignStatusScheduled {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Can only add recipients to draft or scheduled campaigns", nil, "")
 	}
 
 	var req struct {
@@ -641,21 +648,37 @@ func (a *App) ImportRecipients(r *fastglue.Request) error {
 		return nil
 	}
 
-	// Create recipients
-	recipients := make([]models.BulkMessageRecipient, len(req.Recipients))
+	// Contacts who opted out are never added
+	phones := make([]string, len(req.Recipients))
 	for i, rec := range req.Recipients {
-		recipients[i] = models.BulkMessageRecipient{
+		phones[i] = rec.PhoneNumber
+	}
+	suppressed, err := contactutil.SuppressedPhones(a.DB, orgID, phones)
+	if err != nil {
+		a.Log.Error("Failed to check suppression list", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to add recipients", nil, "")
+	}
+
+	// Create recipients
+	recipients := make([]models.BulkMessageRecipient, 0, len(req.Recipients))
+	for _, rec := range req.Recipients {
+		if suppressed[contactutil.NormalizePhone(rec.PhoneNumber)] {
+			continue
+		}
+		recipients = append(recipients, models.BulkMessageRecipient{
 			CampaignID:     id,
 			PhoneNumber:    rec.PhoneNumber,
 			RecipientName:  rec.RecipientName,
 			TemplateParams: models.JSONB(rec.TemplateParams),
 			Status:         models.MessageStatusPending,
-		}
+		})
 	}
 
-	if err := a.DB.Create(&recipients).Error; err != nil {
-		a.Log.Error("Failed to add recipients", "error", err)
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to add recipients", nil, "")
+	if len(recipients) > 0 {
+		if err := a.DB.Create(&recipients).Error; err != nil {
+			a.Log.Error("Failed to add recipients", "error", err)
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to add recipients", nil, "")
+		}
 	}
 
 	// Update total recipients count
@@ -663,11 +686,13 @@ func (a *App) ImportRecipients(r *fastglue.Request) error {
 	a.DB.Model(&models.BulkMessageRecipient{}).Where("campaign_id = ?", id).Count(&totalCount)
 	a.DB.Model(campaign).Update("total_recipients", totalCount)
 
-	a.Log.Info("Recipients added to campaign", "campaign_id", id, "count", len(req.Recipients))
+	suppressedCount := len(req.Recipients) - len(recipients)
+	a.Log.Info("Recipients added to campaign", "campaign_id", id, "count", len(recipients), "suppressed", suppressedCount)
 
 	return r.SendEnvelope(map[string]interface{}{
 		"message":          "Recipients added successfully",
-		"added_count":      len(req.Recipients),
+		"added_count":      len(recipients),
+		"suppressed_count": suppressedCount,
 		"total_recipients": totalCount,
 	})
 }
@@ -847,9 +872,9 @@ func (a *App) UploadCampaignMedia(r *fastglue.Request) error {
 	}
 
 	// Save file locally for preview
-	localPath, err := a.saveCampaignMedia(campaignUUID.String(), data, mimeType)
+	localPath, err := a.saveCampaignMedia(ctx, campaignUUID.String(), data, mimeType)
 	if err != nil {
-		a.Log.Error("Failed to save media locally", "error", err)
+		a.Log.Error("Failed to save campaign media", "error", err)
 		// Don't fail the request, just log the error - preview won't work
 	}
 
@@ -876,34 +901,23 @@ func (a *App) UploadCampaignMedia(r *fastglue.Request) error {
 	})
 }
 
-// saveCampaignMedia saves uploaded media locally for preview
-func (a *App) saveCampaignMedia(campaignID string, data []byte, mimeType string) (string, error) {
+// saveCampaignMedia saves uploaded media to media storage for preview
+func (a *App) saveCampaignMedia(ctx context.Context, campaignID string, data []byte, mimeType string) (string, error) {
 	// Determine file extension
 	ext := getExtensionFromMimeType(mimeType)
 	if ext == "" {
 		ext = ".bin"
 	}
 
-	// Create campaigns media directory
-	subdir := "campaigns"
-	if err := a.ensureMediaDir(subdir); err != nil {
-		return "", fmt.Errorf("failed to create media directory: %w", err)
-	}
-
-	// Generate filename using campaign ID
-	filename := campaignID + ext
-	filePath := filepath.Join(a.getMediaStoragePath(), subdir, filename)
-
-	// Save file
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	// Key the file by campaign ID so re-uploads replace the previous media
+	key := path.Join("campaigns", campaignID+ext)
+	if err := a.mediaStorage().Put(ctx, key, data, mimeType); err != nil {
 		return "", fmt.Errorf("failed to save media file: %w", err)
 	}
 
-	// Return relative path for storage
-	relativePath := filepath.Join(subdir, filename)
-	a.Log.Info("Campaign media saved locally", "path", relativePath, "size", len(data))
+	a.Log.Info("Campaign media saved", "path", key, "size", len(data))
 
-	return relativePath, nil
+	return key, nil
 }
 
 // ServeCampaignMedia serves campaign media files for preview
@@ -931,45 +945,14 @@ func (a *App) ServeCampaignMedia(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "No media found", nil, "")
 	}
 
-	// Security: prevent directory traversal and symlink attacks
-	filePath := filepath.Clean(campaign.HeaderMediaLocalPath)
-	baseDir, err := filepath.Abs(a.getMediaStoragePath())
-	if err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Storage configuration error", nil, "")
-	}
-	fullPath, err := filepath.Abs(filepath.Join(baseDir, filePath))
-	if err != nil || !strings.HasPrefix(fullPath, baseDir+string(os.PathSeparator)) {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid file path", nil, "")
-	}
-
-	// Reject symlinks
-	info, err := os.Lstat(fullPath)
-	if err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "File not found", nil, "")
-	}
-	if info.Mode()&os.ModeSymlink != 0 {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid file path", nil, "")
-	}
-
-	// Read file
-	data, err := os.ReadFile(fullPath)
-	if err != nil {
-		a.Log.Error("Failed to read media file", "path", fullPath, "error", err)
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to read file", nil, "")
-	}
-
 	// Use stored mime type or determine from extension
 	contentType := campaign.HeaderMediaMimeType
 	if contentType == "" {
-		ext := strings.ToLower(filepath.Ext(filePath))
+		ext := strings.ToLower(path.Ext(campaign.HeaderMediaLocalPath))
 		contentType = getMimeTypeFromExtension(ext)
 	}
 
-	r.RequestCtx.Response.Header.Set("Content-Type", contentType)
-	r.RequestCtx.Response.Header.Set("Cache-Control", "private, max-age=3600")
-	r.RequestCtx.SetBody(data)
-
-	return nil
+	return a.serveStoredMedia(r, campaign.HeaderMediaLocalPath, contentType)
 }
 
 // getMimeTypeFromExtension returns MIME type from file extension
@@ -1079,6 +1062,22 @@ func (a *App) recalculateCampaignStats(campaignID uuid.UUID) {
 	}
 }
 
+// buildRecipientJobs converts campaign recipients into queue jobs
+func buildRecipientJobs(campaignID, orgID uuid.UUID, recipients []models.BulkMessageRecipient) []*queue.RecipientJob {
+	jobs := make([]*queue.RecipientJob, len(recipients))
+	for i, recipient := range recipients {
+		jobs[i] = &queue.RecipientJob{
+			CampaignID:     campaignID,
+			RecipientID:    recipient.ID,
+			OrganizationID: orgID,
+			PhoneNumber:    recipient.PhoneNumber,
+			RecipientName:  recipient.RecipientName,
+			TemplateParams: recipient.TemplateParams,
+		}
+	}
+	return jobs
+}
+
 // sanitizeFilename removes path separators, dangerous characters, and truncates length.
 var safeFilenameRe = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
 
@@ -1,6 +1,7 @@
 package handlers_test
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/handlers"
 	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/storage"
 	"github.com/shridarpatil/whatomate/test/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -497,6 +499,35 @@ func TestApp_StartCampaign_Success(t *testing.T) {
 	assert.NotNil(t, updated.StartedAt)
 }
 
+func TestApp_StartCampaign_FutureScheduleDefersSend(t *testing.T) {
+	mockQueue := testutil.NewMockQueue()
+	app := newTestApp(t, withQueue(mockQueue))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("start-scheduled")), testutil.WithPassword("password"))
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, testutil.WithAccountName("start-scheduled-account"))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusDraft)
+	scheduledAt := time.Now().Add(2 * time.Hour)
+	require.NoError(t, app.DB.Model(campaign).Update("scheduled_at", scheduledAt).Error)
+	createTestRecipient(t, app, campaign.ID, "+1234567890", models.MessageStatusPending)
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", campaign.ID.String())
+
+	err := app.StartCampaign(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	// Nothing is enqueued until the scheduler picks it up
+	assert.Empty(t, mockQueue.Jobs)
+
+	var updated models.BulkMessageCampaign
+	app.DB.Where("id = ?", campaign.ID).First(&updated)
+	assert.Equal(t, models.CampaignStatusScheduled, updated.Status)
+	assert.Nil(t, updated.StartedAt)
+}
+
 func TestApp_StartCampaign_NoPendingRecipients(t *testing.T) {
 	mockQueue := testutil.NewMockQueue()
 	app := newTestApp(t, withQueue(mockQueue))
@@ -889,3 +920,49 @@ func TestApp_Campaign_CrossOrgIsolation(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
 }
+
+func TestApp_ServeCampaignMedia_StreamsFromStorage(t *testing.T) {
+	app := newTestApp(t)
+	app.Storage = storage.NewLocal(t.TempDir())
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusDraft)
+
+	key := "campaigns/" + campaign.ID.String() + ".png"
+	require.NoError(t, app.Storage.Put(context.Background(), key, []byte("png-bytes"), "image/png"))
+	require.NoError(t, app.DB.Model(campaign).Updates(map[string]any{
+		"header_media_local_path": key,
+		"header_media_mime_type":  "image/png",
+	}).Error)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", campaign.ID.String())
+
+	err := app.ServeCampaignMedia(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+	assert.Equal(t, "image/png", string(req.RequestCtx.Response.Header.ContentType()))
+	assert.Equal(t, "png-bytes", string(testutil.GetResponseBody(req)))
+}
+
+func TestApp_ServeCampaignMedia_MissingObject(t *testing.T) {
+	app := newTestApp(t)
+	app.Storage = storage.NewLocal(t.TempDir())
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusDraft)
+	require.NoError(t, app.DB.Model(campaign).Update("header_media_local_path", "campaigns/missing.png").Error)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", campaign.ID.String())
+
+	err := app.ServeCampaignMedia(req)
+	require.NoError(t, err)
+	assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+}
@@ -2,10 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/llm"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 	"gorm.io/gorm"
@@ -23,14 +28,23 @@ type ChatbotSettingsResponse struct {
 	BusinessHours              []map[string]interface{} `json:"business_hours"`
 	OutOfHoursMessage          string                   `json:"out_of_hours_message"`
 	AllowAutomatedOutsideHours bool                     `json:"allow_automated_outside_hours"`
+	BusinessHoursTimezone      string                   `json:"business_hours_timezone"`
+	BusinessHoursHolidays      []map[string]interface{} `json:"business_hours_holidays"`
 	AllowAgentQueuePickup        bool                     `json:"allow_agent_queue_pickup"`
 	AssignToSameAgent            bool                     `json:"assign_to_same_agent"`
 	AgentCurrentConversationOnly bool                     `json:"agent_current_conversation_only"`
+	SkillRules                   []map[string]interface{} `json:"skill_rules"`
 	AIEnabled                    bool                     `json:"ai_enabled"`
 	AIProvider            models.AIProvider        `json:"ai_provider"`
 	AIModel               string                   `json:"ai_model"`
 	AIMaxTokens           int                      `json:"ai_max_tokens"`
 	AISystemPrompt        string                   `json:"ai_system_prompt"`
+	AIBaseURL             string                   `json:"ai_base_url"`
+	AITimeoutSeconds      int                      `json:"ai_timeout_seconds"`
+	AIToolCalling         bool                     `json:"ai_tool_calling"`
+	AIMaxToolCalls        int                      `json:"ai_max_tool_calls"`
+	AIEmbeddingModel      string                   `json:"ai_embedding_model"`
+	AIKnowledgeChunks     int                      `json:"ai_knowledge_chunks"`
 	// SLA Settings
 	SLAEnabled             bool     `json:"sla_enabled"`
 	SLAResponseMinutes     int      `json:"sla_response_minutes"`
@@ -46,6 +60,27 @@ type ChatbotSettingsResponse struct {
 	ClientReminderMessage  string `json:"client_reminder_message"`
 	ClientAutoCloseMinutes int    `json:"client_auto_close_minutes"`
 	ClientAutoCloseMessage string `json:"client_auto_close_message"`
+	// Opt-out Settings
+	OptOutKeywords []string `json:"opt_out_keywords"`
+	OptOutMessage  string   `json:"opt_out_message"`
+	OptInKeywords  []string `json:"opt_in_keywords"`
+	OptInMessage   string   `json:"opt_in_message"`
+	// Intent Settings
+	IntentThreshold        float64 `json:"intent_threshold"`
+	IntentAIClassification bool    `json:"intent_ai_classification"`
+	// Language Settings
+	DefaultLanguage string       `json:"default_language"`
+	Languages       []string     `json:"languages"`
+	DetectLanguage  bool         `json:"detect_language"`
+	Translations    models.JSONB `json:"translations"`
+	// CSAT Settings
+	CSATEnabled         bool                  `json:"csat_enabled"`
+	CSATDelayMinutes    int                   `json:"csat_delay_minutes"`
+	CSATSurveyType      models.CSATSurveyType `json:"csat_survey_type"`
+	CSATQuestion        string                `json:"csat_question"`
+	CSATThankYouMessage string                `json:"csat_thank_you_message"`
+	CSATFlowID          string                `json:"csat_flow_id"`
+	CSATFlowCTA         string                `json:"csat_flow_cta"`
 }
 
 // ChatbotStatsResponse represents chatbot statistics
@@ -66,8 +101,10 @@ type KeywordRuleResponse struct {
 	Name            string             `json:"name"`
 	Keywords        []string           `json:"keywords"`
 	MatchType       models.MatchType   `json:"match_type"`
+	FuzzyMaxEdits   int                `json:"fuzzy_max_edits"`
 	ResponseType    models.ResponseType `json:"response_type"`
 	ResponseContent json.RawMessage    `json:"response_content"`
+	Translations    models.JSONB       `json:"translations"`
 	Priority        int                `json:"priority"`
 	Enabled         bool               `json:"enabled"`
 	CreatedAt       string             `json:"created_at"`
@@ -75,13 +112,15 @@ type KeywordRuleResponse struct {
 
 // ChatbotFlowResponse represents a chatbot flow for API response
 type ChatbotFlowResponse struct {
-	ID              string   `json:"id"`
-	Name            string   `json:"name"`
-	Description     string   `json:"description"`
-	TriggerKeywords []string `json:"trigger_keywords"`
-	Enabled         bool     `json:"enabled"`
-	StepsCount      int      `json:"steps_count"`
-	CreatedAt       string   `json:"created_at"`
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	TriggerKeywords  []string `json:"trigger_keywords"`
+	Enabled          bool     `json:"enabled"`
+	StepsCount       int      `json:"steps_count"`
+	PublishedVersion int      `json:"published_version"`
+	HasDraftChanges  bool     `json:"has_draft_changes"`
+	CreatedAt        string   `json:"created_at"`
 }
 
 // AIContextResponse represents an AI context for API response
@@ -91,6 +130,8 @@ type AIContextResponse struct {
 	ContextType     models.ContextType `json:"context_type"`
 	TriggerKeywords []string          `json:"trigger_keywords"`
 	StaticContent   string            `json:"static_content"`
+	ApiConfig       models.JSONB      `json:"api_config"`
+	ToolParameters  models.JSONB      `json:"tool_parameters"`
 	Enabled         bool              `json:"enabled"`
 	Priority        int               `json:"priority"`
 	CreatedAt       string            `json:"created_at"`
@@ -113,6 +154,8 @@ func (a *App) GetChatbotSettings(r *fastglue.Request) error {
 			DefaultResponse:    "Hello! How can I help you today?",
 			SessionTimeoutMins: 30,
 			AI:                 models.AIConfig{Enabled: false},
+			Language:           models.LanguageConfig{DefaultLanguage: "en"},
+			CSAT:               models.CSATConfig{DelayMinutes: 5, SurveyType: models.CSATSurveyTypeButtons},
 		}
 	}
 
@@ -148,6 +191,20 @@ func (a *App) GetChatbotSettings(r *fastglue.Request) error {
 		}
 	}
 
+	holidays := make([]map[string]interface{}, 0)
+	for _, h := range settings.BusinessHours.Holidays {
+		if hMap, ok := h.(map[string]interface{}); ok {
+			holidays = append(holidays, hMap)
+		}
+	}
+
+	skillRules := make([]map[string]interface{}, 0)
+	for _, rule := range settings.AgentAssignment.SkillRules {
+		if ruleMap, ok := rule.(map[string]interface{}); ok {
+			skillRules = append(skillRules, ruleMap)
+		}
+	}
+
 	settingsResp := ChatbotSettingsResponse{
 		Enabled:               settings.IsEnabled,
 		GreetingMessage:       settings.DefaultResponse,
@@ -160,16 +217,25 @@ func (a *App) GetChatbotSettings(r *fastglue.Request) error {
 		BusinessHours:              businessHours,
 		OutOfHoursMessage:          settings.BusinessHours.OutOfHoursMessage,
 		AllowAutomatedOutsideHours: settings.BusinessHours.AllowAutomatedOutside,
+		BusinessHoursTimezone:      settings.BusinessHours.Timezone,
+		BusinessHoursHolidays:      holidays,
 		// Agent Assignment
 		AllowAgentQueuePickup:        settings.AgentAssignment.AllowQueuePickup,
 		AssignToSameAgent:            settings.AgentAssignment.AssignToSameAgent,
 		AgentCurrentConversationOnly: settings.AgentAssignment.CurrentConversationOnly,
+		SkillRules:                   skillRules,
 		// AI
 		AIEnabled:      settings.AI.Enabled,
 		AIProvider:     settings.AI.Provider,
 		AIModel:        settings.AI.Model,
 		AIMaxTokens:    settings.AI.MaxTokens,
 		AISystemPrompt: settings.AI.SystemPrompt,
+		AIBaseURL:        settings.AI.BaseURL,
+		AITimeoutSeconds: settings.AI.TimeoutSeconds,
+		AIToolCalling:    settings.AI.ToolCalling,
+		AIMaxToolCalls:   settings.AI.MaxToolCalls,
+		AIEmbeddingModel:  settings.AI.EmbeddingModel,
+		AIKnowledgeChunks: settings.AI.KnowledgeChunks,
 		// SLA Settings
 		SLAEnabled:             settings.SLA.Enabled,
 		SLAResponseMinutes:     settings.SLA.ResponseMinutes,
@@ -185,6 +251,27 @@ func (a *App) GetChatbotSettings(r *fastglue.Request) error {
 		ClientReminderMessage:  settings.ClientInactivity.ReminderMessage,
 		ClientAutoCloseMinutes: settings.ClientInactivity.AutoCloseMinutes,
 		ClientAutoCloseMessage: settings.ClientInactivity.AutoCloseMessage,
+		// Opt-out Settings
+		OptOutKeywords: optOutKeywordsOrDefault(settings.OptOut.Keywords, models.DefaultOptOutKeywords),
+		OptOutMessage:  settings.OptOut.Message,
+		OptInKeywords:  optOutKeywordsOrDefault(settings.OptOut.OptInKeywords, models.DefaultOptInKeywords),
+		OptInMessage:   settings.OptOut.OptInMessage,
+		// Intent Settings
+		IntentThreshold:        settings.Intent.Threshold,
+		IntentAIClassification: settings.Intent.AIClassification,
+		// Language Settings
+		DefaultLanguage: settings.Language.DefaultLanguage,
+		Languages:       settings.Language.Languages,
+		DetectLanguage:  settings.Language.DetectLanguage,
+		Translations:    settings.Translations,
+		// CSAT Settings
+		CSATEnabled:         settings.CSAT.Enabled,
+		CSATDelayMinutes:    settings.CSAT.DelayMinutes,
+		CSATSurveyType:      settings.CSAT.SurveyType,
+		CSATQuestion:        settings.CSAT.Question,
+		CSATThankYouMessage: settings.CSAT.ThankYouMessage,
+		CSATFlowID:          settings.CSAT.FlowID,
+		CSATFlowCTA:         settings.CSAT.FlowCTA,
 	}
 
 	return r.SendEnvelope(map[string]interface{}{
@@ -193,6 +280,31 @@ func (a *App) GetChatbotSettings(r *fastglue.Request) error {
 	})
 }
 
+// maxAITimeoutSeconds limits how long a chatbot waits for the AI provider
+const maxAITimeoutSeconds = 300
+
+// validateAIBaseURL checks an AI provider base URL. Internal addresses are only
+// allowed for hosts the server config trusts (ai.private_hosts).
+func (a *App) validateAIBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("AI base URL must be an http or https URL")
+	}
+	if err := validateWebhookURL(baseURL); err != nil {
+		var trusted []string
+		if a.Config != nil {
+			trusted = a.Config.AI.PrivateHosts
+		}
+		if !isTrustedHost(trusted, u.Hostname(), u.Port()) {
+			return fmt.Errorf("AI base URL points to an internal address; add the host to ai.private_hosts in the server config to allow it")
+		}
+	}
+	return nil
+}
+
 // UpdateChatbotSettings updates chatbot settings
 func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 	orgID, err := a.getOrgID(r)
@@ -211,15 +323,24 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 		BusinessHours              *[]map[string]interface{}  `json:"business_hours"`
 		OutOfHoursMessage          *string                    `json:"out_of_hours_message"`
 		AllowAutomatedOutsideHours *bool                      `json:"allow_automated_outside_hours"`
+		BusinessHoursTimezone      *string                    `json:"business_hours_timezone"`
+		BusinessHoursHolidays      *[]map[string]interface{}  `json:"business_hours_holidays"`
 		AllowAgentQueuePickup        *bool                      `json:"allow_agent_queue_pickup"`
 		AssignToSameAgent            *bool                      `json:"assign_to_same_agent"`
 		AgentCurrentConversationOnly *bool                      `json:"agent_current_conversation_only"`
+		SkillRules                   *[]map[string]interface{}  `json:"skill_rules"`
 		AIEnabled                    *bool                      `json:"ai_enabled"`
 		AIProvider                 *models.AIProvider         `json:"ai_provider"`
 		AIAPIKey                   *string                    `json:"ai_api_key"`
 		AIModel                    *string                    `json:"ai_model"`
 		AIMaxTokens                *int                       `json:"ai_max_tokens"`
 		AISystemPrompt             *string                    `json:"ai_system_prompt"`
+		AIBaseURL                  *string                    `json:"ai_base_url"`
+		AITimeoutSeconds           *int                       `json:"ai_timeout_seconds"`
+		AIToolCalling              *bool                      `json:"ai_tool_calling"`
+		AIMaxToolCalls             *int                       `json:"ai_max_tool_calls"`
+		AIEmbeddingModel           *string                    `json:"ai_embedding_model"`
+		AIKnowledgeChunks          *int                       `json:"ai_knowledge_chunks"`
 		// SLA Settings
 		SLAEnabled             *bool     `json:"sla_enabled"`
 		SLAResponseMinutes     *int      `json:"sla_response_minutes"`
@@ -235,6 +356,27 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 		ClientReminderMessage  *string `json:"client_reminder_message"`
 		ClientAutoCloseMinutes *int    `json:"client_auto_close_minutes"`
 		ClientAutoCloseMessage *string `json:"client_auto_close_message"`
+		// Opt-out Settings
+		OptOutKeywords *[]string `json:"opt_out_keywords"`
+		OptOutMessage  *string   `json:"opt_out_message"`
+		OptInKeywords  *[]string `json:"opt_in_keywords"`
+		OptInMessage   *string   `json:"opt_in_message"`
+		// Intent Settings
+		IntentThreshold        *float64 `json:"intent_threshold"`
+		IntentAIClassification *bool    `json:"intent_ai_classification"`
+		// Language Settings
+		DefaultLanguage *string                 `json:"default_language"`
+		Languages       *[]string               `json:"languages"`
+		DetectLanguage  *bool                   `json:"detect_language"`
+		Translations    *map[string]interface{} `json:"translations"`
+		// CSAT Settings
+		CSATEnabled         *bool                  `json:"csat_enabled"`
+		CSATDelayMinutes    *int                   `json:"csat_delay_minutes"`
+		CSATSurveyType      *models.CSATSurveyType `json:"csat_survey_type"`
+		CSATQuestion        *string                `json:"csat_question"`
+		CSATThankYouMessage *string                `json:"csat_thank_you_message"`
+		CSATFlowID          *string                `json:"csat_flow_id"`
+		CSATFlowCTA         *string                `json:"csat_flow_cta"`
 	}
 
 	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
@@ -298,6 +440,22 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 	if req.AllowAutomatedOutsideHours != nil {
 		settings.BusinessHours.AllowAutomatedOutside = *req.AllowAutomatedOutsideHours
 	}
+	if req.BusinessHoursTimezone != nil {
+		if err := validateBusinessHoursTimezone(*req.BusinessHoursTimezone); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		settings.BusinessHours.Timezone = *req.BusinessHoursTimezone
+	}
+	if req.BusinessHoursHolidays != nil {
+		if err := validateHolidays(*req.BusinessHoursHolidays); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		holidays := make([]interface{}, len(*req.BusinessHoursHolidays))
+		for i, h := range *req.BusinessHoursHolidays {
+			holidays[i] = h
+		}
+		settings.BusinessHours.Holidays = holidays
+	}
 
 	// Agent Assignment
 	if req.AllowAgentQueuePickup != nil {
@@ -306,6 +464,21 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 	if req.AssignToSameAgent != nil {
 		settings.AgentAssignment.AssignToSameAgent = *req.AssignToSameAgent
 	}
+	if req.SkillRules != nil {
+		if err := validateSkillRules(*req.SkillRules); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		rules := make([]interface{}, len(*req.SkillRules))
+		for i, rule := range *req.SkillRules {
+			skill, _ := normalizeSkills([]string{getStringFromMap(rule, "skill")})
+			rules[i] = map[string]interface{}{
+				"field": getStringFromMap(rule, "field"),
+				"value": strings.TrimSpace(getStringFromMap(rule, "value")),
+				"skill": skill[0],
+			}
+		}
+		settings.AgentAssignment.SkillRules = rules
+	}
 	if req.AgentCurrentConversationOnly != nil {
 		settings.AgentAssignment.CurrentConversationOnly = *req.AgentCurrentConversationOnly
 	}
@@ -315,6 +488,9 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 		settings.AI.Enabled = *req.AIEnabled
 	}
 	if req.AIProvider != nil {
+		if *req.AIProvider != "" && !llm.IsRegistered(string(*req.AIProvider)) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Unsupported AI provider %q", *req.AIProvider), nil, "")
+		}
 		settings.AI.Provider = *req.AIProvider
 	}
 	if req.AIAPIKey != nil && *req.AIAPIKey != "" {
@@ -329,6 +505,37 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 	if req.AISystemPrompt != nil {
 		settings.AI.SystemPrompt = *req.AISystemPrompt
 	}
+	if req.AIBaseURL != nil {
+		baseURL := strings.TrimSpace(*req.AIBaseURL)
+		if err := a.validateAIBaseURL(baseURL); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		settings.AI.BaseURL = baseURL
+	}
+	if req.AITimeoutSeconds != nil {
+		if *req.AITimeoutSeconds < 1 || *req.AITimeoutSeconds > maxAITimeoutSeconds {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("AI timeout must be between 1 and %d seconds", maxAITimeoutSeconds), nil, "")
+		}
+		settings.AI.TimeoutSeconds = *req.AITimeoutSeconds
+	}
+	if req.AIToolCalling != nil {
+		settings.AI.ToolCalling = *req.AIToolCalling
+	}
+	if req.AIMaxToolCalls != nil {
+		if *req.AIMaxToolCalls < 1 || *req.AIMaxToolCalls > maxAIToolCalls {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("AI tool calls per reply must be between 1 and %d", maxAIToolCalls), nil, "")
+		}
+		settings.AI.MaxToolCalls = *req.AIMaxToolCalls
+	}
+	if req.AIEmbeddingModel != nil {
+		settings.AI.EmbeddingModel = strings.TrimSpace(*req.AIEmbeddingModel)
+	}
+	if req.AIKnowledgeChunks != nil {
+		if *req.AIKnowledgeChunks < 1 || *req.AIKnowledgeChunks > maxKnowledgeChunks {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Knowledge base passages per reply must be between 1 and %d", maxKnowledgeChunks), nil, "")
+		}
+		settings.AI.KnowledgeChunks = *req.AIKnowledgeChunks
+	}
 
 	// SLA Settings
 	if req.SLAEnabled != nil {
@@ -373,6 +580,106 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 		settings.ClientInactivity.AutoCloseMessage = *req.ClientAutoCloseMessage
 	}
 
+	// Opt-out Settings
+	if req.OptOutKeywords != nil {
+		keywords, err := normalizeOptOutKeywords(*req.OptOutKeywords)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		settings.OptOut.Keywords = keywords
+	}
+	if req.OptOutMessage != nil {
+		settings.OptOut.Message = *req.OptOutMessage
+	}
+	if req.OptInKeywords != nil {
+		keywords, err := normalizeOptOutKeywords(*req.OptInKeywords)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		settings.OptOut.OptInKeywords = keywords
+	}
+	if req.OptInMessage != nil {
+		settings.OptOut.OptInMessage = *req.OptInMessage
+	}
+	for _, kw := range optOutKeywordsOrDefault(settings.OptOut.OptInKeywords, models.DefaultOptInKeywords) {
+		if matchOptOutKeyword(kw, optOutKeywordsOrDefault(settings.OptOut.Keywords, models.DefaultOptOutKeywords)) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("%q cannot be both an opt-out and an opt-in keyword", kw), nil, "")
+		}
+	}
+
+	// Intent Settings
+	if req.IntentThreshold != nil {
+		if *req.IntentThreshold <= 0 || *req.IntentThreshold > 1 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Intent threshold must be greater than 0 and at most 1", nil, "")
+		}
+		settings.Intent.Threshold = *req.IntentThreshold
+	}
+	if req.IntentAIClassification != nil {
+		settings.Intent.AIClassification = *req.IntentAIClassification
+	}
+
+	// Language Settings
+	if req.DefaultLanguage != nil {
+		lang := strings.TrimSpace(*req.DefaultLanguage)
+		if !validLanguageCode.MatchString(lang) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid default language", nil, "")
+		}
+		settings.Language.DefaultLanguage = lang
+	}
+	if req.Languages != nil {
+		languages, err := normalizeLanguages(*req.Languages)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		settings.Language.Languages = languages
+	}
+	if req.DetectLanguage != nil {
+		settings.Language.DetectLanguage = *req.DetectLanguage
+	}
+	if req.Translations != nil {
+		translations, err := normalizeTranslations(*req.Translations, settingsTranslationFields, settingsTranslationButtonFields)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		settings.Translations = translations
+	}
+
+	// CSAT Settings
+	if req.CSATEnabled != nil {
+		settings.CSAT.Enabled = *req.CSATEnabled
+	}
+	if req.CSATDelayMinutes != nil {
+		if *req.CSATDelayMinutes < 0 || *req.CSATDelayMinutes > maxCSATDelayMinutes {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("CSAT delay must be between 0 and %d minutes", maxCSATDelayMinutes), nil, "")
+		}
+		settings.CSAT.DelayMinutes = *req.CSATDelayMinutes
+	}
+	if req.CSATSurveyType != nil {
+		if *req.CSATSurveyType != models.CSATSurveyTypeButtons && *req.CSATSurveyType != models.CSATSurveyTypeFlow {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "CSAT survey type must be buttons or flow", nil, "")
+		}
+		settings.CSAT.SurveyType = *req.CSATSurveyType
+	}
+	if req.CSATQuestion != nil {
+		settings.CSAT.Question = *req.CSATQuestion
+	}
+	if req.CSATThankYouMessage != nil {
+		settings.CSAT.ThankYouMessage = *req.CSATThankYouMessage
+	}
+	if req.CSATFlowID != nil {
+		settings.CSAT.FlowID = strings.TrimSpace(*req.CSATFlowID)
+	}
+	if req.CSATFlowCTA != nil {
+		cta := strings.TrimSpace(*req.CSATFlowCTA)
+		if utf8.RuneCountInString(cta) > maxCSATFlowCTALength {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("CSAT flow button text must be at most %d characters", maxCSATFlowCTALength), nil, "")
+		}
+		settings.CSAT.FlowCTA = cta
+	}
+	if settings.CSAT.Enabled && settings.CSAT.SurveyType == models.CSATSurveyTypeFlow && settings.CSAT.FlowID == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "A WhatsApp Flow is required for flow surveys", nil, "")
+	}
+
 	if err := a.DB.Save(&settings).Error; err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save settings", nil, "")
 	}
@@ -392,6 +699,9 @@ func (a *App) UpdateChatbotSettings(r *fastglue.Request) error {
 		if req.AssignToSameAgent != nil && !*req.AssignToSameAgent {
 			zeroOverrides["assign_to_same_agent"] = false
 		}
+		if req.CSATDelayMinutes != nil && *req.CSATDelayMinutes == 0 {
+			zeroOverrides["csat_delay_minutes"] = 0
+		}
 		if len(zeroOverrides) > 0 {
 			if err := a.DB.Model(&settings).Updates(zeroOverrides).Error; err != nil {
 				return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save settings", nil, "")
@@ -444,8 +754,10 @@ func (a *App) ListKeywordRules(r *fastglue.Request) error {
 			Name:            rule.Name,
 			Keywords:        rule.Keywords,
 			MatchType:       rule.MatchType,
+			FuzzyMaxEdits:   rule.FuzzyMaxEdits,
 			ResponseType:    rule.ResponseType,
 			ResponseContent: responseContent,
+			Translations:    rule.Translations,
 			Priority:        rule.Priority,
 			Enabled:         rule.IsEnabled,
 			CreatedAt:       rule.CreatedAt.Format(time.RFC3339),
@@ -471,8 +783,10 @@ func (a *App) CreateKeywordRule(r *fastglue.Request) error {
 		Name            string                 `json:"name"`
 		Keywords        []string               `json:"keywords"`
 		MatchType       models.MatchType       `json:"match_type"`
+		FuzzyMaxEdits   int                    `json:"fuzzy_max_edits"`
 		ResponseType    models.ResponseType    `json:"response_type"`
 		ResponseContent map[string]interface{} `json:"response_content"`
+		Translations    map[string]interface{} `json:"translations"`
 		Priority        int                    `json:"priority"`
 		Enabled         bool                   `json:"enabled"`
 	}
@@ -495,6 +809,16 @@ func (a *App) CreateKeywordRule(r *fastglue.Request) error {
 	if req.Name == "" {
 		req.Name = req.Keywords[0]
 	}
+	if err := validateKeywordMatch(req.MatchType, req.FuzzyMaxEdits); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+	translations, err := normalizeTranslations(req.Translations, keywordTranslationFields, translationButtonFields)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+	if err := normalizeContentSkills(req.ResponseContent); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
 
 	rule := models.KeywordRule{
 		BaseModel:       models.BaseModel{ID: uuid.New()},
@@ -502,8 +826,10 @@ func (a *App) CreateKeywordRule(r *fastglue.Request) error {
 		Name:            req.Name,
 		Keywords:        req.Keywords,
 		MatchType:       req.MatchType,
+		FuzzyMaxEdits:   req.FuzzyMaxEdits,
 		ResponseType:    req.ResponseType,
 		ResponseContent: models.JSONB(req.ResponseContent),
+		Translations:    translations,
 		Priority:        req.Priority,
 		IsEnabled:       req.Enabled,
 	}
@@ -544,8 +870,10 @@ func (a *App) GetKeywordRule(r *fastglue.Request) error {
 		Name:            rule.Name,
 		Keywords:        rule.Keywords,
 		MatchType:       rule.MatchType,
+		FuzzyMaxEdits:   rule.FuzzyMaxEdits,
 		ResponseType:    rule.ResponseType,
 		ResponseContent: responseContent,
+		Translations:    rule.Translations,
 		Priority:        rule.Priority,
 		Enabled:         rule.IsEnabled,
 		CreatedAt:       rule.CreatedAt.Format(time.RFC3339),
@@ -575,8 +903,10 @@ func (a *App) UpdateKeywordRule(r *fastglue.Request) error {
 		Name            *string                 `json:"name"`
 		Keywords        []string                `json:"keywords"`
 		MatchType       *models.MatchType       `json:"match_type"`
+		FuzzyMaxEdits   *int                    `json:"fuzzy_max_edits"`
 		ResponseType    *models.ResponseType    `json:"response_type"`
 		ResponseContent map[string]interface{}  `json:"response_content"`
+		Translations    *map[string]interface{} `json:"translations"`
 		Priority        *int                    `json:"priority"`
 		Enabled         *bool                   `json:"enabled"`
 	}
@@ -595,12 +925,28 @@ func (a *App) UpdateKeywordRule(r *fastglue.Request) error {
 	if req.MatchType != nil {
 		rule.MatchType = *req.MatchType
 	}
+	if req.FuzzyMaxEdits != nil {
+		rule.FuzzyMaxEdits = *req.FuzzyMaxEdits
+	}
+	if err := validateKeywordMatch(rule.MatchType, rule.FuzzyMaxEdits); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
 	if req.ResponseType != nil {
 		rule.ResponseType = *req.ResponseType
 	}
 	if req.ResponseContent != nil {
+		if err := normalizeContentSkills(req.ResponseContent); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
 		rule.ResponseContent = models.JSONB(req.ResponseContent)
 	}
+	if req.Translations != nil {
+		translations, err := normalizeTranslations(*req.Translations, keywordTranslationFields, translationButtonFields)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		rule.Translations = translations
+	}
 	if req.Priority != nil {
 		rule.Priority = *req.Priority
 	}
@@ -648,6 +994,74 @@ func (a *App) DeleteKeywordRule(r *fastglue.Request) error {
 	})
 }
 
+// KeywordMatchTestResponse shows which keyword rule would fire for a message
+type KeywordMatchTestResponse struct {
+	Matched      bool                  `json:"matched"`
+	RuleID       string                `json:"rule_id,omitempty"`
+	RuleName     string                `json:"rule_name,omitempty"`
+	MatchType    models.MatchType      `json:"match_type,omitempty"`
+	ResponseType models.ResponseType   `json:"response_type,omitempty"`
+	Keyword      string                `json:"keyword,omitempty"`
+	Score        float64               `json:"score"`
+	Method       string                `json:"method,omitempty"`
+	Intents      []IntentScoreResponse `json:"intents"`
+}
+
+// IntentScoreResponse is the confidence of an intent rule for a tested message
+type IntentScoreResponse struct {
+	RuleID   string  `json:"rule_id"`
+	RuleName string  `json:"rule_name"`
+	Phrase   string  `json:"phrase"`
+	Score    float64 `json:"score"`
+}
+
+// TestKeywordMatch shows which keyword rule would fire for a message and its score,
+// along with how each intent rule scored
+func (a *App) TestKeywordMatch(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	var req struct {
+		Message         string `json:"message"`
+		WhatsAppAccount string `json:"whatsapp_account"`
+	}
+	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Message is required", nil, "")
+	}
+
+	response := KeywordMatchTestResponse{Intents: []IntentScoreResponse{}}
+	if match := a.findKeywordMatch(orgID, req.WhatsAppAccount, req.Message); match != nil {
+		response.Matched = true
+		response.RuleID = match.Rule.ID.String()
+		response.RuleName = match.Rule.Name
+		response.MatchType = match.Rule.MatchType
+		response.ResponseType = match.Rule.ResponseType
+		response.Keyword = match.Keyword
+		response.Score = match.Score
+		response.Method = match.Method
+	}
+
+	rules, err := a.getKeywordRulesCached(orgID, req.WhatsAppAccount)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to fetch keyword rules", nil, "")
+	}
+	for _, score := range scoreIntents(rules, req.Message) {
+		response.Intents = append(response.Intents, IntentScoreResponse{
+			RuleID:   score.Rule.ID.String(),
+			RuleName: score.Rule.Name,
+			Phrase:   score.Phrase,
+			Score:    score.Score,
+		})
+	}
+
+	return r.SendEnvelope(response)
+}
+
 // ListChatbotFlows lists all chatbot flows
 func (a *App) ListChatbotFlows(r *fastglue.Request) error {
 	orgID, userID, err := a.getOrgAndUserID(r)
@@ -682,13 +1096,15 @@ func (a *App) ListChatbotFlows(r *fastglue.Request) error {
 	response := make([]ChatbotFlowResponse, len(flows))
 	for i, flow := range flows {
 		response[i] = ChatbotFlowResponse{
-			ID:              flow.ID.String(),
-			Name:            flow.Name,
-			Description:     flow.Description,
-			TriggerKeywords: flow.TriggerKeywords,
-			Enabled:         flow.IsEnabled,
-			StepsCount:      len(flow.Steps),
-			CreatedAt:       flow.CreatedAt.Format(time.RFC3339),
+			ID:               flow.ID.String(),
+			Name:             flow.Name,
+			Description:      flow.Description,
+			TriggerKeywords:  flow.TriggerKeywords,
+			Enabled:          flow.IsEnabled,
+			StepsCount:       len(flow.Steps),
+			PublishedVersion: flow.PublishedVersion,
+			HasDraftChanges:  flow.HasDraftChanges,
+			CreatedAt:        flow.CreatedAt.Format(time.RFC3339),
 		}
 	}
 
@@ -711,6 +1127,9 @@ type FlowStepRequest struct {
 	ApiConfig       map[string]interface{}   `json:"api_config"`
 	Buttons         []map[string]interface{} `json:"buttons"`
 	TransferConfig  map[string]interface{}   `json:"transfer_config"`
+	WaitConfig      map[string]interface{}   `json:"wait_config"`
+	Actions         []map[string]interface{} `json:"actions"`
+	AIConfig        map[string]interface{}   `json:"ai_config"`
 	ValidationRegex string                   `json:"validation_regex"`
 	ValidationError string                   `json:"validation_error"`
 	StoreAs         string                   `json:"store_as"`
@@ -719,6 +1138,50 @@ type FlowStepRequest struct {
 	SkipCondition   string                   `json:"skip_condition"`
 	RetryOnInvalid  bool                     `json:"retry_on_invalid"`
 	MaxRetries      int                      `json:"max_retries"`
+	Translations    map[string]interface{}   `json:"translations"`
+}
+
+// validateFlowSteps checks step configuration that would otherwise only fail when the step runs
+func validateFlowSteps(steps []FlowStepRequest) error {
+	now := time.Now()
+	for _, stepReq := range steps {
+		if _, err := normalizeTranslations(stepReq.Translations, stepTranslationFields, translationButtonFields); err != nil {
+			return fmt.Errorf("step %q: %w", stepReq.StepName, err)
+		}
+		switch stepReq.MessageType {
+		case models.FlowStepTypeWait:
+			// Times built from session variables can only be checked at runtime
+			if until, _ := stepReq.WaitConfig["until"].(string); strings.Contains(until, "{{") {
+				continue
+			}
+			step := models.ChatbotFlowStep{WaitConfig: models.JSONB(stepReq.WaitConfig)}
+			if _, err := stepWaitUntil(&step, nil, now); err != nil {
+				return fmt.Errorf("step %q: %w", stepReq.StepName, err)
+			}
+		case models.FlowStepTypeAction:
+			if len(stepReq.Actions) == 0 {
+				return fmt.Errorf("step %q: action step needs at least one action", stepReq.StepName)
+			}
+			for i, action := range stepReq.Actions {
+				if err := validateFlowAction(action); err != nil {
+					return fmt.Errorf("step %q action %d: %w", stepReq.StepName, i+1, err)
+				}
+			}
+		case models.FlowStepTypeTransfer:
+			// Skills may use session variables, so only their shape is checked
+			if _, err := skillList(stepReq.TransferConfig["skills"]); err != nil {
+				return fmt.Errorf("step %q: %w", stepReq.StepName, err)
+			}
+		case models.FlowStepTypeAI:
+			if stepReq.InputType == models.InputTypeNone {
+				return fmt.Errorf("step %q: ai step needs the contact's reply, input_type can't be none", stepReq.StepName)
+			}
+			if err := validateAIStepConfig(stepReq.AIConfig); err != nil {
+				return fmt.Errorf("step %q: %w", stepReq.StepName, err)
+			}
+		}
+	}
+	return nil
 }
 
 // CreateChatbotFlow creates a new chatbot flow
@@ -741,6 +1204,7 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 		OnCompleteAction  string                 `json:"on_complete_action"`
 		CompletionConfig  map[string]interface{} `json:"completion_config"`
 		PanelConfig       map[string]interface{} `json:"panel_config"`
+		Translations      map[string]interface{} `json:"translations"`
 		Enabled           bool                   `json:"enabled"`
 		Steps             []FlowStepRequest      `json:"steps"`
 	}
@@ -752,6 +1216,13 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 	if req.Name == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Name is required", nil, "")
 	}
+	if err := validateFlowSteps(req.Steps); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+	translations, err := normalizeTranslations(req.Translations, flowTranslationFields, nil)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
 
 	// Use transaction for flow + steps
 	tx := a.DB.Begin()
@@ -768,6 +1239,7 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 		OnCompleteAction:  req.OnCompleteAction,
 		CompletionConfig:  models.JSONB(req.CompletionConfig),
 		PanelConfig:       models.JSONB(req.PanelConfig),
+		Translations:      translations,
 		IsEnabled:         req.Enabled,
 	}
 
@@ -783,6 +1255,10 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 		for _, btn := range stepReq.Buttons {
 			buttons = append(buttons, btn)
 		}
+		var actions models.JSONBArray
+		for _, action := range stepReq.Actions {
+			actions = append(actions, action)
+		}
 
 		step := models.ChatbotFlowStep{
 			BaseModel:       models.BaseModel{ID: uuid.New()},
@@ -796,6 +1272,9 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 			ApiConfig:       models.JSONB(stepReq.ApiConfig),
 			Buttons:         buttons,
 			TransferConfig:  models.JSONB(stepReq.TransferConfig),
+			WaitConfig:      models.JSONB(stepReq.WaitConfig),
+			Actions:         actions,
+			AIConfig:        models.JSONB(stepReq.AIConfig),
 			ValidationRegex: stepReq.ValidationRegex,
 			ValidationError: stepReq.ValidationError,
 			StoreAs:         stepReq.StoreAs,
@@ -804,6 +1283,7 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 			SkipCondition:   stepReq.SkipCondition,
 			RetryOnInvalid:  stepReq.RetryOnInvalid,
 			MaxRetries:      stepReq.MaxRetries,
+			Translations:    models.JSONB(stepReq.Translations),
 		}
 		if step.MessageType == "" {
 			step.MessageType = models.FlowStepTypeText
@@ -817,6 +1297,12 @@ func (a *App) CreateChatbotFlow(r *fastglue.Request) error {
 		}
 	}
 
+	// New flows go live as version 1
+	if _, err := publishChatbotFlow(tx, orgID, flowID, "Initial version", &userID); err != nil {
+		tx.Rollback()
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to publish flow", nil, "")
+	}
+
 	tx.Commit()
 
 	// Invalidate cache
@@ -886,6 +1372,7 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 		OnCompleteAction  *string                `json:"on_complete_action"`
 		CompletionConfig  map[string]interface{} `json:"completion_config"`
 		PanelConfig       map[string]interface{} `json:"panel_config"`
+		Translations      *map[string]interface{} `json:"translations"`
 		Enabled           *bool                  `json:"enabled"`
 		Steps             []FlowStepRequest      `json:"steps"`
 	}
@@ -893,16 +1380,43 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
 	}
+	if err := validateFlowSteps(req.Steps); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+	if req.Translations != nil {
+		translations, err := normalizeTranslations(*req.Translations, flowTranslationFields, nil)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		flow.Translations = translations
+	}
 
 	tx := a.DB.Begin()
 
+	// Everything except enabling/disabling edits the draft, which goes live on publish
+	editsDraft := req.Name != nil || req.Description != nil || req.TriggerKeywords != nil ||
+		req.InitialMessage != nil || req.CompletionMessage != nil || req.OnCompleteAction != nil ||
+		req.CompletionConfig != nil || req.PanelConfig != nil || req.Translations != nil || len(req.Steps) > 0
+	if editsDraft {
+		// Flows created before versioning run their draft rows; keep them live as version 1
+		if flow.PublishedVersion == 0 {
+			version, err := publishChatbotFlow(tx, orgID, id, "Initial version", &userID)
+			if err != nil {
+				tx.Rollback()
+				return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update flow", nil, "")
+			}
+			flow.PublishedVersion = version.Version
+		}
+		flow.HasDraftChanges = true
+	}
+
 	if req.Name != nil {
 		flow.Name = *req.Name
 	}
 	if req.Description != nil {
 		flow.Description = *req.Description
 	}
-	if len(req.TriggerKeywords) > 0 {
+	if req.TriggerKeywords != nil {
 		flow.TriggerKeywords = req.TriggerKeywords
 	}
 	if req.InitialMessage != nil {
@@ -944,6 +1458,10 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 			for _, btn := range stepReq.Buttons {
 				buttons = append(buttons, btn)
 			}
+			var actions models.JSONBArray
+			for _, action := range stepReq.Actions {
+				actions = append(actions, action)
+			}
 
 			step := models.ChatbotFlowStep{
 				BaseModel:       models.BaseModel{ID: uuid.New()},
@@ -957,6 +1475,9 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 				ApiConfig:       models.JSONB(stepReq.ApiConfig),
 				Buttons:         buttons,
 				TransferConfig:  models.JSONB(stepReq.TransferConfig),
+				WaitConfig:      models.JSONB(stepReq.WaitConfig),
+				Actions:         actions,
+				AIConfig:        models.JSONB(stepReq.AIConfig),
 				ValidationRegex: stepReq.ValidationRegex,
 				ValidationError: stepReq.ValidationError,
 				StoreAs:         stepReq.StoreAs,
@@ -965,6 +1486,7 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 				SkipCondition:   stepReq.SkipCondition,
 				RetryOnInvalid:  stepReq.RetryOnInvalid,
 				MaxRetries:      stepReq.MaxRetries,
+				Translations:    models.JSONB(stepReq.Translations),
 			}
 			if step.MessageType == "" {
 				step.MessageType = models.FlowStepTypeText
@@ -985,7 +1507,9 @@ func (a *App) UpdateChatbotFlow(r *fastglue.Request) error {
 	a.InvalidateChatbotFlowsCache(orgID)
 
 	return r.SendEnvelope(map[string]interface{}{
-		"message": "Flow updated successfully",
+		"message":           "Flow updated successfully",
+		"published_version": flow.PublishedVersion,
+		"has_draft_changes": flow.HasDraftChanges,
 	})
 }
 
@@ -1008,11 +1532,15 @@ func (a *App) DeleteChatbotFlow(r *fastglue.Request) error {
 	// Delete flow and steps in transaction
 	tx := a.DB.Begin()
 
-	// Delete steps first
+	// Delete steps and published versions first
 	if err := tx.Where("flow_id = ?", id).Delete(&models.ChatbotFlowStep{}).Error; err != nil {
 		tx.Rollback()
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete flow steps", nil, "")
 	}
+	if err := tx.Where("flow_id = ? AND organization_id = ?", id, orgID).Delete(&models.ChatbotFlowVersion{}).Error; err != nil {
+		tx.Rollback()
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete flow versions", nil, "")
+	}
 
 	// Delete flow
 	result := tx.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.ChatbotFlow{})
@@ -1070,6 +1598,8 @@ func (a *App) ListAIContexts(r *fastglue.Request) error {
 			ContextType:     ctx.ContextType,
 			TriggerKeywords: ctx.TriggerKeywords,
 			StaticContent:   ctx.StaticContent,
+			ApiConfig:       ctx.ApiConfig,
+			ToolParameters:  ctx.ToolParameters,
 			Enabled:         ctx.IsEnabled,
 			Priority:        ctx.Priority,
 			CreatedAt:       ctx.CreatedAt.Format(time.RFC3339),
@@ -1096,6 +1626,8 @@ func (a *App) CreateAIContext(r *fastglue.Request) error {
 		ContextType     models.ContextType `json:"context_type"`
 		TriggerKeywords []string          `json:"trigger_keywords"`
 		StaticContent   string            `json:"static_content"`
+		ApiConfig       models.JSONB      `json:"api_config"`
+		ToolParameters  models.JSONB      `json:"tool_parameters"`
 		Priority        int               `json:"priority"`
 		Enabled         bool              `json:"enabled"`
 	}
@@ -1110,6 +1642,9 @@ func (a *App) CreateAIContext(r *fastglue.Request) error {
 	if req.ContextType == "" {
 		req.ContextType = models.ContextTypeStatic
 	}
+	if err := validateAIToolParameters(req.ToolParameters); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid tool parameters: "+err.Error(), nil, "")
+	}
 
 	ctx := models.AIContext{
 		BaseModel:       models.BaseModel{ID: uuid.New()},
@@ -1118,6 +1653,8 @@ func (a *App) CreateAIContext(r *fastglue.Request) error {
 		ContextType:     req.ContextType,
 		TriggerKeywords: req.TriggerKeywords,
 		StaticContent:   req.StaticContent,
+		ApiConfig:       req.ApiConfig,
+		ToolParameters:  req.ToolParameters,
 		Priority:        req.Priority,
 		IsEnabled:       req.Enabled,
 	}
@@ -1177,6 +1714,8 @@ func (a *App) UpdateAIContext(r *fastglue.Request) error {
 		ContextType     *models.ContextType `json:"context_type"`
 		TriggerKeywords []string            `json:"trigger_keywords"`
 		StaticContent   *string             `json:"static_content"`
+		ApiConfig       *models.JSONB       `json:"api_config"`
+		ToolParameters  *models.JSONB       `json:"tool_parameters"`
 		Priority        *int                `json:"priority"`
 		Enabled         *bool               `json:"enabled"`
 	}
@@ -1197,6 +1736,15 @@ func (a *App) UpdateAIContext(r *fastglue.Request) error {
 	if req.StaticContent != nil {
 		aiCtx.StaticContent = *req.StaticContent
 	}
+	if req.ApiConfig != nil {
+		aiCtx.ApiConfig = *req.ApiConfig
+	}
+	if req.ToolParameters != nil {
+		if err := validateAIToolParameters(*req.ToolParameters); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid tool parameters: "+err.Error(), nil, "")
+		}
+		aiCtx.ToolParameters = *req.ToolParameters
+	}
 	if req.Priority != nil {
 		aiCtx.Priority = *req.Priority
 	}
@@ -1311,7 +1859,7 @@ func (a *App) getChatbotStats(orgID uuid.UUID) ChatbotStatsResponse {
 	// Messages handled (from chatbot_session_messages)
 	a.DB.Model(&models.ChatbotSessionMessage{}).
 		Joins("JOIN chatbot_sessions ON chatbot_sessions.id = chatbot_session_messages.session_id").
-		Where("chatbot_sessions.organization_id = ?", orgID).
+		Where("chatbot_sessions.organization_id = ? AND chatbot_session_messages.direction <> ?", orgID, models.DirectionInternal).
 		Count(&stats.MessagesHandled)
 
 	// Agent transfers
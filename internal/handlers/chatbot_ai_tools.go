@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/llm"
+)
+
+// AI tool calling limits
+const (
+	defaultAIMaxToolCalls = 3
+	maxAIToolCalls        = 10   // upper bound of the ai_max_tool_calls setting
+	maxAIToolResultLength = 4000 // bytes of a tool result sent to the model
+	maxAIToolNameLength   = 64   // longest tool name providers accept
+)
+
+// aiTool is a tool offered to the model with the function that runs it
+type aiTool struct {
+	llm.Tool
+	run func(args map[string]interface{}) (string, error)
+}
+
+var aiToolNameInvalidChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// aiToolName turns a context or action name into a tool name, e.g. "Order Status" becomes order_status
+func aiToolName(name string) string {
+	toolName := strings.Trim(aiToolNameInvalidChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if len(toolName) > maxAIToolNameLength {
+		toolName = toolName[:maxAIToolNameLength]
+	}
+	if toolName == "" {
+		toolName = "tool"
+	}
+	return toolName
+}
+
+// uniqueAIToolName returns the tool name of name that isn't taken yet, e.g. when an account
+// context shadows a global one. Numbered names are shortened to keep within the length limit.
+func uniqueAIToolName(name string, taken map[string]bool) string {
+	base := aiToolName(name)
+	toolName := base
+	for i := 2; taken[toolName]; i++ {
+		suffix := fmt.Sprintf("_%d", i)
+		toolName = base[:min(len(base), maxAIToolNameLength-len(suffix))] + suffix
+	}
+	return toolName
+}
+
+// validateAIToolParameters checks the JSON schema of a tool's arguments. An empty schema means no arguments.
+func validateAIToolParameters(schema map[string]interface{}) error {
+	if len(schema) == 0 {
+		return nil
+	}
+	if _, ok := schema["properties"].(map[string]interface{}); !ok {
+		return fmt.Errorf("schema needs a properties object")
+	}
+	return validateAISchema(schema)
+}
+
+// parseAIToolArguments decodes the arguments of a tool call and checks them against the tool's schema
+func parseAIToolArguments(arguments string, schema map[string]interface{}) (map[string]interface{}, error) {
+	raw := map[string]interface{}{}
+	if strings.TrimSpace(arguments) != "" {
+		if err := json.Unmarshal([]byte(arguments), &raw); err != nil {
+			return nil, fmt.Errorf("arguments are not a JSON object")
+		}
+	}
+	return checkAISchemaFields(raw, schema)
+}
+
+// runAIToolLoop asks the model for a reply, running the tools it calls and sending their results
+// back until it answers. At most maxCalls tools run per reply; later calls get an error result
+// asking the model to answer, and a model that still calls tools after that fails the reply.
+func runAIToolLoop(ctx context.Context, caller llm.ToolCaller, req llm.Request, tools []aiTool, maxCalls int, timeout time.Duration) (string, error) {
+	defs := make([]llm.Tool, len(tools))
+	byName := make(map[string]aiTool, len(tools))
+	for i, tool := range tools {
+		defs[i] = tool.Tool
+		byName[tool.Name] = tool
+	}
+	req.Messages = append([]llm.Message(nil), req.Messages...)
+
+	calls := 0
+	for {
+		limitReached := calls >= maxCalls
+
+		reqCtx, cancel := context.WithTimeout(ctx, timeout)
+		resp, err := caller.CompleteWithTools(reqCtx, req, defs)
+		cancel()
+		if err != nil {
+			return "", err
+		}
+		if len(resp.ToolCalls) == 0 {
+			return resp.Content, nil
+		}
+		if limitReached {
+			return "", fmt.Errorf("model kept calling tools after the limit of %d calls", maxCalls)
+		}
+
+		req.Messages = append(req.Messages, llm.Message{Role: llm.RoleAssistant, Content: resp.Content, ToolCalls: resp.ToolCalls})
+		for _, call := range resp.ToolCalls {
+			var result string
+			if calls >= maxCalls {
+				result = "Error: tool call limit reached. Answer with the information you have."
+			} else {
+				calls++
+				result = runAIToolCall(byName, call)
+			}
+			req.Messages = append(req.Messages, llm.Message{Role: llm.RoleTool, Content: result, ToolCallID: call.ID, ToolName: call.Name})
+		}
+	}
+}
+
+// runAIToolCall runs a tool call, returning its result or the error for the model to see
+func runAIToolCall(tools map[string]aiTool, call llm.ToolCall) string {
+	tool, ok := tools[call.Name]
+	if !ok {
+		return fmt.Sprintf("Error: unknown tool %q", call.Name)
+	}
+	args, err := parseAIToolArguments(call.Arguments, tool.Parameters)
+	if err != nil {
+		return "Error: invalid arguments: " + err.Error()
+	}
+	result, err := tool.run(args)
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	return truncateAIToolResult(result)
+}
+
+// truncateAIToolResult cuts a tool result to maxAIToolResultLength bytes, keeping
+// multi-byte characters whole so the model isn't sent invalid UTF-8
+func truncateAIToolResult(result string) string {
+	if len(result) <= maxAIToolResultLength {
+		return result
+	}
+	cut := maxAIToolResultLength
+	for cut > 0 && !utf8.RuneStart(result[cut]) {
+		cut--
+	}
+	return result[:cut] + "…(truncated)"
+}
+
+// completeAIWithTools generates a reply where the model can call the organization's API contexts
+// and AI custom actions as tools. Static contexts and knowledge base passages are still part
+// of the system prompt.
+func (a *App) completeAIWithTools(settings *models.ChatbotSettings, session *models.ChatbotSession, userMessage string) (string, error) {
+	provider, err := a.newAIProvider(settings)
+	if err != nil {
+		return "", err
+	}
+
+	contextData := a.buildAIContext(settings, session, userMessage, false)
+	req := a.aiRequest(settings, session, userMessage, contextData)
+	tools := a.aiTools(settings, session, userMessage)
+
+	caller, ok := provider.(llm.ToolCaller)
+	if !ok || len(tools) == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), aiRequestTimeout(settings))
+		defer cancel()
+		return provider.Complete(ctx, req)
+	}
+
+	maxCalls := settings.AI.MaxToolCalls
+	if maxCalls <= 0 {
+		maxCalls = defaultAIMaxToolCalls
+	}
+	return runAIToolLoop(context.Background(), caller, req, tools, maxCalls, aiRequestTimeout(settings))
+}
+
+// aiTools returns the tools of the organization: its enabled API contexts and the active custom
+// actions marked as AI tools
+func (a *App) aiTools(settings *models.ChatbotSettings, session *models.ChatbotSession, userMessage string) []aiTool {
+	whatsAppAccount := ""
+	if session != nil {
+		whatsAppAccount = session.WhatsAppAccount
+	}
+
+	var tools []aiTool
+	names := map[string]bool{}
+	add := func(name, description string, parameters models.JSONB, run func(args map[string]interface{}) (string, error)) {
+		toolName := uniqueAIToolName(name, names)
+		names[toolName] = true
+
+		tools = append(tools, aiTool{
+			Tool: llm.Tool{Name: toolName, Description: description, Parameters: parameters},
+			run: func(args map[string]interface{}) (string, error) {
+				result, err := run(args)
+				if err != nil {
+					a.Log.Error("AI tool call failed", "tool", toolName, "error", err)
+				} else {
+					a.Log.Info("AI tool called", "tool", toolName)
+				}
+				return result, err
+			},
+		})
+	}
+
+	contexts, err := a.getAIContextsCached(settings.OrganizationID, whatsAppAccount)
+	if err != nil {
+		a.Log.Error("Failed to load AI contexts for tools", "error", err)
+	}
+	for _, aiCtx := range contexts {
+		if aiCtx.ContextType != models.ContextTypeAPI {
+			continue
+		}
+		apiConfig := aiCtx.ApiConfig
+		description := aiCtx.StaticContent
+		if description == "" {
+			description = "Fetches " + aiCtx.Name
+		}
+		add(aiCtx.Name, description, aiCtx.ToolParameters, func(args map[string]interface{}) (string, error) {
+			return a.fetchAPIContext(apiConfig, session, userMessage, args)
+		})
+	}
+
+	var actions []models.CustomAction
+	if err := a.DB.Where("organization_id = ? AND is_active = ? AND ai_tool = ?", settings.OrganizationID, true, true).
+		Order("display_order ASC").
+		Find(&actions).Error; err != nil {
+		a.Log.Error("Failed to load AI tool actions", "error", err)
+	}
+	for _, action := range actions {
+		add(action.Name, action.AIToolDescription, action.AIToolParameters, func(args map[string]interface{}) (string, error) {
+			return a.runCustomActionTool(action, session, args)
+		})
+	}
+
+	return tools
+}
+
+// runCustomActionTool executes a custom action called by the AI. The action's templates see the
+// arguments as {{args.name}}, next to the contact and organization.
+func (a *App) runCustomActionTool(action models.CustomAction, session *models.ChatbotSession, args map[string]interface{}) (string, error) {
+	var contact models.Contact
+	if session != nil {
+		a.DB.Where("id = ? AND organization_id = ?", session.ContactID, action.OrganizationID).First(&contact)
+	}
+
+	result, err := a.runUnattendedCustomAction(action, contact, map[string]interface{}{"args": args})
+	if err != nil {
+		return "", err
+	}
+
+	out, err := json.Marshal(map[string]interface{}{
+		"success": result.Success,
+		"message": result.Message,
+		"data":    result.Data,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
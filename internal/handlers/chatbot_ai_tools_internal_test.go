@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeToolCaller replies with its responses in order and records the requests
+type fakeToolCaller struct {
+	responses []*llm.Response
+	requests  []llm.Request
+}
+
+func (f *fakeToolCaller) Complete(ctx context.Context, req llm.Request) (string, error) {
+	return "", errors.New("not used")
+}
+
+func (f *fakeToolCaller) CompleteWithTools(ctx context.Context, req llm.Request, tools []llm.Tool) (*llm.Response, error) {
+	f.requests = append(f.requests, req)
+	resp := f.responses[0]
+	if len(f.responses) > 1 {
+		f.responses = f.responses[1:]
+	}
+	return resp, nil
+}
+
+func orderStatusTool(calls *[]map[string]interface{}) aiTool {
+	return aiTool{
+		Tool: llm.Tool{
+			Name: "order_status",
+			Parameters: map[string]interface{}{
+				"properties": map[string]interface{}{"order_id": map[string]interface{}{"type": "string"}},
+				"required":   []interface{}{"order_id"},
+			},
+		},
+		run: func(args map[string]interface{}) (string, error) {
+			*calls = append(*calls, args)
+			if args["order_id"] == "404" {
+				return "", errors.New("API returned status 404")
+			}
+			return `{"status": "shipped"}`, nil
+		},
+	}
+}
+
+func TestAIToolName(t *testing.T) {
+	assert.Equal(t, "order_status", aiToolName("Order Status"))
+	assert.Equal(t, "look_up_order_1234", aiToolName("  Look up order #1234!"))
+	assert.Equal(t, "tool", aiToolName("???"))
+	assert.Len(t, aiToolName(strings.Repeat("a", 100)), 64)
+}
+
+func TestUniqueAIToolName(t *testing.T) {
+	taken := map[string]bool{"order_status": true, "order_status_2": true}
+	assert.Equal(t, "refund", uniqueAIToolName("Refund", taken))
+	assert.Equal(t, "order_status_3", uniqueAIToolName("Order Status", taken))
+
+	long := strings.Repeat("a", 100)
+	taken = map[string]bool{aiToolName(long): true}
+	name := uniqueAIToolName(long, taken)
+	assert.Equal(t, strings.Repeat("a", 62)+"_2", name)
+	assert.Len(t, name, maxAIToolNameLength)
+}
+
+func TestValidateAIToolParameters(t *testing.T) {
+	assert.NoError(t, validateAIToolParameters(nil))
+	assert.NoError(t, validateAIToolParameters(map[string]interface{}{
+		"properties": map[string]interface{}{"order_id": map[string]interface{}{"type": "string"}},
+	}))
+	assert.Error(t, validateAIToolParameters(map[string]interface{}{"type": "object"}))
+	assert.Error(t, validateAIToolParameters(map[string]interface{}{
+		"properties": map[string]interface{}{"order_id": map[string]interface{}{"type": "uuid"}},
+	}))
+}
+
+func TestTruncateAIToolResult(t *testing.T) {
+	assert.Equal(t, `{"status": "shipped"}`, truncateAIToolResult(`{"status": "shipped"}`))
+
+	// "é" is two bytes, so the limit falls inside one
+	result := truncateAIToolResult("a" + strings.Repeat("é", maxAIToolResultLength))
+	assert.True(t, utf8.ValidString(result))
+	assert.Equal(t, "a"+strings.Repeat("é", maxAIToolResultLength/2-1)+"…(truncated)", result)
+}
+
+func TestRunAIToolLoop(t *testing.T) {
+	req := llm.Request{Messages: []llm.Message{{Role: llm.RoleUser, Content: "where is my order #1234"}}}
+
+	t.Run("runs tool calls and returns the answer", func(t *testing.T) {
+		var calls []map[string]interface{}
+		caller := &fakeToolCaller{responses: []*llm.Response{
+			{ToolCalls: []llm.ToolCall{
+				{ID: "1", Name: "order_status", Arguments: `{"order_id": "1234"}`},
+				{ID: "2", Name: "order_status", Arguments: `{"order": "1234"}`},
+				{ID: "3", Name: "refund", Arguments: `{}`},
+			}},
+			{Content: "Your order has shipped."},
+		}}
+
+		reply, err := runAIToolLoop(context.Background(), caller, req, []aiTool{orderStatusTool(&calls)}, 5, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "Your order has shipped.", reply)
+		assert.Equal(t, []map[string]interface{}{{"order_id": "1234"}}, calls)
+
+		require.Len(t, caller.requests, 2)
+		messages := caller.requests[1].Messages
+		require.Len(t, messages, 5)
+		assert.Equal(t, llm.RoleAssistant, messages[1].Role)
+		assert.Equal(t, llm.Message{Role: llm.RoleTool, Content: `{"status": "shipped"}`, ToolCallID: "1", ToolName: "order_status"}, messages[2])
+		assert.Equal(t, `Error: invalid arguments: missing required field "order_id"`, messages[3].Content)
+		assert.Equal(t, `Error: unknown tool "refund"`, messages[4].Content)
+		assert.Len(t, req.Messages, 1, "the caller's request is not modified")
+	})
+
+	t.Run("tool errors are sent to the model", func(t *testing.T) {
+		var calls []map[string]interface{}
+		caller := &fakeToolCaller{responses: []*llm.Response{
+			{ToolCalls: []llm.ToolCall{{ID: "1", Name: "order_status", Arguments: `{"order_id": "404"}`}}},
+			{Content: "I couldn't find that order."},
+		}}
+
+		reply, err := runAIToolLoop(context.Background(), caller, req, []aiTool{orderStatusTool(&calls)}, 5, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "I couldn't find that order.", reply)
+		assert.Equal(t, "Error: API returned status 404", caller.requests[1].Messages[2].Content)
+	})
+
+	t.Run("stops calling tools at the limit", func(t *testing.T) {
+		var calls []map[string]interface{}
+		caller := &fakeToolCaller{responses: []*llm.Response{
+			{ToolCalls: []llm.ToolCall{{ID: "1", Name: "order_status", Arguments: `{"order_id": "1"}`}}},
+			{ToolCalls: []llm.ToolCall{
+				{ID: "2", Name: "order_status", Arguments: `{"order_id": "2"}`},
+				{ID: "3", Name: "order_status", Arguments: `{"order_id": "3"}`},
+			}},
+			{ToolCalls: []llm.ToolCall{{ID: "4", Name: "order_status", Arguments: `{"order_id": "4"}`}}},
+		}}
+
+		_, err := runAIToolLoop(context.Background(), caller, req, []aiTool{orderStatusTool(&calls)}, 2, time.Second)
+		assert.EqualError(t, err, "model kept calling tools after the limit of 2 calls")
+		assert.Len(t, calls, 2)
+		require.Len(t, caller.requests, 3)
+		last := caller.requests[2].Messages
+		assert.Contains(t, last[len(last)-1].Content, "tool call limit reached")
+	})
+}
+
+func TestFetchAPIContext_ToolArguments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/orders/12 34?x=1", r.URL.Path)
+		assert.Equal(t, "", r.URL.RawQuery, "arguments can't add a query string")
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"order": "12 34?x=1", "qty": "2", "phone": "919999999999"}`, string(body))
+		_, _ = w.Write([]byte(`{"status": "shipped"}`))
+	}))
+	defer server.Close()
+
+	app := &App{HTTPClient: server.Client()}
+	session := &models.ChatbotSession{PhoneNumber: "919999999999", SessionData: models.JSONB{}}
+	apiConfig := models.JSONB{
+		"url":    server.URL + "/orders/{{order_id}}",
+		"method": "POST",
+		"body":   `{"order": "{{order_id}}", "qty": "{{qty}}", "phone": "{{phone_number}}"}`,
+	}
+
+	result, err := app.fetchAPIContext(apiConfig, session, "where is my order", map[string]interface{}{"order_id": "12 34?x=1", "qty": float64(2)})
+	require.NoError(t, err)
+	assert.Equal(t, `{"status": "shipped"}`, result)
+	assert.Empty(t, session.SessionData["order_id"], "arguments are not stored in the session")
+}
+
+func TestFetchAPIContext_EscapesToolArguments(t *testing.T) {
+	injected := `1", "refund": "all`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]string{"order": injected}, body, "arguments can't add fields to the body")
+		assert.Equal(t, "1X-Admin: true", r.Header.Get("X-Order"), "arguments can't add headers")
+		assert.Empty(t, r.Header.Get("X-Admin"))
+		_, _ = w.Write([]byte(`{"status": "shipped"}`))
+	}))
+	defer server.Close()
+
+	app := &App{HTTPClient: server.Client()}
+	apiConfig := models.JSONB{
+		"url":     server.URL + "/orders",
+		"method":  "POST",
+		"body":    `{"order": "{{order_id}}"}`,
+		"headers": map[string]interface{}{"X-Order": "{{ref}}"},
+	}
+
+	_, err := app.fetchAPIContext(apiConfig, &models.ChatbotSession{}, "", map[string]interface{}{
+		"order_id": injected,
+		"ref":      "1\r\nX-Admin: true",
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// validateFlowAction checks that an action step entry has a known type and the fields it needs
+func validateFlowAction(action map[string]interface{}) error {
+	actionType, _ := action["type"].(string)
+	switch models.FlowActionType(actionType) {
+	case models.FlowActionAddTag, models.FlowActionRemoveTag:
+		if flowActionString(action, "tag") == "" {
+			return fmt.Errorf("%s needs a tag", actionType)
+		}
+	case models.FlowActionSetMetadata:
+		if flowActionString(action, "key") == "" {
+			return fmt.Errorf("set_metadata needs a key")
+		}
+	case models.FlowActionAssignUser:
+		if flowActionString(action, "user_id") == "" && flowActionString(action, "user_email") == "" {
+			return fmt.Errorf("assign_user needs a user_id or user_email")
+		}
+	case models.FlowActionCustomAction:
+		id := flowActionString(action, "custom_action_id")
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("custom_action needs a valid custom_action_id")
+		}
+	case models.FlowActionSetLanguage:
+		if flowActionString(action, "language") == "" {
+			return fmt.Errorf("set_language needs a language")
+		}
+	default:
+		return fmt.Errorf("unknown action type %q", actionType)
+	}
+	return nil
+}
+
+// flowActionString returns a trimmed string field of an action entry
+func flowActionString(action map[string]interface{}, key string) string {
+	value, _ := action[key].(string)
+	return strings.TrimSpace(value)
+}
+
+// flowActionValue returns the value of a set_metadata action, with {{variables}} replaced in strings
+func flowActionValue(action map[string]interface{}, data models.JSONB) interface{} {
+	if value, ok := action["value"].(string); ok {
+		return processTemplate(value, data)
+	}
+	return action["value"]
+}
+
+// describeFlowAction renders an action for the session log and the simulator
+func describeFlowAction(action map[string]interface{}, data models.JSONB) string {
+	actionType, _ := action["type"].(string)
+	switch models.FlowActionType(actionType) {
+	case models.FlowActionAddTag, models.FlowActionRemoveTag:
+		return fmt.Sprintf("%s %q", actionType, processTemplate(flowActionString(action, "tag"), data))
+	case models.FlowActionSetMetadata:
+		return fmt.Sprintf("set_metadata %s = %v", processTemplate(flowActionString(action, "key"), data), flowActionValue(action, data))
+	case models.FlowActionAssignUser:
+		if email := flowActionString(action, "user_email"); email != "" {
+			return "assign_user " + processTemplate(email, data)
+		}
+		return "assign_user " + processTemplate(flowActionString(action, "user_id"), data)
+	case models.FlowActionCustomAction:
+		return "custom_action " + flowActionString(action, "custom_action_id")
+	case models.FlowActionSetLanguage:
+		return "set_language " + processTemplate(flowActionString(action, "language"), data)
+	}
+	return actionType
+}
+
+// runFlowActions performs an action step's operations on the contact in order.
+// A failed action is logged and doesn't stop the ones after it.
+func (a *App) runFlowActions(session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep) {
+	for _, item := range step.Actions {
+		action, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		entry := describeFlowAction(action, session.SessionData)
+		if err := a.runFlowAction(session, contact, action); err != nil {
+			a.Log.Error("Flow action failed", "error", err, "action", entry, "step", step.StepName, "contact", contact.PhoneNumber)
+			entry += " failed: " + err.Error()
+		}
+		a.logSessionMessage(session.ID, models.DirectionInternal, entry, step.StepName)
+	}
+}
+
+// runFlowAction performs a single action step operation
+func (a *App) runFlowAction(session *models.ChatbotSession, contact *models.Contact, action map[string]interface{}) error {
+	data := session.SessionData
+	actionType, _ := action["type"].(string)
+
+	switch models.FlowActionType(actionType) {
+	case models.FlowActionAddTag:
+		tag := strings.TrimSpace(processTemplate(flowActionString(action, "tag"), data))
+		if tag == "" {
+			return fmt.Errorf("tag is empty")
+		}
+		for _, t := range contact.Tags {
+			if t == tag {
+				return nil
+			}
+		}
+		contact.Tags = append(contact.Tags, tag)
+		return a.DB.Model(contact).Update("tags", contact.Tags).Error
+
+	case models.FlowActionRemoveTag:
+		tag := strings.TrimSpace(processTemplate(flowActionString(action, "tag"), data))
+		tags := models.JSONBArray{}
+		for _, t := range contact.Tags {
+			if t != tag {
+				tags = append(tags, t)
+			}
+		}
+		if len(tags) == len(contact.Tags) {
+			return nil
+		}
+		contact.Tags = tags
+		return a.DB.Model(contact).Update("tags", contact.Tags).Error
+
+	case models.FlowActionSetMetadata:
+		key := strings.TrimSpace(processTemplate(flowActionString(action, "key"), data))
+		if key == "" {
+			return fmt.Errorf("metadata key is empty")
+		}
+		if contact.Metadata == nil {
+			contact.Metadata = models.JSONB{}
+		}
+		contact.Metadata[key] = flowActionValue(action, data)
+		return a.DB.Model(contact).Update("metadata", contact.Metadata).Error
+
+	case models.FlowActionAssignUser:
+		user, err := a.findFlowActionUser(contact.OrganizationID, action, data)
+		if err != nil {
+			return err
+		}
+		contact.AssignedUserID = &user.ID
+		return a.DB.Model(contact).Update("assigned_user_id", user.ID).Error
+
+	case models.FlowActionCustomAction:
+		return a.runFlowCustomAction(contact, flowActionString(action, "custom_action_id"), data)
+
+	case models.FlowActionSetLanguage:
+		lang := strings.TrimSpace(processTemplate(flowActionString(action, "language"), data))
+		if lang == "" {
+			return fmt.Errorf("language is empty")
+		}
+		// Chosen languages aren't overwritten by detection
+		contact.Language = lang
+		contact.LanguageSource = models.LanguageSourceFlow
+		return a.DB.Model(contact).Updates(map[string]interface{}{
+			"language":        contact.Language,
+			"language_source": contact.LanguageSource,
+		}).Error
+	}
+
+	return fmt.Errorf("unknown action type %q", actionType)
+}
+
+// findFlowActionUser resolves the user of an assign_user action within the contact's organization
+func (a *App) findFlowActionUser(orgID uuid.UUID, action map[string]interface{}, data models.JSONB) (*models.User, error) {
+	query := a.DB.
+		Select("users.*").
+		Joins("JOIN user_organizations ON user_organizations.user_id = users.id AND user_organizations.organization_id = ? AND user_organizations.deleted_at IS NULL", orgID).
+		Where("users.is_active = ?", true)
+
+	if email := strings.TrimSpace(processTemplate(flowActionString(action, "user_email"), data)); email != "" {
+		query = query.Where("LOWER(users.email) = LOWER(?)", email)
+	} else {
+		userID, err := uuid.Parse(strings.TrimSpace(processTemplate(flowActionString(action, "user_id"), data)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid user_id")
+		}
+		query = query.Where("users.id = ?", userID)
+	}
+
+	var user models.User
+	if err := query.First(&user).Error; err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return &user, nil
+}
+
+// runFlowCustomAction fires a webhook or JavaScript custom action for the contact.
+// Session variables are available to the action as {{session.*}}.
+func (a *App) runFlowCustomAction(contact *models.Contact, actionID string, data models.JSONB) error {
+	var action models.CustomAction
+	if err := a.DB.Where("id = ? AND organization_id = ?", actionID, contact.OrganizationID).First(&action).Error; err != nil {
+		return fmt.Errorf("custom action not found")
+	}
+	if !action.IsActive {
+		return fmt.Errorf("custom action %q is not active", action.Name)
+	}
+
+	result, err := a.runUnattendedCustomAction(action, *contact, map[string]interface{}{
+		"session": map[string]interface{}(data),
+	})
+	if err != nil {
+		return err
+	}
+	if !result.Success {
+		return errors.New(result.Message)
+	}
+	return nil
+}
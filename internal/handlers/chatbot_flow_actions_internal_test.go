@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFlowAction(t *testing.T) {
+	valid := []map[string]interface{}{
+		{"type": "add_tag", "tag": "vip"},
+		{"type": "remove_tag", "tag": "{{old_tier}}"},
+		{"type": "set_metadata", "key": "plan", "value": 3},
+		{"type": "assign_user", "user_email": "agent@example.com"},
+		{"type": "custom_action", "custom_action_id": uuid.New().String()},
+		{"type": "set_language", "language": "{{language}}"},
+	}
+	for _, action := range valid {
+		assert.NoError(t, validateFlowAction(action), action["type"])
+	}
+
+	invalid := []map[string]interface{}{
+		{"type": "add_tag"},
+		{"type": "set_metadata", "value": "x"},
+		{"type": "assign_user"},
+		{"type": "custom_action", "custom_action_id": "ticket"},
+		{"type": "set_language", "language": " "},
+		{"type": "delete_contact"},
+		{},
+	}
+	for _, action := range invalid {
+		assert.Error(t, validateFlowAction(action), action["type"])
+	}
+}
+
+func TestDescribeFlowAction(t *testing.T) {
+	data := models.JSONB{"plan": "gold"}
+	assert.Equal(t, `add_tag "plan-gold"`, describeFlowAction(map[string]interface{}{"type": "add_tag", "tag": "plan-{{plan}}"}, data))
+	assert.Equal(t, "set_metadata plan = gold", describeFlowAction(map[string]interface{}{"type": "set_metadata", "key": "plan", "value": "{{plan}}"}, data))
+	assert.Equal(t, "assign_user sales@example.com", describeFlowAction(map[string]interface{}{"type": "assign_user", "user_email": "sales@example.com"}, data))
+	assert.Equal(t, "set_language hi", describeFlowAction(map[string]interface{}{"type": "set_language", "language": "{{lang}}"}, models.JSONB{"lang": "hi"}))
+}
+
+func TestRunFlowActions_UpdatesContact(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	require.NoError(t, app.DB.Model(contact).Update("tags", models.JSONBArray{"lead"}).Error)
+	contact.Tags = models.JSONBArray{"lead"}
+	agent := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithEmail(testutil.UniqueEmail("flow-action-agent")))
+
+	session := &models.ChatbotSession{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: account.Name,
+		PhoneNumber:     contact.PhoneNumber,
+		Status:          models.SessionStatusActive,
+		SessionData:     models.JSONB{"plan": "gold", "language": "mr"},
+		StartedAt:       time.Now(),
+		LastActivityAt:  time.Now(),
+	}
+	require.NoError(t, app.DB.Create(session).Error)
+
+	step := &models.ChatbotFlowStep{
+		StepName:    "qualify",
+		MessageType: models.FlowStepTypeAction,
+		Actions: models.JSONBArray{
+			map[string]interface{}{"type": "add_tag", "tag": "plan-{{plan}}"},
+			map[string]interface{}{"type": "remove_tag", "tag": "lead"},
+			map[string]interface{}{"type": "set_metadata", "key": "plan", "value": "{{plan}}"},
+			map[string]interface{}{"type": "assign_user", "user_email": agent.Email},
+			map[string]interface{}{"type": "custom_action", "custom_action_id": uuid.New().String()},
+			map[string]interface{}{"type": "set_language", "language": "{{language}}"},
+		},
+	}
+	app.runFlowActions(session, contact, step)
+
+	var dbContact models.Contact
+	require.NoError(t, app.DB.First(&dbContact, contact.ID).Error)
+	assert.Equal(t, models.JSONBArray{"plan-gold"}, dbContact.Tags)
+	assert.Equal(t, "gold", dbContact.Metadata["plan"])
+	require.NotNil(t, dbContact.AssignedUserID)
+	assert.Equal(t, agent.ID, *dbContact.AssignedUserID)
+	assert.Equal(t, "mr", dbContact.Language)
+	assert.Equal(t, models.LanguageSourceFlow, dbContact.LanguageSource)
+	assert.Equal(t, "mr", contact.Language, "later steps are sent in the new language")
+
+	// Every action is recorded in the session log, including the failed one
+	var entries []models.ChatbotSessionMessage
+	require.NoError(t, app.DB.Where("session_id = ? AND direction = ?", session.ID, models.DirectionInternal).
+		Find(&entries).Error)
+	require.Len(t, entries, 6)
+	var logged []string
+	for _, e := range entries {
+		assert.Equal(t, "qualify", e.StepName)
+		logged = append(logged, e.Message)
+	}
+	assert.Contains(t, logged, `add_tag "plan-gold"`)
+	assert.Contains(t, logged, "custom_action "+step.Actions[4].(map[string]interface{})["custom_action_id"].(string)+" failed: custom action not found")
+
+	// Log entries aren't fed to the AI as conversation history
+	assert.Empty(t, app.getSessionHistory(session.ID, 10))
+}
+
+func TestSendStepWithSkipCheck_ActionStepLoopCompletesFlow(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	// Two action steps leading to each other
+	flow := &models.ChatbotFlow{
+		BaseModel:      models.BaseModel{ID: uuid.New()},
+		OrganizationID: org.ID,
+		Name:           "Loop",
+		IsEnabled:      true,
+		Steps: []models.ChatbotFlowStep{
+			{StepName: "tag", MessageType: models.FlowStepTypeAction, NextStep: "note",
+				Actions: models.JSONBArray{map[string]interface{}{"type": "add_tag", "tag": "looped"}}},
+			{StepName: "note", MessageType: models.FlowStepTypeAction, NextStep: "tag",
+				Actions: models.JSONBArray{map[string]interface{}{"type": "set_metadata", "key": "looped", "value": "yes"}}},
+		},
+	}
+	session := &models.ChatbotSession{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: account.Name,
+		PhoneNumber:     contact.PhoneNumber,
+		Status:          models.SessionStatusActive,
+		CurrentStep:     "tag",
+		SessionData:     models.JSONB{},
+		StartedAt:       time.Now(),
+		LastActivityAt:  time.Now(),
+	}
+	require.NoError(t, app.DB.Create(session).Error)
+
+	app.sendStepWithSkipCheck(account, session, contact, &flow.Steps[0], flow, nil)
+
+	var dbSession models.ChatbotSession
+	require.NoError(t, app.DB.First(&dbSession, session.ID).Error)
+	assert.Equal(t, models.SessionStatusCompleted, dbSession.Status)
+
+	// Each step ran once
+	var entries int64
+	app.DB.Model(&models.ChatbotSessionMessage{}).
+		Where("session_id = ? AND direction = ?", session.ID, models.DirectionInternal).
+		Count(&entries)
+	assert.Equal(t, int64(2), entries)
+}
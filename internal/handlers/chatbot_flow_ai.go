@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// AI step modes
+const (
+	aiStepModeReply   = "reply"
+	aiStepModeExtract = "extract"
+)
+
+// maxAIExtractAttempts is how many times the model is asked for valid JSON per contact reply
+const maxAIExtractAttempts = 2
+
+// aiCompleteFunc sends a system prompt and user message to the model and returns its raw output
+type aiCompleteFunc func(systemPrompt, userMessage string) (string, error)
+
+// aiStepResult is the outcome of an ai step for one contact reply
+type aiStepResult struct {
+	Reply     string                 // text to send to the contact (reply mode)
+	Extracted map[string]interface{} // fields to store in the session (extract mode)
+	Invalid   []string               // why earlier model outputs were rejected
+}
+
+// stepAIConfig returns the ai_config of an ai step with defaults applied
+func stepAIConfig(step *models.ChatbotFlowStep) (prompt, mode string, schema map[string]interface{}, includeHistory bool) {
+	prompt, _ = step.AIConfig["prompt"].(string)
+	mode, _ = step.AIConfig["mode"].(string)
+	if mode == "" {
+		mode = aiStepModeReply
+	}
+	schema, _ = step.AIConfig["schema"].(map[string]interface{})
+	includeHistory, _ = step.AIConfig["include_history"].(bool)
+	return prompt, mode, schema, includeHistory
+}
+
+// validateAIStepConfig checks an ai step's mode and, for extraction, its JSON schema
+func validateAIStepConfig(config map[string]interface{}) error {
+	mode, _ := config["mode"].(string)
+	switch mode {
+	case "", aiStepModeReply:
+		return nil
+	case aiStepModeExtract:
+	default:
+		return fmt.Errorf("unknown AI mode %q, expected reply or extract", mode)
+	}
+
+	schema, _ := config["schema"].(map[string]interface{})
+	if properties, _ := schema["properties"].(map[string]interface{}); len(properties) == 0 {
+		return fmt.Errorf("extract mode needs a schema with properties")
+	}
+	return validateAISchema(schema)
+}
+
+// validateAISchema checks the property types and required fields of an object JSON schema
+func validateAISchema(schema map[string]interface{}) error {
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, p := range properties {
+		prop, _ := p.(map[string]interface{})
+		switch prop["type"] {
+		case nil, "string", "number", "integer", "boolean", "array", "object":
+		default:
+			return fmt.Errorf("property %q has unsupported type %v", name, prop["type"])
+		}
+	}
+	required, _ := schema["required"].([]interface{})
+	for _, r := range required {
+		name, _ := r.(string)
+		if _, ok := properties[name]; !ok {
+			return fmt.Errorf("required field %q is not in properties", name)
+		}
+	}
+	return nil
+}
+
+// aiStepSystemPrompt builds the system prompt of an ai step, with {{variables}} replaced
+func aiStepSystemPrompt(step *models.ChatbotFlowStep, data models.JSONB) string {
+	prompt, mode, schema, _ := stepAIConfig(step)
+	prompt = processTemplate(prompt, data)
+	if mode != aiStepModeExtract {
+		return prompt
+	}
+
+	schemaJSON, _ := json.Marshal(schema)
+	instructions := "Extract the requested details from the user's message. Reply with only a JSON object, " +
+		"without any other text, that matches this JSON schema:\n" + string(schemaJSON) +
+		"\nUse null for details the user didn't give."
+	if prompt == "" {
+		return instructions
+	}
+	return prompt + "\n\n" + instructions
+}
+
+// runAIStep asks the model to reply to the contact's message, or to extract the fields of the
+// step's schema from it. Malformed extractions are retried with the validation error.
+func runAIStep(step *models.ChatbotFlowStep, data models.JSONB, userMessage string, complete aiCompleteFunc) (*aiStepResult, error) {
+	_, mode, schema, _ := stepAIConfig(step)
+	systemPrompt := aiStepSystemPrompt(step, data)
+	result := &aiStepResult{}
+
+	if mode != aiStepModeExtract {
+		output, err := complete(systemPrompt, userMessage)
+		if err != nil {
+			return result, err
+		}
+		if strings.TrimSpace(output) == "" {
+			return result, fmt.Errorf("model returned an empty reply")
+		}
+		result.Reply = strings.TrimSpace(output)
+		return result, nil
+	}
+
+	message := userMessage
+	for attempt := 1; attempt <= maxAIExtractAttempts; attempt++ {
+		output, err := complete(systemPrompt, message)
+		if err != nil {
+			return result, err
+		}
+		fields, err := parseAIExtraction(output, schema)
+		if err == nil {
+			result.Extracted = fields
+			return result, nil
+		}
+		result.Invalid = append(result.Invalid, err.Error())
+		message = userMessage + "\n\n(Your previous reply was invalid: " + err.Error() + ". Reply with only the JSON object.)"
+	}
+	return result, fmt.Errorf("no valid extraction after %d attempts: %s", maxAIExtractAttempts, result.Invalid[len(result.Invalid)-1])
+}
+
+// parseAIExtraction parses the JSON object in a model's output and checks it against the schema.
+// Unknown and null fields are dropped.
+func parseAIExtraction(output string, schema map[string]interface{}) (map[string]interface{}, error) {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("reply is not a JSON object")
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(output[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("reply is not valid JSON: %v", err)
+	}
+	return checkAISchemaFields(raw, schema)
+}
+
+// checkAISchemaFields returns the fields of raw defined by the schema, checking their types and
+// that required fields are present. Unknown, null and blank fields are dropped.
+func checkAISchemaFields(raw map[string]interface{}, schema map[string]interface{}) (map[string]interface{}, error) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	fields := make(map[string]interface{})
+	for name, p := range properties {
+		value, ok := raw[name]
+		if !ok || value == nil {
+			continue
+		}
+		if s, isString := value.(string); isString && strings.TrimSpace(s) == "" {
+			continue
+		}
+		prop, _ := p.(map[string]interface{})
+		if err := checkAISchemaValue(name, value, prop); err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+
+	required, _ := schema["required"].([]interface{})
+	for _, r := range required {
+		name, _ := r.(string)
+		if _, ok := fields[name]; !ok {
+			return nil, fmt.Errorf("missing required field %q", name)
+		}
+	}
+	return fields, nil
+}
+
+// checkAISchemaValue checks a value against the type and enum of its schema property
+func checkAISchemaValue(name string, value interface{}, prop map[string]interface{}) error {
+	valid := true
+	switch prop["type"] {
+	case "string":
+		_, valid = value.(string)
+	case "number":
+		_, valid = value.(float64)
+	case "integer":
+		n, ok := value.(float64)
+		valid = ok && n == math.Trunc(n)
+	case "boolean":
+		_, valid = value.(bool)
+	case "array":
+		_, valid = value.([]interface{})
+	case "object":
+		_, valid = value.(map[string]interface{})
+	}
+	if !valid {
+		return fmt.Errorf("field %q should be of type %v", name, prop["type"])
+	}
+
+	if enum, ok := prop["enum"].([]interface{}); ok && len(enum) > 0 {
+		for _, option := range enum {
+			if fmt.Sprint(option) == fmt.Sprint(value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("field %q must be one of %v", name, enum)
+	}
+	return nil
+}
+
+// storeAIStepResult saves an ai step's outcome in the session data. Extracted fields are stored
+// as top-level variables; store_as holds the reply or the whole extraction.
+func storeAIStepResult(data models.JSONB, step *models.ChatbotFlowStep, result *aiStepResult) {
+	if result.Extracted != nil {
+		for k, v := range result.Extracted {
+			data[k] = v
+		}
+		if step.StoreAs != "" {
+			data[step.StoreAs] = result.Extracted
+		}
+		return
+	}
+	if step.StoreAs != "" {
+		data[step.StoreAs] = result.Reply
+	}
+}
+
+// handleAIStepInput runs an ai step on the contact's reply using the provider from the chatbot's
+// AI settings. It returns false if the step should wait for another reply.
+func (a *App) handleAIStepInput(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep, userInput string) bool {
+	_, _, _, includeHistory := stepAIConfig(step)
+
+	settings, err := a.getChatbotSettingsCached(session.OrganizationID, account.Name)
+	if err == nil {
+		_, err = a.newAIProvider(settings)
+	}
+
+	var result *aiStepResult
+	if err == nil {
+		complete := func(systemPrompt, userMessage string) (string, error) {
+			stepSettings := *settings
+			stepSettings.AI.SystemPrompt = systemPrompt
+			stepSettings.AI.IncludeHistory = includeHistory
+			return a.completeAI(&stepSettings, session, userMessage, "")
+		}
+		result, err = runAIStep(step, session.SessionData, userInput, complete)
+	}
+
+	if err != nil {
+		a.Log.Error("AI step failed", "error", err, "step", step.StepName, "contact", contact.PhoneNumber)
+		session.StepRetries++
+		if step.RetryOnInvalid && session.StepRetries < step.MaxRetries {
+			a.DB.Model(session).Update("step_retries", session.StepRetries)
+			errorMsg := stepValidationError(step)
+			if err := a.sendAndSaveTextMessage(account, contact, errorMsg); err != nil {
+				a.Log.Error("Failed to send validation error", "error", err, "contact", contact.PhoneNumber)
+			}
+			a.logSessionMessage(session.ID, models.DirectionOutgoing, errorMsg, step.StepName+"_retry")
+			return false
+		}
+		// Max retries exceeded, continue without the result like other steps do
+		a.Log.Warn("Max retries exceeded", "step", step.StepName)
+		return true
+	}
+
+	if session.SessionData == nil {
+		session.SessionData = models.JSONB{}
+	}
+	storeAIStepResult(session.SessionData, step, result)
+	a.DB.Model(session).Update("session_data", session.SessionData)
+
+	if result.Reply != "" {
+		if err := a.sendAndSaveTextMessage(account, contact, result.Reply); err != nil {
+			a.Log.Error("Failed to send AI step reply", "error", err, "contact", contact.PhoneNumber)
+		}
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, result.Reply, step.StepName)
+	}
+	return true
+}
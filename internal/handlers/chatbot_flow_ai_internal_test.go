@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bookingSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"date":   map[string]interface{}{"type": "string"},
+			"guests": map[string]interface{}{"type": "integer"},
+			"seating": map[string]interface{}{
+				"type": "string",
+				"enum": []interface{}{"indoor", "outdoor"},
+			},
+		},
+		"required": []interface{}{"date", "guests"},
+	}
+}
+
+func TestValidateAIStepConfig(t *testing.T) {
+	assert.NoError(t, validateAIStepConfig(nil))
+	assert.NoError(t, validateAIStepConfig(map[string]interface{}{"mode": "reply", "prompt": "Be brief"}))
+	assert.NoError(t, validateAIStepConfig(map[string]interface{}{"mode": "extract", "schema": bookingSchema()}))
+
+	assert.Error(t, validateAIStepConfig(map[string]interface{}{"mode": "summarize"}))
+	assert.Error(t, validateAIStepConfig(map[string]interface{}{"mode": "extract"}))
+	assert.Error(t, validateAIStepConfig(map[string]interface{}{"mode": "extract", "schema": map[string]interface{}{
+		"properties": map[string]interface{}{"when": map[string]interface{}{"type": "datetime"}},
+	}}))
+	assert.Error(t, validateAIStepConfig(map[string]interface{}{"mode": "extract", "schema": map[string]interface{}{
+		"properties": map[string]interface{}{"date": map[string]interface{}{"type": "string"}},
+		"required":   []interface{}{"guests"},
+	}}))
+}
+
+func TestParseAIExtraction(t *testing.T) {
+	schema := bookingSchema()
+
+	fields, err := parseAIExtraction("```json\n{\"date\": \"2026-03-06\", \"guests\": 4, \"seating\": null, \"extra\": 1}\n```", schema)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"date": "2026-03-06", "guests": float64(4)}, fields)
+
+	_, err = parseAIExtraction("Friday for four", schema)
+	assert.EqualError(t, err, "reply is not a JSON object")
+	_, err = parseAIExtraction(`{"date": "friday"}`, schema)
+	assert.EqualError(t, err, `missing required field "guests"`)
+	_, err = parseAIExtraction(`{"date": "friday", "guests": 4.5}`, schema)
+	assert.EqualError(t, err, `field "guests" should be of type integer`)
+	_, err = parseAIExtraction(`{"date": "friday", "guests": 4, "seating": "bar"}`, schema)
+	assert.Error(t, err)
+}
+
+func TestRunAIStep(t *testing.T) {
+	step := &models.ChatbotFlowStep{
+		StepName: "details",
+		AIConfig: models.JSONB{"mode": "extract", "prompt": "Booking for {{name}}", "schema": bookingSchema()},
+	}
+	data := models.JSONB{"name": "Asha"}
+
+	t.Run("retries malformed output", func(t *testing.T) {
+		outputs := []string{"Friday for four", `{"date": "friday", "guests": 4}`}
+		var prompts, messages []string
+		complete := func(systemPrompt, userMessage string) (string, error) {
+			prompts = append(prompts, systemPrompt)
+			messages = append(messages, userMessage)
+			output := outputs[0]
+			outputs = outputs[1:]
+			return output, nil
+		}
+
+		result, err := runAIStep(step, data, "Friday, 4 of us", complete)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"date": "friday", "guests": float64(4)}, result.Extracted)
+		assert.Equal(t, []string{"reply is not a JSON object"}, result.Invalid)
+
+		require.Len(t, prompts, 2)
+		assert.Contains(t, prompts[0], "Booking for Asha")
+		assert.Contains(t, prompts[0], `"required":["date","guests"]`)
+		assert.Equal(t, "Friday, 4 of us", messages[0])
+		assert.Contains(t, messages[1], "reply is not a JSON object")
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		calls := 0
+		complete := func(systemPrompt, userMessage string) (string, error) {
+			calls++
+			return `{"date": "friday"}`, nil
+		}
+		_, err := runAIStep(step, data, "Friday", complete)
+		assert.Error(t, err)
+		assert.Equal(t, maxAIExtractAttempts, calls)
+	})
+
+	t.Run("reply mode", func(t *testing.T) {
+		replyStep := &models.ChatbotFlowStep{AIConfig: models.JSONB{"prompt": "Answer questions about {{name}}'s order"}}
+		complete := func(systemPrompt, userMessage string) (string, error) {
+			assert.Equal(t, "Answer questions about Asha's order", systemPrompt)
+			return "  It ships tomorrow.\n", nil
+		}
+		result, err := runAIStep(replyStep, data, "When does it ship?", complete)
+		require.NoError(t, err)
+		assert.Equal(t, "It ships tomorrow.", result.Reply)
+
+		stored := models.JSONB{}
+		replyStep.StoreAs = "answer"
+		storeAIStepResult(stored, replyStep, result)
+		assert.Equal(t, models.JSONB{"answer": "It ships tomorrow."}, stored)
+	})
+
+	t.Run("provider error", func(t *testing.T) {
+		complete := func(systemPrompt, userMessage string) (string, error) {
+			return "", errors.New("rate limited")
+		}
+		_, err := runAIStep(step, data, "Friday", complete)
+		assert.EqualError(t, err, "rate limited")
+	})
+}
+
+func TestCompleteAI_OpenAICompatible(t *testing.T) {
+	var payload struct {
+		Model    string              `json:"model"`
+		Messages []map[string]string `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		if payload.Messages[len(payload.Messages)-1]["content"] == "slow" {
+			time.Sleep(1500 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"content": "We open at 9."}}]}`))
+	}))
+	defer server.Close()
+
+	app := &App{AIHTTPClient: server.Client()}
+	settings := &models.ChatbotSettings{AI: models.AIConfig{
+		Provider:       models.AIProviderOpenAICompatible,
+		BaseURL:        server.URL + "/v1",
+		Model:          "llama3",
+		SystemPrompt:   "Be brief",
+		TimeoutSeconds: 1,
+	}}
+
+	reply, err := app.completeAI(settings, nil, "When do you open?", "Hours: 9-5")
+	require.NoError(t, err)
+	assert.Equal(t, "We open at 9.", reply)
+	assert.Equal(t, "llama3", payload.Model)
+	assert.Equal(t, []map[string]string{
+		{"role": "system", "content": "Be brief\n\nHours: 9-5"},
+		{"role": "user", "content": "When do you open?"},
+	}, payload.Messages)
+
+	// The settings' timeout applies to each request
+	_, err = app.completeAI(settings, nil, "slow", "")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
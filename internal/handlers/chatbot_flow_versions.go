@@ -0,0 +1,569 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// draftFlowVersion identifies the editable draft in version references (published versions start at 1)
+const draftFlowVersion = 0
+
+// versionedFlowColumns are the flow columns captured in a version and restored on rollback.
+// Enabling or disabling a flow is not versioned and takes effect immediately.
+var versionedFlowColumns = []string{
+	"whats_app_account", "name", "description", "trigger_keywords", "trigger_button_id",
+	"initial_message", "initial_message_type", "initial_template_id", "completion_message",
+	"on_complete_action", "completion_config", "timeout_message", "cancel_keywords", "translations", "panel_config",
+}
+
+// Fields left out of diffs: identity, timestamps, relations and unversioned state
+var (
+	flowDiffIgnoredFields = map[string]bool{
+		"id": true, "organization_id": true, "created_at": true, "updated_at": true, "deleted_at": true,
+		"is_enabled": true, "published_version": true, "has_draft_changes": true,
+		"steps": true, "organization": true, "initial_template": true,
+	}
+	stepDiffIgnoredFields = map[string]bool{
+		"id": true, "flow_id": true, "created_at": true, "updated_at": true, "deleted_at": true,
+		"flow": true, "template": true,
+	}
+)
+
+// ChatbotFlowVersionResponse represents a published flow version for API response
+type ChatbotFlowVersionResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	Version       int        `json:"version"`
+	Notes         string     `json:"notes"`
+	PublishedByID *uuid.UUID `json:"published_by_id,omitempty"`
+	IsLive        bool       `json:"is_live"`
+	CreatedAt     string     `json:"created_at"`
+}
+
+// FlowFieldChange is a single changed field in a flow diff
+type FlowFieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// FlowStepDiff lists the changed fields of a step present on both sides of a diff
+type FlowStepDiff struct {
+	StepName string            `json:"step_name"`
+	Changes  []FlowFieldChange `json:"changes"`
+}
+
+// ChatbotFlowDiffResponse describes the changes between two versions of a flow
+type ChatbotFlowDiffResponse struct {
+	From         string            `json:"from"`
+	To           string            `json:"to"`
+	Flow         []FlowFieldChange `json:"flow"`
+	StepsAdded   []string          `json:"steps_added"`
+	StepsRemoved []string          `json:"steps_removed"`
+	StepsChanged []FlowStepDiff    `json:"steps_changed"`
+}
+
+// PublishChatbotFlow publishes the flow's draft as a new immutable version.
+// New sessions start on the published version; running sessions finish on the version they started with.
+func (a *App) PublishChatbotFlow(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionWrite, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	var req struct {
+		Notes string `json:"notes"`
+	}
+	if len(r.RequestCtx.PostBody()) > 0 {
+		if err := a.decodeRequest(r, &req); err != nil {
+			return nil
+		}
+	}
+
+	flow, err := findByIDAndOrg[models.ChatbotFlow](a.DB, r, id, orgID, "Flow")
+	if err != nil {
+		return nil
+	}
+	if flow.PublishedVersion > 0 && !flow.HasDraftChanges {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "No draft changes to publish", nil, "")
+	}
+
+	var version *models.ChatbotFlowVersion
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		version, err = publishChatbotFlow(tx, orgID, id, req.Notes, &userID)
+		return err
+	})
+	if err != nil {
+		a.Log.Error("Failed to publish flow", "error", err, "flow_id", id)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to publish flow", nil, "")
+	}
+
+	a.InvalidateChatbotFlowsCache(orgID)
+
+	return r.SendEnvelope(map[string]any{
+		"version": chatbotFlowVersionToResponse(version, version.Version),
+		"message": "Flow published successfully",
+	})
+}
+
+// ListChatbotFlowVersions lists the published versions of a flow, newest first
+func (a *App) ListChatbotFlowVersions(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionRead, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	flow, err := findByIDAndOrg[models.ChatbotFlow](a.DB, r, id, orgID, "Flow")
+	if err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	query := a.DB.Model(&models.ChatbotFlowVersion{}).Where("flow_id = ? AND organization_id = ?", id, orgID)
+
+	var total int64
+	query.Count(&total)
+
+	var versions []models.ChatbotFlowVersion
+	if err := pg.Apply(query.Omit("definition").Order("version DESC")).Find(&versions).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to fetch flow versions", nil, "")
+	}
+
+	response := make([]ChatbotFlowVersionResponse, len(versions))
+	for i := range versions {
+		response[i] = chatbotFlowVersionToResponse(&versions[i], flow.PublishedVersion)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"versions":          response,
+		"published_version": flow.PublishedVersion,
+		"has_draft_changes": flow.HasDraftChanges,
+		"total":             total,
+		"page":              pg.Page,
+		"limit":             pg.Limit,
+	})
+}
+
+// GetChatbotFlowVersion returns a published version with its flow definition
+func (a *App) GetChatbotFlowVersion(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionRead, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+	versionNum, err := parseFlowVersionParam(r)
+	if err != nil {
+		return nil
+	}
+
+	flow, err := findByIDAndOrg[models.ChatbotFlow](a.DB, r, id, orgID, "Flow")
+	if err != nil {
+		return nil
+	}
+
+	var version models.ChatbotFlowVersion
+	if err := a.DB.Where("flow_id = ? AND organization_id = ? AND version = ?", id, orgID, versionNum).
+		First(&version).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow version not found", nil, "")
+	}
+	snapshot, err := chatbotFlowFromVersion(&version)
+	if err != nil {
+		a.Log.Error("Failed to decode flow version", "error", err, "flow_id", id, "version", versionNum)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load flow version", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"version": chatbotFlowVersionToResponse(&version, flow.PublishedVersion),
+		"flow":    snapshot,
+	})
+}
+
+// DiffChatbotFlowVersions compares two versions of a flow.
+// The from and to query params take a version number or "draft"; they default to the published version and the draft.
+func (a *App) DiffChatbotFlowVersions(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionRead, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	flow, err := findByIDAndOrg[models.ChatbotFlow](a.DB, r, id, orgID, "Flow")
+	if err != nil {
+		return nil
+	}
+
+	from, err := parseFlowVersionRef(string(r.RequestCtx.QueryArgs().Peek("from")), flow.PublishedVersion)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid from version", nil, "")
+	}
+	to, err := parseFlowVersionRef(string(r.RequestCtx.QueryArgs().Peek("to")), draftFlowVersion)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid to version", nil, "")
+	}
+
+	fromFlow, err := loadChatbotFlowRevision(a.DB, orgID, id, from)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, fmt.Sprintf("Flow version %s not found", flowVersionLabel(from)), nil, "")
+	}
+	toFlow, err := loadChatbotFlowRevision(a.DB, orgID, id, to)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, fmt.Sprintf("Flow version %s not found", flowVersionLabel(to)), nil, "")
+	}
+
+	diff := diffChatbotFlows(fromFlow, toFlow)
+	diff.From = flowVersionLabel(from)
+	diff.To = flowVersionLabel(to)
+
+	return r.SendEnvelope(diff)
+}
+
+// RollbackChatbotFlow republishes an earlier version as a new version and resets the draft to it
+func (a *App) RollbackChatbotFlow(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionWrite, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+	versionNum, err := parseFlowVersionParam(r)
+	if err != nil {
+		return nil
+	}
+
+	flow, err := findByIDAndOrg[models.ChatbotFlow](a.DB, r, id, orgID, "Flow")
+	if err != nil {
+		return nil
+	}
+	if versionNum == flow.PublishedVersion && !flow.HasDraftChanges {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("Version %d is already live", versionNum), nil, "")
+	}
+
+	target, err := loadChatbotFlowRevision(a.DB, orgID, id, versionNum)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow version not found", nil, "")
+	}
+
+	var version *models.ChatbotFlowVersion
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := restoreChatbotFlowDraft(tx, flow, target); err != nil {
+			return err
+		}
+		var err error
+		version, err = publishChatbotFlow(tx, orgID, id, fmt.Sprintf("Rolled back to version %d", versionNum), &userID)
+		return err
+	})
+	if err != nil {
+		a.Log.Error("Failed to roll back flow", "error", err, "flow_id", id, "version", versionNum)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to roll back flow", nil, "")
+	}
+
+	a.InvalidateChatbotFlowsCache(orgID)
+
+	return r.SendEnvelope(map[string]any{
+		"version": chatbotFlowVersionToResponse(version, version.Version),
+		"message": fmt.Sprintf("Flow rolled back to version %d", versionNum),
+	})
+}
+
+// publishChatbotFlow snapshots the flow's draft rows as the next version and makes it live
+func publishChatbotFlow(tx *gorm.DB, orgID, flowID uuid.UUID, notes string, userID *uuid.UUID) (*models.ChatbotFlowVersion, error) {
+	var flow models.ChatbotFlow
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND organization_id = ?", flowID, orgID).
+		First(&flow).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("flow_id = ?", flowID).Order("step_order ASC").Find(&flow.Steps).Error; err != nil {
+		return nil, err
+	}
+
+	var latest int
+	if err := tx.Model(&models.ChatbotFlowVersion{}).
+		Where("flow_id = ?", flowID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		return nil, err
+	}
+
+	flow.PublishedVersion = latest + 1
+	flow.HasDraftChanges = false
+	definition, err := chatbotFlowDefinition(&flow)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &models.ChatbotFlowVersion{
+		OrganizationID: orgID,
+		FlowID:         flowID,
+		Version:        flow.PublishedVersion,
+		Definition:     definition,
+		Notes:          notes,
+		PublishedByID:  userID,
+	}
+	if err := tx.Create(version).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&models.ChatbotFlow{}).Where("id = ?", flowID).
+		Updates(map[string]any{"published_version": version.Version, "has_draft_changes": false}).Error; err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// restoreChatbotFlowDraft overwrites the flow's draft rows with a snapshot
+func restoreChatbotFlowDraft(tx *gorm.DB, flow *models.ChatbotFlow, snapshot *models.ChatbotFlow) error {
+	steps := snapshot.Steps
+	fields := *snapshot
+	fields.Steps = nil
+	if err := tx.Model(flow).Select(versionedFlowColumns).Updates(&fields).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("flow_id = ?", flow.ID).Delete(&models.ChatbotFlowStep{}).Error; err != nil {
+		return err
+	}
+	for _, step := range steps {
+		step.BaseModel = models.BaseModel{ID: uuid.New()}
+		step.FlowID = flow.ID
+		step.Flow = nil
+		step.Template = nil
+		if err := tx.Create(&step).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadChatbotFlowRevision loads the draft (version 0) or a published version of a flow, with steps
+func loadChatbotFlowRevision(db *gorm.DB, orgID, flowID uuid.UUID, version int) (*models.ChatbotFlow, error) {
+	if version == draftFlowVersion {
+		var flow models.ChatbotFlow
+		if err := db.Where("id = ? AND organization_id = ?", flowID, orgID).
+			Preload("Steps", func(db *gorm.DB) *gorm.DB {
+				return db.Order("step_order ASC")
+			}).
+			First(&flow).Error; err != nil {
+			return nil, err
+		}
+		return &flow, nil
+	}
+
+	var v models.ChatbotFlowVersion
+	if err := db.Where("flow_id = ? AND organization_id = ? AND version = ?", flowID, orgID, version).
+		First(&v).Error; err != nil {
+		return nil, err
+	}
+	return chatbotFlowFromVersion(&v)
+}
+
+// chatbotFlowDefinition encodes a flow and its steps for storage in a version
+func chatbotFlowDefinition(flow *models.ChatbotFlow) (models.JSONB, error) {
+	data, err := json.Marshal(flow)
+	if err != nil {
+		return nil, err
+	}
+	var definition models.JSONB
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+// chatbotFlowFromVersion decodes a version's definition back into a flow
+func chatbotFlowFromVersion(version *models.ChatbotFlowVersion) (*models.ChatbotFlow, error) {
+	data, err := json.Marshal(version.Definition)
+	if err != nil {
+		return nil, err
+	}
+	var flow models.ChatbotFlow
+	if err := json.Unmarshal(data, &flow); err != nil {
+		return nil, err
+	}
+	flow.ID = version.FlowID
+	flow.OrganizationID = version.OrganizationID
+	flow.PublishedVersion = version.Version
+	flow.HasDraftChanges = false
+	return &flow, nil
+}
+
+func chatbotFlowVersionToResponse(v *models.ChatbotFlowVersion, liveVersion int) ChatbotFlowVersionResponse {
+	return ChatbotFlowVersionResponse{
+		ID:            v.ID,
+		Version:       v.Version,
+		Notes:         v.Notes,
+		PublishedByID: v.PublishedByID,
+		IsLive:        v.Version == liveVersion,
+		CreatedAt:     v.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// parseFlowVersionParam parses the {version} path param, sending a 400 on failure
+func parseFlowVersionParam(r *fastglue.Request) (int, error) {
+	raw, _ := r.RequestCtx.UserValue("version").(string)
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < 1 {
+		_ = r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid version", nil, "")
+		return 0, errEnvelopeSent
+	}
+	return version, nil
+}
+
+// parseFlowVersionRef parses a version number or "draft", falling back to def when empty
+func parseFlowVersionRef(raw string, def int) (int, error) {
+	switch raw {
+	case "":
+		return def, nil
+	case "draft":
+		return draftFlowVersion, nil
+	}
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < 1 {
+		return 0, errors.New("invalid version")
+	}
+	return version, nil
+}
+
+func flowVersionLabel(version int) string {
+	if version == draftFlowVersion {
+		return "draft"
+	}
+	return strconv.Itoa(version)
+}
+
+// diffChatbotFlows compares flow settings field by field and steps by step name
+func diffChatbotFlows(from, to *models.ChatbotFlow) ChatbotFlowDiffResponse {
+	diff := ChatbotFlowDiffResponse{
+		Flow:         diffFields(from, to, flowDiffIgnoredFields),
+		StepsAdded:   []string{},
+		StepsRemoved: []string{},
+		StepsChanged: []FlowStepDiff{},
+	}
+
+	fromSteps := make(map[string]*models.ChatbotFlowStep, len(from.Steps))
+	for i := range from.Steps {
+		fromSteps[from.Steps[i].StepName] = &from.Steps[i]
+	}
+	toSteps := make(map[string]bool, len(to.Steps))
+
+	for i := range to.Steps {
+		step := &to.Steps[i]
+		toSteps[step.StepName] = true
+		prev, ok := fromSteps[step.StepName]
+		if !ok {
+			diff.StepsAdded = append(diff.StepsAdded, step.StepName)
+			continue
+		}
+		if changes := diffFields(prev, step, stepDiffIgnoredFields); len(changes) > 0 {
+			diff.StepsChanged = append(diff.StepsChanged, FlowStepDiff{StepName: step.StepName, Changes: changes})
+		}
+	}
+	for _, step := range from.Steps {
+		if !toSteps[step.StepName] {
+			diff.StepsRemoved = append(diff.StepsRemoved, step.StepName)
+		}
+	}
+
+	return diff
+}
+
+// diffFields compares the JSON representation of two values, skipping ignored fields
+func diffFields(from, to any, ignored map[string]bool) []FlowFieldChange {
+	fromFields, toFields := jsonFields(from), jsonFields(to)
+
+	keys := make([]string, 0, len(fromFields)+len(toFields))
+	for k := range fromFields {
+		keys = append(keys, k)
+	}
+	for k := range toFields {
+		if _, ok := fromFields[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := []FlowFieldChange{}
+	for _, k := range keys {
+		if ignored[k] || isEmptyJSON(fromFields[k]) && isEmptyJSON(toFields[k]) {
+			continue
+		}
+		if !reflect.DeepEqual(fromFields[k], toFields[k]) {
+			changes = append(changes, FlowFieldChange{Field: k, From: fromFields[k], To: toFields[k]})
+		}
+	}
+	return changes
+}
+
+func jsonFields(v any) map[string]any {
+	fields := map[string]any{}
+	if data, err := json.Marshal(v); err == nil {
+		_ = json.Unmarshal(data, &fields)
+	}
+	return fields
+}
+
+// isEmptyJSON treats null and empty collections alike so they don't show up as changes
+func isEmptyJSON(v any) bool {
+	switch val := v.(type) {
+	case nil:
+		return true
+	case []any:
+		return len(val) == 0
+	case map[string]any:
+		return len(val) == 0
+	}
+	return false
+}
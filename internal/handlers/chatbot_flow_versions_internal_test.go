@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffChatbotFlows(t *testing.T) {
+	from := &models.ChatbotFlow{
+		Name:            "Support",
+		TriggerKeywords: models.StringArray{"help"},
+		IsEnabled:       true,
+		Steps: []models.ChatbotFlowStep{
+			{StepName: "ask_name", StepOrder: 1, Message: "Name?", InputType: models.InputTypeText},
+			{StepName: "ask_phone", StepOrder: 2, Message: "Phone?", InputType: models.InputTypeText},
+		},
+	}
+	to := &models.ChatbotFlow{
+		Name:             "Support",
+		TriggerKeywords:  models.StringArray{"help", "support"},
+		IsEnabled:        false,
+		PublishedVersion: 4,
+		CompletionConfig: models.JSONB{},
+		Steps: []models.ChatbotFlowStep{
+			{StepName: "ask_name", StepOrder: 1, Message: "Your name?", InputType: models.InputTypeText},
+			{StepName: "ask_email", StepOrder: 2, Message: "Email?", InputType: models.InputTypeEmail},
+		},
+	}
+
+	diff := diffChatbotFlows(from, to)
+
+	require.Len(t, diff.Flow, 1, "enabled state, versions and empty configs are not changes")
+	assert.Equal(t, "trigger_keywords", diff.Flow[0].Field)
+	assert.Equal(t, []string{"ask_email"}, diff.StepsAdded)
+	assert.Equal(t, []string{"ask_phone"}, diff.StepsRemoved)
+	require.Len(t, diff.StepsChanged, 1)
+	assert.Equal(t, []FlowFieldChange{{Field: "message", From: "Name?", To: "Your name?"}}, diff.StepsChanged[0].Changes)
+}
+
+func TestParseFlowVersionRef(t *testing.T) {
+	v, err := parseFlowVersionRef("", 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, v)
+
+	v, err = parseFlowVersionRef("draft", 3)
+	require.NoError(t, err)
+	assert.Equal(t, draftFlowVersion, v)
+
+	v, err = parseFlowVersionRef("7", 3)
+	require.NoError(t, err)
+	assert.Equal(t, 7, v)
+
+	_, err = parseFlowVersionRef("0", 3)
+	assert.Error(t, err)
+	_, err = parseFlowVersionRef("latest", 3)
+	assert.Error(t, err)
+}
+
+func TestChatbotFlowDefinitionRoundTrip(t *testing.T) {
+	flow := &models.ChatbotFlow{
+		Name:        "Support",
+		PanelConfig: models.JSONB{"sections": []any{}},
+		Steps: []models.ChatbotFlowStep{
+			{StepName: "menu", Buttons: models.JSONBArray{map[string]any{"id": "a", "title": "A"}}},
+		},
+	}
+	definition, err := chatbotFlowDefinition(flow)
+	require.NoError(t, err)
+
+	version := &models.ChatbotFlowVersion{Version: 2, Definition: definition}
+	restored, err := chatbotFlowFromVersion(version)
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored.PublishedVersion)
+	assert.Equal(t, "Support", restored.Name)
+	require.Len(t, restored.Steps, 1)
+	assert.Equal(t, "A", restored.Steps[0].Buttons[0].(map[string]any)["title"])
+	assert.Empty(t, diffChatbotFlows(flow, restored).StepsChanged)
+}
+
+func TestGetPinnedChatbotFlow_SurvivesPublishAndRollback(t *testing.T) {
+	app := newSLATestApp(t)
+	if app.Redis == nil {
+		t.Skip("Redis not available")
+	}
+	org := testutil.CreateTestOrganization(t, app.DB)
+
+	flow := &models.ChatbotFlow{
+		BaseModel:      models.BaseModel{ID: uuid.New()},
+		OrganizationID: org.ID,
+		Name:           "Support",
+		IsEnabled:      true,
+		Steps: []models.ChatbotFlowStep{
+			{BaseModel: models.BaseModel{ID: uuid.New()}, StepName: "ask_name", StepOrder: 1, Message: "Your name?", InputType: models.InputTypeText},
+		},
+	}
+	require.NoError(t, app.DB.Create(flow).Error)
+	v1, err := publishChatbotFlow(app.DB, org.ID, flow.ID, "", nil)
+	require.NoError(t, err)
+
+	firstMessage := func(version int) string {
+		t.Helper()
+		pinned, err := app.getPinnedChatbotFlow(org.ID, flow.ID, version)
+		require.NoError(t, err)
+		require.NotEmpty(t, pinned.Steps)
+		return pinned.Steps[0].Message
+	}
+	// A session that started on version 1 caches it before the flow changes
+	assert.Equal(t, "Your name?", firstMessage(v1.Version))
+
+	// Publishing version 2 doesn't change what a version 1 session runs
+	require.NoError(t, app.DB.Model(&models.ChatbotFlowStep{}).Where("flow_id = ?", flow.ID).
+		Update("message", "What's your name?").Error)
+	v2, err := publishChatbotFlow(app.DB, org.ID, flow.ID, "", nil)
+	require.NoError(t, err)
+	app.InvalidateChatbotFlowsCache(org.ID)
+
+	assert.Equal(t, "Your name?", firstMessage(v1.Version))
+	assert.Equal(t, "What's your name?", firstMessage(v2.Version))
+	assert.Equal(t, "What's your name?", firstMessage(0), "sessions from before versioning run the live version")
+
+	// Rolling back to version 1 publishes version 3; version 2 sessions keep version 2
+	target, err := loadChatbotFlowRevision(app.DB, org.ID, flow.ID, v1.Version)
+	require.NoError(t, err)
+	require.NoError(t, restoreChatbotFlowDraft(app.DB, flow, target))
+	v3, err := publishChatbotFlow(app.DB, org.ID, flow.ID, "", nil)
+	require.NoError(t, err)
+	app.InvalidateChatbotFlowsCache(org.ID)
+
+	assert.Equal(t, "What's your name?", firstMessage(v2.Version))
+	assert.Equal(t, "Your name?", firstMessage(v3.Version))
+	assert.Equal(t, "Your name?", firstMessage(0))
+
+	// Disabling the flow stops pinned sessions too
+	require.NoError(t, app.DB.Model(flow).Update("is_enabled", false).Error)
+	app.InvalidateChatbotFlowsCache(org.ID)
+	_, err = app.getPinnedChatbotFlow(org.ID, flow.ID, v2.Version)
+	assert.Error(t, err)
+}
+
+func TestGetChatbotFlowsCached_LeavesOutUnloadablePublishedVersion(t *testing.T) {
+	app := newSLATestApp(t)
+	if app.Redis == nil {
+		t.Skip("Redis not available")
+	}
+	org := testutil.CreateTestOrganization(t, app.DB)
+
+	flow := &models.ChatbotFlow{
+		BaseModel:      models.BaseModel{ID: uuid.New()},
+		OrganizationID: org.ID,
+		Name:           "Support",
+		IsEnabled:      true,
+		Steps: []models.ChatbotFlowStep{
+			{BaseModel: models.BaseModel{ID: uuid.New()}, StepName: "ask_name", StepOrder: 1, Message: "Your name?", InputType: models.InputTypeText},
+		},
+	}
+	require.NoError(t, app.DB.Create(flow).Error)
+	v1, err := publishChatbotFlow(app.DB, org.ID, flow.ID, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, app.DB.Model(&models.ChatbotFlowStep{}).Where("flow_id = ?", flow.ID).
+		Update("message", "Unpublished draft").Error)
+
+	// The published version can't be loaded: the draft must not run instead
+	require.NoError(t, app.DB.Model(flow).Update("published_version", v1.Version+1).Error)
+	app.InvalidateChatbotFlowsCache(org.ID)
+	flows, err := app.getChatbotFlowsCached(org.ID)
+	require.NoError(t, err)
+	assert.Empty(t, flows)
+	cached, err := app.Redis.Exists(context.Background(), flowsCachePrefix+org.ID.String()).Result()
+	require.NoError(t, err)
+	assert.Zero(t, cached, "an incomplete list is not cached")
+
+	require.NoError(t, app.DB.Model(flow).Update("published_version", v1.Version).Error)
+	flows, err = app.getChatbotFlowsCached(org.ID)
+	require.NoError(t, err)
+	require.Len(t, flows, 1)
+	require.NotEmpty(t, flows[0].Steps)
+	assert.Equal(t, "Your name?", flows[0].Steps[0].Message)
+}
+
+func TestRestoreChatbotFlowDraft_RestoresTranslations(t *testing.T) {
+	app := newSLATestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+
+	flow := &models.ChatbotFlow{
+		BaseModel:         models.BaseModel{ID: uuid.New()},
+		OrganizationID:    org.ID,
+		Name:              "Support",
+		IsEnabled:         true,
+		InitialMessage:    "Welcome",
+		CompletionMessage: "Thanks",
+		Translations:      models.JSONB{"hi": map[string]interface{}{"initial_message": "स्वागत है", "completion_message": "धन्यवाद"}},
+	}
+	require.NoError(t, app.DB.Create(flow).Error)
+	v1, err := publishChatbotFlow(app.DB, org.ID, flow.ID, "", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, app.DB.Model(flow).Updates(map[string]interface{}{
+		"initial_message": "Hello",
+		"translations":    models.JSONB{"hi": map[string]interface{}{"initial_message": "नमस्ते"}},
+	}).Error)
+	_, err = publishChatbotFlow(app.DB, org.ID, flow.ID, "", nil)
+	require.NoError(t, err)
+
+	target, err := loadChatbotFlowRevision(app.DB, org.ID, flow.ID, v1.Version)
+	require.NoError(t, err)
+	require.NoError(t, restoreChatbotFlowDraft(app.DB, flow, target))
+
+	draft, err := loadChatbotFlowRevision(app.DB, org.ID, flow.ID, draftFlowVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome", draft.InitialMessage)
+	assert.Equal(t, models.JSONB{"hi": map[string]interface{}{"initial_message": "स्वागत है", "completion_message": "धन्यवाद"}}, draft.Translations)
+}
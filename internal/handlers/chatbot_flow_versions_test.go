@@ -0,0 +1,252 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+func TestApp_ChatbotFlowVersioning(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	perms := getChatbotFlowPermissions(t, app)
+	role := testutil.CreateTestRole(t, app.DB, org.ID, "flow-admin", perms)
+	user := testutil.CreateTestUser(t, app.DB, org.ID,
+		testutil.WithEmail(testutil.UniqueEmail("flow-versions")),
+		testutil.WithRoleID(&role.ID),
+	)
+
+	loadFlow := func(t *testing.T, id uuid.UUID) models.ChatbotFlow {
+		t.Helper()
+		var flow models.ChatbotFlow
+		require.NoError(t, app.DB.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("step_order ASC")
+		}).First(&flow, "id = ?", id).Error)
+		return flow
+	}
+
+	// Create publishes version 1
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"name":             "Support",
+		"trigger_keywords": []string{"help"},
+		"enabled":          true,
+		"steps": []map[string]any{
+			{"step_name": "ask_name", "message": "Your name?", "input_type": "text", "store_as": "name"},
+		},
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.CreateChatbotFlow(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var created struct {
+		Data struct {
+			ID uuid.UUID `json:"id"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &created))
+	flowID := created.Data.ID
+
+	flow := loadFlow(t, flowID)
+	assert.Equal(t, 1, flow.PublishedVersion)
+	assert.False(t, flow.HasDraftChanges)
+
+	t.Run("publish without changes", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, map[string]any{})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", flowID.String())
+
+		require.NoError(t, app.PublishChatbotFlow(req))
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("enable toggle is not a draft change", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, map[string]any{"enabled": true})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", flowID.String())
+
+		require.NoError(t, app.UpdateChatbotFlow(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		assert.False(t, loadFlow(t, flowID).HasDraftChanges)
+	})
+
+	t.Run("edit goes to the draft and publish creates version 2", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"steps": []map[string]any{
+				{"step_name": "ask_name", "message": "What's your name?", "input_type": "text", "store_as": "name"},
+				{"step_name": "ask_email", "message": "Your email?", "input_type": "email", "store_as": "email"},
+			},
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", flowID.String())
+		require.NoError(t, app.UpdateChatbotFlow(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		flow := loadFlow(t, flowID)
+		assert.Equal(t, 1, flow.PublishedVersion)
+		assert.True(t, flow.HasDraftChanges)
+
+		req = testutil.NewJSONRequest(t, map[string]any{"notes": "Ask for email"})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", flowID.String())
+		require.NoError(t, app.PublishChatbotFlow(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data struct {
+				Version handlers.ChatbotFlowVersionResponse `json:"version"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.Equal(t, 2, resp.Data.Version.Version)
+		assert.Equal(t, "Ask for email", resp.Data.Version.Notes)
+		assert.True(t, resp.Data.Version.IsLive)
+
+		flow = loadFlow(t, flowID)
+		assert.Equal(t, 2, flow.PublishedVersion)
+		assert.False(t, flow.HasDraftChanges)
+	})
+
+	t.Run("list versions", func(t *testing.T) {
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", flowID.String())
+		require.NoError(t, app.ListChatbotFlowVersions(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data struct {
+				Versions []handlers.ChatbotFlowVersionResponse `json:"versions"`
+				Total    int64                                 `json:"total"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		require.Len(t, resp.Data.Versions, 2)
+		assert.Equal(t, int64(2), resp.Data.Total)
+		assert.Equal(t, 2, resp.Data.Versions[0].Version)
+		assert.True(t, resp.Data.Versions[0].IsLive)
+		assert.False(t, resp.Data.Versions[1].IsLive)
+	})
+
+	t.Run("diff versions", func(t *testing.T) {
+		req := testutil.NewGETRequest(t)
+		req.RequestCtx.QueryArgs().Parse("from=1&to=2")
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", flowID.String())
+		require.NoError(t, app.DiffChatbotFlowVersions(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data handlers.ChatbotFlowDiffResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.Equal(t, "1", resp.Data.From)
+		assert.Equal(t, "2", resp.Data.To)
+		assert.Empty(t, resp.Data.Flow)
+		assert.Equal(t, []string{"ask_email"}, resp.Data.StepsAdded)
+		assert.Empty(t, resp.Data.StepsRemoved)
+		require.Len(t, resp.Data.StepsChanged, 1)
+		assert.Equal(t, "ask_name", resp.Data.StepsChanged[0].StepName)
+		assert.Equal(t, []handlers.FlowFieldChange{
+			{Field: "message", From: "Your name?", To: "What's your name?"},
+		}, resp.Data.StepsChanged[0].Changes)
+	})
+
+	t.Run("get version", func(t *testing.T) {
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", flowID.String())
+		testutil.SetPathParam(req, "version", "1")
+		require.NoError(t, app.GetChatbotFlowVersion(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data struct {
+				Flow models.ChatbotFlow `json:"flow"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.Equal(t, flowID, resp.Data.Flow.ID)
+		require.Len(t, resp.Data.Flow.Steps, 1)
+		assert.Equal(t, "Your name?", resp.Data.Flow.Steps[0].Message)
+	})
+
+	t.Run("rollback republishes the old definition", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, nil)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", flowID.String())
+		testutil.SetPathParam(req, "version", "1")
+		require.NoError(t, app.RollbackChatbotFlow(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		flow := loadFlow(t, flowID)
+		assert.Equal(t, 3, flow.PublishedVersion)
+		assert.False(t, flow.HasDraftChanges)
+		require.Len(t, flow.Steps, 1)
+		assert.Equal(t, "Your name?", flow.Steps[0].Message)
+
+		var version models.ChatbotFlowVersion
+		require.NoError(t, app.DB.Where("flow_id = ? AND version = 3", flowID).First(&version).Error)
+		assert.Equal(t, "Rolled back to version 1", version.Notes)
+	})
+
+	t.Run("simulate a published version", func(t *testing.T) {
+		status, resp := simulateFlow(t, app, org.ID, user.ID, flowID, map[string]any{"version": 2})
+		require.Equal(t, fasthttp.StatusOK, status)
+		assert.Equal(t, []string{"What's your name?"}, bodiesOf(resp.Messages))
+
+		status, _ = simulateFlow(t, app, org.ID, user.ID, flowID, map[string]any{"version": 9})
+		assert.Equal(t, fasthttp.StatusNotFound, status)
+	})
+
+	t.Run("legacy flow keeps its pre-edit definition live", func(t *testing.T) {
+		legacy := createTestChatbotFlow(t, app, org.ID, "Legacy")
+
+		req := testutil.NewJSONRequest(t, map[string]any{"name": "Legacy v2"})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", legacy.ID.String())
+		require.NoError(t, app.UpdateChatbotFlow(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		flow := loadFlow(t, legacy.ID)
+		assert.Equal(t, 1, flow.PublishedVersion)
+		assert.True(t, flow.HasDraftChanges)
+
+		var version models.ChatbotFlowVersion
+		require.NoError(t, app.DB.Where("flow_id = ? AND version = 1", legacy.ID).First(&version).Error)
+		assert.Equal(t, "Legacy", version.Definition["name"])
+	})
+
+	t.Run("clearing trigger keywords is a draft change", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, map[string]any{"trigger_keywords": []string{}})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", flowID.String())
+		require.NoError(t, app.UpdateChatbotFlow(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		flow := loadFlow(t, flowID)
+		assert.True(t, flow.HasDraftChanges)
+		assert.Empty(t, flow.TriggerKeywords)
+	})
+
+	t.Run("delete removes versions", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, nil)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", flowID.String())
+		require.NoError(t, app.DeleteChatbotFlow(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var count int64
+		require.NoError(t, app.DB.Model(&models.ChatbotFlowVersion{}).Where("flow_id = ?", flowID).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/shridarpatil/whatomate/internal/contactutil"
 	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/shridarpatil/whatomate/pkg/llm"
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
 )
 
@@ -44,6 +46,10 @@ type IncomingTextMessage struct {
 			Name         string `json:"name"`
 		} `json:"nfm_reply,omitempty"`
 	} `json:"interactive,omitempty"`
+	Button *struct {
+		Payload string `json:"payload"`
+		Text    string `json:"text"`
+	} `json:"button,omitempty"` // Quick reply button of a template message
 	Image *struct {
 		ID       string `json:"id"`
 		MimeType string `json:"mime_type"`
@@ -174,6 +180,11 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 				}
 			}
 		}
+	} else if msg.Type == "button" && msg.Button != nil {
+		// Handle template quick reply button
+		messageText = msg.Button.Text
+		buttonID = msg.Button.Payload
+		messageType = "button_reply"
 	} else if msg.Type == "image" && msg.Image != nil {
 		// Handle image message
 		messageText = msg.Image.Caption
@@ -284,6 +295,19 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	// Clear chatbot tracking since client has replied
 	a.ClearContactChatbotTracking(contact.ID)
 
+	// Ratings answer the satisfaction survey without reopening the conversation
+	if a.handleCSATResponse(account, contact, buttonID, messageText, flowResponseData) {
+		return
+	}
+
+	// A reply reopens a pending, snoozed or resolved conversation
+	a.reopenConversation(account.OrganizationID, contact.ID, account.Name)
+
+	// Opt-out and opt-in keywords are honored even when the chatbot is disabled
+	if a.handleOptOutKeywords(account, contact, messageText) {
+		return
+	}
+
 	// Check for active agent transfer - skip chatbot processing if transferred
 	if a.hasActiveAgentTransfer(account.OrganizationID, contact.ID) {
 		a.Log.Info("Contact has active agent transfer, skipping chatbot processing",
@@ -301,27 +325,31 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	if !settings.IsEnabled {
 		a.Log.Debug("Chatbot not enabled for this account, creating transfer for agent queue", "account", account.Name, "settings_id", settings.ID)
 		// Create transfer to agent queue when chatbot is disabled
-		a.createTransferToQueue(account, contact, models.TransferSourceChatbotDisabled)
+		a.createTransferToQueue(account, contact, models.TransferSourceChatbotDisabled, nil)
 		return
 	}
 	a.Log.Info("Chatbot settings loaded", "settings_id", settings.ID, "is_enabled", settings.IsEnabled, "ai_enabled", settings.AI.Enabled, "ai_provider", settings.AI.Provider, "default_response", settings.DefaultResponse)
 
+	// Pick up the contact's language from what they typed (button replies are our own text)
+	if buttonID == "" {
+		a.detectContactLanguage(settings, contact, messageText)
+	}
+
 	// Check business hours if enabled
-	if settings.BusinessHours.Enabled && len(settings.BusinessHours.Hours) > 0 {
-		if !a.isWithinBusinessHours(settings.BusinessHours.Hours) {
-			// If automated responses are not allowed outside hours, send out-of-hours message and stop
-			if !settings.BusinessHours.AllowAutomatedOutside {
-				a.Log.Info("Outside business hours, sending out of hours message")
-				if settings.BusinessHours.OutOfHoursMessage != "" {
-					if err := a.sendAndSaveTextMessage(account, contact, settings.BusinessHours.OutOfHoursMessage); err != nil {
-						a.Log.Error("Failed to send out of hours message", "error", err, "contact", contact.PhoneNumber)
-					}
+	if open, outOfHoursMessage := a.checkBusinessHours(settings); !open {
+		// If automated responses are not allowed outside hours, send out-of-hours message and stop
+		if !settings.BusinessHours.AllowAutomatedOutside {
+			a.Log.Info("Outside business hours, sending out of hours message")
+			if outOfHoursMessage != "" {
+				outOfHoursMessage = localized(settings.Translations, contactLanguage(contact), "out_of_hours_message", outOfHoursMessage)
+				if err := a.sendAndSaveTextMessage(account, contact, outOfHoursMessage); err != nil {
+					a.Log.Error("Failed to send out of hours message", "error", err, "contact", contact.PhoneNumber)
 				}
-				return
 			}
-			// AllowAutomatedOutsideHours is true, continue processing flows/keywords/AI
-			a.Log.Info("Outside business hours but automated responses allowed, continuing")
+			return
 		}
+		// AllowAutomatedOutsideHours is true, continue processing flows/keywords/AI
+		a.Log.Info("Outside business hours but automated responses allowed, continuing")
 	}
 
 	// Only process text and interactive messages for chatbot
@@ -338,21 +366,28 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	// Log incoming message to session
 	a.logSessionMessage(session.ID, models.DirectionIncoming, messageText, "keyword_check")
 
+	// A reply cancels a pending wait step; the message is then handled as if no flow were active
+	if session.WaitUntil != nil {
+		a.cancelFlowWait(session)
+	}
+
 	// Check for transfer keyword BEFORE sending greeting (transfer takes priority)
 	keywordResponse, keywordMatched := a.matchKeywordRules(account.OrganizationID, account.Name, messageText)
+	if keywordMatched {
+		keywordResponse = localizeKeywordResponse(keywordResponse, contactLanguage(contact))
+	}
 	if keywordMatched && keywordResponse.ResponseType == models.ResponseTypeTransfer {
 		a.Log.Info("Transfer keyword matched", "response", keywordResponse.Body)
 		// Check business hours - if outside hours, send out of hours message instead
-		if settings.BusinessHours.Enabled && len(settings.BusinessHours.Hours) > 0 {
-			if !a.isWithinBusinessHours(settings.BusinessHours.Hours) {
-				a.Log.Info("Outside business hours, sending out of hours message instead of transfer")
-				if settings.BusinessHours.OutOfHoursMessage != "" {
-					if err := a.sendAndSaveTextMessage(account, contact, settings.BusinessHours.OutOfHoursMessage); err != nil {
-						a.Log.Error("Failed to send out of hours message", "error", err, "contact", contact.PhoneNumber)
-					}
+		if open, outOfHoursMessage := a.checkBusinessHours(settings); !open {
+			a.Log.Info("Outside business hours, sending out of hours message instead of transfer")
+			if outOfHoursMessage != "" {
+				outOfHoursMessage = localized(settings.Translations, contactLanguage(contact), "out_of_hours_message", outOfHoursMessage)
+				if err := a.sendAndSaveTextMessage(account, contact, outOfHoursMessage); err != nil {
+					a.Log.Error("Failed to send out of hours message", "error", err, "contact", contact.PhoneNumber)
 				}
-				return
 			}
+			return
 		}
 		// Within business hours - send transfer message and create transfer
 		if keywordResponse.Body != "" {
@@ -360,7 +395,7 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 				a.Log.Error("Failed to send transfer message", "error", err, "contact", contact.PhoneNumber)
 			}
 		}
-		a.createTransferFromKeyword(account, contact)
+		a.createTransferFromKeyword(account, contact, keywordResponse.Skills)
 		return
 	}
 
@@ -379,28 +414,19 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	// Send greeting message for new sessions (only if no flow was triggered)
 	if isNewSession && settings.DefaultResponse != "" {
 		a.Log.Info("New session - sending greeting message", "contact", contact.PhoneNumber)
-		if len(settings.GreetingButtons) > 0 {
-			greetingButtons := make([]map[string]interface{}, 0)
-			for _, btn := range settings.GreetingButtons {
-				if btnMap, ok := btn.(map[string]interface{}); ok {
-					greetingButtons = append(greetingButtons, btnMap)
-				}
-			}
-			if len(greetingButtons) > 0 {
-				if err := a.sendAndSaveInteractiveButtons(account, contact, settings.DefaultResponse, greetingButtons); err != nil {
-					a.Log.Error("Failed to send greeting buttons", "error", err, "contact", contact.PhoneNumber)
-				}
-			} else {
-				if err := a.sendAndSaveTextMessage(account, contact, settings.DefaultResponse); err != nil {
-					a.Log.Error("Failed to send greeting message", "error", err, "contact", contact.PhoneNumber)
-				}
+		lang := contactLanguage(contact)
+		greeting := localized(settings.Translations, lang, "greeting_message", settings.DefaultResponse)
+		greetingButtons := localizedButtons(settings.Translations, lang, "greeting_buttons", buttonMaps(settings.GreetingButtons))
+		if len(greetingButtons) > 0 {
+			if err := a.sendAndSaveInteractiveButtons(account, contact, greeting, greetingButtons); err != nil {
+				a.Log.Error("Failed to send greeting buttons", "error", err, "contact", contact.PhoneNumber)
 			}
 		} else {
-			if err := a.sendAndSaveTextMessage(account, contact, settings.DefaultResponse); err != nil {
+			if err := a.sendAndSaveTextMessage(account, contact, greeting); err != nil {
 				a.Log.Error("Failed to send greeting message", "error", err, "contact", contact.PhoneNumber)
 			}
 		}
-		a.logSessionMessage(session.ID, models.DirectionOutgoing, settings.DefaultResponse, "greeting")
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, greeting, "greeting")
 		return // After greeting, don't process further for new sessions
 	}
 
@@ -424,7 +450,8 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 	}
 
 	// If no keyword matched, try AI response if enabled
-	if settings.AI.Enabled && settings.AI.Provider != "" && settings.AI.APIKey != "" {
+	_, aiErr := a.newAIProvider(settings)
+	if settings.AI.Enabled && aiErr == nil {
 		a.Log.Info("Attempting AI response", "provider", settings.AI.Provider, "model", settings.AI.Model)
 		aiResponse, err := a.generateAIResponse(settings, session, messageText)
 		if err != nil {
@@ -441,35 +468,26 @@ func (a *App) processIncomingMessageFull(phoneNumberID string, msg IncomingTextM
 			a.Log.Warn("AI returned empty response")
 		}
 	} else {
-		a.Log.Info("AI not configured", "ai_enabled", settings.AI.Enabled, "has_provider", settings.AI.Provider != "", "has_api_key", settings.AI.APIKey != "")
+		a.Log.Info("AI not configured", "ai_enabled", settings.AI.Enabled, "error", aiErr)
 	}
 
 	// If no AI response or AI not enabled, send fallback message (for existing sessions)
 	// Greeting is already sent for new sessions above
 	if settings.FallbackMessage != "" && !isNewSession {
-		a.Log.Info("Sending fallback message", "response", settings.FallbackMessage)
-		if len(settings.FallbackButtons) > 0 {
-			fallbackButtons := make([]map[string]interface{}, 0)
-			for _, btn := range settings.FallbackButtons {
-				if btnMap, ok := btn.(map[string]interface{}); ok {
-					fallbackButtons = append(fallbackButtons, btnMap)
-				}
-			}
-			if len(fallbackButtons) > 0 {
-				if err := a.sendAndSaveInteractiveButtons(account, contact, settings.FallbackMessage, fallbackButtons); err != nil {
-					a.Log.Error("Failed to send fallback buttons", "error", err, "contact", contact.PhoneNumber)
-				}
-			} else {
-				if err := a.sendAndSaveTextMessage(account, contact, settings.FallbackMessage); err != nil {
-					a.Log.Error("Failed to send fallback message", "error", err, "contact", contact.PhoneNumber)
-				}
+		lang := contactLanguage(contact)
+		fallback := localized(settings.Translations, lang, "fallback_message", settings.FallbackMessage)
+		a.Log.Info("Sending fallback message", "response", fallback)
+		fallbackButtons := localizedButtons(settings.Translations, lang, "fallback_buttons", buttonMaps(settings.FallbackButtons))
+		if len(fallbackButtons) > 0 {
+			if err := a.sendAndSaveInteractiveButtons(account, contact, fallback, fallbackButtons); err != nil {
+				a.Log.Error("Failed to send fallback buttons", "error", err, "contact", contact.PhoneNumber)
 			}
 		} else {
-			if err := a.sendAndSaveTextMessage(account, contact, settings.FallbackMessage); err != nil {
+			if err := a.sendAndSaveTextMessage(account, contact, fallback); err != nil {
 				a.Log.Error("Failed to send fallback message", "error", err, "contact", contact.PhoneNumber)
 			}
 		}
-		a.logSessionMessage(session.ID, models.DirectionOutgoing, settings.FallbackMessage, "fallback_response")
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, fallback, "fallback_response")
 	} else if !isNewSession {
 		a.Log.Info("No fallback message configured for existing session")
 	}
@@ -480,89 +498,18 @@ type KeywordResponse struct {
 	Body         string
 	Buttons      []map[string]interface{}
 	ResponseType models.ResponseType // text, transfer
+	Translations models.JSONB        // per-language body and button titles of the rule
+	Skills       []string            // skills the agent of a transfer needs
 }
 
 // matchKeywordRules checks if the message matches any keyword rules
 func (a *App) matchKeywordRules(orgID uuid.UUID, accountName, messageText string) (*KeywordResponse, bool) {
-	// Use cached keyword rules (includes both account-specific and global rules)
-	rules, err := a.getKeywordRulesCached(orgID, accountName)
-	if err != nil {
-		a.Log.Error("Failed to fetch keyword rules", "error", err)
+	match := a.findKeywordMatch(orgID, accountName, messageText)
+	if match == nil {
 		return nil, false
 	}
-
-	messageLower := strings.ToLower(messageText)
-
-	for _, rule := range rules {
-		for _, keyword := range rule.Keywords {
-			keywordLower := strings.ToLower(keyword)
-			matched := false
-
-			switch rule.MatchType {
-			case models.MatchTypeExact:
-				if rule.CaseSensitive {
-					matched = messageText == keyword
-				} else {
-					matched = messageLower == keywordLower
-				}
-			case models.MatchTypeContains:
-				if rule.CaseSensitive {
-					matched = strings.Contains(messageText, keyword)
-				} else {
-					matched = strings.Contains(messageLower, keywordLower)
-				}
-			case models.MatchTypeStartsWith:
-				if rule.CaseSensitive {
-					matched = strings.HasPrefix(messageText, keyword)
-				} else {
-					matched = strings.HasPrefix(messageLower, keywordLower)
-				}
-			case models.MatchTypeRegex:
-				re, err := regexp.Compile(keyword)
-				if err == nil {
-					matched = re.MatchString(messageText)
-				}
-			default:
-				// Default to contains
-				matched = strings.Contains(messageLower, keywordLower)
-			}
-
-			if matched {
-				response := &KeywordResponse{
-					ResponseType: rule.ResponseType,
-				}
-
-				// For transfer type, use body as the transfer message
-				if rule.ResponseType == models.ResponseTypeTransfer {
-					if body, ok := rule.ResponseContent["body"].(string); ok {
-						response.Body = body
-					}
-					return response, true
-				}
-
-				// Get response body
-				if body, ok := rule.ResponseContent["body"].(string); ok {
-					response.Body = body
-				}
-
-				// Get buttons if present
-				if buttons, ok := rule.ResponseContent["buttons"].([]interface{}); ok && len(buttons) > 0 {
-					response.Buttons = make([]map[string]interface{}, 0, len(buttons))
-					for _, btn := range buttons {
-						if btnMap, ok := btn.(map[string]interface{}); ok {
-							response.Buttons = append(response.Buttons, btnMap)
-						}
-					}
-				}
-
-				if response.Body != "" {
-					return response, true
-				}
-			}
-		}
-	}
-
-	return nil, false
+	a.Log.Debug("Keyword rule matched", "rule", match.Rule.Name, "match_type", match.Rule.MatchType, "keyword", match.Keyword, "score", match.Score, "method", match.Method)
+	return match.Response, true
 }
 
 // sendAndSaveTextMessage sends a text message and saves it to the database
@@ -581,7 +528,30 @@ func (a *App) sendAndSaveTextMessage(account *models.WhatsAppAccount, contact *m
 // sendAndSaveInteractiveButtons sends an interactive button message and saves it to the database
 // Uses the unified SendOutgoingMessage for consistent behavior
 func (a *App) sendAndSaveInteractiveButtons(account *models.WhatsAppAccount, contact *models.Contact, bodyText string, buttons []map[string]interface{}) error {
-	// Convert buttons to whatsapp.Button format
+	waButtons := toWhatsAppButtons(buttons)
+
+	// Fall back to text if no buttons
+	if len(waButtons) == 0 {
+		return a.sendAndSaveTextMessage(account, contact, bodyText)
+	}
+
+	interactiveType := interactiveTypeForButtons(waButtons)
+
+	ctx := context.Background()
+	_, err := a.SendOutgoingMessage(ctx, OutgoingMessageRequest{
+		Account:         account,
+		Contact:         contact,
+		Type:            models.MessageTypeInteractive,
+		InteractiveType: interactiveType,
+		BodyText:        bodyText,
+		Buttons:         waButtons,
+	}, ChatbotSendOptions())
+	return err
+}
+
+// toWhatsAppButtons converts configured buttons to whatsapp.Button format,
+// generating missing IDs and dropping buttons without a title
+func toWhatsAppButtons(buttons []map[string]interface{}) []whatsapp.Button {
 	waButtons := make([]whatsapp.Button, 0, len(buttons))
 	for i, btn := range buttons {
 		if i >= 10 {
@@ -600,28 +570,15 @@ func (a *App) sendAndSaveInteractiveButtons(account *models.WhatsAppAccount, con
 			Title: buttonTitle,
 		})
 	}
+	return waButtons
+}
 
-	// Fall back to text if no buttons
-	if len(waButtons) == 0 {
-		return a.sendAndSaveTextMessage(account, contact, bodyText)
-	}
-
-	// Determine interactive type based on button count
-	interactiveType := "button"
-	if len(waButtons) > 3 {
-		interactiveType = "list"
+// interactiveTypeForButtons determines the interactive type based on button count
+func interactiveTypeForButtons(buttons []whatsapp.Button) string {
+	if len(buttons) > 3 {
+		return "list"
 	}
-
-	ctx := context.Background()
-	_, err := a.SendOutgoingMessage(ctx, OutgoingMessageRequest{
-		Account:         account,
-		Contact:         contact,
-		Type:            models.MessageTypeInteractive,
-		InteractiveType: interactiveType,
-		BodyText:        bodyText,
-		Buttons:         waButtons,
-	}, ChatbotSendOptions())
-	return err
+	return "button"
 }
 
 // sendAndSaveCTAURLButton sends a CTA URL button message and saves it to the database
@@ -664,10 +621,10 @@ func (a *App) sendAndSaveFlowMessage(account *models.WhatsAppAccount, contact *m
 func (a *App) getOrCreateSession(orgID, contactID uuid.UUID, accountName, phoneNumber string, timeoutMins int) (*models.ChatbotSession, bool) {
 	now := time.Now()
 
-	// Look for an active session that hasn't timed out
+	// Look for an active session that hasn't timed out (sessions paused on a wait step don't time out)
 	var session models.ChatbotSession
 	timeout := now.Add(-time.Duration(timeoutMins) * time.Minute)
-	result := a.DB.Where("organization_id = ? AND contact_id = ? AND whats_app_account = ? AND status = ? AND last_activity_at > ?",
+	result := a.DB.Where("organization_id = ? AND contact_id = ? AND whats_app_account = ? AND status = ? AND (last_activity_at > ? OR wait_until IS NOT NULL)",
 		orgID, contactID, accountName, models.SessionStatusActive, timeout).First(&session)
 
 	if result.Error == nil {
@@ -740,6 +697,7 @@ func (a *App) startFlow(account *models.WhatsAppAccount, session *models.Chatbot
 
 	// Update session with flow info
 	session.CurrentFlowID = &flow.ID
+	session.CurrentFlowVersion = flow.PublishedVersion
 	session.CurrentStep = ""
 	session.StepRetries = 0
 	session.SessionData = models.JSONB{
@@ -750,10 +708,11 @@ func (a *App) startFlow(account *models.WhatsAppAccount, session *models.Chatbot
 
 	// Send initial message if configured
 	if flow.InitialMessage != "" {
-		if err := a.sendAndSaveTextMessage(account, contact, flow.InitialMessage); err != nil {
+		initialMessage := localized(flow.Translations, contactLanguage(contact), "initial_message", flow.InitialMessage)
+		if err := a.sendAndSaveTextMessage(account, contact, initialMessage); err != nil {
 			a.Log.Error("Failed to send flow initial message", "error", err, "contact", contact.PhoneNumber)
 		}
-		a.logSessionMessage(session.ID, models.DirectionOutgoing, flow.InitialMessage, "flow_start")
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, initialMessage, "flow_start")
 	}
 
 	// Send first step message (with skip check)
@@ -772,8 +731,8 @@ func (a *App) startFlow(account *models.WhatsAppAccount, session *models.Chatbot
 
 // processFlowResponse handles user response within a flow
 func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, userInput string, buttonID string, flowResponseData map[string]interface{}) {
-	// Load the current flow from cache
-	flow, err := a.getChatbotFlowByIDCached(account.OrganizationID, *session.CurrentFlowID)
+	// Load the flow version the session started on
+	flow, err := a.getPinnedChatbotFlow(account.OrganizationID, *session.CurrentFlowID, session.CurrentFlowVersion)
 	if err != nil {
 		a.Log.Error("Failed to load flow", "error", err)
 		a.exitFlow(session)
@@ -781,109 +740,55 @@ func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *mode
 	}
 
 	// Check for cancel keywords
-	userInputLower := strings.ToLower(userInput)
-	for _, cancelKw := range flow.CancelKeywords {
-		if strings.Contains(userInputLower, strings.ToLower(cancelKw)) {
-			if err := a.sendAndSaveTextMessage(account, contact, "Flow cancelled."); err != nil {
-				a.Log.Error("Failed to send flow cancel message", "error", err, "contact", contact.PhoneNumber)
-			}
-			a.logSessionMessage(session.ID, models.DirectionOutgoing, "Flow cancelled.", "flow_cancel")
-			a.exitFlow(session)
-			return
+	if matchCancelKeyword(flow, userInput) {
+		if err := a.sendAndSaveTextMessage(account, contact, flowCancelledMessage); err != nil {
+			a.Log.Error("Failed to send flow cancel message", "error", err, "contact", contact.PhoneNumber)
 		}
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, flowCancelledMessage, "flow_cancel")
+		a.exitFlow(session)
+		return
 	}
 
 	// Find current step
-	var currentStep *models.ChatbotFlowStep
-	var currentStepIndex int
-	for i, step := range flow.Steps {
-		if step.StepName == session.CurrentStep {
-			currentStep = &flow.Steps[i]
-			currentStepIndex = i
-			break
-		}
-	}
-
+	currentStep := findFlowStep(flow, session.CurrentStep)
 	if currentStep == nil {
 		a.Log.Error("Current step not found", "step_name", session.CurrentStep)
 		a.exitFlow(session)
 		return
 	}
+	// Button titles typed back by the contact are in their language
+	currentStep = localizeStep(currentStep, contactLanguage(contact))
 
 	// Validate input if required (skip validation for button/list responses)
-	if currentStep.ValidationRegex != "" && buttonID == "" {
-		re, err := regexp.Compile(currentStep.ValidationRegex)
-		if err == nil && !re.MatchString(userInput) {
-			// Invalid input
-			session.StepRetries++
-			if currentStep.RetryOnInvalid && session.StepRetries < currentStep.MaxRetries {
-				a.DB.Model(session).Update("step_retries", session.StepRetries)
-				errorMsg := currentStep.ValidationError
-				if errorMsg == "" {
-					errorMsg = "Invalid input. Please try again."
-				}
-				if err := a.sendAndSaveTextMessage(account, contact, errorMsg); err != nil {
-					a.Log.Error("Failed to send validation error", "error", err, "contact", contact.PhoneNumber)
-				}
-				a.logSessionMessage(session.ID, models.DirectionOutgoing, errorMsg, currentStep.StepName+"_retry")
-				return
+	if !stepInputValid(currentStep, userInput, buttonID) {
+		// Invalid input
+		session.StepRetries++
+		if currentStep.RetryOnInvalid && session.StepRetries < currentStep.MaxRetries {
+			a.DB.Model(session).Update("step_retries", session.StepRetries)
+			errorMsg := stepValidationError(currentStep)
+			if err := a.sendAndSaveTextMessage(account, contact, errorMsg); err != nil {
+				a.Log.Error("Failed to send validation error", "error", err, "contact", contact.PhoneNumber)
 			}
-			// Max retries exceeded, continue anyway or exit
-			a.Log.Warn("Max retries exceeded", "step", currentStep.StepName)
+			a.logSessionMessage(session.ID, models.DirectionOutgoing, errorMsg, currentStep.StepName+"_retry")
+			return
 		}
+		// Max retries exceeded, continue anyway or exit
+		a.Log.Warn("Max retries exceeded", "step", currentStep.StepName)
 	}
 
 	// Auto-validate button responses when step expects button/select input
-	// Only validate if InputType is button/select, or if buttons are configured and user clicked a button
-	shouldValidateButtons := len(currentStep.Buttons) > 0 &&
-		(currentStep.InputType == models.InputTypeButton || currentStep.InputType == models.InputTypeSelect || buttonID != "")
-
-	if shouldValidateButtons {
-		isValidButton := false
-		userInputLower := strings.ToLower(userInput)
-
-		// Check if buttonID or userInput matches any configured button
-		for i, btn := range currentStep.Buttons {
-			if btnMap, ok := btn.(map[string]interface{}); ok {
-				btnID, _ := btnMap["id"].(string)
-				btnTitle, _ := btnMap["title"].(string)
-
-				// Auto-generate ID if not set (must match what sendInteractiveButtons does)
-				if btnID == "" {
-					btnID = fmt.Sprintf("btn_%d", i+1)
-				}
-
-				// Match by buttonID (exact match) or by title (case-insensitive)
-				if buttonID != "" && buttonID == btnID {
-					isValidButton = true
-					break
-				}
-				if strings.ToLower(btnTitle) == userInputLower || btnID == userInput {
-					isValidButton = true
-					// Set buttonID if not already set (user typed the button text)
-					if buttonID == "" {
-						buttonID = btnID
-					}
-					break
-				}
-			}
-		}
-
+	if stepExpectsButton(currentStep, buttonID) {
+		matchedID, isValidButton := matchStepButton(currentStep, userInput, buttonID)
 		if !isValidButton {
 			// Invalid button selection
 			session.StepRetries++
 			a.Log.Debug("Invalid button selection", "buttonID", buttonID, "userInput", userInput, "step", currentStep.StepName, "retries", session.StepRetries)
 			a.DB.Model(session).Update("step_retries", session.StepRetries)
 
-			maxRetries := currentStep.MaxRetries
-			if maxRetries == 0 {
-				maxRetries = 3 // Default max retries
-			}
-
-			if session.StepRetries >= maxRetries {
+			if session.StepRetries >= stepMaxButtonRetries(currentStep) {
 				// Max retries exceeded - exit flow and close conversation
 				a.Log.Warn("Max button retries exceeded, closing conversation", "step", currentStep.StepName)
-				if err := a.sendAndSaveTextMessage(account, contact, "Sorry, we couldn't continue. Please try again later."); err != nil {
+				if err := a.sendAndSaveTextMessage(account, contact, flowMaxRetriesMessage); err != nil {
 					a.Log.Error("Failed to send max retries message", "error", err, "contact", contact.PhoneNumber)
 				}
 				a.exitFlow(session)
@@ -895,85 +800,43 @@ func (a *App) processFlowResponse(account *models.WhatsAppAccount, session *mode
 			a.sendStepMessage(account, session, contact, currentStep)
 			return
 		}
+		// Set buttonID if not already set (user typed the button text)
+		buttonID = matchedID
 	}
 
-	// Store the user's response (use buttonID if available, otherwise userInput)
-	if currentStep.StoreAs != "" {
-		sessionData := session.SessionData
-		if sessionData == nil {
-			sessionData = models.JSONB{}
+	// Store the user's response (use buttonID if available, otherwise userInput).
+	// AI steps store the model's reply or extraction instead.
+	if currentStep.MessageType == models.FlowStepTypeAI {
+		if !a.handleAIStepInput(account, session, contact, currentStep, userInput) {
+			return
 		}
-		// Store both the ID and the title for button responses
-		if buttonID != "" {
-			sessionData[currentStep.StoreAs] = buttonID
-			sessionData[currentStep.StoreAs+"_title"] = userInput
-		} else {
-			sessionData[currentStep.StoreAs] = userInput
+	} else if currentStep.StoreAs != "" {
+		if session.SessionData == nil {
+			session.SessionData = models.JSONB{}
 		}
-		a.DB.Model(session).Update("session_data", sessionData)
-		session.SessionData = sessionData
+		storeStepResponse(session.SessionData, currentStep, userInput, buttonID)
+		a.DB.Model(session).Update("session_data", session.SessionData)
 	}
 
 	// Store WhatsApp Flow response data (from nfm_reply)
 	if len(flowResponseData) > 0 {
-		sessionData := session.SessionData
-		if sessionData == nil {
-			sessionData = models.JSONB{}
-		}
-		// Store each field from the flow response in the session
-		for key, value := range flowResponseData {
-			sessionData[key] = value
-			a.Log.Debug("Stored flow response field", "key", key, "value", value)
+		if session.SessionData == nil {
+			session.SessionData = models.JSONB{}
 		}
-		// Also store the raw flow response for reference
-		sessionData["_flow_response"] = flowResponseData
-		a.DB.Model(session).Update("session_data", sessionData)
-		session.SessionData = sessionData
+		storeFlowResponseData(session.SessionData, flowResponseData)
+		a.DB.Model(session).Update("session_data", session.SessionData)
 		a.Log.Info("Stored WhatsApp Flow response in session", "fields", len(flowResponseData))
 	}
 
-	// Determine next step
-	nextStepName := currentStep.NextStep
-	if nextStepName == "" && currentStepIndex+1 < len(flow.Steps) {
-		nextStepName = flow.Steps[currentStepIndex+1].StepName
-	}
-
-	// Check conditional next - use buttonID first (for button/list responses), then userInput
-	if len(currentStep.ConditionalNext) > 0 {
-		// Try buttonID first (for interactive responses)
-		if buttonID != "" {
-			if next, ok := currentStep.ConditionalNext[buttonID].(string); ok {
-				nextStepName = next
-			} else if next, ok := currentStep.ConditionalNext[userInput].(string); ok {
-				nextStepName = next
-			} else if defaultNext, ok := currentStep.ConditionalNext["default"].(string); ok {
-				nextStepName = defaultNext
-			}
-		} else {
-			// Text input - try matching the text
-			if next, ok := currentStep.ConditionalNext[userInput].(string); ok {
-				nextStepName = next
-			} else if defaultNext, ok := currentStep.ConditionalNext["default"].(string); ok {
-				nextStepName = defaultNext
-			}
-		}
-	}
-
 	// Move to next step or complete flow
+	nextStepName := resolveNextStep(flow, currentStep, userInput, buttonID)
 	if nextStepName == "" {
 		a.completeFlow(account, session, contact, flow)
 		return
 	}
 
 	// Find and execute next step
-	var nextStep *models.ChatbotFlowStep
-	for i, step := range flow.Steps {
-		if step.StepName == nextStepName {
-			nextStep = &flow.Steps[i]
-			break
-		}
-	}
-
+	nextStep := findFlowStep(flow, nextStepName)
 	if nextStep == nil {
 		a.Log.Warn("Next step not found, completing flow", "next_step", nextStepName)
 		a.completeFlow(account, session, contact, flow)
@@ -996,7 +859,8 @@ func (a *App) completeFlow(account *models.WhatsAppAccount, session *models.Chat
 
 	// Send completion message
 	if flow.CompletionMessage != "" {
-		message := a.replaceVariables(flow.CompletionMessage, session.SessionData)
+		completionMessage := localized(flow.Translations, contactLanguage(contact), "completion_message", flow.CompletionMessage)
+		message := a.replaceVariables(completionMessage, session.SessionData)
 		if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
 			a.Log.Error("Failed to send flow completion message", "error", err, "contact", contact.PhoneNumber)
 		}
@@ -1156,16 +1020,18 @@ func (a *App) replaceVariables(message string, data models.JSONB) string {
 
 // sendStepWithSkipCheck checks if a step should be skipped and sends the appropriate step message
 // It takes the full flow to find next steps when skipping
-func (a *App) sendStepWithSkipCheck(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep, flow *models.ChatbotFlow, skippedSteps map[string]bool) {
-	// Prevent infinite loops
-	if skippedSteps == nil {
-		skippedSteps = make(map[string]bool)
-	}
-	if skippedSteps[step.StepName] {
-		a.Log.Warn("Skip loop detected, completing flow", "step", step.StepName)
+func (a *App) sendStepWithSkipCheck(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep, flow *models.ChatbotFlow, visitedSteps map[string]bool) {
+	// Prevent infinite loops: steps that are skipped or don't wait for input must
+	// not lead back to a step already entered since the last input
+	if visitedSteps == nil {
+		visitedSteps = make(map[string]bool)
+	}
+	if visitedSteps[step.StepName] {
+		a.Log.Warn("Step loop detected, completing flow", "step", step.StepName)
 		a.completeFlow(account, session, contact, flow)
 		return
 	}
+	visitedSteps[step.StepName] = true
 
 	// Check if step should be skipped
 	sessionData := session.SessionData
@@ -1175,20 +1041,9 @@ func (a *App) sendStepWithSkipCheck(account *models.WhatsAppAccount, session *mo
 
 	if a.shouldSkipStep(step, sessionData) {
 		a.Log.Info("Skipping step", "step", step.StepName, "condition", step.SkipCondition)
-		skippedSteps[step.StepName] = true
 
 		// Find next step
-		nextStepName := step.NextStep
-		if nextStepName == "" {
-			// Find by step order
-			for i, s := range flow.Steps {
-				if s.StepName == step.StepName && i+1 < len(flow.Steps) {
-					nextStepName = flow.Steps[i+1].StepName
-					break
-				}
-			}
-		}
-
+		nextStepName := defaultNextStep(flow, step)
 		if nextStepName == "" {
 			// No next step, complete flow
 			a.completeFlow(account, session, contact, flow)
@@ -1196,13 +1051,7 @@ func (a *App) sendStepWithSkipCheck(account *models.WhatsAppAccount, session *mo
 		}
 
 		// Find and execute next step
-		var nextStep *models.ChatbotFlowStep
-		for i := range flow.Steps {
-			if flow.Steps[i].StepName == nextStepName {
-				nextStep = &flow.Steps[i]
-				break
-			}
-		}
+		nextStep := findFlowStep(flow, nextStepName)
 
 		if nextStep == nil {
 			a.Log.Warn("Next step not found after skip, completing flow", "next_step", nextStepName)
@@ -1215,28 +1064,24 @@ func (a *App) sendStepWithSkipCheck(account *models.WhatsAppAccount, session *mo
 		a.DB.Model(session).Update("current_step", nextStep.StepName)
 
 		// Recursively check next step (it may also need to be skipped)
-		a.sendStepWithSkipCheck(account, session, contact, nextStep, flow, skippedSteps)
+		a.sendStepWithSkipCheck(account, session, contact, nextStep, flow, visitedSteps)
+		return
+	}
+
+	// Wait steps pause the flow until the wait processor resumes it
+	if step.MessageType == models.FlowStepTypeWait {
+		a.startFlowWait(account, session, contact, step)
 		return
 	}
 
 	// Not skipping - send the step message normally
 	a.sendStepMessage(account, session, contact, step)
 
-	// If input type is "none", automatically advance to next step without waiting for user input
-	if step.InputType == models.InputTypeNone {
-
+	// If input type is "none", automatically advance to next step without waiting for user input.
+	// Action steps never wait for input.
+	if step.InputType == models.InputTypeNone || step.MessageType == models.FlowStepTypeAction {
 		// Find next step
-		nextStepName := step.NextStep
-		if nextStepName == "" {
-			// Find by step order
-			for i, s := range flow.Steps {
-				if s.StepName == step.StepName && i+1 < len(flow.Steps) {
-					nextStepName = flow.Steps[i+1].StepName
-					break
-				}
-			}
-		}
-
+		nextStepName := defaultNextStep(flow, step)
 		if nextStepName == "" {
 			// No next step, complete flow
 			a.completeFlow(account, session, contact, flow)
@@ -1244,13 +1089,7 @@ func (a *App) sendStepWithSkipCheck(account *models.WhatsAppAccount, session *mo
 		}
 
 		// Find and execute next step
-		var nextStep *models.ChatbotFlowStep
-		for i := range flow.Steps {
-			if flow.Steps[i].StepName == nextStepName {
-				nextStep = &flow.Steps[i]
-				break
-			}
-		}
+		nextStep := findFlowStep(flow, nextStepName)
 
 		if nextStep == nil {
 			a.Log.Warn("Next step not found after no-input step, completing flow", "next_step", nextStepName)
@@ -1263,13 +1102,15 @@ func (a *App) sendStepWithSkipCheck(account *models.WhatsAppAccount, session *mo
 		a.DB.Model(session).Update("current_step", nextStep.StepName)
 
 		// Recursively process next step (it may also need to skip or have no input)
-		a.sendStepWithSkipCheck(account, session, contact, nextStep, flow, skippedSteps)
+		a.sendStepWithSkipCheck(account, session, contact, nextStep, flow, visitedSteps)
 	}
 }
 
 // sendStepMessage sends the appropriate message based on step message_type
 func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep) {
 	var message string
+	original := step
+	step = localizeStep(original, contactLanguage(contact))
 
 	a.Log.Debug("sendStepMessage called", "step", step.StepName, "message_type", step.MessageType, "input_config", step.InputConfig)
 
@@ -1280,14 +1121,7 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 		apiResp, err := a.fetchApiResponse(step.ApiConfig, session.SessionData, step.Message)
 		if err != nil {
 			a.Log.Error("Failed to fetch API response", "error", err, "step", step.StepName)
-			// Use fallback message if configured, otherwise use the step message
-			if fallback, ok := step.ApiConfig["fallback_message"].(string); ok && fallback != "" {
-				message = processTemplate(fallback, session.SessionData)
-			} else if step.Message != "" {
-				message = processTemplate(step.Message, session.SessionData)
-			} else {
-				message = "Sorry, there was an error processing your request."
-			}
+			message = apiFallbackMessage(step, session.SessionData)
 			if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
 				a.Log.Error("Failed to send API error message", "error", err, "contact", contact.PhoneNumber)
 			}
@@ -1321,20 +1155,7 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 		if len(step.Buttons) > 0 {
 			// Separate reply buttons from URL buttons
 			// WhatsApp doesn't allow mixing them in the same message
-			replyButtons := make([]map[string]interface{}, 0)
-			urlButtons := make([]map[string]interface{}, 0)
-
-			for _, btn := range step.Buttons {
-				if btnMap, ok := btn.(map[string]interface{}); ok {
-					btnType, _ := btnMap["type"].(string)
-					if btnType == "url" {
-						urlButtons = append(urlButtons, btnMap)
-					} else {
-						// Default to reply button
-						replyButtons = append(replyButtons, btnMap)
-					}
-				}
-			}
+			replyButtons, urlButtons := splitStepButtons(step.Buttons)
 
 			// Send reply buttons first (with the main message)
 			if len(replyButtons) > 0 {
@@ -1384,50 +1205,41 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 		}
 
 		// Get transfer configuration
-		var teamID *uuid.UUID
-		var notes string
-		if step.TransferConfig != nil {
-			if teamIDStr, ok := step.TransferConfig["team_id"].(string); ok && teamIDStr != "" && teamIDStr != "_general" {
-				if parsedID, err := uuid.Parse(teamIDStr); err == nil {
-					teamID = &parsedID
-				}
-			}
-			if n, ok := step.TransferConfig["notes"].(string); ok {
-				notes = processTemplate(n, session.SessionData)
-			}
-		}
+		teamID, notes := stepTransferConfig(step, session.SessionData)
+		skills := stepTransferSkills(step, session.SessionData)
 
 		// Create the transfer
 		if teamID != nil {
-			a.createTransferToTeam(account, contact, *teamID, notes, models.TransferSourceFlow)
+			a.createTransferToTeam(account, contact, *teamID, notes, models.TransferSourceFlow, skills)
 		} else {
 			// General queue transfer
-			a.createTransferToQueue(account, contact, models.TransferSourceFlow)
+			a.createTransferToQueue(account, contact, models.TransferSourceFlow, skills)
 		}
 
 		// End the flow session (transfer takes over)
 		a.exitFlow(session)
 		return
 
+	case models.FlowStepTypeAction:
+		// Update the contact, then send the optional confirmation message
+		a.runFlowActions(session, contact, step)
+		// Actions may have changed the contact's language
+		step = localizeStep(original, contactLanguage(contact))
+		message = processTemplate(step.Message, session.SessionData)
+		if message != "" {
+			if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
+				a.Log.Error("Failed to send action step message", "error", err, "contact", contact.PhoneNumber)
+			}
+			a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
+		}
+
 	case models.FlowStepTypeWhatsAppFlow:
 		// Send a WhatsApp Flow (interactive form)
 		a.Log.Debug("Processing WhatsApp Flow step", "step", step.StepName, "input_config", step.InputConfig)
 		message = processTemplate(step.Message, session.SessionData)
 
 		// Extract flow configuration from input_config
-		var flowID, headerText, ctaText string
-		if step.InputConfig != nil {
-			if fid, ok := step.InputConfig["whatsapp_flow_id"].(string); ok {
-				flowID = fid
-				a.Log.Debug("Found WhatsApp Flow ID", "flow_id", flowID)
-			}
-			if header, ok := step.InputConfig["flow_header"].(string); ok {
-				headerText = processTemplate(header, session.SessionData)
-			}
-			if cta, ok := step.InputConfig["flow_cta"].(string); ok {
-				ctaText = cta
-			}
-		}
+		flowID, headerText, ctaText := stepWhatsAppFlowConfig(step, session.SessionData)
 
 		if flowID == "" {
 			a.Log.Error("WhatsApp Flow step missing flow ID", "step", step.StepName)
@@ -1437,32 +1249,7 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 			}
 		} else {
 			// Look up the WhatsApp Flow to get the first screen name
-			var waFlow models.WhatsAppFlow
-			firstScreen := ""
-			if err := a.DB.Where("meta_flow_id = ?", flowID).First(&waFlow).Error; err != nil {
-				a.Log.Debug("Could not find WhatsApp Flow in database, using default screen", "meta_flow_id", flowID)
-			} else {
-				// Extract first screen name from screens array
-				if len(waFlow.Screens) > 0 {
-					if screenMap, ok := waFlow.Screens[0].(map[string]interface{}); ok {
-						if screenID, ok := screenMap["id"].(string); ok {
-							firstScreen = screenID
-							a.Log.Debug("Found first screen from flow", "first_screen", firstScreen)
-						}
-					}
-				}
-				// If screens array is empty, try to get from flow_json
-				if firstScreen == "" && waFlow.FlowJSON != nil {
-					if screens, ok := waFlow.FlowJSON["screens"].([]interface{}); ok && len(screens) > 0 {
-						if screenMap, ok := screens[0].(map[string]interface{}); ok {
-							if screenID, ok := screenMap["id"].(string); ok {
-								firstScreen = screenID
-								a.Log.Debug("Found first screen from flow_json", "first_screen", firstScreen)
-							}
-						}
-					}
-				}
-			}
+			firstScreen := a.whatsAppFlowFirstScreen(flowID)
 
 			// Generate a unique flow token for tracking
 			flowToken := fmt.Sprintf("chatbot_%s_%s_%d", session.ID.String(), step.StepName, time.Now().UnixNano())
@@ -1474,6 +1261,16 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 		}
 		a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
 
+	case models.FlowStepTypeAI:
+		// Ask the question; the model runs on the contact's reply (see handleAIStepInput)
+		message = processTemplate(step.Message, session.SessionData)
+		if message != "" {
+			if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
+				a.Log.Error("Failed to send AI step message", "error", err, "contact", contact.PhoneNumber)
+			}
+			a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
+		}
+
 	default:
 		// Default: use the step message with template processing
 		a.Log.Debug("Unhandled message type, falling back to text", "message_type", step.MessageType, "step", step.StepName)
@@ -1485,52 +1282,342 @@ func (a *App) sendStepMessage(account *models.WhatsAppAccount, session *models.C
 	}
 }
 
-// ApiResponse represents a response from an external API that may include buttons
-type ApiResponse struct {
-	Message      string
-	Buttons      []map[string]interface{}
-	MappedData   map[string]interface{} // Data extracted via response_mapping
-	ResponseData map[string]interface{} // Full API response data
-}
+const (
+	flowCancelledMessage  = "Flow cancelled."
+	flowMaxRetriesMessage = "Sorry, we couldn't continue. Please try again later."
+)
 
-// fetchApiResponse fetches a response from an external API, supporting message + buttons
-// and response_mapping for storing API data in session variables
-func (a *App) fetchApiResponse(apiConfig models.JSONB, sessionData models.JSONB, messageTemplate string) (*ApiResponse, error) {
-	if apiConfig == nil {
-		return nil, fmt.Errorf("API config is empty")
+// matchCancelKeyword reports whether the input contains one of the flow's cancel keywords
+func matchCancelKeyword(flow *models.ChatbotFlow, userInput string) bool {
+	userInputLower := strings.ToLower(userInput)
+	for _, cancelKw := range flow.CancelKeywords {
+		if strings.Contains(userInputLower, strings.ToLower(cancelKw)) {
+			return true
+		}
 	}
+	return false
+}
 
-	// Get API URL (required)
-	apiURL, ok := apiConfig["url"].(string)
-	if !ok || apiURL == "" {
-		return nil, fmt.Errorf("API URL is required")
+// findFlowStep returns the step with the given name, or nil if the flow has no such step
+func findFlowStep(flow *models.ChatbotFlow, stepName string) *models.ChatbotFlowStep {
+	for i := range flow.Steps {
+		if flow.Steps[i].StepName == stepName {
+			return &flow.Steps[i]
+		}
 	}
+	return nil
+}
 
-	// Replace variables in URL using template engine
-	apiURL = processTemplate(apiURL, sessionData)
+// defaultNextStep returns the step's configured next step, or the following step by order
+func defaultNextStep(flow *models.ChatbotFlow, step *models.ChatbotFlowStep) string {
+	if step.NextStep != "" {
+		return step.NextStep
+	}
+	for i, s := range flow.Steps {
+		if s.StepName == step.StepName && i+1 < len(flow.Steps) {
+			return flow.Steps[i+1].StepName
+		}
+	}
+	return ""
+}
 
-	// Get HTTP method (default: GET)
-	method := "GET"
-	if m, ok := apiConfig["method"].(string); ok && m != "" {
-		method = strings.ToUpper(m)
+// resolveNextStep determines the step that follows a user response, checking
+// conditional_next by button ID first (for button/list responses), then by input text
+func resolveNextStep(flow *models.ChatbotFlow, step *models.ChatbotFlowStep, userInput, buttonID string) string {
+	nextStepName := defaultNextStep(flow, step)
+	if len(step.ConditionalNext) == 0 {
+		return nextStepName
 	}
 
-	// Prepare request body if configured
-	var bodyReader io.Reader
-	if bodyTemplate, ok := apiConfig["body"].(string); ok && bodyTemplate != "" {
-		bodyWithVars := processTemplate(bodyTemplate, sessionData)
-		bodyReader = strings.NewReader(bodyWithVars)
+	if buttonID != "" {
+		if next, ok := step.ConditionalNext[buttonID].(string); ok {
+			return next
+		}
+	}
+	if next, ok := step.ConditionalNext[userInput].(string); ok {
+		return next
 	}
+	if defaultNext, ok := step.ConditionalNext["default"].(string); ok {
+		return defaultNext
+	}
+	return nextStepName
+}
 
-	// Create request
-	req, err := http.NewRequest(method, apiURL, bodyReader)
+// stepInputValid checks text input against the step's validation regex.
+// Button/list responses and invalid patterns always pass.
+func stepInputValid(step *models.ChatbotFlowStep, userInput, buttonID string) bool {
+	if step.ValidationRegex == "" || buttonID != "" {
+		return true
+	}
+	re, err := regexp.Compile(step.ValidationRegex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return true
 	}
+	return re.MatchString(userInput)
+}
 
-	// Set default headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+// stepValidationError returns the message sent when input fails validation
+func stepValidationError(step *models.ChatbotFlowStep) string {
+	if step.ValidationError != "" {
+		return step.ValidationError
+	}
+	return "Invalid input. Please try again."
+}
+
+// stepExpectsButton reports whether a response must match one of the step's buttons.
+// Only validate if InputType is button/select, or if buttons are configured and user clicked a button
+func stepExpectsButton(step *models.ChatbotFlowStep, buttonID string) bool {
+	return len(step.Buttons) > 0 &&
+		(step.InputType == models.InputTypeButton || step.InputType == models.InputTypeSelect || buttonID != "")
+}
+
+// stepMaxButtonRetries returns how many invalid button selections end the flow
+func stepMaxButtonRetries(step *models.ChatbotFlowStep) int {
+	if step.MaxRetries == 0 {
+		return 3 // Default max retries
+	}
+	return step.MaxRetries
+}
+
+// matchStepButton matches a response to one of the step's buttons by button ID
+// (exact match) or by title (case-insensitive). Returns the button ID to use
+// for the response and whether a button matched.
+func matchStepButton(step *models.ChatbotFlowStep, userInput, buttonID string) (string, bool) {
+	userInputLower := strings.ToLower(userInput)
+	for i, btn := range step.Buttons {
+		btnMap, ok := btn.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		btnID, _ := btnMap["id"].(string)
+		btnTitle, _ := btnMap["title"].(string)
+
+		// Auto-generate ID if not set (must match what sendInteractiveButtons does)
+		if btnID == "" {
+			btnID = fmt.Sprintf("btn_%d", i+1)
+		}
+
+		if buttonID != "" && buttonID == btnID {
+			return buttonID, true
+		}
+		if strings.ToLower(btnTitle) == userInputLower || btnID == userInput {
+			if buttonID != "" {
+				return buttonID, true
+			}
+			return btnID, true
+		}
+	}
+	return buttonID, false
+}
+
+// storeStepResponse stores the user's response under the step's store_as variable.
+// Button responses store both the ID and the title.
+func storeStepResponse(data models.JSONB, step *models.ChatbotFlowStep, userInput, buttonID string) {
+	if buttonID != "" {
+		data[step.StoreAs] = buttonID
+		data[step.StoreAs+"_title"] = userInput
+	} else {
+		data[step.StoreAs] = userInput
+	}
+}
+
+// storeFlowResponseData stores each field of a WhatsApp Flow response in the
+// session data, along with the raw response for reference
+func storeFlowResponseData(data models.JSONB, flowResponseData map[string]interface{}) {
+	for key, value := range flowResponseData {
+		data[key] = value
+	}
+	data["_flow_response"] = flowResponseData
+}
+
+// splitStepButtons separates reply buttons from URL buttons
+func splitStepButtons(buttons models.JSONBArray) (replyButtons, urlButtons []map[string]interface{}) {
+	replyButtons = make([]map[string]interface{}, 0)
+	urlButtons = make([]map[string]interface{}, 0)
+	for _, btn := range buttons {
+		if btnMap, ok := btn.(map[string]interface{}); ok {
+			btnType, _ := btnMap["type"].(string)
+			if btnType == "url" {
+				urlButtons = append(urlButtons, btnMap)
+			} else {
+				// Default to reply button
+				replyButtons = append(replyButtons, btnMap)
+			}
+		}
+	}
+	return replyButtons, urlButtons
+}
+
+// apiFallbackMessage returns the message sent when an api_fetch step fails:
+// the fallback message if configured, otherwise the step message
+func apiFallbackMessage(step *models.ChatbotFlowStep, data models.JSONB) string {
+	if fallback, ok := step.ApiConfig["fallback_message"].(string); ok && fallback != "" {
+		return processTemplate(fallback, data)
+	}
+	if step.Message != "" {
+		return processTemplate(step.Message, data)
+	}
+	return "Sorry, there was an error processing your request."
+}
+
+// stepTransferConfig returns the team (nil for the general queue) and notes of a transfer step
+func stepTransferConfig(step *models.ChatbotFlowStep, data models.JSONB) (*uuid.UUID, string) {
+	var teamID *uuid.UUID
+	var notes string
+	if step.TransferConfig != nil {
+		if teamIDStr, ok := step.TransferConfig["team_id"].(string); ok && teamIDStr != "" && teamIDStr != "_general" {
+			if parsedID, err := uuid.Parse(teamIDStr); err == nil {
+				teamID = &parsedID
+			}
+		}
+		if n, ok := step.TransferConfig["notes"].(string); ok {
+			notes = processTemplate(n, data)
+		}
+	}
+	return teamID, notes
+}
+
+// maxFlowWait caps how long a wait step can pause a flow
+const maxFlowWait = 30 * 24 * time.Hour
+
+// stepWaitUntil returns when a wait step should resume the flow: after wait_config.duration_minutes,
+// or at wait_config.until (RFC 3339, may use {{variables}}). Times in the past resume immediately.
+func stepWaitUntil(step *models.ChatbotFlowStep, data models.JSONB, now time.Time) (time.Time, error) {
+	var minutes float64
+	switch v := step.WaitConfig["duration_minutes"].(type) {
+	case float64:
+		minutes = v
+	case int:
+		minutes = float64(v)
+	}
+
+	var resumeAt time.Time
+	if minutes > 0 {
+		resumeAt = now.Add(time.Duration(minutes * float64(time.Minute)))
+	} else if until, _ := step.WaitConfig["until"].(string); strings.TrimSpace(until) != "" {
+		value := strings.TrimSpace(processTemplate(until, data))
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid wait time %q, expected RFC 3339 such as 2026-01-02T15:04:05Z", value)
+		}
+		resumeAt = t
+	} else {
+		return time.Time{}, fmt.Errorf("wait step needs duration_minutes or until")
+	}
+
+	if resumeAt.Before(now) {
+		return now, nil
+	}
+	if resumeAt.Sub(now) > maxFlowWait {
+		return time.Time{}, fmt.Errorf("wait exceeds the maximum of %d days", int(maxFlowWait.Hours()/24))
+	}
+	return resumeAt, nil
+}
+
+// stepWhatsAppFlowConfig returns the Meta flow ID, header and CTA text of a whatsapp_flow step
+func stepWhatsAppFlowConfig(step *models.ChatbotFlowStep, data models.JSONB) (flowID, headerText, ctaText string) {
+	if step.InputConfig == nil {
+		return "", "", ""
+	}
+	flowID, _ = step.InputConfig["whatsapp_flow_id"].(string)
+	if header, ok := step.InputConfig["flow_header"].(string); ok {
+		headerText = processTemplate(header, data)
+	}
+	ctaText, _ = step.InputConfig["flow_cta"].(string)
+	return flowID, headerText, ctaText
+}
+
+// whatsAppFlowFirstScreen returns the first screen of a WhatsApp Flow, or "" to use the default screen
+func (a *App) whatsAppFlowFirstScreen(metaFlowID string) string {
+	var waFlow models.WhatsAppFlow
+	if err := a.DB.Where("meta_flow_id = ?", metaFlowID).First(&waFlow).Error; err != nil {
+		a.Log.Debug("Could not find WhatsApp Flow in database, using default screen", "meta_flow_id", metaFlowID)
+		return ""
+	}
+
+	// Extract first screen name from screens array
+	if len(waFlow.Screens) > 0 {
+		if screenMap, ok := waFlow.Screens[0].(map[string]interface{}); ok {
+			if screenID, ok := screenMap["id"].(string); ok {
+				return screenID
+			}
+		}
+	}
+	// If screens array is empty, try to get from flow_json
+	if waFlow.FlowJSON != nil {
+		if screens, ok := waFlow.FlowJSON["screens"].([]interface{}); ok && len(screens) > 0 {
+			if screenMap, ok := screens[0].(map[string]interface{}); ok {
+				if screenID, ok := screenMap["id"].(string); ok {
+					return screenID
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// ApiResponse represents a response from an external API that may include buttons
+type ApiResponse struct {
+	Message      string
+	Buttons      []map[string]interface{}
+	MappedData   map[string]interface{} // Data extracted via response_mapping
+	ResponseData map[string]interface{} // Full API response data
+}
+
+// fetchApiResponse fetches a response from an external API, supporting message + buttons
+// and response_mapping for storing API data in session variables
+func (a *App) fetchApiResponse(apiConfig models.JSONB, sessionData models.JSONB, messageTemplate string) (*ApiResponse, error) {
+	req, err := buildApiRequest(apiConfig, sessionData)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := a.doApiRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseApiResponse(apiConfig, sessionData, messageTemplate, respBody), nil
+}
+
+// buildApiRequest creates the HTTP request for an api_fetch step, replacing
+// variables in the URL, body and headers
+func buildApiRequest(apiConfig models.JSONB, sessionData models.JSONB) (*http.Request, error) {
+	if apiConfig == nil {
+		return nil, fmt.Errorf("API config is empty")
+	}
+
+	// Get API URL (required)
+	apiURL, ok := apiConfig["url"].(string)
+	if !ok || apiURL == "" {
+		return nil, fmt.Errorf("API URL is required")
+	}
+
+	// Replace variables in URL using template engine
+	apiURL = processTemplate(apiURL, sessionData)
+
+	// Get HTTP method (default: GET)
+	method := "GET"
+	if m, ok := apiConfig["method"].(string); ok && m != "" {
+		method = strings.ToUpper(m)
+	}
+
+	// Prepare request body if configured
+	var bodyReader io.Reader
+	if bodyTemplate, ok := apiConfig["body"].(string); ok && bodyTemplate != "" {
+		bodyWithVars := processTemplate(bodyTemplate, sessionData)
+		bodyReader = strings.NewReader(bodyWithVars)
+	}
+
+	// Create request
+	req, err := http.NewRequest(method, apiURL, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set default headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
 
 	// Add custom headers if configured
 	if headers, ok := apiConfig["headers"].(map[string]interface{}); ok {
@@ -1542,7 +1629,12 @@ func (a *App) fetchApiResponse(apiConfig models.JSONB, sessionData models.JSONB,
 		}
 	}
 
-	// Make the request
+	return req, nil
+}
+
+// doApiRequest performs an api_fetch request and returns the response body.
+// Non-2xx responses are returned as errors.
+func (a *App) doApiRequest(req *http.Request) ([]byte, error) {
 	resp, err := a.HTTPClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("API request failed: %w", err)
@@ -1560,11 +1652,17 @@ func (a *App) fetchApiResponse(apiConfig models.JSONB, sessionData models.JSONB,
 		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
 	}
 
+	return respBody, nil
+}
+
+// parseApiResponse builds an ApiResponse from an API response body, applying
+// response_mapping to sessionData and rendering the message template
+func parseApiResponse(apiConfig models.JSONB, sessionData models.JSONB, messageTemplate string, respBody []byte) *ApiResponse {
 	// Parse JSON response
 	var jsonResp map[string]interface{}
 	if err := json.Unmarshal(respBody, &jsonResp); err != nil {
 		// If not JSON, return raw response as message
-		return &ApiResponse{Message: string(respBody)}, nil
+		return &ApiResponse{Message: string(respBody)}
 	}
 
 	result := &ApiResponse{
@@ -1626,28 +1724,97 @@ func (a *App) fetchApiResponse(apiConfig models.JSONB, sessionData models.JSONB,
 		}
 	}
 
-	return result, nil
+	return result
 }
 
 // generateAIResponse generates a response using the configured AI provider
 func (a *App) generateAIResponse(settings *models.ChatbotSettings, session *models.ChatbotSession, userMessage string) (string, error) {
+	// With tool calling, the model fetches API contexts itself
+	if settings.AI.ToolCalling {
+		return a.completeAIWithTools(settings, session, userMessage)
+	}
+
 	// Build context from AIContext entries
-	contextData := a.buildAIContext(settings.OrganizationID, session, userMessage)
-
-	switch settings.AI.Provider {
-	case models.AIProviderOpenAI:
-		return a.generateOpenAIResponse(settings, session, userMessage, contextData)
-	case models.AIProviderAnthropic:
-		return a.generateAnthropicResponse(settings, session, userMessage, contextData)
-	case models.AIProviderGoogle:
-		return a.generateGoogleResponse(settings, session, userMessage, contextData)
-	default:
-		return "", fmt.Errorf("unsupported AI provider: %s", settings.AI.Provider)
+	contextData := a.buildAIContext(settings, session, userMessage, true)
+
+	return a.completeAI(settings, session, userMessage, contextData)
+}
+
+// completeAI sends a user message to the configured AI provider. The settings' system prompt
+// and contextData form the system prompt, and session history is included if enabled.
+func (a *App) completeAI(settings *models.ChatbotSettings, session *models.ChatbotSession, userMessage string, contextData string) (string, error) {
+	provider, err := a.newAIProvider(settings)
+	if err != nil {
+		return "", err
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), aiRequestTimeout(settings))
+	defer cancel()
+
+	return provider.Complete(ctx, a.aiRequest(settings, session, userMessage, contextData))
 }
 
-// buildAIContext fetches and combines all AI context data
-func (a *App) buildAIContext(orgID uuid.UUID, session *models.ChatbotSession, userMessage string) string {
+// aiRequest builds the completion request of a user message for completeAI
+func (a *App) aiRequest(settings *models.ChatbotSettings, session *models.ChatbotSession, userMessage string, contextData string) llm.Request {
+	systemPrompt := settings.AI.SystemPrompt
+	if contextData != "" {
+		if systemPrompt != "" {
+			systemPrompt = systemPrompt + "\n\n" + contextData
+		} else {
+			systemPrompt = contextData
+		}
+	}
+
+	var messages []llm.Message
+	if settings.AI.IncludeHistory && session != nil {
+		for _, msg := range a.getSessionHistory(session.ID, settings.AI.HistoryLimit) {
+			role := llm.RoleUser
+			if msg.Direction == models.DirectionOutgoing {
+				role = llm.RoleAssistant
+			}
+			messages = append(messages, llm.Message{Role: role, Content: msg.Message})
+		}
+	}
+	messages = append(messages, llm.Message{Role: llm.RoleUser, Content: userMessage})
+
+	return llm.Request{
+		Model:        settings.AI.Model,
+		SystemPrompt: systemPrompt,
+		Messages:     messages,
+		MaxTokens:    settings.AI.MaxTokens,
+		Temperature:  settings.AI.Temperature,
+	}
+}
+
+// newAIProvider creates the AI provider of the chatbot settings. It fails if the
+// provider is unknown or its settings are incomplete, e.g. a missing API key.
+func (a *App) newAIProvider(settings *models.ChatbotSettings) (llm.Provider, error) {
+	if settings.AI.Provider == "" {
+		return nil, fmt.Errorf("AI provider is not configured")
+	}
+	client := a.AIHTTPClient
+	if client == nil {
+		client = a.HTTPClient
+	}
+	return llm.New(string(settings.AI.Provider), llm.Config{
+		APIKey:     settings.AI.APIKey,
+		BaseURL:    settings.AI.BaseURL,
+		HTTPClient: client,
+	})
+}
+
+// aiRequestTimeout returns the timeout of a request to the AI provider
+func aiRequestTimeout(settings *models.ChatbotSettings) time.Duration {
+	if settings.AI.TimeoutSeconds <= 0 {
+		return llm.DefaultTimeout
+	}
+	return time.Duration(settings.AI.TimeoutSeconds) * time.Second
+}
+
+// buildAIContext fetches and combines all AI context data and the knowledge base passages
+// matching the message. API contexts are skipped unless fetchAPI is set, e.g. when the model
+// calls them as tools instead.
+func (a *App) buildAIContext(settings *models.ChatbotSettings, session *models.ChatbotSession, userMessage string, fetchAPI bool) string {
 	// Get WhatsApp account for cache key
 	whatsAppAccount := ""
 	if session != nil {
@@ -1655,9 +1822,9 @@ func (a *App) buildAIContext(orgID uuid.UUID, session *models.ChatbotSession, us
 	}
 
 	// Use cached AI contexts
-	contexts, err := a.getAIContextsCached(orgID, whatsAppAccount)
-	if err != nil || len(contexts) == 0 {
-		return ""
+	contexts, err := a.getAIContextsCached(settings.OrganizationID, whatsAppAccount)
+	if err != nil {
+		a.Log.Error("Failed to load AI contexts", "error", err)
 	}
 
 	var contextParts []string
@@ -1670,11 +1837,14 @@ func (a *App) buildAIContext(orgID uuid.UUID, session *models.ChatbotSession, us
 			content = ctx.StaticContent
 
 		case models.ContextTypeAPI:
+			if !fetchAPI {
+				continue
+			}
 			// Start with static content/prompt if provided
 			content = ctx.StaticContent
 
 			// Fetch data from external API and append
-			apiContent, err := a.fetchAPIContext(ctx.ApiConfig, session, userMessage)
+			apiContent, err := a.fetchAPIContext(ctx.ApiConfig, session, userMessage, nil)
 			if err != nil {
 				a.Log.Error("Failed to fetch API context", "context_name", ctx.Name, "error", err)
 				// Still use static content if API fails
@@ -1692,6 +1862,10 @@ func (a *App) buildAIContext(orgID uuid.UUID, session *models.ChatbotSession, us
 		}
 	}
 
+	if knowledgeBase := a.knowledgeBaseContext(settings, session, userMessage); knowledgeBase != "" {
+		contextParts = append(contextParts, knowledgeBase)
+	}
+
 	if len(contextParts) == 0 {
 		return ""
 	}
@@ -1699,8 +1873,19 @@ func (a *App) buildAIContext(orgID uuid.UUID, session *models.ChatbotSession, us
 	return "## Context Information\n\n" + strings.Join(contextParts, "\n\n")
 }
 
-// fetchAPIContext fetches context data from an external API
-func (a *App) fetchAPIContext(apiConfig models.JSONB, session *models.ChatbotSession, userMessage string) (string, error) {
+// headerLineBreaks removes line breaks, which would end a header value
+var headerLineBreaks = strings.NewReplacer("\r", "", "\n", "")
+
+// jsonStringContent escapes s to be placed between the quotes of a JSON string
+func jsonStringContent(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data[1 : len(data)-1])
+}
+
+// fetchAPIContext fetches context data from an external API. args are the arguments of a tool
+// call, available as {{variables}} like session data. The model writes them, so they are escaped
+// for where they are used: the URL path, the JSON body and header values.
+func (a *App) fetchAPIContext(apiConfig models.JSONB, session *models.ChatbotSession, userMessage string, args map[string]interface{}) (string, error) {
 	if apiConfig == nil {
 		return "", fmt.Errorf("API config is empty")
 	}
@@ -1722,8 +1907,24 @@ func (a *App) fetchAPIContext(apiConfig models.JSONB, session *models.ChatbotSes
 		sessionData["user_message"] = userMessage
 	}
 
+	urlData, bodyData, headerData := sessionData, sessionData, sessionData
+	if len(args) > 0 {
+		urlData, bodyData, headerData = models.JSONB{}, models.JSONB{}, models.JSONB{}
+		for k, v := range sessionData {
+			urlData[k] = v
+			bodyData[k] = v
+			headerData[k] = v
+		}
+		for k, v := range args {
+			value := formatValue(v)
+			urlData[k] = url.PathEscape(value)
+			bodyData[k] = jsonStringContent(value)
+			headerData[k] = headerLineBreaks.Replace(value)
+		}
+	}
+
 	// Replace variables in URL
-	apiURL = a.replaceVariables(apiURL, sessionData)
+	apiURL = a.replaceVariables(apiURL, urlData)
 
 	// Get HTTP method (default: GET)
 	method := "GET"
@@ -1734,7 +1935,7 @@ func (a *App) fetchAPIContext(apiConfig models.JSONB, session *models.ChatbotSes
 	// Prepare request body if configured
 	var bodyReader io.Reader
 	if bodyTemplate, ok := apiConfig["body"].(string); ok && bodyTemplate != "" {
-		bodyWithVars := a.replaceVariables(bodyTemplate, sessionData)
+		bodyWithVars := a.replaceVariables(bodyTemplate, bodyData)
 		bodyReader = strings.NewReader(bodyWithVars)
 	}
 
@@ -1752,7 +1953,7 @@ func (a *App) fetchAPIContext(apiConfig models.JSONB, session *models.ChatbotSes
 	if headers, ok := apiConfig["headers"].(map[string]interface{}); ok {
 		for key, value := range headers {
 			if strVal, ok := value.(string); ok {
-				req.Header.Set(key, a.replaceVariables(strVal, sessionData))
+				req.Header.Set(key, a.replaceVariables(strVal, headerData))
 			}
 		}
 	}
@@ -1787,332 +1988,10 @@ func (a *App) fetchAPIContext(apiConfig models.JSONB, session *models.ChatbotSes
 	return string(respBody), nil
 }
 
-// generateOpenAIResponse generates a response using OpenAI API
-func (a *App) generateOpenAIResponse(settings *models.ChatbotSettings, session *models.ChatbotSession, userMessage string, contextData string) (string, error) {
-	url := "https://api.openai.com/v1/chat/completions"
-
-	// Build messages array
-	messages := []map[string]string{}
-
-	// Build system prompt with context
-	systemPrompt := settings.AI.SystemPrompt
-	if contextData != "" {
-		if systemPrompt != "" {
-			systemPrompt = systemPrompt + "\n\n" + contextData
-		} else {
-			systemPrompt = contextData
-		}
-	}
-
-	// Add system prompt if configured
-	if systemPrompt != "" {
-		messages = append(messages, map[string]string{
-			"role":    "system",
-			"content": systemPrompt,
-		})
-	}
-
-	// Add conversation history if enabled
-	if settings.AI.IncludeHistory && session != nil {
-		history := a.getSessionHistory(session.ID, settings.AI.HistoryLimit)
-		for _, msg := range history {
-			role := "user"
-			if msg.Direction == models.DirectionOutgoing {
-				role = "assistant"
-			}
-			messages = append(messages, map[string]string{
-				"role":    role,
-				"content": msg.Message,
-			})
-		}
-	}
-
-	// Add current user message
-	messages = append(messages, map[string]string{
-		"role":    "user",
-		"content": userMessage,
-	})
-
-	payload := map[string]interface{}{
-		"model":      settings.AI.Model,
-		"messages":   messages,
-		"max_tokens": settings.AI.MaxTokens,
-	}
-
-	if settings.AI.Temperature > 0 {
-		payload["temperature"] = settings.AI.Temperature
-	}
-
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+settings.AI.APIKey)
-
-	resp, err := a.HTTPClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != 200 {
-		var errResp struct {
-			Error struct {
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-		_ = json.Unmarshal(body, &errResp)
-		return "", fmt.Errorf("OpenAI API error: %s", errResp.Error.Message)
-	}
-
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	if len(result.Choices) > 0 {
-		return strings.TrimSpace(result.Choices[0].Message.Content), nil
-	}
-
-	return "", fmt.Errorf("no response from OpenAI")
-}
-
-// generateAnthropicResponse generates a response using Anthropic API
-func (a *App) generateAnthropicResponse(settings *models.ChatbotSettings, session *models.ChatbotSession, userMessage string, contextData string) (string, error) {
-	url := "https://api.anthropic.com/v1/messages"
-
-	// Build messages array
-	messages := []map[string]string{}
-
-	// Add conversation history if enabled
-	if settings.AI.IncludeHistory && session != nil {
-		history := a.getSessionHistory(session.ID, settings.AI.HistoryLimit)
-		for _, msg := range history {
-			role := "user"
-			if msg.Direction == models.DirectionOutgoing {
-				role = "assistant"
-			}
-			messages = append(messages, map[string]string{
-				"role":    role,
-				"content": msg.Message,
-			})
-		}
-	}
-
-	// Add current user message
-	messages = append(messages, map[string]string{
-		"role":    "user",
-		"content": userMessage,
-	})
-
-	payload := map[string]interface{}{
-		"model":      settings.AI.Model,
-		"messages":   messages,
-		"max_tokens": settings.AI.MaxTokens,
-	}
-
-	// Build system prompt with context
-	systemPrompt := settings.AI.SystemPrompt
-	if contextData != "" {
-		if systemPrompt != "" {
-			systemPrompt = systemPrompt + "\n\n" + contextData
-		} else {
-			systemPrompt = contextData
-		}
-	}
-
-	// Add system prompt if configured
-	if systemPrompt != "" {
-		payload["system"] = systemPrompt
-	}
-
-	if settings.AI.Temperature > 0 {
-		payload["temperature"] = settings.AI.Temperature
-	}
-
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", settings.AI.APIKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
-
-	resp, err := a.HTTPClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != 200 {
-		var errResp struct {
-			Error struct {
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-		_ = json.Unmarshal(body, &errResp)
-		return "", fmt.Errorf("anthropic API error: %s", errResp.Error.Message)
-	}
-
-	var result struct {
-		Content []struct {
-			Type string `json:"type"`
-			Text string `json:"text"`
-		} `json:"content"`
-	}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	for _, content := range result.Content {
-		if content.Type == "text" {
-			return strings.TrimSpace(content.Text), nil
-		}
-	}
-
-	return "", fmt.Errorf("no text response from Anthropic")
-}
-
-// generateGoogleResponse generates a response using Google Gemini API
-func (a *App) generateGoogleResponse(settings *models.ChatbotSettings, session *models.ChatbotSession, userMessage string, contextData string) (string, error) {
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s",
-		settings.AI.Model, settings.AI.APIKey)
-
-	// Build contents array
-	contents := []map[string]interface{}{}
-
-	// Add conversation history if enabled
-	if settings.AI.IncludeHistory && session != nil {
-		history := a.getSessionHistory(session.ID, settings.AI.HistoryLimit)
-		for _, msg := range history {
-			role := "user"
-			if msg.Direction == models.DirectionOutgoing {
-				role = "model"
-			}
-			contents = append(contents, map[string]interface{}{
-				"role": role,
-				"parts": []map[string]string{
-					{"text": msg.Message},
-				},
-			})
-		}
-	}
-
-	// Add current user message
-	contents = append(contents, map[string]interface{}{
-		"role": "user",
-		"parts": []map[string]string{
-			{"text": userMessage},
-		},
-	})
-
-	payload := map[string]interface{}{
-		"contents": contents,
-		"generationConfig": map[string]interface{}{
-			"maxOutputTokens": settings.AI.MaxTokens,
-		},
-	}
-
-	// Build system prompt with context
-	systemPrompt := settings.AI.SystemPrompt
-	if contextData != "" {
-		if systemPrompt != "" {
-			systemPrompt = systemPrompt + "\n\n" + contextData
-		} else {
-			systemPrompt = contextData
-		}
-	}
-
-	// Add system instruction if configured
-	if systemPrompt != "" {
-		payload["systemInstruction"] = map[string]interface{}{
-			"parts": []map[string]string{
-				{"text": systemPrompt},
-			},
-		}
-	}
-
-	if settings.AI.Temperature > 0 {
-		payload["generationConfig"].(map[string]interface{})["temperature"] = settings.AI.Temperature
-	}
-
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := a.HTTPClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode != 200 {
-		var errResp struct {
-			Error struct {
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-		_ = json.Unmarshal(body, &errResp)
-		return "", fmt.Errorf("google AI API error: %s", errResp.Error.Message)
-	}
-
-	var result struct {
-		Candidates []struct {
-			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
-	}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	if len(result.Candidates) > 0 && len(result.Candidates[0].Content.Parts) > 0 {
-		return strings.TrimSpace(result.Candidates[0].Content.Parts[0].Text), nil
-	}
-
-	return "", fmt.Errorf("no response from Google AI")
-}
-
 // getSessionHistory retrieves recent messages from the session
 func (a *App) getSessionHistory(sessionID uuid.UUID, limit int) []models.ChatbotSessionMessage {
 	var messages []models.ChatbotSessionMessage
-	a.DB.Where("session_id = ?", sessionID).
+	a.DB.Where("session_id = ? AND direction <> ?", sessionID, models.DirectionInternal).
 		Order("created_at DESC").
 		Limit(limit).
 		Find(&messages)
@@ -2368,55 +2247,6 @@ func (a *App) saveIncomingMessage(account *models.WhatsAppAccount, contact *mode
 	})
 }
 
-// isWithinBusinessHours checks if current time is within configured business hours
-func (a *App) isWithinBusinessHours(businessHours models.JSONBArray) bool {
-	now := time.Now()
-	currentDay := int(now.Weekday()) // 0 = Sunday, 1 = Monday, etc.
-	currentTime := now.Format("15:04")
-
-	for _, bh := range businessHours {
-		bhMap, ok := bh.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		// Get day (0-6, Sunday-Saturday)
-		day, ok := bhMap["day"].(float64)
-		if !ok {
-			continue
-		}
-
-		if int(day) != currentDay {
-			continue
-		}
-
-		// Check if enabled for this day
-		enabled, ok := bhMap["enabled"].(bool)
-		if !ok || !enabled {
-			return false // Day exists but is disabled
-		}
-
-		// Get start and end times
-		startTime, ok := bhMap["start_time"].(string)
-		if !ok {
-			continue
-		}
-		endTime, ok := bhMap["end_time"].(string)
-		if !ok {
-			continue
-		}
-
-		// Compare times (simple string comparison works for HH:MM format)
-		if currentTime >= startTime && currentTime <= endTime {
-			return true
-		}
-		return false // Found the day but outside hours
-	}
-
-	// If no matching day found, assume outside business hours
-	return false
-}
-
 // shouldSkipStep evaluates a text expression like "(status == 'vip' OR amount > 100) AND name != ”"
 func (a *App) shouldSkipStep(step *models.ChatbotFlowStep, sessionData map[string]interface{}) bool {
 	if step.SkipCondition == "" {
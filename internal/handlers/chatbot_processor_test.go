@@ -343,6 +343,73 @@ func TestMatchKeywordRules_WithButtons(t *testing.T) {
 	assert.Len(t, resp.Buttons, 2)
 }
 
+func TestMatchKeywordRules_FuzzyMatch(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+
+	rule := &models.KeywordRule{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		WhatsAppAccount: account.Name,
+		Name:            "fuzzy-status",
+		Keywords:        models.StringArray{"order status"},
+		MatchType:       models.MatchTypeFuzzy,
+		ResponseType:    models.ResponseTypeText,
+		ResponseContent: models.JSONB{"body": "Status response"},
+		Priority:        10,
+		IsEnabled:       true,
+	}
+	require.NoError(t, app.DB.Create(rule).Error)
+
+	resp, matched := app.matchKeywordRules(org.ID, account.Name, "ordr status pls")
+	assert.True(t, matched)
+	require.NotNil(t, resp)
+	assert.Equal(t, "Status response", resp.Body)
+
+	_, matched2 := app.matchKeywordRules(org.ID, account.Name, "opening hours")
+	assert.False(t, matched2)
+}
+
+func TestMatchKeywordRules_IntentMatch(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+
+	for _, rule := range []*models.KeywordRule{
+		{
+			Name:            "Order status",
+			Keywords:        models.StringArray{"where is my order", "track my order"},
+			ResponseContent: models.JSONB{"body": "Status response"},
+		},
+		{
+			Name:            "Refund",
+			Keywords:        models.StringArray{"I want a refund", "money back"},
+			ResponseContent: models.JSONB{"body": "Refund response"},
+		},
+	} {
+		rule.BaseModel = models.BaseModel{ID: uuid.New()}
+		rule.OrganizationID = org.ID
+		rule.WhatsAppAccount = account.Name
+		rule.MatchType = models.MatchTypeIntent
+		rule.ResponseType = models.ResponseTypeText
+		rule.Priority = 10
+		rule.IsEnabled = true
+		require.NoError(t, app.DB.Create(rule).Error)
+	}
+
+	resp, matched := app.matchKeywordRules(org.ID, account.Name, "can I get my money back?")
+	assert.True(t, matched)
+	require.NotNil(t, resp)
+	assert.Equal(t, "Refund response", resp.Body)
+
+	resp, matched = app.matchKeywordRules(org.ID, account.Name, "trak order 1234")
+	assert.True(t, matched)
+	require.NotNil(t, resp)
+	assert.Equal(t, "Status response", resp.Body)
+
+	_, matched = app.matchKeywordRules(org.ID, account.Name, "what are your opening hours")
+	assert.False(t, matched)
+}
+
 // =============================================================================
 // getOrCreateSession
 // =============================================================================
@@ -416,96 +483,6 @@ func TestGetOrCreateSession_ExpiredSession(t *testing.T) {
 	assert.NotEqual(t, expired.ID, session.ID, "should create a new session, not return expired one")
 }
 
-// =============================================================================
-// isWithinBusinessHours
-// =============================================================================
-
-func TestIsWithinBusinessHours_WithinHours(t *testing.T) {
-	app := newProcessorTestApp(t)
-	now := time.Now()
-	dayOfWeek := float64(now.Weekday())
-
-	hours := models.JSONBArray{
-		map[string]interface{}{
-			"day":        dayOfWeek,
-			"enabled":    true,
-			"start_time": "00:00",
-			"end_time":   "23:59",
-		},
-	}
-
-	result := app.isWithinBusinessHours(hours)
-	assert.True(t, result)
-}
-
-func TestIsWithinBusinessHours_OutsideHours(t *testing.T) {
-	app := newProcessorTestApp(t)
-	now := time.Now()
-	dayOfWeek := float64(now.Weekday())
-
-	// Set hours to a time window that has definitely passed
-	// Use a very narrow window in the past
-	hours := models.JSONBArray{
-		map[string]interface{}{
-			"day":        dayOfWeek,
-			"enabled":    true,
-			"start_time": "00:00",
-			"end_time":   "00:01",
-		},
-	}
-
-	// This will only be true if running at midnight; for all practical purposes it tests false
-	currentTime := now.Format("15:04")
-	if currentTime > "00:01" {
-		result := app.isWithinBusinessHours(hours)
-		assert.False(t, result)
-	}
-}
-
-func TestIsWithinBusinessHours_DayDisabled(t *testing.T) {
-	app := newProcessorTestApp(t)
-	now := time.Now()
-	dayOfWeek := float64(now.Weekday())
-
-	hours := models.JSONBArray{
-		map[string]interface{}{
-			"day":        dayOfWeek,
-			"enabled":    false,
-			"start_time": "00:00",
-			"end_time":   "23:59",
-		},
-	}
-
-	result := app.isWithinBusinessHours(hours)
-	assert.False(t, result)
-}
-
-func TestIsWithinBusinessHours_NoMatchingDay(t *testing.T) {
-	app := newProcessorTestApp(t)
-	now := time.Now()
-	// Use a different day of the week
-	otherDay := float64((int(now.Weekday()) + 1) % 7)
-
-	hours := models.JSONBArray{
-		map[string]interface{}{
-			"day":        otherDay,
-			"enabled":    true,
-			"start_time": "00:00",
-			"end_time":   "23:59",
-		},
-	}
-
-	result := app.isWithinBusinessHours(hours)
-	assert.False(t, result)
-}
-
-func TestIsWithinBusinessHours_EmptyHours(t *testing.T) {
-	app := newProcessorTestApp(t)
-
-	result := app.isWithinBusinessHours(models.JSONBArray{})
-	assert.False(t, result)
-}
-
 // =============================================================================
 // shouldSkipStep
 // =============================================================================
@@ -1007,3 +984,44 @@ func TestEvaluateExpression_Parentheses(t *testing.T) {
 func TestEvaluateExpression_EmptyExpression(t *testing.T) {
 	assert.False(t, evaluateExpression("", map[string]interface{}{}))
 }
+
+// =============================================================================
+// Flow navigation helpers (package-level, not on App)
+// =============================================================================
+
+func TestResolveNextStep(t *testing.T) {
+	flow := &models.ChatbotFlow{Steps: []models.ChatbotFlowStep{
+		{StepName: "menu", ConditionalNext: models.JSONB{"sales": "sales_step", "Support": "support_step", "default": "fallback"}},
+		{StepName: "sales_step"},
+		{StepName: "support_step", NextStep: "done"},
+		{StepName: "fallback"},
+	}}
+	menu := &flow.Steps[0]
+
+	assert.Equal(t, "sales_step", resolveNextStep(flow, menu, "Talk to sales", "sales"))
+	assert.Equal(t, "support_step", resolveNextStep(flow, menu, "Support", "btn_2"))
+	assert.Equal(t, "support_step", resolveNextStep(flow, menu, "Support", ""))
+	assert.Equal(t, "fallback", resolveNextStep(flow, menu, "something else", ""))
+
+	assert.Equal(t, "done", resolveNextStep(flow, &flow.Steps[2], "anything", ""))
+	assert.Equal(t, "support_step", resolveNextStep(flow, &flow.Steps[1], "anything", ""))
+	assert.Equal(t, "", resolveNextStep(flow, &flow.Steps[3], "anything", ""))
+}
+
+func TestMatchStepButton(t *testing.T) {
+	step := &models.ChatbotFlowStep{Buttons: models.JSONBArray{
+		map[string]interface{}{"id": "yes", "title": "Yes"},
+		map[string]interface{}{"title": "No"},
+	}}
+
+	id, ok := matchStepButton(step, "Yes", "yes")
+	assert.True(t, ok)
+	assert.Equal(t, "yes", id)
+
+	id, ok = matchStepButton(step, "no", "")
+	assert.True(t, ok)
+	assert.Equal(t, "btn_2", id, "typed titles resolve to the generated button ID")
+
+	_, ok = matchStepButton(step, "maybe", "")
+	assert.False(t, ok)
+}
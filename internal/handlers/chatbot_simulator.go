@@ -0,0 +1,622 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	// maxSimulationInputs limits the number of user inputs in a single simulation
+	maxSimulationInputs = 50
+	// maxSimulationStepsPerTurn stops flows whose steps advance without input in a loop
+	maxSimulationStepsPerTurn = 100
+)
+
+// Simulation statuses
+const (
+	SimulationStatusActive      = "active"
+	SimulationStatusCompleted   = "completed"
+	SimulationStatusCancelled   = "cancelled"
+	SimulationStatusTransferred = "transferred"
+	SimulationStatusExited      = "exited"
+)
+
+// Simulation event types
+const (
+	SimulationEventStepSent         = "step_sent"
+	SimulationEventStepSkipped      = "step_skipped"
+	SimulationEventValidationFailed = "validation_failed"
+	SimulationEventInvalidButton    = "invalid_button"
+	SimulationEventApiFetch         = "api_fetch"
+	SimulationEventApiError         = "api_error"
+	SimulationEventTransfer         = "transfer"
+	SimulationEventWait             = "wait"
+	SimulationEventAction           = "action"
+	SimulationEventAI               = "ai"
+	SimulationEventWebhook          = "webhook"
+	SimulationEventCompleted        = "completed"
+	SimulationEventCancelled        = "cancelled"
+	SimulationEventExited           = "exited"
+	SimulationEventInputIgnored     = "input_ignored"
+)
+
+// FlowSimulationRequest represents the request body for simulating a chatbot flow
+type FlowSimulationRequest struct {
+	Version     int                              `json:"version"` // published version to run; 0 runs the draft
+	Inputs      []FlowSimulationInput            `json:"inputs"`
+	SessionData map[string]interface{}           `json:"session_data"`
+	ApiStubs    map[string]FlowSimulationApiStub `json:"api_stubs"` // keyed by step name
+	AIStubs     map[string][]string              `json:"ai_stubs"`  // model outputs keyed by step name, used in order; the last one repeats
+}
+
+// FlowSimulationInput is a message sent by the virtual contact
+type FlowSimulationInput struct {
+	Text         string                 `json:"text"`
+	ButtonID     string                 `json:"button_id"`
+	FlowResponse map[string]interface{} `json:"flow_response"` // WhatsApp Flow (nfm_reply) response data
+}
+
+// FlowSimulationApiStub replaces the HTTP call of an api_fetch step
+type FlowSimulationApiStub struct {
+	Status int             `json:"status"` // defaults to 200
+	Body   json.RawMessage `json:"body"`   // JSON response; a JSON string is returned as plain text
+}
+
+// SimulatedMessage is a message the chatbot would have sent
+type SimulatedMessage struct {
+	Turn            int                `json:"turn"`
+	Step            string             `json:"step,omitempty"`
+	Type            models.MessageType `json:"type"`
+	InteractiveType string             `json:"interactive_type,omitempty"`
+	Body            string             `json:"body"`
+	Buttons         []whatsapp.Button  `json:"buttons,omitempty"`
+	ButtonText      string             `json:"button_text,omitempty"`
+	URL             string             `json:"url,omitempty"`
+	FlowID          string             `json:"flow_id,omitempty"`
+	FlowHeader      string             `json:"flow_header,omitempty"`
+	FlowCTA         string             `json:"flow_cta,omitempty"`
+	FlowFirstScreen string             `json:"flow_first_screen,omitempty"`
+}
+
+// FlowSimulationEvent records a decision the flow engine made during a simulation
+type FlowSimulationEvent struct {
+	Turn   int    `json:"turn"`
+	Type   string `json:"type"`
+	Step   string `json:"step,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// FlowSimulationResponse represents the result of a flow simulation.
+// Turn 0 is the flow start; turn N follows the Nth input.
+type FlowSimulationResponse struct {
+	Status      string                `json:"status"`
+	CurrentStep string                `json:"current_step"`
+	SessionData models.JSONB          `json:"session_data"`
+	Messages    []SimulatedMessage    `json:"messages"`
+	Events      []FlowSimulationEvent `json:"events"`
+}
+
+// SimulateChatbotFlow runs a flow against a virtual contact with an in-memory
+// session. Nothing is sent to WhatsApp and nothing is written to the database.
+// It runs the draft unless a published version is requested.
+func (a *App) SimulateChatbotFlow(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if !a.HasPermission(userID, models.ResourceFlowsChatbot, models.ActionRead, orgID) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Permission denied", nil, "")
+	}
+
+	id, err := parsePathUUID(r, "id", "flow")
+	if err != nil {
+		return nil
+	}
+
+	var req FlowSimulationRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if len(req.Inputs) > maxSimulationInputs {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, fmt.Sprintf("At most %d inputs are allowed", maxSimulationInputs), nil, "")
+	}
+
+	if req.Version < 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid version", nil, "")
+	}
+
+	flow, err := loadChatbotFlowRevision(a.DB, orgID, id, req.Version)
+	if err != nil {
+		if req.Version != draftFlowVersion {
+			return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow version not found", nil, "")
+		}
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Flow not found", nil, "")
+	}
+
+	sim := &flowSimulator{
+		app:      a,
+		flow:     flow,
+		apiStubs: req.ApiStubs,
+		aiStubs:  req.AIStubs,
+		aiCalls:  map[string]int{},
+		status:   SimulationStatusActive,
+		messages: []SimulatedMessage{},
+		events:   []FlowSimulationEvent{},
+	}
+	sim.start(req.SessionData)
+	for i, input := range req.Inputs {
+		sim.turn = i + 1
+		sim.steps = 0
+		sim.respond(input)
+	}
+
+	return r.SendEnvelope(FlowSimulationResponse{
+		Status:      sim.status,
+		CurrentStep: sim.currentStep,
+		SessionData: sim.data,
+		Messages:    sim.messages,
+		Events:      sim.events,
+	})
+}
+
+// flowSimulator mirrors startFlow/processFlowResponse with an in-memory session,
+// recording outgoing messages instead of sending them
+type flowSimulator struct {
+	app      *App
+	flow     *models.ChatbotFlow
+	apiStubs map[string]FlowSimulationApiStub
+	aiStubs  map[string][]string
+	aiCalls  map[string]int
+
+	data        models.JSONB
+	currentStep string
+	retries     int
+	status      string
+	turn        int
+	steps       int
+	lastButtons []whatsapp.Button
+
+	messages []SimulatedMessage
+	events   []FlowSimulationEvent
+}
+
+func (s *flowSimulator) start(initialData map[string]interface{}) {
+	s.data = models.JSONB{
+		"_flow_id":   s.flow.ID.String(),
+		"_flow_name": s.flow.Name,
+	}
+	for k, v := range initialData {
+		s.data[k] = v
+	}
+
+	if s.flow.InitialMessage != "" {
+		s.sendText("", s.flow.InitialMessage)
+	}
+
+	if len(s.flow.Steps) == 0 {
+		s.complete()
+		return
+	}
+	firstStep := &s.flow.Steps[0]
+	s.currentStep = firstStep.StepName
+	s.enterStep(firstStep, nil)
+}
+
+func (s *flowSimulator) respond(input FlowSimulationInput) {
+	if s.status != SimulationStatusActive {
+		s.event(SimulationEventInputIgnored, "", "flow has ended")
+		return
+	}
+
+	userInput, buttonID := input.Text, input.ButtonID
+	if userInput == "" && buttonID != "" {
+		// Interactive replies carry the title of the selected button
+		userInput = buttonID
+		for _, btn := range s.lastButtons {
+			if btn.ID == buttonID {
+				userInput = btn.Title
+				break
+			}
+		}
+	}
+	if userInput == "" {
+		s.event(SimulationEventInputIgnored, "", "messages without text are not processed by the chatbot")
+		return
+	}
+
+	if matchCancelKeyword(s.flow, userInput) {
+		s.sendText("flow_cancel", flowCancelledMessage)
+		s.exit(SimulationStatusCancelled, SimulationEventCancelled, "")
+		return
+	}
+
+	step := findFlowStep(s.flow, s.currentStep)
+	if step == nil {
+		s.exit(SimulationStatusExited, SimulationEventExited, fmt.Sprintf("current step %q not found", s.currentStep))
+		return
+	}
+
+	if !stepInputValid(step, userInput, buttonID) {
+		s.retries++
+		if step.RetryOnInvalid && s.retries < step.MaxRetries {
+			s.event(SimulationEventValidationFailed, step.StepName, fmt.Sprintf("attempt %d of %d", s.retries, step.MaxRetries))
+			s.sendText(step.StepName+"_retry", stepValidationError(step))
+			return
+		}
+		s.event(SimulationEventValidationFailed, step.StepName, "max retries exceeded, continuing")
+	}
+
+	if stepExpectsButton(step, buttonID) {
+		matchedID, ok := matchStepButton(step, userInput, buttonID)
+		if !ok {
+			s.retries++
+			maxRetries := stepMaxButtonRetries(step)
+			s.event(SimulationEventInvalidButton, step.StepName, fmt.Sprintf("attempt %d of %d", s.retries, maxRetries))
+			if s.retries >= maxRetries {
+				s.sendText(step.StepName, flowMaxRetriesMessage)
+				s.exit(SimulationStatusExited, SimulationEventExited, "max button retries exceeded")
+				return
+			}
+			s.renderStep(step)
+			return
+		}
+		buttonID = matchedID
+	}
+
+	if step.MessageType == models.FlowStepTypeAI {
+		if !s.runAIStep(step, userInput) {
+			return
+		}
+	} else if step.StoreAs != "" {
+		storeStepResponse(s.data, step, userInput, buttonID)
+	}
+	if len(input.FlowResponse) > 0 {
+		storeFlowResponseData(s.data, input.FlowResponse)
+	}
+
+	nextStepName := resolveNextStep(s.flow, step, userInput, buttonID)
+	if nextStepName == "" {
+		s.complete()
+		return
+	}
+	nextStep := findFlowStep(s.flow, nextStepName)
+	if nextStep == nil {
+		s.event(SimulationEventCompleted, step.StepName, fmt.Sprintf("next step %q not found", nextStepName))
+		s.complete()
+		return
+	}
+
+	s.currentStep = nextStep.StepName
+	s.retries = 0
+	s.enterStep(nextStep, nil)
+}
+
+// enterStep mirrors sendStepWithSkipCheck
+func (s *flowSimulator) enterStep(step *models.ChatbotFlowStep, visitedSteps map[string]bool) {
+	s.steps++
+	if s.steps > maxSimulationStepsPerTurn {
+		s.exit(SimulationStatusExited, SimulationEventExited, fmt.Sprintf("more than %d steps without user input, check for loops", maxSimulationStepsPerTurn))
+		return
+	}
+
+	if visitedSteps == nil {
+		visitedSteps = make(map[string]bool)
+	}
+	if visitedSteps[step.StepName] {
+		s.event(SimulationEventCompleted, step.StepName, "step loop detected")
+		s.complete()
+		return
+	}
+	visitedSteps[step.StepName] = true
+
+	advance := false
+	if s.app.shouldSkipStep(step, s.data) {
+		s.event(SimulationEventStepSkipped, step.StepName, step.SkipCondition)
+		advance = true
+	} else {
+		s.renderStep(step)
+		if s.status != SimulationStatusActive {
+			return
+		}
+		// Simulated waits elapse immediately and action steps never wait for input
+		advance = step.InputType == models.InputTypeNone || step.MessageType == models.FlowStepTypeWait || step.MessageType == models.FlowStepTypeAction
+	}
+	if !advance {
+		return
+	}
+
+	nextStepName := defaultNextStep(s.flow, step)
+	if nextStepName == "" {
+		s.complete()
+		return
+	}
+	nextStep := findFlowStep(s.flow, nextStepName)
+	if nextStep == nil {
+		s.event(SimulationEventCompleted, step.StepName, fmt.Sprintf("next step %q not found", nextStepName))
+		s.complete()
+		return
+	}
+
+	s.currentStep = nextStep.StepName
+	s.enterStep(nextStep, visitedSteps)
+}
+
+// renderStep mirrors sendStepMessage
+func (s *flowSimulator) renderStep(step *models.ChatbotFlowStep) {
+	s.event(SimulationEventStepSent, step.StepName, string(step.MessageType))
+
+	switch step.MessageType {
+	case models.FlowStepTypeAPIFetch:
+		apiResp, err := s.fetchApiResponse(step)
+		if err != nil {
+			s.event(SimulationEventApiError, step.StepName, err.Error())
+			s.sendText(step.StepName, apiFallbackMessage(step, s.data))
+			return
+		}
+		// Mapped response data is merged into the session data by parseApiResponse
+		if len(apiResp.Buttons) > 0 {
+			s.sendButtons(step.StepName, apiResp.Message, apiResp.Buttons)
+		} else {
+			s.sendText(step.StepName, apiResp.Message)
+		}
+
+	case models.FlowStepTypeButtons:
+		message := processTemplate(step.Message, s.data)
+		if len(step.Buttons) == 0 {
+			s.sendText(step.StepName, message)
+			return
+		}
+		replyButtons, urlButtons := splitStepButtons(step.Buttons)
+		if len(replyButtons) > 0 {
+			s.sendButtons(step.StepName, message, replyButtons)
+		} else if len(urlButtons) == 0 {
+			s.sendText(step.StepName, message)
+		}
+		for _, urlBtn := range urlButtons {
+			btnTitle, _ := urlBtn["title"].(string)
+			btnURL, _ := urlBtn["url"].(string)
+			if btnTitle == "" || btnURL == "" {
+				continue
+			}
+			bodyText := ""
+			if len(replyButtons) == 0 {
+				bodyText = message
+				message = ""
+			}
+			s.send(SimulatedMessage{
+				Step:            step.StepName,
+				Type:            models.MessageTypeInteractive,
+				InteractiveType: "cta_url",
+				Body:            bodyText,
+				ButtonText:      btnTitle,
+				URL:             btnURL,
+			})
+		}
+
+	case models.FlowStepTypeTransfer:
+		if message := processTemplate(step.Message, s.data); message != "" {
+			s.sendText(step.StepName, message)
+		}
+		teamID, notes := stepTransferConfig(step, s.data)
+		detail := "general queue"
+		if teamID != nil {
+			detail = "team " + teamID.String()
+		}
+		if skills := stepTransferSkills(step, s.data); len(skills) > 0 {
+			detail += " requiring " + strings.Join(skills, ", ")
+		}
+		if notes != "" {
+			detail += ": " + notes
+		}
+		s.exit(SimulationStatusTransferred, SimulationEventTransfer, detail)
+
+	case models.FlowStepTypeWait:
+		if message := processTemplate(step.Message, s.data); message != "" {
+			s.sendText(step.StepName, message)
+		}
+		now := time.Now()
+		resumeAt, err := stepWaitUntil(step, s.data, now)
+		if err != nil {
+			s.event(SimulationEventWait, step.StepName, "invalid wait, resumes immediately: "+err.Error())
+			return
+		}
+		s.event(SimulationEventWait, step.StepName, fmt.Sprintf("resumes after %s at %s", resumeAt.Sub(now).Round(time.Second), resumeAt.UTC().Format(time.RFC3339)))
+
+	case models.FlowStepTypeAction:
+		// Actions are reported but not performed, so the contact is left untouched
+		for _, item := range step.Actions {
+			if action, ok := item.(map[string]interface{}); ok {
+				s.event(SimulationEventAction, step.StepName, describeFlowAction(action, s.data))
+			}
+		}
+		if message := processTemplate(step.Message, s.data); message != "" {
+			s.sendText(step.StepName, message)
+		}
+
+	case models.FlowStepTypeAI:
+		if message := processTemplate(step.Message, s.data); message != "" {
+			s.sendText(step.StepName, message)
+		}
+
+	case models.FlowStepTypeWhatsAppFlow:
+		message := processTemplate(step.Message, s.data)
+		flowID, headerText, ctaText := stepWhatsAppFlowConfig(step, s.data)
+		if flowID == "" {
+			// Falls back to text, as the engine does
+			s.sendText(step.StepName, message)
+			return
+		}
+		s.send(SimulatedMessage{
+			Step:            step.StepName,
+			Type:            models.MessageTypeFlow,
+			Body:            message,
+			FlowID:          flowID,
+			FlowHeader:      headerText,
+			FlowCTA:         ctaText,
+			FlowFirstScreen: s.app.whatsAppFlowFirstScreen(flowID),
+		})
+
+	default:
+		s.sendText(step.StepName, processTemplate(step.Message, s.data))
+	}
+}
+
+// fetchApiResponse calls the step's API, or uses its stub if one is configured
+func (s *flowSimulator) fetchApiResponse(step *models.ChatbotFlowStep) (*ApiResponse, error) {
+	req, err := buildApiRequest(step.ApiConfig, s.data)
+	if err != nil {
+		return nil, err
+	}
+
+	stub, stubbed := s.apiStubs[step.StepName]
+	if !stubbed {
+		s.event(SimulationEventApiFetch, step.StepName, req.Method+" "+req.URL.String())
+		respBody, err := s.app.doApiRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		return parseApiResponse(step.ApiConfig, s.data, step.Message, respBody), nil
+	}
+
+	s.event(SimulationEventApiFetch, step.StepName, req.Method+" "+req.URL.String()+" (stubbed)")
+	respBody := []byte(stub.Body)
+	var text string
+	if err := json.Unmarshal(stub.Body, &text); err == nil {
+		respBody = []byte(text)
+	}
+	status := stub.Status
+	if status == 0 {
+		status = fasthttp.StatusOK
+	}
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("API returned status %d: %s", status, string(respBody))
+	}
+	return parseApiResponse(step.ApiConfig, s.data, step.Message, respBody), nil
+}
+
+// runAIStep mirrors handleAIStepInput, using the step's stubbed outputs if any are configured.
+// Without stubs the configured provider is called, without conversation history.
+func (s *flowSimulator) runAIStep(step *models.ChatbotFlowStep, userInput string) bool {
+	complete := s.aiCompleter(step)
+	result, err := runAIStep(step, s.data, userInput, complete)
+	for _, invalid := range result.Invalid {
+		s.event(SimulationEventAI, step.StepName, "invalid model output: "+invalid)
+	}
+
+	if err != nil {
+		s.event(SimulationEventAI, step.StepName, err.Error())
+		s.retries++
+		if step.RetryOnInvalid && s.retries < step.MaxRetries {
+			s.event(SimulationEventValidationFailed, step.StepName, fmt.Sprintf("attempt %d of %d", s.retries, step.MaxRetries))
+			s.sendText(step.StepName+"_retry", stepValidationError(step))
+			return false
+		}
+		s.event(SimulationEventValidationFailed, step.StepName, "max retries exceeded, continuing")
+		return true
+	}
+
+	storeAIStepResult(s.data, step, result)
+	if result.Extracted != nil {
+		extracted, _ := json.Marshal(result.Extracted)
+		s.event(SimulationEventAI, step.StepName, "extracted "+string(extracted))
+	}
+	if result.Reply != "" {
+		s.sendText(step.StepName, result.Reply)
+	}
+	return true
+}
+
+// aiCompleter returns the model used for an ai step during the simulation
+func (s *flowSimulator) aiCompleter(step *models.ChatbotFlowStep) aiCompleteFunc {
+	if stubs, ok := s.aiStubs[step.StepName]; ok {
+		return func(systemPrompt, userMessage string) (string, error) {
+			if len(stubs) == 0 {
+				return "", fmt.Errorf("no stubbed output")
+			}
+			i := s.aiCalls[step.StepName]
+			s.aiCalls[step.StepName]++
+			if i >= len(stubs) {
+				i = len(stubs) - 1
+			}
+			s.event(SimulationEventAI, step.StepName, "model called (stubbed)")
+			return stubs[i], nil
+		}
+	}
+
+	return func(systemPrompt, userMessage string) (string, error) {
+		settings, err := s.app.getChatbotSettingsCached(s.flow.OrganizationID, s.flow.WhatsAppAccount)
+		if err != nil {
+			return "", err
+		}
+		if _, err := s.app.newAIProvider(settings); err != nil {
+			return "", err
+		}
+		s.event(SimulationEventAI, step.StepName, "model called ("+string(settings.AI.Provider)+")")
+		stepSettings := *settings
+		stepSettings.AI.SystemPrompt = systemPrompt
+		stepSettings.AI.IncludeHistory = false
+		return s.app.completeAI(&stepSettings, nil, userMessage, "")
+	}
+}
+
+// complete mirrors completeFlow
+func (s *flowSimulator) complete() {
+	if s.flow.CompletionMessage != "" {
+		s.sendText("flow_complete", s.app.replaceVariables(s.flow.CompletionMessage, s.data))
+	}
+	if s.flow.OnCompleteAction == "webhook" && len(s.flow.CompletionConfig) > 0 {
+		webhookURL, _ := s.flow.CompletionConfig["url"].(string)
+		s.event(SimulationEventWebhook, "", "not sent during simulation: "+s.app.replaceVariables(webhookURL, s.data))
+	}
+	s.exit(SimulationStatusCompleted, SimulationEventCompleted, "")
+}
+
+func (s *flowSimulator) exit(status, eventType, detail string) {
+	s.event(eventType, s.currentStep, detail)
+	s.status = status
+	s.currentStep = ""
+	s.retries = 0
+}
+
+func (s *flowSimulator) sendText(stepName, message string) {
+	s.send(SimulatedMessage{Step: stepName, Type: models.MessageTypeText, Body: message})
+}
+
+// sendButtons mirrors sendAndSaveInteractiveButtons
+func (s *flowSimulator) sendButtons(stepName, bodyText string, buttons []map[string]interface{}) {
+	waButtons := toWhatsAppButtons(buttons)
+	if len(waButtons) == 0 {
+		s.sendText(stepName, bodyText)
+		return
+	}
+	s.lastButtons = waButtons
+	s.send(SimulatedMessage{
+		Step:            stepName,
+		Type:            models.MessageTypeInteractive,
+		InteractiveType: interactiveTypeForButtons(waButtons),
+		Body:            bodyText,
+		Buttons:         waButtons,
+	})
+}
+
+func (s *flowSimulator) send(msg SimulatedMessage) {
+	msg.Turn = s.turn
+	s.messages = append(s.messages, msg)
+}
+
+func (s *flowSimulator) event(eventType, stepName, detail string) {
+	s.events = append(s.events, FlowSimulationEvent{
+		Turn:   s.turn,
+		Type:   eventType,
+		Step:   stepName,
+		Detail: strings.TrimSpace(detail),
+	})
+}
@@ -0,0 +1,346 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// createTestSimulationFlow creates an order-status flow exercising buttons,
+// conditional next, skip conditions and an api_fetch step.
+func createTestSimulationFlow(t *testing.T, app *handlers.App, orgID uuid.UUID) *models.ChatbotFlow {
+	t.Helper()
+
+	flow := createTestChatbotFlow(t, app, orgID, "Order Status")
+	require.NoError(t, app.DB.Model(flow).Updates(map[string]any{
+		"initial_message":    "Hi there!",
+		"completion_message": "Thanks {{name}}!",
+		"cancel_keywords":    models.StringArray{"cancel"},
+	}).Error)
+
+	steps := []models.ChatbotFlowStep{
+		{
+			StepName: "ask_name", StepOrder: 1, MessageType: models.FlowStepTypeText,
+			Message: "What's your name?", InputType: models.InputTypeText, StoreAs: "name",
+			SkipCondition: "name != ''",
+		},
+		{
+			StepName: "menu", StepOrder: 2, MessageType: models.FlowStepTypeButtons,
+			Message: "Hi {{name}}, what do you need?", InputType: models.InputTypeButton, StoreAs: "choice",
+			Buttons: models.JSONBArray{
+				map[string]any{"id": "order", "title": "Order status"},
+				map[string]any{"id": "agent", "title": "Talk to us"},
+			},
+			ConditionalNext: models.JSONB{"order": "ask_order", "agent": "handoff"},
+			MaxRetries:      2,
+		},
+		{
+			StepName: "ask_order", StepOrder: 3, MessageType: models.FlowStepTypeText,
+			Message: "Order number?", InputType: models.InputTypeText, StoreAs: "order_id",
+			ValidationRegex: `^\d+$`, ValidationError: "Digits only please", RetryOnInvalid: true, MaxRetries: 3,
+		},
+		{
+			StepName: "lookup", StepOrder: 4, MessageType: models.FlowStepTypeAPIFetch,
+			Message: "Order {{order_id}} is {{status}}", InputType: models.InputTypeNone, NextStep: "end",
+			ApiConfig: models.JSONB{
+				"url":              "https://api.example.invalid/orders/{{order_id}}",
+				"response_mapping": map[string]any{"status": "order.status"},
+			},
+		},
+		{
+			StepName: "handoff", StepOrder: 5, MessageType: models.FlowStepTypeTransfer,
+			Message: "Connecting you to an agent", InputType: models.InputTypeNone,
+		},
+	}
+	for i := range steps {
+		steps[i].BaseModel = models.BaseModel{ID: uuid.New()}
+		steps[i].FlowID = flow.ID
+		require.NoError(t, app.DB.Create(&steps[i]).Error)
+	}
+	return flow
+}
+
+func simulateFlow(t *testing.T, app *handlers.App, orgID, userID, flowID uuid.UUID, body map[string]any) (int, handlers.FlowSimulationResponse) {
+	t.Helper()
+
+	req := testutil.NewJSONRequest(t, body)
+	testutil.SetAuthContext(req, orgID, userID)
+	testutil.SetPathParam(req, "id", flowID.String())
+
+	require.NoError(t, app.SimulateChatbotFlow(req))
+
+	var resp struct {
+		Data handlers.FlowSimulationResponse `json:"data"`
+	}
+	status := testutil.GetResponseStatusCode(req)
+	if status == fasthttp.StatusOK {
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	}
+	return status, resp.Data
+}
+
+func TestApp_SimulateChatbotFlow(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	perms := getChatbotFlowPermissions(t, app)
+	role := testutil.CreateTestRole(t, app.DB, org.ID, "flow-admin", perms)
+	user := testutil.CreateTestUser(t, app.DB, org.ID,
+		testutil.WithEmail(testutil.UniqueEmail("simulate-flow")),
+		testutil.WithRoleID(&role.ID),
+	)
+	flow := createTestSimulationFlow(t, app, org.ID)
+
+	t.Run("full path with stubbed API", func(t *testing.T) {
+		status, resp := simulateFlow(t, app, org.ID, user.ID, flow.ID, map[string]any{
+			"inputs": []map[string]any{
+				{"text": "Asha"},
+				{"button_id": "order"},
+				{"text": "abc"},
+				{"text": "1234"},
+			},
+			"api_stubs": map[string]any{
+				"lookup": map[string]any{"body": map[string]any{"order": map[string]any{"status": "shipped"}}},
+			},
+		})
+		require.Equal(t, fasthttp.StatusOK, status)
+
+		assert.Equal(t, handlers.SimulationStatusCompleted, resp.Status)
+		assert.Equal(t, "Asha", resp.SessionData["name"])
+		assert.Equal(t, "order", resp.SessionData["choice"])
+		assert.Equal(t, "Order status", resp.SessionData["choice_title"])
+		assert.Equal(t, "1234", resp.SessionData["order_id"])
+		assert.Equal(t, "shipped", resp.SessionData["status"])
+
+		assert.Equal(t, []string{
+			"Hi there!",
+			"What's your name?",
+			"Hi Asha, what do you need?",
+			"Order number?",
+			"Digits only please",
+			"Order 1234 is shipped",
+			"Thanks Asha!",
+		}, bodiesOf(resp.Messages))
+
+		menu := resp.Messages[2]
+		assert.Equal(t, models.MessageTypeInteractive, menu.Type)
+		assert.Equal(t, "button", menu.InteractiveType)
+		require.Len(t, menu.Buttons, 2)
+		assert.Equal(t, 1, menu.Turn)
+
+		var stubbed bool
+		for _, e := range resp.Events {
+			if e.Type == handlers.SimulationEventApiFetch {
+				stubbed = true
+				assert.Equal(t, "GET https://api.example.invalid/orders/1234 (stubbed)", e.Detail)
+			}
+		}
+		assert.True(t, stubbed)
+	})
+
+	t.Run("skip condition and transfer", func(t *testing.T) {
+		status, resp := simulateFlow(t, app, org.ID, user.ID, flow.ID, map[string]any{
+			"session_data": map[string]any{"name": "Ravi"},
+			"inputs":       []map[string]any{{"text": "talk to us"}, {"text": "hello?"}},
+		})
+		require.Equal(t, fasthttp.StatusOK, status)
+
+		assert.Equal(t, handlers.SimulationStatusTransferred, resp.Status)
+		assert.Equal(t, "Hi Ravi, what do you need?", resp.Messages[1].Body)
+		assert.Equal(t, "Connecting you to an agent", resp.Messages[len(resp.Messages)-1].Body)
+
+		var types []string
+		for _, e := range resp.Events {
+			types = append(types, e.Type)
+		}
+		assert.Contains(t, types, handlers.SimulationEventStepSkipped)
+		assert.Contains(t, types, handlers.SimulationEventTransfer)
+		assert.Equal(t, handlers.SimulationEventInputIgnored, types[len(types)-1])
+	})
+
+	t.Run("API failure uses fallback", func(t *testing.T) {
+		status, resp := simulateFlow(t, app, org.ID, user.ID, flow.ID, map[string]any{
+			"session_data": map[string]any{"name": "Ravi"},
+			"inputs":       []map[string]any{{"button_id": "order"}, {"text": "42"}},
+			"api_stubs":    map[string]any{"lookup": map[string]any{"status": 500, "body": "boom"}},
+		})
+		require.Equal(t, fasthttp.StatusOK, status)
+
+		assert.Equal(t, handlers.SimulationStatusCompleted, resp.Status)
+		var apiErr *handlers.FlowSimulationEvent
+		for i, e := range resp.Events {
+			if e.Type == handlers.SimulationEventApiError {
+				apiErr = &resp.Events[i]
+			}
+		}
+		require.NotNil(t, apiErr)
+		assert.Equal(t, "lookup", apiErr.Step)
+		assert.Contains(t, apiErr.Detail, "status 500")
+		assert.Equal(t, "Thanks Ravi!", resp.Messages[len(resp.Messages)-1].Body)
+	})
+
+	t.Run("cancel keyword", func(t *testing.T) {
+		status, resp := simulateFlow(t, app, org.ID, user.ID, flow.ID, map[string]any{
+			"inputs": []map[string]any{{"text": "please cancel"}},
+		})
+		require.Equal(t, fasthttp.StatusOK, status)
+		assert.Equal(t, handlers.SimulationStatusCancelled, resp.Status)
+		assert.Equal(t, "Flow cancelled.", resp.Messages[len(resp.Messages)-1].Body)
+	})
+
+	t.Run("invalid button exits after max retries", func(t *testing.T) {
+		status, resp := simulateFlow(t, app, org.ID, user.ID, flow.ID, map[string]any{
+			"session_data": map[string]any{"name": "Ravi"},
+			"inputs":       []map[string]any{{"text": "what?"}, {"text": "huh"}},
+		})
+		require.Equal(t, fasthttp.StatusOK, status)
+		assert.Equal(t, handlers.SimulationStatusExited, resp.Status)
+		assert.Equal(t, []string{
+			"Hi there!",
+			"Hi Ravi, what do you need?",
+			"Hi Ravi, what do you need?",
+			"Sorry, we couldn't continue. Please try again later.",
+		}, bodiesOf(resp.Messages))
+	})
+
+	t.Run("wait elapses immediately", func(t *testing.T) {
+		waitFlow := createTestChatbotFlow(t, app, org.ID, "Follow Up")
+		steps := []models.ChatbotFlowStep{
+			{
+				StepName: "pause", StepOrder: 1, MessageType: models.FlowStepTypeWait,
+				Message: "We'll check back soon", InputType: models.InputTypeNone,
+				WaitConfig: models.JSONB{"duration_minutes": 120},
+			},
+			{
+				StepName: "follow_up", StepOrder: 2, MessageType: models.FlowStepTypeText,
+				Message: "Did that help?", InputType: models.InputTypeText, StoreAs: "feedback",
+			},
+		}
+		for i := range steps {
+			steps[i].BaseModel = models.BaseModel{ID: uuid.New()}
+			steps[i].FlowID = waitFlow.ID
+			require.NoError(t, app.DB.Create(&steps[i]).Error)
+		}
+
+		status, resp := simulateFlow(t, app, org.ID, user.ID, waitFlow.ID, map[string]any{})
+		require.Equal(t, fasthttp.StatusOK, status)
+		assert.Equal(t, []string{"We'll check back soon", "Did that help?"}, bodiesOf(resp.Messages))
+
+		var wait *handlers.FlowSimulationEvent
+		for i, e := range resp.Events {
+			if e.Type == handlers.SimulationEventWait {
+				wait = &resp.Events[i]
+			}
+		}
+		require.NotNil(t, wait)
+		assert.Equal(t, "pause", wait.Step)
+		assert.Contains(t, wait.Detail, "resumes after 2h0m0s")
+	})
+
+	t.Run("action step reports actions without running them", func(t *testing.T) {
+		actionFlow := createTestChatbotFlow(t, app, org.ID, "Qualify")
+		step := models.ChatbotFlowStep{
+			BaseModel: models.BaseModel{ID: uuid.New()}, FlowID: actionFlow.ID,
+			StepName: "tag", StepOrder: 1, MessageType: models.FlowStepTypeAction,
+			Message: "You're on the {{plan}} plan", InputType: models.InputTypeText,
+			Actions: models.JSONBArray{
+				map[string]any{"type": "add_tag", "tag": "plan-{{plan}}"},
+				map[string]any{"type": "set_metadata", "key": "plan", "value": "{{plan}}"},
+			},
+		}
+		require.NoError(t, app.DB.Create(&step).Error)
+
+		status, resp := simulateFlow(t, app, org.ID, user.ID, actionFlow.ID, map[string]any{
+			"session_data": map[string]any{"plan": "gold"},
+		})
+		require.Equal(t, fasthttp.StatusOK, status)
+		assert.Equal(t, handlers.SimulationStatusCompleted, resp.Status)
+		assert.Equal(t, []string{"You're on the gold plan"}, bodiesOf(resp.Messages))
+
+		var actions []string
+		for _, e := range resp.Events {
+			if e.Type == handlers.SimulationEventAction {
+				actions = append(actions, e.Detail)
+			}
+		}
+		assert.Equal(t, []string{`add_tag "plan-gold"`, "set_metadata plan = gold"}, actions)
+	})
+
+	t.Run("ai step retries invalid extraction", func(t *testing.T) {
+		aiFlow := createTestChatbotFlow(t, app, org.ID, "Booking")
+		step := models.ChatbotFlowStep{
+			BaseModel: models.BaseModel{ID: uuid.New()}, FlowID: aiFlow.ID,
+			StepName: "details", StepOrder: 1, MessageType: models.FlowStepTypeAI,
+			Message: "When and for how many people?", InputType: models.InputTypeText, StoreAs: "booking",
+			AIConfig: models.JSONB{
+				"mode": "extract",
+				"schema": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"date":   map[string]any{"type": "string"},
+						"guests": map[string]any{"type": "integer"},
+					},
+					"required": []any{"date", "guests"},
+				},
+			},
+		}
+		require.NoError(t, app.DB.Create(&step).Error)
+
+		status, resp := simulateFlow(t, app, org.ID, user.ID, aiFlow.ID, map[string]any{
+			"inputs": []map[string]any{{"text": "Friday, 4 of us"}},
+			"ai_stubs": map[string]any{
+				"details": []string{"Sure! Friday for 4.", "```json\n{\"date\": \"friday\", \"guests\": 4, \"notes\": null}\n```"},
+			},
+		})
+		require.Equal(t, fasthttp.StatusOK, status)
+		assert.Equal(t, handlers.SimulationStatusCompleted, resp.Status)
+		assert.Equal(t, []string{"When and for how many people?"}, bodiesOf(resp.Messages))
+		assert.Equal(t, "friday", resp.SessionData["date"])
+		assert.Equal(t, float64(4), resp.SessionData["guests"])
+		assert.Equal(t, map[string]any{"date": "friday", "guests": float64(4)}, resp.SessionData["booking"])
+
+		var details []string
+		for _, e := range resp.Events {
+			if e.Type == handlers.SimulationEventAI {
+				details = append(details, e.Detail)
+			}
+		}
+		assert.Contains(t, details, "invalid model output: reply is not a JSON object")
+		assert.Contains(t, details, `extracted {"date":"friday","guests":4}`)
+	})
+
+	t.Run("has no side effects", func(t *testing.T) {
+		var sessions, messages int64
+		require.NoError(t, app.DB.Model(&models.ChatbotSession{}).Where("organization_id = ?", org.ID).Count(&sessions).Error)
+		require.NoError(t, app.DB.Model(&models.Message{}).Where("organization_id = ?", org.ID).Count(&messages).Error)
+		assert.Zero(t, sessions)
+		assert.Zero(t, messages)
+	})
+
+	t.Run("other organization", func(t *testing.T) {
+		otherOrg := testutil.CreateTestOrganization(t, app.DB)
+		otherRole := testutil.CreateTestRole(t, app.DB, otherOrg.ID, "flow-admin", perms)
+		otherUser := testutil.CreateTestUser(t, app.DB, otherOrg.ID,
+			testutil.WithEmail(testutil.UniqueEmail("simulate-flow-other")),
+			testutil.WithRoleID(&otherRole.ID),
+		)
+		status, _ := simulateFlow(t, app, otherOrg.ID, otherUser.ID, flow.ID, map[string]any{})
+		assert.Equal(t, fasthttp.StatusNotFound, status)
+	})
+}
+
+func bodiesOf(messages []handlers.SimulatedMessage) []string {
+	bodies := make([]string, len(messages))
+	for i, m := range messages {
+		bodies[i] = m.Body
+	}
+	return bodies
+}
@@ -508,6 +508,89 @@ func TestApp_DeleteKeywordRule(t *testing.T) {
 	})
 }
 
+// =============================================================================
+// TestKeywordMatch
+// =============================================================================
+
+func TestApp_TestKeywordMatch(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+
+	rules := []*models.KeywordRule{
+		{Name: "Greeting", Keywords: models.StringArray{"hello"}, MatchType: models.MatchTypeExact, Priority: 20},
+		{Name: "Order status", Keywords: models.StringArray{"where is my order", "track my order"}, MatchType: models.MatchTypeIntent, Priority: 10},
+		{Name: "Refund", Keywords: models.StringArray{"I want a refund", "money back"}, MatchType: models.MatchTypeIntent, Priority: 10},
+	}
+	for _, rule := range rules {
+		rule.BaseModel = models.BaseModel{ID: uuid.New()}
+		rule.OrganizationID = org.ID
+		rule.ResponseType = models.ResponseTypeText
+		rule.ResponseContent = models.JSONB{"body": rule.Name}
+		rule.IsEnabled = true
+		require.NoError(t, app.DB.Create(rule).Error)
+	}
+
+	type testResponse struct {
+		Data struct {
+			Matched   bool    `json:"matched"`
+			RuleID    string  `json:"rule_id"`
+			RuleName  string  `json:"rule_name"`
+			MatchType string  `json:"match_type"`
+			Keyword   string  `json:"keyword"`
+			Score     float64 `json:"score"`
+			Method    string  `json:"method"`
+			Intents   []struct {
+				RuleName string  `json:"rule_name"`
+				Score    float64 `json:"score"`
+			} `json:"intents"`
+		} `json:"data"`
+	}
+	testMessage := func(t *testing.T, message string) testResponse {
+		req := testutil.NewJSONRequest(t, map[string]any{"message": message})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.TestKeywordMatch(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		var resp testResponse
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		return resp
+	}
+
+	t.Run("intent", func(t *testing.T) {
+		resp := testMessage(t, "where is my ordr?")
+		assert.True(t, resp.Data.Matched)
+		assert.Equal(t, rules[1].ID.String(), resp.Data.RuleID)
+		assert.Equal(t, "intent", resp.Data.MatchType)
+		assert.Equal(t, "where is my order", resp.Data.Keyword)
+		assert.Equal(t, "local", resp.Data.Method)
+		assert.Greater(t, resp.Data.Score, 0.5)
+		require.Len(t, resp.Data.Intents, 2)
+		assert.Equal(t, "Order status", resp.Data.Intents[0].RuleName)
+	})
+
+	t.Run("higher priority rule", func(t *testing.T) {
+		resp := testMessage(t, "hello")
+		assert.True(t, resp.Data.Matched)
+		assert.Equal(t, "Greeting", resp.Data.RuleName)
+		assert.Equal(t, 1.0, resp.Data.Score)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		resp := testMessage(t, "what are your opening hours")
+		assert.False(t, resp.Data.Matched)
+		assert.Len(t, resp.Data.Intents, 2)
+	})
+
+	t.Run("empty message", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, map[string]any{"message": " "})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.TestKeywordMatch(req))
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	})
+}
+
 // =============================================================================
 // ListChatbotFlows
 // =============================================================================
@@ -1301,6 +1384,121 @@ func TestApp_UpdateChatbotSettings_PartialUpdate(t *testing.T) {
 		assert.Equal(t, "SLA warning: response time exceeded.", resp.Data.Settings.SLAWarningMessage)
 	})
 
+	t.Run("update self-hosted AI provider", func(t *testing.T) {
+		app := newTestApp(t)
+		app.Config.AI.PrivateHosts = []string{"10.0.0.5:8000"}
+		org := testutil.CreateTestOrganization(t, app.DB)
+		user := testutil.CreateTestUser(t, app.DB, org.ID)
+
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"ai_provider":        "openai_compatible",
+			"ai_base_url":        "http://10.0.0.5:8000/v1",
+			"ai_timeout_seconds": 90,
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		err := app.UpdateChatbotSettings(req)
+		require.NoError(t, err)
+		assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		getReq := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(getReq, org.ID, user.ID)
+		err = app.GetChatbotSettings(getReq)
+		require.NoError(t, err)
+
+		var resp struct {
+			Data struct {
+				Settings handlers.ChatbotSettingsResponse `json:"settings"`
+			} `json:"data"`
+		}
+		err = json.Unmarshal(testutil.GetResponseBody(getReq), &resp)
+		require.NoError(t, err)
+
+		assert.Equal(t, models.AIProviderOpenAICompatible, resp.Data.Settings.AIProvider)
+		assert.Equal(t, "http://10.0.0.5:8000/v1", resp.Data.Settings.AIBaseURL)
+		assert.Equal(t, 90, resp.Data.Settings.AITimeoutSeconds)
+	})
+
+	t.Run("invalid AI settings return 400", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		user := testutil.CreateTestUser(t, app.DB, org.ID)
+
+		for _, body := range []map[string]any{
+			{"ai_provider": "mistral-direct"},
+			{"ai_base_url": "ftp://models.example.com"},
+			{"ai_base_url": "http://127.0.0.1:11434/v1"},
+			{"ai_timeout_seconds": 0},
+			{"ai_timeout_seconds": 301},
+			{"ai_max_tool_calls": 0},
+			{"ai_max_tool_calls": 11},
+		} {
+			req := testutil.NewJSONRequest(t, body)
+			testutil.SetAuthContext(req, org.ID, user.ID)
+
+			err := app.UpdateChatbotSettings(req)
+			require.NoError(t, err)
+			assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req), body)
+		}
+	})
+
+	t.Run("update CSAT settings", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		user := testutil.CreateTestUser(t, app.DB, org.ID)
+
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"csat_enabled":           true,
+			"csat_delay_minutes":     0,
+			"csat_survey_type":       "flow",
+			"csat_flow_id":           "1234567890",
+			"csat_question":          "How did we do?",
+			"csat_thank_you_message": "Thanks for rating us!",
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		require.NoError(t, app.UpdateChatbotSettings(req))
+		assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		getReq := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(getReq, org.ID, user.ID)
+		require.NoError(t, app.GetChatbotSettings(getReq))
+
+		var resp struct {
+			Data struct {
+				Settings handlers.ChatbotSettingsResponse `json:"settings"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(getReq), &resp))
+
+		assert.True(t, resp.Data.Settings.CSATEnabled)
+		assert.Equal(t, 0, resp.Data.Settings.CSATDelayMinutes)
+		assert.Equal(t, models.CSATSurveyTypeFlow, resp.Data.Settings.CSATSurveyType)
+		assert.Equal(t, "1234567890", resp.Data.Settings.CSATFlowID)
+		assert.Equal(t, "How did we do?", resp.Data.Settings.CSATQuestion)
+		assert.Equal(t, "Thanks for rating us!", resp.Data.Settings.CSATThankYouMessage)
+	})
+
+	t.Run("invalid CSAT settings return 400", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		user := testutil.CreateTestUser(t, app.DB, org.ID)
+
+		for _, body := range []map[string]any{
+			{"csat_delay_minutes": -1},
+			{"csat_delay_minutes": 24*60 + 1},
+			{"csat_survey_type": "stars"},
+			{"csat_flow_cta": "Tell us how we did today"},
+			{"csat_enabled": true, "csat_survey_type": "flow"},
+		} {
+			req := testutil.NewJSONRequest(t, body)
+			testutil.SetAuthContext(req, org.ID, user.ID)
+
+			require.NoError(t, app.UpdateChatbotSettings(req))
+			assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req), body)
+		}
+	})
+
 	t.Run("invalid JSON body returns 400", func(t *testing.T) {
 		app := newTestApp(t)
 		org := testutil.CreateTestOrganization(t, app.DB)
@@ -1673,6 +1871,84 @@ func TestApp_CreateChatbotFlow_Additional(t *testing.T) {
 		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
 	})
 
+	t.Run("validation error invalid wait step", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		perms := getChatbotFlowPermissions(t, app)
+		role := testutil.CreateTestRole(t, app.DB, org.ID, "flow-admin", perms)
+		user := testutil.CreateTestUser(t, app.DB, org.ID,
+			testutil.WithEmail(testutil.UniqueEmail("create-flow-badwait")),
+			testutil.WithRoleID(&role.ID),
+		)
+
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"name":             "Bad Wait Flow",
+			"trigger_keywords": []string{"wait"},
+			"steps": []map[string]any{
+				{"step_name": "pause", "message_type": "wait", "wait_config": map[string]any{"until": "next week"}},
+			},
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		err := app.CreateChatbotFlow(req)
+		require.NoError(t, err)
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+		assert.Contains(t, string(testutil.GetResponseBody(req)), "pause")
+	})
+
+	t.Run("validation error unknown flow action", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		perms := getChatbotFlowPermissions(t, app)
+		role := testutil.CreateTestRole(t, app.DB, org.ID, "flow-admin", perms)
+		user := testutil.CreateTestUser(t, app.DB, org.ID,
+			testutil.WithEmail(testutil.UniqueEmail("create-flow-badaction")),
+			testutil.WithRoleID(&role.ID),
+		)
+
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"name":             "Bad Action Flow",
+			"trigger_keywords": []string{"action"},
+			"steps": []map[string]any{
+				{"step_name": "tag", "message_type": "action", "actions": []map[string]any{
+					{"type": "add_tag", "tag": "vip"},
+					{"type": "delete_contact"},
+				}},
+			},
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		err := app.CreateChatbotFlow(req)
+		require.NoError(t, err)
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+		assert.Contains(t, string(testutil.GetResponseBody(req)), "action 2")
+	})
+
+	t.Run("validation error ai extract without schema", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		perms := getChatbotFlowPermissions(t, app)
+		role := testutil.CreateTestRole(t, app.DB, org.ID, "flow-admin", perms)
+		user := testutil.CreateTestUser(t, app.DB, org.ID,
+			testutil.WithEmail(testutil.UniqueEmail("create-flow-badai")),
+			testutil.WithRoleID(&role.ID),
+		)
+
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"name":             "Bad AI Flow",
+			"trigger_keywords": []string{"book"},
+			"steps": []map[string]any{
+				{"step_name": "details", "message_type": "ai", "input_type": "text", "ai_config": map[string]any{"mode": "extract"}},
+			},
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		err := app.CreateChatbotFlow(req)
+		require.NoError(t, err)
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+		assert.Contains(t, string(testutil.GetResponseBody(req)), "schema")
+	})
+
 	t.Run("create flow without steps", func(t *testing.T) {
 		app := newTestApp(t)
 		org := testutil.CreateTestOrganization(t, app.DB)
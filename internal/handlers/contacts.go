@@ -7,8 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path/filepath"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -23,20 +22,24 @@ import (
 
 // ContactResponse represents a contact with additional fields for the frontend
 type ContactResponse struct {
-	ID                 uuid.UUID  `json:"id"`
-	PhoneNumber        string     `json:"phone_number"`
-	Name               string     `json:"name"`
-	ProfileName        string     `json:"profile_name"`
-	AvatarURL          string     `json:"avatar_url"`
-	Status             string     `json:"status"`
-	Tags               []string   `json:"tags"`
-	Metadata           any        `json:"metadata"`
-	LastMessageAt      *time.Time `json:"last_message_at"`
-	LastMessagePreview string     `json:"last_message_preview"`
-	UnreadCount        int        `json:"unread_count"`
-	AssignedUserID     *uuid.UUID `json:"assigned_user_id,omitempty"`
-	CreatedAt          time.Time  `json:"created_at"`
-	UpdatedAt          time.Time  `json:"updated_at"`
+	ID                 uuid.UUID                 `json:"id"`
+	PhoneNumber        string                    `json:"phone_number"`
+	Name               string                    `json:"name"`
+	ProfileName        string                    `json:"profile_name"`
+	AvatarURL          string                    `json:"avatar_url"`
+	Status             string                    `json:"status"`
+	Tags               []string                  `json:"tags"`
+	Metadata           any                       `json:"metadata"`
+	LastMessageAt      *time.Time                `json:"last_message_at"`
+	LastMessagePreview string                    `json:"last_message_preview"`
+	UnreadCount        int                       `json:"unread_count"`
+	AssignedUserID     *uuid.UUID                `json:"assigned_user_id,omitempty"`
+	Language           string                    `json:"language"`
+	LanguageSource     string                    `json:"language_source"`
+	ConversationStatus models.ConversationStatus `json:"conversation_status"`
+	SnoozedUntil       *time.Time                `json:"snoozed_until,omitempty"`
+	CreatedAt          time.Time                 `json:"created_at"`
+	UpdatedAt          time.Time                 `json:"updated_at"`
 }
 
 // MessageResponse represents a message for the frontend
@@ -88,6 +91,7 @@ func (a *App) ListContacts(r *fastglue.Request) error {
 	pg := parsePagination(r)
 	search := string(r.RequestCtx.QueryArgs().Peek("search"))
 	tagsParam := string(r.RequestCtx.QueryArgs().Peek("tags"))
+	conversationStatus := string(r.RequestCtx.QueryArgs().Peek("conversation_status"))
 
 	var contacts []models.Contact
 	query := a.ScopeToOrg(a.DB, userID, orgID)
@@ -128,6 +132,11 @@ func (a *App) ListContacts(r *fastglue.Request) error {
 		}
 	}
 
+	// Filter by the status of the contact's conversation (open when there is none yet)
+	if conversationStatus != "" {
+		query = query.Where(contactConversationStatus+" = ?", conversationStatus)
+	}
+
 	// Order by last message time (most recent first)
 	query = query.Order("last_message_at DESC NULLS LAST, created_at DESC")
 
@@ -142,6 +151,8 @@ func (a *App) ListContacts(r *fastglue.Request) error {
 	// Check if phone masking is enabled
 	shouldMask := a.ShouldMaskPhoneNumbers(orgID)
 
+	conversations := a.conversationStatuses(orgID, contacts)
+
 	// Convert to response format
 	response := make([]ContactResponse, len(contacts))
 	for i, c := range contacts {
@@ -179,9 +190,16 @@ func (a *App) ListContacts(r *fastglue.Request) error {
 			LastMessagePreview: c.LastMessagePreview,
 			UnreadCount:        int(unreadCount),
 			AssignedUserID:     c.AssignedUserID,
+			Language:           c.Language,
+			LanguageSource:     string(c.LanguageSource),
+			ConversationStatus: models.ConversationStatusOpen,
 			CreatedAt:          c.CreatedAt,
 			UpdatedAt:          c.UpdatedAt,
 		}
+		if conv, ok := conversations[c.ID]; ok {
+			response[i].ConversationStatus = conv.Status
+			response[i].SnoozedUntil = conv.SnoozedUntil
+		}
 	}
 
 	return r.SendEnvelope(map[string]any{
@@ -251,9 +269,16 @@ func (a *App) GetContact(r *fastglue.Request) error {
 		LastMessagePreview: contact.LastMessagePreview,
 		UnreadCount:        int(unreadCount),
 		AssignedUserID:     contact.AssignedUserID,
+		Language:           contact.Language,
+		LanguageSource:     string(contact.LanguageSource),
+		ConversationStatus: models.ConversationStatusOpen,
 		CreatedAt:          contact.CreatedAt,
 		UpdatedAt:          contact.UpdatedAt,
 	}
+	if conv, ok := a.conversationStatuses(orgID, []models.Contact{contact})[contact.ID]; ok {
+		response.ConversationStatus = conv.Status
+		response.SnoozedUntil = conv.SnoozedUntil
+	}
 
 	return r.SendEnvelope(response)
 }
@@ -741,10 +766,10 @@ func (a *App) SendMediaMessage(r *fastglue.Request) error {
 		}
 	}
 
-	// Save file locally first
-	localPath, err := a.saveMediaLocally(fileData, mimeType, fileHeader.Filename)
+	// Save file to media storage first
+	localPath, err := a.saveMedia(r.RequestCtx, fileData, mimeType, fileHeader.Filename)
 	if err != nil {
-		a.Log.Error("Failed to save media locally", "error", err)
+		a.Log.Error("Failed to save media", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save media", nil, "")
 	}
 
@@ -786,26 +811,8 @@ func (a *App) SendMediaMessage(r *fastglue.Request) error {
 	return r.SendEnvelope(response)
 }
 
-// saveMediaLocally saves media data to local storage and returns the relative path
-func (a *App) saveMediaLocally(data []byte, mimeType, filename string) (string, error) {
-	// Determine subdirectory based on MIME type
-	var subdir string
-	switch {
-	case strings.HasPrefix(mimeType, "image/"):
-		subdir = "images"
-	case strings.HasPrefix(mimeType, "video/"):
-		subdir = "videos"
-	case strings.HasPrefix(mimeType, "audio/"):
-		subdir = "audio"
-	default:
-		subdir = "documents"
-	}
-
-	// Ensure directory exists
-	if err := a.ensureMediaDir(subdir); err != nil {
-		return "", fmt.Errorf("failed to create media directory: %w", err)
-	}
-
+// saveMedia saves media data to media storage and returns the storage key (relative path)
+func (a *App) saveMedia(ctx context.Context, data []byte, mimeType, filename string) (string, error) {
 	// Get extension from MIME type or filename
 	ext := getExtensionFromMimeType(mimeType)
 	if ext == "" {
@@ -817,20 +824,15 @@ func (a *App) saveMediaLocally(data []byte, mimeType, filename string) (string,
 		}
 	}
 
-	// Generate unique filename
-	newFilename := uuid.New().String() + ext
-	filePath := filepath.Join(a.getMediaStoragePath(), subdir, newFilename)
-
-	// Save file
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	// Store under a unique filename in a subdirectory based on MIME type
+	key := path.Join(getMediaSubdir(mimeType), uuid.New().String()+ext)
+	if err := a.mediaStorage().Put(ctx, key, data, mimeType); err != nil {
 		return "", fmt.Errorf("failed to save media file: %w", err)
 	}
 
-	// Return relative path
-	relativePath := filepath.Join(subdir, newFilename)
-	a.Log.Info("Media saved locally", "path", relativePath, "size", len(data))
+	a.Log.Info("Media saved", "path", key, "size", len(data))
 
-	return relativePath, nil
+	return key, nil
 }
 
 // SendReactionRequest represents a request to send a reaction
@@ -1133,8 +1135,8 @@ func (a *App) GetContactSessionData(r *fastglue.Request) error {
 		}
 
 		if flowID != nil {
-			// Use cached flow to avoid DB query
-			flow, err := a.getChatbotFlowByIDCached(orgID, *flowID)
+			// Use cached flow to avoid DB query, pinned to the version the session ran
+			flow, err := a.getPinnedChatbotFlow(orgID, *flowID, session.CurrentFlowVersion)
 			if err == nil && flow != nil {
 				response.FlowName = flow.Name
 				response.FlowID = flowID
@@ -1350,6 +1352,7 @@ type UpdateContactRequest struct {
 	Tags            []string        `json:"tags"`
 	Metadata        *map[string]any `json:"metadata"`
 	AssignedUserID  *uuid.UUID      `json:"assigned_user_id"`
+	Language        *string         `json:"language"` // empty clears it so the chatbot detects it again
 }
 
 // UpdateContact updates an existing contact
@@ -1407,6 +1410,19 @@ func (a *App) UpdateContact(r *fastglue.Request) error {
 		}
 		updates["assigned_user_id"] = req.AssignedUserID
 	}
+	if req.Language != nil {
+		lang := strings.TrimSpace(*req.Language)
+		switch {
+		case lang == "":
+			updates["language"] = ""
+			updates["language_source"] = ""
+		case validLanguageCode.MatchString(lang):
+			updates["language"] = lang
+			updates["language_source"] = models.LanguageSourceManual
+		default:
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid language", nil, "")
+		}
+	}
 
 	if len(updates) == 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "No fields to update", nil, "")
@@ -1482,7 +1498,7 @@ func (a *App) buildContactResponse(contact *models.Contact, orgID uuid.UUID) Con
 		profileName = MaskIfPhoneNumber(profileName)
 	}
 
-	return ContactResponse{
+	response := ContactResponse{
 		ID:                 contact.ID,
 		PhoneNumber:        phoneNumber,
 		Name:               profileName,
@@ -1494,7 +1510,15 @@ func (a *App) buildContactResponse(contact *models.Contact, orgID uuid.UUID) Con
 		LastMessagePreview: contact.LastMessagePreview,
 		UnreadCount:        int(unreadCount),
 		AssignedUserID:     contact.AssignedUserID,
+		Language:           contact.Language,
+		LanguageSource:     string(contact.LanguageSource),
+		ConversationStatus: models.ConversationStatusOpen,
 		CreatedAt:          contact.CreatedAt,
 		UpdatedAt:          contact.UpdatedAt,
 	}
+	if conv, ok := a.conversationStatuses(orgID, []models.Contact{*contact})[contact.ID]; ok {
+		response.ConversationStatus = conv.Status
+		response.SnoozedUntil = conv.SnoozedUntil
+	}
+	return response
 }
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,6 +12,40 @@ import (
 	"github.com/zerodha/fastglue"
 )
 
+// noteMentionPattern matches mentions in note content, written as
+// @[Name](user:<id>) or @[Name](team:<id>) so they survive renames.
+var noteMentionPattern = regexp.MustCompile(`@\[([^\]\n]+)\]\((user|team):([0-9a-fA-F-]{36})\)`)
+
+// noteMentions holds the users and teams mentioned in a note.
+type noteMentions struct {
+	UserIDs []uuid.UUID
+	TeamIDs []uuid.UUID
+}
+
+// parseNoteMentions extracts the distinct users and teams mentioned in note content.
+func parseNoteMentions(content string) noteMentions {
+	var mentions noteMentions
+	seen := make(map[uuid.UUID]bool)
+	for _, match := range noteMentionPattern.FindAllStringSubmatch(content, -1) {
+		id, err := uuid.Parse(match[3])
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if match[2] == "user" {
+			mentions.UserIDs = append(mentions.UserIDs, id)
+		} else {
+			mentions.TeamIDs = append(mentions.TeamIDs, id)
+		}
+	}
+	return mentions
+}
+
+// noteMentionText replaces mentions in note content with @Name for plain-text previews.
+func noteMentionText(content string) string {
+	return strings.TrimSpace(noteMentionPattern.ReplaceAllString(content, "@$1"))
+}
+
 // ConversationNoteRequest represents the request body for creating/updating a note.
 type ConversationNoteRequest struct {
 	Content string `json:"content"`
@@ -144,6 +180,8 @@ func (a *App) CreateConversationNote(r *fastglue.Request) error {
 		})
 	}
 
+	a.notifyNoteMentions(&note, "")
+
 	return r.SendEnvelope(resp)
 }
 
@@ -187,6 +225,7 @@ func (a *App) UpdateConversationNote(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "content is required", nil, "")
 	}
 
+	previousContent := note.Content
 	note.Content = req.Content
 	if err := a.DB.Save(note).Error; err != nil {
 		a.Log.Error("Failed to update conversation note", "error", err)
@@ -209,6 +248,9 @@ func (a *App) UpdateConversationNote(r *fastglue.Request) error {
 		})
 	}
 
+	// Only users mentioned by the edit are notified
+	a.notifyNoteMentions(note, previousContent)
+
 	return r.SendEnvelope(resp)
 }
 
@@ -262,6 +304,8 @@ func (a *App) DeleteConversationNote(r *fastglue.Request) error {
 		})
 	}
 
+	a.deleteNoteNotifications(orgID, noteID)
+
 	return r.SendEnvelope(map[string]string{"message": "Note deleted"})
 }
 
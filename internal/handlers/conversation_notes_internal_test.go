@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNoteMentions(t *testing.T) {
+	alice, bob, support := uuid.New(), uuid.New(), uuid.New()
+	content := "@[Alice Smith](user:" + alice.String() + ") and @[Support](team:" + support.String() + ") please check. " +
+		"cc @[Bob](user:" + bob.String() + ") @[Alice](user:" + alice.String() + ") @[Ghost](user:not-a-uuid-at-all-not-a-uuid-at-all-x) @bob"
+
+	mentions := parseNoteMentions(content)
+	assert.Equal(t, []uuid.UUID{alice, bob}, mentions.UserIDs)
+	assert.Equal(t, []uuid.UUID{support}, mentions.TeamIDs)
+
+	assert.Empty(t, parseNoteMentions("email me at alice@example.com").UserIDs)
+}
+
+func TestNoteMentionText(t *testing.T) {
+	id := uuid.New()
+	assert.Equal(t, "@Alice Smith can you call back?", noteMentionText(" @[Alice Smith](user:"+id.String()+") can you call back? "))
+	assert.Equal(t, "no mentions", noteMentionText("no mentions"))
+}
@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxResolutionReasonLength limits the reason given when resolving a conversation
+	maxResolutionReasonLength = 255
+
+	// snoozeWakeBatchSize limits how many snoozed conversations are reopened per tick
+	snoozeWakeBatchSize = 100
+
+	// slaExpiredResolution is the resolution reason of conversations whose transfer expired
+	slaExpiredResolution = "No agent response within SLA"
+
+	// transferResumedResolution is the resolution reason of conversations whose
+	// transfer an agent closed
+	transferResumedResolution = "Transfer closed by agent"
+)
+
+// transferNotWaitingOnCustomer excludes transfers whose conversation is pending
+// or snoozed, as SLA clocks don't run while waiting on the customer
+const transferNotWaitingOnCustomer = `NOT EXISTS (SELECT 1 FROM conversations
+	WHERE conversations.contact_id = agent_transfers.contact_id
+	AND conversations.whats_app_account = agent_transfers.whats_app_account
+	AND conversations.status IN ('pending', 'snoozed')
+	AND conversations.deleted_at IS NULL)`
+
+// transferConversationJoin joins the conversation of each transfer's contact and account
+const transferConversationJoin = `LEFT JOIN conversations ON conversations.contact_id = agent_transfers.contact_id
+	AND conversations.whats_app_account = agent_transfers.whats_app_account
+	AND conversations.deleted_at IS NULL`
+
+// contactConversationStatus is the status of the conversation of each contact on
+// their account, open when there is none yet
+const contactConversationStatus = `COALESCE((SELECT conversations.status FROM conversations
+	WHERE conversations.contact_id = contacts.id
+	AND conversations.whats_app_account = contacts.whats_app_account
+	AND conversations.deleted_at IS NULL), 'open')`
+
+// ConversationStatusRequest represents the request to change the status of a conversation
+type ConversationStatusRequest struct {
+	Status           models.ConversationStatus `json:"status"`
+	SnoozedUntil     *time.Time                `json:"snoozed_until"`     // Required when snoozing
+	ResolutionReason string                    `json:"resolution_reason"` // Optional when resolving
+}
+
+// ConversationResponse represents a conversation in API responses
+type ConversationResponse struct {
+	ID                uuid.UUID                 `json:"id"`
+	ContactID         uuid.UUID                 `json:"contact_id"`
+	WhatsAppAccount   string                    `json:"whatsapp_account"`
+	Status            models.ConversationStatus `json:"status"`
+	SnoozedUntil      *time.Time                `json:"snoozed_until,omitempty"`
+	ResolutionReason  string                    `json:"resolution_reason,omitempty"`
+	ResolvedAt        *time.Time                `json:"resolved_at,omitempty"`
+	ResolvedByID      *uuid.UUID                `json:"resolved_by_id,omitempty"`
+	ResolvedByName    string                    `json:"resolved_by_name,omitempty"`
+	StatusChangedAt   time.Time                 `json:"status_changed_at"`
+	StatusChangedByID *uuid.UUID                `json:"status_changed_by_id,omitempty"`
+}
+
+// GetConversation returns the conversation of a contact on their WhatsApp account
+func (a *App) GetConversation(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionRead); err != nil {
+		return nil
+	}
+
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil
+	}
+
+	contact, err := findByIDAndOrg[models.Contact](a.DB, r, contactID, orgID, "Contact")
+	if err != nil {
+		return nil
+	}
+
+	conv, err := a.contactConversation(orgID, contact.ID, contact.WhatsAppAccount)
+	if err != nil {
+		a.Log.Error("Failed to load conversation", "error", err, "contact_id", contact.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load conversation", nil, "")
+	}
+
+	return r.SendEnvelope(a.conversationResponse(conv))
+}
+
+// UpdateConversationStatus opens, parks, snoozes or resolves the conversation of a contact.
+// Resolving it also resumes the contact's active agent transfer.
+func (a *App) UpdateConversationStatus(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	contactID, err := parsePathUUID(r, "id", "contact")
+	if err != nil {
+		return nil
+	}
+
+	contact, err := findByIDAndOrg[models.Contact](a.DB, r, contactID, orgID, "Contact")
+	if err != nil {
+		return nil
+	}
+
+	var req ConversationStatusRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if err := validateConversationStatus(req, time.Now()); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	conv, err := a.contactConversation(orgID, contact.ID, contact.WhatsAppAccount)
+	if err != nil {
+		a.Log.Error("Failed to load conversation", "error", err, "contact_id", contact.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load conversation", nil, "")
+	}
+
+	if _, err := a.changeConversationStatus(conv, req.Status, req.SnoozedUntil, req.ResolutionReason, &userID); err != nil {
+		a.Log.Error("Failed to update conversation status", "error", err, "conversation_id", conv.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update conversation status", nil, "")
+	}
+
+	// A resolved conversation no longer needs an agent
+	if req.Status == models.ConversationStatusResolved {
+		var transfers []models.AgentTransfer
+		a.DB.Where("organization_id = ? AND contact_id = ? AND status = ?", orgID, contact.ID, models.TransferStatusActive).Find(&transfers)
+		for i := range transfers {
+			if err := a.resumeTransfer(orgID, userID, &transfers[i]); err != nil {
+				a.Log.Error("Failed to resume transfer of resolved conversation", "error", err, "transfer_id", transfers[i].ID)
+			}
+		}
+	}
+
+	return r.SendEnvelope(a.conversationResponse(conv))
+}
+
+// validateConversationStatus checks a status change request
+func validateConversationStatus(req ConversationStatusRequest, now time.Time) error {
+	switch req.Status {
+	case models.ConversationStatusOpen, models.ConversationStatusPending, models.ConversationStatusResolved:
+		if req.SnoozedUntil != nil {
+			return fmt.Errorf("snoozed_until is only allowed when snoozing")
+		}
+	case models.ConversationStatusSnoozed:
+		if req.SnoozedUntil == nil {
+			return fmt.Errorf("snoozed_until is required to snooze a conversation")
+		}
+		if !req.SnoozedUntil.After(now) {
+			return fmt.Errorf("snoozed_until must be in the future")
+		}
+	default:
+		return fmt.Errorf("invalid status, expected open, pending, snoozed or resolved")
+	}
+	if req.ResolutionReason != "" && req.Status != models.ConversationStatusResolved {
+		return fmt.Errorf("resolution_reason is only allowed when resolving")
+	}
+	if len(req.ResolutionReason) > maxResolutionReasonLength {
+		return fmt.Errorf("resolution_reason is longer than %d characters", maxResolutionReasonLength)
+	}
+	return nil
+}
+
+// contactConversation returns the conversation of a contact on an account, creating it open
+func (a *App) contactConversation(orgID, contactID uuid.UUID, accountName string) (*models.Conversation, error) {
+	var conv models.Conversation
+	err := a.DB.Where("organization_id = ? AND contact_id = ? AND whats_app_account = ?", orgID, contactID, accountName).First(&conv).Error
+	if err == nil {
+		return &conv, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Another message may be creating it at the same time
+	conv = models.Conversation{
+		OrganizationID:  orgID,
+		ContactID:       contactID,
+		WhatsAppAccount: accountName,
+		Status:          models.ConversationStatusOpen,
+		StatusChangedAt: time.Now(),
+	}
+	if err := a.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&conv).Error; err != nil {
+		return nil, err
+	}
+	conv = models.Conversation{}
+	if err := a.DB.Where("organization_id = ? AND contact_id = ? AND whats_app_account = ?", orgID, contactID, accountName).First(&conv).Error; err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// changeConversationStatus moves a conversation to a status and broadcasts the
+// transition. changedBy is nil for system changes. The change is skipped if the
+// conversation changed in the meantime, e.g. a snoozed conversation reopened by
+// a message. Returns whether the conversation changed.
+func (a *App) changeConversationStatus(conv *models.Conversation, status models.ConversationStatus, snoozedUntil *time.Time, reason string, changedBy *uuid.UUID) (bool, error) {
+	previous, previousChangedAt := conv.Status, conv.StatusChangedAt
+	if previous == status && status != models.ConversationStatusSnoozed {
+		return false, nil
+	}
+
+	now := time.Now().Truncate(time.Microsecond)
+	updates := map[string]interface{}{
+		"status":               status,
+		"snoozed_until":        nil,
+		"resolution_reason":    "",
+		"resolved_at":          nil,
+		"resolved_by_id":       nil,
+		"status_changed_at":    now,
+		"status_changed_by_id": changedBy,
+	}
+	switch status {
+	case models.ConversationStatusSnoozed:
+		until := snoozedUntil.Truncate(time.Microsecond)
+		snoozedUntil = &until
+		updates["snoozed_until"] = until
+	case models.ConversationStatusResolved:
+		updates["resolution_reason"] = reason
+		updates["resolved_at"] = now
+		updates["resolved_by_id"] = changedBy
+	}
+
+	query := a.DB.Model(&models.Conversation{}).Where("id = ? AND status = ?", conv.ID, previous)
+	if conv.SnoozedUntil != nil {
+		query = query.Where("snoozed_until = ?", *conv.SnoozedUntil)
+	} else {
+		query = query.Where("snoozed_until IS NULL")
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Changed by someone else, return the current state
+		return false, a.DB.First(conv, conv.ID).Error
+	}
+
+	conv.Status = status
+	conv.SnoozedUntil = nil
+	conv.ResolutionReason = ""
+	conv.ResolvedAt = nil
+	conv.ResolvedByID = nil
+	conv.StatusChangedAt = now
+	conv.StatusChangedByID = changedBy
+	switch status {
+	case models.ConversationStatusSnoozed:
+		conv.SnoozedUntil = snoozedUntil
+	case models.ConversationStatusResolved:
+		conv.ResolutionReason = reason
+		conv.ResolvedAt = &now
+		conv.ResolvedByID = changedBy
+	}
+
+	// SLA clocks of the contact's transfers were stopped while waiting on the customer
+	if status == models.ConversationStatusOpen && (previous == models.ConversationStatusPending || previous == models.ConversationStatusSnoozed) {
+		a.extendTransferDeadlines(conv, now.Sub(previousChangedAt))
+	}
+
+	a.Log.Info("Conversation status changed", "conversation_id", conv.ID, "contact_id", conv.ContactID, "from", previous, "to", status)
+	a.broadcastConversationStatus(conv, previous)
+	return true, nil
+}
+
+// extendTransferDeadlines pushes the SLA deadlines of a conversation's active
+// transfers back by the time it was waiting on the customer
+func (a *App) extendTransferDeadlines(conv *models.Conversation, waited time.Duration) {
+	if waited <= 0 {
+		return
+	}
+	interval := fmt.Sprintf("%d seconds", int64(waited.Seconds()))
+	updates := make(map[string]interface{})
+	for _, column := range []string{"sla_response_deadline", "sla_resolution_deadline", "sla_escalation_at", "expires_at"} {
+		updates[column] = gorm.Expr(column+" + ?::interval", interval)
+	}
+	if err := a.DB.Model(&models.AgentTransfer{}).
+		Where("organization_id = ? AND contact_id = ? AND whats_app_account = ? AND status = ?", conv.OrganizationID, conv.ContactID, conv.WhatsAppAccount, models.TransferStatusActive).
+		Updates(updates).Error; err != nil {
+		a.Log.Error("Failed to extend transfer deadlines", "error", err, "conversation_id", conv.ID)
+	}
+}
+
+// reopenConversation opens a contact's conversation that is pending, snoozed or
+// resolved, e.g. when the contact sends a new message
+func (a *App) reopenConversation(orgID, contactID uuid.UUID, accountName string) {
+	conv, err := a.contactConversation(orgID, contactID, accountName)
+	if err != nil {
+		a.Log.Error("Failed to load conversation", "error", err, "contact_id", contactID)
+		return
+	}
+	if conv.Status == models.ConversationStatusOpen {
+		return
+	}
+	if _, err := a.changeConversationStatus(conv, models.ConversationStatusOpen, nil, "", nil); err != nil {
+		a.Log.Error("Failed to reopen conversation", "error", err, "conversation_id", conv.ID)
+	}
+}
+
+// resolveConversation resolves a contact's conversation as a side effect of
+// closing their transfer. resolvedBy is nil when the system closed it.
+func (a *App) resolveConversation(orgID, contactID uuid.UUID, accountName, reason string, resolvedBy *uuid.UUID) {
+	conv, err := a.contactConversation(orgID, contactID, accountName)
+	if err != nil {
+		a.Log.Error("Failed to load conversation", "error", err, "contact_id", contactID)
+		return
+	}
+	if _, err := a.changeConversationStatus(conv, models.ConversationStatusResolved, nil, reason, resolvedBy); err != nil {
+		a.Log.Error("Failed to resolve conversation", "error", err, "conversation_id", conv.ID)
+	}
+}
+
+// wakeSnoozedConversations reopens snoozed conversations whose time is up
+func (a *App) wakeSnoozedConversations(now time.Time) {
+	var conversations []models.Conversation
+	if err := a.DB.Where("status = ? AND snoozed_until <= ?", models.ConversationStatusSnoozed, now).
+		Order("snoozed_until ASC").
+		Limit(snoozeWakeBatchSize).
+		Find(&conversations).Error; err != nil {
+		a.Log.Error("Failed to find snoozed conversations", "error", err)
+		return
+	}
+
+	for i := range conversations {
+		if _, err := a.changeConversationStatus(&conversations[i], models.ConversationStatusOpen, nil, "", nil); err != nil {
+			a.Log.Error("Failed to reopen snoozed conversation", "error", err, "conversation_id", conversations[i].ID)
+		}
+	}
+}
+
+// conversationStatuses returns the conversations of contacts on their accounts, by contact ID
+func (a *App) conversationStatuses(orgID uuid.UUID, contacts []models.Contact) map[uuid.UUID]models.Conversation {
+	result := make(map[uuid.UUID]models.Conversation, len(contacts))
+	if len(contacts) == 0 {
+		return result
+	}
+	contactIDs := make([]uuid.UUID, len(contacts))
+	accounts := make(map[uuid.UUID]string, len(contacts))
+	for i, c := range contacts {
+		contactIDs[i] = c.ID
+		accounts[c.ID] = c.WhatsAppAccount
+	}
+
+	var conversations []models.Conversation
+	a.DB.Where("organization_id = ? AND contact_id IN ?", orgID, contactIDs).Find(&conversations)
+	for _, conv := range conversations {
+		if conv.WhatsAppAccount == accounts[conv.ContactID] {
+			result[conv.ContactID] = conv
+		}
+	}
+	return result
+}
+
+func (a *App) conversationResponse(conv *models.Conversation) ConversationResponse {
+	resp := ConversationResponse{
+		ID:                conv.ID,
+		ContactID:         conv.ContactID,
+		WhatsAppAccount:   conv.WhatsAppAccount,
+		Status:            conv.Status,
+		SnoozedUntil:      conv.SnoozedUntil,
+		ResolutionReason:  conv.ResolutionReason,
+		ResolvedAt:        conv.ResolvedAt,
+		ResolvedByID:      conv.ResolvedByID,
+		StatusChangedAt:   conv.StatusChangedAt,
+		StatusChangedByID: conv.StatusChangedByID,
+	}
+	if conv.ResolvedByID != nil {
+		var user models.User
+		if a.DB.Select("full_name").Where("id = ?", *conv.ResolvedByID).First(&user).Error == nil {
+			resp.ResolvedByName = user.FullName
+		}
+	}
+	return resp
+}
+
+func (a *App) broadcastConversationStatus(conv *models.Conversation, previous models.ConversationStatus) {
+	if a.WSHub == nil {
+		return
+	}
+
+	a.WSHub.BroadcastToOrg(conv.OrganizationID, websocket.WSMessage{
+		Type: websocket.TypeConversationStatusChanged,
+		Payload: map[string]any{
+			"conversation":    a.conversationResponse(conv),
+			"previous_status": previous,
+		},
+	})
+}
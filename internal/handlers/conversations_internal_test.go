@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConversationStatus(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	valid := []ConversationStatusRequest{
+		{Status: models.ConversationStatusOpen},
+		{Status: models.ConversationStatusPending},
+		{Status: models.ConversationStatusSnoozed, SnoozedUntil: &later},
+		{Status: models.ConversationStatusResolved, ResolutionReason: "Refund issued"},
+	}
+	for _, req := range valid {
+		assert.NoError(t, validateConversationStatus(req, now), req.Status)
+	}
+
+	invalid := []ConversationStatusRequest{
+		{Status: "closed"},
+		{Status: models.ConversationStatusSnoozed},
+		{Status: models.ConversationStatusSnoozed, SnoozedUntil: &earlier},
+		{Status: models.ConversationStatusOpen, SnoozedUntil: &later},
+		{Status: models.ConversationStatusPending, ResolutionReason: "done"},
+		{Status: models.ConversationStatusResolved, ResolutionReason: strings.Repeat("a", maxResolutionReasonLength+1)},
+	}
+	for _, req := range invalid {
+		assert.Error(t, validateConversationStatus(req, now), req.Status)
+	}
+}
+
+func TestContactConversation_CreatedOnce(t *testing.T) {
+	app := newSLATestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	first, err := app.contactConversation(org.ID, contact.ID, account.Name)
+	require.NoError(t, err)
+	assert.Equal(t, models.ConversationStatusOpen, first.Status)
+
+	second, err := app.contactConversation(org.ID, contact.ID, account.Name)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+}
+
+func TestReopenConversation_FromResolved(t *testing.T) {
+	app := newSLATestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	app.resolveConversation(org.ID, contact.ID, account.Name, "Answered", nil)
+	conv, err := app.contactConversation(org.ID, contact.ID, account.Name)
+	require.NoError(t, err)
+	assert.Equal(t, models.ConversationStatusResolved, conv.Status)
+	assert.Equal(t, "Answered", conv.ResolutionReason)
+	require.NotNil(t, conv.ResolvedAt)
+
+	app.reopenConversation(org.ID, contact.ID, account.Name)
+	conv, err = app.contactConversation(org.ID, contact.ID, account.Name)
+	require.NoError(t, err)
+	assert.Equal(t, models.ConversationStatusOpen, conv.Status)
+	assert.Empty(t, conv.ResolutionReason)
+	assert.Nil(t, conv.ResolvedAt)
+}
+
+func TestWakeSnoozedConversations_ExtendsTransferDeadlines(t *testing.T) {
+	app := newSLATestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	agent := testutil.CreateTestUser(t, app.DB, org.ID)
+
+	deadline := time.Now().Add(10 * time.Minute)
+	transfer := createSLATestTransfer(t, app, org.ID, contact.ID, agent.ID, account.Name, models.SLATracking{
+		ResponseDeadline: &deadline,
+	})
+
+	conv, err := app.contactConversation(org.ID, contact.ID, account.Name)
+	require.NoError(t, err)
+	until := time.Now().Add(time.Hour)
+	changed, err := app.changeConversationStatus(conv, models.ConversationStatusSnoozed, &until, "", &agent.ID)
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	// Snoozed an hour ago, waking now
+	require.NoError(t, app.DB.Model(conv).Update("status_changed_at", time.Now().Add(-time.Hour)).Error)
+	app.wakeSnoozedConversations(time.Now())
+	require.NoError(t, app.DB.First(conv, conv.ID).Error)
+	assert.Equal(t, models.ConversationStatusSnoozed, conv.Status, "snooze time not up yet")
+
+	app.wakeSnoozedConversations(until.Add(time.Minute))
+	require.NoError(t, app.DB.First(conv, conv.ID).Error)
+	assert.Equal(t, models.ConversationStatusOpen, conv.Status)
+	assert.Nil(t, conv.SnoozedUntil)
+	assert.Nil(t, conv.StatusChangedByID)
+
+	var updated models.AgentTransfer
+	require.NoError(t, app.DB.First(&updated, transfer.ID).Error)
+	require.NotNil(t, updated.SLA.ResponseDeadline)
+	assert.WithinDuration(t, deadline.Add(time.Hour), *updated.SLA.ResponseDeadline, time.Minute)
+}
+
+func TestSLAEscalationSkippedWhileSnoozed(t *testing.T) {
+	app := newSLATestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	agent := testutil.CreateTestUser(t, app.DB, org.ID)
+
+	escalationAt := time.Now().Add(-5 * time.Minute)
+	transfer := createSLATestTransfer(t, app, org.ID, contact.ID, agent.ID, account.Name, models.SLATracking{
+		EscalationAt: &escalationAt,
+	})
+
+	conv, err := app.contactConversation(org.ID, contact.ID, account.Name)
+	require.NoError(t, err)
+	until := time.Now().Add(time.Hour)
+	_, err = app.changeConversationStatus(conv, models.ConversationStatusSnoozed, &until, "", &agent.ID)
+	require.NoError(t, err)
+
+	settings := models.ChatbotSettings{
+		OrganizationID: org.ID,
+		SLA:            models.SLAConfig{Enabled: true, EscalationMinutes: 30},
+	}
+	proc := NewSLAProcessor(app, time.Minute)
+	proc.escalateTransfers(org.ID, settings, time.Now())
+
+	var updated models.AgentTransfer
+	require.NoError(t, app.DB.First(&updated, transfer.ID).Error)
+	assert.Equal(t, 0, updated.SLA.EscalationLevel, "waiting on the customer, SLA is paused")
+}
+
+func TestResumeTransfer_ResolvesConversation(t *testing.T) {
+	app := newSLATestApp(t)
+	if app.Redis == nil {
+		t.Skip("Redis not available")
+	}
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	agent := testutil.CreateTestUser(t, app.DB, org.ID)
+
+	first := createSLATestTransfer(t, app, org.ID, contact.ID, agent.ID, account.Name, models.SLATracking{})
+	second := createSLATestTransfer(t, app, org.ID, contact.ID, agent.ID, account.Name, models.SLATracking{})
+
+	// Another transfer of the contact is still open
+	require.NoError(t, app.resumeTransfer(org.ID, agent.ID, first))
+	conv, err := app.contactConversation(org.ID, contact.ID, account.Name)
+	require.NoError(t, err)
+	assert.Equal(t, models.ConversationStatusOpen, conv.Status)
+
+	require.NoError(t, app.resumeTransfer(org.ID, agent.ID, second))
+	conv, err = app.contactConversation(org.ID, contact.ID, account.Name)
+	require.NoError(t, err)
+	assert.Equal(t, models.ConversationStatusResolved, conv.Status)
+	assert.Equal(t, transferResumedResolution, conv.ResolutionReason)
+	require.NotNil(t, conv.ResolvedByID)
+	assert.Equal(t, agent.ID, *conv.ResolvedByID)
+}
+
+func TestSLAAutoCloseSkippedWhilePending(t *testing.T) {
+	app := newSLATestApp(t)
+	if app.Redis == nil {
+		t.Skip("Redis not available")
+	}
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	agent := testutil.CreateTestUser(t, app.DB, org.ID)
+	waiting := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	open := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	expiresAt := time.Now().Add(-time.Hour)
+	waitingTransfer := createSLATestTransfer(t, app, org.ID, waiting.ID, agent.ID, account.Name, models.SLATracking{ExpiresAt: &expiresAt})
+	openTransfer := createSLATestTransfer(t, app, org.ID, open.ID, agent.ID, account.Name, models.SLATracking{ExpiresAt: &expiresAt})
+
+	conv, err := app.contactConversation(org.ID, waiting.ID, account.Name)
+	require.NoError(t, err)
+	_, err = app.changeConversationStatus(conv, models.ConversationStatusPending, nil, "", &agent.ID)
+	require.NoError(t, err)
+
+	settings := models.ChatbotSettings{
+		OrganizationID: org.ID,
+		SLA:            models.SLAConfig{Enabled: true, AutoCloseHours: 2},
+	}
+	proc := NewSLAProcessor(app, time.Minute)
+	proc.autoCloseExpiredTransfers(org.ID, settings, time.Now())
+
+	var updated models.AgentTransfer
+	require.NoError(t, app.DB.First(&updated, waitingTransfer.ID).Error)
+	assert.Equal(t, models.TransferStatusActive, updated.Status, "waiting on the customer, SLA is paused")
+
+	require.NoError(t, app.DB.First(&updated, openTransfer.ID).Error)
+	assert.Equal(t, models.TransferStatusExpired, updated.Status)
+	conv, err = app.contactConversation(org.ID, open.ID, account.Name)
+	require.NoError(t, err)
+	assert.Equal(t, models.ConversationStatusResolved, conv.Status)
+	assert.Equal(t, slaExpiredResolution, conv.ResolutionReason)
+	assert.Nil(t, conv.ResolvedByID)
+}
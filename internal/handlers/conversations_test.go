@@ -0,0 +1,131 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestApp_UpdateConversationStatus_Snooze(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	until := time.Now().Add(2 * time.Hour)
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"status":        "snoozed",
+		"snoozed_until": until,
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", contact.ID.String())
+
+	require.NoError(t, app.UpdateConversationStatus(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.ConversationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, models.ConversationStatusSnoozed, resp.Data.Status)
+	require.NotNil(t, resp.Data.SnoozedUntil)
+	assert.WithinDuration(t, until, *resp.Data.SnoozedUntil, time.Second)
+	assert.Equal(t, contact.ID, resp.Data.ContactID)
+}
+
+func TestApp_UpdateConversationStatus_SnoozeInPast(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"status":        "snoozed",
+		"snoozed_until": time.Now().Add(-time.Hour),
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", contact.ID.String())
+
+	require.NoError(t, app.UpdateConversationStatus(req))
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusBadRequest, "snoozed_until must be in the future")
+}
+
+func TestApp_UpdateConversationStatus_ResolveResumesTransfer(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	transfer := createTestTransfer(t, app, org.ID, contact.ID, account.Name, models.TransferStatusActive, &user.ID)
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"status":            "resolved",
+		"resolution_reason": "Order refunded",
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", contact.ID.String())
+
+	require.NoError(t, app.UpdateConversationStatus(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.ConversationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, models.ConversationStatusResolved, resp.Data.Status)
+	assert.Equal(t, "Order refunded", resp.Data.ResolutionReason)
+	require.NotNil(t, resp.Data.ResolvedByID)
+	assert.Equal(t, user.ID, *resp.Data.ResolvedByID)
+
+	var updated models.AgentTransfer
+	require.NoError(t, app.DB.First(&updated, transfer.ID).Error)
+	assert.Equal(t, models.TransferStatusResumed, updated.Status)
+}
+
+func TestApp_ListContacts_FilterByConversationStatus(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	pending := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+
+	statusReq := testutil.NewJSONRequest(t, map[string]any{"status": "pending"})
+	testutil.SetAuthContext(statusReq, org.ID, user.ID)
+	testutil.SetPathParam(statusReq, "id", pending.ID.String())
+	require.NoError(t, app.UpdateConversationStatus(statusReq))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(statusReq))
+
+	for status, want := range map[string]int{"pending": 1, "open": 1, "resolved": 0} {
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetQueryParam(req, "conversation_status", status)
+
+		require.NoError(t, app.ListContacts(req))
+		assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data struct {
+				Contacts []handlers.ContactResponse `json:"contacts"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		require.Len(t, resp.Data.Contacts, want, status)
+		if status == "pending" {
+			assert.Equal(t, pending.ID, resp.Data.Contacts[0].ID)
+			assert.Equal(t, models.ConversationStatusPending, resp.Data.Contacts[0].ConversationStatus)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Ratings a contact can give, from very dissatisfied to very satisfied
+	minCSATRating       = 1
+	maxCSATRating       = 5
+	satisfiedCSATRating = 4 // ratings from here up count towards the CSAT score
+
+	// maxCSATDelayMinutes keeps the survey inside the 24h customer service window
+	maxCSATDelayMinutes = 24 * 60
+
+	// csatResponseWindow is how long a sent survey waits for its rating before expiring
+	csatResponseWindow = 24 * time.Hour
+
+	// csatSendBatchSize limits how many due surveys are sent per tick
+	csatSendBatchSize = 100
+
+	// csatButtonPrefix and csatFlowTokenPrefix identify replies to a survey
+	csatButtonPrefix    = "csat:"
+	csatFlowTokenPrefix = "csat_"
+
+	defaultCSATQuestion  = "How satisfied are you with the support you received? Please rate us from 1 to 5."
+	defaultCSATFlowCTA   = "Rate us"
+	maxCSATFlowCTALength = 20
+)
+
+// csatButtonID returns the ID of the list option giving a survey a rating
+func csatButtonID(surveyID uuid.UUID, rating int) string {
+	return fmt.Sprintf("%s%s:%d", csatButtonPrefix, surveyID, rating)
+}
+
+// parseCSATButtonID returns the survey and rating of a survey list option ID
+func parseCSATButtonID(id string) (uuid.UUID, int, bool) {
+	rest, ok := strings.CutPrefix(id, csatButtonPrefix)
+	if !ok {
+		return uuid.Nil, 0, false
+	}
+	surveyPart, ratingPart, ok := strings.Cut(rest, ":")
+	if !ok {
+		return uuid.Nil, 0, false
+	}
+	surveyID, err := uuid.Parse(surveyPart)
+	if err != nil {
+		return uuid.Nil, 0, false
+	}
+	rating, ok := parseCSATRating(ratingPart)
+	return surveyID, rating, ok
+}
+
+// parseCSATRating reads a 1-5 rating from a typed reply or a flow response field
+func parseCSATRating(value interface{}) (int, bool) {
+	var rating int
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, false
+		}
+		rating = int(v)
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, false
+		}
+		rating = n
+	default:
+		return 0, false
+	}
+	return rating, rating >= minCSATRating && rating <= maxCSATRating
+}
+
+// csatRatingButtons returns the survey's list options, best rating first
+func csatRatingButtons(surveyID uuid.UUID) []whatsapp.Button {
+	buttons := make([]whatsapp.Button, 0, maxCSATRating)
+	for rating := maxCSATRating; rating >= minCSATRating; rating-- {
+		buttons = append(buttons, whatsapp.Button{
+			ID:    csatButtonID(surveyID, rating),
+			Title: fmt.Sprintf("%d %s", rating, strings.Repeat("⭐", rating)),
+		})
+	}
+	return buttons
+}
+
+// csatSurveyMessage builds the survey message in the contact's language
+func csatSurveyMessage(survey *models.CSATSurvey, settings *models.ChatbotSettings, contact *models.Contact) OutgoingMessageRequest {
+	question := settings.CSAT.Question
+	if strings.TrimSpace(question) == "" {
+		question = defaultCSATQuestion
+	}
+	question = localized(settings.Translations, contactLanguage(contact), "csat_question", question)
+
+	if survey.SurveyType == models.CSATSurveyTypeFlow {
+		cta := settings.CSAT.FlowCTA
+		if cta == "" {
+			cta = defaultCSATFlowCTA
+		}
+		return OutgoingMessageRequest{
+			Contact:   contact,
+			Type:      models.MessageTypeFlow,
+			FlowID:    settings.CSAT.FlowID,
+			BodyText:  question,
+			FlowCTA:   cta,
+			FlowToken: csatFlowTokenPrefix + survey.ID.String(),
+		}
+	}
+
+	buttons := csatRatingButtons(survey.ID)
+	return OutgoingMessageRequest{
+		Contact:         contact,
+		Type:            models.MessageTypeInteractive,
+		InteractiveType: interactiveTypeForButtons(buttons),
+		BodyText:        question,
+		Buttons:         buttons,
+	}
+}
+
+// scheduleCSATSurvey schedules the satisfaction survey of a resolved transfer,
+// if surveys are enabled for its account. A transfer is surveyed at most once.
+func (a *App) scheduleCSATSurvey(transfer *models.AgentTransfer) {
+	settings, err := a.getChatbotSettingsCached(transfer.OrganizationID, transfer.WhatsAppAccount)
+	if err != nil || !settings.CSAT.Enabled {
+		return
+	}
+
+	survey := models.CSATSurvey{
+		OrganizationID:  transfer.OrganizationID,
+		TransferID:      transfer.ID,
+		ContactID:       transfer.ContactID,
+		WhatsAppAccount: transfer.WhatsAppAccount,
+		AgentID:         transfer.AgentID,
+		TeamID:          transfer.TeamID,
+		SurveyType:      settings.CSAT.SurveyType,
+		Status:          models.CSATSurveyStatusScheduled,
+		SendAt:          time.Now().Add(time.Duration(settings.CSAT.DelayMinutes) * time.Minute),
+	}
+	if err := a.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&survey).Error; err != nil {
+		a.Log.Error("Failed to schedule CSAT survey", "error", err, "transfer_id", transfer.ID)
+	}
+}
+
+// sendDueCSATSurveys sends the surveys whose delay is up and expires those
+// left unanswered past the response window
+func (a *App) sendDueCSATSurveys(now time.Time) {
+	if err := a.DB.Model(&models.CSATSurvey{}).
+		Where("status = ? AND sent_at < ?", models.CSATSurveyStatusSent, now.Add(-csatResponseWindow)).
+		Update("status", models.CSATSurveyStatusExpired).Error; err != nil {
+		a.Log.Error("Failed to expire CSAT surveys", "error", err)
+	}
+
+	var surveys []models.CSATSurvey
+	if err := a.DB.Where("status = ? AND send_at <= ?", models.CSATSurveyStatusScheduled, now).
+		Order("send_at ASC").
+		Limit(csatSendBatchSize).
+		Find(&surveys).Error; err != nil {
+		a.Log.Error("Failed to find due CSAT surveys", "error", err)
+		return
+	}
+
+	for i := range surveys {
+		a.sendCSATSurvey(&surveys[i], now)
+	}
+}
+
+// sendCSATSurvey sends a due survey to its contact
+func (a *App) sendCSATSurvey(survey *models.CSATSurvey, now time.Time) {
+	// Claim the survey so it is sent only once
+	result := a.DB.Model(&models.CSATSurvey{}).
+		Where("id = ? AND status = ?", survey.ID, models.CSATSurveyStatusScheduled).
+		Updates(map[string]interface{}{"status": models.CSATSurveyStatusSent, "sent_at": now})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+
+	settings, err := a.getChatbotSettingsCached(survey.OrganizationID, survey.WhatsAppAccount)
+	if err != nil || !settings.CSAT.Enabled {
+		a.failCSATSurvey(survey, "CSAT surveys are disabled")
+		return
+	}
+	// Don't interrupt a conversation the contact has started again
+	if a.hasActiveAgentTransfer(survey.OrganizationID, survey.ContactID) {
+		a.failCSATSurvey(survey, "Contact has an active transfer")
+		return
+	}
+
+	var account models.WhatsAppAccount
+	if err := a.DB.Where("name = ? AND organization_id = ?", survey.WhatsAppAccount, survey.OrganizationID).First(&account).Error; err != nil {
+		a.failCSATSurvey(survey, "WhatsApp account not found")
+		return
+	}
+	var contact models.Contact
+	if err := a.DB.Where("id = ? AND organization_id = ?", survey.ContactID, survey.OrganizationID).First(&contact).Error; err != nil {
+		a.failCSATSurvey(survey, "Contact not found")
+		return
+	}
+
+	req := csatSurveyMessage(survey, settings, &contact)
+	req.Account = &account
+	if req.Type == models.MessageTypeFlow {
+		if req.FlowID == "" {
+			a.failCSATSurvey(survey, "No WhatsApp Flow configured")
+			return
+		}
+		req.FlowFirstScreen = a.whatsAppFlowFirstScreen(req.FlowID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := a.SendOutgoingMessage(ctx, req, SLASendOptions()); err != nil {
+		a.Log.Error("Failed to send CSAT survey", "error", err, "survey_id", survey.ID)
+		a.failCSATSurvey(survey, err.Error())
+		return
+	}
+	a.Log.Info("CSAT survey sent", "survey_id", survey.ID, "transfer_id", survey.TransferID, "contact_id", survey.ContactID)
+}
+
+// failCSATSurvey records why a survey could not be sent
+func (a *App) failCSATSurvey(survey *models.CSATSurvey, reason string) {
+	a.DB.Model(&models.CSATSurvey{}).Where("id = ?", survey.ID).Updates(map[string]interface{}{
+		"status":        models.CSATSurveyStatusFailed,
+		"error_message": reason,
+	})
+}
+
+// handleCSATResponse records a contact's reply to a satisfaction survey: a
+// rating option, a flow response, or a typed 1-5 while a survey awaits its
+// rating and the chatbot isn't waiting for input. Returns true if the message
+// was a survey reply and needs no other handling.
+func (a *App) handleCSATResponse(account *models.WhatsAppAccount, contact *models.Contact, buttonID, messageText string, flowResponseData map[string]interface{}) bool {
+	var surveyID uuid.UUID
+	var rating int
+	var comment string
+
+	switch {
+	case strings.HasPrefix(buttonID, csatButtonPrefix):
+		id, r, ok := parseCSATButtonID(buttonID)
+		if !ok {
+			return false
+		}
+		surveyID, rating = id, r
+	case flowResponseData != nil:
+		id, ok := strings.CutPrefix(getStringFromMap(flowResponseData, "flow_token"), csatFlowTokenPrefix)
+		if !ok {
+			return false
+		}
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return false
+		}
+		r, ok := parseCSATRating(flowResponseData["rating"])
+		if !ok {
+			a.Log.Warn("CSAT flow response has no valid rating", "survey_id", parsed, "data", flowResponseData)
+			return true
+		}
+		surveyID, rating = parsed, r
+		comment = strings.TrimSpace(getStringFromMap(flowResponseData, "comment"))
+	case buttonID == "":
+		r, ok := parseCSATRating(messageText)
+		if !ok || a.hasActiveAgentTransfer(account.OrganizationID, contact.ID) {
+			return false
+		}
+		// A digit may answer a menu or flow step instead
+		if a.chatbotAwaitingInput(account, contact.ID) {
+			return false
+		}
+		var survey models.CSATSurvey
+		if err := a.DB.Where("organization_id = ? AND contact_id = ? AND whats_app_account = ? AND status = ?",
+			account.OrganizationID, contact.ID, account.Name, models.CSATSurveyStatusSent).
+			Order("sent_at DESC").First(&survey).Error; err != nil {
+			return false
+		}
+		surveyID, rating = survey.ID, r
+	default:
+		return false
+	}
+
+	now := time.Now()
+	result := a.DB.Model(&models.CSATSurvey{}).
+		Where("id = ? AND organization_id = ? AND contact_id = ? AND status = ?", surveyID, account.OrganizationID, contact.ID, models.CSATSurveyStatusSent).
+		Updates(map[string]interface{}{
+			"status":       models.CSATSurveyStatusAnswered,
+			"rating":       rating,
+			"comment":      comment,
+			"responded_at": now,
+		})
+	if result.Error != nil {
+		a.Log.Error("Failed to record CSAT response", "error", result.Error, "survey_id", surveyID)
+		return true
+	}
+	if result.RowsAffected == 0 {
+		// Already answered or expired
+		return true
+	}
+	a.Log.Info("CSAT response recorded", "survey_id", surveyID, "rating", rating)
+
+	settings, err := a.getChatbotSettingsCached(account.OrganizationID, account.Name)
+	if err != nil || strings.TrimSpace(settings.CSAT.ThankYouMessage) == "" {
+		return true
+	}
+	message := localized(settings.Translations, contactLanguage(contact), "csat_thank_you_message", settings.CSAT.ThankYouMessage)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := a.SendOutgoingMessage(ctx, OutgoingMessageRequest{
+		Account: account,
+		Contact: contact,
+		Type:    models.MessageTypeText,
+		Content: message,
+	}, SLASendOptions()); err != nil {
+		a.Log.Error("Failed to send CSAT thank you message", "error", err, "survey_id", surveyID)
+	}
+	return true
+}
+
+// chatbotAwaitingInput reports whether the contact is in the middle of a
+// chatbot flow, i.e. the chatbot would continue a session with their next message
+func (a *App) chatbotAwaitingInput(account *models.WhatsAppAccount, contactID uuid.UUID) bool {
+	settings, err := a.getChatbotSettingsCached(account.OrganizationID, account.Name)
+	if err != nil || !settings.IsEnabled {
+		return false
+	}
+	timeout := time.Now().Add(-time.Duration(settings.SessionTimeoutMins) * time.Minute)
+	var count int64
+	a.DB.Model(&models.ChatbotSession{}).
+		Where("organization_id = ? AND contact_id = ? AND whats_app_account = ? AND status = ? AND current_flow_id IS NOT NULL AND (last_activity_at > ? OR wait_until IS NOT NULL)",
+			account.OrganizationID, contactID, account.Name, models.SessionStatusActive, timeout).
+		Count(&count)
+	return count > 0
+}
+
+// csatStats summarizes the ratings received in a period
+type csatStats struct {
+	Responses int64
+	AvgRating float64
+	Satisfied int64
+}
+
+// Score is the percentage of ratings of 4 or 5
+func (s csatStats) Score() float64 {
+	if s.Responses == 0 {
+		return 0
+	}
+	return float64(s.Satisfied) * 100 / float64(s.Responses)
+}
+
+// calculateCSATStats returns the ratings answered in a period, for one agent if agentID is set
+func (a *App) calculateCSATStats(orgID uuid.UUID, agentID *uuid.UUID, start, end time.Time) csatStats {
+	var stats csatStats
+	query := a.DB.Model(&models.CSATSurvey{}).
+		Select("COUNT(*) AS responses, COALESCE(AVG(rating), 0) AS avg_rating, COUNT(*) FILTER (WHERE rating >= ?) AS satisfied", satisfiedCSATRating).
+		Where("organization_id = ? AND status = ? AND responded_at >= ? AND responded_at <= ?",
+			orgID, models.CSATSurveyStatusAnswered, start, end)
+	if agentID != nil {
+		query = query.Where("agent_id = ?", *agentID)
+	}
+	query.Scan(&stats)
+	return stats
+}
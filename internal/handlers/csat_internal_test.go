@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSATButtonID(t *testing.T) {
+	surveyID := uuid.New()
+	for rating := minCSATRating; rating <= maxCSATRating; rating++ {
+		id, r, ok := parseCSATButtonID(csatButtonID(surveyID, rating))
+		require.True(t, ok)
+		assert.Equal(t, surveyID, id)
+		assert.Equal(t, rating, r)
+	}
+
+	for _, invalid := range []string{"", "yes", "csat:", "csat:" + surveyID.String(), "csat:not-a-uuid:3", "csat:" + surveyID.String() + ":6"} {
+		_, _, ok := parseCSATButtonID(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestParseCSATRating(t *testing.T) {
+	valid := map[interface{}]int{"1": 1, " 5 ": 5, float64(3): 3}
+	for value, want := range valid {
+		rating, ok := parseCSATRating(value)
+		assert.True(t, ok, value)
+		assert.Equal(t, want, rating)
+	}
+
+	for _, invalid := range []interface{}{nil, "", "0", "6", "great", float64(4.5), true} {
+		_, ok := parseCSATRating(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestCSATSurveyMessage(t *testing.T) {
+	contact := &models.Contact{Language: "hi"}
+	survey := &models.CSATSurvey{BaseModel: models.BaseModel{ID: uuid.New()}, SurveyType: models.CSATSurveyTypeButtons}
+	settings := &models.ChatbotSettings{
+		Translations: models.JSONB{"hi": map[string]interface{}{"csat_question": "आपका अनुभव कैसा रहा?"}},
+	}
+
+	req := csatSurveyMessage(survey, settings, contact)
+	assert.Equal(t, models.MessageTypeInteractive, req.Type)
+	assert.Equal(t, "list", req.InteractiveType)
+	assert.Equal(t, "आपका अनुभव कैसा रहा?", req.BodyText)
+	require.Len(t, req.Buttons, maxCSATRating)
+	assert.Equal(t, csatButtonID(survey.ID, 5), req.Buttons[0].ID)
+	assert.Equal(t, csatButtonID(survey.ID, 1), req.Buttons[4].ID)
+	for _, button := range req.Buttons {
+		assert.LessOrEqual(t, len(button.Title), 24, "list titles are cut at 24 bytes")
+	}
+
+	survey.SurveyType = models.CSATSurveyTypeFlow
+	settings.CSAT = models.CSATConfig{FlowID: "123456", Question: "How did we do?"}
+	req = csatSurveyMessage(survey, settings, &models.Contact{})
+	assert.Equal(t, models.MessageTypeFlow, req.Type)
+	assert.Equal(t, "123456", req.FlowID)
+	assert.Equal(t, "How did we do?", req.BodyText)
+	assert.Equal(t, defaultCSATFlowCTA, req.FlowCTA)
+	assert.Equal(t, "csat_"+survey.ID.String(), req.FlowToken)
+}
+
+// createCSATTestSurvey creates a survey for a new transfer in the given status
+func createCSATTestSurvey(t *testing.T, app *App, orgID, contactID, agentID uuid.UUID, account string, status models.CSATSurveyStatus, sentAt *time.Time) *models.CSATSurvey {
+	t.Helper()
+	transfer := createSLATestTransfer(t, app, orgID, contactID, agentID, account, models.SLATracking{})
+	require.NoError(t, app.DB.Model(transfer).Update("status", models.TransferStatusResumed).Error)
+	survey := &models.CSATSurvey{
+		OrganizationID:  orgID,
+		TransferID:      transfer.ID,
+		ContactID:       contactID,
+		WhatsAppAccount: account,
+		AgentID:         &agentID,
+		SurveyType:      models.CSATSurveyTypeButtons,
+		Status:          status,
+		SendAt:          time.Now(),
+		SentAt:          sentAt,
+	}
+	require.NoError(t, app.DB.Create(survey).Error)
+	return survey
+}
+
+func TestScheduleCSATSurvey_OncePerTransfer(t *testing.T) {
+	app := newSLATestApp(t)
+	if app.Redis == nil {
+		t.Skip("Redis not available")
+	}
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	agent := testutil.CreateTestUser(t, app.DB, org.ID)
+	require.NoError(t, app.DB.Create(&models.ChatbotSettings{
+		OrganizationID: org.ID,
+		CSAT:           models.CSATConfig{Enabled: true, DelayMinutes: 10, SurveyType: models.CSATSurveyTypeButtons},
+	}).Error)
+	transfer := createSLATestTransfer(t, app, org.ID, contact.ID, agent.ID, account.Name, models.SLATracking{})
+
+	app.scheduleCSATSurvey(transfer)
+	app.scheduleCSATSurvey(transfer)
+
+	var surveys []models.CSATSurvey
+	require.NoError(t, app.DB.Where("transfer_id = ?", transfer.ID).Find(&surveys).Error)
+	require.Len(t, surveys, 1)
+	assert.Equal(t, models.CSATSurveyStatusScheduled, surveys[0].Status)
+	require.NotNil(t, surveys[0].AgentID)
+	assert.Equal(t, agent.ID, *surveys[0].AgentID)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), surveys[0].SendAt, time.Minute)
+}
+
+func TestSendDueCSATSurveys_ExpiresUnanswered(t *testing.T) {
+	app := newSLATestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	agent := testutil.CreateTestUser(t, app.DB, org.ID)
+
+	longAgo := time.Now().Add(-csatResponseWindow - time.Hour)
+	recently := time.Now().Add(-time.Hour)
+	stale := createCSATTestSurvey(t, app, org.ID, contact.ID, agent.ID, account.Name, models.CSATSurveyStatusSent, &longAgo)
+	fresh := createCSATTestSurvey(t, app, org.ID, contact.ID, agent.ID, account.Name, models.CSATSurveyStatusSent, &recently)
+
+	app.sendDueCSATSurveys(time.Now())
+
+	require.NoError(t, app.DB.First(stale, stale.ID).Error)
+	assert.Equal(t, models.CSATSurveyStatusExpired, stale.Status)
+	require.NoError(t, app.DB.First(fresh, fresh.ID).Error)
+	assert.Equal(t, models.CSATSurveyStatusSent, fresh.Status)
+}
+
+func TestHandleCSATResponse_RecordsRating(t *testing.T) {
+	app := newSLATestApp(t)
+	if app.Redis == nil {
+		t.Skip("Redis not available")
+	}
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	agent := testutil.CreateTestUser(t, app.DB, org.ID)
+	sentAt := time.Now().Add(-time.Minute)
+
+	// Rating option
+	survey := createCSATTestSurvey(t, app, org.ID, contact.ID, agent.ID, account.Name, models.CSATSurveyStatusSent, &sentAt)
+	assert.True(t, app.handleCSATResponse(account, contact, csatButtonID(survey.ID, 4), "4 ⭐⭐⭐⭐", nil))
+	require.NoError(t, app.DB.First(survey, survey.ID).Error)
+	assert.Equal(t, models.CSATSurveyStatusAnswered, survey.Status)
+	assert.Equal(t, 4, survey.Rating)
+	assert.NotNil(t, survey.RespondedAt)
+
+	// A second tap is swallowed without changing the rating
+	assert.True(t, app.handleCSATResponse(account, contact, csatButtonID(survey.ID, 1), "1 ⭐", nil))
+	require.NoError(t, app.DB.First(survey, survey.ID).Error)
+	assert.Equal(t, 4, survey.Rating)
+
+	// Typed rating and flow response
+	typed := createCSATTestSurvey(t, app, org.ID, contact.ID, agent.ID, account.Name, models.CSATSurveyStatusSent, &sentAt)
+	assert.True(t, app.handleCSATResponse(account, contact, "", "2", nil))
+	require.NoError(t, app.DB.First(typed, typed.ID).Error)
+	assert.Equal(t, 2, typed.Rating)
+
+	flow := createCSATTestSurvey(t, app, org.ID, contact.ID, agent.ID, account.Name, models.CSATSurveyStatusSent, &sentAt)
+	assert.True(t, app.handleCSATResponse(account, contact, "", "Sent", map[string]interface{}{
+		"flow_token": "csat_" + flow.ID.String(),
+		"rating":     "5",
+		"comment":    " Quick and friendly ",
+	}))
+	require.NoError(t, app.DB.First(flow, flow.ID).Error)
+	assert.Equal(t, 5, flow.Rating)
+	assert.Equal(t, "Quick and friendly", flow.Comment)
+
+	// Nothing awaits a rating now, so a digit is an ordinary message
+	assert.False(t, app.handleCSATResponse(account, contact, "", "3", nil))
+	assert.False(t, app.handleCSATResponse(account, contact, "menu_1", "Orders", nil))
+}
+
+func TestHandleCSATResponse_TypedDigitAnswersActiveFlow(t *testing.T) {
+	app := newSLATestApp(t)
+	if app.Redis == nil {
+		t.Skip("Redis not available")
+	}
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	agent := testutil.CreateTestUser(t, app.DB, org.ID)
+	require.NoError(t, app.DB.Create(&models.ChatbotSettings{
+		OrganizationID:     org.ID,
+		IsEnabled:          true,
+		SessionTimeoutMins: 30,
+		CSAT:               models.CSATConfig{Enabled: true, SurveyType: models.CSATSurveyTypeButtons},
+	}).Error)
+	flow := &models.ChatbotFlow{
+		BaseModel:      models.BaseModel{ID: uuid.New()},
+		OrganizationID: org.ID,
+		Name:           "Orders",
+		IsEnabled:      true,
+	}
+	require.NoError(t, app.DB.Create(flow).Error)
+	sentAt := time.Now().Add(-time.Minute)
+	survey := createCSATTestSurvey(t, app, org.ID, contact.ID, agent.ID, account.Name, models.CSATSurveyStatusSent, &sentAt)
+
+	// The contact is picking option 2 of a flow's menu
+	session := &models.ChatbotSession{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  org.ID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: account.Name,
+		PhoneNumber:     contact.PhoneNumber,
+		Status:          models.SessionStatusActive,
+		CurrentFlowID:   &flow.ID,
+		CurrentStep:     "menu",
+		LastActivityAt:  time.Now(),
+	}
+	require.NoError(t, app.DB.Create(session).Error)
+	assert.False(t, app.handleCSATResponse(account, contact, "", "2", nil))
+	require.NoError(t, app.DB.First(survey, survey.ID).Error)
+	assert.Equal(t, models.CSATSurveyStatusSent, survey.Status)
+
+	// The rating option still answers the survey
+	assert.True(t, app.handleCSATResponse(account, contact, csatButtonID(survey.ID, 4), "4 ⭐⭐⭐⭐", nil))
+
+	// Once the flow is done, a typed digit is a rating again
+	require.NoError(t, app.DB.Model(session).Update("status", models.SessionStatusCompleted).Error)
+	typed := createCSATTestSurvey(t, app, org.ID, contact.ID, agent.ID, account.Name, models.CSATSurveyStatusSent, &sentAt)
+	assert.True(t, app.handleCSATResponse(account, contact, "", "5", nil))
+	require.NoError(t, app.DB.First(typed, typed.ID).Error)
+	assert.Equal(t, 5, typed.Rating)
+}
+
+func TestCalculateCSATStats(t *testing.T) {
+	app := newSLATestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	agent := testutil.CreateTestUser(t, app.DB, org.ID)
+	other := testutil.CreateTestUser(t, app.DB, org.ID)
+
+	now := time.Now()
+	for agentID, ratings := range map[uuid.UUID][]int{agent.ID: {5, 4, 1}, other.ID: {2}} {
+		for _, rating := range ratings {
+			survey := createCSATTestSurvey(t, app, org.ID, contact.ID, agentID, account.Name, models.CSATSurveyStatusAnswered, &now)
+			require.NoError(t, app.DB.Model(survey).Updates(map[string]any{"rating": rating, "responded_at": now}).Error)
+		}
+	}
+	// Unanswered surveys don't count
+	createCSATTestSurvey(t, app, org.ID, contact.ID, agent.ID, account.Name, models.CSATSurveyStatusSent, &now)
+
+	start, end := now.Add(-time.Hour), now.Add(time.Hour)
+	stats := app.calculateCSATStats(org.ID, &agent.ID, start, end)
+	assert.Equal(t, int64(3), stats.Responses)
+	assert.InDelta(t, 10.0/3, stats.AvgRating, 0.01)
+	assert.InDelta(t, 200.0/3, stats.Score(), 0.01)
+
+	all := app.calculateCSATStats(org.ID, nil, start, end)
+	assert.Equal(t, int64(4), all.Responses)
+	assert.InDelta(t, 3.0, all.AvgRating, 0.01)
+
+	assert.Equal(t, 4.0, app.queryCSAT(org.ID, "count", "", nil, start, end))
+	assert.InDelta(t, 3.0, app.queryCSAT(org.ID, "avg", "rating", nil, start, end), 0.01)
+	assert.InDelta(t, 50.0, app.queryCSAT(org.ID, "avg", "csat_score", nil, start, end), 0.01)
+	assert.Equal(t, 1.0, app.queryCSAT(org.ID, "count", "", []FilterInput{{Field: "rating", Operator: "equals", Value: "5"}}, start, end))
+}
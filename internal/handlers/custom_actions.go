@@ -29,19 +29,26 @@ type CustomActionRequest struct {
 	Config       map[string]interface{} `json:"config"`
 	IsActive     bool                   `json:"is_active"`
 	DisplayOrder int                    `json:"display_order"`
+	// Chatbot AI tool; left unchanged on update when omitted
+	AITool            *bool                  `json:"ai_tool"`
+	AIToolDescription *string                `json:"ai_tool_description"`
+	AIToolParameters  map[string]interface{} `json:"ai_tool_parameters"`
 }
 
 // CustomActionResponse represents the API response for a custom action
 type CustomActionResponse struct {
-	ID           uuid.UUID              `json:"id"`
-	Name         string                 `json:"name"`
-	Icon         string                 `json:"icon"`
-	ActionType   models.ActionType      `json:"action_type"`
-	Config       map[string]interface{} `json:"config"`
-	IsActive     bool                   `json:"is_active"`
-	DisplayOrder int                    `json:"display_order"`
-	CreatedAt    string                 `json:"created_at"`
-	UpdatedAt    string                 `json:"updated_at"`
+	ID                uuid.UUID              `json:"id"`
+	Name              string                 `json:"name"`
+	Icon              string                 `json:"icon"`
+	ActionType        models.ActionType      `json:"action_type"`
+	Config            map[string]interface{} `json:"config"`
+	IsActive          bool                   `json:"is_active"`
+	DisplayOrder      int                    `json:"display_order"`
+	AITool            bool                   `json:"ai_tool"`
+	AIToolDescription string                 `json:"ai_tool_description"`
+	AIToolParameters  map[string]interface{} `json:"ai_tool_parameters"`
+	CreatedAt         string                 `json:"created_at"`
+	UpdatedAt         string                 `json:"updated_at"`
 }
 
 // ExecuteActionRequest represents the request to execute a custom action
@@ -164,15 +171,25 @@ func (a *App) CreateCustomAction(r *fastglue.Request) error {
 	if err := validateActionConfig(req.ActionType, req.Config); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
 	}
+	aiTool, aiToolDescription := req.AITool != nil && *req.AITool, ""
+	if req.AIToolDescription != nil {
+		aiToolDescription = *req.AIToolDescription
+	}
+	if err := validateActionAITool(req.ActionType, aiTool, aiToolDescription, req.AIToolParameters); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
 
 	action := models.CustomAction{
-		OrganizationID: orgID,
-		Name:           req.Name,
-		Icon:           req.Icon,
-		ActionType:     req.ActionType,
-		Config:         models.JSONB(req.Config),
-		IsActive:       req.IsActive,
-		DisplayOrder:   req.DisplayOrder,
+		OrganizationID:    orgID,
+		Name:              req.Name,
+		Icon:              req.Icon,
+		ActionType:        req.ActionType,
+		Config:            models.JSONB(req.Config),
+		IsActive:          req.IsActive,
+		DisplayOrder:      req.DisplayOrder,
+		AITool:            aiTool,
+		AIToolDescription: aiToolDescription,
+		AIToolParameters:  models.JSONB(req.AIToolParameters),
 	}
 
 	if err := a.DB.Create(&action).Error; err != nil {
@@ -234,6 +251,28 @@ func (a *App) UpdateCustomAction(r *fastglue.Request) error {
 	updates["is_active"] = req.IsActive
 	updates["display_order"] = req.DisplayOrder
 
+	actionType := req.ActionType
+	if actionType == "" {
+		actionType = action.ActionType
+	}
+	aiTool, aiToolDescription, aiToolParameters := action.AITool, action.AIToolDescription, map[string]interface{}(action.AIToolParameters)
+	if req.AITool != nil {
+		aiTool = *req.AITool
+	}
+	if req.AIToolDescription != nil {
+		aiToolDescription = *req.AIToolDescription
+	}
+	if req.AIToolParameters != nil {
+		aiToolParameters = req.AIToolParameters
+	}
+	if err := validateActionAITool(actionType, aiTool, aiToolDescription, aiToolParameters); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+	parametersJSON, _ := json.Marshal(aiToolParameters)
+	updates["ai_tool"] = aiTool
+	updates["ai_tool_description"] = aiToolDescription
+	updates["ai_tool_parameters"] = parametersJSON
+
 	if err := a.DB.Model(action).Updates(updates).Error; err != nil {
 		a.Log.Error("Failed to update custom action", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update custom action", nil, "")
@@ -562,12 +601,37 @@ func (a *App) executeJavaScriptAction(action models.CustomAction, context map[st
 			if msg, ok := jsResult["message"].(string); ok {
 				result.Message = msg
 			}
+			if data, ok := jsResult["data"].(map[string]interface{}); ok {
+				result.Data = data
+			}
 		}
 	}
 
 	return result, nil
 }
 
+// runUnattendedCustomAction runs a webhook or JavaScript custom action for a
+// contact with no agent involved, as chatbot flows and AI tools do. vars are
+// added to the action's context next to the contact and organization.
+// URL actions open a browser tab for an agent, so they can't run unattended.
+func (a *App) runUnattendedCustomAction(action models.CustomAction, contact models.Contact, vars map[string]interface{}) (*ActionResult, error) {
+	var org models.Organization
+	a.DB.First(&org, action.OrganizationID)
+
+	context := buildActionContext(contact, models.User{}, org)
+	for k, v := range vars {
+		context[k] = v
+	}
+
+	switch action.ActionType {
+	case models.ActionTypeWebhook:
+		return a.executeWebhookAction(action, context)
+	case models.ActionTypeJavascript:
+		return a.executeJavaScriptAction(action, context)
+	}
+	return nil, fmt.Errorf("%s custom actions need an agent to run", action.ActionType)
+}
+
 // buildActionContext builds the context object for variable replacement
 func buildActionContext(contact models.Contact, user models.User, org models.Organization) map[string]interface{} {
 	return map[string]interface{}{
@@ -652,21 +716,42 @@ func validateActionConfig(actionType models.ActionType, config map[string]interf
 	return nil
 }
 
+// validateActionAITool validates the chatbot AI tool settings of an action. Only webhook and
+// JavaScript actions can be tools, since URL actions only make sense in an agent's browser.
+func validateActionAITool(actionType models.ActionType, aiTool bool, description string, parameters map[string]interface{}) error {
+	if !aiTool {
+		return nil
+	}
+	if actionType != models.ActionTypeWebhook && actionType != models.ActionTypeJavascript {
+		return &ValidationError{Field: "ai_tool", Message: "Only webhook and JavaScript actions can be AI tools"}
+	}
+	if strings.TrimSpace(description) == "" {
+		return &ValidationError{Field: "ai_tool_description", Message: "AI tool description is required"}
+	}
+	if err := validateAIToolParameters(parameters); err != nil {
+		return &ValidationError{Field: "ai_tool_parameters", Message: "Invalid AI tool parameters: " + err.Error()}
+	}
+	return nil
+}
+
 // customActionToResponse converts a CustomAction model to response
 func customActionToResponse(action models.CustomAction) CustomActionResponse {
 	// Config is already a map[string]interface{}, just use it directly
 	config := map[string]interface{}(action.Config)
 
 	return CustomActionResponse{
-		ID:           action.ID,
-		Name:         action.Name,
-		Icon:         action.Icon,
-		ActionType:   action.ActionType,
-		Config:       config,
-		IsActive:     action.IsActive,
-		DisplayOrder: action.DisplayOrder,
-		CreatedAt:    action.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:    action.UpdatedAt.Format(time.RFC3339),
+		ID:                action.ID,
+		Name:              action.Name,
+		Icon:              action.Icon,
+		ActionType:        action.ActionType,
+		Config:            config,
+		IsActive:          action.IsActive,
+		DisplayOrder:      action.DisplayOrder,
+		AITool:            action.AITool,
+		AIToolDescription: action.AIToolDescription,
+		AIToolParameters:  map[string]interface{}(action.AIToolParameters),
+		CreatedAt:         action.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:         action.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
@@ -361,6 +361,45 @@ func TestApp_CreateCustomAction(t *testing.T) {
 		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
 	})
 
+	t.Run("ValidationError_AIToolURLAction", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		user := testutil.CreateTestUser(t, app.DB, org.ID)
+
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"name":                "Open Order",
+			"action_type":         "url",
+			"config":              map[string]any{"url": "https://shop.example.com/orders"},
+			"is_active":           true,
+			"ai_tool":             true,
+			"ai_tool_description": "Opens an order",
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		err := app.CreateCustomAction(req)
+		require.NoError(t, err)
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("ValidationError_AIToolMissingDescription", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		user := testutil.CreateTestUser(t, app.DB, org.ID)
+
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"name":        "Look Up Order",
+			"action_type": "webhook",
+			"config":      map[string]any{"url": "https://shop.example.com/orders"},
+			"is_active":   true,
+			"ai_tool":     true,
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		err := app.CreateCustomAction(req)
+		require.NoError(t, err)
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	})
+
 	t.Run("Unauthorized", func(t *testing.T) {
 		app := newTestApp(t)
 
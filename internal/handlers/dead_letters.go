@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+var (
+	// deadLetterIDPattern matches Redis stream entry IDs
+	deadLetterIDPattern = regexp.MustCompile(`^\d+-\d+$`)
+
+	// errRecipientNotFailed is returned when requeueing a job whose recipient is no longer failed
+	errRecipientNotFailed = errors.New("recipient is not failed")
+)
+
+// ListDeadLetters returns campaign jobs that exhausted their retries, newest first.
+// Use the returned next_cursor as the before parameter to load older jobs.
+func (a *App) ListDeadLetters(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionRead); err != nil {
+		return nil
+	}
+
+	var campaignID uuid.UUID
+	if s := string(r.RequestCtx.QueryArgs().Peek("campaign_id")); s != "" {
+		if campaignID, err = uuid.Parse(s); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid campaign ID", nil, "")
+		}
+	}
+
+	pg := parsePagination(r)
+	before := string(r.RequestCtx.QueryArgs().Peek("before"))
+	if before != "" && !deadLetterIDPattern.MatchString(before) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid cursor", nil, "")
+	}
+
+	jobs, next, err := queue.NewDeadLetterQueue(a.Redis).List(r.RequestCtx, func(dl *queue.DeadLetter) bool {
+		if dl.Job == nil || dl.Job.OrganizationID != orgID {
+			return false
+		}
+		return campaignID == uuid.Nil || dl.Job.CampaignID == campaignID
+	}, before, pg.Limit)
+	if err != nil {
+		a.Log.Error("Failed to list dead-lettered jobs", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list dead-lettered jobs", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"jobs":        jobs,
+		"next_cursor": next,
+		"limit":       pg.Limit,
+	})
+}
+
+// RequeueDeadLetter moves a dead-lettered job back to the campaign queue. The
+// recipient is reset to pending and the campaign resumes processing.
+func (a *App) RequeueDeadLetter(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionExecute); err != nil {
+		return nil
+	}
+
+	dlq := queue.NewDeadLetterQueue(a.Redis)
+	dl, err := a.findDeadLetter(r, dlq, orgID)
+	if err != nil {
+		return nil
+	}
+
+	var campaign models.BulkMessageCampaign
+	if err := a.DB.Where("id = ? AND organization_id = ?", dl.Job.CampaignID, orgID).First(&campaign).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Campaign no longer exists, delete the job instead", nil, "")
+		}
+		a.Log.Error("Failed to load campaign", "error", err, "campaign_id", dl.Job.CampaignID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to requeue job", nil, "")
+	}
+	if campaign.Status == models.CampaignStatusPaused || campaign.Status == models.CampaignStatusCancelled {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Cannot requeue jobs of a paused or cancelled campaign", nil, "")
+	}
+
+	// The recipient may have been retried since, e.g. with retry-failed; don't send twice
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.BulkMessageRecipient{}).
+			Where("id = ? AND campaign_id = ? AND status = ?", dl.Job.RecipientID, campaign.ID, models.MessageStatusFailed).
+			Updates(map[string]interface{}{
+				"status":        models.MessageStatusPending,
+				"error_message": "",
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRecipientNotFailed
+		}
+
+		updates := map[string]interface{}{
+			"failed_count": gorm.Expr("GREATEST(failed_count - 1, 0)"),
+		}
+		if campaign.Status == models.CampaignStatusCompleted || campaign.Status == models.CampaignStatusFailed {
+			updates["status"] = models.CampaignStatusProcessing
+			updates["completed_at"] = nil
+		}
+		if err := tx.Model(&campaign).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		return dlq.Requeue(r.RequestCtx, dl)
+	})
+	switch {
+	case errors.Is(err, errRecipientNotFailed):
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Recipient is no longer failed, delete the job instead", nil, "")
+	case errors.Is(err, queue.ErrDeadLetterNotFound):
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Dead-lettered job not found", nil, "")
+	case err != nil:
+		a.Log.Error("Failed to requeue dead-lettered job", "error", err, "id", dl.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to requeue job", nil, "")
+	}
+
+	a.Log.Info("Dead-lettered job requeued", "id", dl.ID, "campaign_id", campaign.ID, "recipient_id", dl.Job.RecipientID)
+
+	return r.SendEnvelope(map[string]any{
+		"message":      "Job requeued",
+		"id":           dl.ID,
+		"campaign_id":  campaign.ID,
+		"recipient_id": dl.Job.RecipientID,
+	})
+}
+
+// DeleteDeadLetter discards a dead-lettered job
+func (a *App) DeleteDeadLetter(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceCampaigns, models.ActionExecute); err != nil {
+		return nil
+	}
+
+	dlq := queue.NewDeadLetterQueue(a.Redis)
+	dl, err := a.findDeadLetter(r, dlq, orgID)
+	if err != nil {
+		return nil
+	}
+
+	if err := dlq.Delete(r.RequestCtx, dl.ID); err != nil {
+		if errors.Is(err, queue.ErrDeadLetterNotFound) {
+			return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Dead-lettered job not found", nil, "")
+		}
+		a.Log.Error("Failed to delete dead-lettered job", "error", err, "id", dl.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete job", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]string{"message": "Job deleted"})
+}
+
+// findDeadLetter loads the dead-lettered job named by the id path parameter and
+// checks that it belongs to the organization. Sends an error response on failure.
+func (a *App) findDeadLetter(r *fastglue.Request, dlq *queue.DeadLetterQueue, orgID uuid.UUID) (*queue.DeadLetter, error) {
+	id, _ := r.RequestCtx.UserValue("id").(string)
+	if !deadLetterIDPattern.MatchString(id) {
+		_ = r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid job ID", nil, "")
+		return nil, errEnvelopeSent
+	}
+
+	dl, err := dlq.Get(r.RequestCtx, id)
+	if err != nil && !errors.Is(err, queue.ErrDeadLetterNotFound) {
+		a.Log.Error("Failed to load dead-lettered job", "error", err, "id", id)
+		_ = r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load job", nil, "")
+		return nil, errEnvelopeSent
+	}
+	// Jobs of other organizations are reported as missing
+	if dl == nil || dl.Job == nil || dl.Job.OrganizationID != orgID {
+		_ = r.SendErrorEnvelope(fasthttp.StatusNotFound, "Dead-lettered job not found", nil, "")
+		return nil, errEnvelopeSent
+	}
+	return dl, nil
+}
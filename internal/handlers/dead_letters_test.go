@@ -0,0 +1,213 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// addTestDeadLetter adds a dead-lettered recipient job to the dead-letter stream
+func addTestDeadLetter(t *testing.T, app *handlers.App, job *queue.RecipientJob) string {
+	t.Helper()
+	payload, err := json.Marshal(job)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	id, err := app.Redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: queue.DeadLetterStreamName,
+		Values: map[string]interface{}{
+			"type":       string(queue.JobTypeRecipient),
+			"payload":    string(payload),
+			"error":      "temporary send failure: API returned status 503",
+			"retries":    queue.MaxJobRetries,
+			"message_id": "1-0",
+			"failed_at":  time.Now().UTC().Format(time.RFC3339),
+		},
+	}).Result()
+	require.NoError(t, err)
+	t.Cleanup(func() { app.Redis.XDel(ctx, queue.DeadLetterStreamName, id) })
+	return id
+}
+
+// deadLetterTestData creates a completed campaign with one failed recipient and its dead-lettered job
+func deadLetterTestData(t *testing.T, app *handlers.App) (*models.User, *models.BulkMessageCampaign, *models.BulkMessageRecipient, string) {
+	t.Helper()
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusCompleted)
+	recipient := createTestRecipient(t, app, campaign.ID, "15550001111", models.MessageStatusFailed)
+	require.NoError(t, app.DB.Model(campaign).Update("failed_count", 1).Error)
+
+	id := addTestDeadLetter(t, app, &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		Retries:        queue.MaxJobRetries,
+	})
+	return user, campaign, recipient, id
+}
+
+func TestApp_ListDeadLetters(t *testing.T) {
+	app := newTestApp(t)
+	user, campaign, recipient, id := deadLetterTestData(t, app)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, campaign.OrganizationID, user.ID)
+
+	require.NoError(t, app.ListDeadLetters(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			Jobs []queue.DeadLetter `json:"jobs"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	require.Len(t, resp.Data.Jobs, 1)
+	assert.Equal(t, id, resp.Data.Jobs[0].ID)
+	assert.Equal(t, recipient.ID, resp.Data.Jobs[0].Job.RecipientID)
+	assert.Equal(t, queue.MaxJobRetries, resp.Data.Jobs[0].Retries)
+
+	t.Run("other organization", func(t *testing.T) {
+		otherOrg := testutil.CreateTestOrganization(t, app.DB)
+		otherRole := testutil.CreateAdminRole(t, app.DB, otherOrg.ID)
+		otherUser := testutil.CreateTestUser(t, app.DB, otherOrg.ID, testutil.WithRoleID(&otherRole.ID))
+
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, otherOrg.ID, otherUser.ID)
+
+		require.NoError(t, app.ListDeadLetters(req))
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.Empty(t, resp.Data.Jobs)
+	})
+}
+
+func TestApp_RequeueDeadLetter(t *testing.T) {
+	app := newTestApp(t)
+	user, campaign, recipient, id := deadLetterTestData(t, app)
+	ctx := context.Background()
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, campaign.OrganizationID, user.ID)
+	testutil.SetPathParam(req, "id", id)
+
+	require.NoError(t, app.RequeueDeadLetter(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, app.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusPending, updatedRecipient.Status)
+
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, app.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Equal(t, models.CampaignStatusProcessing, updatedCampaign.Status)
+	assert.Equal(t, 0, updatedCampaign.FailedCount)
+
+	// The job is back on the campaign stream with its retries reset
+	msgs, err := app.Redis.XRevRangeN(ctx, queue.StreamName, "+", "-", 50).Result()
+	require.NoError(t, err)
+	var requeued *queue.RecipientJob
+	for _, msg := range msgs {
+		var job queue.RecipientJob
+		if json.Unmarshal([]byte(msg.Values["payload"].(string)), &job) == nil && job.RecipientID == recipient.ID {
+			requeued = &job
+			app.Redis.XDel(ctx, queue.StreamName, msg.ID)
+			break
+		}
+	}
+	require.NotNil(t, requeued)
+	assert.Equal(t, 0, requeued.Retries)
+
+	t.Run("already requeued", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, nil)
+		testutil.SetAuthContext(req, campaign.OrganizationID, user.ID)
+		testutil.SetPathParam(req, "id", id)
+
+		require.NoError(t, app.RequeueDeadLetter(req))
+		assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+	})
+}
+
+func TestApp_RequeueDeadLetter_RecipientRetried(t *testing.T) {
+	app := newTestApp(t)
+	user, campaign, recipient, id := deadLetterTestData(t, app)
+
+	// The recipient was sent again by retry-failed in the meantime
+	require.NoError(t, app.DB.Model(recipient).Update("status", models.MessageStatusSent).Error)
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, campaign.OrganizationID, user.ID)
+	testutil.SetPathParam(req, "id", id)
+
+	require.NoError(t, app.RequeueDeadLetter(req))
+	assert.Equal(t, fasthttp.StatusConflict, testutil.GetResponseStatusCode(req))
+
+	// The job stays dead-lettered
+	_, err := queue.NewDeadLetterQueue(app.Redis).Get(context.Background(), id)
+	assert.NoError(t, err)
+}
+
+func TestApp_DeleteDeadLetter(t *testing.T) {
+	app := newTestApp(t)
+	user, campaign, _, id := deadLetterTestData(t, app)
+
+	t.Run("invalid id", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, nil)
+		testutil.SetAuthContext(req, campaign.OrganizationID, user.ID)
+		testutil.SetPathParam(req, "id", "not-an-id")
+
+		require.NoError(t, app.DeleteDeadLetter(req))
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("other organization", func(t *testing.T) {
+		otherOrg := testutil.CreateTestOrganization(t, app.DB)
+		otherRole := testutil.CreateAdminRole(t, app.DB, otherOrg.ID)
+		otherUser := testutil.CreateTestUser(t, app.DB, otherOrg.ID, testutil.WithRoleID(&otherRole.ID))
+
+		req := testutil.NewJSONRequest(t, nil)
+		testutil.SetAuthContext(req, otherOrg.ID, otherUser.ID)
+		testutil.SetPathParam(req, "id", id)
+
+		require.NoError(t, app.DeleteDeadLetter(req))
+		assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+	})
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, campaign.OrganizationID, user.ID)
+	testutil.SetPathParam(req, "id", id)
+
+	require.NoError(t, app.DeleteDeadLetter(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	_, err := queue.NewDeadLetterQueue(app.Redis).Get(context.Background(), id)
+	assert.ErrorIs(t, err, queue.ErrDeadLetterNotFound)
+}
+
+func TestApp_ListDeadLetters_RequiresPermission(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateTestRoleWithKeys(t, app.DB, org.ID, "viewer-"+uuid.New().String()[:8], []string{"contacts:read"})
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+
+	require.NoError(t, app.ListDeadLetters(req))
+	assert.Equal(t, fasthttp.StatusForbidden, testutil.GetResponseStatusCode(req))
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// flowWaitBatchSize limits how many due waits are resumed per tick
+const flowWaitBatchSize = 100
+
+// FlowWaitProcessor resumes chatbot flows paused on a wait step once their
+// wait_until has passed. Timers live on the session row, so they survive
+// restarts, and each wait is claimed with a conditional update so several
+// replicas can run the processor without resuming a session twice.
+type FlowWaitProcessor struct {
+	app      *App
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewFlowWaitProcessor creates a new flow wait processor
+func NewFlowWaitProcessor(app *App, interval time.Duration) *FlowWaitProcessor {
+	return &FlowWaitProcessor{
+		app:      app,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the flow wait processing loop
+func (p *FlowWaitProcessor) Start(ctx context.Context) {
+	p.app.Log.Info("Flow wait processor started", "interval", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.app.Log.Info("Flow wait processor stopped by context")
+			return
+		case <-p.stopCh:
+			p.app.Log.Info("Flow wait processor stopped")
+			return
+		case <-ticker.C:
+			p.resumeDueWaits()
+		}
+	}
+}
+
+// Stop stops the flow wait processor
+func (p *FlowWaitProcessor) Stop() {
+	close(p.stopCh)
+}
+
+// resumeDueWaits finds active sessions whose wait has elapsed and resumes them
+func (p *FlowWaitProcessor) resumeDueWaits() {
+	now := time.Now()
+
+	var sessions []models.ChatbotSession
+	if err := p.app.DB.Where("status = ? AND wait_until IS NOT NULL AND wait_until <= ?", models.SessionStatusActive, now).
+		Order("wait_until ASC").
+		Limit(flowWaitBatchSize).
+		Find(&sessions).Error; err != nil {
+		p.app.Log.Error("Failed to find due flow waits", "error", err)
+		return
+	}
+
+	for i := range sessions {
+		p.resumeSession(&sessions[i], now)
+	}
+}
+
+// resumeSession claims a due wait and continues the flow with the step after it
+func (p *FlowWaitProcessor) resumeSession(session *models.ChatbotSession, now time.Time) {
+	a := p.app
+	waitUntil := *session.WaitUntil
+
+	result := a.DB.Model(&models.ChatbotSession{}).
+		Where("id = ? AND status = ? AND wait_until = ?", session.ID, models.SessionStatusActive, waitUntil).
+		Updates(map[string]interface{}{
+			"wait_until":       nil,
+			"last_activity_at": now,
+		})
+	if result.Error != nil {
+		a.Log.Error("Failed to claim flow wait", "error", result.Error, "session_id", session.ID)
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Another replica resumed it, or the contact replied in the meantime
+		return
+	}
+	session.WaitUntil = nil
+	session.LastActivityAt = now
+
+	if session.CurrentFlowID == nil {
+		return
+	}
+
+	flow, err := a.getPinnedChatbotFlow(session.OrganizationID, *session.CurrentFlowID, session.CurrentFlowVersion)
+	if err != nil {
+		a.Log.Warn("Flow no longer available, ending waiting session", "error", err, "session_id", session.ID)
+		a.exitFlow(session)
+		return
+	}
+	step := findFlowStep(flow, session.CurrentStep)
+	if step == nil || step.MessageType != models.FlowStepTypeWait {
+		a.Log.Warn("Waiting session is not on a wait step, ending it", "session_id", session.ID, "step", session.CurrentStep)
+		a.exitFlow(session)
+		return
+	}
+
+	account, err := a.resolveWhatsAppAccount(session.OrganizationID, session.WhatsAppAccount)
+	if err != nil {
+		a.Log.Error("Failed to load WhatsApp account for flow wait", "error", err, "session_id", session.ID)
+		a.exitFlow(session)
+		return
+	}
+	a.decryptAccountSecrets(account)
+
+	var contact models.Contact
+	if err := a.DB.Where("id = ? AND organization_id = ?", session.ContactID, session.OrganizationID).First(&contact).Error; err != nil {
+		a.Log.Error("Failed to load contact for flow wait", "error", err, "session_id", session.ID)
+		a.exitFlow(session)
+		return
+	}
+
+	// An agent took over or the chatbot was switched off while the flow was waiting
+	if a.hasActiveAgentTransfer(session.OrganizationID, contact.ID) {
+		a.Log.Info("Contact has active agent transfer, ending waiting flow", "session_id", session.ID)
+		a.exitFlow(session)
+		return
+	}
+	settings, err := a.getChatbotSettingsCached(session.OrganizationID, account.Name)
+	if err != nil || !settings.IsEnabled {
+		a.Log.Info("Chatbot disabled, ending waiting flow", "session_id", session.ID)
+		a.exitFlow(session)
+		return
+	}
+
+	// Don't send stale follow-ups if the processor was down for longer than a session lasts
+	if flowWaitOverdue(now, waitUntil, settings.SessionTimeoutMins) {
+		a.Log.Warn("Flow wait overdue by more than the session timeout, ending session", "session_id", session.ID, "overdue", now.Sub(waitUntil))
+		a.exitFlow(session)
+		return
+	}
+
+	a.Log.Info("Resuming flow after wait", "session_id", session.ID, "flow_id", flow.ID, "step", step.StepName)
+	a.continueFlowAfter(account, session, &contact, step, flow)
+}
+
+// flowWaitOverdue reports whether a wait ended longer ago than the session
+// timeout. Without a timeout, no wait is overdue.
+func flowWaitOverdue(now, waitUntil time.Time, timeoutMins int) bool {
+	if timeoutMins <= 0 {
+		return false
+	}
+	return now.Sub(waitUntil) > time.Duration(timeoutMins)*time.Minute
+}
+
+// startFlowWait sends the wait step's message and pauses the session until the wait elapses
+func (a *App) startFlowWait(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep) {
+	if message := processTemplate(localized(step.Translations, contactLanguage(contact), "message", step.Message), session.SessionData); message != "" {
+		if err := a.sendAndSaveTextMessage(account, contact, message); err != nil {
+			a.Log.Error("Failed to send wait step message", "error", err, "contact", contact.PhoneNumber)
+		}
+		a.logSessionMessage(session.ID, models.DirectionOutgoing, message, step.StepName)
+	}
+
+	resumeAt, err := stepWaitUntil(step, session.SessionData, time.Now())
+	if err != nil {
+		// Resume on the next tick rather than leaving the flow stuck
+		a.Log.Error("Invalid wait step, continuing without waiting", "error", err, "step", step.StepName)
+		resumeAt = time.Now()
+	}
+
+	session.CurrentStep = step.StepName
+	session.WaitUntil = &resumeAt
+	a.DB.Model(session).Updates(map[string]interface{}{
+		"current_step": step.StepName,
+		"step_retries": 0,
+		"wait_until":   resumeAt,
+	})
+
+	// The bot isn't waiting for a reply, so client inactivity reminders must not fire
+	a.ClearContactChatbotTracking(contact.ID)
+
+	a.Log.Info("Flow waiting", "session_id", session.ID, "step", step.StepName, "resume_at", resumeAt)
+}
+
+// cancelFlowWait drops a pending wait and takes the session out of its flow
+func (a *App) cancelFlowWait(session *models.ChatbotSession) {
+	a.Log.Info("Contact replied during wait, cancelling flow", "session_id", session.ID, "step", session.CurrentStep)
+
+	session.CurrentFlowID = nil
+	session.CurrentStep = ""
+	session.StepRetries = 0
+	session.WaitUntil = nil
+	a.DB.Model(session).Updates(map[string]interface{}{
+		"current_flow_id": nil,
+		"current_step":    "",
+		"step_retries":    0,
+		"wait_until":      nil,
+	})
+}
+
+// continueFlowAfter moves the session to the step after the given one, or completes the flow
+func (a *App) continueFlowAfter(account *models.WhatsAppAccount, session *models.ChatbotSession, contact *models.Contact, step *models.ChatbotFlowStep, flow *models.ChatbotFlow) {
+	nextStepName := defaultNextStep(flow, step)
+	if nextStepName == "" {
+		a.completeFlow(account, session, contact, flow)
+		return
+	}
+
+	nextStep := findFlowStep(flow, nextStepName)
+	if nextStep == nil {
+		a.Log.Warn("Next step not found after wait, completing flow", "next_step", nextStepName)
+		a.completeFlow(account, session, contact, flow)
+		return
+	}
+
+	session.CurrentStep = nextStep.StepName
+	session.StepRetries = 0
+	a.DB.Model(session).Updates(map[string]interface{}{
+		"current_step": nextStep.StepName,
+		"step_retries": 0,
+	})
+
+	a.sendStepWithSkipCheck(account, session, contact, nextStep, flow, nil)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepWaitUntil(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	step := &models.ChatbotFlowStep{WaitConfig: models.JSONB{"duration_minutes": float64(90)}}
+	resumeAt, err := stepWaitUntil(step, nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(90*time.Minute), resumeAt)
+
+	step = &models.ChatbotFlowStep{WaitConfig: models.JSONB{"until": "{{appointment}}"}}
+	resumeAt, err = stepWaitUntil(step, models.JSONB{"appointment": "2026-03-02T09:30:00+05:30"}, now)
+	require.NoError(t, err)
+	assert.True(t, resumeAt.Equal(time.Date(2026, 3, 2, 4, 0, 0, 0, time.UTC)))
+
+	step = &models.ChatbotFlowStep{WaitConfig: models.JSONB{"until": "2026-02-01T00:00:00Z"}}
+	resumeAt, err = stepWaitUntil(step, nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, now, resumeAt, "past times resume immediately")
+
+	_, err = stepWaitUntil(&models.ChatbotFlowStep{WaitConfig: models.JSONB{"until": "tomorrow"}}, nil, now)
+	assert.Error(t, err)
+	_, err = stepWaitUntil(&models.ChatbotFlowStep{WaitConfig: models.JSONB{"duration_minutes": float64(60 * 24 * 31)}}, nil, now)
+	assert.Error(t, err)
+	_, err = stepWaitUntil(&models.ChatbotFlowStep{}, nil, now)
+	assert.Error(t, err)
+}
+
+func TestFlowWaitOverdue(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	assert.False(t, flowWaitOverdue(now, now.Add(-time.Minute), 30))
+	assert.True(t, flowWaitOverdue(now, now.Add(-31*time.Minute), 30))
+	assert.False(t, flowWaitOverdue(now, now.Add(-time.Minute), 0), "no session timeout")
+	assert.False(t, flowWaitOverdue(now, now.Add(-48*time.Hour), 0), "no session timeout")
+}
+
+// createWaitTestFlow creates a flow that waits an hour after asking for a name
+// and then sends a follow-up question.
+func createWaitTestFlow(t *testing.T, app *App, orgID uuid.UUID, accountName string) *models.ChatbotFlow {
+	t.Helper()
+	flowID := uuid.New()
+	flow := &models.ChatbotFlow{
+		BaseModel:       models.BaseModel{ID: flowID},
+		OrganizationID:  orgID,
+		WhatsAppAccount: accountName,
+		Name:            "Follow Up",
+		IsEnabled:       true,
+		Steps: []models.ChatbotFlowStep{
+			{
+				BaseModel: models.BaseModel{ID: uuid.New()}, FlowID: flowID,
+				StepName: "pause", StepOrder: 1, MessageType: models.FlowStepTypeWait,
+				Message: "We'll get back to you shortly", InputType: models.InputTypeNone,
+				WaitConfig: models.JSONB{"duration_minutes": float64(60)},
+			},
+			{
+				BaseModel: models.BaseModel{ID: uuid.New()}, FlowID: flowID,
+				StepName: "follow_up", StepOrder: 2, MessageType: models.FlowStepTypeText,
+				Message: "Did that help?", InputType: models.InputTypeText, StoreAs: "feedback",
+			},
+		},
+	}
+	require.NoError(t, app.DB.Create(flow).Error)
+	require.NoError(t, app.DB.Create(&models.ChatbotSettings{
+		BaseModel:          models.BaseModel{ID: uuid.New()},
+		OrganizationID:     orgID,
+		IsEnabled:          true,
+		SessionTimeoutMins: 30,
+	}).Error)
+	return flow
+}
+
+func newWaitTestSession(t *testing.T, app *App, orgID uuid.UUID, contact *models.Contact, accountName string) *models.ChatbotSession {
+	t.Helper()
+	session := &models.ChatbotSession{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  orgID,
+		ContactID:       contact.ID,
+		WhatsAppAccount: accountName,
+		PhoneNumber:     contact.PhoneNumber,
+		Status:          models.SessionStatusActive,
+		SessionData:     models.JSONB{},
+		StartedAt:       time.Now(),
+		LastActivityAt:  time.Now(),
+	}
+	require.NoError(t, app.DB.Create(session).Error)
+	return session
+}
+
+func TestFlowWait_StartFlowPausesOnWaitStep(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	flow := createWaitTestFlow(t, app, org.ID, account.Name)
+	session := newWaitTestSession(t, app, org.ID, contact, account.Name)
+
+	before := time.Now()
+	app.startFlow(account, session, contact, flow)
+
+	var dbSession models.ChatbotSession
+	require.NoError(t, app.DB.First(&dbSession, session.ID).Error)
+	assert.Equal(t, "pause", dbSession.CurrentStep)
+	require.NotNil(t, dbSession.WaitUntil)
+	assert.WithinDuration(t, before.Add(time.Hour), *dbSession.WaitUntil, time.Minute)
+
+	// The wait keeps the session alive past the normal inactivity timeout
+	require.NoError(t, app.DB.Model(&dbSession).Update("last_activity_at", time.Now().Add(-2*time.Hour)).Error)
+	found, isNew := app.getOrCreateSession(org.ID, contact.ID, account.Name, contact.PhoneNumber, 30)
+	assert.False(t, isNew)
+	assert.Equal(t, session.ID, found.ID)
+
+	// A reply cancels the wait and takes the session out of the flow
+	app.cancelFlowWait(found)
+	require.NoError(t, app.DB.First(&dbSession, session.ID).Error)
+	assert.Nil(t, dbSession.WaitUntil)
+	assert.Nil(t, dbSession.CurrentFlowID)
+	assert.Empty(t, dbSession.CurrentStep)
+}
+
+func TestFlowWaitProcessor_ResumesDueWaitOnce(t *testing.T) {
+	app := newProcessorTestApp(t)
+	if app.Redis == nil {
+		t.Skip("Redis not available")
+	}
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	flow := createWaitTestFlow(t, app, org.ID, account.Name)
+	session := newWaitTestSession(t, app, org.ID, contact, account.Name)
+
+	due := time.Now().Add(-time.Minute)
+	require.NoError(t, app.DB.Model(session).Updates(map[string]any{
+		"current_flow_id": flow.ID,
+		"current_step":    "pause",
+		"wait_until":      due,
+	}).Error)
+
+	p := NewFlowWaitProcessor(app, time.Minute)
+	p.resumeDueWaits()
+
+	var dbSession models.ChatbotSession
+	require.NoError(t, app.DB.First(&dbSession, session.ID).Error)
+	assert.Nil(t, dbSession.WaitUntil)
+	assert.Equal(t, "follow_up", dbSession.CurrentStep)
+	assert.Equal(t, models.SessionStatusActive, dbSession.Status)
+
+	// A stale copy of the row can't be claimed again
+	stale := *session
+	stale.WaitUntil = &due
+	p.resumeSession(&stale, time.Now())
+
+	var sent int64
+	require.NoError(t, app.DB.Model(&models.Message{}).
+		Where("contact_id = ? AND content = ?", contact.ID, "Did that help?").
+		Count(&sent).Error)
+	assert.Equal(t, int64(1), sent)
+}
+
+func TestFlowWaitProcessor_EndsOverdueWait(t *testing.T) {
+	app := newProcessorTestApp(t)
+	if app.Redis == nil {
+		t.Skip("Redis not available")
+	}
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	flow := createWaitTestFlow(t, app, org.ID, account.Name)
+	session := newWaitTestSession(t, app, org.ID, contact, account.Name)
+
+	require.NoError(t, app.DB.Model(session).Updates(map[string]any{
+		"current_flow_id": flow.ID,
+		"current_step":    "pause",
+		"wait_until":      time.Now().Add(-2 * time.Hour),
+	}).Error)
+
+	NewFlowWaitProcessor(app, time.Minute).resumeDueWaits()
+
+	var dbSession models.ChatbotSession
+	require.NoError(t, app.DB.First(&dbSession, session.ID).Error)
+	assert.NotEqual(t, models.SessionStatusActive, dbSession.Status)
+	assert.Nil(t, dbSession.WaitUntil)
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/contactutil"
 	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
@@ -117,6 +118,18 @@ var exportConfigs = map[string]ExportConfig{
 			"created_at":  "Created At",
 		},
 	},
+	"suppressions": {
+		Model:          &models.ContactSuppression{},
+		Resource:       "contacts",
+		AllowedColumns: []string{"phone_number", "source", "reason", "created_at"},
+		DefaultColumns: []string{"phone_number", "source", "reason", "created_at"},
+		ColumnLabels: map[string]string{
+			"phone_number": "Phone Number",
+			"source":       "Source",
+			"reason":       "Reason",
+			"created_at":   "Created At",
+		},
+	},
 }
 
 var importConfigs = map[string]ImportConfig{
@@ -161,6 +174,26 @@ var importConfigs = map[string]ImportConfig{
 		OptionalColumns: []string{"color", "description"},
 		UniqueColumn:    "name",
 	},
+	"suppressions": {
+		Model:           &models.ContactSuppression{},
+		Resource:        "contacts",
+		RequiredColumns: []string{"phone_number"},
+		OptionalColumns: []string{"reason"},
+		UniqueColumn:    "phone_number",
+		ColumnTransform: map[string]func(string) (interface{}, error){
+			"phone_number": func(s string) (interface{}, error) {
+				phone := contactutil.NormalizePhone(s)
+				if phone == "" {
+					return nil, fmt.Errorf("phone number is required")
+				}
+				return phone, nil
+			},
+		},
+		BeforeCreate: func(db *gorm.DB, orgID uuid.UUID, record map[string]interface{}) error {
+			record["source"] = models.SuppressionSourceImport
+			return nil
+		},
+	},
 }
 
 // ExportRequest represents an export request
@@ -225,6 +258,8 @@ func (a *App) ExportData(r *fastglue.Request) error {
 			query = query.Where("phone_number LIKE ? OR profile_name ILIKE ?", searchPattern, searchPattern)
 		case "tags":
 			query = query.Where("name ILIKE ? OR description ILIKE ?", searchPattern, searchPattern)
+		case "suppressions":
+			query = query.Where("phone_number LIKE ? OR reason ILIKE ?", searchPattern, searchPattern)
 		}
 	}
 
@@ -309,8 +344,8 @@ func (a *App) ExportData(r *fastglue.Request) error {
 				csvRow[i] = formatExportValue(val, colTypes[i+1])
 			}
 		}
-		// Apply phone masking for contacts export
-		if req.Table == "contacts" && a.ShouldMaskPhoneNumbers(orgID) {
+		// Apply phone masking for contacts and suppression list exports
+		if (req.Table == "contacts" || req.Table == "suppressions") && a.ShouldMaskPhoneNumbers(orgID) {
 			for i, col := range safeColumns {
 				if col == "phone_number" {
 					csvRow[i] = MaskPhoneNumber(csvRow[i])
@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// Keyword matching limits
+const (
+	defaultIntentThreshold = 0.5
+	maxFuzzyMaxEdits       = 5
+	intentAIMaxTokens      = 10
+)
+
+// Keyword match methods
+const (
+	keywordMatchLocal = "local"
+	keywordMatchAI    = "ai" // the AI provider picked the intent
+)
+
+// keywordMatch is the keyword rule that fires for a message
+type keywordMatch struct {
+	Rule     *models.KeywordRule
+	Keyword  string  // keyword or training phrase that matched
+	Score    float64 // 1 for exact matches, similarity for fuzzy and intent rules
+	Method   string
+	Response *KeywordResponse
+}
+
+// intentScore is the confidence of an intent rule for a message
+type intentScore struct {
+	Rule   *models.KeywordRule
+	Phrase string // closest training phrase
+	Score  float64
+}
+
+// intentStopWords carry no intent and are ignored when comparing a message to training phrases
+var intentStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "i": true, "me": true, "my": true, "you": true, "your": true,
+	"is": true, "are": true, "am": true, "was": true, "be": true, "to": true, "of": true, "in": true,
+	"on": true, "at": true, "for": true, "and": true, "or": true, "it": true, "its": true, "do": true,
+	"does": true, "can": true, "could": true, "would": true, "please": true, "pls": true, "this": true,
+	"that": true, "we": true, "our": true, "with": true,
+}
+
+// findKeywordMatch returns the first keyword rule, by priority, that matches the message and
+// has a response to send. Intent rules fire when they are the best intent for the message.
+func (a *App) findKeywordMatch(orgID uuid.UUID, accountName, messageText string) *keywordMatch {
+	// Use cached keyword rules (includes both account-specific and global rules)
+	rules, err := a.getKeywordRulesCached(orgID, accountName)
+	if err != nil {
+		a.Log.Error("Failed to fetch keyword rules", "error", err)
+		return nil
+	}
+
+	// The intent is classified once, when the first intent rule is reached
+	var intent *keywordMatch
+	intentClassified := false
+
+	for i := range rules {
+		rule := &rules[i]
+		response, ok := keywordRuleResponse(rule)
+		if !ok {
+			continue
+		}
+
+		if rule.MatchType == models.MatchTypeIntent {
+			if !intentClassified {
+				intent = a.classifyIntent(orgID, accountName, rules, messageText)
+				intentClassified = true
+			}
+			if intent != nil && intent.Rule.ID == rule.ID {
+				intent.Rule = rule
+				intent.Response = response
+				return intent
+			}
+			continue
+		}
+
+		if keyword, score, ok := matchKeywordRule(rule, messageText); ok {
+			return &keywordMatch{Rule: rule, Keyword: keyword, Score: score, Method: keywordMatchLocal, Response: response}
+		}
+	}
+	return nil
+}
+
+// keywordRuleResponse returns the response of a keyword rule. Rules without a body
+// are skipped, except transfers whose body is optional.
+func keywordRuleResponse(rule *models.KeywordRule) (*KeywordResponse, bool) {
+	response := &KeywordResponse{
+		ResponseType: rule.ResponseType,
+		Translations: rule.Translations,
+	}
+
+	// Get response body, which is the transfer message for transfers
+	if body, ok := rule.ResponseContent["body"].(string); ok {
+		response.Body = body
+	}
+	if rule.ResponseType == models.ResponseTypeTransfer {
+		response.Skills, _ = skillList(rule.ResponseContent["skills"])
+		return response, true
+	}
+
+	// Get buttons if present
+	if buttons, ok := rule.ResponseContent["buttons"].([]interface{}); ok && len(buttons) > 0 {
+		response.Buttons = make([]map[string]interface{}, 0, len(buttons))
+		for _, btn := range buttons {
+			if btnMap, ok := btn.(map[string]interface{}); ok {
+				response.Buttons = append(response.Buttons, btnMap)
+			}
+		}
+	}
+
+	return response, response.Body != ""
+}
+
+// matchKeywordRule checks a message against the keywords of a rule that isn't an intent rule.
+// It returns the keyword that matched and how closely.
+func matchKeywordRule(rule *models.KeywordRule, messageText string) (string, float64, bool) {
+	messageLower := strings.ToLower(messageText)
+
+	for _, keyword := range rule.Keywords {
+		keywordLower := strings.ToLower(keyword)
+		matched := false
+		score := 1.0
+
+		switch rule.MatchType {
+		case models.MatchTypeExact:
+			if rule.CaseSensitive {
+				matched = messageText == keyword
+			} else {
+				matched = messageLower == keywordLower
+			}
+		case models.MatchTypeContains:
+			if rule.CaseSensitive {
+				matched = strings.Contains(messageText, keyword)
+			} else {
+				matched = strings.Contains(messageLower, keywordLower)
+			}
+		case models.MatchTypeStartsWith:
+			if rule.CaseSensitive {
+				matched = strings.HasPrefix(messageText, keyword)
+			} else {
+				matched = strings.HasPrefix(messageLower, keywordLower)
+			}
+		case models.MatchTypeRegex:
+			re, err := regexp.Compile(keyword)
+			if err == nil {
+				matched = re.MatchString(messageText)
+			}
+		case models.MatchTypeFuzzy:
+			score, matched = fuzzyKeywordScore(keyword, messageText, rule.FuzzyMaxEdits)
+		case models.MatchTypeTokenSet:
+			matched = tokenSetMatch(keyword, messageText)
+		default:
+			// Default to contains
+			matched = strings.Contains(messageLower, keywordLower)
+		}
+
+		if matched {
+			return keyword, score, true
+		}
+	}
+	return "", 0, false
+}
+
+// keywordTokens splits text into lowercase words, dropping punctuation
+func keywordTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// fuzzyMaxEdits returns the typos allowed in a keyword when the rule doesn't set them.
+// Short keywords must match exactly, since a typo often makes another word.
+func fuzzyMaxEdits(keyword string) int {
+	switch n := utf8.RuneCountInString(keyword); {
+	case n <= 3:
+		return 0
+	case n <= 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// fuzzyKeywordScore finds the words of the message closest to the keyword. It matches when
+// they are at most maxEdits edits apart, and scores 1 minus the edits per keyword character.
+func fuzzyKeywordScore(keyword, messageText string, maxEdits int) (float64, bool) {
+	words := keywordTokens(keyword)
+	if len(words) == 0 {
+		return 0, false
+	}
+	target := strings.Join(words, " ")
+	length := utf8.RuneCountInString(target)
+	if maxEdits <= 0 {
+		maxEdits = fuzzyMaxEdits(target)
+	}
+	// A keyword needs at least one of its characters left, or it matches any short word
+	maxEdits = min(maxEdits, length-1)
+
+	message := keywordTokens(messageText)
+	best := -1
+	// A typo can merge two words or split one, so compare one word more and less too
+	for n := len(words) - 1; n <= len(words)+1; n++ {
+		for i := 0; n > 0 && i+n <= len(message); i++ {
+			if d := levenshtein(target, strings.Join(message[i:i+n], " ")); best < 0 || d < best {
+				best = d
+			}
+		}
+	}
+	if best < 0 || best > maxEdits {
+		return 0, false
+	}
+	return max(0, 1-float64(best)/float64(length)), true
+}
+
+// tokenSetMatch reports whether the message has every word of the keyword, in any order
+func tokenSetMatch(keyword, messageText string) bool {
+	words := keywordTokens(keyword)
+	if len(words) == 0 {
+		return false
+	}
+	message := make(map[string]bool)
+	for _, w := range keywordTokens(messageText) {
+		message[w] = true
+	}
+	for _, w := range words {
+		if !message[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// levenshtein returns the number of single character edits between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// intentTokens returns the distinct words of a text that carry meaning. Stop words are
+// kept if the text has nothing else, so a phrase like "hi there" still has tokens.
+func intentTokens(text string) []string {
+	all := keywordTokens(text)
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, t := range all {
+		if !intentStopWords[t] && !seen[t] {
+			seen[t] = true
+			tokens = append(tokens, t)
+		}
+	}
+	if len(tokens) > 0 {
+		return tokens
+	}
+	for _, t := range all {
+		if !seen[t] {
+			seen[t] = true
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// scoreIntents rates the message against the training phrases of each intent rule, best first.
+// Phrases and the message are compared as sets of words weighted by how few intents use them,
+// so words shared by many intents count less. Message words within a typo of a training word
+// count as that word.
+func scoreIntents(rules []models.KeywordRule, messageText string) []intentScore {
+	type phrase struct {
+		rule   int
+		text   string
+		tokens []string
+	}
+	var phrases []phrase
+	intents := 0
+	docFreq := make(map[string]int)
+	for i := range rules {
+		if rules[i].MatchType != models.MatchTypeIntent {
+			continue
+		}
+		intents++
+		ruleTokens := make(map[string]bool)
+		for _, text := range rules[i].Keywords {
+			tokens := intentTokens(text)
+			if len(tokens) == 0 {
+				continue
+			}
+			phrases = append(phrases, phrase{rule: i, text: text, tokens: tokens})
+			for _, t := range tokens {
+				ruleTokens[t] = true
+			}
+		}
+		for t := range ruleTokens {
+			docFreq[t]++
+		}
+	}
+	if len(phrases) == 0 {
+		return nil
+	}
+
+	weight := func(token string) float64 {
+		if df := docFreq[token]; df > 0 {
+			return math.Log(1 + float64(intents)/float64(df))
+		}
+		return math.Log(1 + float64(intents))
+	}
+
+	// Map each message word to the training word it stands for
+	message := make(map[string]bool)
+	var messageNorm float64
+	for _, t := range intentTokens(messageText) {
+		if docFreq[t] == 0 && utf8.RuneCountInString(t) >= 4 {
+			for known := range docFreq {
+				if utf8.RuneCountInString(known) >= 4 && levenshtein(t, known) <= 1 {
+					t = known
+					break
+				}
+			}
+		}
+		if !message[t] {
+			message[t] = true
+			messageNorm += weight(t) * weight(t)
+		}
+	}
+	if messageNorm == 0 {
+		return nil
+	}
+
+	best := make(map[int]intentScore)
+	var order []int
+	for _, p := range phrases {
+		var dot, norm float64
+		for _, t := range p.tokens {
+			w := weight(t)
+			norm += w * w
+			if message[t] {
+				dot += w * w
+			}
+		}
+		score := dot / (math.Sqrt(norm) * math.Sqrt(messageNorm))
+		current, seen := best[p.rule]
+		if !seen {
+			order = append(order, p.rule)
+		}
+		if !seen || score > current.Score {
+			best[p.rule] = intentScore{Rule: &rules[p.rule], Phrase: p.text, Score: score}
+		}
+	}
+
+	scores := make([]intentScore, 0, len(order))
+	for _, i := range order {
+		scores = append(scores, best[i])
+	}
+	// Ties go to the rule with the higher priority, which comes first
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Score > scores[j].Score })
+	return scores
+}
+
+// classifyIntent returns the intent rule that best fits the message. If no intent reaches the
+// threshold in the chatbot settings, the AI provider is asked when AI classification is on.
+func (a *App) classifyIntent(orgID uuid.UUID, accountName string, rules []models.KeywordRule, messageText string) *keywordMatch {
+	// Only rules with a response can fire
+	var candidates []models.KeywordRule
+	for _, rule := range rules {
+		if _, ok := keywordRuleResponse(&rule); ok && rule.MatchType == models.MatchTypeIntent {
+			candidates = append(candidates, rule)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	threshold := defaultIntentThreshold
+	settings, err := a.getChatbotSettingsCached(orgID, accountName)
+	if err == nil && settings.Intent.Threshold > 0 {
+		threshold = settings.Intent.Threshold
+	}
+
+	scores := scoreIntents(candidates, messageText)
+	if len(scores) > 0 && scores[0].Score >= threshold {
+		return &keywordMatch{Rule: scores[0].Rule, Keyword: scores[0].Phrase, Score: scores[0].Score, Method: keywordMatchLocal}
+	}
+
+	if err != nil || !settings.Intent.AIClassification {
+		return nil
+	}
+	rule, err := a.classifyIntentWithAI(settings, candidates, messageText)
+	if err != nil {
+		a.Log.Error("AI intent classification failed", "error", err)
+		return nil
+	}
+	if rule == nil {
+		return nil
+	}
+	return &keywordMatch{Rule: rule, Score: 1, Method: keywordMatchAI}
+}
+
+// classifyIntentWithAI asks the AI provider which intent the message has. It returns nil
+// if the model finds none fits.
+func (a *App) classifyIntentWithAI(settings *models.ChatbotSettings, intents []models.KeywordRule, messageText string) (*models.KeywordRule, error) {
+	var sb strings.Builder
+	sb.WriteString("Classify the customer's message into one of these intents. Reply with only the intent's number, or 0 if none fits.\n")
+	for i, intent := range intents {
+		fmt.Fprintf(&sb, "\n%d. %s (for example: %s)", i+1, intent.Name, strings.Join(intent.Keywords, "; "))
+	}
+
+	intentSettings := *settings
+	intentSettings.AI.SystemPrompt = sb.String()
+	intentSettings.AI.IncludeHistory = false
+	intentSettings.AI.MaxTokens = intentAIMaxTokens
+	intentSettings.AI.Temperature = 0
+	output, err := a.completeAI(&intentSettings, nil, messageText, "")
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.FieldsFunc(output, func(r rune) bool { return !unicode.IsDigit(r) })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("model replied %q instead of an intent number", output)
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n > len(intents) {
+		return nil, fmt.Errorf("model replied %q instead of an intent number", output)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	return &intents[n-1], nil
+}
+
+// validateKeywordMatch checks the match type of a keyword rule and its allowed typos
+func validateKeywordMatch(matchType models.MatchType, maxEdits int) error {
+	switch matchType {
+	case "", models.MatchTypeExact, models.MatchTypeContains, models.MatchTypeStartsWith, models.MatchTypeRegex,
+		models.MatchTypeFuzzy, models.MatchTypeTokenSet, models.MatchTypeIntent:
+	default:
+		return fmt.Errorf("unknown match type %q", matchType)
+	}
+	if maxEdits < 0 || maxEdits > maxFuzzyMaxEdits {
+		return fmt.Errorf("fuzzy max edits must be between 0 and %d", maxFuzzyMaxEdits)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("order", "order"))
+	assert.Equal(t, 1, levenshtein("order", "ordr"))
+	assert.Equal(t, 1, levenshtein("envío", "envio"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+	assert.Equal(t, 4, levenshtein("", "test"))
+}
+
+func TestFuzzyKeywordScore(t *testing.T) {
+	score, ok := fuzzyKeywordScore("order status", "ordr status pls", 0)
+	require.True(t, ok)
+	assert.InDelta(t, 1-1.0/12, score, 0.001)
+
+	_, ok = fuzzyKeywordScore("order status", "hi, what's my ORDER STATUS?", 0)
+	assert.True(t, ok)
+	_, ok = fuzzyKeywordScore("order status", "orderstatus", 0)
+	assert.True(t, ok, "merged words")
+	_, ok = fuzzyKeywordScore("refund", "refnd please", 0)
+	assert.True(t, ok)
+
+	_, ok = fuzzyKeywordScore("order status", "odr stats", 0)
+	assert.False(t, ok, "three edits is more than allowed")
+	_, ok = fuzzyKeywordScore("order status", "odr stats", 3)
+	assert.True(t, ok, "rule allows three edits")
+	_, ok = fuzzyKeywordScore("hi", "ho", 0)
+	assert.False(t, ok, "short keywords must match exactly")
+	_, ok = fuzzyKeywordScore("hi", "ok", 5)
+	assert.False(t, ok, "edits are capped below the keyword's length")
+	score, ok = fuzzyKeywordScore("hi", "ho", 5)
+	require.True(t, ok)
+	assert.InDelta(t, 0.5, score, 0.001)
+	_, ok = fuzzyKeywordScore("order status", "", 0)
+	assert.False(t, ok)
+}
+
+func TestTokenSetMatch(t *testing.T) {
+	assert.True(t, tokenSetMatch("order status", "status of my order?"))
+	assert.True(t, tokenSetMatch("Order", "my order #123"))
+	assert.False(t, tokenSetMatch("order status", "where is my order"))
+	assert.False(t, tokenSetMatch("order", "reorder"), "whole words only")
+	assert.False(t, tokenSetMatch("", "anything"))
+}
+
+func intentRule(name string, phrases ...string) models.KeywordRule {
+	return models.KeywordRule{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		Name:            name,
+		Keywords:        phrases,
+		MatchType:       models.MatchTypeIntent,
+		ResponseType:    models.ResponseTypeText,
+		ResponseContent: models.JSONB{"body": name},
+	}
+}
+
+func TestScoreIntents(t *testing.T) {
+	rules := []models.KeywordRule{
+		intentRule("Order status", "where is my order", "track my order", "order status"),
+		intentRule("Refund", "I want a refund", "return my item", "money back"),
+		{MatchType: models.MatchTypeContains, Keywords: models.StringArray{"order"}},
+	}
+
+	scores := scoreIntents(rules, "ordr status pls")
+	require.Len(t, scores, 2)
+	assert.Equal(t, "Order status", scores[0].Rule.Name)
+	assert.Equal(t, "order status", scores[0].Phrase)
+	assert.Greater(t, scores[0].Score, defaultIntentThreshold)
+	assert.Zero(t, scores[1].Score)
+
+	scores = scoreIntents(rules, "Can I get my money back?")
+	assert.Equal(t, "Refund", scores[0].Rule.Name)
+	assert.Greater(t, scores[0].Score, defaultIntentThreshold)
+
+	scores = scoreIntents(rules, "what are your opening hours")
+	assert.Less(t, scores[0].Score, defaultIntentThreshold)
+
+	assert.Nil(t, scoreIntents(rules[2:], "order status"), "no intent rules")
+}
+
+func TestKeywordRuleResponse(t *testing.T) {
+	_, ok := keywordRuleResponse(&models.KeywordRule{ResponseType: models.ResponseTypeText, ResponseContent: models.JSONB{}})
+	assert.False(t, ok, "text rule without body")
+
+	resp, ok := keywordRuleResponse(&models.KeywordRule{ResponseType: models.ResponseTypeTransfer, ResponseContent: models.JSONB{}})
+	assert.True(t, ok, "transfer body is optional")
+	assert.Equal(t, models.ResponseTypeTransfer, resp.ResponseType)
+
+	resp, ok = keywordRuleResponse(&models.KeywordRule{
+		ResponseType:    models.ResponseTypeText,
+		ResponseContent: models.JSONB{"body": "Hi", "buttons": []interface{}{map[string]interface{}{"id": "a"}}},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "Hi", resp.Body)
+	assert.Len(t, resp.Buttons, 1)
+}
+
+func TestValidateKeywordMatch(t *testing.T) {
+	assert.NoError(t, validateKeywordMatch("", 0))
+	assert.NoError(t, validateKeywordMatch(models.MatchTypeFuzzy, 2))
+	assert.NoError(t, validateKeywordMatch(models.MatchTypeIntent, 0))
+	assert.Error(t, validateKeywordMatch("similar", 0))
+	assert.Error(t, validateKeywordMatch(models.MatchTypeFuzzy, maxFuzzyMaxEdits+1))
+	assert.Error(t, validateKeywordMatch(models.MatchTypeFuzzy, -1))
+}
@@ -0,0 +1,697 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/knowledge"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/pkg/llm"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// Knowledge base limits
+const (
+	maxKnowledgeFileSize      = 20 << 20 // 20MB
+	defaultKnowledgeChunks    = 4
+	maxKnowledgeChunks        = 10 // upper bound of the ai_knowledge_chunks setting
+	knowledgeCandidates       = 20 // matches taken from each search before fusing
+	knowledgeEmbedBatchSize   = 64
+	knowledgeMaxQueryTerms    = 32
+	knowledgeRRFConstant      = 60 // k of reciprocal rank fusion
+	knowledgeSearchConfig     = "english"
+	knowledgeCitationStepName = "knowledge_base"
+)
+
+// KnowledgeDocumentResponse represents a knowledge base document for API response
+type KnowledgeDocumentResponse struct {
+	ID              string                         `json:"id"`
+	Name            string                         `json:"name"`
+	FileName        string                         `json:"file_name"`
+	SourceType      string                         `json:"source_type"`
+	FileSize        int64                          `json:"file_size"`
+	WhatsAppAccount string                         `json:"whatsapp_account"`
+	Status          models.KnowledgeDocumentStatus `json:"status"`
+	Error           string                         `json:"error,omitempty"`
+	ChunkCount      int                            `json:"chunk_count"`
+	EmbeddingModel  string                         `json:"embedding_model"`
+	Enabled         bool                           `json:"enabled"`
+	CreatedAt       string                         `json:"created_at"`
+	UpdatedAt       string                         `json:"updated_at"`
+}
+
+func knowledgeDocumentToResponse(doc models.KnowledgeDocument) KnowledgeDocumentResponse {
+	return KnowledgeDocumentResponse{
+		ID:              doc.ID.String(),
+		Name:            doc.Name,
+		FileName:        doc.FileName,
+		SourceType:      doc.SourceType,
+		FileSize:        doc.FileSize,
+		WhatsAppAccount: doc.WhatsAppAccount,
+		Status:          doc.Status,
+		Error:           doc.Error,
+		ChunkCount:      doc.ChunkCount,
+		EmbeddingModel:  doc.EmbeddingModel,
+		Enabled:         doc.IsEnabled,
+		CreatedAt:       doc.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       doc.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// knowledgeMatch is a chunk found for a query
+type knowledgeMatch struct {
+	ID           uuid.UUID `json:"id"`
+	DocumentID   uuid.UUID `json:"document_id"`
+	DocumentName string    `json:"document_name"`
+	ChunkIndex   int       `json:"chunk_index"`
+	Content      string    `json:"content"`
+	Score        float64   `json:"score"`
+}
+
+// ListKnowledgeDocuments lists the documents of the knowledge base
+func (a *App) ListKnowledgeDocuments(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChatbotAI, models.ActionRead); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	search := string(r.RequestCtx.QueryArgs().Peek("search"))
+
+	query := a.DB.Model(&models.KnowledgeDocument{}).Where("organization_id = ?", orgID)
+	if search != "" {
+		searchPattern := "%" + search + "%"
+		query = query.Where("name ILIKE ? OR file_name ILIKE ?", searchPattern, searchPattern)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var docs []models.KnowledgeDocument
+	if err := pg.Apply(query.Order("created_at DESC")).Find(&docs).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to fetch knowledge base documents", nil, "")
+	}
+
+	response := make([]KnowledgeDocumentResponse, len(docs))
+	for i, doc := range docs {
+		response[i] = knowledgeDocumentToResponse(doc)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"documents": response,
+		"total":     total,
+		"page":      pg.Page,
+		"limit":     pg.Limit,
+	})
+}
+
+// UploadKnowledgeDocument adds a PDF, Markdown, HTML, CSV or text file to the knowledge base.
+// The text is extracted and chunked right away, so the document is searchable by keywords
+// when this returns; embeddings, if configured, are computed in the background.
+func (a *App) UploadKnowledgeDocument(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChatbotAI, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	form, err := r.RequestCtx.MultipartForm()
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid multipart form", nil, "")
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "No file provided", nil, "")
+	}
+	fileHeader := files[0]
+
+	sourceType, err := knowledge.SourceType(fileHeader.Filename)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Unsupported file type. Use PDF, Markdown, HTML, CSV or text files", nil, "")
+	}
+
+	name := strings.TrimSpace(formValue(form.Value, "name"))
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(fileHeader.Filename), filepath.Ext(fileHeader.Filename))
+	}
+	whatsAppAccount := strings.TrimSpace(formValue(form.Value, "whatsapp_account"))
+	if whatsAppAccount != "" {
+		var count int64
+		a.DB.Model(&models.WhatsAppAccount{}).Where("organization_id = ? AND name = ?", orgID, whatsAppAccount).Count(&count)
+		if count == 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to open file", nil, "")
+	}
+	defer func() { _ = file.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxKnowledgeFileSize+1))
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to read file", nil, "")
+	}
+	if len(data) > maxKnowledgeFileSize {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "File too large. Maximum size is 20MB", nil, "")
+	}
+
+	text, err := knowledge.Extract(sourceType, data)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Failed to read document: "+err.Error(), nil, "")
+	}
+	chunks := knowledge.Chunk(text, knowledge.DefaultChunkSize, knowledge.DefaultChunkOverlap)
+	if len(chunks) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "No text found in document", nil, "")
+	}
+
+	doc := models.KnowledgeDocument{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  orgID,
+		WhatsAppAccount: whatsAppAccount,
+		Name:            name,
+		FileName:        sanitizeFilename(fileHeader.Filename),
+		SourceType:      sourceType,
+		FileSize:        int64(len(data)),
+		Status:          models.KnowledgeDocumentReady,
+		ChunkCount:      len(chunks),
+		IsEnabled:       true,
+		CreatedByID:     &userID,
+	}
+
+	settings, _ := a.getChatbotSettingsCached(orgID, whatsAppAccount)
+	embedder := a.knowledgeEmbedder(settings)
+	if embedder != nil {
+		doc.Status = models.KnowledgeDocumentProcessing
+	}
+
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&doc).Error; err != nil {
+			return err
+		}
+		rows := make([]models.KnowledgeChunk, len(chunks))
+		for i, content := range chunks {
+			rows[i] = models.KnowledgeChunk{
+				BaseModel:      models.BaseModel{ID: uuid.New()},
+				OrganizationID: orgID,
+				DocumentID:     doc.ID,
+				ChunkIndex:     i,
+				Content:        content,
+			}
+		}
+		return tx.CreateInBatches(rows, 100).Error
+	})
+	if err != nil {
+		a.Log.Error("Failed to save knowledge base document", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to save document", nil, "")
+	}
+
+	if embedder != nil {
+		a.startKnowledgeEmbedding(doc, embedder, settings)
+	}
+
+	a.Log.Info("Knowledge base document uploaded", "document_id", doc.ID, "chunks", len(chunks), "source_type", sourceType)
+
+	return r.SendEnvelope(knowledgeDocumentToResponse(doc))
+}
+
+// GetKnowledgeDocument returns a document with its chunks
+func (a *App) GetKnowledgeDocument(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChatbotAI, models.ActionRead); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "document")
+	if err != nil {
+		return nil
+	}
+	doc, err := findByIDAndOrg[models.KnowledgeDocument](a.DB, r, id, orgID, "Document")
+	if err != nil {
+		return nil
+	}
+
+	var chunks []models.KnowledgeChunk
+	if err := a.DB.Where("document_id = ?", doc.ID).Order("chunk_index ASC").Find(&chunks).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to fetch document chunks", nil, "")
+	}
+	chunkResponse := make([]map[string]any, len(chunks))
+	for i, chunk := range chunks {
+		chunkResponse[i] = map[string]any{
+			"index":    chunk.ChunkIndex,
+			"content":  chunk.Content,
+			"embedded": len(chunk.Embedding) > 0,
+		}
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"document": knowledgeDocumentToResponse(*doc),
+		"chunks":   chunkResponse,
+	})
+}
+
+// UpdateKnowledgeDocument renames, enables or disables a document, or moves it to another account
+func (a *App) UpdateKnowledgeDocument(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChatbotAI, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "document")
+	if err != nil {
+		return nil
+	}
+	doc, err := findByIDAndOrg[models.KnowledgeDocument](a.DB, r, id, orgID, "Document")
+	if err != nil {
+		return nil
+	}
+
+	var req struct {
+		Name            *string `json:"name"`
+		WhatsAppAccount *string `json:"whatsapp_account"`
+		Enabled         *bool   `json:"enabled"`
+	}
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Name is required", nil, "")
+		}
+		doc.Name = name
+	}
+	if req.WhatsAppAccount != nil {
+		account := strings.TrimSpace(*req.WhatsAppAccount)
+		if account != "" {
+			var count int64
+			a.DB.Model(&models.WhatsAppAccount{}).Where("organization_id = ? AND name = ?", orgID, account).Count(&count)
+			if count == 0 {
+				return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "WhatsApp account not found", nil, "")
+			}
+		}
+		doc.WhatsAppAccount = account
+	}
+	if req.Enabled != nil {
+		doc.IsEnabled = *req.Enabled
+	}
+
+	if err := a.DB.Save(doc).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update document", nil, "")
+	}
+
+	return r.SendEnvelope(knowledgeDocumentToResponse(*doc))
+}
+
+// DeleteKnowledgeDocument removes a document and its chunks from the knowledge base
+func (a *App) DeleteKnowledgeDocument(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChatbotAI, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "document")
+	if err != nil {
+		return nil
+	}
+	doc, err := findByIDAndOrg[models.KnowledgeDocument](a.DB, r, id, orgID, "Document")
+	if err != nil {
+		return nil
+	}
+
+	err = a.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("document_id = ?", doc.ID).Delete(&models.KnowledgeChunk{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(doc).Error
+	})
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete document", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{"message": "Document deleted successfully"})
+}
+
+// ReindexKnowledgeDocument computes the embeddings of a document's chunks again, e.g. after
+// the embedding model changed or embedding failed
+func (a *App) ReindexKnowledgeDocument(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChatbotAI, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "document")
+	if err != nil {
+		return nil
+	}
+	doc, err := findByIDAndOrg[models.KnowledgeDocument](a.DB, r, id, orgID, "Document")
+	if err != nil {
+		return nil
+	}
+	if doc.Status == models.KnowledgeDocumentProcessing {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Document is already being indexed", nil, "")
+	}
+
+	settings, _ := a.getChatbotSettingsCached(orgID, doc.WhatsAppAccount)
+	embedder := a.knowledgeEmbedder(settings)
+	if embedder == nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "No embedding model is configured for the AI provider", nil, "")
+	}
+
+	// Searches skip the old embeddings until the new ones are stored
+	doc.Status = models.KnowledgeDocumentProcessing
+	doc.Error = ""
+	doc.EmbeddingModel = ""
+	if err := a.DB.Model(doc).Updates(map[string]any{"status": doc.Status, "error": "", "embedding_model": ""}).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update document", nil, "")
+	}
+	a.startKnowledgeEmbedding(*doc, embedder, settings)
+
+	return r.SendEnvelope(knowledgeDocumentToResponse(*doc))
+}
+
+// SearchKnowledgeBase returns the passages the AI would get for a message, to test the knowledge base
+func (a *App) SearchKnowledgeBase(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceChatbotAI, models.ActionRead); err != nil {
+		return nil
+	}
+
+	var req struct {
+		Query           string `json:"query"`
+		WhatsAppAccount string `json:"whatsapp_account"`
+		Limit           int    `json:"limit"`
+	}
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Query is required", nil, "")
+	}
+	if req.Limit <= 0 || req.Limit > maxKnowledgeChunks {
+		req.Limit = defaultKnowledgeChunks
+	}
+
+	settings, err := a.getChatbotSettingsCached(orgID, req.WhatsAppAccount)
+	if err != nil {
+		settings = &models.ChatbotSettings{OrganizationID: orgID}
+	}
+	matches, err := a.searchKnowledgeBase(settings, req.WhatsAppAccount, req.Query, req.Limit)
+	if err != nil {
+		a.Log.Error("Knowledge base search failed", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to search knowledge base", nil, "")
+	}
+	if matches == nil {
+		matches = []knowledgeMatch{}
+	}
+
+	return r.SendEnvelope(map[string]any{"results": matches})
+}
+
+// formValue returns the first value of a multipart form field
+func formValue(values map[string][]string, key string) string {
+	if v := values[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// knowledgeEmbedder returns the provider that embeds knowledge base text, or nil if the
+// settings have no embedding model or their provider has no embeddings API
+func (a *App) knowledgeEmbedder(settings *models.ChatbotSettings) llm.Embedder {
+	if settings == nil || settings.AI.EmbeddingModel == "" {
+		return nil
+	}
+	provider, err := a.newAIProvider(settings)
+	if err != nil {
+		return nil
+	}
+	embedder, _ := provider.(llm.Embedder)
+	return embedder
+}
+
+// startKnowledgeEmbedding embeds the chunks of a document in the background
+func (a *App) startKnowledgeEmbedding(doc models.KnowledgeDocument, embedder llm.Embedder, settings *models.ChatbotSettings) {
+	model := settings.AI.EmbeddingModel
+	timeout := aiRequestTimeout(settings)
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		if err := a.embedKnowledgeDocument(doc.ID, embedder, model, timeout); err != nil {
+			a.Log.Error("Failed to embed knowledge base document", "document_id", doc.ID, "error", err)
+			a.DB.Model(&models.KnowledgeDocument{}).Where("id = ?", doc.ID).
+				Updates(map[string]any{"status": models.KnowledgeDocumentFailed, "error": err.Error()})
+			return
+		}
+		a.DB.Model(&models.KnowledgeDocument{}).Where("id = ?", doc.ID).
+			Updates(map[string]any{"status": models.KnowledgeDocumentReady, "error": "", "embedding_model": model})
+	}()
+}
+
+// embedKnowledgeDocument stores the embeddings of a document's chunks
+func (a *App) embedKnowledgeDocument(docID uuid.UUID, embedder llm.Embedder, model string, timeout time.Duration) error {
+	var chunks []models.KnowledgeChunk
+	if err := a.DB.Select("id", "content").Where("document_id = ?", docID).Order("chunk_index ASC").Find(&chunks).Error; err != nil {
+		return err
+	}
+
+	for start := 0; start < len(chunks); start += knowledgeEmbedBatchSize {
+		batch := chunks[start:min(start+knowledgeEmbedBatchSize, len(chunks))]
+		texts := make([]string, len(batch))
+		for i, chunk := range batch {
+			texts[i] = chunk.Content
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		vectors, err := embedder.Embed(ctx, model, texts)
+		cancel()
+		if err != nil {
+			return err
+		}
+		for i, chunk := range batch {
+			if err := a.DB.Model(&models.KnowledgeChunk{}).Where("id = ?", chunk.ID).
+				Update("embedding", models.FloatArray(vectors[i])).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// knowledgeBaseContext returns the knowledge base passages that match a user's message as a
+// context section, and logs their sources in the session
+func (a *App) knowledgeBaseContext(settings *models.ChatbotSettings, session *models.ChatbotSession, userMessage string) string {
+	whatsAppAccount := ""
+	if session != nil {
+		whatsAppAccount = session.WhatsAppAccount
+	}
+	limit := settings.AI.KnowledgeChunks
+	if limit <= 0 {
+		limit = defaultKnowledgeChunks
+	}
+
+	matches, err := a.searchKnowledgeBase(settings, whatsAppAccount, userMessage, limit)
+	if err != nil {
+		a.Log.Error("Knowledge base search failed", "error", err)
+		return ""
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+
+	passages := make([]string, len(matches))
+	sources := make([]string, len(matches))
+	for i, m := range matches {
+		source := fmt.Sprintf("%s, part %d", m.DocumentName, m.ChunkIndex+1)
+		passages[i] = fmt.Sprintf("[%s]\n%s", source, m.Content)
+		sources[i] = source
+	}
+	if session != nil {
+		a.logSessionMessage(session.ID, models.DirectionInternal, "Knowledge base sources: "+strings.Join(sources, "; "), knowledgeCitationStepName)
+	}
+
+	return "### Knowledge Base\nUse these passages when they answer the question. Each starts with its source.\n\n" + strings.Join(passages, "\n\n")
+}
+
+// searchKnowledgeBase finds the chunks that best match a query. Chunks are ranked by full-text
+// search and, when the settings have an embedding model, by similarity of their embeddings;
+// the two rankings are combined with reciprocal rank fusion.
+func (a *App) searchKnowledgeBase(settings *models.ChatbotSettings, whatsAppAccount, query string, limit int) ([]knowledgeMatch, error) {
+	var rankings [][]knowledgeMatch
+
+	if tsQuery := knowledgeSearchQuery(query); tsQuery != "" {
+		var matches []knowledgeMatch
+		err := a.knowledgeChunksQuery(settings.OrganizationID, whatsAppAccount).
+			Select("knowledge_chunks.id, knowledge_chunks.document_id, knowledge_documents.name AS document_name, "+
+				"knowledge_chunks.chunk_index, knowledge_chunks.content, "+
+				"ts_rank(to_tsvector('"+knowledgeSearchConfig+"', knowledge_chunks.content), to_tsquery('"+knowledgeSearchConfig+"', ?)) AS score", tsQuery).
+			Where("to_tsvector('"+knowledgeSearchConfig+"', knowledge_chunks.content) @@ to_tsquery('"+knowledgeSearchConfig+"', ?)", tsQuery).
+			Order("score DESC").
+			Limit(knowledgeCandidates).
+			Scan(&matches).Error
+		if err != nil {
+			return nil, err
+		}
+		rankings = append(rankings, matches)
+	}
+
+	if embedder := a.knowledgeEmbedder(settings); embedder != nil {
+		matches, err := a.searchKnowledgeEmbeddings(settings, embedder, whatsAppAccount, query)
+		if err != nil {
+			// Keyword results are still useful
+			a.Log.Error("Knowledge base semantic search failed", "error", err)
+		} else {
+			rankings = append(rankings, matches)
+		}
+	}
+
+	return fuseKnowledgeRankings(rankings, limit), nil
+}
+
+// searchKnowledgeEmbeddings ranks the chunks embedded with the settings' model by cosine
+// similarity to the query. Vectors are compared in Go, so this reads every embedded chunk
+// the session can see.
+func (a *App) searchKnowledgeEmbeddings(settings *models.ChatbotSettings, embedder llm.Embedder, whatsAppAccount, query string) ([]knowledgeMatch, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), aiRequestTimeout(settings))
+	defer cancel()
+	vectors, err := embedder.Embed(ctx, settings.AI.EmbeddingModel, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, errors.New("no embedding returned for the query")
+	}
+
+	var rows []struct {
+		knowledgeMatch
+		Embedding models.FloatArray
+	}
+	err = a.knowledgeChunksQuery(settings.OrganizationID, whatsAppAccount).
+		Select("knowledge_chunks.id, knowledge_chunks.document_id, knowledge_documents.name AS document_name, "+
+			"knowledge_chunks.chunk_index, knowledge_chunks.content, knowledge_chunks.embedding").
+		Where("knowledge_documents.embedding_model = ? AND knowledge_chunks.embedding IS NOT NULL", settings.AI.EmbeddingModel).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]knowledgeMatch, 0, len(rows))
+	for _, row := range rows {
+		m := row.knowledgeMatch
+		m.Score = cosineSimilarity(vectors[0], row.Embedding)
+		matches = append(matches, m)
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > knowledgeCandidates {
+		matches = matches[:knowledgeCandidates]
+	}
+	return matches, nil
+}
+
+// knowledgeChunksQuery selects the chunks of the enabled documents an account can use
+func (a *App) knowledgeChunksQuery(orgID uuid.UUID, whatsAppAccount string) *gorm.DB {
+	return a.DB.Table("knowledge_chunks").
+		Joins("JOIN knowledge_documents ON knowledge_documents.id = knowledge_chunks.document_id").
+		Where("knowledge_chunks.organization_id = ? AND knowledge_chunks.deleted_at IS NULL", orgID).
+		Where("knowledge_documents.is_enabled = ? AND knowledge_documents.deleted_at IS NULL", true).
+		Where("(knowledge_documents.whats_app_account = '' OR knowledge_documents.whats_app_account = ?)", whatsAppAccount)
+}
+
+var knowledgeQueryTerm = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// knowledgeSearchQuery turns a message into a tsquery matching any of its words, so a
+// question finds passages that share some of its terms. Postgres drops stop words.
+func knowledgeSearchQuery(message string) string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, term := range knowledgeQueryTerm.FindAllString(strings.ToLower(message), -1) {
+		if len([]rune(term)) < 2 || seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == knowledgeMaxQueryTerms {
+			break
+		}
+	}
+	return strings.Join(terms, " | ")
+}
+
+// fuseKnowledgeRankings combines rankings of chunks with reciprocal rank fusion: a chunk
+// scores 1/(k+rank) in each ranking it appears in. The score of the result is the fused score.
+func fuseKnowledgeRankings(rankings [][]knowledgeMatch, limit int) []knowledgeMatch {
+	scores := map[uuid.UUID]float64{}
+	byID := map[uuid.UUID]knowledgeMatch{}
+	var order []uuid.UUID
+	for _, ranking := range rankings {
+		for rank, m := range ranking {
+			if _, ok := byID[m.ID]; !ok {
+				byID[m.ID] = m
+				order = append(order, m.ID)
+			}
+			scores[m.ID] += 1 / float64(knowledgeRRFConstant+rank+1)
+		}
+	}
+
+	fused := make([]knowledgeMatch, 0, len(order))
+	for _, id := range order {
+		m := byID[id]
+		m.Score = scores[id]
+		fused = append(fused, m)
+	}
+	sort.SliceStable(fused, func(i, j int) bool { return fused[i].Score > fused[j].Score })
+	if len(fused) > limit {
+		fused = fused[:limit]
+	}
+	return fused
+}
+
+// cosineSimilarity returns the cosine of the angle between two vectors, or 0 if they can't be compared
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKnowledgeSearchQuery(t *testing.T) {
+	assert.Equal(t, "how | do | return | damaged | item", knowledgeSearchQuery("How do I return a damaged item?? How?"))
+	assert.Equal(t, "envío | en | días", knowledgeSearchQuery("¿Envío en 3 días?"))
+	assert.Equal(t, "order | 1234", knowledgeSearchQuery("order #1234 & | !"))
+	assert.Empty(t, knowledgeSearchQuery("?! a"))
+}
+
+func TestFuseKnowledgeRankings(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	keyword := []knowledgeMatch{{ID: a, Content: "a"}, {ID: b, Content: "b"}}
+	semantic := []knowledgeMatch{{ID: c, Content: "c"}, {ID: b, Content: "b"}}
+
+	fused := fuseKnowledgeRankings([][]knowledgeMatch{keyword, semantic}, 2)
+	assert.Len(t, fused, 2)
+	assert.Equal(t, b, fused[0].ID, "a chunk found by both searches ranks first")
+	assert.Equal(t, a, fused[1].ID, "ties keep the order of the first ranking")
+	assert.InDelta(t, 2.0/62, fused[0].Score, 1e-9)
+
+	assert.Empty(t, fuseKnowledgeRankings(nil, 4))
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	assert.InDelta(t, 1, cosineSimilarity([]float32{1, 2}, []float32{2, 4}), 1e-9)
+	assert.InDelta(t, 0, cosineSimilarity([]float32{1, 0}, []float32{0, 1}), 1e-9)
+	assert.Equal(t, 0.0, cosineSimilarity([]float32{1, 0}, []float32{1}))
+	assert.Equal(t, 0.0, cosineSimilarity([]float32{0, 0}, []float32{1, 1}))
+}
@@ -0,0 +1,100 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// newKnowledgeUploadRequest creates a multipart request uploading a file to the knowledge base
+func newKnowledgeUploadRequest(t *testing.T, filename, content string, fields map[string]string) *fastglue.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for k, v := range fields {
+		require.NoError(t, writer.WriteField(k, v))
+	}
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := testutil.NewRequest(t)
+	req.RequestCtx.Request.Header.SetMethod("POST")
+	req.RequestCtx.Request.Header.SetContentType(writer.FormDataContentType())
+	req.RequestCtx.Request.SetBody(body.Bytes())
+	return req
+}
+
+func TestApp_KnowledgeBase(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+
+	faq := "# Returns\n\nRefunds are issued within 5 business days after we receive the item.\n\n# Shipping\n\nWe ship to 20 countries."
+	req := newKnowledgeUploadRequest(t, "faq.md", faq, map[string]string{"name": "Support FAQ"})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	require.NoError(t, app.UploadKnowledgeDocument(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req), string(testutil.GetResponseBody(req)))
+
+	var uploadResp struct {
+		Data handlers.KnowledgeDocumentResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &uploadResp))
+	doc := uploadResp.Data
+	assert.Equal(t, "Support FAQ", doc.Name)
+	assert.Equal(t, "markdown", doc.SourceType)
+	assert.Equal(t, models.KnowledgeDocumentReady, doc.Status)
+	assert.Equal(t, 1, doc.ChunkCount)
+
+	t.Run("search finds the passage", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, map[string]any{"query": "how long do refunds take?"})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.SearchKnowledgeBase(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data struct {
+				Results []struct {
+					DocumentName string `json:"document_name"`
+					Content      string `json:"content"`
+				} `json:"results"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		require.Len(t, resp.Data.Results, 1)
+		assert.Equal(t, "Support FAQ", resp.Data.Results[0].DocumentName)
+		assert.Contains(t, resp.Data.Results[0].Content, "Refunds are issued")
+	})
+
+	t.Run("unsupported file type", func(t *testing.T) {
+		req := newKnowledgeUploadRequest(t, "faq.docx", "binary", nil)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		require.NoError(t, app.UploadKnowledgeDocument(req))
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("delete removes the chunks", func(t *testing.T) {
+		req := testutil.NewRequest(t)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", doc.ID)
+		require.NoError(t, app.DeleteKnowledgeDocument(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var count int64
+		app.DB.Model(&models.KnowledgeChunk{}).Where("document_id = ?", doc.ID).Count(&count)
+		assert.Zero(t, count)
+	})
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/shridarpatil/whatomate/internal/language"
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// contactLanguage returns the language chatbot replies to contact are sent in,
+// or "" to send the untranslated content
+func contactLanguage(contact *models.Contact) string {
+	if contact == nil {
+		return ""
+	}
+	return contact.Language
+}
+
+// translationFields returns the translated fields for lang from content
+// translations keyed by language code, falling back to the same base language
+func translationFields(translations models.JSONB, lang string) map[string]interface{} {
+	if len(translations) == 0 || lang == "" {
+		return nil
+	}
+	codes := make([]string, 0, len(translations))
+	for code := range translations {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	i := language.Match(lang, codes)
+	if i < 0 {
+		return nil
+	}
+	fields, _ := translations[codes[i]].(map[string]interface{})
+	return fields
+}
+
+// localized returns the translation of a content field in lang, or text if it
+// hasn't been translated
+func localized(translations models.JSONB, lang, field, text string) string {
+	if s, ok := translationFields(translations, lang)[field].(string); ok && strings.TrimSpace(s) != "" {
+		return s
+	}
+	return text
+}
+
+// localizedButtons returns copies of buttons with their titles translated in
+// lang. Button titles are translated by button ID under field.
+func localizedButtons(translations models.JSONB, lang, field string, buttons []map[string]interface{}) []map[string]interface{} {
+	titles, _ := translationFields(translations, lang)[field].(map[string]interface{})
+	if len(titles) == 0 {
+		return buttons
+	}
+	result := make([]map[string]interface{}, len(buttons))
+	for i, btn := range buttons {
+		id, _ := btn["id"].(string)
+		title, ok := titles[id].(string)
+		if !ok || strings.TrimSpace(title) == "" {
+			result[i] = btn
+			continue
+		}
+		copied := make(map[string]interface{}, len(btn))
+		for k, v := range btn {
+			copied[k] = v
+		}
+		copied["title"] = title
+		result[i] = copied
+	}
+	return result
+}
+
+// buttonMaps returns the buttons of a JSONB array that are objects
+func buttonMaps(buttons models.JSONBArray) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(buttons))
+	for _, btn := range buttons {
+		if btnMap, ok := btn.(map[string]interface{}); ok {
+			result = append(result, btnMap)
+		}
+	}
+	return result
+}
+
+// localizeKeywordResponse returns the keyword response translated in lang
+func localizeKeywordResponse(response *KeywordResponse, lang string) *KeywordResponse {
+	if translationFields(response.Translations, lang) == nil {
+		return response
+	}
+	localizedResponse := *response
+	localizedResponse.Body = localized(response.Translations, lang, "body", response.Body)
+	localizedResponse.Buttons = localizedButtons(response.Translations, lang, "buttons", response.Buttons)
+	return &localizedResponse
+}
+
+// localizeStep returns a copy of a flow step with its message, validation
+// error and button titles translated in lang
+func localizeStep(step *models.ChatbotFlowStep, lang string) *models.ChatbotFlowStep {
+	if translationFields(step.Translations, lang) == nil {
+		return step
+	}
+	localizedStep := *step
+	localizedStep.Message = localized(step.Translations, lang, "message", step.Message)
+	localizedStep.ValidationError = localized(step.Translations, lang, "validation_error", step.ValidationError)
+	if len(step.Buttons) > 0 {
+		buttons := localizedButtons(step.Translations, lang, "buttons", buttonMaps(step.Buttons))
+		localizedStep.Buttons = make(models.JSONBArray, len(buttons))
+		for i, btn := range buttons {
+			localizedStep.Buttons[i] = btn
+		}
+	}
+	return &localizedStep
+}
+
+// chatbotLanguages returns the default language followed by the other
+// languages the chatbot content is translated into
+func chatbotLanguages(settings *models.ChatbotSettings) []string {
+	languages := []string{settings.Language.DefaultLanguage}
+	if languages[0] == "" {
+		languages[0] = "en"
+	}
+	for _, code := range settings.Language.Languages {
+		if code = strings.TrimSpace(code); code != "" && language.Match(code, languages) < 0 {
+			languages = append(languages, code)
+		}
+	}
+	return languages
+}
+
+// detectContactLanguage sets the contact's language from their message when
+// detection is enabled. A language chosen in a flow or by an agent is kept.
+func (a *App) detectContactLanguage(settings *models.ChatbotSettings, contact *models.Contact, text string) {
+	if !settings.Language.DetectLanguage || strings.TrimSpace(text) == "" {
+		return
+	}
+	if contact.LanguageSource == models.LanguageSourceFlow || contact.LanguageSource == models.LanguageSourceManual {
+		return
+	}
+
+	detected := language.Detect(text, chatbotLanguages(settings))
+	if detected == "" || detected == contact.Language {
+		return
+	}
+	if err := a.DB.Model(contact).Updates(map[string]interface{}{
+		"language":        detected,
+		"language_source": models.LanguageSourceDetected,
+	}).Error; err != nil {
+		a.Log.Error("Failed to update contact language", "error", err, "contact_id", contact.ID)
+		return
+	}
+	a.Log.Info("Detected contact language", "contact_id", contact.ID, "language", detected)
+	contact.Language = detected
+	contact.LanguageSource = models.LanguageSourceDetected
+}
+
+// Content fields that can be translated, by the API field names of their owner
+var (
+	settingsTranslationFields = []string{
+		"greeting_message", "fallback_message", "out_of_hours_message",
+		"sla_auto_close_message", "sla_warning_message",
+		"client_reminder_message", "client_auto_close_message",
+		"opt_out_message", "opt_in_message",
+		"csat_question", "csat_thank_you_message",
+	}
+	settingsTranslationButtonFields = []string{"greeting_buttons", "fallback_buttons"}
+	keywordTranslationFields        = []string{"body"}
+	flowTranslationFields           = []string{"initial_message", "completion_message"}
+	stepTranslationFields           = []string{"message", "validation_error"}
+	translationButtonFields         = []string{"buttons"}
+)
+
+// validLanguageCode matches codes such as "hi", "en_US" or "pt-BR"
+var validLanguageCode = regexp.MustCompile(`^[A-Za-z]{2,3}([_-][A-Za-z0-9]{2,8})?$`)
+
+// normalizeLanguages validates language codes, dropping blanks and duplicates
+func normalizeLanguages(codes []string) ([]string, error) {
+	result := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		if code == "" {
+			continue
+		}
+		if !validLanguageCode.MatchString(code) {
+			return nil, fmt.Errorf("invalid language code %q", code)
+		}
+		if !slices.Contains(result, code) {
+			result = append(result, code)
+		}
+	}
+	return result, nil
+}
+
+// normalizeTranslations validates content translations keyed by language code
+// then field. Text fields hold strings and button fields hold titles keyed by
+// button ID. Blank translations are dropped so the original text is used.
+func normalizeTranslations(raw map[string]interface{}, fields, buttonFields []string) (models.JSONB, error) {
+	result := models.JSONB{}
+	for code, value := range raw {
+		if !validLanguageCode.MatchString(code) {
+			return nil, fmt.Errorf("invalid language code %q", code)
+		}
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("translations for %q must be an object", code)
+		}
+
+		translated := map[string]interface{}{}
+		for field, v := range entries {
+			switch {
+			case slices.Contains(fields, field):
+				text, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("translation of %s in %q must be a string", field, code)
+				}
+				if strings.TrimSpace(text) != "" {
+					translated[field] = text
+				}
+			case slices.Contains(buttonFields, field):
+				titles, ok := v.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("translation of %s in %q must map button IDs to titles", field, code)
+				}
+				kept := map[string]interface{}{}
+				for id, t := range titles {
+					title, ok := t.(string)
+					if !ok {
+						return nil, fmt.Errorf("translation of %s in %q must map button IDs to titles", field, code)
+					}
+					if strings.TrimSpace(title) != "" {
+						kept[id] = title
+					}
+				}
+				if len(kept) > 0 {
+					translated[field] = kept
+				}
+			default:
+				return nil, fmt.Errorf("%s cannot be translated", field)
+			}
+		}
+		if len(translated) > 0 {
+			result[code] = translated
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalized(t *testing.T) {
+	translations := models.JSONB{
+		"hi":    map[string]interface{}{"greeting_message": "नमस्ते!", "fallback_message": "  "},
+		"mr_IN": map[string]interface{}{"greeting_message": "नमस्कार!"},
+	}
+
+	assert.Equal(t, "नमस्ते!", localized(translations, "hi", "greeting_message", "Hello!"))
+	assert.Equal(t, "नमस्ते!", localized(translations, "hi_IN", "greeting_message", "Hello!"), "same base language")
+	assert.Equal(t, "नमस्कार!", localized(translations, "mr", "greeting_message", "Hello!"))
+	assert.Equal(t, "Sorry?", localized(translations, "hi", "fallback_message", "Sorry?"), "blank translation")
+	assert.Equal(t, "Hello!", localized(translations, "ta", "greeting_message", "Hello!"), "untranslated language")
+	assert.Equal(t, "Hello!", localized(translations, "", "greeting_message", "Hello!"))
+	assert.Equal(t, "Hello!", localized(nil, "hi", "greeting_message", "Hello!"))
+}
+
+func TestLocalizedButtons(t *testing.T) {
+	translations := models.JSONB{
+		"hi": map[string]interface{}{"buttons": map[string]interface{}{"yes": "हाँ"}},
+	}
+	buttons := []map[string]interface{}{
+		{"id": "yes", "title": "Yes"},
+		{"id": "no", "title": "No"},
+	}
+
+	result := localizedButtons(translations, "hi", "buttons", buttons)
+	assert.Equal(t, "हाँ", result[0]["title"])
+	assert.Equal(t, "yes", result[0]["id"])
+	assert.Equal(t, "No", result[1]["title"], "untranslated button keeps its title")
+	assert.Equal(t, "Yes", buttons[0]["title"], "original buttons are unchanged")
+
+	assert.Equal(t, buttons, localizedButtons(translations, "en", "buttons", buttons))
+}
+
+func TestLocalizeStep(t *testing.T) {
+	step := &models.ChatbotFlowStep{
+		StepName:        "confirm",
+		Message:         "Confirm your order?",
+		ValidationError: "Please pick an option",
+		Buttons: models.JSONBArray{
+			map[string]interface{}{"id": "yes", "title": "Yes"},
+		},
+		Translations: models.JSONB{
+			"hi": map[string]interface{}{
+				"message": "क्या आप ऑर्डर की पुष्टि करते हैं?",
+				"buttons": map[string]interface{}{"yes": "हाँ"},
+			},
+		},
+	}
+
+	localizedStep := localizeStep(step, "hi")
+	assert.Equal(t, "क्या आप ऑर्डर की पुष्टि करते हैं?", localizedStep.Message)
+	assert.Equal(t, "Please pick an option", localizedStep.ValidationError)
+	assert.Equal(t, "हाँ", localizedStep.Buttons[0].(map[string]interface{})["title"])
+	assert.Equal(t, "Confirm your order?", step.Message, "original step is unchanged")
+
+	matchedID, ok := matchStepButton(localizedStep, "हाँ", "")
+	assert.True(t, ok, "typed translated title matches the button")
+	assert.Equal(t, "yes", matchedID)
+
+	assert.Same(t, step, localizeStep(step, "mr"))
+}
+
+func TestLocalizeKeywordResponse(t *testing.T) {
+	response := &KeywordResponse{
+		Body:         "Our hours are 9 to 5",
+		ResponseType: models.ResponseTypeText,
+		Translations: models.JSONB{"mr": map[string]interface{}{"body": "आमची वेळ ९ ते ५ आहे"}},
+	}
+	assert.Equal(t, "आमची वेळ ९ ते ५ आहे", localizeKeywordResponse(response, "mr").Body)
+	assert.Equal(t, "Our hours are 9 to 5", response.Body)
+	assert.Same(t, response, localizeKeywordResponse(response, "hi"))
+}
+
+func TestNormalizeTranslations(t *testing.T) {
+	result, err := normalizeTranslations(map[string]interface{}{
+		"hi": map[string]interface{}{
+			"message": "नमस्ते",
+			"buttons": map[string]interface{}{"a": "हाँ", "b": ""},
+		},
+		"mr": map[string]interface{}{"message": " "},
+	}, stepTranslationFields, translationButtonFields)
+	require.NoError(t, err)
+	assert.Equal(t, models.JSONB{
+		"hi": map[string]interface{}{
+			"message": "नमस्ते",
+			"buttons": map[string]interface{}{"a": "हाँ"},
+		},
+	}, result, "blank translations are dropped")
+
+	invalid := []map[string]interface{}{
+		{"hindi language": map[string]interface{}{"message": "x"}},
+		{"hi": "नमस्ते"},
+		{"hi": map[string]interface{}{"store_as": "x"}},
+		{"hi": map[string]interface{}{"message": 1}},
+		{"hi": map[string]interface{}{"buttons": []interface{}{"हाँ"}}},
+	}
+	for _, raw := range invalid {
+		_, err := normalizeTranslations(raw, stepTranslationFields, translationButtonFields)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestNormalizeLanguages(t *testing.T) {
+	languages, err := normalizeLanguages([]string{"hi", " mr ", "", "hi", "en_US"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hi", "mr", "en_US"}, languages)
+
+	_, err = normalizeLanguages([]string{"Hindi!"})
+	assert.Error(t, err)
+}
+
+func TestChatbotLanguages(t *testing.T) {
+	settings := &models.ChatbotSettings{Language: models.LanguageConfig{Languages: models.StringArray{"hi", "en_US", "mr"}}}
+	assert.Equal(t, []string{"en", "hi", "mr"}, chatbotLanguages(settings))
+}
+
+func TestDetectContactLanguage(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	settings := &models.ChatbotSettings{Language: models.LanguageConfig{
+		DefaultLanguage: "en",
+		Languages:       models.StringArray{"hi", "mr"},
+		DetectLanguage:  true,
+	}}
+
+	app.detectContactLanguage(settings, contact, "मला मदत पाहिजे")
+	assert.Equal(t, "mr", contact.Language)
+	var dbContact models.Contact
+	require.NoError(t, app.DB.First(&dbContact, contact.ID).Error)
+	assert.Equal(t, "mr", dbContact.Language)
+	assert.Equal(t, models.LanguageSourceDetected, dbContact.LanguageSource)
+
+	// Unclear messages keep the detected language
+	app.detectContactLanguage(settings, contact, "ok")
+	assert.Equal(t, "mr", contact.Language)
+
+	// A language chosen in a flow isn't overwritten
+	contact.LanguageSource = models.LanguageSourceFlow
+	app.detectContactLanguage(settings, contact, "Where is my order?")
+	assert.Equal(t, "mr", contact.Language)
+
+	settings.Language.DetectLanguage = false
+	contact.LanguageSource = models.LanguageSourceDetected
+	app.detectContactLanguage(settings, contact, "Where is my order?")
+	assert.Equal(t, "mr", contact.Language, "detection is off")
+}
@@ -2,13 +2,14 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"path"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/storage"
 	"github.com/shridarpatil/whatomate/pkg/whatsapp"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
@@ -23,10 +24,27 @@ func (a *App) getMediaStoragePath() string {
 	return basePath
 }
 
-// ensureMediaDir ensures the media directory exists
-func (a *App) ensureMediaDir(subdir string) error {
-	path := filepath.Join(a.getMediaStoragePath(), subdir)
-	return os.MkdirAll(path, 0755)
+// mediaStorage returns the configured media storage backend, falling back to
+// local storage under the configured path when none has been set
+func (a *App) mediaStorage() storage.Storage {
+	if a.Storage != nil {
+		return a.Storage
+	}
+	return storage.NewLocal(a.getMediaStoragePath())
+}
+
+// getMediaSubdir returns the storage subdirectory for a MIME type
+func getMediaSubdir(mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return "images"
+	case strings.HasPrefix(mimeType, "video/"):
+		return "videos"
+	case strings.HasPrefix(mimeType, "audio/"):
+		return "audio"
+	default:
+		return "documents"
+	}
 }
 
 // getExtensionFromMimeType returns file extension based on mime type
@@ -75,8 +93,8 @@ func getExtensionFromMimeType(mimeType string) string {
 	}
 }
 
-// DownloadAndSaveMedia downloads media from Meta and saves it locally
-// Returns the local file path (relative to media storage) or error
+// DownloadAndSaveMedia downloads media from Meta and saves it to media storage
+// Returns the storage key (relative path) or error
 func (a *App) DownloadAndSaveMedia(ctx context.Context, mediaID string, mimeType string, account *whatsapp.Account) (string, error) {
 	// Get the media URL from Meta
 	mediaURL, err := a.WhatsApp.GetMediaURL(ctx, mediaID, account)
@@ -96,41 +114,41 @@ func (a *App) DownloadAndSaveMedia(ctx context.Context, mediaID string, mimeType
 		ext = ".bin"
 	}
 
-	// Generate unique filename
-	filename := uuid.New().String() + ext
-
-	// Determine subdirectory based on media type
-	var subdir string
-	switch {
-	case strings.HasPrefix(mimeType, "image/"):
-		subdir = "images"
-	case strings.HasPrefix(mimeType, "video/"):
-		subdir = "videos"
-	case strings.HasPrefix(mimeType, "audio/"):
-		subdir = "audio"
-	default:
-		subdir = "documents"
+	// Store under a unique filename in a subdirectory based on media type
+	key := path.Join(getMediaSubdir(mimeType), uuid.New().String()+ext)
+	if err := a.mediaStorage().Put(ctx, key, data, mimeType); err != nil {
+		return "", fmt.Errorf("failed to save media file: %w", err)
 	}
 
-	// Ensure directory exists
-	if err := a.ensureMediaDir(subdir); err != nil {
-		return "", fmt.Errorf("failed to create media directory: %w", err)
-	}
+	a.Log.Info("Media saved", "path", key, "size", len(data))
 
-	// Save file
-	filePath := filepath.Join(a.getMediaStoragePath(), subdir, filename)
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return "", fmt.Errorf("failed to save media file: %w", err)
+	return key, nil
+}
+
+// serveStoredMedia streams a media object from storage as the response body
+func (a *App) serveStoredMedia(r *fastglue.Request, key, contentType string) error {
+	body, size, err := a.mediaStorage().Get(r.RequestCtx, key)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			return r.SendErrorEnvelope(fasthttp.StatusNotFound, "File not found", nil, "")
+		case errors.Is(err, storage.ErrInvalidKey):
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid file path", nil, "")
+		default:
+			a.Log.Error("Failed to read media file", "path", key, "error", err)
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to read file", nil, "")
+		}
 	}
 
-	// Return relative path for storage in database
-	relativePath := filepath.Join(subdir, filename)
-	a.Log.Info("Media saved", "path", relativePath, "size", len(data))
+	r.RequestCtx.Response.Header.Set("Content-Type", contentType)
+	r.RequestCtx.Response.Header.Set("Cache-Control", "private, max-age=3600") // Cache for 1 hour, private
+	// fasthttp closes the body stream once the response is written
+	r.RequestCtx.SetBodyStream(body, int(size))
 
-	return relativePath, nil
+	return nil
 }
 
-// ServeMedia serves media files from local storage
+// ServeMedia serves media files from media storage
 // Only authorized users who have access to the message can view the media
 func (a *App) ServeMedia(r *fastglue.Request) error {
 	// Get auth context
@@ -176,35 +194,8 @@ func (a *App) ServeMedia(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "No media found", nil, "")
 	}
 
-	// Security: prevent directory traversal and symlink attacks
-	filePath := filepath.Clean(message.MediaURL)
-	baseDir, err := filepath.Abs(a.getMediaStoragePath())
-	if err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Storage configuration error", nil, "")
-	}
-	fullPath, err := filepath.Abs(filepath.Join(baseDir, filePath))
-	if err != nil || !strings.HasPrefix(fullPath, baseDir+string(os.PathSeparator)) {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid file path", nil, "")
-	}
-
-	// Reject symlinks
-	info, err := os.Lstat(fullPath)
-	if err != nil {
-		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "File not found", nil, "")
-	}
-	if info.Mode()&os.ModeSymlink != 0 {
-		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid file path", nil, "")
-	}
-
-	// Read file
-	data, err := os.ReadFile(fullPath)
-	if err != nil {
-		a.Log.Error("Failed to read media file", "path", fullPath, "error", err)
-		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to read file", nil, "")
-	}
-
 	// Determine content type from extension
-	ext := strings.ToLower(filepath.Ext(filePath))
+	ext := strings.ToLower(path.Ext(message.MediaURL))
 	contentType := "application/octet-stream"
 	switch ext {
 	case ".jpg", ".jpeg":
@@ -243,9 +234,6 @@ func (a *App) ServeMedia(r *fastglue.Request) error {
 		contentType = "text/plain"
 	}
 
-	r.RequestCtx.Response.Header.Set("Content-Type", contentType)
-	r.RequestCtx.Response.Header.Set("Cache-Control", "private, max-age=3600") // Cache for 1 hour, private
-	r.RequestCtx.SetBody(data)
-
-	return nil
+	// Security: directory traversal and symlinks are rejected by the storage backend
+	return a.serveStoredMedia(r, message.MediaURL, contentType)
 }
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/contactutil"
 	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/shridarpatil/whatomate/internal/templateutil"
 	"github.com/shridarpatil/whatomate/internal/websocket"
@@ -49,6 +52,9 @@ type OutgoingMessageRequest struct {
 	// Template messages
 	Template   *models.Template
 	BodyParams map[string]string // Parameter name -> value (supports both named and positional)
+	// HeaderMediaLink overrides the header of IMAGE/VIDEO/DOCUMENT templates with an external URL
+	HeaderMediaLink     string
+	HeaderMediaFilename string // Shown for DOCUMENT headers
 
 	// WhatsApp Flow messages
 	FlowID          string // Meta Flow ID
@@ -123,6 +129,18 @@ func SLASendOptions() MessageSendOptions {
 // SendOutgoingMessage is the unified method for sending all types of WhatsApp messages.
 // It handles: text, media (image/video/audio/document), interactive (buttons/list/cta_url), and template messages.
 func (a *App) SendOutgoingMessage(ctx context.Context, req OutgoingMessageRequest, opts MessageSendOptions) (*models.Message, error) {
+	// Contacts who opted out must not receive marketing templates
+	if req.Type == models.MessageTypeTemplate && isMarketingTemplate(req.Template) {
+		suppressed, err := contactutil.IsSuppressed(a.DB, req.Account.OrganizationID, req.Contact.PhoneNumber)
+		if err != nil {
+			a.Log.Error("Failed to check suppression list", "error", err)
+			return nil, fmt.Errorf("failed to check suppression list: %w", err)
+		}
+		if suppressed {
+			return nil, ErrContactSuppressed
+		}
+	}
+
 	// 1. Create message record
 	msg := a.createOutgoingMessage(req, opts)
 
@@ -180,6 +198,10 @@ func (a *App) SendOutgoingMessage(ctx context.Context, req OutgoingMessageReques
 			if req.Template == nil {
 				return "", fmt.Errorf("template is required for template messages")
 			}
+			if req.HeaderMediaLink != "" {
+				components := buildTemplateComponents(req.Template, req.BodyParams, req.HeaderMediaLink, req.HeaderMediaFilename)
+				return a.WhatsApp.SendTemplateMessageWithComponents(sendCtx, waAccount, req.Contact.PhoneNumber, req.Template.Name, req.Template.Language, components)
+			}
 			return a.WhatsApp.SendTemplateMessage(sendCtx, waAccount, req.Contact.PhoneNumber, req.Template.Name, req.Template.Language, req.BodyParams)
 
 		case models.MessageTypeFlow:
@@ -294,6 +316,53 @@ func (a *App) createOutgoingMessage(req OutgoingMessageRequest, opts MessageSend
 	return msg
 }
 
+// buildTemplateComponents builds header and body components for a template send
+// with a media header. Body parameters are ordered as they appear in the template.
+func buildTemplateComponents(template *models.Template, bodyParams map[string]string, headerLink, headerFilename string) []map[string]interface{} {
+	var components []map[string]interface{}
+
+	var mediaType string
+	switch template.HeaderType {
+	case "IMAGE":
+		mediaType = "image"
+	case "VIDEO":
+		mediaType = "video"
+	case "DOCUMENT":
+		mediaType = "document"
+	}
+	if mediaType != "" {
+		media := map[string]interface{}{"link": headerLink}
+		if mediaType == "document" && headerFilename != "" {
+			media["filename"] = headerFilename
+		}
+		components = append(components, map[string]interface{}{
+			"type": "header",
+			"parameters": []map[string]interface{}{
+				{"type": mediaType, mediaType: media},
+			},
+		})
+	}
+
+	paramNames := templateutil.ExtParamNames(template.BodyContent)
+	values := templateutil.ResolveParamsFromMap(paramNames, bodyParams)
+	if len(values) > 0 {
+		params := make([]map[string]interface{}, len(values))
+		for i, val := range values {
+			params[i] = map[string]interface{}{"type": "text", "text": val}
+			// Named parameters must carry their name
+			if _, err := strconv.Atoi(paramNames[i]); err != nil {
+				params[i]["parameter_name"] = paramNames[i]
+			}
+		}
+		components = append(components, map[string]interface{}{
+			"type":       "body",
+			"parameters": params,
+		})
+	}
+
+	return components
+}
+
 // buildInteractiveData creates the InteractiveData JSONB for interactive messages
 func (a *App) buildInteractiveData(req OutgoingMessageRequest) models.JSONB {
 	switch req.InteractiveType {
@@ -584,6 +653,11 @@ func (a *App) SendTemplateMessage(r *fastglue.Request) error {
 		contact = &c
 	}
 
+	// A template picked by name is sent in the contact's language when it has been translated
+	if req.TemplateID == "" {
+		template = *templateutil.LanguageVariant(a.DB, &template, contactLanguage(contact))
+	}
+
 	// Get WhatsApp account
 	var account models.WhatsAppAccount
 	if req.AccountName != "" {
@@ -640,6 +714,9 @@ func (a *App) SendTemplateMessage(r *fastglue.Request) error {
 
 	ctx := context.Background()
 	message, err := a.SendOutgoingMessage(ctx, msgReq, opts)
+	if errors.Is(err, ErrContactSuppressed) {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Contact has opted out of marketing messages", nil, "")
+	}
 	if err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to send template message", nil, "")
 	}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/contactutil"
 	"github.com/shridarpatil/whatomate/internal/handlers"
 	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/shridarpatil/whatomate/internal/templateutil"
@@ -584,6 +585,44 @@ func TestApp_SendOutgoingMessage_TemplateMessage(t *testing.T) {
 	assert.Equal(t, "en", templateData["language"].(map[string]interface{})["code"])
 }
 
+func TestApp_SendOutgoingMessage_TemplateMessage_Suppressed(t *testing.T) {
+	mockServer := newMockWhatsAppServer()
+	defer mockServer.close()
+
+	app := newMsgTestApp(t, mockServer)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := createTestAccount(t, app, org.ID)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+	_, err := contactutil.Suppress(app.DB, org.ID, "+"+contact.PhoneNumber, models.SuppressionSourceManual, "", nil)
+	require.NoError(t, err)
+
+	ctx := testutil.TestContext(t)
+	req := handlers.OutgoingMessageRequest{
+		Account:    account,
+		Contact:    contact,
+		Type:       models.MessageTypeTemplate,
+		Template:   template,
+		BodyParams: map[string]string{"1": "John"},
+	}
+
+	t.Run("marketing template is blocked", func(t *testing.T) {
+		msg, err := app.SendOutgoingMessage(ctx, req, handlers.ChatbotSendOptions())
+		assert.ErrorIs(t, err, handlers.ErrContactSuppressed)
+		assert.Nil(t, msg)
+		assert.Empty(t, mockServer.sentMessages)
+	})
+
+	t.Run("utility template is sent", func(t *testing.T) {
+		template.Category = string(models.TemplateCategoryUtility)
+		msg, err := app.SendOutgoingMessage(ctx, req, handlers.ChatbotSendOptions())
+		require.NoError(t, err)
+		require.NotNil(t, msg)
+		assert.Len(t, mockServer.sentMessages, 1)
+	})
+}
+
 func TestApp_SendOutgoingMessage_TemplateMessage_MissingTemplate(t *testing.T) {
 	mockServer := newMockWhatsAppServer()
 	defer mockServer.close()
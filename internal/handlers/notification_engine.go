@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/contactutil"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"github.com/valyala/fasthttp"
+)
+
+// Notification rule trigger types
+const (
+	NotificationTriggerWebhook   = "webhook"
+	NotificationTriggerAPI       = "api"
+	NotificationTriggerScheduler = "scheduler"
+)
+
+// defaultNotificationPhoneField is the payload path used when trigger_config.phone_field is unset
+const defaultNotificationPhoneField = "phone"
+
+// NotificationRuleResult describes the outcome of firing a notification rule
+type NotificationRuleResult struct {
+	RuleID      uuid.UUID  `json:"rule_id"`
+	Status      string     `json:"status"` // sent, skipped
+	Reason      string     `json:"reason,omitempty"`
+	MessageID   *uuid.UUID `json:"message_id,omitempty"`
+	ContactID   *uuid.UUID `json:"contact_id,omitempty"`
+	PhoneNumber string     `json:"phone_number,omitempty"`
+}
+
+// notificationRuleError is returned by the engine for failures that map to an HTTP status
+type notificationRuleError struct {
+	Status  int
+	Message string
+}
+
+func (e *notificationRuleError) Error() string {
+	return e.Message
+}
+
+func newNotificationRuleError(status int, format string, args ...any) error {
+	return &notificationRuleError{Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// ExecuteNotificationRule evaluates a rule against an inbound payload and, if its
+// conditions match, sends the rule's template to the phone number in the payload.
+//
+// Payload values are referenced by dot paths ("order.id", "items[0].sku"):
+//   - trigger_config.phone_field: path to the recipient phone (default "phone")
+//   - trigger_config.name_field: optional path to the contact name
+//   - conditions: {"match": "all"|"any", "rules": ["order.status == 'shipped'"]}
+//   - field_mappings: template param -> path or template, e.g. {"order_id": "order.id"}
+//   - attachment_config: {"url": "{{invoice.url}}", "filename": "Invoice {{order.id}}.pdf"}
+func (a *App) ExecuteNotificationRule(ctx context.Context, rule *models.NotificationRule, payload map[string]interface{}) (*NotificationRuleResult, error) {
+	result := &NotificationRuleResult{RuleID: rule.ID}
+
+	if !rule.IsEnabled {
+		return nil, newNotificationRuleError(fasthttp.StatusBadRequest, "Notification rule is disabled")
+	}
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+
+	// Conditions
+	if !evaluateNotificationConditions(rule.Conditions, payload) {
+		result.Status = "skipped"
+		result.Reason = "conditions not met"
+		return result, nil
+	}
+
+	// Recipient
+	phoneField := getStringFromMap(rule.TriggerConfig, "phone_field")
+	if phoneField == "" {
+		phoneField = defaultNotificationPhoneField
+	}
+	phone := strings.TrimSpace(formatValue(getNestedValue(payload, phoneField)))
+	if phone == "" {
+		return nil, newNotificationRuleError(fasthttp.StatusBadRequest, "Payload is missing recipient phone at '%s'", phoneField)
+	}
+	var contactName string
+	if nameField := getStringFromMap(rule.TriggerConfig, "name_field"); nameField != "" {
+		contactName = formatValue(getNestedValue(payload, nameField))
+	}
+
+	// Template
+	var template models.Template
+	if err := a.DB.Where("id = ? AND organization_id = ?", rule.TemplateID, rule.OrganizationID).First(&template).Error; err != nil {
+		return nil, newNotificationRuleError(fasthttp.StatusBadRequest, "Template not found")
+	}
+	if template.Status != "APPROVED" {
+		return nil, newNotificationRuleError(fasthttp.StatusBadRequest, "Template is not approved (status: %s)", template.Status)
+	}
+
+	// WhatsApp account
+	var account models.WhatsAppAccount
+	if err := a.DB.Where("name = ? AND organization_id = ?", rule.WhatsAppAccount, rule.OrganizationID).First(&account).Error; err != nil {
+		return nil, newNotificationRuleError(fasthttp.StatusBadRequest, "WhatsApp account not found")
+	}
+
+	// Map payload fields onto template parameters
+	bodyParams := mapNotificationFields(rule.FieldMappings, payload)
+	paramNames := templateutil.ExtParamNames(template.BodyContent)
+	if len(paramNames) > 0 {
+		resolved := templateutil.ResolveParamsFromMap(paramNames, bodyParams)
+		var missing []string
+		for i, name := range paramNames {
+			if i >= len(resolved) || resolved[i] == "" {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, newNotificationRuleError(fasthttp.StatusBadRequest, "Missing template parameters: %s", strings.Join(missing, ", "))
+		}
+	}
+
+	msgReq := OutgoingMessageRequest{
+		Account:    &account,
+		Type:       models.MessageTypeTemplate,
+		Template:   &template,
+		BodyParams: bodyParams,
+	}
+
+	// Optional header attachment for media templates
+	if len(rule.AttachmentConfig) > 0 {
+		if template.HeaderType == "" || template.HeaderType == "TEXT" {
+			return nil, newNotificationRuleError(fasthttp.StatusBadRequest, "Attachments require a template with an IMAGE, VIDEO or DOCUMENT header")
+		}
+		link := strings.TrimSpace(renderNotificationValue(getStringFromMap(rule.AttachmentConfig, "url"), payload))
+		if link == "" {
+			return nil, newNotificationRuleError(fasthttp.StatusBadRequest, "Attachment URL resolved to an empty value")
+		}
+		if err := validateWebhookURL(link); err != nil {
+			return nil, newNotificationRuleError(fasthttp.StatusBadRequest, "Invalid attachment URL: %s", err.Error())
+		}
+		msgReq.HeaderMediaLink = link
+		msgReq.HeaderMediaFilename = renderNotificationValue(getStringFromMap(rule.AttachmentConfig, "filename"), payload)
+	}
+
+	contact, _, err := contactutil.GetOrCreateContact(a.DB, rule.OrganizationID, phone, contactName)
+	if err != nil {
+		a.Log.Error("Failed to get or create contact for notification", "error", err, "rule_id", rule.ID, "phone", phone)
+		return nil, newNotificationRuleError(fasthttp.StatusInternalServerError, "Failed to resolve contact")
+	}
+	msgReq.Contact = contact
+	msgReq.Template = templateutil.LanguageVariant(a.DB, msgReq.Template, contactLanguage(contact))
+
+	message, err := a.SendOutgoingMessage(ctx, msgReq, APISendOptions())
+	if errors.Is(err, ErrContactSuppressed) {
+		result.Status = "skipped"
+		result.Reason = "contact opted out"
+		result.ContactID = &contact.ID
+		result.PhoneNumber = contact.PhoneNumber
+		return result, nil
+	}
+	if err != nil {
+		a.Log.Error("Failed to send notification", "error", err, "rule_id", rule.ID)
+		return nil, newNotificationRuleError(fasthttp.StatusInternalServerError, "Failed to send notification")
+	}
+
+	a.Log.Info("Notification rule fired", "rule_id", rule.ID, "message_id", message.ID, "contact_id", contact.ID)
+
+	result.Status = "sent"
+	result.MessageID = &message.ID
+	result.ContactID = &contact.ID
+	result.PhoneNumber = contact.PhoneNumber
+	return result, nil
+}
+
+// evaluateNotificationConditions checks the rule conditions against the payload.
+// An empty rule list always matches.
+func evaluateNotificationConditions(conditions models.JSONB, payload map[string]interface{}) bool {
+	rules := getStringSliceFromMap(conditions, "rules")
+	if len(rules) == 0 {
+		return true
+	}
+
+	matchAny := strings.EqualFold(getStringFromMap(conditions, "match"), "any")
+	for _, cond := range rules {
+		ok := evaluateCondition(cond, payload)
+		if matchAny && ok {
+			return true
+		}
+		if !matchAny && !ok {
+			return false
+		}
+	}
+	return !matchAny
+}
+
+// mapNotificationFields resolves field mappings into template body parameters
+func mapNotificationFields(mappings models.JSONB, payload map[string]interface{}) map[string]string {
+	params := make(map[string]string, len(mappings))
+	for param, source := range mappings {
+		src, ok := source.(string)
+		if !ok {
+			continue
+		}
+		params[param] = renderNotificationValue(src, payload)
+	}
+	return params
+}
+
+// renderNotificationValue resolves a mapping value. Values containing {{...}}
+// are rendered as templates; anything else is treated as a payload path.
+func renderNotificationValue(source string, payload map[string]interface{}) string {
+	if source == "" {
+		return ""
+	}
+	if strings.Contains(source, "{{") {
+		return processTemplate(source, payload)
+	}
+	return formatValue(getNestedValue(payload, source))
+}
+
+// getStringSliceFromMap returns a string slice from a map, skipping non-string and blank entries
+func getStringSliceFromMap(m map[string]interface{}, key string) []string {
+	switch v := m[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
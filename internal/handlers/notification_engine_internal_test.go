@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateNotificationConditions(t *testing.T) {
+	payload := map[string]interface{}{
+		"order": map[string]interface{}{"status": "shipped", "total": 250.0},
+	}
+
+	tests := []struct {
+		name       string
+		conditions models.JSONB
+		want       bool
+	}{
+		{"no conditions", nil, true},
+		{"empty rules", models.JSONB{"rules": []interface{}{}}, true},
+		{"all match", models.JSONB{"rules": []interface{}{"order.status == 'shipped'", "order.total > 100"}}, true},
+		{"all with one miss", models.JSONB{"rules": []interface{}{"order.status == 'shipped'", "order.total > 500"}}, false},
+		{"any with one hit", models.JSONB{"match": "any", "rules": []interface{}{"order.status == 'pending'", "order.total > 100"}}, true},
+		{"any with no hit", models.JSONB{"match": "any", "rules": []interface{}{"order.status == 'pending'"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, evaluateNotificationConditions(tt.conditions, payload))
+		})
+	}
+}
+
+func TestMapNotificationFields(t *testing.T) {
+	payload := map[string]interface{}{
+		"customer": map[string]interface{}{"name": "Asha"},
+		"order":    map[string]interface{}{"id": "A-100", "qty": 3.0},
+	}
+
+	params := mapNotificationFields(models.JSONB{
+		"1":       "customer.name",
+		"order":   "Order #{{order.id}}",
+		"qty":     "order.qty",
+		"missing": "order.nope",
+		"ignored": 42,
+	}, payload)
+
+	assert.Equal(t, "Asha", params["1"])
+	assert.Equal(t, "Order #A-100", params["order"])
+	assert.Equal(t, "3", params["qty"])
+	assert.Equal(t, "", params["missing"])
+	assert.NotContains(t, params, "ignored")
+}
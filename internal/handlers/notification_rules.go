@@ -0,0 +1,465 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// NotificationRuleRequest represents the request body for creating/updating a notification rule
+type NotificationRuleRequest struct {
+	Name             string                 `json:"name"`
+	WhatsAppAccount  string                 `json:"whatsapp_account"`
+	IsEnabled        *bool                  `json:"is_enabled"`
+	TriggerType      string                 `json:"trigger_type"`
+	TriggerConfig    map[string]interface{} `json:"trigger_config"`
+	TemplateID       string                 `json:"template_id"`
+	FieldMappings    map[string]interface{} `json:"field_mappings"`
+	Conditions       map[string]interface{} `json:"conditions"`
+	AttachmentConfig map[string]interface{} `json:"attachment_config"`
+	RegenerateToken  bool                   `json:"regenerate_token"`
+}
+
+// NotificationRuleResponse represents the API response for a notification rule
+type NotificationRuleResponse struct {
+	ID               uuid.UUID    `json:"id"`
+	Name             string       `json:"name"`
+	WhatsAppAccount  string       `json:"whatsapp_account"`
+	IsEnabled        bool         `json:"is_enabled"`
+	TriggerType      string       `json:"trigger_type"`
+	TriggerConfig    models.JSONB `json:"trigger_config"`
+	TemplateID       uuid.UUID    `json:"template_id"`
+	TemplateName     string       `json:"template_name,omitempty"`
+	FieldMappings    models.JSONB `json:"field_mappings"`
+	Conditions       models.JSONB `json:"conditions"`
+	AttachmentConfig models.JSONB `json:"attachment_config,omitempty"`
+	WebhookPath      string       `json:"webhook_path,omitempty"`
+	CreatedAt        string       `json:"created_at"`
+	UpdatedAt        string       `json:"updated_at"`
+}
+
+// ListNotificationRules returns all notification rules for the organization
+func (a *App) ListNotificationRules(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceNotificationRules, models.ActionRead); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	search := string(r.RequestCtx.QueryArgs().Peek("search"))
+
+	query := a.DB.Model(&models.NotificationRule{}).Where("organization_id = ?", orgID)
+	if search != "" {
+		query = query.Where("name ILIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var rules []models.NotificationRule
+	if err := pg.Apply(query.Preload("Template").Order("created_at DESC")).Find(&rules).Error; err != nil {
+		a.Log.Error("Failed to list notification rules", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list notification rules", nil, "")
+	}
+
+	result := make([]NotificationRuleResponse, len(rules))
+	for i, rule := range rules {
+		result[i] = notificationRuleToResponse(rule)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"notification_rules": result,
+		"total":              total,
+		"page":               pg.Page,
+		"limit":              pg.Limit,
+	})
+}
+
+// GetNotificationRule returns a single notification rule by ID
+func (a *App) GetNotificationRule(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceNotificationRules, models.ActionRead); err != nil {
+		return nil
+	}
+
+	ruleID, err := parsePathUUID(r, "id", "notification rule")
+	if err != nil {
+		return nil
+	}
+
+	rule, err := findByIDAndOrg[models.NotificationRule](a.DB, r, ruleID, orgID, "Notification rule")
+	if err != nil {
+		return nil
+	}
+	a.DB.Preload("Template").First(rule, ruleID)
+
+	return r.SendEnvelope(notificationRuleToResponse(*rule))
+}
+
+// CreateNotificationRule creates a new notification rule
+func (a *App) CreateNotificationRule(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceNotificationRules, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	var req NotificationRuleRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	if req.Name == "" || req.WhatsAppAccount == "" || req.TemplateID == "" || req.TriggerType == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "name, whatsapp_account, template_id and trigger_type are required", nil, "")
+	}
+	if err := validateNotificationTriggerType(req.TriggerType); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	templateID, err := uuid.Parse(req.TemplateID)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid template_id", nil, "")
+	}
+	if err := a.validateNotificationRuleRefs(orgID, req.WhatsAppAccount, templateID); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+	if err := validateNotificationConditions(req.Conditions); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+	}
+
+	triggerConfig := models.JSONB(req.TriggerConfig)
+	if triggerConfig == nil {
+		triggerConfig = models.JSONB{}
+	}
+	if req.TriggerType == NotificationTriggerWebhook {
+		triggerConfig["token"] = generateVerifyToken()
+	} else {
+		delete(triggerConfig, "token")
+	}
+
+	rule := models.NotificationRule{
+		OrganizationID:   orgID,
+		WhatsAppAccount:  req.WhatsAppAccount,
+		Name:             req.Name,
+		IsEnabled:        req.IsEnabled == nil || *req.IsEnabled,
+		TriggerType:      req.TriggerType,
+		TriggerConfig:    triggerConfig,
+		TemplateID:       templateID,
+		FieldMappings:    models.JSONB(req.FieldMappings),
+		Conditions:       models.JSONB(req.Conditions),
+		AttachmentConfig: models.JSONB(req.AttachmentConfig),
+	}
+	if rule.FieldMappings == nil {
+		rule.FieldMappings = models.JSONB{}
+	}
+	if rule.Conditions == nil {
+		rule.Conditions = models.JSONB{}
+	}
+
+	if err := a.DB.Create(&rule).Error; err != nil {
+		a.Log.Error("Failed to create notification rule", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to create notification rule", nil, "")
+	}
+	// GORM skips false booleans with a default tag on create
+	if !rule.IsEnabled {
+		a.DB.Model(&rule).Update("is_enabled", false)
+	}
+
+	a.DB.Preload("Template").First(&rule, rule.ID)
+
+	a.Log.Info("Notification rule created", "rule_id", rule.ID, "name", rule.Name, "trigger_type", rule.TriggerType)
+	return r.SendEnvelope(notificationRuleToResponse(rule))
+}
+
+// UpdateNotificationRule updates an existing notification rule
+func (a *App) UpdateNotificationRule(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceNotificationRules, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	ruleID, err := parsePathUUID(r, "id", "notification rule")
+	if err != nil {
+		return nil
+	}
+
+	rule, err := findByIDAndOrg[models.NotificationRule](a.DB, r, ruleID, orgID, "Notification rule")
+	if err != nil {
+		return nil
+	}
+
+	var req NotificationRuleRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != "" {
+		updates["name"] = req.Name
+	}
+	if req.IsEnabled != nil {
+		updates["is_enabled"] = *req.IsEnabled
+	}
+
+	triggerType := rule.TriggerType
+	if req.TriggerType != "" {
+		if err := validateNotificationTriggerType(req.TriggerType); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		triggerType = req.TriggerType
+		updates["trigger_type"] = triggerType
+	}
+
+	accountName := rule.WhatsAppAccount
+	if req.WhatsAppAccount != "" {
+		accountName = req.WhatsAppAccount
+		updates["whatsapp_account"] = accountName
+	}
+	templateID := rule.TemplateID
+	if req.TemplateID != "" {
+		templateID, err = uuid.Parse(req.TemplateID)
+		if err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid template_id", nil, "")
+		}
+		updates["template_id"] = templateID
+	}
+	if req.WhatsAppAccount != "" || req.TemplateID != "" {
+		if err := a.validateNotificationRuleRefs(orgID, accountName, templateID); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+	}
+
+	// Keep the webhook token stable across edits unless a new one is requested
+	triggerConfig := rule.TriggerConfig
+	if req.TriggerConfig != nil {
+		triggerConfig = models.JSONB(req.TriggerConfig)
+		triggerConfig["token"] = rule.TriggerConfig["token"]
+	}
+	if triggerType == NotificationTriggerWebhook {
+		if req.RegenerateToken || getStringFromMap(triggerConfig, "token") == "" {
+			triggerConfig["token"] = generateVerifyToken()
+		}
+	} else {
+		delete(triggerConfig, "token")
+	}
+	configJSON, _ := json.Marshal(triggerConfig)
+	updates["trigger_config"] = configJSON
+
+	if req.FieldMappings != nil {
+		mappingsJSON, _ := json.Marshal(req.FieldMappings)
+		updates["field_mappings"] = mappingsJSON
+	}
+	if req.Conditions != nil {
+		if err := validateNotificationConditions(req.Conditions); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, err.Error(), nil, "")
+		}
+		conditionsJSON, _ := json.Marshal(req.Conditions)
+		updates["conditions"] = conditionsJSON
+	}
+	if req.AttachmentConfig != nil {
+		if len(req.AttachmentConfig) == 0 {
+			updates["attachment_config"] = nil
+		} else {
+			attachmentJSON, _ := json.Marshal(req.AttachmentConfig)
+			updates["attachment_config"] = attachmentJSON
+		}
+	}
+
+	if err := a.DB.Model(rule).Updates(updates).Error; err != nil {
+		a.Log.Error("Failed to update notification rule", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update notification rule", nil, "")
+	}
+
+	// Reload to get updated values
+	a.DB.Preload("Template").First(rule, ruleID)
+
+	a.Log.Info("Notification rule updated", "rule_id", rule.ID)
+	return r.SendEnvelope(notificationRuleToResponse(*rule))
+}
+
+// DeleteNotificationRule deletes a notification rule
+func (a *App) DeleteNotificationRule(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceNotificationRules, models.ActionDelete); err != nil {
+		return nil
+	}
+
+	ruleID, err := parsePathUUID(r, "id", "notification rule")
+	if err != nil {
+		return nil
+	}
+
+	result := a.DB.Where("id = ? AND organization_id = ?", ruleID, orgID).Delete(&models.NotificationRule{})
+	if result.Error != nil {
+		a.Log.Error("Failed to delete notification rule", "error", result.Error)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete notification rule", nil, "")
+	}
+	if result.RowsAffected == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Notification rule not found", nil, "")
+	}
+
+	a.Log.Info("Notification rule deleted", "rule_id", ruleID)
+	return r.SendEnvelope(map[string]string{"status": "deleted"})
+}
+
+// TriggerNotificationRule fires a rule with the request body as payload.
+// Authenticated with a JWT or API key; used by "api" rules and for testing webhook rules.
+func (a *App) TriggerNotificationRule(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceNotificationRules, models.ActionExecute); err != nil {
+		return nil
+	}
+
+	ruleID, err := parsePathUUID(r, "id", "notification rule")
+	if err != nil {
+		return nil
+	}
+
+	rule, err := findByIDAndOrg[models.NotificationRule](a.DB, r, ruleID, orgID, "Notification rule")
+	if err != nil {
+		return nil
+	}
+	if rule.TriggerType == NotificationTriggerScheduler {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Scheduler rules cannot be triggered over HTTP", nil, "")
+	}
+
+	return a.fireNotificationRule(r, rule)
+}
+
+// NotificationRuleWebhook is the public inbound endpoint for "webhook" rules.
+// The secret token in the URL identifies the rule; no other authentication is required.
+func (a *App) NotificationRuleWebhook(r *fastglue.Request) error {
+	token, _ := r.RequestCtx.UserValue("token").(string)
+	if token == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Notification rule not found", nil, "")
+	}
+
+	var rule models.NotificationRule
+	if err := a.DB.Where("trigger_type = ? AND trigger_config->>'token' = ?", NotificationTriggerWebhook, token).
+		First(&rule).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Notification rule not found", nil, "")
+	}
+
+	return a.fireNotificationRule(r, &rule)
+}
+
+// fireNotificationRule decodes the request payload, runs the engine and writes the result
+func (a *App) fireNotificationRule(r *fastglue.Request, rule *models.NotificationRule) error {
+	var payload map[string]interface{}
+	if body := r.RequestCtx.PostBody(); len(body) > 0 {
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid request body", nil, "")
+		}
+	}
+
+	result, err := a.ExecuteNotificationRule(r.RequestCtx, rule, payload)
+	if err != nil {
+		var ruleErr *notificationRuleError
+		if errors.As(err, &ruleErr) {
+			return r.SendErrorEnvelope(ruleErr.Status, ruleErr.Message, nil, "")
+		}
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to execute notification rule", nil, "")
+	}
+
+	return r.SendEnvelope(result)
+}
+
+// validateNotificationRuleRefs checks that the account and template belong to the organization
+func (a *App) validateNotificationRuleRefs(orgID uuid.UUID, accountName string, templateID uuid.UUID) error {
+	var count int64
+	a.DB.Model(&models.WhatsAppAccount{}).Where("name = ? AND organization_id = ?", accountName, orgID).Count(&count)
+	if count == 0 {
+		return fmt.Errorf("WhatsApp account not found")
+	}
+	a.DB.Model(&models.Template{}).Where("id = ? AND organization_id = ?", templateID, orgID).Count(&count)
+	if count == 0 {
+		return fmt.Errorf("template not found")
+	}
+	return nil
+}
+
+// validateNotificationTriggerType checks that a trigger type is supported
+func validateNotificationTriggerType(triggerType string) error {
+	switch triggerType {
+	case NotificationTriggerWebhook, NotificationTriggerAPI:
+		return nil
+	case NotificationTriggerScheduler:
+		return fmt.Errorf("scheduler triggers are not supported yet")
+	default:
+		return fmt.Errorf("invalid trigger_type. Must be webhook or api")
+	}
+}
+
+// validateNotificationConditions checks the shape of a conditions object
+func validateNotificationConditions(conditions map[string]interface{}) error {
+	if conditions == nil {
+		return nil
+	}
+	if match := getStringFromMap(conditions, "match"); match != "" && match != "all" && match != "any" {
+		return fmt.Errorf("conditions.match must be all or any")
+	}
+	if rules, ok := conditions["rules"]; ok {
+		list, ok := rules.([]interface{})
+		if !ok {
+			return fmt.Errorf("conditions.rules must be a list of expressions")
+		}
+		for _, item := range list {
+			if _, ok := item.(string); !ok {
+				return fmt.Errorf("conditions.rules must be a list of expressions")
+			}
+		}
+	}
+	return nil
+}
+
+func notificationRuleToResponse(rule models.NotificationRule) NotificationRuleResponse {
+	resp := NotificationRuleResponse{
+		ID:               rule.ID,
+		Name:             rule.Name,
+		WhatsAppAccount:  rule.WhatsAppAccount,
+		IsEnabled:        rule.IsEnabled,
+		TriggerType:      rule.TriggerType,
+		TriggerConfig:    rule.TriggerConfig,
+		TemplateID:       rule.TemplateID,
+		FieldMappings:    rule.FieldMappings,
+		Conditions:       rule.Conditions,
+		AttachmentConfig: rule.AttachmentConfig,
+		CreatedAt:        rule.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:        rule.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if rule.Template != nil {
+		resp.TemplateName = rule.Template.Name
+	}
+	if token := getStringFromMap(rule.TriggerConfig, "token"); token != "" && rule.TriggerType == NotificationTriggerWebhook {
+		resp.WebhookPath = "/api/notification-rules/hooks/" + token
+	}
+	return resp
+}
@@ -0,0 +1,395 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// createTestNotificationRule creates a notification rule directly in the database.
+func createTestNotificationRule(t *testing.T, app *handlers.App, orgID uuid.UUID, accountName string, templateID uuid.UUID, triggerType string, triggerConfig, fieldMappings, conditions models.JSONB) *models.NotificationRule {
+	t.Helper()
+
+	if triggerConfig == nil {
+		triggerConfig = models.JSONB{}
+	}
+	rule := &models.NotificationRule{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  orgID,
+		WhatsAppAccount: accountName,
+		Name:            "rule-" + uuid.New().String()[:8],
+		IsEnabled:       true,
+		TriggerType:     triggerType,
+		TriggerConfig:   triggerConfig,
+		TemplateID:      templateID,
+		FieldMappings:   fieldMappings,
+		Conditions:      conditions,
+	}
+	require.NoError(t, app.DB.Create(rule).Error)
+	return rule
+}
+
+// createShippingTemplate creates an approved template with named body params.
+func createShippingTemplate(t *testing.T, app *handlers.App, orgID uuid.UUID, accountName string) *models.Template {
+	t.Helper()
+
+	template := &models.Template{
+		BaseModel:       models.BaseModel{ID: uuid.New()},
+		OrganizationID:  orgID,
+		WhatsAppAccount: accountName,
+		Name:            "order_shipped_" + uuid.New().String()[:8],
+		MetaTemplateID:  "meta-" + uuid.New().String()[:8],
+		Category:        "UTILITY",
+		Language:        "en",
+		Status:          string(models.TemplateStatusApproved),
+		BodyContent:     "Hi {{name}}, order {{order_id}} has shipped.",
+	}
+	require.NoError(t, app.DB.Create(template).Error)
+	return template
+}
+
+func TestApp_CreateNotificationRule(t *testing.T) {
+	t.Parallel()
+
+	t.Run("WebhookRuleGetsToken", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+		template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"name":             "Order shipped",
+			"whatsapp_account": account.Name,
+			"template_id":      template.ID.String(),
+			"trigger_type":     "webhook",
+			"trigger_config":   map[string]any{"phone_field": "customer.phone"},
+			"field_mappings":   map[string]any{"1": "customer.name"},
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		require.NoError(t, app.CreateNotificationRule(req))
+		assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data handlers.NotificationRuleResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.Equal(t, "Order shipped", resp.Data.Name)
+		assert.True(t, resp.Data.IsEnabled)
+		assert.Equal(t, template.Name, resp.Data.TemplateName)
+		assert.Equal(t, "customer.phone", resp.Data.TriggerConfig["phone_field"])
+
+		token, _ := resp.Data.TriggerConfig["token"].(string)
+		assert.Len(t, token, 64)
+		assert.Equal(t, "/api/notification-rules/hooks/"+token, resp.Data.WebhookPath)
+	})
+
+	t.Run("SchedulerNotSupported", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+		template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"name":             "Nightly",
+			"whatsapp_account": account.Name,
+			"template_id":      template.ID.String(),
+			"trigger_type":     "scheduler",
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		require.NoError(t, app.CreateNotificationRule(req))
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("TemplateFromOtherOrg", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		otherOrg := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+		otherAccount := testutil.CreateTestWhatsAppAccount(t, app.DB, otherOrg.ID)
+		template := testutil.CreateTestTemplate(t, app.DB, otherOrg.ID, otherAccount.Name)
+
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"name":             "Cross org",
+			"whatsapp_account": account.Name,
+			"template_id":      template.ID.String(),
+			"trigger_type":     "api",
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		require.NoError(t, app.CreateNotificationRule(req))
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("NoPermission", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAgentRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+
+		req := testutil.NewJSONRequest(t, map[string]any{"name": "x"})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		require.NoError(t, app.CreateNotificationRule(req))
+		assert.Equal(t, fasthttp.StatusForbidden, testutil.GetResponseStatusCode(req))
+	})
+}
+
+func TestApp_ListNotificationRules(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	otherOrg := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+
+	createTestNotificationRule(t, app, org.ID, account.Name, template.ID, "api", nil, nil, nil)
+	createTestNotificationRule(t, app, org.ID, account.Name, template.ID, "api", nil, nil, nil)
+	createTestNotificationRule(t, app, otherOrg.ID, account.Name, template.ID, "api", nil, nil, nil)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+
+	require.NoError(t, app.ListNotificationRules(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			NotificationRules []handlers.NotificationRuleResponse `json:"notification_rules"`
+			Total             int64                               `json:"total"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Len(t, resp.Data.NotificationRules, 2)
+	assert.Equal(t, int64(2), resp.Data.Total)
+}
+
+func TestApp_UpdateNotificationRule(t *testing.T) {
+	t.Parallel()
+
+	t.Run("KeepsTokenUnlessRegenerated", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+		template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+		rule := createTestNotificationRule(t, app, org.ID, account.Name, template.ID, "webhook",
+			models.JSONB{"token": "original-token"}, nil, nil)
+
+		update := func(body map[string]any) handlers.NotificationRuleResponse {
+			req := testutil.NewJSONRequest(t, body)
+			testutil.SetAuthContext(req, org.ID, user.ID)
+			testutil.SetPathParam(req, "id", rule.ID.String())
+			require.NoError(t, app.UpdateNotificationRule(req))
+			require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+			var resp struct {
+				Data handlers.NotificationRuleResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+			return resp.Data
+		}
+
+		updated := update(map[string]any{
+			"name":           "Renamed",
+			"is_enabled":     false,
+			"trigger_config": map[string]any{"phone_field": "to"},
+		})
+		assert.Equal(t, "Renamed", updated.Name)
+		assert.False(t, updated.IsEnabled)
+		assert.Equal(t, "to", updated.TriggerConfig["phone_field"])
+		assert.Equal(t, "original-token", updated.TriggerConfig["token"])
+
+		regenerated := update(map[string]any{"regenerate_token": true})
+		assert.NotEqual(t, "original-token", regenerated.TriggerConfig["token"])
+		assert.Equal(t, "to", regenerated.TriggerConfig["phone_field"])
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		app := newTestApp(t)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+
+		req := testutil.NewJSONRequest(t, map[string]any{"name": "x"})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", uuid.New().String())
+
+		require.NoError(t, app.UpdateNotificationRule(req))
+		assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+	})
+}
+
+func TestApp_DeleteNotificationRule(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	rule := createTestNotificationRule(t, app, org.ID, account.Name, template.ID, "api", nil, nil, nil)
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", rule.ID.String())
+
+	require.NoError(t, app.DeleteNotificationRule(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var count int64
+	app.DB.Model(&models.NotificationRule{}).Where("id = ?", rule.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestApp_TriggerNotificationRule(t *testing.T) {
+	t.Parallel()
+
+	t.Run("SendsMappedTemplate", func(t *testing.T) {
+		mockServer := newMockWhatsAppServer()
+		defer mockServer.close()
+
+		app := newMsgTestApp(t, mockServer)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		account := createTestAccount(t, app, org.ID)
+		template := createShippingTemplate(t, app, org.ID, account.Name)
+		rule := createTestNotificationRule(t, app, org.ID, account.Name, template.ID, "api",
+			models.JSONB{"phone_field": "customer.phone", "name_field": "customer.name"},
+			models.JSONB{"name": "customer.name", "order_id": "#{{order.id}}"},
+			models.JSONB{"rules": []any{"order.status == 'shipped'"}})
+
+		req := testutil.NewJSONRequest(t, map[string]any{
+			"customer": map[string]any{"phone": "919876543210", "name": "Asha"},
+			"order":    map[string]any{"id": "A-100", "status": "shipped"},
+		})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", rule.ID.String())
+
+		require.NoError(t, app.TriggerNotificationRule(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		app.WaitForBackgroundTasks()
+
+		var resp struct {
+			Data handlers.NotificationRuleResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.Equal(t, "sent", resp.Data.Status)
+		require.NotNil(t, resp.Data.MessageID)
+
+		var msg models.Message
+		require.NoError(t, app.DB.First(&msg, resp.Data.MessageID).Error)
+		assert.Equal(t, "Hi Asha, order #A-100 has shipped.", msg.Content)
+
+		require.Len(t, mockServer.sentMessages, 1)
+		assert.Equal(t, "919876543210", mockServer.sentMessages[0]["to"])
+	})
+
+	t.Run("ConditionsNotMet", func(t *testing.T) {
+		mockServer := newMockWhatsAppServer()
+		defer mockServer.close()
+
+		app := newMsgTestApp(t, mockServer)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		account := createTestAccount(t, app, org.ID)
+		template := createShippingTemplate(t, app, org.ID, account.Name)
+		rule := createTestNotificationRule(t, app, org.ID, account.Name, template.ID, "api", nil,
+			models.JSONB{"name": "name", "order_id": "order_id"},
+			models.JSONB{"rules": []any{"status == 'shipped'"}})
+
+		req := testutil.NewJSONRequest(t, map[string]any{"phone": "919876543210", "status": "pending"})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", rule.ID.String())
+
+		require.NoError(t, app.TriggerNotificationRule(req))
+		assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data handlers.NotificationRuleResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.Equal(t, "skipped", resp.Data.Status)
+		assert.Empty(t, mockServer.sentMessages)
+	})
+
+	t.Run("MissingParams", func(t *testing.T) {
+		mockServer := newMockWhatsAppServer()
+		defer mockServer.close()
+
+		app := newMsgTestApp(t, mockServer)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		role := testutil.CreateAdminRole(t, app.DB, org.ID)
+		user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		account := createTestAccount(t, app, org.ID)
+		template := createShippingTemplate(t, app, org.ID, account.Name)
+		rule := createTestNotificationRule(t, app, org.ID, account.Name, template.ID, "api", nil,
+			models.JSONB{"name": "name"}, nil)
+
+		req := testutil.NewJSONRequest(t, map[string]any{"phone": "919876543210", "name": "Asha"})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", rule.ID.String())
+
+		require.NoError(t, app.TriggerNotificationRule(req))
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+		assert.Contains(t, string(testutil.GetResponseBody(req)), "order_id")
+	})
+}
+
+func TestApp_NotificationRuleWebhook(t *testing.T) {
+	t.Parallel()
+
+	t.Run("FiresRuleByToken", func(t *testing.T) {
+		mockServer := newMockWhatsAppServer()
+		defer mockServer.close()
+
+		app := newMsgTestApp(t, mockServer)
+		org := testutil.CreateTestOrganization(t, app.DB)
+		account := createTestAccount(t, app, org.ID)
+		template := createShippingTemplate(t, app, org.ID, account.Name)
+		token := "hook-" + uuid.New().String()
+		createTestNotificationRule(t, app, org.ID, account.Name, template.ID, "webhook",
+			models.JSONB{"token": token},
+			models.JSONB{"name": "name", "order_id": "order_id"}, nil)
+
+		req := testutil.NewJSONRequest(t, map[string]any{"phone": "919876543210", "name": "Ravi", "order_id": "B-7"})
+		testutil.SetPathParam(req, "token", token)
+
+		require.NoError(t, app.NotificationRuleWebhook(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		app.WaitForBackgroundTasks()
+
+		require.Len(t, mockServer.sentMessages, 1)
+	})
+
+	t.Run("UnknownToken", func(t *testing.T) {
+		app := newTestApp(t)
+
+		req := testutil.NewJSONRequest(t, map[string]any{"phone": "919876543210"})
+		testutil.SetPathParam(req, "token", "does-not-exist")
+
+		require.NoError(t, app.NotificationRuleWebhook(req))
+		assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+	})
+}
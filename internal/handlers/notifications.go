@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	// maxNotificationBodyLength caps the note preview stored in a notification, in runes
+	maxNotificationBodyLength = 200
+	// maxMentionSuggestions caps the users and teams offered for mentions
+	maxMentionSuggestions = 10
+)
+
+// NotificationResponse represents the API response for a notification.
+type NotificationResponse struct {
+	ID          uuid.UUID               `json:"id"`
+	Type        models.NotificationType `json:"type"`
+	ActorID     *uuid.UUID              `json:"actor_id,omitempty"`
+	ActorName   string                  `json:"actor_name"`
+	ContactID   *uuid.UUID              `json:"contact_id,omitempty"`
+	ContactName string                  `json:"contact_name"`
+	NoteID      *uuid.UUID              `json:"note_id,omitempty"`
+	TeamID      *uuid.UUID              `json:"team_id,omitempty"`
+	TeamName    string                  `json:"team_name,omitempty"`
+	Body        string                  `json:"body"`
+	IsRead      bool                    `json:"is_read"`
+	ReadAt      *time.Time              `json:"read_at,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+}
+
+// MentionSuggestion is a user or team that can be mentioned in a note.
+type MentionSuggestion struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// ListNotifications returns the current user's notifications, newest first.
+func (a *App) ListNotifications(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	pg := parsePagination(r)
+	query := a.DB.Model(&models.Notification{}).Where("organization_id = ? AND user_id = ?", orgID, userID)
+	if string(r.RequestCtx.QueryArgs().Peek("unread")) == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var notifications []models.Notification
+	if err := pg.Apply(query.Preload("Actor").Preload("Contact").Preload("Team").
+		Order("created_at DESC")).
+		Find(&notifications).Error; err != nil {
+		a.Log.Error("Failed to list notifications", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list notifications", nil, "")
+	}
+
+	result := make([]NotificationResponse, len(notifications))
+	for i, n := range notifications {
+		result[i] = notificationToResponse(n)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"notifications": result,
+		"total":         total,
+		"unread_count":  a.unreadNotificationCount(orgID, userID),
+		"page":          pg.Page,
+		"limit":         pg.Limit,
+	})
+}
+
+// GetUnreadNotificationCount returns the number of unread notifications of the current user.
+func (a *App) GetUnreadNotificationCount(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"unread_count": a.unreadNotificationCount(orgID, userID),
+	})
+}
+
+// MarkNotificationRead marks one of the current user's notifications as read.
+func (a *App) MarkNotificationRead(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	notificationID, err := parsePathUUID(r, "id", "notification")
+	if err != nil {
+		return nil
+	}
+
+	var notification models.Notification
+	if err := a.DB.Where("id = ? AND organization_id = ? AND user_id = ?", notificationID, orgID, userID).
+		First(&notification).Error; err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Notification not found", nil, "")
+	}
+
+	if notification.ReadAt == nil {
+		if err := a.DB.Model(&notification).Update("read_at", time.Now()).Error; err != nil {
+			a.Log.Error("Failed to mark notification read", "error", err)
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to mark notification read", nil, "")
+		}
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"unread_count": a.broadcastUnreadNotificationCount(orgID, userID),
+	})
+}
+
+// MarkAllNotificationsRead marks all of the current user's notifications as read.
+func (a *App) MarkAllNotificationsRead(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	result := a.DB.Model(&models.Notification{}).
+		Where("organization_id = ? AND user_id = ? AND read_at IS NULL", orgID, userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		a.Log.Error("Failed to mark notifications read", "error", result.Error)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to mark notifications read", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"updated":      result.RowsAffected,
+		"unread_count": a.broadcastUnreadNotificationCount(orgID, userID),
+	})
+}
+
+// ListMentionSuggestions returns active users and teams of the organization
+// matching the search, for mentions in conversation notes.
+func (a *App) ListMentionSuggestions(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	if err := a.requirePermission(r, userID, models.ResourceChat, models.ActionRead); err != nil {
+		return nil
+	}
+
+	search := "%" + string(r.RequestCtx.QueryArgs().Peek("search")) + "%"
+
+	users := []MentionSuggestion{}
+	if err := a.DB.Model(&models.User{}).
+		Select("users.id, users.full_name AS name").
+		Joins("JOIN user_organizations ON user_organizations.user_id = users.id AND user_organizations.organization_id = ? AND user_organizations.deleted_at IS NULL", orgID).
+		Where("users.is_active = ? AND (users.full_name ILIKE ? OR users.email ILIKE ?)", true, search, search).
+		Order("users.full_name").
+		Limit(maxMentionSuggestions).
+		Scan(&users).Error; err != nil {
+		a.Log.Error("Failed to list mention suggestions", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list mention suggestions", nil, "")
+	}
+
+	teams := []MentionSuggestion{}
+	if err := a.DB.Model(&models.Team{}).
+		Select("id, name").
+		Where("organization_id = ? AND is_active = ? AND name ILIKE ?", orgID, true, search).
+		Order("name").
+		Limit(maxMentionSuggestions).
+		Scan(&teams).Error; err != nil {
+		a.Log.Error("Failed to list mention suggestions", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list mention suggestions", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"users": users,
+		"teams": teams,
+	})
+}
+
+// notifyNoteMentions creates a notification for every user mentioned in the note,
+// directly or through one of their teams, and pushes it to them over WebSocket.
+// Users already mentioned in previousContent and the note's author are skipped.
+func (a *App) notifyNoteMentions(note *models.ConversationNote, previousContent string) {
+	recipients := a.resolveMentionRecipients(note.OrganizationID, parseNoteMentions(note.Content))
+	if len(recipients) == 0 {
+		return
+	}
+	if previousContent != "" {
+		for userID := range a.resolveMentionRecipients(note.OrganizationID, parseNoteMentions(previousContent)) {
+			delete(recipients, userID)
+		}
+	}
+	delete(recipients, note.CreatedByID)
+	if len(recipients) == 0 {
+		return
+	}
+
+	body := noteMentionText(note.Content)
+	if utf8.RuneCountInString(body) > maxNotificationBodyLength {
+		body = string([]rune(body)[:maxNotificationBodyLength-3]) + "..."
+	}
+
+	notifications := make([]models.Notification, 0, len(recipients))
+	for userID, teamID := range recipients {
+		notifications = append(notifications, models.Notification{
+			OrganizationID: note.OrganizationID,
+			UserID:         userID,
+			Type:           models.NotificationTypeMention,
+			ActorID:        &note.CreatedByID,
+			ContactID:      &note.ContactID,
+			NoteID:         &note.ID,
+			TeamID:         teamID,
+			Body:           body,
+		})
+	}
+	if err := a.DB.Create(&notifications).Error; err != nil {
+		a.Log.Error("Failed to create mention notifications", "error", err, "note_id", note.ID)
+		return
+	}
+
+	if a.WSHub == nil {
+		return
+	}
+	ids := make([]uuid.UUID, len(notifications))
+	for i, n := range notifications {
+		ids[i] = n.ID
+	}
+	if err := a.DB.Preload("Actor").Preload("Contact").Preload("Team").
+		Where("id IN ?", ids).Find(&notifications).Error; err != nil {
+		a.Log.Error("Failed to load mention notifications", "error", err, "note_id", note.ID)
+		return
+	}
+	for _, n := range notifications {
+		a.WSHub.BroadcastToUser(note.OrganizationID, n.UserID, websocket.WSMessage{
+			Type: websocket.TypeNotificationCreated,
+			Payload: map[string]any{
+				"notification": notificationToResponse(n),
+				"unread_count": a.unreadNotificationCount(note.OrganizationID, n.UserID),
+			},
+		})
+	}
+}
+
+// resolveMentionRecipients returns the active organization members mentioned
+// directly or through an active team, mapped to the team they were mentioned
+// through (nil for direct mentions).
+func (a *App) resolveMentionRecipients(orgID uuid.UUID, mentions noteMentions) map[uuid.UUID]*uuid.UUID {
+	recipients := make(map[uuid.UUID]*uuid.UUID)
+	memberJoin := "JOIN user_organizations ON user_organizations.user_id = users.id AND user_organizations.organization_id = ? AND user_organizations.deleted_at IS NULL"
+
+	if len(mentions.TeamIDs) > 0 {
+		var members []struct {
+			UserID uuid.UUID
+			TeamID uuid.UUID
+		}
+		if err := a.DB.Table("team_members").
+			Select("team_members.user_id, team_members.team_id").
+			Joins("JOIN teams ON teams.id = team_members.team_id AND teams.deleted_at IS NULL").
+			Joins("JOIN users ON users.id = team_members.user_id AND users.deleted_at IS NULL").
+			Joins(memberJoin, orgID).
+			Where("team_members.team_id IN ? AND team_members.deleted_at IS NULL", mentions.TeamIDs).
+			Where("teams.organization_id = ? AND teams.is_active = ? AND users.is_active = ?", orgID, true, true).
+			Scan(&members).Error; err != nil {
+			a.Log.Error("Failed to resolve team mentions", "error", err)
+		}
+		for _, m := range members {
+			teamID := m.TeamID
+			recipients[m.UserID] = &teamID
+		}
+	}
+
+	if len(mentions.UserIDs) > 0 {
+		var userIDs []uuid.UUID
+		if err := a.DB.Model(&models.User{}).
+			Joins(memberJoin, orgID).
+			Where("users.id IN ? AND users.is_active = ?", mentions.UserIDs, true).
+			Pluck("users.id", &userIDs).Error; err != nil {
+			a.Log.Error("Failed to resolve user mentions", "error", err)
+		}
+		// A direct mention takes precedence over a team mention
+		for _, id := range userIDs {
+			recipients[id] = nil
+		}
+	}
+
+	return recipients
+}
+
+// deleteNoteNotifications removes the notifications of a deleted note and
+// updates the unread count of their recipients.
+func (a *App) deleteNoteNotifications(orgID, noteID uuid.UUID) {
+	var userIDs []uuid.UUID
+	a.DB.Model(&models.Notification{}).
+		Where("organization_id = ? AND note_id = ? AND read_at IS NULL", orgID, noteID).
+		Distinct().Pluck("user_id", &userIDs)
+
+	if err := a.DB.Where("organization_id = ? AND note_id = ?", orgID, noteID).
+		Delete(&models.Notification{}).Error; err != nil {
+		a.Log.Error("Failed to delete note notifications", "error", err, "note_id", noteID)
+		return
+	}
+
+	for _, userID := range userIDs {
+		a.broadcastUnreadNotificationCount(orgID, userID)
+	}
+}
+
+// unreadNotificationCount returns the number of unread notifications of a user.
+func (a *App) unreadNotificationCount(orgID, userID uuid.UUID) int64 {
+	var count int64
+	a.DB.Model(&models.Notification{}).
+		Where("organization_id = ? AND user_id = ? AND read_at IS NULL", orgID, userID).
+		Count(&count)
+	return count
+}
+
+// broadcastUnreadNotificationCount sends a user's unread count to all their
+// clients so other tabs stay in sync, and returns it.
+func (a *App) broadcastUnreadNotificationCount(orgID, userID uuid.UUID) int64 {
+	count := a.unreadNotificationCount(orgID, userID)
+	if a.WSHub != nil {
+		a.WSHub.BroadcastToUser(orgID, userID, websocket.WSMessage{
+			Type:    websocket.TypeNotificationsUpdated,
+			Payload: map[string]any{"unread_count": count},
+		})
+	}
+	return count
+}
+
+func notificationToResponse(n models.Notification) NotificationResponse {
+	resp := NotificationResponse{
+		ID:        n.ID,
+		Type:      n.Type,
+		ActorID:   n.ActorID,
+		ContactID: n.ContactID,
+		NoteID:    n.NoteID,
+		TeamID:    n.TeamID,
+		Body:      n.Body,
+		IsRead:    n.ReadAt != nil,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+	if n.Actor != nil {
+		resp.ActorName = n.Actor.FullName
+	}
+	if n.Contact != nil {
+		resp.ContactName = n.Contact.ProfileName
+		if resp.ContactName == "" {
+			resp.ContactName = n.Contact.PhoneNumber
+		}
+	}
+	if n.Team != nil {
+		resp.TeamName = n.Team.Name
+	}
+	return resp
+}
@@ -0,0 +1,190 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func userMention(user *models.User) string {
+	return "@[" + user.FullName + "](user:" + user.ID.String() + ")"
+}
+
+func teamMention(team *models.Team) string {
+	return "@[" + team.Name + "](team:" + team.ID.String() + ")"
+}
+
+// createMentionNote creates a note as the author through the API
+func createMentionNote(t *testing.T, app *handlers.App, orgID, authorID, contactID uuid.UUID, content string) handlers.ConversationNoteResponse {
+	t.Helper()
+	req := testutil.NewJSONRequest(t, map[string]any{"content": content})
+	testutil.SetAuthContext(req, orgID, authorID)
+	testutil.SetPathParam(req, "id", contactID.String())
+
+	require.NoError(t, app.CreateConversationNote(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.ConversationNoteResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	return resp.Data
+}
+
+func userNotifications(t *testing.T, app *handlers.App, userID uuid.UUID) []models.Notification {
+	t.Helper()
+	var notifications []models.Notification
+	require.NoError(t, app.DB.Where("user_id = ?", userID).Find(&notifications).Error)
+	return notifications
+}
+
+func TestApp_CreateConversationNote_NotifiesMentions(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	author := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	alice := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithFullName("Alice"))
+	bob := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithFullName("Bob"))
+	inactive := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithInactive())
+	outsider := testutil.CreateTestUser(t, app.DB, testutil.CreateTestOrganization(t, app.DB).ID)
+	team := createTestTeam(t, app, org.ID, author.ID, alice.ID, bob.ID, inactive.ID)
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	note := createMentionNote(t, app, org.ID, author.ID, contact.ID,
+		userMention(alice)+" "+teamMention(team)+" "+userMention(outsider)+" can you call back?")
+
+	// Alice is mentioned directly and through the team, but notified once
+	aliceNotifications := userNotifications(t, app, alice.ID)
+	require.Len(t, aliceNotifications, 1)
+	n := aliceNotifications[0]
+	assert.Equal(t, models.NotificationTypeMention, n.Type)
+	assert.Nil(t, n.TeamID, "direct mention takes precedence")
+	require.NotNil(t, n.NoteID)
+	assert.Equal(t, note.ID, *n.NoteID)
+	require.NotNil(t, n.ContactID)
+	assert.Equal(t, contact.ID, *n.ContactID)
+	require.NotNil(t, n.ActorID)
+	assert.Equal(t, author.ID, *n.ActorID)
+	assert.Equal(t, "@Alice @"+team.Name+" @"+outsider.FullName+" can you call back?", n.Body)
+
+	bobNotifications := userNotifications(t, app, bob.ID)
+	require.Len(t, bobNotifications, 1)
+	require.NotNil(t, bobNotifications[0].TeamID)
+	assert.Equal(t, team.ID, *bobNotifications[0].TeamID)
+
+	assert.Empty(t, userNotifications(t, app, author.ID), "authors are not notified of their own mentions")
+	assert.Empty(t, userNotifications(t, app, inactive.ID))
+	assert.Empty(t, userNotifications(t, app, outsider.ID))
+}
+
+func TestApp_UpdateConversationNote_NotifiesNewMentionsOnly(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	author := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID))
+	alice := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithFullName("Alice"))
+	bob := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithFullName("Bob"))
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	note := createMentionNote(t, app, org.ID, author.ID, contact.ID, userMention(alice)+" please check")
+
+	req := testutil.NewJSONRequest(t, map[string]any{"content": userMention(alice) + " " + userMention(bob) + " please check"})
+	testutil.SetAuthContext(req, org.ID, author.ID)
+	testutil.SetPathParam(req, "id", contact.ID.String())
+	testutil.SetPathParam(req, "note_id", note.ID.String())
+	require.NoError(t, app.UpdateConversationNote(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	assert.Len(t, userNotifications(t, app, alice.ID), 1)
+	assert.Len(t, userNotifications(t, app, bob.ID), 1)
+
+	// Deleting the note removes its notifications
+	req = testutil.NewRequest(t)
+	testutil.SetAuthContext(req, org.ID, author.ID)
+	testutil.SetPathParam(req, "id", contact.ID.String())
+	testutil.SetPathParam(req, "note_id", note.ID.String())
+	require.NoError(t, app.DeleteConversationNote(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	assert.Empty(t, userNotifications(t, app, alice.ID))
+	assert.Empty(t, userNotifications(t, app, bob.ID))
+}
+
+func TestApp_Notifications_ListAndMarkRead(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	adminRole := testutil.CreateAdminRole(t, app.DB, org.ID)
+	author := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&adminRole.ID), testutil.WithFullName("Author"))
+	alice := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithFullName("Alice"))
+	contact := testutil.CreateTestContact(t, app.DB, org.ID)
+
+	createMentionNote(t, app, org.ID, author.ID, contact.ID, userMention(alice)+" first")
+	createMentionNote(t, app, org.ID, author.ID, contact.ID, userMention(alice)+" second")
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, alice.ID)
+	require.NoError(t, app.ListNotifications(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var list struct {
+		Data struct {
+			Notifications []handlers.NotificationResponse `json:"notifications"`
+			Total         int64                           `json:"total"`
+			UnreadCount   int64                           `json:"unread_count"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &list))
+	require.Len(t, list.Data.Notifications, 2)
+	assert.Equal(t, int64(2), list.Data.UnreadCount)
+	first := list.Data.Notifications[0]
+	assert.Equal(t, "Author", first.ActorName)
+	assert.Equal(t, contact.ProfileName, first.ContactName)
+	assert.False(t, first.IsRead)
+
+	// Others can't mark Alice's notifications
+	req = testutil.NewRequest(t)
+	testutil.SetAuthContext(req, org.ID, author.ID)
+	testutil.SetPathParam(req, "id", first.ID.String())
+	require.NoError(t, app.MarkNotificationRead(req))
+	testutil.AssertErrorResponse(t, req, fasthttp.StatusNotFound, "Notification not found")
+
+	req = testutil.NewRequest(t)
+	testutil.SetAuthContext(req, org.ID, alice.ID)
+	testutil.SetPathParam(req, "id", first.ID.String())
+	require.NoError(t, app.MarkNotificationRead(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var count struct {
+		Data struct {
+			UnreadCount int64 `json:"unread_count"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &count))
+	assert.Equal(t, int64(1), count.Data.UnreadCount)
+
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, alice.ID)
+	testutil.SetQueryParam(req, "unread", "true")
+	require.NoError(t, app.ListNotifications(req))
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &list))
+	require.Len(t, list.Data.Notifications, 1)
+	assert.NotEqual(t, first.ID, list.Data.Notifications[0].ID)
+
+	req = testutil.NewRequest(t)
+	testutil.SetAuthContext(req, org.ID, alice.ID)
+	require.NoError(t, app.MarkAllNotificationsRead(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, alice.ID)
+	require.NoError(t, app.GetUnreadNotificationCount(req))
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &count))
+	assert.Equal(t, int64(0), count.Data.UnreadCount)
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/language"
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+// Agent skill proficiency, from basic to expert
+const (
+	minSkillProficiency     = 1
+	maxSkillProficiency     = 5
+	defaultSkillProficiency = 3
+	maxSkillLength          = 100
+)
+
+// normalizeSkill lowercases a skill name and collapses its whitespace
+func normalizeSkill(skill string) string {
+	return strings.ToLower(strings.Join(strings.Fields(skill), " "))
+}
+
+// normalizeSkills normalizes skill names, dropping blanks and duplicates
+func normalizeSkills(skills []string) ([]string, error) {
+	result := make([]string, 0, len(skills))
+	seen := make(map[string]bool, len(skills))
+	for _, skill := range skills {
+		skill = normalizeSkill(skill)
+		if skill == "" || seen[skill] {
+			continue
+		}
+		if len(skill) > maxSkillLength {
+			return nil, fmt.Errorf("skill %q is longer than %d characters", skill, maxSkillLength)
+		}
+		seen[skill] = true
+		result = append(result, skill)
+	}
+	return result, nil
+}
+
+// skillList returns the strings of a JSON list of skills, such as the skills
+// of a transfer step or keyword rule
+func skillList(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("skills must be a list")
+	}
+	skills := make([]string, 0, len(list))
+	for _, item := range list {
+		skill, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("skills must be strings")
+		}
+		skills = append(skills, skill)
+	}
+	return skills, nil
+}
+
+// normalizeContentSkills validates and normalizes the skills listed in a keyword
+// rule's response content
+func normalizeContentSkills(content map[string]interface{}) error {
+	raw, err := skillList(content["skills"])
+	if err != nil || raw == nil {
+		return err
+	}
+	skills, err := normalizeSkills(raw)
+	if err != nil {
+		return err
+	}
+	content["skills"] = skills
+	return nil
+}
+
+// stepTransferSkills returns the skills a transfer step requires. Skills may
+// use {{variables}}, e.g. {{language}} from a language menu.
+func stepTransferSkills(step *models.ChatbotFlowStep, data models.JSONB) []string {
+	raw, _ := skillList(step.TransferConfig["skills"])
+	for i, skill := range raw {
+		raw[i] = processTemplate(skill, data)
+	}
+	skills, _ := normalizeSkills(raw)
+	return skills
+}
+
+// contactSkills returns the skills the skill rules require to serve contact.
+// A rule matches on the contact's language, a tag, or a metadata field.
+func contactSkills(rules models.JSONBArray, contact *models.Contact) []string {
+	var skills []string
+	for _, item := range rules {
+		rule, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		field := getStringFromMap(rule, "field")
+		value := strings.TrimSpace(getStringFromMap(rule, "value"))
+		if value != "" && contactMatchesSkillRule(contact, field, value) {
+			skills = append(skills, getStringFromMap(rule, "skill"))
+		}
+	}
+	return skills
+}
+
+// contactMatchesSkillRule checks a contact attribute against the value of a skill rule
+func contactMatchesSkillRule(contact *models.Contact, field, value string) bool {
+	switch {
+	case field == "language":
+		return contact.Language != "" && language.Base(contact.Language) == language.Base(value)
+	case field == "tag":
+		for _, tag := range contact.Tags {
+			if s, ok := tag.(string); ok && strings.EqualFold(s, value) {
+				return true
+			}
+		}
+	case strings.HasPrefix(field, "metadata."):
+		if v, ok := contact.Metadata[strings.TrimPrefix(field, "metadata.")]; ok && v != nil {
+			return strings.EqualFold(fmt.Sprint(v), value)
+		}
+	}
+	return false
+}
+
+// validateSkillRules checks the fields and skills of skill rules
+func validateSkillRules(rules []map[string]interface{}) error {
+	for _, rule := range rules {
+		field := getStringFromMap(rule, "field")
+		if field != "language" && field != "tag" && (!strings.HasPrefix(field, "metadata.") || field == "metadata.") {
+			return fmt.Errorf("invalid skill rule field %q, expected language, tag or metadata.<key>", field)
+		}
+		if strings.TrimSpace(getStringFromMap(rule, "value")) == "" {
+			return fmt.Errorf("skill rule for %s needs a value", field)
+		}
+		skills, err := normalizeSkills([]string{getStringFromMap(rule, "skill")})
+		if err != nil {
+			return err
+		}
+		if len(skills) == 0 {
+			return fmt.Errorf("skill rule for %s needs a skill", field)
+		}
+	}
+	return nil
+}
+
+// transferSkills returns the skills required by a transfer: those asked for by
+// the flow, keyword rule or API, plus those required by the contact's attributes
+func transferSkills(settings *models.ChatbotSettings, contact *models.Contact, requested []string) []string {
+	skills := append([]string{}, requested...)
+	if settings != nil {
+		skills = append(skills, contactSkills(settings.AgentAssignment.SkillRules, contact)...)
+	}
+	normalized, _ := normalizeSkills(skills)
+	return normalized
+}
+
+// skillCandidate is an available agent with every skill a transfer requires
+type skillCandidate struct {
+	UserID uuid.UUID
+	Score  int   // sum of the agent's proficiency in the required skills
+	InTeam bool  // member of the transfer's team
+	Load   int64 // active transfers assigned to the agent
+}
+
+// bestSkillCandidate returns the agent a transfer goes to: members of the
+// transfer's team first, then the most proficient, then the least busy
+func bestSkillCandidate(candidates []skillCandidate) *skillCandidate {
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if ci.InTeam != cj.InTeam {
+			return ci.InTeam
+		}
+		if ci.Score != cj.Score {
+			return ci.Score > cj.Score
+		}
+		return ci.Load < cj.Load
+	})
+	return &candidates[0]
+}
+
+// routeBySkills returns the available agent of any team best matching the
+// required skills, preferring members of teamID. Returns nil if no available
+// agent below their limit has all of them, leaving the transfer in its queue.
+func (a *App) routeBySkills(orgID uuid.UUID, teamID *uuid.UUID, skills []string) *uuid.UUID {
+	var candidates []skillCandidate
+	err := a.DB.Model(&models.AgentSkill{}).
+		Select("agent_skills.user_id, SUM(agent_skills.proficiency) AS score").
+		Joins("JOIN users ON users.id = agent_skills.user_id AND users.deleted_at IS NULL").
+		Joins("JOIN user_organizations ON user_organizations.user_id = agent_skills.user_id AND user_organizations.organization_id = agent_skills.organization_id AND user_organizations.deleted_at IS NULL").
+		Where("agent_skills.organization_id = ? AND agent_skills.skill IN ? AND users.is_available = ? AND users.is_active = ?", orgID, skills, true, true).
+		Group("agent_skills.user_id").
+		Having("COUNT(DISTINCT agent_skills.skill) = ?", len(skills)).
+		Scan(&candidates).Error
+	if err != nil || len(candidates) == 0 {
+		a.Log.Debug("No available agent with required skills", "skills", skills, "error", err)
+		return nil
+	}
+
+	userIDs := make([]uuid.UUID, len(candidates))
+	for i, c := range candidates {
+		userIDs[i] = c.UserID
+	}
+
+	// Active transfers and limit per candidate
+	capacities := a.agentCapacities(orgID, userIDs)
+
+	inTeam := make(map[uuid.UUID]bool)
+	if teamID != nil {
+		var memberIDs []uuid.UUID
+		a.DB.Model(&models.TeamMember{}).
+			Where("team_id = ? AND user_id IN ?", *teamID, userIDs).
+			Pluck("user_id", &memberIDs)
+		for _, id := range memberIDs {
+			inTeam[id] = true
+		}
+	}
+
+	available := candidates[:0]
+	for _, c := range candidates {
+		capacity := capacities[c.UserID]
+		if capacity.Full() {
+			continue
+		}
+		c.Load = capacity.Load
+		c.InTeam = inTeam[c.UserID]
+		available = append(available, c)
+	}
+	best := bestSkillCandidate(available)
+	if best == nil {
+		a.Log.Debug("All agents with required skills are at capacity", "skills", skills)
+		return nil
+	}
+	a.Log.Debug("Skill-based routing assigned to agent", "user_id", best.UserID, "skills", skills, "score", best.Score, "current_load", best.Load)
+	return &best.UserID
+}
+
+// agentHasSkills checks whether an agent has all the required skills
+func (a *App) agentHasSkills(orgID, agentID uuid.UUID, skills []string) bool {
+	if len(skills) == 0 {
+		return true
+	}
+	var count int64
+	a.DB.Model(&models.AgentSkill{}).
+		Where("organization_id = ? AND user_id = ? AND skill IN ?", orgID, agentID, skills).
+		Distinct("skill").
+		Count(&count)
+	return count == int64(len(skills))
+}
+
+// assignTransfer picks the agent for a new transfer. Transfers requiring
+// skills are routed to the best matching agent, otherwise the team's
+// assignment strategy applies. Returns nil to leave the transfer in its queue.
+func (a *App) assignTransfer(orgID uuid.UUID, teamID *uuid.UUID, skills []string) *uuid.UUID {
+	if teamID != nil {
+		var team models.Team
+		if err := a.DB.Select("assignment_strategy").Where("id = ? AND organization_id = ?", *teamID, orgID).First(&team).Error; err == nil && team.AssignmentStrategy == models.AssignmentStrategyManual {
+			return nil
+		}
+	}
+	if len(skills) > 0 {
+		return a.routeBySkills(orgID, teamID, skills)
+	}
+	if teamID != nil {
+		return a.assignToTeam(*teamID, orgID)
+	}
+	return nil
+}
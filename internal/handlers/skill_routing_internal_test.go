@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeSkills(t *testing.T) {
+	skills, err := normalizeSkills([]string{"Hindi", " billing  support ", "", "hindi"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hindi", "billing support"}, skills)
+
+	_, err = normalizeSkills([]string{strings.Repeat("a", maxSkillLength+1)})
+	assert.Error(t, err)
+}
+
+func TestNormalizeAgentSkills(t *testing.T) {
+	skills, err := normalizeAgentSkills([]AgentSkillRequest{
+		{Skill: "Hindi", Proficiency: 5},
+		{Skill: "billing"},
+		{Skill: "hindi", Proficiency: 4},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []AgentSkillResponse{
+		{Skill: "hindi", Proficiency: 4},
+		{Skill: "billing", Proficiency: defaultSkillProficiency},
+	}, skills)
+
+	_, err = normalizeAgentSkills([]AgentSkillRequest{{Skill: "hindi", Proficiency: 6}})
+	assert.Error(t, err)
+	_, err = normalizeAgentSkills([]AgentSkillRequest{{Skill: " ", Proficiency: 3}})
+	assert.Error(t, err)
+}
+
+func TestContactSkills(t *testing.T) {
+	rules := models.JSONBArray{
+		map[string]interface{}{"field": "language", "value": "hi", "skill": "hindi"},
+		map[string]interface{}{"field": "tag", "value": "VIP", "skill": "tier 2"},
+		map[string]interface{}{"field": "metadata.plan", "value": "enterprise", "skill": "enterprise"},
+		map[string]interface{}{"field": "language", "value": "mr", "skill": "marathi"},
+	}
+	contact := &models.Contact{
+		Language: "hi_IN",
+		Tags:     models.JSONBArray{"vip"},
+		Metadata: models.JSONB{"plan": "Enterprise"},
+	}
+	assert.Equal(t, []string{"hindi", "tier 2", "enterprise"}, contactSkills(rules, contact))
+	assert.Empty(t, contactSkills(rules, &models.Contact{}))
+}
+
+func TestValidateSkillRules(t *testing.T) {
+	assert.NoError(t, validateSkillRules([]map[string]interface{}{
+		{"field": "language", "value": "hi", "skill": "hindi"},
+		{"field": "metadata.plan", "value": "pro", "skill": "tier 2"},
+	}))
+
+	invalid := []map[string]interface{}{
+		{"field": "name", "value": "x", "skill": "y"},
+		{"field": "metadata.", "value": "x", "skill": "y"},
+		{"field": "tag", "value": " ", "skill": "y"},
+		{"field": "tag", "value": "vip", "skill": ""},
+	}
+	for _, rule := range invalid {
+		assert.Error(t, validateSkillRules([]map[string]interface{}{rule}), rule)
+	}
+}
+
+func TestTransferSkills(t *testing.T) {
+	settings := &models.ChatbotSettings{AgentAssignment: models.AgentAssignmentConfig{
+		SkillRules: models.JSONBArray{
+			map[string]interface{}{"field": "language", "value": "hi", "skill": "hindi"},
+		},
+	}}
+	contact := &models.Contact{Language: "hi"}
+
+	assert.Equal(t, []string{"billing", "hindi"}, transferSkills(settings, contact, []string{"Billing", "hindi"}))
+	assert.Equal(t, []string{"billing"}, transferSkills(nil, contact, []string{"billing"}))
+}
+
+func TestBestSkillCandidate(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+
+	best := bestSkillCandidate([]skillCandidate{
+		{UserID: a, Score: 5, Load: 0},
+		{UserID: b, Score: 3, InTeam: true, Load: 4},
+	})
+	assert.Equal(t, b, best.UserID, "team members come first")
+
+	best = bestSkillCandidate([]skillCandidate{
+		{UserID: a, Score: 3, Load: 0},
+		{UserID: b, Score: 5, Load: 2},
+		{UserID: c, Score: 5, Load: 1},
+	})
+	assert.Equal(t, c, best.UserID, "most proficient, then least busy")
+
+	assert.Nil(t, bestSkillCandidate(nil))
+}
+
+func TestRouteBySkills(t *testing.T) {
+	app := newProcessorTestApp(t)
+	org, _ := createProcessorTestOrg(t, app)
+
+	addSkills := func(user *models.User, skills map[string]int) {
+		for skill, proficiency := range skills {
+			require.NoError(t, app.DB.Create(&models.AgentSkill{
+				BaseModel:      models.BaseModel{ID: uuid.New()},
+				OrganizationID: org.ID,
+				UserID:         user.ID,
+				Skill:          skill,
+				Proficiency:    proficiency,
+			}).Error)
+		}
+	}
+
+	novice := testutil.CreateTestUser(t, app.DB, org.ID)
+	expert := testutil.CreateTestUser(t, app.DB, org.ID)
+	away := testutil.CreateTestUser(t, app.DB, org.ID)
+	require.NoError(t, app.DB.Model(away).Update("is_available", false).Error)
+	addSkills(novice, map[string]int{"hindi": 2, "billing": 2})
+	addSkills(expert, map[string]int{"hindi": 5, "billing": 4})
+	addSkills(away, map[string]int{"hindi": 5, "billing": 5})
+
+	agentID := app.routeBySkills(org.ID, nil, []string{"hindi", "billing"})
+	require.NotNil(t, agentID)
+	assert.Equal(t, expert.ID, *agentID)
+
+	assert.Nil(t, app.routeBySkills(org.ID, nil, []string{"hindi", "tamil"}), "no agent has every skill")
+
+	assert.True(t, app.agentHasSkills(org.ID, novice.ID, []string{"hindi"}))
+	assert.False(t, app.agentHasSkills(org.ID, novice.ID, []string{"hindi", "tamil"}))
+}
@@ -55,6 +55,12 @@ func (p *SLAProcessor) Stop() {
 func (p *SLAProcessor) processStaleTransfers() {
 	now := time.Now()
 
+	// Reopen snoozed conversations first so their transfers are checked again
+	p.app.wakeSnoozedConversations(now)
+
+	// Satisfaction surveys are sent for every organization, SLA or not
+	p.app.sendDueCSATSurveys(now)
+
 	// Get all organizations with SLA enabled (use cache)
 	settings, err := p.app.getSLAEnabledSettingsCached()
 	if err != nil {
@@ -98,7 +104,7 @@ func (p *SLAProcessor) autoCloseExpiredTransfers(orgID uuid.UUID, settings model
 	if err := p.app.DB.Where(
 		"organization_id = ? AND status = ? AND expires_at IS NOT NULL AND expires_at < ?",
 		orgID, models.TransferStatusActive, now,
-	).Find(&transfers).Error; err != nil {
+	).Where(transferNotWaitingOnCustomer).Find(&transfers).Error; err != nil {
 		p.app.Log.Error("Failed to find expired transfers", "error", err, "org_id", orgID)
 		return
 	}
@@ -128,7 +134,7 @@ func (p *SLAProcessor) autoCloseExpiredTransfers(orgID uuid.UUID, settings model
 
 		// Send auto-close message to customer if configured
 		if settings.SLA.AutoCloseMessage != "" {
-			p.sendSLAAutoCloseToCustomer(transfer, settings.SLA.AutoCloseMessage)
+			p.sendSLAAutoCloseToCustomer(transfer, settings.SLA.AutoCloseMessage, settings.Translations)
 		}
 
 		// Update transfer status
@@ -150,6 +156,14 @@ func (p *SLAProcessor) autoCloseExpiredTransfers(orgID uuid.UUID, settings model
 
 		// Broadcast update
 		p.broadcastTransferUpdate(transfer, string(models.TransferStatusExpired))
+
+		p.app.resolveConversation(orgID, transfer.ContactID, transfer.WhatsAppAccount, slaExpiredResolution, nil)
+		p.app.scheduleCSATSurvey(&transfer)
+
+		// The agent has room for a queued transfer
+		if transfer.AgentID != nil {
+			p.app.assignQueuedTransfers(orgID, *transfer.AgentID)
+		}
 	}
 
 	if closedCount > 0 {
@@ -163,7 +177,7 @@ func (p *SLAProcessor) escalateTransfers(orgID uuid.UUID, settings models.Chatbo
 	if err := p.app.DB.Where(
 		"organization_id = ? AND status = ? AND sla_escalation_at IS NOT NULL AND sla_escalation_at < ? AND escalation_level < 2",
 		orgID, models.TransferStatusActive, now,
-	).Find(&transfers).Error; err != nil {
+	).Where(transferNotWaitingOnCustomer).Find(&transfers).Error; err != nil {
 		p.app.Log.Error("Failed to find transfers for escalation", "error", err, "org_id", orgID)
 		return
 	}
@@ -226,7 +240,7 @@ func (p *SLAProcessor) escalateTransfers(orgID uuid.UUID, settings models.Chatbo
 
 		// Send warning message to customer if configured
 		if newLevel == 1 && settings.SLA.WarningMessage != "" {
-			p.sendSLAWarningToCustomer(transfer, settings.SLA.WarningMessage)
+			p.sendSLAWarningToCustomer(transfer, settings.SLA.WarningMessage, settings.Translations)
 		}
 	}
 
@@ -240,7 +254,7 @@ func (p *SLAProcessor) markSLABreached(orgID uuid.UUID, settings models.ChatbotS
 	result := p.app.DB.Model(&models.AgentTransfer{}).Where(
 		"organization_id = ? AND status = ? AND sla_breached = ? AND sla_response_deadline IS NOT NULL AND sla_response_deadline < ? AND agent_id IS NULL",
 		orgID, models.TransferStatusActive, false, now,
-	).Updates(map[string]interface{}{
+	).Where(transferNotWaitingOnCustomer).Updates(map[string]interface{}{
 		"sla_breached":    true,
 		"sla_breached_at": now,
 	})
@@ -305,8 +319,8 @@ func (p *SLAProcessor) notifyEscalation(transfer models.AgentTransfer, settings
 	)
 }
 
-// sendSLAWarningToCustomer sends a warning message to the customer
-func (p *SLAProcessor) sendSLAWarningToCustomer(transfer models.AgentTransfer, message string) {
+// sendSLAWarningToCustomer sends a warning message to the customer in their language
+func (p *SLAProcessor) sendSLAWarningToCustomer(transfer models.AgentTransfer, message string, translations models.JSONB) {
 	// Get WhatsApp account
 	var account models.WhatsAppAccount
 	if err := p.app.DB.Where("name = ?", transfer.WhatsAppAccount).First(&account).Error; err != nil {
@@ -320,6 +334,7 @@ func (p *SLAProcessor) sendSLAWarningToCustomer(transfer models.AgentTransfer, m
 		p.app.Log.Error("Failed to load contact for SLA warning", "error", err)
 		return
 	}
+	message = localized(translations, contactLanguage(&contact), "sla_warning_message", message)
 
 	// Send using unified message sender
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -340,8 +355,8 @@ func (p *SLAProcessor) sendSLAWarningToCustomer(transfer models.AgentTransfer, m
 	p.app.Log.Info("SLA warning message sent to customer", "phone", transfer.PhoneNumber, "transfer_id", transfer.ID)
 }
 
-// sendSLAAutoCloseToCustomer sends an auto-close notification message to the customer
-func (p *SLAProcessor) sendSLAAutoCloseToCustomer(transfer models.AgentTransfer, message string) {
+// sendSLAAutoCloseToCustomer sends an auto-close notification message to the customer in their language
+func (p *SLAProcessor) sendSLAAutoCloseToCustomer(transfer models.AgentTransfer, message string, translations models.JSONB) {
 	// Get WhatsApp account
 	var account models.WhatsAppAccount
 	if err := p.app.DB.Where("name = ?", transfer.WhatsAppAccount).First(&account).Error; err != nil {
@@ -355,6 +370,7 @@ func (p *SLAProcessor) sendSLAAutoCloseToCustomer(transfer models.AgentTransfer,
 		p.app.Log.Error("Failed to load contact for SLA auto-close message", "error", err)
 		return
 	}
+	message = localized(translations, contactLanguage(&contact), "sla_auto_close_message", message)
 
 	// Send using unified message sender
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -542,7 +558,7 @@ func (p *SLAProcessor) sendChatbotReminder(contact models.Contact, settings mode
 		Account: &account,
 		Contact: &contact,
 		Type:    models.MessageTypeText,
-		Content: settings.ClientInactivity.ReminderMessage,
+		Content: localized(settings.Translations, contactLanguage(&contact), "client_reminder_message", settings.ClientInactivity.ReminderMessage),
 	}, SLASendOptions())
 
 	if err != nil {
@@ -581,7 +597,7 @@ func (p *SLAProcessor) autoCloseChatbotSession(contact models.Contact, settings
 				Account: &account,
 				Contact: &contact,
 				Type:    models.MessageTypeText,
-				Content: settings.ClientInactivity.AutoCloseMessage,
+				Content: localized(settings.Translations, contactLanguage(&contact), "client_auto_close_message", settings.ClientInactivity.AutoCloseMessage),
 			}, SLASendOptions())
 
 			if err != nil {
@@ -178,6 +178,9 @@ func TestSLAAutoCloseSkippedWhenAgentActive(t *testing.T) {
 
 func TestSLAAutoCloseFiresWhenNoAgentResponse(t *testing.T) {
 	app := newSLATestApp(t)
+	if app.Redis == nil {
+		t.Skip("Redis not available")
+	}
 	org := testutil.CreateTestOrganization(t, app.DB)
 	contact := testutil.CreateTestContact(t, app.DB, org.ID)
 	agent := testutil.CreateTestUser(t, app.DB, org.ID)
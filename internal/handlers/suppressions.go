@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/contactutil"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+	"gorm.io/gorm"
+)
+
+// ErrContactSuppressed is returned when sending a marketing template to a contact
+// on the organization's suppression list
+var ErrContactSuppressed = errors.New("contact has opted out of marketing messages")
+
+// SuppressionRequest represents the request body for adding a phone number to the suppression list
+type SuppressionRequest struct {
+	PhoneNumber string `json:"phone_number"`
+	Reason      string `json:"reason"`
+}
+
+// SuppressionResponse represents a suppression list entry in API responses
+type SuppressionResponse struct {
+	ID          uuid.UUID                `json:"id"`
+	PhoneNumber string                   `json:"phone_number"`
+	Source      models.SuppressionSource `json:"source"`
+	Reason      string                   `json:"reason"`
+	CreatedByID *uuid.UUID               `json:"created_by_id,omitempty"`
+	CreatedAt   time.Time                `json:"created_at"`
+}
+
+// ListSuppressions returns the organization's suppression list, newest first
+func (a *App) ListSuppressions(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceContacts, models.ActionRead); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	query := a.DB.Model(&models.ContactSuppression{}).Where("organization_id = ?", orgID)
+	if search := string(r.RequestCtx.QueryArgs().Peek("search")); search != "" {
+		query = query.Where("phone_number LIKE ?", "%"+contactutil.NormalizePhone(search)+"%")
+	}
+	if source := string(r.RequestCtx.QueryArgs().Peek("source")); source != "" {
+		query = query.Where("source = ?", source)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var entries []models.ContactSuppression
+	if err := pg.Apply(query.Order("created_at DESC")).Find(&entries).Error; err != nil {
+		a.Log.Error("Failed to list suppressions", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list suppressions", nil, "")
+	}
+
+	shouldMask := a.ShouldMaskPhoneNumbers(orgID)
+	result := make([]SuppressionResponse, len(entries))
+	for i, e := range entries {
+		result[i] = suppressionToResponse(e)
+		if shouldMask {
+			result[i].PhoneNumber = MaskPhoneNumber(result[i].PhoneNumber)
+		}
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"suppressions": result,
+		"total":        total,
+		"page":         pg.Page,
+		"limit":        pg.Limit,
+	})
+}
+
+// CreateSuppression adds a phone number to the suppression list
+func (a *App) CreateSuppression(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceContacts, models.ActionWrite); err != nil {
+		return nil
+	}
+
+	var req SuppressionRequest
+	if err := a.decodeRequest(r, &req); err != nil {
+		return nil
+	}
+
+	phone := contactutil.NormalizePhone(req.PhoneNumber)
+	if phone == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "phone_number is required", nil, "")
+	}
+	if len(phone) > 50 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "phone_number must be at most 50 characters", nil, "")
+	}
+
+	created, err := contactutil.Suppress(a.DB, orgID, phone, models.SuppressionSourceManual, strings.TrimSpace(req.Reason), &userID)
+	if err != nil {
+		a.Log.Error("Failed to add suppression", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to add suppression", nil, "")
+	}
+	if !created {
+		return r.SendErrorEnvelope(fasthttp.StatusConflict, "Phone number is already suppressed", nil, "")
+	}
+
+	var entry models.ContactSuppression
+	if err := a.DB.Where("organization_id = ? AND phone_number = ?", orgID, phone).First(&entry).Error; err != nil {
+		a.Log.Error("Failed to load suppression", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to add suppression", nil, "")
+	}
+
+	a.Log.Info("Phone number suppressed", "org_id", orgID, "phone", phone, "user_id", userID)
+
+	return r.SendEnvelope(suppressionToResponse(entry))
+}
+
+// DeleteSuppression removes a phone number from the suppression list
+func (a *App) DeleteSuppression(r *fastglue.Request) error {
+	orgID, userID, err := a.getOrgAndUserID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+	if err := a.requirePermission(r, userID, models.ResourceContacts, models.ActionDelete); err != nil {
+		return nil
+	}
+
+	id, err := parsePathUUID(r, "id", "suppression")
+	if err != nil {
+		return nil
+	}
+
+	result := a.DB.Where("id = ? AND organization_id = ?", id, orgID).Delete(&models.ContactSuppression{})
+	if result.Error != nil {
+		a.Log.Error("Failed to delete suppression", "error", result.Error)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to delete suppression", nil, "")
+	}
+	if result.RowsAffected == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, "Suppression not found", nil, "")
+	}
+
+	a.Log.Info("Suppression removed", "org_id", orgID, "id", id, "user_id", userID)
+
+	return r.SendEnvelope(map[string]string{"message": "Suppression removed"})
+}
+
+// handleOptOutKeywords updates the suppression list when an incoming message is
+// an opt-out or opt-in keyword. Returns true if the message was handled and
+// should not be processed further. Keywords apply even when the chatbot is disabled.
+func (a *App) handleOptOutKeywords(account *models.WhatsAppAccount, contact *models.Contact, text string) bool {
+	if strings.TrimSpace(text) == "" {
+		return false
+	}
+
+	var cfg models.OptOutConfig
+	var translations models.JSONB
+	settings, err := a.getChatbotSettingsCached(account.OrganizationID, account.Name)
+	switch {
+	case err == nil:
+		cfg = settings.OptOut
+		translations = settings.Translations
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		a.Log.Warn("Failed to load chatbot settings, using default opt-out keywords", "error", err, "org_id", account.OrganizationID)
+	}
+
+	switch {
+	case matchOptOutKeyword(text, optOutKeywordsOrDefault(cfg.Keywords, models.DefaultOptOutKeywords)):
+		created, err := contactutil.Suppress(a.DB, account.OrganizationID, contact.PhoneNumber, models.SuppressionSourceKeyword,
+			"Replied \""+strings.TrimSpace(text)+"\"", nil)
+		if err != nil {
+			a.Log.Error("Failed to suppress contact", "error", err, "contact", contact.PhoneNumber)
+			return true
+		}
+		if created {
+			a.Log.Info("Contact opted out", "contact_id", contact.ID, "org_id", account.OrganizationID)
+		}
+		if cfg.Message != "" {
+			if err := a.sendAndSaveTextMessage(account, contact, localized(translations, contactLanguage(contact), "opt_out_message", cfg.Message)); err != nil {
+				a.Log.Error("Failed to send opt-out confirmation", "error", err, "contact", contact.PhoneNumber)
+			}
+		}
+		return true
+
+	case matchOptOutKeyword(text, optOutKeywordsOrDefault(cfg.OptInKeywords, models.DefaultOptInKeywords)):
+		removed, err := contactutil.Unsuppress(a.DB, account.OrganizationID, contact.PhoneNumber)
+		if err != nil {
+			a.Log.Error("Failed to unsuppress contact", "error", err, "contact", contact.PhoneNumber)
+			return true
+		}
+		// Contacts who never opted out may use the keyword for something else, e.g. a flow trigger
+		if !removed {
+			return false
+		}
+		a.Log.Info("Contact opted back in", "contact_id", contact.ID, "org_id", account.OrganizationID)
+		if cfg.OptInMessage != "" {
+			if err := a.sendAndSaveTextMessage(account, contact, localized(translations, contactLanguage(contact), "opt_in_message", cfg.OptInMessage)); err != nil {
+				a.Log.Error("Failed to send opt-in confirmation", "error", err, "contact", contact.PhoneNumber)
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// matchOptOutKeyword reports whether text is exactly one of the keywords,
+// ignoring case and surrounding or repeated whitespace
+func matchOptOutKeyword(text string, keywords []string) bool {
+	text = strings.Join(strings.Fields(text), " ")
+	for _, kw := range keywords {
+		kw = strings.Join(strings.Fields(kw), " ")
+		if kw != "" && strings.EqualFold(text, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// optOutKeywordsOrDefault returns the configured keywords, or defaults if none are configured
+func optOutKeywordsOrDefault(keywords models.StringArray, defaults []string) []string {
+	if len(keywords) == 0 {
+		return defaults
+	}
+	return keywords
+}
+
+// normalizeOptOutKeywords trims and deduplicates opt-out or opt-in keywords
+func normalizeOptOutKeywords(keywords []string) (models.StringArray, error) {
+	const (
+		maxKeywords      = 20
+		maxKeywordLength = 50
+	)
+
+	result := make(models.StringArray, 0, len(keywords))
+	seen := make(map[string]bool, len(keywords))
+	for _, kw := range keywords {
+		kw = strings.Join(strings.Fields(kw), " ")
+		if kw == "" || seen[strings.ToLower(kw)] {
+			continue
+		}
+		if len(kw) > maxKeywordLength {
+			return nil, fmt.Errorf("keyword %q must be at most %d characters", kw, maxKeywordLength)
+		}
+		seen[strings.ToLower(kw)] = true
+		result = append(result, kw)
+	}
+	if len(result) > maxKeywords {
+		return nil, fmt.Errorf("at most %d keywords are allowed", maxKeywords)
+	}
+	return result, nil
+}
+
+// isMarketingTemplate reports whether a template may only be sent to contacts who haven't opted out
+func isMarketingTemplate(template *models.Template) bool {
+	return template != nil && strings.EqualFold(template.Category, string(models.TemplateCategoryMarketing))
+}
+
+func suppressionToResponse(e models.ContactSuppression) SuppressionResponse {
+	return SuppressionResponse{
+		ID:          e.ID,
+		PhoneNumber: e.PhoneNumber,
+		Source:      e.Source,
+		Reason:      e.Reason,
+		CreatedByID: e.CreatedByID,
+		CreatedAt:   e.CreatedAt,
+	}
+}
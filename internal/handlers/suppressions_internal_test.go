@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/shridarpatil/whatomate/internal/contactutil"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchOptOutKeyword(t *testing.T) {
+	keywords := []string{"STOP", "opt out"}
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"STOP", true},
+		{"stop", true},
+		{"  Stop \n", true},
+		{"OPT   OUT", true},
+		{"please stop", false},
+		{"stopped", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchOptOutKeyword(tt.text, keywords))
+		})
+	}
+}
+
+func TestNormalizeOptOutKeywords(t *testing.T) {
+	keywords, err := normalizeOptOutKeywords([]string{" STOP ", "stop", "", "opt   out"})
+	require.NoError(t, err)
+	assert.Equal(t, models.StringArray{"STOP", "opt out"}, keywords)
+
+	tooMany := make([]string, 21)
+	for i := range tooMany {
+		tooMany[i] = string(rune('a' + i))
+	}
+	_, err = normalizeOptOutKeywords(tooMany)
+	assert.Error(t, err)
+}
+
+func TestHandleOptOutKeywords(t *testing.T) {
+	app := newProcessorTestApp(t)
+	if app.Redis == nil {
+		t.Skip("TEST_REDIS_URL not set, skipping test")
+	}
+	org, account := createProcessorTestOrg(t, app)
+	contact := testutil.CreateTestContactWith(t, app.DB, org.ID, testutil.WithContactAccount(account.Name))
+	t.Cleanup(func() { app.InvalidateChatbotSettingsCache(org.ID) })
+
+	isSuppressed := func() bool {
+		t.Helper()
+		suppressed, err := contactutil.IsSuppressed(app.DB, org.ID, contact.PhoneNumber)
+		require.NoError(t, err)
+		return suppressed
+	}
+
+	t.Run("default keywords without settings", func(t *testing.T) {
+		assert.True(t, app.handleOptOutKeywords(account, contact, "stop"))
+		assert.True(t, isSuppressed())
+
+		var entry models.ContactSuppression
+		require.NoError(t, app.DB.Where("organization_id = ?", org.ID).First(&entry).Error)
+		assert.Equal(t, models.SuppressionSourceKeyword, entry.Source)
+
+		assert.True(t, app.handleOptOutKeywords(account, contact, "START"))
+		assert.False(t, isSuppressed())
+	})
+
+	t.Run("opt-in keyword is ignored when not suppressed", func(t *testing.T) {
+		assert.False(t, app.handleOptOutKeywords(account, contact, "START"))
+	})
+
+	t.Run("other messages are not handled", func(t *testing.T) {
+		assert.False(t, app.handleOptOutKeywords(account, contact, "please stop by tomorrow"))
+		assert.False(t, isSuppressed())
+	})
+
+	t.Run("configured keywords replace defaults", func(t *testing.T) {
+		settings := models.ChatbotSettings{
+			OrganizationID: org.ID,
+			OptOut: models.OptOutConfig{
+				Keywords:      models.StringArray{"NO MORE"},
+				OptInKeywords: models.StringArray{"RESUME"},
+			},
+		}
+		require.NoError(t, app.DB.Create(&settings).Error)
+		app.InvalidateChatbotSettingsCache(org.ID)
+
+		assert.False(t, app.handleOptOutKeywords(account, contact, "STOP"))
+		assert.True(t, app.handleOptOutKeywords(account, contact, "no more"))
+		assert.True(t, isSuppressed())
+		assert.True(t, app.handleOptOutKeywords(account, contact, "resume"))
+		assert.False(t, isSuppressed())
+	})
+}
@@ -0,0 +1,198 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/contactutil"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestApp_CreateSuppression(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+
+	t.Run("success", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, map[string]any{"phone_number": " +15550001111 ", "reason": "Customer request"})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		require.NoError(t, app.CreateSuppression(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data handlers.SuppressionResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.Equal(t, "15550001111", resp.Data.PhoneNumber)
+		assert.Equal(t, models.SuppressionSourceManual, resp.Data.Source)
+		assert.Equal(t, "Customer request", resp.Data.Reason)
+		require.NotNil(t, resp.Data.CreatedByID)
+		assert.Equal(t, user.ID, *resp.Data.CreatedByID)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, map[string]any{"phone_number": "15550001111"})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		require.NoError(t, app.CreateSuppression(req))
+		assert.Equal(t, fasthttp.StatusConflict, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("missing phone number", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, map[string]any{"reason": "no phone"})
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		require.NoError(t, app.CreateSuppression(req))
+		assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("without permission", func(t *testing.T) {
+		role := testutil.CreateTestRoleWithKeys(t, app.DB, org.ID, "contacts-reader", []string{"contacts:read"})
+		reader := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+		req := testutil.NewJSONRequest(t, map[string]any{"phone_number": "15550002222"})
+		testutil.SetAuthContext(req, org.ID, reader.ID)
+
+		require.NoError(t, app.CreateSuppression(req))
+		assert.Equal(t, fasthttp.StatusForbidden, testutil.GetResponseStatusCode(req))
+	})
+}
+
+func TestApp_ListSuppressions(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+	otherOrg := testutil.CreateTestOrganization(t, app.DB)
+
+	_, err := contactutil.Suppress(app.DB, org.ID, "15550001111", models.SuppressionSourceKeyword, `Replied "STOP"`, nil)
+	require.NoError(t, err)
+	_, err = contactutil.Suppress(app.DB, org.ID, "15550002222", models.SuppressionSourceManual, "", &user.ID)
+	require.NoError(t, err)
+	_, err = contactutil.Suppress(app.DB, otherOrg.ID, "15550003333", models.SuppressionSourceManual, "", nil)
+	require.NoError(t, err)
+
+	list := func(t *testing.T, query string) []handlers.SuppressionResponse {
+		t.Helper()
+		req := testutil.NewGETRequest(t)
+		req.RequestCtx.QueryArgs().Parse(query)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+
+		require.NoError(t, app.ListSuppressions(req))
+		require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data struct {
+				Suppressions []handlers.SuppressionResponse `json:"suppressions"`
+				Total        int64                          `json:"total"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.Equal(t, int64(len(resp.Data.Suppressions)), resp.Data.Total)
+		return resp.Data.Suppressions
+	}
+
+	assert.Len(t, list(t, ""), 2, "other organizations' entries must not be listed")
+
+	bySource := list(t, "source=keyword")
+	require.Len(t, bySource, 1)
+	assert.Equal(t, "15550001111", bySource[0].PhoneNumber)
+
+	bySearch := list(t, "search=%2B1555000222")
+	require.Len(t, bySearch, 1)
+	assert.Equal(t, "15550002222", bySearch[0].PhoneNumber)
+}
+
+func TestApp_DeleteSuppression(t *testing.T) {
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	role := testutil.CreateAdminRole(t, app.DB, org.ID)
+	user := testutil.CreateTestUser(t, app.DB, org.ID, testutil.WithRoleID(&role.ID))
+
+	_, err := contactutil.Suppress(app.DB, org.ID, "15550001111", models.SuppressionSourceManual, "", nil)
+	require.NoError(t, err)
+	var entry models.ContactSuppression
+	require.NoError(t, app.DB.Where("organization_id = ?", org.ID).First(&entry).Error)
+
+	t.Run("other organization", func(t *testing.T) {
+		otherOrg := testutil.CreateTestOrganization(t, app.DB)
+		otherRole := testutil.CreateAdminRole(t, app.DB, otherOrg.ID)
+		otherUser := testutil.CreateTestUser(t, app.DB, otherOrg.ID, testutil.WithRoleID(&otherRole.ID))
+		req := testutil.NewJSONRequest(t, nil)
+		testutil.SetAuthContext(req, otherOrg.ID, otherUser.ID)
+		testutil.SetPathParam(req, "id", entry.ID.String())
+
+		require.NoError(t, app.DeleteSuppression(req))
+		assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, nil)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", entry.ID.String())
+
+		require.NoError(t, app.DeleteSuppression(req))
+		assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		suppressed, err := contactutil.IsSuppressed(app.DB, org.ID, "15550001111")
+		require.NoError(t, err)
+		assert.False(t, suppressed)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, nil)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", uuid.New().String())
+
+		require.NoError(t, app.DeleteSuppression(req))
+		assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+	})
+}
+
+func TestApp_ImportRecipients_SkipsSuppressed(t *testing.T) {
+	app := newTestApp(t, withQueue(testutil.NewMockQueue()))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	account := testutil.CreateTestWhatsAppAccount(t, app.DB, org.ID)
+	template := testutil.CreateTestTemplate(t, app.DB, org.ID, account.Name)
+	campaign := createTestCampaign(t, app, org.ID, template.ID, user.ID, account.Name, models.CampaignStatusDraft)
+
+	_, err := contactutil.Suppress(app.DB, org.ID, "15550001111", models.SuppressionSourceKeyword, "", nil)
+	require.NoError(t, err)
+
+	req := testutil.NewJSONRequest(t, map[string]any{
+		"recipients": []map[string]any{
+			{"phone_number": "+15550001111", "recipient_name": "Opted Out"},
+			{"phone_number": "+15550002222", "recipient_name": "Subscribed"},
+		},
+	})
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", campaign.ID.String())
+
+	require.NoError(t, app.ImportRecipients(req))
+	require.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data struct {
+			AddedCount      int   `json:"added_count"`
+			SuppressedCount int   `json:"suppressed_count"`
+			TotalRecipients int64 `json:"total_recipients"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, 1, resp.Data.AddedCount)
+	assert.Equal(t, 1, resp.Data.SuppressedCount)
+	assert.Equal(t, int64(1), resp.Data.TotalRecipients)
+
+	var recipients []models.BulkMessageRecipient
+	require.NoError(t, app.DB.Where("campaign_id = ?", campaign.ID).Find(&recipients).Error)
+	require.Len(t, recipients, 1)
+	assert.Equal(t, "+15550002222", recipients[0].PhoneNumber)
+}
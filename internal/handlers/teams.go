@@ -11,10 +11,11 @@ import (
 
 // TeamRequest represents create/update team request
 type TeamRequest struct {
-	Name               string                   `json:"name" validate:"required"`
-	Description        string                   `json:"description"`
-	AssignmentStrategy models.AssignmentStrategy `json:"assignment_strategy"` // round_robin, load_balanced, manual
-	IsActive           bool                     `json:"is_active"`
+	Name                   string                    `json:"name" validate:"required"`
+	Description            string                    `json:"description"`
+	AssignmentStrategy     models.AssignmentStrategy `json:"assignment_strategy"` // round_robin, load_balanced, manual
+	IsActive               bool                      `json:"is_active"`
+	MaxConcurrentTransfers *int                      `json:"max_concurrent_transfers"` // Per member, 0 for no limit
 }
 
 // TeamMemberRequest represents add member request
@@ -25,15 +26,16 @@ type TeamMemberRequest struct {
 
 // TeamResponse represents team in API response
 type TeamResponse struct {
-	ID                 uuid.UUID                 `json:"id"`
-	Name               string                    `json:"name"`
-	Description        string                    `json:"description"`
-	AssignmentStrategy models.AssignmentStrategy `json:"assignment_strategy"`
-	IsActive           bool                      `json:"is_active"`
-	MemberCount        int                       `json:"member_count"`
-	Members            []TeamMemberResponse      `json:"members,omitempty"`
-	CreatedAt          time.Time                 `json:"created_at"`
-	UpdatedAt          time.Time                 `json:"updated_at"`
+	ID                     uuid.UUID                 `json:"id"`
+	Name                   string                    `json:"name"`
+	Description            string                    `json:"description"`
+	AssignmentStrategy     models.AssignmentStrategy `json:"assignment_strategy"`
+	IsActive               bool                      `json:"is_active"`
+	MaxConcurrentTransfers int                       `json:"max_concurrent_transfers"`
+	MemberCount            int                       `json:"member_count"`
+	Members                []TeamMemberResponse      `json:"members,omitempty"`
+	CreatedAt              time.Time                 `json:"created_at"`
+	UpdatedAt              time.Time                 `json:"updated_at"`
 }
 
 // TeamMemberResponse represents team member in API response
@@ -165,12 +167,21 @@ func (a *App) CreateTeam(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid assignment strategy", nil, "")
 	}
 
+	maxTransfers := 0
+	if req.MaxConcurrentTransfers != nil {
+		if *req.MaxConcurrentTransfers < 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "max_concurrent_transfers cannot be negative", nil, "")
+		}
+		maxTransfers = *req.MaxConcurrentTransfers
+	}
+
 	team := models.Team{
-		OrganizationID:     orgID,
-		Name:               req.Name,
-		Description:        req.Description,
-		AssignmentStrategy: strategy,
-		IsActive:           true,
+		OrganizationID:         orgID,
+		Name:                   req.Name,
+		Description:            req.Description,
+		AssignmentStrategy:     strategy,
+		MaxConcurrentTransfers: maxTransfers,
+		IsActive:               true,
 	}
 
 	if err := a.DB.Create(&team).Error; err != nil {
@@ -232,11 +243,27 @@ func (a *App) UpdateTeam(r *fastglue.Request) error {
 		team.AssignmentStrategy = req.AssignmentStrategy
 	}
 
+	if req.MaxConcurrentTransfers != nil {
+		if *req.MaxConcurrentTransfers < 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "max_concurrent_transfers cannot be negative", nil, "")
+		}
+		team.MaxConcurrentTransfers = *req.MaxConcurrentTransfers
+	}
+
 	if err := a.DB.Save(&team).Error; err != nil {
 		a.Log.Error("Failed to update team", "error", err)
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update team", nil, "")
 	}
 
+	// Members may have room for queued transfers under the new limit
+	if req.MaxConcurrentTransfers != nil {
+		for _, m := range team.Members {
+			if m.Role == models.TeamRoleAgent {
+				a.assignQueuedTransfers(orgID, m.UserID)
+			}
+		}
+	}
+
 	return r.SendEnvelope(map[string]interface{}{"team": buildTeamResponse(&team, false)})
 }
 
@@ -481,14 +508,15 @@ func (a *App) RemoveTeamMember(r *fastglue.Request) error {
 // Helper function to build team response
 func buildTeamResponse(team *models.Team, includeMembers bool) TeamResponse {
 	resp := TeamResponse{
-		ID:                 team.ID,
-		Name:               team.Name,
-		Description:        team.Description,
-		AssignmentStrategy: team.AssignmentStrategy,
-		IsActive:           team.IsActive,
-		MemberCount:        len(team.Members),
-		CreatedAt:          team.CreatedAt,
-		UpdatedAt:          team.UpdatedAt,
+		ID:                     team.ID,
+		Name:                   team.Name,
+		Description:            team.Description,
+		AssignmentStrategy:     team.AssignmentStrategy,
+		IsActive:               team.IsActive,
+		MaxConcurrentTransfers: team.MaxConcurrentTransfers,
+		MemberCount:            len(team.Members),
+		CreatedAt:              team.CreatedAt,
+		UpdatedAt:              team.UpdatedAt,
 	}
 
 	if includeMembers && len(team.Members) > 0 {
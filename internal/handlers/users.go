@@ -19,6 +19,8 @@ type UserRequest struct {
 	FullName string     `json:"full_name"`
 	RoleID   *uuid.UUID `json:"role_id"`
 	IsActive *bool      `json:"is_active"`
+	// Max active transfers in the organization, 0 for no limit
+	MaxConcurrentTransfers *int `json:"max_concurrent_transfers"`
 }
 
 // superAdminField is used to extract is_super_admin separately from the request body.
@@ -38,19 +40,20 @@ func parseSuperAdminField(r *fastglue.Request) *bool {
 
 // UserResponse represents the response for a user (without sensitive data)
 type UserResponse struct {
-	ID             uuid.UUID    `json:"id"`
-	Email          string       `json:"email"`
-	FullName       string       `json:"full_name"`
-	RoleID         *uuid.UUID   `json:"role_id,omitempty"`
-	Role           *RoleInfo    `json:"role,omitempty"`
-	IsActive       bool         `json:"is_active"`
-	IsAvailable    bool         `json:"is_available"`
-	IsSuperAdmin   bool         `json:"is_super_admin"`
-	IsMember       bool         `json:"is_member"`
-	OrganizationID uuid.UUID    `json:"organization_id"`
-	Settings       models.JSONB `json:"settings,omitempty"`
-	CreatedAt      string       `json:"created_at"`
-	UpdatedAt      string       `json:"updated_at"`
+	ID                     uuid.UUID    `json:"id"`
+	Email                  string       `json:"email"`
+	FullName               string       `json:"full_name"`
+	RoleID                 *uuid.UUID   `json:"role_id,omitempty"`
+	Role                   *RoleInfo    `json:"role,omitempty"`
+	IsActive               bool         `json:"is_active"`
+	IsAvailable            bool         `json:"is_available"`
+	IsSuperAdmin           bool         `json:"is_super_admin"`
+	IsMember               bool         `json:"is_member"`
+	MaxConcurrentTransfers int          `json:"max_concurrent_transfers"`
+	OrganizationID         uuid.UUID    `json:"organization_id"`
+	Settings               models.JSONB `json:"settings,omitempty"`
+	CreatedAt              string       `json:"created_at"`
+	UpdatedAt              string       `json:"updated_at"`
 }
 
 // PermissionInfo represents permission info in role response
@@ -129,10 +132,12 @@ func (a *App) ListUsers(r *fastglue.Request) error {
 			Find(&orgMemberships)
 	}
 	orgRoleMap := make(map[uuid.UUID]*models.CustomRole, len(orgMemberships))
+	maxTransfersMap := make(map[uuid.UUID]int, len(orgMemberships))
 	for _, m := range orgMemberships {
 		if m.Role != nil {
 			orgRoleMap[m.UserID] = m.Role
 		}
+		maxTransfersMap[m.UserID] = m.MaxConcurrentTransfers
 	}
 
 	// Fetch actual home org IDs (separate query avoids JOIN column conflict)
@@ -159,6 +164,7 @@ func (a *App) ListUsers(r *fastglue.Request) error {
 		}
 		resp := userToResponse(user)
 		resp.IsMember = homeOrgMap[user.ID] != orgID
+		resp.MaxConcurrentTransfers = maxTransfersMap[user.ID]
 		response[i] = resp
 	}
 
@@ -204,6 +210,7 @@ func (a *App) GetUser(r *fastglue.Request) error {
 
 	resp := userToResponse(user)
 	resp.IsMember = user.OrganizationID != orgID
+	resp.MaxConcurrentTransfers = userOrg.MaxConcurrentTransfers
 	return r.SendEnvelope(resp)
 }
 
@@ -227,6 +234,13 @@ func (a *App) CreateUser(r *fastglue.Request) error {
 	if req.Email == "" || req.Password == "" || req.FullName == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Email, password, and full_name are required", nil, "")
 	}
+	maxTransfers := 0
+	if req.MaxConcurrentTransfers != nil {
+		if *req.MaxConcurrentTransfers < 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "max_concurrent_transfers cannot be negative", nil, "")
+		}
+		maxTransfers = *req.MaxConcurrentTransfers
+	}
 
 	// Determine role
 	var roleID *uuid.UUID
@@ -287,16 +301,18 @@ func (a *App) CreateUser(r *fastglue.Request) error {
 		var existingOrg models.UserOrganization
 		if err := a.DB.Unscoped().Where("user_id = ? AND organization_id = ?", softDeleted.ID, orgID).First(&existingOrg).Error; err == nil {
 			a.DB.Unscoped().Model(&existingOrg).Updates(map[string]interface{}{
-				"deleted_at": nil,
-				"role_id":    roleID,
-				"is_default": true,
+				"deleted_at":               nil,
+				"role_id":                  roleID,
+				"is_default":               true,
+				"max_concurrent_transfers": maxTransfers,
 			})
 		} else {
 			a.DB.Create(&models.UserOrganization{
-				UserID:         softDeleted.ID,
-				OrganizationID: orgID,
-				RoleID:         roleID,
-				IsDefault:      true,
+				UserID:                 softDeleted.ID,
+				OrganizationID:         orgID,
+				RoleID:                 roleID,
+				IsDefault:              true,
+				MaxConcurrentTransfers: maxTransfers,
 			})
 		}
 
@@ -314,7 +330,9 @@ func (a *App) CreateUser(r *fastglue.Request) error {
 		softDeleted.IsActive = true
 		softDeleted.IsSuperAdmin = isSuperAdmin
 
-		return r.SendEnvelope(userToResponse(softDeleted))
+		resp := userToResponse(softDeleted)
+		resp.MaxConcurrentTransfers = maxTransfers
+		return r.SendEnvelope(resp)
 	}
 
 	user := models.User{
@@ -334,10 +352,11 @@ func (a *App) CreateUser(r *fastglue.Request) error {
 
 	// Create UserOrganization entry
 	userOrg := models.UserOrganization{
-		UserID:         user.ID,
-		OrganizationID: orgID,
-		RoleID:         roleID,
-		IsDefault:      true,
+		UserID:                 user.ID,
+		OrganizationID:         orgID,
+		RoleID:                 roleID,
+		IsDefault:              true,
+		MaxConcurrentTransfers: maxTransfers,
 	}
 	if err := a.DB.Create(&userOrg).Error; err != nil {
 		a.Log.Error("Failed to create user organization entry", "error", err)
@@ -347,7 +366,9 @@ func (a *App) CreateUser(r *fastglue.Request) error {
 	// Load role for response
 	a.DB.Preload("Role").First(&user, user.ID)
 
-	return r.SendEnvelope(userToResponse(user))
+	resp := userToResponse(user)
+	resp.MaxConcurrentTransfers = maxTransfers
+	return r.SendEnvelope(resp)
 }
 
 // UpdateUser updates a user
@@ -403,30 +424,48 @@ func (a *App) UpdateUser(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Insufficient permissions to change roles", nil, "")
 	}
 
-	// For cross-org members, only allow role updates
-	if isMember {
-		if req.RoleID == nil {
-			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Only role can be updated for organization members", nil, "")
+	// Only users with users:write permission can change transfer limits
+	if req.MaxConcurrentTransfers != nil {
+		if !a.HasPermission(currentUserID, models.ResourceUsers, models.ActionWrite, orgID) {
+			return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Insufficient permissions to change transfer limits", nil, "")
 		}
-		// Validate role exists and belongs to org
-		var newRole models.CustomRole
-		if err := a.DB.Where("id = ? AND organization_id = ?", req.RoleID, orgID).First(&newRole).Error; err != nil {
-			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid role", nil, "")
+		if *req.MaxConcurrentTransfers < 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "max_concurrent_transfers cannot be negative", nil, "")
+		}
+	}
+
+	// For cross-org members, only allow role and transfer limit updates
+	if isMember {
+		if req.RoleID == nil && req.MaxConcurrentTransfers == nil {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Only role and transfer limit can be updated for organization members", nil, "")
+		}
+		if req.RoleID != nil {
+			// Validate role exists and belongs to org
+			var newRole models.CustomRole
+			if err := a.DB.Where("id = ? AND organization_id = ?", req.RoleID, orgID).First(&newRole).Error; err != nil {
+				return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid role", nil, "")
+			}
+			// Update role in user_organizations only
+			if err := a.DB.Model(&models.UserOrganization{}).
+				Where("user_id = ? AND organization_id = ?", id, orgID).
+				Update("role_id", req.RoleID).Error; err != nil {
+				a.Log.Error("Failed to update member role", "error", err)
+				return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update member role", nil, "")
+			}
+			a.InvalidateUserPermissionsCache(user.ID)
+			user.RoleID = req.RoleID
+			user.Role = &newRole
 		}
-		// Update role in user_organizations only
-		if err := a.DB.Model(&models.UserOrganization{}).
-			Where("user_id = ? AND organization_id = ?", id, orgID).
-			Update("role_id", req.RoleID).Error; err != nil {
-			a.Log.Error("Failed to update member role", "error", err)
-			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update member role", nil, "")
+		if req.MaxConcurrentTransfers != nil {
+			if err := a.updateMaxConcurrentTransfers(orgID, id, *req.MaxConcurrentTransfers); err != nil {
+				return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update transfer limit", nil, "")
+			}
 		}
-		a.InvalidateUserPermissionsCache(user.ID)
 
 		// Return updated response
-		user.RoleID = req.RoleID
-		user.Role = &newRole
 		resp := userToResponse(user)
 		resp.IsMember = true
+		resp.MaxConcurrentTransfers = a.maxConcurrentTransfers(orgID, id)
 		return r.SendEnvelope(resp)
 	}
 
@@ -503,10 +542,38 @@ func (a *App) UpdateUser(r *fastglue.Request) error {
 		a.InvalidateUserPermissionsCache(user.ID)
 	}
 
+	if req.MaxConcurrentTransfers != nil {
+		if err := a.updateMaxConcurrentTransfers(orgID, id, *req.MaxConcurrentTransfers); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to update transfer limit", nil, "")
+		}
+	}
+
 	// Load role for response
 	a.DB.Preload("Role").First(&user, user.ID)
 
-	return r.SendEnvelope(userToResponse(user))
+	resp := userToResponse(user)
+	resp.MaxConcurrentTransfers = a.maxConcurrentTransfers(orgID, id)
+	return r.SendEnvelope(resp)
+}
+
+// maxConcurrentTransfers returns a user's own limit of active transfers in the organization
+func (a *App) maxConcurrentTransfers(orgID, userID uuid.UUID) int {
+	var userOrg models.UserOrganization
+	a.DB.Select("max_concurrent_transfers").Where("user_id = ? AND organization_id = ?", userID, orgID).First(&userOrg)
+	return userOrg.MaxConcurrentTransfers
+}
+
+// updateMaxConcurrentTransfers sets a user's limit of active transfers in the
+// organization, then assigns them queued transfers if it leaves room for more
+func (a *App) updateMaxConcurrentTransfers(orgID, userID uuid.UUID, limit int) error {
+	if err := a.DB.Model(&models.UserOrganization{}).
+		Where("user_id = ? AND organization_id = ?", userID, orgID).
+		Update("max_concurrent_transfers", limit).Error; err != nil {
+		a.Log.Error("Failed to update transfer limit", "error", err, "user_id", userID)
+		return err
+	}
+	a.assignQueuedTransfers(orgID, userID)
+	return nil
 }
 
 // DeleteUser deletes a user or removes a member from the organization
@@ -883,6 +950,9 @@ func (a *App) UpdateAvailability(r *fastglue.Request) error {
 		status = "away"
 		// Return agent's active transfers to queue when going away
 		transfersReturned = a.ReturnAgentTransfersToQueue(userID, orgID)
+	} else {
+		// Take queued transfers waiting for an agent with capacity
+		a.assignQueuedTransfers(orgID, userID)
 	}
 
 	// Get the current break start time if away
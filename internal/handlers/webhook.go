@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
@@ -136,6 +137,10 @@ type WebhookPayload struct {
 						SHA256   string `json:"sha256"`
 						Caption  string `json:"caption,omitempty"`
 					} `json:"video,omitempty"`
+					Button *struct {
+						Payload string `json:"payload"`
+						Text    string `json:"text"`
+					} `json:"button,omitempty"`
 					Interactive *struct {
 						Type        string `json:"type"`
 						ButtonReply *struct {
@@ -186,7 +191,9 @@ type WebhookPayload struct {
 	} `json:"entry"`
 }
 
-// WebhookHandler processes incoming webhook events from Meta
+// WebhookHandler receives webhook events from Meta. After the signature is
+// verified the raw payload is pushed onto the webhook stream and acknowledged
+// right away; webhook workers process it (see processWebhookPayload).
 func (a *App) WebhookHandler(r *fastglue.Request) error {
 	body := r.RequestCtx.PostBody()
 	signature := r.RequestCtx.Request.Header.Peek("X-Hub-Signature-256")
@@ -197,78 +204,56 @@ func (a *App) WebhookHandler(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Invalid payload", nil, "")
 	}
 
-	// Track if signature has been verified (only need to verify once per request)
-	signatureVerified := false
+	if !a.verifyWebhookPayloadSignature(&payload, body, signature) {
+		return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Invalid signature", nil, "")
+	}
 
-	// Process each entry
-	for _, entry := range payload.Entry {
-		for _, change := range entry.Changes {
-			// Handle template status updates
-			if change.Field == "message_template_status_update" {
-				a.Log.Info("Received template status update",
-					"event", change.Value.Event,
-					"template_name", change.Value.MessageTemplateName,
-					"template_language", change.Value.MessageTemplateLanguage,
-					"waba_id", entry.ID,
-				)
-				go a.processTemplateStatusUpdate(entry.ID, change.Value.Event, change.Value.MessageTemplateName, change.Value.MessageTemplateLanguage, change.Value.Reason)
-				continue
-			}
+	if a.Queue == nil {
+		// No stream configured: process in the background as before
+		data := append([]byte(nil), body...)
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			_ = a.processWebhookPayload(context.Background(), data)
+		}()
+		return r.SendEnvelope(map[string]string{"status": "ok"})
+	}
 
-			if change.Field != "messages" {
-				continue
-			}
+	if err := a.Queue.EnqueueWebhook(r.RequestCtx, body); err != nil {
+		// Not acknowledging makes Meta redeliver, which is safer than dropping the event
+		a.Log.Error("Failed to enqueue webhook", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusServiceUnavailable, "Failed to queue webhook", nil, "")
+	}
 
-			phoneNumberID := change.Value.Metadata.PhoneNumberID
+	return r.SendEnvelope(map[string]string{"status": "ok"})
+}
 
-			// Verify webhook signature on first message processing (uses cached account)
-			if !signatureVerified && len(signature) > 0 && phoneNumberID != "" {
-				account, err := a.getWhatsAppAccountCached(phoneNumberID)
-				if err == nil && account.AppSecret != "" {
-					if !verifyWebhookSignature(body, signature, []byte(account.AppSecret)) {
-						a.Log.Warn("Invalid webhook signature", "phone_id", phoneNumberID)
-						return r.SendErrorEnvelope(fasthttp.StatusForbidden, "Invalid signature", nil, "")
-					}
-					a.Log.Debug("Webhook signature verified successfully")
-				}
-				signatureVerified = true
+// verifyWebhookPayloadSignature checks the X-Hub-Signature-256 header against the
+// app secret of the first account the payload is for. Requests without a signature,
+// or for accounts without an app secret, are accepted.
+func (a *App) verifyWebhookPayloadSignature(payload *WebhookPayload, body, signature []byte) bool {
+	if len(signature) == 0 {
+		return true
+	}
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			phoneNumberID := change.Value.Metadata.PhoneNumberID
+			if change.Field != "messages" || phoneNumberID == "" {
+				continue
 			}
-
-			// Process messages
-			for _, msg := range change.Value.Messages {
-				a.Log.Info("Received message",
-					"from", msg.From,
-					"type", msg.Type,
-					"phone_number_id", phoneNumberID,
-				)
-
-				// Get contact profile name
-				profileName := ""
-				for _, contact := range change.Value.Contacts {
-					if contact.WaID == msg.From {
-						profileName = contact.Profile.Name
-						break
-					}
-				}
-
-				// Process message asynchronously
-				go a.processIncomingMessage(phoneNumberID, msg, profileName)
+			account, err := a.getWhatsAppAccountCached(phoneNumberID)
+			if err != nil || account.AppSecret == "" {
+				return true
 			}
-
-			// Process status updates
-			for _, status := range change.Value.Statuses {
-				a.Log.Info("Received status update",
-					"message_id", status.ID,
-					"status", status.Status,
-				)
-
-				go a.processStatusUpdate(phoneNumberID, status)
+			if !verifyWebhookSignature(body, signature, []byte(account.AppSecret)) {
+				a.Log.Warn("Invalid webhook signature", "phone_id", phoneNumberID)
+				return false
 			}
+			a.Log.Debug("Webhook signature verified successfully")
+			return true
 		}
 	}
-
-	// Always respond with 200 to acknowledge receipt
-	return r.SendEnvelope(map[string]string{"status": "ok"})
+	return true
 }
 
 func (a *App) processIncomingMessage(phoneNumberID string, msg interface{}, profileName string) {
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// WebhookDeliveryDetail is a delivery together with every attempt made for it
+type WebhookDeliveryDetail struct {
+	models.WebhookDelivery
+	DeliveryAttempts []models.WebhookDeliveryAttempt `json:"delivery_attempts"`
+}
+
+// ListWebhookDeliveries returns the delivery log of a webhook, newest first
+func (a *App) ListWebhookDeliveries(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	webhookID, err := parsePathUUID(r, "id", "webhook")
+	if err != nil {
+		return nil
+	}
+
+	if _, err := findByIDAndOrg[models.Webhook](a.DB, r, webhookID, orgID, "Webhook"); err != nil {
+		return nil
+	}
+
+	pg := parsePagination(r)
+	query := a.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ? AND organization_id = ?", webhookID, orgID)
+
+	if status := string(r.RequestCtx.QueryArgs().Peek("status")); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if event := string(r.RequestCtx.QueryArgs().Peek("event")); event != "" {
+		query = query.Where("event = ?", event)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	// The payload is only returned by the detail endpoint
+	var deliveries []models.WebhookDelivery
+	if err := pg.Apply(query.Omit("payload").Order("created_at DESC")).Find(&deliveries).Error; err != nil {
+		a.Log.Error("Failed to list webhook deliveries", "error", err, "webhook_id", webhookID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to list webhook deliveries", nil, "")
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"deliveries": deliveries,
+		"total":      total,
+		"page":       pg.Page,
+		"limit":      pg.Limit,
+	})
+}
+
+// GetWebhookDelivery returns a single delivery with its attempts
+func (a *App) GetWebhookDelivery(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	delivery, err := a.findWebhookDelivery(r, orgID)
+	if err != nil {
+		return nil
+	}
+
+	detail, err := a.webhookDeliveryDetail(delivery)
+	if err != nil {
+		a.Log.Error("Failed to load webhook delivery attempts", "error", err, "delivery_id", delivery.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load webhook delivery", nil, "")
+	}
+
+	return r.SendEnvelope(detail)
+}
+
+// ReplayWebhookDelivery sends the payload of an earlier delivery again as a new
+// delivery. The first attempt is made synchronously; if it fails, the new
+// delivery is retried like any other.
+func (a *App) ReplayWebhookDelivery(r *fastglue.Request) error {
+	orgID, err := a.getOrgID(r)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusUnauthorized, "Unauthorized", nil, "")
+	}
+
+	original, err := a.findWebhookDelivery(r, orgID)
+	if err != nil {
+		return nil
+	}
+
+	webhook, err := findByIDAndOrg[models.Webhook](a.DB, r, original.WebhookID, orgID, "Webhook")
+	if err != nil {
+		return nil
+	}
+	if !webhook.IsActive {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, "Webhook is inactive", nil, "")
+	}
+
+	replay, err := a.createWebhookDelivery(*webhook, original.Event, []byte(original.Payload), &original.ID)
+	if err != nil {
+		a.Log.Error("Failed to create webhook replay", "error", err, "delivery_id", original.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to replay webhook delivery", nil, "")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	a.attemptWebhookDelivery(ctx, *webhook, replay)
+
+	detail, err := a.webhookDeliveryDetail(replay)
+	if err != nil {
+		a.Log.Error("Failed to load webhook delivery attempts", "error", err, "delivery_id", replay.ID)
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, "Failed to load webhook delivery", nil, "")
+	}
+
+	return r.SendEnvelope(detail)
+}
+
+// findWebhookDelivery loads the delivery named by the delivery_id path parameter,
+// scoped to the webhook in the id path parameter. Sends an error response on failure.
+func (a *App) findWebhookDelivery(r *fastglue.Request, orgID uuid.UUID) (*models.WebhookDelivery, error) {
+	webhookID, err := parsePathUUID(r, "id", "webhook")
+	if err != nil {
+		return nil, err
+	}
+	deliveryID, err := parsePathUUID(r, "delivery_id", "delivery")
+	if err != nil {
+		return nil, err
+	}
+
+	var delivery models.WebhookDelivery
+	if err := a.DB.Where("id = ? AND webhook_id = ? AND organization_id = ?", deliveryID, webhookID, orgID).
+		First(&delivery).Error; err != nil {
+		_ = r.SendErrorEnvelope(fasthttp.StatusNotFound, "Webhook delivery not found", nil, "")
+		return nil, errEnvelopeSent
+	}
+	return &delivery, nil
+}
+
+// webhookDeliveryDetail loads the attempts of a delivery
+func (a *App) webhookDeliveryDetail(delivery *models.WebhookDelivery) (WebhookDeliveryDetail, error) {
+	detail := WebhookDeliveryDetail{WebhookDelivery: *delivery}
+	err := a.DB.Where("delivery_id = ?", delivery.ID).
+		Order("attempt_number ASC").
+		Find(&detail.DeliveryAttempts).Error
+	return detail, err
+}
@@ -0,0 +1,349 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/handlers"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// newStatusServer starts a webhook receiver that answers with the status returned by status()
+func newStatusServer(t *testing.T, status func() int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status())
+		_, _ = fmt.Fprintf(w, "delivery %s", r.Header.Get("X-Webhook-Delivery-ID"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+// dispatchAndFetchDelivery dispatches an event and returns the single delivery it recorded
+func dispatchAndFetchDelivery(t *testing.T, app *handlers.App, orgID uuid.UUID, wh *models.Webhook) models.WebhookDelivery {
+	t.Helper()
+	clearWebhookCache(t, app.Redis, orgID)
+	t.Cleanup(func() { clearWebhookCache(t, app.Redis, orgID) })
+
+	app.DispatchWebhook(orgID, models.WebhookEventMessageIncoming, map[string]string{"message_id": "m1"})
+	app.WaitForBackgroundTasks()
+
+	var deliveries []models.WebhookDelivery
+	require.NoError(t, app.DB.Where("webhook_id = ?", wh.ID).Find(&deliveries).Error)
+	require.Len(t, deliveries, 1)
+	return deliveries[0]
+}
+
+func TestApp_DispatchWebhook_RecordsDelivery(t *testing.T) {
+	t.Parallel()
+
+	server, hits := newStatusServer(t, func() int { return http.StatusOK })
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	wh := createTestWebhook(t, app, org.ID, "CRM", server.URL, []string{"message.incoming"})
+
+	delivery := dispatchAndFetchDelivery(t, app, org.ID, wh)
+
+	assert.Equal(t, int32(1), hits.Load())
+	assert.Equal(t, models.WebhookDeliveryStatusSucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.LastStatusCode)
+	assert.NotNil(t, delivery.DeliveredAt)
+	assert.Nil(t, delivery.NextAttemptAt)
+	assert.Contains(t, delivery.Payload, `"message_id":"m1"`)
+
+	var attempts []models.WebhookDeliveryAttempt
+	require.NoError(t, app.DB.Where("delivery_id = ?", delivery.ID).Find(&attempts).Error)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, 1, attempts[0].AttemptNumber)
+	assert.Equal(t, server.URL, attempts[0].RequestURL)
+	assert.Equal(t, delivery.Payload, attempts[0].RequestBody)
+	assert.Equal(t, "delivery "+delivery.ID.String(), attempts[0].ResponseBody)
+	assert.Empty(t, attempts[0].Error)
+}
+
+func TestApp_DispatchWebhook_FailureSchedulesRetry(t *testing.T) {
+	t.Parallel()
+
+	server, hits := newStatusServer(t, func() int { return http.StatusServiceUnavailable })
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	wh := createTestWebhook(t, app, org.ID, "CRM", server.URL, []string{"message.incoming"})
+
+	delivery := dispatchAndFetchDelivery(t, app, org.ID, wh)
+
+	// A single attempt is made inline; retries are left to the retrier
+	assert.Equal(t, int32(1), hits.Load())
+	assert.Equal(t, models.WebhookDeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.LastStatusCode)
+	assert.NotEmpty(t, delivery.LastError)
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.True(t, delivery.NextAttemptAt.After(time.Now()))
+}
+
+func TestWebhookRetrier_RetriesDueDeliveries(t *testing.T) {
+	t.Parallel()
+
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	server, hits := newStatusServer(t, func() int { return int(status.Load()) })
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	wh := createTestWebhook(t, app, org.ID, "CRM", server.URL, []string{"message.incoming"})
+
+	delivery := dispatchAndFetchDelivery(t, app, org.ID, wh)
+	require.Equal(t, models.WebhookDeliveryStatusPending, delivery.Status)
+
+	// Make the retry due and let the endpoint recover
+	status.Store(http.StatusOK)
+	require.NoError(t, app.DB.Model(&delivery).Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	retrier := handlers.NewWebhookRetrier(app, 50*time.Millisecond)
+	go retrier.Start(ctx)
+
+	require.Eventually(t, func() bool {
+		var d models.WebhookDelivery
+		return app.DB.Where("id = ?", delivery.ID).First(&d).Error == nil &&
+			d.Status == models.WebhookDeliveryStatusSucceeded
+	}, 5*time.Second, 50*time.Millisecond)
+	retrier.Stop()
+
+	assert.Equal(t, int32(2), hits.Load())
+	var attempts []models.WebhookDeliveryAttempt
+	require.NoError(t, app.DB.Where("delivery_id = ?", delivery.ID).Order("attempt_number").Find(&attempts).Error)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, http.StatusInternalServerError, attempts[0].ResponseStatus)
+	assert.Equal(t, http.StatusOK, attempts[1].ResponseStatus)
+}
+
+func TestWebhookRetrier_FailsDeliveryForInactiveWebhook(t *testing.T) {
+	t.Parallel()
+
+	server, hits := newStatusServer(t, func() int { return http.StatusInternalServerError })
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	wh := createTestWebhook(t, app, org.ID, "CRM", server.URL, []string{"message.incoming"})
+
+	delivery := dispatchAndFetchDelivery(t, app, org.ID, wh)
+	require.NoError(t, app.DB.Model(wh).Update("is_active", false).Error)
+	require.NoError(t, app.DB.Model(&delivery).Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	retrier := handlers.NewWebhookRetrier(app, 50*time.Millisecond)
+	go retrier.Start(ctx)
+
+	require.Eventually(t, func() bool {
+		var d models.WebhookDelivery
+		return app.DB.Where("id = ?", delivery.ID).First(&d).Error == nil &&
+			d.Status == models.WebhookDeliveryStatusFailed
+	}, 5*time.Second, 50*time.Millisecond)
+	retrier.Stop()
+
+	assert.Equal(t, int32(1), hits.Load(), "inactive webhook should not be retried")
+}
+
+// createTestDelivery inserts a delivery for wh directly into the DB
+func createTestDelivery(t *testing.T, app *handlers.App, wh *models.Webhook, status models.WebhookDeliveryStatus) *models.WebhookDelivery {
+	t.Helper()
+	d := &models.WebhookDelivery{
+		OrganizationID: wh.OrganizationID,
+		WebhookID:      wh.ID,
+		Event:          "message.incoming",
+		Payload:        `{"event":"message.incoming","data":{"message_id":"m1"}}`,
+		Status:         status,
+		Attempts:       1,
+	}
+	require.NoError(t, app.DB.Create(d).Error)
+	return d
+}
+
+func TestApp_ListWebhookDeliveries(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	wh := createTestWebhook(t, app, org.ID, "CRM", "https://example.com/hook", []string{"message.incoming"})
+	other := createTestWebhook(t, app, org.ID, "Other", "https://example.com/other", []string{"message.incoming"})
+
+	createTestDelivery(t, app, wh, models.WebhookDeliveryStatusSucceeded)
+	createTestDelivery(t, app, wh, models.WebhookDeliveryStatusFailed)
+	createTestDelivery(t, app, other, models.WebhookDeliveryStatusFailed)
+
+	t.Run("all", func(t *testing.T) {
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", wh.ID.String())
+
+		require.NoError(t, app.ListWebhookDeliveries(req))
+		assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+		var resp struct {
+			Data struct {
+				Deliveries []models.WebhookDelivery `json:"deliveries"`
+				Total      int64                    `json:"total"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		assert.Equal(t, int64(2), resp.Data.Total)
+		require.Len(t, resp.Data.Deliveries, 2)
+		for _, d := range resp.Data.Deliveries {
+			assert.Equal(t, wh.ID, d.WebhookID)
+			assert.Empty(t, d.Payload, "list should not include payloads")
+		}
+	})
+
+	t.Run("status filter", func(t *testing.T) {
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, org.ID, user.ID)
+		testutil.SetPathParam(req, "id", wh.ID.String())
+		req.RequestCtx.QueryArgs().Set("status", "failed")
+
+		require.NoError(t, app.ListWebhookDeliveries(req))
+
+		var resp struct {
+			Data struct {
+				Deliveries []models.WebhookDelivery `json:"deliveries"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+		require.Len(t, resp.Data.Deliveries, 1)
+		assert.Equal(t, models.WebhookDeliveryStatusFailed, resp.Data.Deliveries[0].Status)
+	})
+
+	t.Run("other organization", func(t *testing.T) {
+		otherOrg := testutil.CreateTestOrganization(t, app.DB)
+		otherUser := testutil.CreateTestUser(t, app.DB, otherOrg.ID)
+		req := testutil.NewGETRequest(t)
+		testutil.SetAuthContext(req, otherOrg.ID, otherUser.ID)
+		testutil.SetPathParam(req, "id", wh.ID.String())
+
+		require.NoError(t, app.ListWebhookDeliveries(req))
+		assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+	})
+}
+
+func TestApp_GetWebhookDelivery(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	wh := createTestWebhook(t, app, org.ID, "CRM", "https://example.com/hook", []string{"message.incoming"})
+	other := createTestWebhook(t, app, org.ID, "Other", "https://example.com/other", []string{"message.incoming"})
+
+	delivery := createTestDelivery(t, app, wh, models.WebhookDeliveryStatusFailed)
+	require.NoError(t, app.DB.Create(&models.WebhookDeliveryAttempt{
+		DeliveryID:     delivery.ID,
+		AttemptNumber:  1,
+		RequestURL:     wh.URL,
+		ResponseStatus: http.StatusBadGateway,
+		ResponseBody:   "bad gateway",
+	}).Error)
+
+	req := testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", wh.ID.String())
+	testutil.SetPathParam(req, "delivery_id", delivery.ID.String())
+
+	require.NoError(t, app.GetWebhookDelivery(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.WebhookDeliveryDetail `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.Equal(t, delivery.ID, resp.Data.ID)
+	assert.Equal(t, delivery.Payload, resp.Data.Payload)
+	require.Len(t, resp.Data.DeliveryAttempts, 1)
+	assert.Equal(t, http.StatusBadGateway, resp.Data.DeliveryAttempts[0].ResponseStatus)
+	assert.Equal(t, "bad gateway", resp.Data.DeliveryAttempts[0].ResponseBody)
+
+	// The delivery is not reachable through another webhook
+	req = testutil.NewGETRequest(t)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", other.ID.String())
+	testutil.SetPathParam(req, "delivery_id", delivery.ID.String())
+
+	require.NoError(t, app.GetWebhookDelivery(req))
+	assert.Equal(t, fasthttp.StatusNotFound, testutil.GetResponseStatusCode(req))
+}
+
+func TestApp_ReplayWebhookDelivery(t *testing.T) {
+	t.Parallel()
+
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, r.ContentLength)
+		_, _ = r.Body.Read(buf)
+		receivedBody = buf
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	app := newTestApp(t, withHTTPClient(&http.Client{Timeout: 5 * time.Second}))
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	wh := createTestWebhook(t, app, org.ID, "CRM", server.URL, []string{"message.incoming"})
+	original := createTestDelivery(t, app, wh, models.WebhookDeliveryStatusFailed)
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", wh.ID.String())
+	testutil.SetPathParam(req, "delivery_id", original.ID.String())
+
+	require.NoError(t, app.ReplayWebhookDelivery(req))
+	assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+
+	var resp struct {
+		Data handlers.WebhookDeliveryDetail `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(testutil.GetResponseBody(req), &resp))
+	assert.NotEqual(t, original.ID, resp.Data.ID)
+	require.NotNil(t, resp.Data.ReplayOfID)
+	assert.Equal(t, original.ID, *resp.Data.ReplayOfID)
+	assert.Equal(t, models.WebhookDeliveryStatusSucceeded, resp.Data.Status)
+	assert.Len(t, resp.Data.DeliveryAttempts, 1)
+	assert.Equal(t, original.Payload, string(receivedBody))
+
+	// The original delivery is left untouched
+	var reloaded models.WebhookDelivery
+	require.NoError(t, app.DB.Where("id = ?", original.ID).First(&reloaded).Error)
+	assert.Equal(t, models.WebhookDeliveryStatusFailed, reloaded.Status)
+}
+
+func TestApp_ReplayWebhookDelivery_InactiveWebhook(t *testing.T) {
+	t.Parallel()
+
+	app := newTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	user := testutil.CreateTestUser(t, app.DB, org.ID)
+	wh := createTestWebhook(t, app, org.ID, "CRM", "https://example.com/hook", []string{"message.incoming"})
+	require.NoError(t, app.DB.Model(wh).Update("is_active", false).Error)
+	original := createTestDelivery(t, app, wh, models.WebhookDeliveryStatusFailed)
+
+	req := testutil.NewJSONRequest(t, nil)
+	testutil.SetAuthContext(req, org.ID, user.ID)
+	testutil.SetPathParam(req, "id", wh.ID.String())
+	testutil.SetPathParam(req, "delivery_id", original.ID.String())
+
+	require.NoError(t, app.ReplayWebhookDelivery(req))
+	assert.Equal(t, fasthttp.StatusBadRequest, testutil.GetResponseStatusCode(req))
+}
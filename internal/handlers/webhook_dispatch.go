@@ -7,7 +7,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -118,6 +120,33 @@ func containsEvent(events models.StringArray, event string) bool {
 	return false
 }
 
+const (
+	// webhookDeliveryLease is how long a delivery is reserved while an attempt is in flight.
+	// If the process dies mid-attempt, the retrier picks the delivery up after the lease expires.
+	webhookDeliveryLease = 2 * time.Minute
+	// webhookResponseExcerptLimit caps how much of a response body is stored per attempt
+	webhookResponseExcerptLimit = 1024
+)
+
+// webhookRetryBackoff is the delay before each retry; a delivery is attempted
+// once plus once per entry before it is marked failed.
+var webhookRetryBackoff = []time.Duration{
+	30 * time.Second,
+	2 * time.Minute,
+	10 * time.Minute,
+	time.Hour,
+	6 * time.Hour,
+}
+
+// maxWebhookAttempts is the total number of attempts made for a delivery
+var maxWebhookAttempts = len(webhookRetryBackoff) + 1
+
+// webhookResponse is the outcome of a single webhook HTTP request
+type webhookResponse struct {
+	StatusCode int
+	Body       string
+}
+
 func (a *App) sendWebhook(ctx context.Context, webhook models.Webhook, eventType string, data interface{}) {
 	payload := OutboundWebhookPayload{
 		Event:     eventType,
@@ -131,60 +160,139 @@ func (a *App) sendWebhook(ctx context.Context, webhook models.Webhook, eventType
 		return
 	}
 
-	// Retry logic with exponential backoff
-	maxRetries := 3
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		// Check if context was cancelled before retry
-		if ctx.Err() != nil {
-			a.Log.Warn("webhook delivery cancelled", "reason", ctx.Err(), "webhook_id", webhook.ID)
-			return
-		}
+	delivery, err := a.createWebhookDelivery(webhook, eventType, jsonData, nil)
+	if err != nil {
+		a.Log.Error("failed to record webhook delivery", "error", err, "webhook_id", webhook.ID)
+		return
+	}
 
-		if attempt > 0 {
-			// Exponential backoff: 1s, 2s, 4s
-			select {
-			case <-ctx.Done():
-				a.Log.Warn("webhook delivery cancelled during backoff", "reason", ctx.Err(), "webhook_id", webhook.ID)
-				return
-			case <-time.After(time.Duration(1<<attempt) * time.Second):
-			}
-		}
+	a.attemptWebhookDelivery(ctx, webhook, delivery)
+}
 
-		if err := a.sendWebhookRequest(ctx, webhook, jsonData); err != nil {
-			a.Log.Warn("webhook delivery failed",
-				"error", err,
-				"webhook_id", webhook.ID,
-				"attempt", attempt+1,
-				"max_retries", maxRetries,
-			)
-			continue
-		}
+// createWebhookDelivery stores a pending delivery, leased to the caller so the
+// retrier leaves it alone while the first attempt is in flight
+func (a *App) createWebhookDelivery(webhook models.Webhook, eventType string, jsonData []byte, replayOfID *uuid.UUID) (*models.WebhookDelivery, error) {
+	lease := time.Now().Add(webhookDeliveryLease)
+	delivery := &models.WebhookDelivery{
+		OrganizationID: webhook.OrganizationID,
+		WebhookID:      webhook.ID,
+		Event:          eventType,
+		Payload:        string(jsonData),
+		Status:         models.WebhookDeliveryStatusPending,
+		NextAttemptAt:  &lease,
+		ReplayOfID:     replayOfID,
+	}
+	if err := a.DB.Create(delivery).Error; err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// attemptWebhookDelivery sends a delivery once, records the attempt and
+// either completes the delivery or schedules the next retry
+func (a *App) attemptWebhookDelivery(ctx context.Context, webhook models.Webhook, delivery *models.WebhookDelivery) {
+	attemptNumber := delivery.Attempts + 1
+	started := time.Now()
+	resp, sendErr := a.sendWebhookRequest(ctx, webhook, delivery.ID, []byte(delivery.Payload))
+	finished := time.Now()
+
+	attempt := models.WebhookDeliveryAttempt{
+		DeliveryID:     delivery.ID,
+		AttemptNumber:  attemptNumber,
+		RequestURL:     webhook.URL,
+		RequestBody:    delivery.Payload,
+		ResponseStatus: resp.StatusCode,
+		ResponseBody:   resp.Body,
+		DurationMs:     finished.Sub(started).Milliseconds(),
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := a.DB.Create(&attempt).Error; err != nil {
+		a.Log.Error("failed to record webhook attempt", "error", err, "delivery_id", delivery.ID)
+	}
 
-		// Success
+	delivery.Attempts = attemptNumber
+	delivery.LastAttemptAt = &finished
+	delivery.LastStatusCode = resp.StatusCode
+	delivery.LastError = attempt.Error
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliveryStatusSucceeded
+		delivery.DeliveredAt = &finished
+		delivery.NextAttemptAt = nil
 		a.Log.Debug("webhook delivered",
 			"webhook_id", webhook.ID,
-			"event", eventType,
+			"delivery_id", delivery.ID,
+			"event", delivery.Event,
 			"url", webhook.URL,
 		)
-		return
+	case attemptNumber >= maxWebhookAttempts:
+		delivery.Status = models.WebhookDeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+		a.Log.Error("webhook delivery failed after all retries",
+			"webhook_id", webhook.ID,
+			"delivery_id", delivery.ID,
+			"event", delivery.Event,
+			"url", webhook.URL,
+		)
+	default:
+		next := finished.Add(webhookRetryBackoff[attemptNumber-1])
+		delivery.NextAttemptAt = &next
+		a.Log.Warn("webhook delivery failed",
+			"error", sendErr,
+			"webhook_id", webhook.ID,
+			"delivery_id", delivery.ID,
+			"attempt", attemptNumber,
+			"next_attempt_at", next,
+		)
 	}
 
-	a.Log.Error("webhook delivery failed after all retries",
-		"webhook_id", webhook.ID,
-		"event", eventType,
-		"url", webhook.URL,
-	)
+	if err := a.DB.Model(delivery).Updates(map[string]interface{}{
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"next_attempt_at":  delivery.NextAttemptAt,
+		"last_attempt_at":  delivery.LastAttemptAt,
+		"last_status_code": delivery.LastStatusCode,
+		"last_error":       delivery.LastError,
+		"delivered_at":     delivery.DeliveredAt,
+	}).Error; err != nil {
+		a.Log.Error("failed to update webhook delivery", "error", err, "delivery_id", delivery.ID)
+	}
 }
 
-func (a *App) sendWebhookRequest(ctx context.Context, webhook models.Webhook, jsonData []byte) error {
+// failWebhookDelivery marks a pending delivery as failed without attempting it
+func (a *App) failWebhookDelivery(delivery *models.WebhookDelivery, reason string) {
+	delivery.Status = models.WebhookDeliveryStatusFailed
+	delivery.NextAttemptAt = nil
+	delivery.LastError = reason
+	if err := a.DB.Model(delivery).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"next_attempt_at": nil,
+		"last_error":      reason,
+	}).Error; err != nil {
+		a.Log.Error("failed to update webhook delivery", "error", err, "delivery_id", delivery.ID)
+	}
+}
+
+// sendWebhookRequest posts jsonData to the webhook. The response status and the
+// start of the response body are returned even when the status is not 2xx.
+func (a *App) sendWebhookRequest(ctx context.Context, webhook models.Webhook, deliveryID uuid.UUID, jsonData []byte) (webhookResponse, error) {
+	var result webhookResponse
+
 	req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "Whatomate-Webhook/1.0")
+	if deliveryID != uuid.Nil {
+		// Lets receivers de-duplicate retries of the same delivery
+		req.Header.Set("X-Webhook-Delivery-ID", deliveryID.String())
+	}
 
 	// Add custom headers from webhook config
 	if webhook.Headers != nil {
@@ -204,16 +312,21 @@ func (a *App) sendWebhookRequest(ctx context.Context, webhook models.Webhook, js
 	// Send request
 	resp, err := a.HTTPClient.Do(req)
 	if err != nil {
-		return err
+		return result, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	result.StatusCode = resp.StatusCode
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseExcerptLimit))
+	// Postgres text columns reject invalid UTF-8 and NUL bytes
+	result.Body = strings.ReplaceAll(strings.ToValidUTF8(string(body), ""), "\x00", "")
+
 	// Check for successful status code (2xx)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return &WebhookError{StatusCode: resp.StatusCode}
+		return result, &WebhookError{StatusCode: resp.StatusCode}
 	}
 
-	return nil
+	return result, nil
 }
 
 func computeHMACSignature(data []byte, secret string) string {
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/shridarpatil/whatomate/internal/queue"
+)
+
+const (
+	// webhookDedupPrefix namespaces the Redis keys used to deduplicate inbound webhook events
+	webhookDedupPrefix = "whatomate:webhook:seen:"
+
+	// webhookDedupTTL is how long processed events are remembered. Meta redeliveries
+	// after this window fall back to the duplicate message check in the database.
+	webhookDedupTTL = 72 * time.Hour
+
+	// webhookEventLease is how long an event stays reserved by the worker processing it.
+	// If the worker dies, the event is processed again once the lease expires.
+	webhookEventLease = 5 * time.Minute
+
+	// webhookWorkerConcurrency is how many payloads each webhook worker processes
+	// at a time, so slow chatbot replies to a few contacts don't hold up the rest
+	webhookWorkerConcurrency = 16
+)
+
+// StartWebhookWorkers starts n workers that process inbound Meta webhooks from
+// the webhook stream. Several API instances may run workers; each payload is
+// handled by one of them. Payloads of a contact are processed in order while
+// other contacts' payloads proceed concurrently.
+func (a *App) StartWebhookWorkers(n int) error {
+	if a.Redis == nil {
+		return nil
+	}
+	if n <= 0 {
+		// Only API servers process webhooks; the worker command doesn't
+		a.Log.Error("Webhook workers disabled on this instance, inbound webhooks queue until an API server with webhook workers runs")
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.WebhookWorkersCancel = cancel
+
+	for i := 0; i < n; i++ {
+		consumer, err := queue.NewWebhookConsumer(a.Redis, a.Log, strconv.Itoa(i+1), webhookWorkerConcurrency)
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		a.webhookWorkers.Add(1)
+		go func() {
+			defer a.webhookWorkers.Done()
+			if err := consumer.Consume(ctx, a.processWebhookPayload, webhookPayloadKey); err != nil && ctx.Err() == nil {
+				a.Log.Error("Webhook worker stopped", "error", err)
+			}
+		}()
+	}
+
+	a.Log.Info("Webhook workers started", "count", n)
+	return nil
+}
+
+// StopWebhookWorkers stops the webhook workers and waits for in-flight payloads
+func (a *App) StopWebhookWorkers() {
+	if a.WebhookWorkersCancel != nil {
+		a.WebhookWorkersCancel()
+	}
+	a.webhookWorkers.Wait()
+}
+
+// processWebhookPayload handles a raw Meta webhook payload. Messages and status
+// updates that were already processed (Meta redelivers on timeouts) are skipped.
+// An error leaves the payload on the stream to be retried.
+func (a *App) processWebhookPayload(ctx context.Context, body []byte) error {
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		// Retrying cannot fix a malformed payload
+		a.Log.Error("Failed to parse queued webhook payload", "error", err)
+		return nil
+	}
+
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			// Handle template status updates
+			if change.Field == "message_template_status_update" {
+				a.Log.Info("Received template status update",
+					"event", change.Value.Event,
+					"template_name", change.Value.MessageTemplateName,
+					"template_language", change.Value.MessageTemplateLanguage,
+					"waba_id", entry.ID,
+				)
+				a.processTemplateStatusUpdate(entry.ID, change.Value.Event, change.Value.MessageTemplateName, change.Value.MessageTemplateLanguage, change.Value.Reason)
+				continue
+			}
+
+			if change.Field != "messages" {
+				continue
+			}
+
+			phoneNumberID := change.Value.Metadata.PhoneNumberID
+
+			// Process messages
+			for _, msg := range change.Value.Messages {
+				key := webhookEventKey("message", msg.ID)
+				claimed, err := a.claimWebhookEvent(ctx, key)
+				if err != nil {
+					return err
+				}
+				if !claimed {
+					a.Log.Debug("Duplicate webhook message, skipping", "message_id", msg.ID)
+					continue
+				}
+
+				a.Log.Info("Received message",
+					"from", msg.From,
+					"type", msg.Type,
+					"phone_number_id", phoneNumberID,
+				)
+
+				// Get contact profile name
+				profileName := ""
+				for _, contact := range change.Value.Contacts {
+					if contact.WaID == msg.From {
+						profileName = contact.Profile.Name
+						break
+					}
+				}
+
+				a.processIncomingMessage(phoneNumberID, msg, profileName)
+				a.completeWebhookEvent(ctx, key)
+			}
+
+			// Process status updates
+			for _, status := range change.Value.Statuses {
+				key := webhookEventKey("status", status.ID, status.Status)
+				claimed, err := a.claimWebhookEvent(ctx, key)
+				if err != nil {
+					return err
+				}
+				if !claimed {
+					a.Log.Debug("Duplicate webhook status update, skipping", "message_id", status.ID, "status", status.Status)
+					continue
+				}
+
+				a.Log.Info("Received status update",
+					"message_id", status.ID,
+					"status", status.Status,
+				)
+
+				a.processStatusUpdate(phoneNumberID, status)
+				a.completeWebhookEvent(ctx, key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// webhookPayloadKey returns the contact a payload is about, so a contact's
+// messages and status updates are processed in the order Meta sent them.
+// Payloads without a contact, such as template status updates, have no key.
+func webhookPayloadKey(body []byte) string {
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			if change.Field != "messages" {
+				continue
+			}
+			phoneNumberID := change.Value.Metadata.PhoneNumberID
+			for _, msg := range change.Value.Messages {
+				if msg.From != "" {
+					return phoneNumberID + ":" + msg.From
+				}
+			}
+			for _, status := range change.Value.Statuses {
+				if status.RecipientID != "" {
+					return phoneNumberID + ":" + status.RecipientID
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// webhookEventKey builds the dedup key for an event, or "" if the event has no ID
+func webhookEventKey(kind, id string, parts ...string) string {
+	if id == "" {
+		return ""
+	}
+	key := kind + ":" + id
+	for _, p := range parts {
+		key += ":" + p
+	}
+	return key
+}
+
+// claimWebhookEvent reserves an inbound event for processing. It returns false if
+// the event was already processed. If another worker is still processing it, an
+// error is returned so the payload stays pending and is checked again later.
+func (a *App) claimWebhookEvent(ctx context.Context, key string) (bool, error) {
+	if a.Redis == nil || key == "" {
+		return true, nil
+	}
+	claimed, err := a.Redis.SetNX(ctx, webhookDedupPrefix+key, "processing", webhookEventLease).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim webhook event: %w", err)
+	}
+	if claimed {
+		return true, nil
+	}
+
+	state, err := a.Redis.Get(ctx, webhookDedupPrefix+key).Result()
+	if err == redis.Nil {
+		// The lease expired in between; try again on the next delivery
+		return false, fmt.Errorf("webhook event %s lease expired while claiming", key)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check webhook event: %w", err)
+	}
+	if state != "done" {
+		return false, fmt.Errorf("webhook event %s is being processed by another worker", key)
+	}
+	return false, nil
+}
+
+// completeWebhookEvent marks a claimed event as processed
+func (a *App) completeWebhookEvent(ctx context.Context, key string) {
+	if a.Redis == nil || key == "" {
+		return
+	}
+	if err := a.Redis.Set(ctx, webhookDedupPrefix+key, "done", webhookDedupTTL).Err(); err != nil {
+		a.Log.Warn("Failed to mark webhook event as processed", "error", err, "key", key)
+	}
+}
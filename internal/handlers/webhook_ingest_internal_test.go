@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/config"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// ingestTestApp creates an App with a database, Redis and a mock queue
+func ingestTestApp(t *testing.T) (*App, *testutil.MockQueue) {
+	t.Helper()
+	db := testutil.SetupTestDB(t)
+	rdb := testutil.SetupTestRedis(t)
+	if rdb == nil {
+		t.Skip("TEST_REDIS_URL not set, skipping test")
+	}
+	q := testutil.NewMockQueue()
+	return &App{
+		Config: &config.Config{},
+		DB:     db,
+		Redis:  rdb,
+		Log:    testutil.NopLogger(),
+		Queue:  q,
+	}, q
+}
+
+// statusWebhookBody builds a Meta webhook payload carrying a single status update
+func statusWebhookBody(t *testing.T, phoneID, wamid, status string) []byte {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{
+		"object": "whatsapp_business_account",
+		"entry": []any{map[string]any{
+			"id": "waba",
+			"changes": []any{map[string]any{
+				"field": "messages",
+				"value": map[string]any{
+					"metadata": map[string]any{"phone_number_id": phoneID},
+					"statuses": []any{map[string]any{"id": wamid, "status": status}},
+				},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+	return body
+}
+
+func TestWebhookHandler_EnqueuesVerifiedPayload(t *testing.T) {
+	app, q := ingestTestApp(t)
+	org := testutil.CreateTestOrganization(t, app.DB)
+	account := testutil.CreateTestWhatsAppAccountWith(t, app.DB, org.ID, func(a *models.WhatsAppAccount) {
+		a.AppSecret = "app-secret"
+	})
+	t.Cleanup(func() { app.InvalidateWhatsAppAccountCache(account.PhoneID) })
+
+	body := statusWebhookBody(t, account.PhoneID, "wamid.1", "delivered")
+	mac := hmac.New(sha256.New, []byte("app-secret"))
+	mac.Write(body)
+
+	t.Run("valid signature", func(t *testing.T) {
+		req := testutil.NewJSONRequest(t, nil)
+		req.RequestCtx.Request.SetBody(body)
+		req.RequestCtx.Request.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+		require.NoError(t, app.WebhookHandler(req))
+		assert.Equal(t, fasthttp.StatusOK, testutil.GetResponseStatusCode(req))
+		require.Equal(t, 1, q.WebhookCount())
+		assert.JSONEq(t, string(body), string(q.Webhooks[0]))
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		q.Reset()
+		req := testutil.NewJSONRequest(t, nil)
+		req.RequestCtx.Request.SetBody(body)
+		req.RequestCtx.Request.Header.Set("X-Hub-Signature-256", "sha256=deadbeef")
+
+		require.NoError(t, app.WebhookHandler(req))
+		assert.Equal(t, fasthttp.StatusForbidden, testutil.GetResponseStatusCode(req))
+		assert.Equal(t, 0, q.WebhookCount())
+	})
+
+	t.Run("queue unavailable", func(t *testing.T) {
+		q.Reset()
+		q.Error = assert.AnError
+		req := testutil.NewJSONRequest(t, nil)
+		req.RequestCtx.Request.SetBody(body)
+
+		require.NoError(t, app.WebhookHandler(req))
+		// Meta redelivers when the webhook is not acknowledged
+		assert.Equal(t, fasthttp.StatusServiceUnavailable, testutil.GetResponseStatusCode(req))
+	})
+}
+
+func TestClaimWebhookEvent(t *testing.T) {
+	app, _ := ingestTestApp(t)
+	ctx := context.Background()
+	key := webhookEventKey("message", "wamid."+uuid.New().String())
+	t.Cleanup(func() { app.Redis.Del(ctx, webhookDedupPrefix+key) })
+
+	claimed, err := app.claimWebhookEvent(ctx, key)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// Still being processed: the payload must be retried, not dropped
+	_, err = app.claimWebhookEvent(ctx, key)
+	assert.Error(t, err)
+
+	app.completeWebhookEvent(ctx, key)
+	claimed, err = app.claimWebhookEvent(ctx, key)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	// Events without an ID cannot be deduplicated
+	claimed, err = app.claimWebhookEvent(ctx, webhookEventKey("message", ""))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
+
+func TestProcessWebhookPayload_SkipsRedeliveredStatus(t *testing.T) {
+	app, _ := ingestTestApp(t)
+	ctx := context.Background()
+	_, msg, campaign, _ := webhookTestData(t, app, models.MessageStatusSent)
+	key := webhookEventKey("status", msg.WhatsAppMessageID, "delivered")
+	t.Cleanup(func() { app.Redis.Del(ctx, webhookDedupPrefix+key) })
+
+	body := statusWebhookBody(t, "phone-unknown", msg.WhatsAppMessageID, "delivered")
+	require.NoError(t, app.processWebhookPayload(ctx, body))
+
+	// Reset the message so a second run would be visible
+	require.NoError(t, app.DB.Model(&models.Message{}).Where("id = ?", msg.ID).Update("status", models.MessageStatusSent).Error)
+	require.NoError(t, app.processWebhookPayload(ctx, body))
+
+	var updated models.Message
+	require.NoError(t, app.DB.First(&updated, msg.ID).Error)
+	assert.Equal(t, models.MessageStatusSent, updated.Status, "redelivered status should be skipped")
+
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, app.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Equal(t, 1, updatedCampaign.DeliveredCount)
+}
+
+func TestWebhookPayloadKey(t *testing.T) {
+	message, err := json.Marshal(map[string]any{
+		"entry": []any{map[string]any{
+			"changes": []any{map[string]any{
+				"field": "messages",
+				"value": map[string]any{
+					"metadata": map[string]any{"phone_number_id": "123"},
+					"messages": []any{map[string]any{"id": "wamid.1", "from": "919999999999", "type": "text"}},
+				},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "123:919999999999", webhookPayloadKey(message))
+
+	status, err := json.Marshal(map[string]any{
+		"entry": []any{map[string]any{
+			"changes": []any{map[string]any{
+				"field": "messages",
+				"value": map[string]any{
+					"metadata": map[string]any{"phone_number_id": "123"},
+					"statuses": []any{map[string]any{"id": "wamid.2", "status": "read", "recipient_id": "919999999999"}},
+				},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "123:919999999999", webhookPayloadKey(status), "same contact as their messages")
+
+	assert.Empty(t, webhookPayloadKey(statusWebhookBody(t, "123", "wamid.3", "sent")), "no recipient")
+	assert.Empty(t, webhookPayloadKey([]byte(`{"entry":[{"changes":[{"field":"message_template_status_update"}]}]}`)))
+	assert.Empty(t, webhookPayloadKey([]byte("not json")))
+}
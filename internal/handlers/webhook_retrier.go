@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/models"
+	"gorm.io/gorm"
+)
+
+// webhookRetrierBatchSize limits how many due deliveries are retried per tick
+const webhookRetrierBatchSize = 100
+
+// WebhookRetrier periodically retries pending webhook deliveries whose
+// next_attempt_at has passed. Because retry state lives in the database,
+// deliveries survive restarts. Each delivery is claimed by moving its
+// next_attempt_at forward, so several replicas can run the retrier without
+// sending the same attempt twice.
+type WebhookRetrier struct {
+	app      *App
+	interval time.Duration
+	stopCh   chan struct{}
+}
+
+// NewWebhookRetrier creates a new webhook retrier
+func NewWebhookRetrier(app *App, interval time.Duration) *WebhookRetrier {
+	return &WebhookRetrier{
+		app:      app,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start begins the webhook retry loop
+func (w *WebhookRetrier) Start(ctx context.Context) {
+	w.app.Log.Info("Webhook retrier started", "interval", w.interval)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.app.Log.Info("Webhook retrier stopped by context")
+			return
+		case <-w.stopCh:
+			w.app.Log.Info("Webhook retrier stopped")
+			return
+		case <-ticker.C:
+			w.retryDueDeliveries(ctx)
+		}
+	}
+}
+
+// Stop stops the webhook retrier
+func (w *WebhookRetrier) Stop() {
+	close(w.stopCh)
+}
+
+// retryDueDeliveries finds pending deliveries that are due and attempts them again
+func (w *WebhookRetrier) retryDueDeliveries(ctx context.Context) {
+	now := time.Now()
+
+	var deliveries []models.WebhookDelivery
+	if err := w.app.DB.Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryStatusPending, now).
+		Order("next_attempt_at ASC").
+		Limit(webhookRetrierBatchSize).
+		Find(&deliveries).Error; err != nil {
+		w.app.Log.Error("Failed to find due webhook deliveries", "error", err)
+		return
+	}
+
+	sem := make(chan struct{}, maxConcurrentWebhooks)
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(delivery *models.WebhookDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			w.retryDelivery(ctx, delivery, now)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+}
+
+// retryDelivery claims a single due delivery and attempts it
+func (w *WebhookRetrier) retryDelivery(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) {
+	lease := now.Add(webhookDeliveryLease)
+	result := w.app.DB.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", delivery.ID, models.WebhookDeliveryStatusPending, now).
+		Update("next_attempt_at", lease)
+	if result.Error != nil {
+		w.app.Log.Error("Failed to claim webhook delivery", "error", result.Error, "delivery_id", delivery.ID)
+		return
+	}
+	if result.RowsAffected == 0 {
+		// Another replica claimed it
+		return
+	}
+
+	var webhook models.Webhook
+	if err := w.app.DB.Where("id = ? AND organization_id = ?", delivery.WebhookID, delivery.OrganizationID).
+		First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			w.app.failWebhookDelivery(delivery, "webhook was deleted")
+			return
+		}
+		// Leave the lease in place; the delivery is retried once it expires
+		w.app.Log.Error("Failed to load webhook for delivery", "error", err, "delivery_id", delivery.ID)
+		return
+	}
+	if !webhook.IsActive {
+		w.app.failWebhookDelivery(delivery, "webhook is inactive")
+		return
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, webhookDeliveryLease/2)
+	defer cancel()
+	w.app.attemptWebhookDelivery(attemptCtx, webhook, delivery)
+}
@@ -55,12 +55,21 @@ func validateWebhookURL(rawURL string) error {
 // private/loopback IPs after DNS resolution. Use this in http.Transport
 // for webhook and custom action HTTP calls.
 func SSRFSafeDialer() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return SSRFSafeDialerAllowing(nil)
+}
+
+// SSRFSafeDialerAllowing is SSRFSafeDialer with exceptions for trusted hosts,
+// given as "host" or "host:port", which may resolve to private addresses.
+func SSRFSafeDialerAllowing(trustedHosts []string) func(ctx context.Context, network, addr string) (net.Conn, error) {
 	dialer := &net.Dialer{Timeout: 10 * time.Second}
 	return func(ctx context.Context, network, addr string) (net.Conn, error) {
 		host, port, err := net.SplitHostPort(addr)
 		if err != nil {
 			return nil, err
 		}
+		if isTrustedHost(trustedHosts, host, port) {
+			return dialer.DialContext(ctx, network, addr)
+		}
 
 		ips, err := net.DefaultResolver.LookupHost(ctx, host)
 		if err != nil {
@@ -83,6 +92,17 @@ func SSRFSafeDialer() func(ctx context.Context, network, addr string) (net.Conn,
 	}
 }
 
+// isTrustedHost reports whether host, or host:port, is in the trusted list
+func isTrustedHost(trustedHosts []string, host, port string) bool {
+	for _, trusted := range trustedHosts {
+		trusted = strings.ToLower(strings.TrimSpace(trusted))
+		if trusted == strings.ToLower(host) || (port != "" && trusted == strings.ToLower(net.JoinHostPort(host, port))) {
+			return true
+		}
+	}
+	return false
+}
+
 // WebhookRequest represents the request body for creating/updating a webhook
 type WebhookRequest struct {
 	Name     string            `json:"name"`
@@ -363,7 +383,7 @@ func (a *App) TestWebhook(r *fastglue.Request) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	if err := a.sendWebhookRequest(ctx, *webhook, jsonData); err != nil {
+	if _, err := a.sendWebhookRequest(ctx, *webhook, uuid.Nil, jsonData); err != nil {
 		a.Log.Error("Webhook test failed", "error", err, "webhook_id", webhook.ID)
 		return r.SendErrorEnvelope(fasthttp.StatusBadGateway, "Webhook test failed", nil, "")
 	}
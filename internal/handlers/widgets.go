@@ -16,9 +16,9 @@ import (
 type WidgetRequest struct {
 	Name        string        `json:"name"`
 	Description string        `json:"description"`
-	DataSource  string        `json:"data_source"`  // messages, contacts, campaigns, transfers, sessions
+	DataSource  string        `json:"data_source"`  // messages, contacts, campaigns, transfers, sessions, csat
 	Metric      string        `json:"metric"`       // count, sum, avg
-	Field       string        `json:"field"`        // Field for sum/avg
+	Field       string        `json:"field"`        // Field for sum/avg (csat: rating or csat_score)
 	Filters     []FilterInput `json:"filters"`      // Filter conditions
 	DisplayType  string        `json:"display_type"`   // number, percentage, chart
 	ChartType    string        `json:"chart_type"`     // line, bar, pie
@@ -124,6 +124,12 @@ var widgetDataSources = map[string][]string{
 	"campaigns": {"status", "message_status"},
 	"transfers": {"status", "source"},
 	"sessions":  {"status"},
+	"csat":      {"rating", "survey_type"},
+}
+
+// Labels of data sources whose name doesn't read as one
+var widgetDataSourceLabels = map[string]string{
+	"csat": "CSAT",
 }
 
 // Available metrics
@@ -570,9 +576,13 @@ func (a *App) SaveWidgetLayout(r *fastglue.Request) error {
 func (a *App) GetWidgetDataSources(r *fastglue.Request) error {
 	sources := make([]map[string]interface{}, 0)
 	for source, fields := range widgetDataSources {
+		label, ok := widgetDataSourceLabels[source]
+		if !ok {
+			label = formatLabel(source)
+		}
 		sources = append(sources, map[string]interface{}{
 			"name":   source,
-			"label":  formatLabel(source),
+			"label":  label,
 			"fields": fields,
 		})
 	}
@@ -820,6 +830,10 @@ func (a *App) executeWidgetQuery(orgID uuid.UUID, widget models.Widget, fromStr,
 	case "sessions":
 		currentValue = a.querySessions(orgID, widget.Metric, filters, periodStart, periodEnd)
 		previousValue = a.querySessions(orgID, widget.Metric, filters, previousPeriodStart, previousPeriodEnd)
+
+	case "csat":
+		currentValue = a.queryCSAT(orgID, widget.Metric, widget.Field, filters, periodStart, periodEnd)
+		previousValue = a.queryCSAT(orgID, widget.Metric, widget.Field, filters, previousPeriodStart, previousPeriodEnd)
 	}
 
 	response.Value = currentValue
@@ -937,6 +951,34 @@ func (a *App) querySessions(orgID uuid.UUID, _ string, filters []FilterInput, st
 	return float64(count)
 }
 
+// queryCSAT counts the survey ratings received in a period, or averages them:
+// avg of rating is the average rating, avg of csat_score the percentage of
+// ratings of 4 or 5
+func (a *App) queryCSAT(orgID uuid.UUID, metric, field string, filters []FilterInput, start, end time.Time) float64 {
+	query := a.DB.Model(&models.CSATSurvey{}).Where("organization_id = ? AND status = ? AND responded_at >= ? AND responded_at <= ?",
+		orgID, models.CSATSurveyStatusAnswered, start, end)
+
+	for _, f := range filters {
+		query = applyFilter(query, f)
+	}
+
+	var result float64
+	switch metric {
+	case "count":
+		var count int64
+		query.Count(&count)
+		result = float64(count)
+	case "avg":
+		switch field {
+		case "", "rating":
+			query.Select("COALESCE(AVG(rating), 0)").Scan(&result)
+		case "csat_score":
+			query.Select("COALESCE(AVG(CASE WHEN rating >= ? THEN 100.0 ELSE 0 END), 0)", satisfiedCSATRating).Scan(&result)
+		}
+	}
+	return result
+}
+
 func (a *App) getChartData(orgID uuid.UUID, widget models.Widget, filters []FilterInput, start, end time.Time) []ChartPoint {
 	chartData := make([]ChartPoint, 0)
 
@@ -988,6 +1030,8 @@ func resolveDataSourceTable(dataSource string) (tableName, dateField string, ok
 		return "agent_transfers", "transferred_at", true
 	case "sessions":
 		return "chatbot_sessions", "created_at", true
+	case "csat":
+		return "csat_surveys", "responded_at", true
 	default:
 		return "", "", false
 	}
@@ -1023,6 +1067,7 @@ func (a *App) getGroupedData(orgID uuid.UUID, widget models.Widget, filters []Fi
 		"message_type": true, "assigned_user_id": true, "channel": true,
 		"is_active": true, "priority": true, "category": true,
 		"type": true, "action_type": true, "provider": true,
+		"rating": true, "survey_type": true,
 	}
 	if !allowedGroupByFields[widget.GroupByField] {
 		a.Log.Error("Invalid GroupByField", "field", widget.GroupByField)
@@ -1310,6 +1355,13 @@ var tableQuerySQL = map[string]struct{ base, orderBy string }{
 			WHERE s.organization_id = ? AND s.created_at >= ? AND s.created_at <= ?`,
 		orderBy: " ORDER BY s.created_at DESC LIMIT 10",
 	},
+	"csat": {
+		base: `SELECT id, COALESCE((SELECT COALESCE(c.profile_name, c.phone_number) FROM contacts c WHERE c.id = csat_surveys.contact_id), '') as label,
+			CONCAT_WS(' - ', rating || '/5', NULLIF(LEFT(comment, 80), '')) as sub_label, status, '' as direction, responded_at as created_at
+			FROM csat_surveys
+			WHERE organization_id = ? AND responded_at >= ? AND responded_at <= ?`,
+		orderBy: " ORDER BY responded_at DESC LIMIT 10",
+	},
 }
 
 // getTableRows returns the last 10 rows for a table widget based on the data source.
@@ -0,0 +1,159 @@
+package knowledge
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Default chunking, in characters
+const (
+	DefaultChunkSize    = 1000
+	DefaultChunkOverlap = 150
+)
+
+// chunkPart is a piece of text that is kept whole in a chunk if it fits
+type chunkPart struct {
+	text      string
+	paragraph bool // starts a paragraph
+}
+
+// Chunk splits text into chunks of at most size characters. It breaks between
+// paragraphs where it can, then between sentences and then between words.
+// Each chunk repeats up to overlap characters from the end of the previous one,
+// so a passage cut in two still has its context.
+func Chunk(text string, size, overlap int) []string {
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var parts []chunkPart
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		for i, piece := range splitToFit(paragraph, size) {
+			parts = append(parts, chunkPart{text: piece, paragraph: i == 0})
+		}
+	}
+
+	var chunks []string
+	var current []chunkPart
+	length, carriedParts := 0, 0
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		chunks = append(chunks, joinChunkParts(current))
+
+		// Carry the last parts that fit in the overlap into the next chunk
+		carried := 0
+		start := len(current)
+		for start > 0 {
+			n := utf8.RuneCountInString(current[start-1].text) + 1
+			if carried+n > overlap {
+				break
+			}
+			carried += n
+			start--
+		}
+		current = append([]chunkPart(nil), current[start:]...)
+		length, carriedParts = carried, len(current)
+	}
+
+	for _, part := range parts {
+		n := utf8.RuneCountInString(part.text) + 1
+		if length+n > size+1 {
+			flush()
+			// Drop carried parts that leave no room for this one
+			for len(current) > 0 && length+n > size+1 {
+				length -= utf8.RuneCountInString(current[0].text) + 1
+				current = current[1:]
+				carriedParts--
+			}
+		}
+		current = append(current, part)
+		length += n
+	}
+	// Carried parts alone are already in the last chunk
+	if len(current) > carriedParts {
+		flush()
+	}
+	return chunks
+}
+
+func joinChunkParts(parts []chunkPart) string {
+	var sb strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			if part.paragraph {
+				sb.WriteString("\n\n")
+			} else {
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString(part.text)
+	}
+	return sb.String()
+}
+
+// splitToFit splits a paragraph longer than size into sentences, and those
+// still too long into words. A single word longer than size is cut.
+func splitToFit(paragraph string, size int) []string {
+	if utf8.RuneCountInString(paragraph) <= size {
+		return []string{paragraph}
+	}
+	var pieces []string
+	for _, sentence := range splitSentences(paragraph) {
+		if utf8.RuneCountInString(sentence) <= size {
+			pieces = append(pieces, sentence)
+			continue
+		}
+		var line []string
+		lineLen := 0
+		for _, word := range strings.Fields(sentence) {
+			for utf8.RuneCountInString(word) > size {
+				runes := []rune(word)
+				pieces = append(pieces, string(runes[:size]))
+				word = string(runes[size:])
+			}
+			n := utf8.RuneCountInString(word)
+			if lineLen > 0 && lineLen+1+n > size {
+				pieces = append(pieces, strings.Join(line, " "))
+				line, lineLen = nil, 0
+			}
+			if lineLen > 0 {
+				lineLen++
+			}
+			line = append(line, word)
+			lineLen += n
+		}
+		if len(line) > 0 {
+			pieces = append(pieces, strings.Join(line, " "))
+		}
+	}
+	return pieces
+}
+
+// splitSentences splits text after sentence punctuation followed by a space
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		if (r == '.' || r == '!' || r == '?' || r == '\n') && i+1 < len(runes) && unicode.IsSpace(runes[i+1]) {
+			if s := strings.TrimSpace(string(runes[start : i+1])); s != "" {
+				sentences = append(sentences, s)
+			}
+			start = i + 1
+		}
+	}
+	if s := strings.TrimSpace(string(runes[start:])); s != "" {
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
@@ -0,0 +1,45 @@
+package knowledge
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunk(t *testing.T) {
+	t.Run("short text is one chunk", func(t *testing.T) {
+		assert.Equal(t, []string{"One.\n\nTwo."}, Chunk("One.\n\nTwo.", 100, 10))
+		assert.Empty(t, Chunk("  \n\n ", 100, 10))
+	})
+
+	t.Run("breaks between paragraphs with overlap", func(t *testing.T) {
+		text := "Alpha alpha.\n\nBravo bravo.\n\nCharlie charlie.\n\nDelta delta."
+		assert.Equal(t, []string{
+			"Alpha alpha.\n\nBravo bravo.",
+			"Bravo bravo.\n\nCharlie charlie.",
+			"Charlie charlie.\n\nDelta delta.",
+		}, Chunk(text, 30, 17))
+		assert.Equal(t, []string{
+			"Alpha alpha.\n\nBravo bravo.",
+			"Charlie charlie.\n\nDelta delta.",
+		}, Chunk(text, 30, 0))
+	})
+
+	t.Run("long paragraphs break between sentences and words", func(t *testing.T) {
+		text := "First sentence here. Second one is a bit longer than the limit allows."
+		chunks := Chunk(text, 25, 0)
+		assert.Equal(t, []string{"First sentence here.", "Second one is a bit", "longer than the limit", "allows."}, chunks)
+	})
+
+	t.Run("chunks stay within the size", func(t *testing.T) {
+		text := strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit. ", 40) + "\n\n" + strings.Repeat("x", 120)
+		chunks := Chunk(text, 100, 30)
+		assert.Greater(t, len(chunks), 20)
+		for _, chunk := range chunks {
+			assert.LessOrEqual(t, utf8.RuneCountInString(chunk), 100)
+		}
+		assert.Equal(t, strings.Repeat("x", 20), chunks[len(chunks)-1])
+	})
+}
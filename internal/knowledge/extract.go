@@ -0,0 +1,186 @@
+// Package knowledge turns knowledge base documents into plain text and splits
+// it into chunks that are indexed and retrieved as AI context.
+package knowledge
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Source types of documents
+const (
+	SourcePDF      = "pdf"
+	SourceMarkdown = "markdown"
+	SourceHTML     = "html"
+	SourceCSV      = "csv"
+	SourceText     = "text"
+)
+
+// ErrUnsupportedType is returned for files that can't be added to the knowledge base
+var ErrUnsupportedType = errors.New("unsupported file type, use PDF, Markdown, HTML, CSV or text files")
+
+// SourceType returns the source type of a file from its extension
+func SourceType(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf":
+		return SourcePDF, nil
+	case ".md", ".markdown":
+		return SourceMarkdown, nil
+	case ".html", ".htm":
+		return SourceHTML, nil
+	case ".csv":
+		return SourceCSV, nil
+	case ".txt":
+		return SourceText, nil
+	}
+	return "", ErrUnsupportedType
+}
+
+// Extract returns the text of a document. Markdown is kept as is since models read it well.
+func Extract(sourceType string, data []byte) (string, error) {
+	var text string
+	switch sourceType {
+	case SourcePDF:
+		var err error
+		if text, err = extractPDF(data); err != nil {
+			return "", err
+		}
+	case SourceHTML:
+		var err error
+		if text, err = extractHTML(data); err != nil {
+			return "", err
+		}
+	case SourceCSV:
+		var err error
+		if text, err = extractCSV(data); err != nil {
+			return "", err
+		}
+	case SourceMarkdown, SourceText:
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("file is not UTF-8 text")
+		}
+		text = string(data)
+	default:
+		return "", ErrUnsupportedType
+	}
+	return normalizeText(text), nil
+}
+
+// htmlSkippedTags are elements whose text isn't content
+var htmlSkippedTags = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true, "svg": true,
+}
+
+// htmlBlockTags are elements that start a new line
+var htmlBlockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true,
+	"div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "header": true, "hr": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "tr": true, "ul": true,
+}
+
+// extractHTML returns the visible text of an HTML document, with a paragraph per block element
+func extractHTML(data []byte) (string, error) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("invalid HTML: %w", err)
+	}
+
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && htmlSkippedTags[n.Data] {
+			return
+		}
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			return
+		}
+		block := n.Type == html.ElementNode && htmlBlockTags[n.Data]
+		if block {
+			sb.WriteString("\n\n")
+		}
+		if n.Type == html.ElementNode && (n.Data == "td" || n.Data == "th") {
+			sb.WriteString(" | ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			sb.WriteString("\n\n")
+		}
+	}
+	walk(doc)
+	return sb.String(), nil
+}
+
+// extractCSV turns each row into a paragraph of "header: value" lines, so a
+// chunk of rows still says what each value is
+func extractCSV(data []byte) (string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+
+	headers, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return "", nil
+		}
+		return "", fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	var rows []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid CSV: %w", err)
+		}
+		var lines []string
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			if i < len(headers) && strings.TrimSpace(headers[i]) != "" {
+				lines = append(lines, strings.TrimSpace(headers[i])+": "+value)
+			} else {
+				lines = append(lines, value)
+			}
+		}
+		if len(lines) > 0 {
+			rows = append(rows, strings.Join(lines, "\n"))
+		}
+	}
+	return strings.Join(rows, "\n\n"), nil
+}
+
+var (
+	spaceRun     = regexp.MustCompile(`[ \t\f\v\x{00a0}]+`)
+	blankLineRun = regexp.MustCompile(`\n{3,}`)
+)
+
+// normalizeText collapses runs of spaces and blank lines and trims each line
+func normalizeText(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = spaceRun.ReplaceAllString(text, " ")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text = blankLineRun.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
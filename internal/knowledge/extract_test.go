@@ -0,0 +1,206 @@
+package knowledge
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceType(t *testing.T) {
+	for filename, want := range map[string]string{
+		"faq.PDF":       SourcePDF,
+		"guide.md":      SourceMarkdown,
+		"page.htm":      SourceHTML,
+		"products.csv":  SourceCSV,
+		"notes.txt":     SourceText,
+		"guide.v2.html": SourceHTML,
+	} {
+		got, err := SourceType(filename)
+		require.NoError(t, err, filename)
+		assert.Equal(t, want, got, filename)
+	}
+
+	_, err := SourceType("report.docx")
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestExtract_HTML(t *testing.T) {
+	page := `<html><head><title>FAQ</title><style>p { color: red }</style></head>
+<body><h1>Returns</h1><p>Items can be   returned within <b>30 days</b>.</p>
+<script>track()</script><ul><li>Keep the receipt</li><li>Use the original box</li></ul></body></html>`
+
+	text, err := Extract(SourceHTML, []byte(page))
+	require.NoError(t, err)
+	assert.Equal(t, "Returns\n\nItems can be returned within 30 days.\n\nKeep the receipt\n\nUse the original box", text)
+}
+
+func TestExtract_CSV(t *testing.T) {
+	data := "\xef\xbb\xbfQuestion,Answer\nDo you ship abroad?,\"Yes, to 20 countries\"\nOpening hours,9-5,extra\n,\n"
+
+	text, err := Extract(SourceCSV, []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, "Question: Do you ship abroad?\nAnswer: Yes, to 20 countries\n\nQuestion: Opening hours\nAnswer: 9-5\nextra", text)
+}
+
+func TestExtract_Markdown(t *testing.T) {
+	text, err := Extract(SourceMarkdown, []byte("# Shipping\r\n\r\n\r\n\r\nWe ship   worldwide.  \r\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Shipping\n\nWe ship worldwide.", text)
+
+	_, err = Extract(SourceText, []byte{0xff, 0xfe, 0x00})
+	assert.Error(t, err)
+}
+
+// buildPDF returns a PDF file of the given objects, numbered from 1
+func buildPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func flateStream(dict, data string) string {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, _ = w.Write([]byte(data))
+	_ = w.Close()
+	return fmt.Sprintf("<< %s /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", dict, buf.Len(), buf.String())
+}
+
+// utf16Hex encodes ASCII text as the 2-byte codes of an Identity-H font
+func utf16Hex(s string) string {
+	var codes []byte
+	for _, r := range s {
+		codes = append(codes, 0, byte(r))
+	}
+	return "<" + hex.EncodeToString(codes) + ">"
+}
+
+func TestExtract_PDF(t *testing.T) {
+	cmap := `/CIDInit /ProcSet findresource begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+1 beginbfchar <0100> <00E9> endbfchar
+1 beginbfrange <0020> <007E> <0020> endbfrange
+endcmap`
+	content := fmt.Sprintf("BT /F1 12 Tf 72 700 Td %s Tj 0 -14 Td [%s -400 %s <0100>] TJ ET",
+		utf16Hex("Refunds take 5 days."), utf16Hex("Call"), utf16Hex("us"))
+
+	data := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 6 0 R] /Count 2 /Resources << /Font << /F1 4 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Arial /Encoding /Identity-H /ToUnicode 7 0 R >>",
+		flateStream("", content),
+		"<< /Type /Page /Parent 2 0 R /Contents [8 0 R] /Resources << /Font << /F2 9 0 R >> >> >>",
+		flateStream("", cmap),
+		"<< /Length 44 >>\nstream\nBT /F2 12 Tf (Caf\\351 opens at \\(9\\)) Tj ET\nendstream",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	)
+
+	text, err := Extract(SourcePDF, data)
+	require.NoError(t, err)
+	assert.Equal(t, "Refunds take 5 days.\nCall usé\n\nCafé opens at (9)", text)
+}
+
+func TestExtract_PDFErrors(t *testing.T) {
+	_, err := Extract(SourcePDF, []byte("hello"))
+	assert.EqualError(t, err, "not a PDF file")
+
+	scanned := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		"<< /Length 23 >>\nstream\nq 612 0 0 792 0 0 cm Q\nendstream",
+	)
+	_, err = Extract(SourcePDF, scanned)
+	assert.ErrorIs(t, err, errPDFNoText)
+
+	encrypted := buildPDF("<< /Type /XRef /Root 2 0 R /Encrypt 3 0 R >>", "<< /Type /Catalog >>", "<< /Filter /Standard >>")
+	_, err = Extract(SourcePDF, encrypted)
+	assert.EqualError(t, err, "encrypted PDFs are not supported")
+}
+
+func TestExtract_PDFMalformed(t *testing.T) {
+	page := func(contents string) []byte {
+		return buildPDF(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+			contents,
+		)
+	}
+	cases := map[string][]byte{
+		"negative length":   page("<< /Length -1 >>\nstream\nendstream"),
+		"huge length":       page("<< /Length 1e300 >>\nstream\nBT (x) Tj ET\nendstream"),
+		"length past end":   page("<< /Length 99999 >>\nstream\nBT (x) Tj ET\nendstream"),
+		"stream at eof":     []byte("%PDF-1.5\n1 0 obj << /Length 5 >>stream"),
+		"unterminated":      page("<< /Length 3 >>\nstream\nBT (x"),
+		"deep nesting":      page("<< /Length 1 >>\nstream\n" + strings.Repeat("[", 100000) + "\nendstream"),
+		"huge object count": []byte("%PDF-1.5\n2147483647 0 obj << /Type /Page >> endobj\n"),
+		"objstm offsets":    buildPDF(flateStream("/Type /ObjStm /N 1000000 /First -5", "1 -9999 2 99999")),
+		"bfrange at max code": buildPDF(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 /Resources << /Font << /F1 4 0 R >> >> >>",
+			"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+			"<< /Type /Font /ToUnicode 6 0 R >>",
+			"<< /Length 20 >>\nstream\nBT /F1 12 Tf (A) Tj ET\nendstream",
+			flateStream("", "1 beginbfrange <FFFFFFFF> <FFFFFFFF> <0041> endbfrange"),
+		),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.NotPanics(t, func() {
+				_, _ = Extract(SourcePDF, data)
+			})
+		})
+	}
+
+	// The declared length is ignored when it doesn't end at "endstream"
+	text, err := Extract(SourcePDF, page("<< /Length -1 >>\nstream\nBT (Still readable) Tj ET\nendstream"))
+	require.NoError(t, err)
+	assert.Equal(t, "Still readable", text)
+}
+
+func TestExtract_PDFDecompressionLimit(t *testing.T) {
+	bomb := strings.Repeat(" ", maxPDFDecodedSize+1)
+	doc := func(contents, image string) []byte {
+		return buildPDF(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /XObject << /Im1 5 0 R >> >> >>",
+			contents,
+			image,
+		)
+	}
+	text := "<< /Length 27 >>\nstream\nBT (Refunds take 5 days) Tj ET\nendstream"
+
+	// Streams text isn't read from are never decompressed
+	got, err := Extract(SourcePDF, doc(text, flateStream("/Type /XObject /Subtype /Image", bomb)))
+	require.NoError(t, err)
+	assert.Equal(t, "Refunds take 5 days", got)
+
+	_, err = Extract(SourcePDF, doc(flateStream("", "BT (x) Tj ET"+bomb), text))
+	assert.ErrorIs(t, err, errPDFTooLarge)
+
+	// The limit is for the whole document, not each stream
+	half := flateStream("", "BT (x) Tj ET"+bomb[:maxPDFDecodedSize/2])
+	_, err = Extract(SourcePDF, buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 5 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		half,
+		"<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>",
+		half,
+	))
+	assert.ErrorIs(t, err, errPDFTooLarge)
+}
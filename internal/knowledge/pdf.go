@@ -0,0 +1,828 @@
+package knowledge
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// This is a small PDF text extractor. It reads the pages' content streams
+// (uncompressed or FlateDecode, including object streams) and maps font codes
+// to text with the fonts' ToUnicode CMaps. Scanned, encrypted and otherwise
+// encoded PDFs are not supported. Only the streams text is read from are
+// decompressed, so images and other embedded files are never inflated.
+
+const (
+	// maxPDFDecodedSize limits the total size of the streams decompressed
+	// from one PDF, so a small file can't inflate to gigabytes
+	maxPDFDecodedSize = 64 << 20
+	// maxPDFNesting limits how deeply arrays and dictionaries may be nested
+	maxPDFNesting = 100
+)
+
+var (
+	errPDFNoText   = errors.New("no text found in PDF; scanned documents are not supported")
+	errPDFTooLarge = fmt.Errorf("PDF decompresses to more than %dMB", maxPDFDecodedSize>>20)
+)
+
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfRef     int
+	pdfDict    map[pdfName]interface{}
+	pdfArray   []interface{}
+)
+
+// pdfObject is an indirect object. The data of its stream, if any, is
+// decoded the first time it's read.
+type pdfObject struct {
+	value     interface{}
+	streamPos int // offset of the stream data in the file, or -1
+	stream    []byte
+	decoded   bool
+}
+
+type pdfDocument struct {
+	data    []byte
+	objects map[int]*pdfObject
+	cmaps   map[int]*pdfCMap // by font object
+	budget  int              // bytes left to decompress
+	err     error            // set when the budget is exceeded
+}
+
+var pdfObjectHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+func extractPDF(data []byte) (_ string, err error) {
+	// The parser is lenient with malformed files; a bug on some unexpected
+	// input must fail the upload, not take down the server.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to read PDF: %v", r)
+		}
+	}()
+
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+	doc := parsePDF(data)
+	if doc.err != nil {
+		return "", doc.err
+	}
+	for _, obj := range doc.objects {
+		if dict, ok := obj.value.(pdfDict); ok && dict["Encrypt"] != nil {
+			return "", fmt.Errorf("encrypted PDFs are not supported")
+		}
+	}
+
+	var pages []string
+	for _, page := range doc.pages() {
+		if text := doc.pageText(page); strings.TrimSpace(text) != "" {
+			pages = append(pages, text)
+		}
+		if doc.err != nil {
+			return "", doc.err
+		}
+	}
+	if len(pages) == 0 {
+		return "", errPDFNoText
+	}
+	return strings.Join(pages, "\n\n"), nil
+}
+
+// parsePDF reads all indirect objects by scanning for "N G obj", which also
+// works for files with a broken cross-reference table
+func parsePDF(data []byte) *pdfDocument {
+	doc := &pdfDocument{data: data, objects: map[int]*pdfObject{}, cmaps: map[int]*pdfCMap{}, budget: maxPDFDecodedSize}
+	for _, m := range pdfObjectHeader.FindAllSubmatchIndex(data, -1) {
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		lex := &pdfLexer{data: data, pos: m[1]}
+		value := lex.next()
+		obj := &pdfObject{value: value, streamPos: -1}
+
+		if _, ok := value.(pdfDict); ok {
+			lex.skipSpace()
+			if bytes.HasPrefix(data[lex.pos:], []byte("stream")) {
+				obj.streamPos = lex.pos + len("stream")
+			}
+		}
+		// Later revisions of an object replace earlier ones
+		doc.objects[num] = obj
+	}
+
+	// Objects stored in object streams. Collect the streams first since
+	// their objects are added to the map.
+	var objStms []*pdfObject
+	for _, obj := range doc.objects {
+		if dict, ok := obj.value.(pdfDict); ok && dict["Type"] == pdfName("ObjStm") {
+			objStms = append(objStms, obj)
+		}
+	}
+	for _, obj := range objStms {
+		stream := doc.stream(obj)
+		if doc.err != nil {
+			return doc
+		}
+		if stream == nil {
+			continue
+		}
+		dict := obj.value.(pdfDict)
+		n, _ := dict["N"].(float64)
+		first, _ := dict["First"].(float64)
+		header := &pdfLexer{data: stream}
+		for i := 0; i < int(n); i++ {
+			num, ok1 := header.next().(float64)
+			offset, ok2 := header.next().(float64)
+			pos := int(first) + int(offset)
+			if !ok1 || !ok2 || pos < 0 || pos >= len(stream) {
+				break
+			}
+			if _, exists := doc.objects[int(num)]; !exists {
+				doc.objects[int(num)] = &pdfObject{value: (&pdfLexer{data: stream, pos: pos}).next(), streamPos: -1}
+			}
+		}
+	}
+	return doc
+}
+
+// stream returns the decoded data of an object's stream, decoding it on first
+// use. Returns nil once the document's decompression budget is exceeded.
+func (d *pdfDocument) stream(obj *pdfObject) []byte {
+	if obj == nil || obj.streamPos < 0 || d.err != nil {
+		return nil
+	}
+	if !obj.decoded {
+		dict, _ := obj.value.(pdfDict)
+		obj.stream, d.err = decodePDFStream(dict, d.data, obj.streamPos, &d.budget)
+		obj.decoded = true
+	}
+	return obj.stream
+}
+
+// decodePDFStream returns the data of a stream starting at pos, or nil if its
+// filter isn't supported. Decompressed bytes are taken from budget, failing
+// with errPDFTooLarge when it runs out.
+func decodePDFStream(dict pdfDict, data []byte, pos int, budget *int) ([]byte, error) {
+	if pos < 0 || pos > len(data) {
+		return nil, nil
+	}
+	if bytes.HasPrefix(data[pos:], []byte("\r\n")) {
+		pos += 2
+	} else if pos < len(data) && (data[pos] == '\n' || data[pos] == '\r') {
+		pos++
+	}
+
+	end := -1
+	// The length is checked as a float so huge values can't overflow int
+	if length, ok := dict["Length"].(float64); ok && length >= 0 && length <= float64(len(data)-pos) {
+		rest := bytes.TrimLeft(data[pos+int(length):], " \t\r\n")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			end = pos + int(length)
+		}
+	}
+	if end < 0 {
+		idx := bytes.Index(data[pos:], []byte("endstream"))
+		if idx < 0 {
+			return nil, nil
+		}
+		end = pos + idx
+	}
+	raw := data[pos:end]
+
+	var filters []interface{}
+	switch f := dict["Filter"].(type) {
+	case pdfName:
+		filters = []interface{}{f}
+	case pdfArray:
+		filters = f
+	}
+	for _, f := range filters {
+		if f != pdfName("FlateDecode") {
+			return nil, nil
+		}
+		r, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, nil
+		}
+		// Truncated streams are common; keep what was decoded
+		decoded, _ := io.ReadAll(io.LimitReader(r, int64(*budget)+1))
+		if len(decoded) > *budget {
+			return nil, errPDFTooLarge
+		}
+		*budget -= len(decoded)
+		raw = decoded
+	}
+	return raw, nil
+}
+
+// resolve follows a reference to the object's value
+func (d *pdfDocument) resolve(v interface{}) interface{} {
+	for i := 0; i < 10; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		obj := d.objects[int(ref)]
+		if obj == nil {
+			return nil
+		}
+		v = obj.value
+	}
+	return nil
+}
+
+func (d *pdfDocument) dict(v interface{}) pdfDict {
+	dict, _ := d.resolve(v).(pdfDict)
+	return dict
+}
+
+// pdfPage is a page dictionary with the resources it inherits
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages returns the pages in document order by walking the page tree. Files
+// without a usable tree fall back to all page objects in object number order.
+func (d *pdfDocument) pages() []pdfPage {
+	var pages []pdfPage
+	visited := map[pdfRef]bool{}
+	var walk func(v interface{}, resources pdfDict)
+	walk = func(v interface{}, resources pdfDict) {
+		if ref, ok := v.(pdfRef); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+		node := d.dict(v)
+		if node == nil {
+			return
+		}
+		if res := d.dict(node["Resources"]); res != nil {
+			resources = res
+		}
+		if node["Type"] == pdfName("Page") {
+			pages = append(pages, pdfPage{dict: node, resources: resources})
+			return
+		}
+		kids, _ := d.resolve(node["Kids"]).(pdfArray)
+		for _, kid := range kids {
+			walk(kid, resources)
+		}
+	}
+	for _, obj := range d.objects {
+		if dict, ok := obj.value.(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+			walk(dict["Pages"], nil)
+			break
+		}
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	// Object numbers come from the file, so iterate over the objects that
+	// exist rather than up to the largest number
+	nums := make([]int, 0, len(d.objects))
+	for num, obj := range d.objects {
+		if dict, ok := obj.value.(pdfDict); ok && dict["Type"] == pdfName("Page") {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		dict := d.objects[num].value.(pdfDict)
+		pages = append(pages, pdfPage{dict: dict, resources: d.dict(dict["Resources"])})
+	}
+	return pages
+}
+
+// pageText runs the text operators of a page's content streams
+func (d *pdfDocument) pageText(page pdfPage) string {
+	fonts := map[pdfName]*pdfCMap{}
+	for name, ref := range d.dict(page.resources["Font"]) {
+		fonts[name] = d.fontCMap(ref)
+	}
+
+	var content []byte
+	switch c := d.resolve(page.dict["Contents"]).(type) {
+	case pdfArray:
+		for _, ref := range c {
+			content = append(content, d.streamOf(ref)...)
+			content = append(content, '\n')
+		}
+	default:
+		content = d.streamOf(page.dict["Contents"])
+	}
+
+	t := &pdfTextWriter{}
+	var cmap *pdfCMap
+	var operands []interface{}
+	lex := &pdfLexer{data: content}
+	for {
+		token := lex.next()
+		if token == nil && lex.pos >= len(content) {
+			break
+		}
+		op, ok := token.(pdfKeyword)
+		if !ok {
+			operands = append(operands, token)
+			continue
+		}
+		switch op {
+		case "Tf":
+			if len(operands) >= 2 {
+				name, _ := operands[len(operands)-2].(pdfName)
+				cmap = fonts[name]
+			}
+		case "Tj":
+			if len(operands) > 0 {
+				t.write(cmap.decode(operands[len(operands)-1]))
+			}
+		case "'", "\"":
+			t.newline()
+			if len(operands) > 0 {
+				t.write(cmap.decode(operands[len(operands)-1]))
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				items, _ := operands[len(operands)-1].(pdfArray)
+				for _, item := range items {
+					if adjust, ok := item.(float64); ok {
+						// A large negative adjustment is a gap between words
+						if adjust < -200 {
+							t.space()
+						}
+						continue
+					}
+					t.write(cmap.decode(item))
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if ty, _ := operands[len(operands)-1].(float64); ty != 0 {
+					t.newline()
+				} else {
+					t.space()
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				y, _ := operands[len(operands)-1].(float64)
+				if t.hasY && y != t.y {
+					t.newline()
+				} else {
+					t.space()
+				}
+				t.y, t.hasY = y, true
+			}
+		case "T*":
+			t.newline()
+		case "ET":
+			t.space()
+		case "ID":
+			// Skip the binary data of an inline image
+			if idx := bytes.Index(content[lex.pos:], []byte("EI")); idx >= 0 {
+				lex.pos += idx + 2
+			} else {
+				lex.pos = len(content)
+			}
+		}
+		operands = operands[:0]
+	}
+	return t.sb.String()
+}
+
+func (d *pdfDocument) streamOf(v interface{}) []byte {
+	ref, ok := v.(pdfRef)
+	if !ok {
+		return nil
+	}
+	return d.stream(d.objects[int(ref)])
+}
+
+// fontCMap returns the ToUnicode CMap of a font, or nil if it has none
+func (d *pdfDocument) fontCMap(font interface{}) *pdfCMap {
+	ref, ok := font.(pdfRef)
+	if ok {
+		if cmap, cached := d.cmaps[int(ref)]; cached {
+			return cmap
+		}
+	}
+	var cmap *pdfCMap
+	if dict := d.dict(font); dict != nil {
+		if data := d.streamOf(dict["ToUnicode"]); data != nil {
+			cmap = parsePDFCMap(data)
+		}
+	}
+	if ok {
+		d.cmaps[int(ref)] = cmap
+	}
+	return cmap
+}
+
+// pdfTextWriter joins shown text, avoiding repeated spaces and newlines
+type pdfTextWriter struct {
+	sb   strings.Builder
+	last byte
+	y    float64
+	hasY bool
+}
+
+func (t *pdfTextWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	t.sb.WriteString(s)
+	t.last = s[len(s)-1]
+}
+
+func (t *pdfTextWriter) space() {
+	if t.last != 0 && t.last != ' ' && t.last != '\n' {
+		t.write(" ")
+	}
+}
+
+func (t *pdfTextWriter) newline() {
+	if t.last != 0 && t.last != '\n' {
+		t.write("\n")
+	}
+}
+
+// pdfCMap maps character codes of a font to text
+type pdfCMap struct {
+	codeBytes int
+	chars     map[uint32]string
+}
+
+// maxPDFCMapRange limits the codes a single bfrange entry may map
+const maxPDFCMapRange = 1 << 16
+
+func parsePDFCMap(data []byte) *pdfCMap {
+	cmap := &pdfCMap{codeBytes: 1, chars: map[uint32]string{}}
+	lex := &pdfLexer{data: data}
+	var operands []interface{}
+	for {
+		token := lex.next()
+		if token == nil && lex.pos >= len(data) {
+			break
+		}
+		op, ok := token.(pdfKeyword)
+		if !ok {
+			operands = append(operands, token)
+			continue
+		}
+		switch op {
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if lo, ok := operands[0].(string); ok && len(lo) > 0 {
+					cmap.codeBytes = len(lo)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(string)
+				dst, ok2 := operands[i+1].(string)
+				if ok1 && ok2 {
+					cmap.chars[pdfCode(src)] = utf16BE(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(string)
+				hi, ok2 := operands[i+1].(string)
+				if !ok1 || !ok2 {
+					continue
+				}
+				start, end := pdfCode(lo), pdfCode(hi)
+				if end < start || end-start > maxPDFCMapRange {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case string:
+					runes := []rune(utf16BE(dst))
+					if len(runes) == 0 {
+						continue
+					}
+					// Count by offset so a range ending at the largest code doesn't wrap around
+					for offset := uint32(0); offset <= end-start; offset++ {
+						r := append([]rune(nil), runes...)
+						r[len(r)-1] += rune(offset)
+						cmap.chars[start+offset] = string(r)
+					}
+				case pdfArray:
+					for j, item := range dst {
+						if s, ok := item.(string); ok && start+uint32(j) <= end {
+							cmap.chars[start+uint32(j)] = utf16BE(s)
+						}
+					}
+				}
+			}
+		}
+		if strings.HasPrefix(string(op), "end") || strings.HasPrefix(string(op), "begin") {
+			operands = operands[:0]
+		}
+	}
+	return cmap
+}
+
+// decode maps the codes of a shown string to text. Without a CMap, bytes are read as Latin-1.
+func (c *pdfCMap) decode(v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		return ""
+	}
+	var sb strings.Builder
+	if c == nil {
+		for i := 0; i < len(s); i++ {
+			if b := s[i]; b >= 0x20 && b != 0x7f {
+				sb.WriteRune(rune(b))
+			}
+		}
+		return sb.String()
+	}
+	for i := 0; i < len(s); i += c.codeBytes {
+		end := i + c.codeBytes
+		if end > len(s) {
+			end = len(s)
+		}
+		code := pdfCode(s[i:end])
+		if text, ok := c.chars[code]; ok {
+			sb.WriteString(text)
+		} else if c.codeBytes == 1 && code >= 0x20 && code < 0x7f {
+			sb.WriteByte(byte(code))
+		}
+	}
+	return sb.String()
+}
+
+// pdfCode reads a big-endian character code
+func pdfCode(s string) uint32 {
+	var code uint32
+	for i := 0; i < len(s) && i < 4; i++ {
+		code = code<<8 | uint32(s[i])
+	}
+	return code
+}
+
+// utf16BE decodes the UTF-16BE text of a CMap destination
+func utf16BE(s string) string {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return strings.ReplaceAll(string(utf16.Decode(units)), "\x00", "")
+}
+
+// pdfLexer reads PDF objects and content stream operators. Strings are
+// returned as Go strings of their raw bytes, numbers as float64, and
+// "N G R" as a pdfRef. It returns nil at the end of the data.
+type pdfLexer struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+func isPDFSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == 0
+}
+
+func isPDFDelimiter(b byte) bool {
+	return strings.IndexByte("()<>[]{}/%", b) >= 0
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		b := l.data[l.pos]
+		if b == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(b) {
+			return
+		}
+		l.pos++
+	}
+}
+
+func (l *pdfLexer) next() interface{} {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil
+	}
+	b := l.data[l.pos]
+	if (b == '[' || (b == '<' && l.peek(1) == '<')) && l.depth >= maxPDFNesting {
+		// Deeper containers are read as keywords to bound the recursion
+		l.pos++
+		return pdfKeyword(string(b))
+	}
+	switch {
+	case b == '<' && l.peek(1) == '<':
+		l.pos += 2
+		l.depth++
+		defer func() { l.depth-- }()
+		dict := pdfDict{}
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return dict
+			}
+			if l.data[l.pos] == '>' && l.peek(1) == '>' {
+				l.pos += 2
+				return dict
+			}
+			key, ok := l.next().(pdfName)
+			if !ok {
+				continue
+			}
+			dict[key] = l.next()
+		}
+	case b == '<':
+		l.pos++
+		return l.hexString()
+	case b == '(':
+		l.pos++
+		return l.literalString()
+	case b == '[':
+		l.pos++
+		l.depth++
+		defer func() { l.depth-- }()
+		var arr pdfArray
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return arr
+			}
+			if l.data[l.pos] == ']' {
+				l.pos++
+				return arr
+			}
+			arr = append(arr, l.next())
+		}
+	case b == '/':
+		l.pos++
+		return l.name()
+	case b == '+' || b == '-' || b == '.' || (b >= '0' && b <= '9'):
+		return l.number()
+	case isPDFDelimiter(b):
+		// Stray delimiters such as ">>" or "]" outside a container
+		l.pos++
+		return pdfKeyword(string(b))
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	switch word := string(l.data[start:l.pos]); word {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	default:
+		return pdfKeyword(word)
+	}
+}
+
+func (l *pdfLexer) peek(offset int) byte {
+	if l.pos+offset < len(l.data) {
+		return l.data[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *pdfLexer) number() interface{} {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.data) && (l.data[l.pos] == '.' || (l.data[l.pos] >= '0' && l.data[l.pos] <= '9')) {
+		l.pos++
+	}
+	text := string(l.data[start:l.pos])
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return pdfKeyword(text)
+	}
+
+	// "N G R" is a reference
+	if !strings.ContainsAny(text, "+-.") {
+		save := l.pos
+		l.skipSpace()
+		genStart := l.pos
+		for l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '9' {
+			l.pos++
+		}
+		if l.pos > genStart {
+			l.skipSpace()
+			if l.peek(0) == 'R' && (l.pos+1 >= len(l.data) || isPDFSpace(l.peek(1)) || isPDFDelimiter(l.peek(1))) {
+				l.pos++
+				return pdfRef(int(n))
+			}
+		}
+		l.pos = save
+	}
+	return n
+}
+
+func (l *pdfLexer) name() pdfName {
+	var sb strings.Builder
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		b := l.data[l.pos]
+		if b == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				sb.WriteByte(byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		sb.WriteByte(b)
+		l.pos++
+	}
+	return pdfName(sb.String())
+}
+
+func (l *pdfLexer) hexString() string {
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		b := l.data[l.pos]
+		if (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F') {
+			digits = append(digits, b)
+		}
+		l.pos++
+	}
+	l.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		v, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(v)
+	}
+	return string(out)
+}
+
+func (l *pdfLexer) literalString() string {
+	var sb strings.Builder
+	depth := 1
+	for l.pos < len(l.data) {
+		b := l.data[l.pos]
+		l.pos++
+		switch b {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return sb.String()
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return sb.String()
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case '\r':
+				// Line continuation
+				if l.peek(0) == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					sb.WriteByte(byte(v))
+				} else {
+					sb.WriteByte(e)
+				}
+			}
+			continue
+		}
+		sb.WriteByte(b)
+	}
+	return sb.String()
+}
@@ -0,0 +1,165 @@
+// Package language matches and detects the languages that chatbot content and
+// WhatsApp templates are written in. Languages are identified by codes such as
+// "en", "hi" or "en_US", as used by WhatsApp templates.
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Base returns the lowercase language part of a code, e.g. "en" for "en_US"
+func Base(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "_-"); i >= 0 {
+		code = code[:i]
+	}
+	return code
+}
+
+// Match returns the index of the code in available that best matches want:
+// an exact match first, then one with the same base language. Returns -1 if
+// none matches.
+func Match(want string, available []string) int {
+	if strings.TrimSpace(want) == "" {
+		return -1
+	}
+	for i, code := range available {
+		if strings.EqualFold(strings.ReplaceAll(code, "-", "_"), strings.ReplaceAll(want, "-", "_")) {
+			return i
+		}
+	}
+	base := Base(want)
+	for i, code := range available {
+		if Base(code) == base {
+			return i
+		}
+	}
+	return -1
+}
+
+// scriptLanguages lists the languages written in each script, most common first
+var scriptLanguages = []struct {
+	table     *unicode.RangeTable
+	languages []string
+}{
+	{unicode.Devanagari, []string{"hi", "mr", "ne"}},
+	{unicode.Bengali, []string{"bn", "as"}},
+	{unicode.Gurmukhi, []string{"pa"}},
+	{unicode.Gujarati, []string{"gu"}},
+	{unicode.Oriya, []string{"or"}},
+	{unicode.Tamil, []string{"ta"}},
+	{unicode.Telugu, []string{"te"}},
+	{unicode.Kannada, []string{"kn"}},
+	{unicode.Malayalam, []string{"ml"}},
+	{unicode.Arabic, []string{"ar", "ur", "fa"}},
+	{unicode.Cyrillic, []string{"ru", "uk"}},
+	{unicode.Thai, []string{"th"}},
+	{unicode.Hebrew, []string{"he"}},
+	{unicode.Greek, []string{"el"}},
+	{unicode.Latin, []string{"en", "es", "pt", "fr", "de", "id", "it"}},
+}
+
+// markerWords are common words that tell apart languages sharing a script
+var markerWords = map[string][]string{
+	"hi": {"है", "हैं", "मुझे", "मेरा", "मेरी", "आप", "क्या", "नहीं", "कैसे", "और", "चाहिए", "कहाँ", "कब", "हूँ"},
+	"mr": {"आहे", "आहेत", "मला", "माझा", "माझी", "तुम्ही", "काय", "नाही", "कसे", "आणि", "पाहिजे", "कुठे", "केव्हा", "आहो"},
+	"ne": {"छ", "छन्", "मलाई", "तपाईं", "के", "छैन", "कसरी", "र", "चाहिन्छ"},
+	"ar": {"في", "من", "على", "هل", "أريد", "شكرا", "مرحبا", "كيف", "لا"},
+	"ur": {"ہے", "ہیں", "میں", "کیا", "نہیں", "آپ", "مجھے", "چاہیے", "کیسے", "اور"},
+	"fa": {"است", "هست", "می", "خواهم", "ممنون", "سلام", "چطور", "نه"},
+	"ru": {"и", "не", "что", "как", "привет", "спасибо", "хочу", "мой"},
+	"uk": {"і", "що", "як", "привіт", "дякую", "хочу", "мій"},
+	"en": {"the", "is", "are", "my", "i", "you", "what", "where", "how", "please", "thanks", "hello", "hi", "want", "can"},
+	"es": {"el", "la", "es", "mi", "yo", "que", "qué", "dónde", "donde", "cómo", "como", "por", "favor", "gracias", "hola", "quiero", "pedido"},
+	"pt": {"o", "a", "é", "meu", "minha", "eu", "você", "onde", "como", "por", "favor", "obrigado", "obrigada", "olá", "oi", "quero", "pedido"},
+	"fr": {"le", "la", "est", "mon", "ma", "je", "vous", "où", "comment", "merci", "bonjour", "veux", "commande", "plaît"},
+	"de": {"der", "die", "das", "ist", "mein", "meine", "ich", "sie", "wo", "wie", "bitte", "danke", "hallo", "möchte", "bestellung"},
+	"id": {"saya", "anda", "apa", "di", "mana", "bagaimana", "tolong", "terima", "kasih", "halo", "mau", "pesanan", "yang"},
+	"it": {"il", "è", "mio", "mia", "io", "dove", "come", "per", "favore", "grazie", "ciao", "voglio", "ordine"},
+}
+
+// Detect guesses the language of text among candidates. It looks at the
+// script the text is written in, then at common words to tell apart the
+// languages sharing it. Returns the matching candidate, or "" if the text is
+// too short or ambiguous to tell.
+func Detect(text string, candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	// Count letters per script
+	counts := make([]int, len(scriptLanguages))
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for i, s := range scriptLanguages {
+			if unicode.Is(s.table, r) {
+				counts[i]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	best := 0
+	for i, n := range counts {
+		if n > counts[best] {
+			best = i
+		}
+	}
+	if counts[best]*2 <= letters {
+		return ""
+	}
+
+	// Candidates written in the dominant script
+	var inScript []string
+	for _, code := range candidates {
+		for _, lang := range scriptLanguages[best].languages {
+			if Base(code) == lang {
+				inScript = append(inScript, code)
+				break
+			}
+		}
+	}
+	switch len(inScript) {
+	case 0:
+		return ""
+	case 1:
+		// Latin text is too often another language to assume without evidence
+		if scriptLanguages[best].table != unicode.Latin {
+			return inScript[0]
+		}
+	}
+
+	// Score by marker words
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Mc, r)
+	})
+	winner, top, tie := "", 0, false
+	for _, code := range inScript {
+		score := 0
+		for _, w := range words {
+			for _, marker := range markerWords[Base(code)] {
+				if w == marker {
+					score++
+					break
+				}
+			}
+		}
+		switch {
+		case score > top:
+			winner, top, tie = code, score, false
+		case score == top && score > 0:
+			tie = true
+		}
+	}
+	if top == 0 || tie {
+		return ""
+	}
+	return winner
+}
@@ -0,0 +1,54 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBase(t *testing.T) {
+	assert.Equal(t, "en", Base("en_US"))
+	assert.Equal(t, "pt", Base("pt-BR"))
+	assert.Equal(t, "hi", Base(" HI "))
+	assert.Equal(t, "", Base(""))
+}
+
+func TestMatch(t *testing.T) {
+	available := []string{"en_US", "en", "hi", "pt_BR"}
+	assert.Equal(t, 1, Match("en", available))
+	assert.Equal(t, 0, Match("en-us", available))
+	assert.Equal(t, 0, Match("en_GB", available), "same base language")
+	assert.Equal(t, 3, Match("pt", available))
+	assert.Equal(t, -1, Match("mr", available))
+	assert.Equal(t, -1, Match("", available))
+}
+
+func TestDetect(t *testing.T) {
+	candidates := []string{"en", "hi", "mr"}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "Where is my order?", "en"},
+		{"hindi", "मेरा ऑर्डर कहाँ है?", "hi"},
+		{"marathi", "माझा ऑर्डर कुठे आहे?", "mr"},
+		{"hindi question", "क्या आप मेरी मदद कर सकते हैं", "hi"},
+		{"marathi request", "मला मदत पाहिजे", "mr"},
+		{"devanagari without markers", "ऑर्डर", ""},
+		{"romanized hindi", "mera order kab aayega", ""},
+		{"digits only", "12345", ""},
+		{"empty", "", ""},
+		{"script not offered", "Привет, как дела?", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Detect(tt.text, candidates))
+		})
+	}
+
+	assert.Equal(t, "hi_IN", Detect("ऑर्डर", []string{"en", "hi_IN"}), "only candidate in script")
+	assert.Equal(t, "es", Detect("Hola, dónde está mi pedido?", []string{"en", "es"}))
+	assert.Equal(t, "", Detect("Hello", nil))
+}
@@ -26,6 +26,7 @@ type BulkMessageCampaign struct {
 	ScheduledAt     *time.Time `json:"scheduled_at,omitempty"`
 	StartedAt       *time.Time `json:"started_at,omitempty"`
 	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	ThrottledUntil  *time.Time `json:"throttled_until,omitempty"` // Set while sends are deferred by account rate limits
 	CreatedBy       uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
 
 	// Relations
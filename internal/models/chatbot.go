@@ -9,9 +9,11 @@ import (
 // BusinessHoursConfig holds business hours settings
 type BusinessHoursConfig struct {
 	Enabled              bool       `gorm:"column:business_hours_enabled;default:false" json:"business_hours_enabled"`
-	Hours                JSONBArray `gorm:"column:business_hours;type:jsonb;default:'[]'" json:"business_hours"` // [{day, enabled, start_time, end_time}]
+	Hours                JSONBArray `gorm:"column:business_hours;type:jsonb;default:'[]'" json:"business_hours"` // [{day, enabled, start_time, end_time, intervals}]
 	OutOfHoursMessage    string     `gorm:"column:out_of_hours_message;type:text" json:"out_of_hours_message"`
 	AllowAutomatedOutside bool      `gorm:"column:allow_automated_outside_hours;default:true" json:"allow_automated_outside_hours"` // Allow flows/keywords/AI outside business hours
+	Timezone             string     `gorm:"column:business_hours_timezone;size:64" json:"business_hours_timezone"` // IANA name, e.g. Asia/Kolkata; empty uses the organization timezone
+	Holidays             JSONBArray `gorm:"column:business_hours_holidays;type:jsonb;default:'[]'" json:"business_hours_holidays"` // [{date, name, recurring, hours, out_of_hours_message}]
 }
 
 // AgentAssignmentConfig holds agent assignment and queue settings
@@ -19,6 +21,7 @@ type AgentAssignmentConfig struct {
 	AllowQueuePickup        bool `gorm:"column:allow_agent_queue_pickup;default:true" json:"allow_agent_queue_pickup"`           // Allow agents to pick transfers from queue
 	AssignToSameAgent       bool `gorm:"column:assign_to_same_agent;default:true" json:"assign_to_same_agent"`                   // Auto-assign transfers to contact's existing agent
 	CurrentConversationOnly bool `gorm:"column:agent_current_conversation_only;default:false" json:"agent_current_conversation_only"` // Agents see only current session messages
+	SkillRules              JSONBArray `gorm:"column:skill_rules;type:jsonb;default:'[]'" json:"skill_rules"` // [{field, value, skill}] skills required to serve contacts matching an attribute
 }
 
 // SLAConfig holds SLA tracking settings
@@ -45,14 +48,52 @@ type ClientInactivityConfig struct {
 // AIConfig holds AI provider settings
 type AIConfig struct {
 	Enabled        bool    `gorm:"column:ai_enabled;default:false" json:"ai_enabled"`
-	Provider       AIProvider `gorm:"column:ai_provider;size:20" json:"ai_provider"`                     // openai, anthropic, google
+	Provider       AIProvider `gorm:"column:ai_provider;size:20" json:"ai_provider"`                     // openai, anthropic, google, openai_compatible
 	APIKey         string  `gorm:"column:ai_api_key;type:text" json:"-"`                                 // encrypted
+	BaseURL        string  `gorm:"column:ai_base_url;size:500" json:"ai_base_url"`                       // overrides the provider's endpoint; required for openai_compatible
+	TimeoutSeconds int     `gorm:"column:ai_timeout_seconds;default:30" json:"ai_timeout_seconds"`      // per-request timeout
 	Model          string  `gorm:"column:ai_model;size:100" json:"ai_model"`
 	MaxTokens      int     `gorm:"column:ai_max_tokens;default:500" json:"ai_max_tokens"`
 	Temperature    float64 `gorm:"column:ai_temperature;type:decimal(3,2);default:0.7" json:"ai_temperature"`
 	SystemPrompt   string  `gorm:"column:ai_system_prompt;type:text" json:"ai_system_prompt"`
 	IncludeHistory bool    `gorm:"column:ai_include_history;default:true" json:"ai_include_history"`
 	HistoryLimit   int     `gorm:"column:ai_history_limit;default:4" json:"ai_history_limit"`
+	ToolCalling    bool    `gorm:"column:ai_tool_calling;default:false" json:"ai_tool_calling"`         // API contexts and AI custom actions are tools instead of prefetched context
+	MaxToolCalls   int     `gorm:"column:ai_max_tool_calls;default:3" json:"ai_max_tool_calls"`        // tool calls allowed per reply
+	EmbeddingModel string  `gorm:"column:ai_embedding_model;size:100" json:"ai_embedding_model"`       // embeds knowledge base chunks for semantic search; empty uses full-text search only
+	KnowledgeChunks int    `gorm:"column:ai_knowledge_chunks;default:4" json:"ai_knowledge_chunks"`    // knowledge base passages added to the context per reply
+}
+
+// OptOutConfig holds the keywords contacts use to opt out of and back into marketing messages
+type OptOutConfig struct {
+	Keywords      StringArray `gorm:"column:opt_out_keywords;type:jsonb;default:'[]'" json:"opt_out_keywords"` // Empty uses DefaultOptOutKeywords
+	Message       string      `gorm:"column:opt_out_message;type:text" json:"opt_out_message"`                 // Confirmation sent after opting out
+	OptInKeywords StringArray `gorm:"column:opt_in_keywords;type:jsonb;default:'[]'" json:"opt_in_keywords"`   // Empty uses DefaultOptInKeywords
+	OptInMessage  string      `gorm:"column:opt_in_message;type:text" json:"opt_in_message"`                   // Confirmation sent after opting back in
+}
+
+// IntentConfig holds how messages are classified against intent keyword rules
+type IntentConfig struct {
+	Threshold        float64 `gorm:"column:intent_threshold;type:decimal(3,2);default:0.5" json:"intent_threshold"`          // minimum confidence for an intent rule to fire
+	AIClassification bool    `gorm:"column:intent_ai_classification;default:false" json:"intent_ai_classification"` // ask the AI provider when no intent reaches the threshold
+}
+
+// LanguageConfig holds the languages the chatbot replies in
+type LanguageConfig struct {
+	DefaultLanguage string      `gorm:"column:default_language;size:20;default:'en'" json:"default_language"` // language of the untranslated content
+	Languages       StringArray `gorm:"column:languages;type:jsonb;default:'[]'" json:"languages"`            // other languages content is translated into
+	DetectLanguage  bool        `gorm:"column:detect_language;default:false" json:"detect_language"`          // set the contact's language from their messages
+}
+
+// CSATConfig holds the satisfaction survey sent after a transfer is resolved
+type CSATConfig struct {
+	Enabled         bool           `gorm:"column:csat_enabled;default:false" json:"csat_enabled"`
+	DelayMinutes    int            `gorm:"column:csat_delay_minutes;default:5" json:"csat_delay_minutes"`             // wait after resolution before sending
+	SurveyType      CSATSurveyType `gorm:"column:csat_survey_type;size:20;default:'buttons'" json:"csat_survey_type"` // buttons or flow
+	Question        string         `gorm:"column:csat_question;type:text" json:"csat_question"`                       // body of the survey message
+	ThankYouMessage string         `gorm:"column:csat_thank_you_message;type:text" json:"csat_thank_you_message"`     // sent after the contact rates
+	FlowID          string         `gorm:"column:csat_flow_id;size:100" json:"csat_flow_id"`                          // Meta flow ID, returns rating and comment
+	FlowCTA         string         `gorm:"column:csat_flow_cta;size:20" json:"csat_flow_cta"`                         // button text opening the flow
 }
 
 // PanelFieldConfig defines a field to display in the contact info panel
@@ -100,6 +141,14 @@ type ChatbotSettings struct {
 	SLA              SLAConfig              `gorm:"embedded"`
 	ClientInactivity ClientInactivityConfig `gorm:"embedded"`
 	AI               AIConfig               `gorm:"embedded"`
+	OptOut           OptOutConfig           `gorm:"embedded"`
+	Intent           IntentConfig           `gorm:"embedded"`
+	Language         LanguageConfig         `gorm:"embedded"`
+	CSAT             CSATConfig             `gorm:"embedded"`
+
+	// Per-language variants of the messages, keyed by language code then settings API field:
+	// {"hi": {"greeting_message": "...", "greeting_buttons": {"<button id>": "title"}}}
+	Translations JSONB `gorm:"type:jsonb;default:'{}'" json:"translations"`
 
 	// Session settings
 	SessionTimeoutMins int        `gorm:"default:30" json:"session_timeout_minutes"`
@@ -122,13 +171,15 @@ type KeywordRule struct {
 	IsEnabled       bool        `gorm:"default:true" json:"is_enabled"`
 	Priority        int         `gorm:"default:10" json:"priority"`
 	Keywords        StringArray `gorm:"type:jsonb;not null" json:"keywords"`
-	MatchType       MatchType    `gorm:"size:20;default:'contains'" json:"match_type"` // exact, contains, starts_with, regex
+	MatchType       MatchType    `gorm:"size:20;default:'contains'" json:"match_type"` // exact, contains, starts_with, regex, fuzzy, token_set, intent
+	FuzzyMaxEdits   int          `gorm:"default:0" json:"fuzzy_max_edits"`              // typos allowed by fuzzy rules; 0 scales with the keyword's length
 	CaseSensitive   bool         `gorm:"default:false" json:"case_sensitive"`
 	ResponseType    ResponseType `gorm:"size:20;not null" json:"response_type"` // text, template, media, flow, script
 	ResponseContent JSONB       `gorm:"type:jsonb;not null" json:"response_content"`
 	Conditions      string      `gorm:"type:text" json:"conditions"`
 	ActiveFrom      *time.Time  `json:"active_from,omitempty"`
 	ActiveUntil     *time.Time  `json:"active_until,omitempty"`
+	Translations    JSONB       `gorm:"type:jsonb;default:'{}'" json:"translations"` // {"hi": {"body": "...", "buttons": {"<button id>": "title"}}}
 
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
@@ -156,7 +207,10 @@ type ChatbotFlow struct {
 	CompletionConfig   JSONB       `gorm:"type:jsonb" json:"completion_config"`
 	TimeoutMessage     string      `gorm:"type:text" json:"timeout_message"`
 	CancelKeywords     StringArray `gorm:"type:jsonb" json:"cancel_keywords"`
+	Translations       JSONB       `gorm:"type:jsonb;default:'{}'" json:"translations"` // {"hi": {"initial_message": "...", "completion_message": "..."}}
 	PanelConfig        JSONB       `gorm:"type:jsonb;default:'{}'" json:"panel_config"` // Contact info panel configuration
+	PublishedVersion   int         `gorm:"default:0" json:"published_version"`      // Live version; 0 = never published, the draft is live
+	HasDraftChanges    bool        `gorm:"default:false" json:"has_draft_changes"` // Draft differs from the published version
 
 	// Relations
 	Organization    *Organization     `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
@@ -175,11 +229,14 @@ type ChatbotFlowStep struct {
 	StepName        string     `gorm:"size:100;not null" json:"step_name"`
 	StepOrder       int        `gorm:"not null" json:"step_order"`
 	Message         string       `gorm:"type:text;not null" json:"message"`
-	MessageType     FlowStepType `gorm:"size:20;default:'text'" json:"message_type"` // text, template, script, api_fetch, buttons, transfer
+	MessageType     FlowStepType `gorm:"size:20;default:'text'" json:"message_type"` // text, template, script, api_fetch, buttons, transfer, whatsapp_flow, wait, action, ai
 	TemplateID      *uuid.UUID `gorm:"type:uuid" json:"template_id,omitempty"`
 	ApiConfig       JSONB      `gorm:"type:jsonb" json:"api_config"`      // {url, method, headers, body, response_path, fallback_message}
 	Buttons         JSONBArray `gorm:"type:jsonb" json:"buttons"`         // [{id, title}] - max 10 options (3=buttons, 4-10=list)
 	TransferConfig  JSONB      `gorm:"type:jsonb" json:"transfer_config"` // {team_id: uuid, notes: string} - for transfer message type
+	WaitConfig      JSONB      `gorm:"type:jsonb" json:"wait_config"`     // {duration_minutes: int} or {until: RFC 3339 time} - for wait message type
+	Actions         JSONBArray `gorm:"type:jsonb" json:"actions"`         // [{type, tag, key, value, user_id, user_email, custom_action_id}] - for action message type
+	AIConfig        JSONB      `gorm:"type:jsonb" json:"ai_config"`       // {prompt, mode: reply|extract, schema: JSON schema, include_history} - for ai message type
 	InputType       InputType  `gorm:"size:20" json:"input_type"`         // none, text, number, email, phone, date, select, button, whatsapp_flow
 	InputConfig     JSONB      `gorm:"type:jsonb" json:"input_config"`
 	ValidationRegex string     `gorm:"size:255" json:"validation_regex"`
@@ -190,6 +247,7 @@ type ChatbotFlowStep struct {
 	SkipCondition   string     `gorm:"type:text" json:"skip_condition"`
 	RetryOnInvalid  bool       `gorm:"default:true" json:"retry_on_invalid"`
 	MaxRetries      int        `gorm:"default:3" json:"max_retries"`
+	Translations    JSONB      `gorm:"type:jsonb;default:'{}'" json:"translations"` // {"hi": {"message": "...", "validation_error": "...", "buttons": {"<button id>": "title"}}}
 
 	// Relations
 	Flow     *ChatbotFlow `gorm:"foreignKey:FlowID" json:"flow,omitempty"`
@@ -200,6 +258,23 @@ func (ChatbotFlowStep) TableName() string {
 	return "chatbot_flow_steps"
 }
 
+// ChatbotFlowVersion is an immutable snapshot of a flow and its steps, created on publish.
+// The ChatbotFlow and ChatbotFlowStep rows are the editable draft.
+type ChatbotFlowVersion struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	OrganizationID uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
+	FlowID         uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_chatbot_flow_versions_flow_version" json:"flow_id"`
+	Version        int        `gorm:"not null;uniqueIndex:idx_chatbot_flow_versions_flow_version" json:"version"`
+	Definition     JSONB      `gorm:"type:jsonb;not null" json:"definition"` // ChatbotFlow with steps at publish time
+	Notes          string     `gorm:"type:text" json:"notes"`
+	PublishedByID  *uuid.UUID `gorm:"type:uuid" json:"published_by_id,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (ChatbotFlowVersion) TableName() string {
+	return "chatbot_flow_versions"
+}
+
 // ChatbotSession tracks active conversation sessions
 type ChatbotSession struct {
 	BaseModel
@@ -209,7 +284,9 @@ type ChatbotSession struct {
 	PhoneNumber     string     `gorm:"size:50;not null" json:"phone_number"`
 	Status          SessionStatus `gorm:"size:20;default:'active'" json:"status"` // active, completed, cancelled, timeout
 	CurrentFlowID   *uuid.UUID `gorm:"type:uuid" json:"current_flow_id,omitempty"`
+	CurrentFlowVersion int     `gorm:"default:0" json:"current_flow_version"` // Flow version the session started on
 	CurrentStep     string     `gorm:"size:100" json:"current_step"`
+	WaitUntil       *time.Time `gorm:"index" json:"wait_until,omitempty"` // Set while the flow is paused on a wait step
 	StepRetries     int        `gorm:"default:0" json:"step_retries"`
 	SessionData     JSONB      `gorm:"type:jsonb;default:'{}'" json:"session_data"`
 	StartedAt       time.Time  `gorm:"autoCreateTime" json:"started_at"`
@@ -255,6 +332,7 @@ type AIContext struct {
 	TriggerKeywords StringArray `gorm:"type:jsonb" json:"trigger_keywords"`
 	StaticContent   string      `gorm:"type:text" json:"static_content"`
 	ApiConfig       JSONB       `gorm:"type:jsonb" json:"api_config"` // url, method, headers, body
+	ToolParameters  JSONB       `gorm:"type:jsonb" json:"tool_parameters"` // JSON schema of the arguments when called as an AI tool
 
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
@@ -289,6 +367,7 @@ type AgentTransfer struct {
 	Source              TransferSource `gorm:"size:20;default:'manual'" json:"source"` // manual, flow, keyword, chatbot_disabled
 	AgentID             *uuid.UUID `gorm:"type:uuid" json:"agent_id,omitempty"`
 	TeamID              *uuid.UUID `gorm:"type:uuid;index" json:"team_id,omitempty"` // Team queue (null = general queue)
+	RequiredSkills      StringArray `gorm:"type:jsonb;default:'[]'" json:"required_skills"` // Skills an agent needs to take the transfer
 	TransferredByUserID *uuid.UUID `gorm:"type:uuid" json:"transferred_by_user_id,omitempty"` // User who initiated the transfer (null for system)
 	Notes               string     `gorm:"type:text" json:"notes"`
 	TransferredAt       time.Time  `gorm:"autoCreateTime" json:"transferred_at"`
@@ -14,6 +14,7 @@ type Direction string
 const (
 	DirectionIncoming Direction = "incoming"
 	DirectionOutgoing Direction = "outgoing"
+	DirectionInternal Direction = "internal" // Chatbot session log entries that aren't messages, such as flow actions
 )
 
 // MessageType represents the type of WhatsApp message
@@ -49,9 +50,10 @@ const (
 type AIProvider string
 
 const (
-	AIProviderOpenAI    AIProvider = "openai"
-	AIProviderAnthropic AIProvider = "anthropic"
-	AIProviderGoogle    AIProvider = "google"
+	AIProviderOpenAI           AIProvider = "openai"
+	AIProviderAnthropic        AIProvider = "anthropic"
+	AIProviderGoogle           AIProvider = "google"
+	AIProviderOpenAICompatible AIProvider = "openai_compatible" // vLLM, Ollama, LiteLLM, Azure OpenAI and other servers with the OpenAI API
 )
 
 // MatchType represents keyword matching strategies
@@ -62,6 +64,9 @@ const (
 	MatchTypeContains   MatchType = "contains"
 	MatchTypeStartsWith MatchType = "starts_with"
 	MatchTypeRegex      MatchType = "regex"
+	MatchTypeFuzzy      MatchType = "fuzzy"     // within a few typos of the keyword
+	MatchTypeTokenSet   MatchType = "token_set" // all words of the keyword, in any order
+	MatchTypeIntent     MatchType = "intent"    // keywords are training phrases of an intent
 )
 
 // ResponseType represents chatbot response types
@@ -87,6 +92,21 @@ const (
 	FlowStepTypeButtons      FlowStepType = "buttons"
 	FlowStepTypeTransfer     FlowStepType = "transfer"
 	FlowStepTypeWhatsAppFlow FlowStepType = "whatsapp_flow"
+	FlowStepTypeWait         FlowStepType = "wait"
+	FlowStepTypeAction       FlowStepType = "action"
+	FlowStepTypeAI           FlowStepType = "ai"
+)
+
+// FlowActionType represents the operations an action step can perform on a contact
+type FlowActionType string
+
+const (
+	FlowActionAddTag       FlowActionType = "add_tag"
+	FlowActionRemoveTag    FlowActionType = "remove_tag"
+	FlowActionSetMetadata  FlowActionType = "set_metadata"
+	FlowActionAssignUser   FlowActionType = "assign_user"
+	FlowActionCustomAction FlowActionType = "custom_action"
+	FlowActionSetLanguage  FlowActionType = "set_language"
 )
 
 // SessionStatus represents chatbot session states
@@ -118,6 +138,42 @@ const (
 	TransferSourceChatbotDisabled TransferSource = "chatbot_disabled"
 )
 
+// ConversationStatus represents the states of a conversation in the inbox
+type ConversationStatus string
+
+const (
+	ConversationStatusOpen     ConversationStatus = "open"
+	ConversationStatusPending  ConversationStatus = "pending" // waiting on the customer
+	ConversationStatusSnoozed  ConversationStatus = "snoozed"
+	ConversationStatusResolved ConversationStatus = "resolved"
+)
+
+// CSATSurveyType represents how a satisfaction survey asks for the rating
+type CSATSurveyType string
+
+const (
+	CSATSurveyTypeButtons CSATSurveyType = "buttons" // list of 1-5 star options
+	CSATSurveyTypeFlow    CSATSurveyType = "flow"    // WhatsApp Flow with rating and comment
+)
+
+// CSATSurveyStatus represents the states of a satisfaction survey
+type CSATSurveyStatus string
+
+const (
+	CSATSurveyStatusScheduled CSATSurveyStatus = "scheduled"
+	CSATSurveyStatusSent      CSATSurveyStatus = "sent"
+	CSATSurveyStatusAnswered  CSATSurveyStatus = "answered"
+	CSATSurveyStatusFailed    CSATSurveyStatus = "failed"
+	CSATSurveyStatusExpired   CSATSurveyStatus = "expired" // not answered within the response window
+)
+
+// NotificationType represents the kinds of in-app notifications in a user's inbox
+type NotificationType string
+
+const (
+	NotificationTypeMention NotificationType = "mention" // mentioned in a conversation note
+)
+
 // CampaignStatus represents bulk message campaign states
 type CampaignStatus string
 
@@ -158,6 +214,15 @@ const (
 	ContextTypeAPI    ContextType = "api"
 )
 
+// KnowledgeDocumentStatus represents the indexing state of a knowledge base document
+type KnowledgeDocumentStatus string
+
+const (
+	KnowledgeDocumentProcessing KnowledgeDocumentStatus = "processing" // chunks are being embedded
+	KnowledgeDocumentReady      KnowledgeDocumentStatus = "ready"
+	KnowledgeDocumentFailed     KnowledgeDocumentStatus = "failed" // embedding failed; searched by keywords only
+)
+
 // InputType represents chatbot flow step input types
 type InputType string
 
@@ -206,6 +271,33 @@ const (
 	WebhookEventTransferAssigned WebhookEvent = "transfer.assigned"
 )
 
+// WebhookDeliveryStatus represents the state of an outbound webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+// SuppressionSource represents how a phone number was added to the suppression list
+type SuppressionSource string
+
+const (
+	SuppressionSourceKeyword SuppressionSource = "keyword" // Contact replied with an opt-out keyword
+	SuppressionSourceManual  SuppressionSource = "manual"
+	SuppressionSourceImport  SuppressionSource = "import"
+)
+
+// LanguageSource represents how a contact's language was set
+type LanguageSource string
+
+const (
+	LanguageSourceDetected LanguageSource = "detected" // Detected from the contact's messages
+	LanguageSourceFlow     LanguageSource = "flow"     // Set by a chatbot flow step
+	LanguageSourceManual   LanguageSource = "manual"   // Set by an agent
+)
+
 // ActionType represents custom action types
 type ActionType string
 
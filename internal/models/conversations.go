@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Conversation is the inbox state of a contact's chat on a WhatsApp account.
+// There is one per contact and account; resolving it and reopening it on the
+// next message reuses the same row.
+type Conversation struct {
+	BaseModel
+	OrganizationID    uuid.UUID          `gorm:"type:uuid;index;not null" json:"organization_id"`
+	ContactID         uuid.UUID          `gorm:"type:uuid;uniqueIndex:idx_conversations_contact_account;not null" json:"contact_id"`
+	WhatsAppAccount   string             `gorm:"size:100;uniqueIndex:idx_conversations_contact_account;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
+	Status            ConversationStatus `gorm:"size:20;index;default:'open'" json:"status"`
+	SnoozedUntil      *time.Time         `json:"snoozed_until,omitempty"` // Reopens at this time, or on the contact's next message
+	ResolutionReason  string             `gorm:"size:255" json:"resolution_reason"`
+	ResolvedAt        *time.Time         `json:"resolved_at,omitempty"`
+	ResolvedByID      *uuid.UUID         `gorm:"type:uuid" json:"resolved_by_id,omitempty"`
+	StatusChangedAt   time.Time          `json:"status_changed_at"`
+	StatusChangedByID *uuid.UUID         `gorm:"type:uuid" json:"status_changed_by_id,omitempty"` // null for system changes
+
+	// Relations
+	Organization    *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+	Contact         *Contact      `gorm:"foreignKey:ContactID" json:"contact,omitempty"`
+	ResolvedBy      *User         `gorm:"foreignKey:ResolvedByID" json:"resolved_by,omitempty"`
+	StatusChangedBy *User         `gorm:"foreignKey:StatusChangedByID" json:"status_changed_by,omitempty"`
+}
+
+func (Conversation) TableName() string {
+	return "conversations"
+}
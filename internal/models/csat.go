@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CSATSurvey is the satisfaction survey sent to a contact after their transfer
+// is resolved. There is at most one per transfer; the rating is credited to the
+// agent and team the transfer was assigned to.
+type CSATSurvey struct {
+	BaseModel
+	OrganizationID  uuid.UUID        `gorm:"type:uuid;index;not null" json:"organization_id"`
+	TransferID      uuid.UUID        `gorm:"type:uuid;uniqueIndex;not null" json:"transfer_id"`
+	ContactID       uuid.UUID        `gorm:"type:uuid;index;not null" json:"contact_id"`
+	WhatsAppAccount string           `gorm:"size:100;not null" json:"whatsapp_account"` // References WhatsAppAccount.Name
+	AgentID         *uuid.UUID       `gorm:"type:uuid;index" json:"agent_id,omitempty"`
+	TeamID          *uuid.UUID       `gorm:"type:uuid;index" json:"team_id,omitempty"`
+	SurveyType      CSATSurveyType   `gorm:"size:20;not null" json:"survey_type"`
+	Status          CSATSurveyStatus `gorm:"size:20;index;default:'scheduled'" json:"status"`
+	Rating          int              `gorm:"default:0" json:"rating"` // 1-5 once answered
+	Comment         string           `gorm:"type:text" json:"comment"`
+	SendAt          time.Time        `gorm:"index;not null" json:"send_at"`
+	SentAt          *time.Time       `json:"sent_at,omitempty"`
+	RespondedAt     *time.Time       `gorm:"index" json:"responded_at,omitempty"`
+	ErrorMessage    string           `gorm:"type:text" json:"error_message,omitempty"`
+
+	// Relations
+	Organization *Organization  `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+	Transfer     *AgentTransfer `gorm:"foreignKey:TransferID" json:"transfer,omitempty"`
+	Contact      *Contact       `gorm:"foreignKey:ContactID" json:"contact,omitempty"`
+	Agent        *User          `gorm:"foreignKey:AgentID" json:"agent,omitempty"`
+}
+
+func (CSATSurvey) TableName() string {
+	return "csat_surveys"
+}
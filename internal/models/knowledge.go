@@ -0,0 +1,74 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// KnowledgeDocument is a file uploaded to the chatbot's knowledge base. Its text is split
+// into chunks, and the chunks matching a user's message are added to the AI context.
+type KnowledgeDocument struct {
+	BaseModel
+	OrganizationID  uuid.UUID               `gorm:"type:uuid;index;not null" json:"organization_id"`
+	WhatsAppAccount string                  `gorm:"size:100;index" json:"whatsapp_account"` // empty for all accounts
+	Name            string                  `gorm:"size:255;not null" json:"name"`
+	FileName        string                  `gorm:"size:255" json:"file_name"`
+	SourceType      string                  `gorm:"size:20;not null" json:"source_type"` // pdf, markdown, html, csv, text
+	FileSize        int64                   `json:"file_size"`
+	Status          KnowledgeDocumentStatus `gorm:"size:20;not null;default:'ready'" json:"status"`
+	Error           string                  `gorm:"type:text" json:"error"`
+	ChunkCount      int                     `json:"chunk_count"`
+	EmbeddingModel  string                  `gorm:"size:100" json:"embedding_model"` // model of the chunks' embeddings, empty if not embedded
+	IsEnabled       bool                    `gorm:"default:true" json:"is_enabled"`
+	CreatedByID     *uuid.UUID              `gorm:"type:uuid" json:"created_by_id,omitempty"`
+
+	// Relations
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+}
+
+func (KnowledgeDocument) TableName() string {
+	return "knowledge_documents"
+}
+
+// KnowledgeChunk is a passage of a knowledge base document. It is found by full-text
+// search on its content, and by similarity when it has an embedding.
+type KnowledgeChunk struct {
+	BaseModel
+	OrganizationID uuid.UUID  `gorm:"type:uuid;index;not null" json:"organization_id"`
+	DocumentID     uuid.UUID  `gorm:"type:uuid;index;not null" json:"document_id"`
+	ChunkIndex     int        `gorm:"not null" json:"chunk_index"`
+	Content        string     `gorm:"type:text;not null" json:"content"`
+	Embedding      FloatArray `gorm:"type:jsonb" json:"-"`
+
+	// Relations
+	Document *KnowledgeDocument `gorm:"foreignKey:DocumentID" json:"document,omitempty"`
+}
+
+func (KnowledgeChunk) TableName() string {
+	return "knowledge_chunks"
+}
+
+// FloatArray is a vector stored as a JSON array
+type FloatArray []float32
+
+func (f FloatArray) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return json.Marshal(f)
+}
+
+func (f *FloatArray) Scan(value interface{}) error {
+	if value == nil {
+		*f = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, f)
+}
@@ -131,10 +131,11 @@ func (User) TableName() string {
 // UserOrganization represents a many-to-many relationship between users and organizations
 type UserOrganization struct {
 	BaseModel
-	UserID         uuid.UUID  `gorm:"type:uuid;uniqueIndex:idx_user_org;not null" json:"user_id"`
-	OrganizationID uuid.UUID  `gorm:"type:uuid;uniqueIndex:idx_user_org;not null" json:"organization_id"`
-	RoleID         *uuid.UUID `gorm:"type:uuid;index" json:"role_id,omitempty"`
-	IsDefault      bool       `gorm:"default:false" json:"is_default"`
+	UserID                 uuid.UUID  `gorm:"type:uuid;uniqueIndex:idx_user_org;not null" json:"user_id"`
+	OrganizationID         uuid.UUID  `gorm:"type:uuid;uniqueIndex:idx_user_org;not null" json:"organization_id"`
+	RoleID                 *uuid.UUID `gorm:"type:uuid;index" json:"role_id,omitempty"`
+	IsDefault              bool       `gorm:"default:false" json:"is_default"`
+	MaxConcurrentTransfers int        `gorm:"default:0" json:"max_concurrent_transfers"` // Max active transfers in this org, 0 for no limit
 
 	// Relations
 	User         *User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -166,11 +167,12 @@ func (UserAvailabilityLog) TableName() string {
 // Team represents a group of agents handling specific types of chats
 type Team struct {
 	BaseModel
-	OrganizationID     uuid.UUID `gorm:"type:uuid;index;not null" json:"organization_id"`
-	Name               string    `gorm:"size:100;not null" json:"name"`
-	Description        string    `gorm:"size:500" json:"description"`
-	AssignmentStrategy AssignmentStrategy `gorm:"size:50;default:'round_robin'" json:"assignment_strategy"` // round_robin, load_balanced, manual
-	IsActive           bool      `gorm:"default:true" json:"is_active"`
+	OrganizationID         uuid.UUID          `gorm:"type:uuid;index;not null" json:"organization_id"`
+	Name                   string             `gorm:"size:100;not null" json:"name"`
+	Description            string             `gorm:"size:500" json:"description"`
+	AssignmentStrategy     AssignmentStrategy `gorm:"size:50;default:'round_robin'" json:"assignment_strategy"` // round_robin, load_balanced, manual
+	MaxConcurrentTransfers int                `gorm:"default:0" json:"max_concurrent_transfers"`                // Max active transfers per member, 0 for no limit
+	IsActive               bool               `gorm:"default:true" json:"is_active"`
 
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
@@ -198,6 +200,23 @@ func (TeamMember) TableName() string {
 	return "team_members"
 }
 
+// AgentSkill is a skill of a user in an organization, such as a language or
+// product line, used to route transfers to the agents best suited to them
+type AgentSkill struct {
+	BaseModel
+	OrganizationID uuid.UUID `gorm:"type:uuid;index:idx_agent_skills_org_skill;not null" json:"organization_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;index;not null" json:"user_id"`
+	Skill          string    `gorm:"size:100;index:idx_agent_skills_org_skill;not null" json:"skill"` // lowercase, e.g. hindi or tier:gold
+	Proficiency    int       `gorm:"default:3" json:"proficiency"`                                     // 1 (basic) to 5 (expert)
+
+	// Relations
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (AgentSkill) TableName() string {
+	return "agent_skills"
+}
+
 // APIKey represents an API key for programmatic access
 type APIKey struct {
 	BaseModel
@@ -263,6 +282,48 @@ func (Webhook) TableName() string {
 	return "webhooks"
 }
 
+// WebhookDelivery records a single event sent to a webhook. Failed deliveries
+// stay pending with a next_attempt_at until they succeed or run out of attempts.
+type WebhookDelivery struct {
+	BaseModel
+	OrganizationID uuid.UUID             `gorm:"type:uuid;index;not null" json:"organization_id"`
+	WebhookID      uuid.UUID             `gorm:"type:uuid;index;not null" json:"webhook_id"`
+	Event          string                `gorm:"size:100;not null" json:"event"`
+	Payload        string                `gorm:"type:text;not null" json:"payload"`
+	Status         WebhookDeliveryStatus `gorm:"size:20;not null" json:"status"`
+	Attempts       int                   `gorm:"default:0" json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty"`
+	LastStatusCode int                   `json:"last_status_code"`
+	LastError      string                `gorm:"type:text" json:"last_error"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	ReplayOfID     *uuid.UUID            `gorm:"type:uuid" json:"replay_of_id,omitempty"`
+
+	// Relations
+	Webhook *Webhook `gorm:"foreignKey:WebhookID" json:"webhook,omitempty"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookDeliveryAttempt records one HTTP request made for a webhook delivery
+type WebhookDeliveryAttempt struct {
+	BaseModel
+	DeliveryID     uuid.UUID `gorm:"type:uuid;index;not null" json:"delivery_id"`
+	AttemptNumber  int       `gorm:"not null" json:"attempt_number"`
+	RequestURL     string    `gorm:"type:text" json:"request_url"`
+	RequestBody    string    `gorm:"type:text" json:"request_body"`
+	ResponseStatus int       `json:"response_status"`
+	ResponseBody   string    `gorm:"type:text" json:"response_body"` // First 1KB of the response
+	Error          string    `gorm:"type:text" json:"error"`
+	DurationMs     int64     `json:"duration_ms"`
+}
+
+func (WebhookDeliveryAttempt) TableName() string {
+	return "webhook_delivery_attempts"
+}
+
 // CustomAction represents a custom action button for chat integrations
 type CustomAction struct {
 	BaseModel
@@ -274,6 +335,11 @@ type CustomAction struct {
 	IsActive       bool      `gorm:"default:true" json:"is_active"`
 	DisplayOrder   int       `gorm:"default:0" json:"display_order"`
 
+	// Exposes the action as a tool the chatbot's AI can call
+	AITool            bool   `gorm:"column:ai_tool;default:false" json:"ai_tool"`
+	AIToolDescription string `gorm:"column:ai_tool_description;type:text" json:"ai_tool_description"`
+	AIToolParameters  JSONB  `gorm:"column:ai_tool_parameters;type:jsonb" json:"ai_tool_parameters"` // JSON schema of the arguments
+
 	// Relations
 	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
 }
@@ -297,6 +363,8 @@ type WhatsAppAccount struct {
 	IsDefaultIncoming  bool      `gorm:"default:false" json:"is_default_incoming"`
 	IsDefaultOutgoing  bool      `gorm:"default:false" json:"is_default_outgoing"`
 	AutoReadReceipt    bool      `gorm:"default:false" json:"auto_read_receipt"`
+	MessagesPerSecond  int       `gorm:"default:0" json:"messages_per_second"` // Campaign send rate, 0 = server default
+	DailyMessageLimit  int       `gorm:"default:0" json:"daily_message_limit"` // Campaign messages per UTC day, 0 = server default
 	Status             string    `gorm:"size:20;default:'active'" json:"status"`
 
 	// Relations
@@ -320,6 +388,8 @@ type Contact struct {
 	IsRead             bool       `gorm:"default:true" json:"is_read"`
 	Tags               JSONBArray `gorm:"type:jsonb;default:'[]'" json:"tags"`
 	Metadata           JSONB      `gorm:"type:jsonb;default:'{}'" json:"metadata"`
+	Language           string     `gorm:"size:20" json:"language"`        // preferred language for chatbot replies and templates
+	LanguageSource     LanguageSource `gorm:"size:20" json:"language_source"` // detected, flow, manual
 
 	// Chatbot SLA tracking
 	ChatbotLastMessageAt *time.Time `json:"chatbot_last_message_at,omitempty"` // When chatbot last sent a message
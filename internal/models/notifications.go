@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification is an in-app notification in a user's inbox, e.g. a mention in a
+// conversation note. It is unread until ReadAt is set.
+type Notification struct {
+	BaseModel
+	OrganizationID uuid.UUID        `gorm:"type:uuid;index;not null" json:"organization_id"`
+	UserID         uuid.UUID        `gorm:"type:uuid;index;not null" json:"user_id"` // Recipient
+	Type           NotificationType `gorm:"size:50;not null" json:"type"`
+	ActorID        *uuid.UUID       `gorm:"type:uuid" json:"actor_id,omitempty"`
+	ContactID      *uuid.UUID       `gorm:"type:uuid;index" json:"contact_id,omitempty"`
+	NoteID         *uuid.UUID       `gorm:"type:uuid;index" json:"note_id,omitempty"`
+	TeamID         *uuid.UUID       `gorm:"type:uuid" json:"team_id,omitempty"` // Set when notified through a team mention
+	Body           string           `gorm:"type:text" json:"body"`
+	ReadAt         *time.Time       `json:"read_at,omitempty"`
+
+	// Relations
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+	User         *User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Actor        *User         `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Contact      *Contact      `gorm:"foreignKey:ContactID" json:"contact,omitempty"`
+	Team         *Team         `gorm:"foreignKey:TeamID" json:"team,omitempty"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
@@ -46,31 +46,32 @@ func (RolePermission) TableName() string {
 
 // PermissionResource constants for available resources
 const (
-	ResourceUsers           = "users"
-	ResourceTeams           = "teams"
-	ResourceRoles           = "roles"
-	ResourceSettingsGeneral = "settings.general"
-	ResourceSettingsChatbot = "settings.chatbot"
-	ResourceSettingsSSO     = "settings.sso"
-	ResourceAccounts        = "accounts"
-	ResourceTemplates       = "templates"
-	ResourceFlowsWhatsApp   = "flows.whatsapp"
-	ResourceFlowsChatbot    = "flows.chatbot"
-	ResourceCampaigns       = "campaigns"
-	ResourceChatbotKeywords = "chatbot.keywords"
-	ResourceChatbotAI       = "chatbot.ai"
-	ResourceChat            = "chat"
-	ResourceChatAssign      = "chat.assign"
-	ResourceContacts        = "contacts"
-	ResourceTags            = "tags"
-	ResourceAnalytics       = "analytics"
-	ResourceAnalyticsAgents = "analytics.agents"
-	ResourceTransfers       = "transfers"
-	ResourceWebhooks        = "webhooks"
-	ResourceAPIKeys         = "api_keys"
-	ResourceCannedResponses = "canned_responses"
-	ResourceCustomActions   = "custom_actions"
-	ResourceOrganizations   = "organizations"
+	ResourceUsers             = "users"
+	ResourceTeams             = "teams"
+	ResourceRoles             = "roles"
+	ResourceSettingsGeneral   = "settings.general"
+	ResourceSettingsChatbot   = "settings.chatbot"
+	ResourceSettingsSSO       = "settings.sso"
+	ResourceAccounts          = "accounts"
+	ResourceTemplates         = "templates"
+	ResourceFlowsWhatsApp     = "flows.whatsapp"
+	ResourceFlowsChatbot      = "flows.chatbot"
+	ResourceCampaigns         = "campaigns"
+	ResourceChatbotKeywords   = "chatbot.keywords"
+	ResourceChatbotAI         = "chatbot.ai"
+	ResourceChat              = "chat"
+	ResourceChatAssign        = "chat.assign"
+	ResourceContacts          = "contacts"
+	ResourceTags              = "tags"
+	ResourceAnalytics         = "analytics"
+	ResourceAnalyticsAgents   = "analytics.agents"
+	ResourceTransfers         = "transfers"
+	ResourceWebhooks          = "webhooks"
+	ResourceAPIKeys           = "api_keys"
+	ResourceCannedResponses   = "canned_responses"
+	ResourceCustomActions     = "custom_actions"
+	ResourceNotificationRules = "notification_rules"
+	ResourceOrganizations     = "organizations"
 )
 
 // PermissionAction constants for available actions
@@ -196,6 +197,12 @@ func DefaultPermissions() []Permission {
 		{Resource: ResourceCustomActions, Action: ActionWrite, Description: "Create and edit custom actions"},
 		{Resource: ResourceCustomActions, Action: ActionDelete, Description: "Delete custom actions"},
 
+		// Notification Rules
+		{Resource: ResourceNotificationRules, Action: ActionRead, Description: "View notification rules"},
+		{Resource: ResourceNotificationRules, Action: ActionWrite, Description: "Create and edit notification rules"},
+		{Resource: ResourceNotificationRules, Action: ActionDelete, Description: "Delete notification rules"},
+		{Resource: ResourceNotificationRules, Action: ActionExecute, Description: "Trigger notification rules"},
+
 		// Organizations
 		{Resource: ResourceOrganizations, Action: ActionRead, Description: "View organizations"},
 		{Resource: ResourceOrganizations, Action: ActionWrite, Description: "Create organizations"},
@@ -246,6 +253,8 @@ func SystemRolePermissions() map[string][]string {
 		"canned_responses:read", "canned_responses:write", "canned_responses:delete",
 		// Custom Actions
 		"custom_actions:read", "custom_actions:write", "custom_actions:delete",
+		// Notification Rules
+		"notification_rules:read", "notification_rules:write", "notification_rules:delete", "notification_rules:execute",
 		// Organizations (read only)
 		"organizations:read",
 	}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultOptOutKeywords are used when the chatbot settings don't configure opt-out keywords
+var DefaultOptOutKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "OPT OUT", "OPTOUT"}
+
+// DefaultOptInKeywords are used when the chatbot settings don't configure opt-in keywords
+var DefaultOptInKeywords = []string{"START", "UNSTOP", "SUBSCRIBE"}
+
+// ContactSuppression is a phone number that must not receive marketing messages.
+// Entries are removed when the contact opts back in, so the list only holds
+// numbers that are currently suppressed.
+type ContactSuppression struct {
+	ID             uuid.UUID         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	OrganizationID uuid.UUID         `gorm:"type:uuid;not null;uniqueIndex:idx_contact_suppressions_org_phone" json:"organization_id"`
+	PhoneNumber    string            `gorm:"size:50;not null;uniqueIndex:idx_contact_suppressions_org_phone" json:"phone_number"`
+	Source         SuppressionSource `gorm:"size:20;not null" json:"source"` // keyword, manual, import
+	Reason         string            `gorm:"type:text" json:"reason"`
+	CreatedByID    *uuid.UUID        `gorm:"type:uuid" json:"created_by_id,omitempty"` // Null for keyword opt-outs
+	CreatedAt      time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Organization *Organization `gorm:"foreignKey:OrganizationID" json:"organization,omitempty"`
+}
+
+func (ContactSuppression) TableName() string {
+	return "contact_suppressions"
+}
@@ -0,0 +1,240 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DeadLetterStreamName is the Redis stream for campaign jobs that could not be processed
+	DeadLetterStreamName = "whatomate:campaigns:dead"
+
+	// DeadLetterMaxLen caps the dead-letter stream; older entries are trimmed approximately
+	DeadLetterMaxLen = 10000
+
+	// MaxJobRetries is how often a failed job is retried before it is dead-lettered
+	MaxJobRetries = 5
+
+	// MaxDeliveries is how often a job may be delivered without being acknowledged,
+	// e.g. because it crashed the worker, before it is dead-lettered
+	MaxDeliveries = 5
+
+	// RetryBaseDelay is the delay before the first retry; it doubles with each retry
+	RetryBaseDelay = 30 * time.Second
+
+	// RetryMaxDelay caps the delay between retries
+	RetryMaxDelay = 30 * time.Minute
+)
+
+// ErrDeadLetterNotFound is returned when a dead-lettered job does not exist
+var ErrDeadLetterNotFound = errors.New("dead-lettered job not found")
+
+// requeueScript moves a dead-lettered job back to the job stream, unless another
+// request already requeued or deleted it
+var requeueScript = redis.NewScript(`
+if redis.call('XDEL', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('XADD', KEYS[2], '*', 'type', ARGV[2], 'payload', ARGV[3])
+return 1
+`)
+
+// permanentError marks a job failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that the job is dead-lettered without being retried
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// RetryDelay returns the delay before retrying a job that has been retried the given number of times
+func RetryDelay(retries int) time.Duration {
+	delay := RetryBaseDelay
+	for i := 0; i < retries && delay < RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > RetryMaxDelay {
+		delay = RetryMaxDelay
+	}
+	return delay
+}
+
+// DeadLetter is a job in the dead-letter stream
+type DeadLetter struct {
+	ID        string        `json:"id"`
+	Type      JobType       `json:"type"`
+	Job       *RecipientJob `json:"job,omitempty"`
+	Payload   string        `json:"payload,omitempty"` // Raw payload, only set if it could not be decoded
+	Error     string        `json:"error"`
+	Retries   int           `json:"retries"`
+	MessageID string        `json:"message_id"` // ID of the job in the job stream
+	FailedAt  time.Time     `json:"failed_at"`
+}
+
+// addDeadLetter adds a job stream message to the dead-letter stream
+func addDeadLetter(ctx context.Context, client *redis.Client, msg redis.XMessage, retries int, cause error) error {
+	jobType, _ := msg.Values["type"].(string)
+	payload, _ := msg.Values["payload"].(string)
+
+	_, err := client.XAdd(ctx, &redis.XAddArgs{
+		Stream: DeadLetterStreamName,
+		MaxLen: DeadLetterMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":       jobType,
+			"payload":    payload,
+			"error":      cause.Error(),
+			"retries":    retries,
+			"message_id": msg.ID,
+			"failed_at":  time.Now().UTC().Format(time.RFC3339),
+		},
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter job: %w", err)
+	}
+	return nil
+}
+
+// parseDeadLetter decodes an entry of the dead-letter stream
+func parseDeadLetter(msg redis.XMessage) DeadLetter {
+	str := func(key string) string {
+		v, _ := msg.Values[key].(string)
+		return v
+	}
+
+	dl := DeadLetter{
+		ID:        msg.ID,
+		Type:      JobType(str("type")),
+		Error:     str("error"),
+		MessageID: str("message_id"),
+	}
+	dl.Retries, _ = strconv.Atoi(str("retries"))
+	dl.FailedAt, _ = time.Parse(time.RFC3339, str("failed_at"))
+
+	var job RecipientJob
+	if dl.Type == JobTypeRecipient && json.Unmarshal([]byte(str("payload")), &job) == nil {
+		dl.Job = &job
+	} else {
+		dl.Payload = str("payload")
+	}
+	return dl
+}
+
+// DeadLetterQueue gives access to dead-lettered jobs
+type DeadLetterQueue struct {
+	client *redis.Client
+}
+
+// NewDeadLetterQueue creates a new dead-letter queue
+func NewDeadLetterQueue(client *redis.Client) *DeadLetterQueue {
+	return &DeadLetterQueue{client: client}
+}
+
+// List returns up to limit dead-lettered jobs matching filter, newest first.
+// Listing starts after the entry with ID before, or at the newest entry if
+// before is empty. The returned cursor continues the listing, or is empty
+// when there are no more entries.
+func (d *DeadLetterQueue) List(ctx context.Context, filter func(*DeadLetter) bool, before string, limit int) ([]DeadLetter, string, error) {
+	const batchSize = 200
+
+	result := []DeadLetter{}
+	end := "+"
+	if before != "" {
+		end = "(" + before
+	}
+
+	for {
+		msgs, err := d.client.XRevRangeN(ctx, DeadLetterStreamName, end, "-", batchSize).Result()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list dead-lettered jobs: %w", err)
+		}
+
+		for _, msg := range msgs {
+			dl := parseDeadLetter(msg)
+			if filter != nil && !filter(&dl) {
+				continue
+			}
+			result = append(result, dl)
+			if len(result) == limit {
+				return result, msg.ID, nil
+			}
+		}
+
+		if len(msgs) < batchSize {
+			return result, "", nil
+		}
+		end = "(" + msgs[len(msgs)-1].ID
+	}
+}
+
+// Get returns a single dead-lettered job
+func (d *DeadLetterQueue) Get(ctx context.Context, id string) (*DeadLetter, error) {
+	msgs, err := d.client.XRange(ctx, DeadLetterStreamName, id, id).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead-lettered job: %w", err)
+	}
+	if len(msgs) == 0 {
+		return nil, ErrDeadLetterNotFound
+	}
+	dl := parseDeadLetter(msgs[0])
+	return &dl, nil
+}
+
+// Requeue moves a dead-lettered recipient job back to the job stream with its
+// retries reset
+func (d *DeadLetterQueue) Requeue(ctx context.Context, dl *DeadLetter) error {
+	if dl.Job == nil {
+		return fmt.Errorf("dead-lettered job %s cannot be decoded", dl.ID)
+	}
+
+	job := *dl.Job
+	job.Retries = 0
+	job.Attempts = 0
+	job.EnqueuedAt = time.Now()
+	payload, err := json.Marshal(&job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recipient job: %w", err)
+	}
+
+	moved, err := requeueScript.Run(ctx, d.client,
+		[]string{DeadLetterStreamName, StreamName},
+		dl.ID, string(JobTypeRecipient), string(payload),
+	).Int()
+	if err != nil {
+		return fmt.Errorf("failed to requeue dead-lettered job: %w", err)
+	}
+	if moved == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+// Delete removes a dead-lettered job
+func (d *DeadLetterQueue) Delete(ctx context.Context, id string) error {
+	n, err := d.client.XDel(ctx, DeadLetterStreamName, id).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete dead-lettered job: %w", err)
+	}
+	if n == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DelayedSetName is the sorted set of recipient jobs waiting to be re-enqueued,
+	// scored by the unix time in milliseconds at which they become due
+	DelayedSetName = "whatomate:campaigns:delayed"
+
+	// promoteBatchSize is the maximum number of delayed jobs moved per call
+	promoteBatchSize = 500
+)
+
+// promoteScript atomically moves due jobs from the delayed set to the stream so
+// that several workers can promote concurrently without duplicating jobs.
+var promoteScript = redis.NewScript(`
+local jobs = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, job in ipairs(jobs) do
+	redis.call('XADD', KEYS[2], '*', 'type', ARGV[3], 'payload', job)
+	redis.call('ZREM', KEYS[1], job)
+end
+return #jobs
+`)
+
+// EnqueueRecipientAt schedules a recipient job to be added to the stream at the given time
+func (q *RedisQueue) EnqueueRecipientAt(ctx context.Context, job *RecipientJob, at time.Time) error {
+	return scheduleRecipient(ctx, q.client, job, at)
+}
+
+// scheduleRecipient adds a recipient job to the delayed set
+func scheduleRecipient(ctx context.Context, client *redis.Client, job *RecipientJob, at time.Time) error {
+	if job.EnqueuedAt.IsZero() {
+		job.EnqueuedAt = time.Now()
+	}
+
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recipient job: %w", err)
+	}
+
+	err = client.ZAdd(ctx, DelayedSetName, redis.Z{
+		Score:  float64(at.UnixMilli()),
+		Member: string(payload),
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to schedule recipient job: %w", err)
+	}
+	return nil
+}
+
+// PromoteDueRecipients moves delayed recipient jobs that are due onto the stream
+// and returns how many were moved
+func (q *RedisQueue) PromoteDueRecipients(ctx context.Context) (int, error) {
+	n, err := promoteScript.Run(ctx, q.client,
+		[]string{DelayedSetName, StreamName},
+		strconv.FormatInt(time.Now().UnixMilli(), 10), promoteBatchSize, string(JobTypeRecipient),
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to promote delayed recipient jobs: %w", err)
+	}
+	return n, nil
+}
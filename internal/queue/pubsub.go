@@ -3,6 +3,7 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
@@ -13,6 +14,9 @@ import (
 const (
 	// CampaignStatsChannel is the Redis pub/sub channel for campaign stats updates
 	CampaignStatsChannel = "whatomate:campaign_stats"
+
+	// WSBroadcastChannel is the Redis pub/sub channel for WebSocket broadcasts between API instances
+	WSBroadcastChannel = "whatomate:ws_broadcast"
 )
 
 // CampaignStatsUpdate represents a campaign stats update message
@@ -24,6 +28,7 @@ type CampaignStatsUpdate struct {
 	DeliveredCount int                  `json:"delivered_count"`
 	ReadCount      int                  `json:"read_count"`
 	FailedCount    int                  `json:"failed_count"`
+	ThrottledUntil *time.Time           `json:"throttled_until,omitempty"`
 }
 
 // Publisher publishes messages to Redis pub/sub channels
@@ -56,6 +61,15 @@ func (p *Publisher) PublishCampaignStats(ctx context.Context, update *CampaignSt
 	return nil
 }
 
+// PublishWSBroadcast publishes an encoded WebSocket broadcast to all API instances
+func (p *Publisher) PublishWSBroadcast(ctx context.Context, payload []byte) error {
+	if err := p.client.Publish(ctx, WSBroadcastChannel, payload).Err(); err != nil {
+		p.log.Error("Failed to publish websocket broadcast", "error", err)
+		return err
+	}
+	return nil
+}
+
 // Subscriber subscribes to Redis pub/sub channels
 type Subscriber struct {
 	client *redis.Client
@@ -112,6 +126,39 @@ func (s *Subscriber) SubscribeCampaignStats(ctx context.Context, handler func(up
 	return nil
 }
 
+// SubscribeWSBroadcast subscribes to WebSocket broadcasts from other API instances.
+// The handler is called with the raw payload of each received broadcast.
+func (s *Subscriber) SubscribeWSBroadcast(ctx context.Context, handler func(payload []byte)) error {
+	s.pubsub = s.client.Subscribe(ctx, WSBroadcastChannel)
+
+	// Wait for subscription confirmation
+	_, err := s.pubsub.Receive(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.log.Info("Subscribed to websocket broadcast channel")
+
+	ch := s.pubsub.Channel()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				s.log.Info("Websocket broadcast subscriber shutting down")
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					s.log.Info("Websocket broadcast channel closed")
+					return
+				}
+				handler([]byte(msg.Payload))
+			}
+		}
+	}()
+
+	return nil
+}
+
 // Close closes the subscriber
 func (s *Subscriber) Close() error {
 	if s.pubsub != nil {
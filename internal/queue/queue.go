@@ -14,6 +14,9 @@ type JobType string
 const (
 	// JobTypeRecipient is for processing a single recipient message
 	JobTypeRecipient JobType = "recipient"
+
+	// JobTypeWebhook is for processing a raw inbound Meta webhook payload
+	JobTypeWebhook JobType = "webhook"
 )
 
 // RecipientJob represents a single recipient message job
@@ -25,6 +28,10 @@ type RecipientJob struct {
 	RecipientName  string        `json:"recipient_name"`
 	TemplateParams models.JSONB  `json:"template_params"`
 	EnqueuedAt     time.Time     `json:"enqueued_at"`
+	// Attempts counts how often the job was deferred because of rate limiting
+	Attempts       int           `json:"attempts,omitempty"`
+	// Retries counts how often the job was retried after its handler failed
+	Retries        int           `json:"retries,omitempty"`
 }
 
 // Queue defines the interface for job queue operations
@@ -35,15 +42,25 @@ type Queue interface {
 	// EnqueueRecipients adds multiple recipient jobs to the queue
 	EnqueueRecipients(ctx context.Context, jobs []*RecipientJob) error
 
+	// EnqueueWebhook adds a raw inbound webhook payload to the webhook stream
+	EnqueueWebhook(ctx context.Context, payload []byte) error
+
 	// Close closes the queue connection
 	Close() error
 }
 
-// JobHandler handles different job types
+// JobHandler handles different job types. Returning an error retries the job
+// with backoff; wrap the error with Permanent to dead-letter it right away.
 type JobHandler interface {
 	HandleRecipientJob(ctx context.Context, job *RecipientJob) error
 }
 
+// DeadLetterHandler is implemented by job handlers that need to know when a job
+// is moved to the dead-letter stream, e.g. to mark the recipient as failed
+type DeadLetterHandler interface {
+	HandleDeadRecipientJob(ctx context.Context, job *RecipientJob, reason string)
+}
+
 // Consumer defines the interface for consuming jobs from the queue
 type Consumer interface {
 	// Consume starts consuming jobs from the queue
@@ -3,6 +3,7 @@ package queue_test
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -447,6 +448,46 @@ func TestSubscribeCampaignStats_ReceivesUpdate(t *testing.T) {
 	assert.Equal(t, update.FailedCount, matched.FailedCount)
 }
 
+func TestSubscribeWSBroadcast_ReceivesPayload(t *testing.T) {
+	t.Parallel()
+	client := skipIfNoRedis(t)
+	log := testutil.NopLogger()
+	ctx := testutil.TestContextWithTimeout(t, 10*time.Second)
+
+	pub := queue.NewPublisher(client, log)
+	sub := queue.NewSubscriber(client, log)
+	defer sub.Close()
+
+	// Use a unique payload to filter out messages from parallel tests
+	target := `{"origin":"` + uuid.New().String() + `"}`
+
+	var mu sync.Mutex
+	var received bool
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	err := sub.SubscribeWSBroadcast(subCtx, func(payload []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		if string(payload) == target {
+			received = true
+		}
+	})
+	require.NoError(t, err)
+
+	// Give the subscriber a moment to fully establish.
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, pub.PublishWSBroadcast(ctx, []byte(target)))
+
+	testutil.AssertEventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return received
+	}, 5*time.Second, "subscriber should have received the broadcast")
+}
+
 func TestSubscriber_Close(t *testing.T) {
 	t.Parallel()
 	client := skipIfNoRedis(t)
@@ -535,3 +576,359 @@ func TestPublishCampaignStats_InvalidRedis(t *testing.T) {
 	err := pub.PublishCampaignStats(ctx, update)
 	assert.Error(t, err)
 }
+
+// --- Webhook stream tests ---
+
+// cleanWebhookStream deletes the webhook stream so each test starts fresh.
+func cleanWebhookStream(t *testing.T, client *redis.Client) {
+	t.Helper()
+	ctx := context.Background()
+	client.Del(ctx, queue.WebhookStreamName)
+	t.Cleanup(func() {
+		client.Del(ctx, queue.WebhookStreamName)
+		client.XGroupDestroy(ctx, queue.WebhookStreamName, queue.WebhookConsumerGroup)
+	})
+}
+
+func TestConsumeWebhooks_ProcessesAndAcksPayload(t *testing.T) {
+	client := skipIfNoRedis(t)
+	cleanWebhookStream(t, client)
+	log := testutil.NopLogger()
+	ctx := testutil.TestContextWithTimeout(t, 10*time.Second)
+
+	q := queue.NewRedisQueue(client, log)
+	require.NoError(t, q.EnqueueWebhook(ctx, []byte(`{"object":"whatsapp_business_account"}`)))
+
+	consumer, err := queue.NewWebhookConsumer(client, log, "test", 1)
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	var mu sync.Mutex
+	var received []string
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		_ = consumer.Consume(consumeCtx, func(_ context.Context, payload []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, string(payload))
+			return nil
+		}, nil)
+	}()
+
+	testutil.AssertEventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 1
+	}, 8*time.Second, "handler should have received the webhook")
+	cancel()
+
+	assert.Equal(t, `{"object":"whatsapp_business_account"}`, received[0])
+	testutil.AssertEventually(t, func() bool {
+		pending, err := client.XPending(ctx, queue.WebhookStreamName, queue.WebhookConsumerGroup).Result()
+		return err == nil && pending.Count == 0
+	}, 2*time.Second, "processed webhook should be acknowledged")
+}
+
+func TestConsumeWebhooks_FailedPayloadStaysPending(t *testing.T) {
+	client := skipIfNoRedis(t)
+	cleanWebhookStream(t, client)
+	log := testutil.NopLogger()
+	ctx := testutil.TestContextWithTimeout(t, 10*time.Second)
+
+	q := queue.NewRedisQueue(client, log)
+	require.NoError(t, q.EnqueueWebhook(ctx, []byte(`{}`)))
+
+	consumer, err := queue.NewWebhookConsumer(client, log, "test", 1)
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		_ = consumer.Consume(consumeCtx, func(context.Context, []byte) error {
+			return assert.AnError
+		}, nil)
+	}()
+
+	testutil.AssertEventually(t, func() bool {
+		pending, err := client.XPending(ctx, queue.WebhookStreamName, queue.WebhookConsumerGroup).Result()
+		return err == nil && pending.Count == 1
+	}, 8*time.Second, "failed webhook should stay pending")
+}
+
+func TestConsumeWebhooks_SlowKeyDoesNotBlockOthers(t *testing.T) {
+	client := skipIfNoRedis(t)
+	cleanWebhookStream(t, client)
+	log := testutil.NopLogger()
+	ctx := testutil.TestContextWithTimeout(t, 10*time.Second)
+
+	q := queue.NewRedisQueue(client, log)
+	for _, payload := range []string{"alice 1", "alice 2", "bob 1"} {
+		require.NoError(t, q.EnqueueWebhook(ctx, []byte(payload)))
+	}
+
+	consumer, err := queue.NewWebhookConsumer(client, log, "test", 4)
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var received []string
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		_ = consumer.Consume(consumeCtx, func(_ context.Context, payload []byte) error {
+			if string(payload) == "alice 1" {
+				<-release
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, string(payload))
+			return nil
+		}, func(payload []byte) string {
+			return strings.Fields(string(payload))[0]
+		})
+	}()
+
+	testutil.AssertEventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 1
+	}, 8*time.Second, "bob's payload should not wait for alice's")
+	close(release)
+	testutil.AssertEventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 3
+	}, 8*time.Second, "alice's payloads should be processed")
+
+	assert.Equal(t, []string{"bob 1", "alice 1", "alice 2"}, received)
+}
+
+func TestEnqueueWebhook_InvalidRedis(t *testing.T) {
+	t.Parallel()
+	log := testutil.NopLogger()
+
+	badClient := redis.NewClient(&redis.Options{
+		Addr:        "localhost:1",
+		DialTimeout: 100 * time.Millisecond,
+	})
+	defer badClient.Close()
+
+	q := queue.NewRedisQueue(badClient, log)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	assert.Error(t, q.EnqueueWebhook(ctx, []byte(`{}`)))
+}
+
+// --- Delayed recipient tests ---
+
+func TestPromoteDueRecipients_MovesOnlyDueJobs(t *testing.T) {
+	client := skipIfNoRedis(t)
+	cleanStream(t, client)
+	ctx := testutil.TestContext(t)
+	client.Del(ctx, queue.DelayedSetName)
+	t.Cleanup(func() { client.Del(context.Background(), queue.DelayedSetName) })
+
+	q := queue.NewRedisQueue(client, testutil.NopLogger())
+	due := makeRecipientJob()
+	due.Attempts = 1
+	later := makeRecipientJob()
+
+	require.NoError(t, q.EnqueueRecipientAt(ctx, due, time.Now().Add(-time.Second)))
+	require.NoError(t, q.EnqueueRecipientAt(ctx, later, time.Now().Add(time.Hour)))
+
+	n, err := q.PromoteDueRecipients(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	msgs, err := client.XRange(ctx, queue.StreamName, "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, string(queue.JobTypeRecipient), msgs[0].Values["type"])
+
+	var decoded queue.RecipientJob
+	require.NoError(t, json.Unmarshal([]byte(msgs[0].Values["payload"].(string)), &decoded))
+	assert.Equal(t, due.RecipientID, decoded.RecipientID)
+	assert.Equal(t, 1, decoded.Attempts)
+
+	remaining, err := client.ZCard(ctx, queue.DelayedSetName).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), remaining)
+}
+
+func TestEnqueueRecipientAt_InvalidRedis(t *testing.T) {
+	t.Parallel()
+	log := testutil.NopLogger()
+
+	badClient := redis.NewClient(&redis.Options{
+		Addr:        "localhost:1",
+		DialTimeout: 100 * time.Millisecond,
+	})
+	defer badClient.Close()
+
+	q := queue.NewRedisQueue(badClient, log)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	assert.Error(t, q.EnqueueRecipientAt(ctx, makeRecipientJob(), time.Now()))
+}
+
+// --- Retry and dead-letter tests ---
+
+// cleanRetryState deletes the delayed set and dead-letter stream so each test starts fresh.
+func cleanRetryState(t *testing.T, client *redis.Client) {
+	t.Helper()
+	ctx := context.Background()
+	client.Del(ctx, queue.DelayedSetName, queue.DeadLetterStreamName)
+	t.Cleanup(func() {
+		client.Del(ctx, queue.DelayedSetName, queue.DeadLetterStreamName)
+	})
+}
+
+// deadLetterHandler is a mockHandler that also records dead-lettered jobs.
+type deadLetterHandler struct {
+	mockHandler
+	dead []string
+}
+
+func (h *deadLetterHandler) HandleDeadRecipientJob(_ context.Context, job *queue.RecipientJob, reason string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dead = append(h.dead, reason)
+}
+
+func (h *deadLetterHandler) getDead() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.dead...)
+}
+
+// consumeJob enqueues job and runs a consumer with handler until cond holds.
+func consumeJob(t *testing.T, client *redis.Client, job *queue.RecipientJob, handler queue.JobHandler, cond func() bool, msg string) {
+	t.Helper()
+	log := testutil.NopLogger()
+	ctx := testutil.TestContextWithTimeout(t, 10*time.Second)
+
+	require.NoError(t, queue.NewRedisQueue(client, log).EnqueueRecipient(ctx, job))
+
+	consumer, err := queue.NewRedisConsumer(client, log)
+	require.NoError(t, err)
+	defer consumer.Close()
+
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		_ = consumer.Consume(consumeCtx, handler)
+	}()
+
+	testutil.AssertEventually(t, cond, 8*time.Second, msg)
+	cancel()
+
+	testutil.AssertEventually(t, func() bool {
+		pending, err := client.XPending(ctx, queue.StreamName, queue.ConsumerGroup).Result()
+		return err == nil && pending.Count == 0
+	}, 2*time.Second, "handled job should be acknowledged")
+}
+
+func TestConsume_FailedJobIsRetried(t *testing.T) {
+	client := skipIfNoRedis(t)
+	cleanStream(t, client)
+	cleanRetryState(t, client)
+	ctx := context.Background()
+
+	handler := &deadLetterHandler{mockHandler: mockHandler{err: assert.AnError}}
+	consumeJob(t, client, makeRecipientJob(), handler, func() bool {
+		n, _ := client.ZCard(ctx, queue.DelayedSetName).Result()
+		return n == 1
+	}, "failed job should be scheduled for retry")
+
+	members, err := client.ZRangeWithScores(ctx, queue.DelayedSetName, 0, -1).Result()
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+
+	var retried queue.RecipientJob
+	require.NoError(t, json.Unmarshal([]byte(members[0].Member.(string)), &retried))
+	assert.Equal(t, 1, retried.Retries)
+	assert.InDelta(t, float64(time.Now().Add(queue.RetryBaseDelay).UnixMilli()), members[0].Score, float64(5*time.Second/time.Millisecond))
+	assert.Empty(t, handler.getDead())
+}
+
+func TestConsume_ExhaustedJobIsDeadLettered(t *testing.T) {
+	client := skipIfNoRedis(t)
+	cleanStream(t, client)
+	cleanRetryState(t, client)
+	ctx := context.Background()
+
+	job := makeRecipientJob()
+	job.Retries = queue.MaxJobRetries
+	handler := &deadLetterHandler{mockHandler: mockHandler{err: assert.AnError}}
+	consumeJob(t, client, job, handler, func() bool {
+		return len(handler.getDead()) == 1
+	}, "exhausted job should be dead-lettered")
+
+	jobs, _, err := queue.NewDeadLetterQueue(client).List(ctx, nil, "", 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.NotNil(t, jobs[0].Job)
+	assert.Equal(t, job.RecipientID, jobs[0].Job.RecipientID)
+	assert.Equal(t, queue.MaxJobRetries, jobs[0].Retries)
+	assert.Equal(t, assert.AnError.Error(), jobs[0].Error)
+}
+
+func TestConsume_PermanentErrorIsDeadLettered(t *testing.T) {
+	client := skipIfNoRedis(t)
+	cleanStream(t, client)
+	cleanRetryState(t, client)
+
+	handler := &deadLetterHandler{mockHandler: mockHandler{err: queue.Permanent(assert.AnError)}}
+	consumeJob(t, client, makeRecipientJob(), handler, func() bool {
+		return len(handler.getDead()) == 1
+	}, "permanently failed job should be dead-lettered without retries")
+
+	n, err := client.ZCard(context.Background(), queue.DelayedSetName).Result()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestDeadLetterQueue_RequeueAndDelete(t *testing.T) {
+	client := skipIfNoRedis(t)
+	cleanStream(t, client)
+	cleanRetryState(t, client)
+
+	job := makeRecipientJob()
+	job.Retries = queue.MaxJobRetries
+	handler := &deadLetterHandler{mockHandler: mockHandler{err: assert.AnError}}
+	consumeJob(t, client, job, handler, func() bool {
+		return len(handler.getDead()) == 1
+	}, "exhausted job should be dead-lettered")
+
+	ctx := testutil.TestContext(t)
+	dlq := queue.NewDeadLetterQueue(client)
+	jobs, _, err := dlq.List(ctx, nil, "", 10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+
+	dl, err := dlq.Get(ctx, jobs[0].ID)
+	require.NoError(t, err)
+	require.NoError(t, dlq.Requeue(ctx, dl))
+	assert.ErrorIs(t, dlq.Requeue(ctx, dl), queue.ErrDeadLetterNotFound)
+	assert.ErrorIs(t, dlq.Delete(ctx, dl.ID), queue.ErrDeadLetterNotFound)
+
+	msgs, err := client.XRange(ctx, queue.StreamName, "-", "+").Result()
+	require.NoError(t, err)
+	require.NotEmpty(t, msgs)
+	var requeued queue.RecipientJob
+	require.NoError(t, json.Unmarshal([]byte(msgs[len(msgs)-1].Values["payload"].(string)), &requeued))
+	assert.Equal(t, job.RecipientID, requeued.RecipientID)
+	assert.Zero(t, requeued.Retries)
+}
+
+func TestRetryDelay(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, queue.RetryBaseDelay, queue.RetryDelay(0))
+	assert.Equal(t, 4*queue.RetryBaseDelay, queue.RetryDelay(2))
+	assert.Equal(t, queue.RetryMaxDelay, queue.RetryDelay(20))
+}
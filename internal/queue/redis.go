@@ -141,11 +141,7 @@ func NewRedisConsumer(client *redis.Client, log logf.Logger) (*RedisConsumer, er
 func (c *RedisConsumer) Consume(ctx context.Context, handler JobHandler) error {
 	c.log.Info("Starting to consume jobs", "consumer_id", c.consumerID)
 
-	// First, try to claim any stale pending messages from crashed workers
-	if err := c.claimPendingMessages(ctx, handler); err != nil {
-		c.log.Warn("Failed to claim pending messages", "error", err)
-	}
-
+	lastClaim := time.Time{}
 	for {
 		select {
 		case <-ctx.Done():
@@ -154,6 +150,14 @@ func (c *RedisConsumer) Consume(ctx context.Context, handler JobHandler) error {
 		default:
 		}
 
+		// Claim stale pending messages from crashed workers, at startup and periodically
+		if time.Since(lastClaim) >= ClaimMinIdleTime {
+			if err := c.claimPendingMessages(ctx, handler); err != nil {
+				c.log.Warn("Failed to claim pending messages", "error", err)
+			}
+			lastClaim = time.Now()
+		}
+
 		// Read new messages from the stream
 		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
 			Group:    ConsumerGroup,
@@ -178,16 +182,7 @@ func (c *RedisConsumer) Consume(ctx context.Context, handler JobHandler) error {
 
 		for _, stream := range streams {
 			for _, msg := range stream.Messages {
-				if err := c.processMessage(ctx, msg, handler); err != nil {
-					c.log.Error("Failed to process message", "error", err, "message_id", msg.ID)
-					// Don't ACK failed messages - they'll be reclaimed later
-					continue
-				}
-
-				// Acknowledge the message
-				if err := c.client.XAck(ctx, StreamName, ConsumerGroup, msg.ID).Err(); err != nil {
-					c.log.Error("Failed to ACK message", "error", err, "message_id", msg.ID)
-				}
+				c.handleMessage(ctx, msg, handler)
 			}
 		}
 	}
@@ -232,44 +227,113 @@ func (c *RedisConsumer) claimPendingMessages(ctx context.Context, handler JobHan
 		}
 
 		for _, msg := range messages {
-			if err := c.processMessage(ctx, msg, handler); err != nil {
-				c.log.Error("Failed to process claimed message", "error", err, "message_id", msg.ID)
+			// A message that keeps getting stuck is likely crashing the worker
+			if p.RetryCount >= MaxDeliveries {
+				job, _ := decodeJob(msg)
+				if c.deadLetter(ctx, msg, job, fmt.Errorf("job was delivered %d times without completing", p.RetryCount), handler) {
+					c.ack(ctx, msg)
+				}
 				continue
 			}
+			c.handleMessage(ctx, msg, handler)
+		}
+	}
+
+	return nil
+}
 
-			// Acknowledge the message
-			if err := c.client.XAck(ctx, StreamName, ConsumerGroup, msg.ID).Err(); err != nil {
-				c.log.Error("Failed to ACK claimed message", "error", err, "message_id", msg.ID)
+// handleMessage processes a single message from the stream. Failed jobs are
+// retried with backoff or dead-lettered, then the message is acknowledged.
+func (c *RedisConsumer) handleMessage(ctx context.Context, msg redis.XMessage, handler JobHandler) {
+	job, err := decodeJob(msg)
+	if err == nil {
+		c.log.Debug("Processing recipient job", "campaign_id", job.CampaignID, "recipient_id", job.RecipientID, "message_id", msg.ID)
+		err = handler.HandleRecipientJob(ctx, job)
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down - leave the message pending so it is reclaimed
+			return
+		}
+		c.log.Error("Failed to process message", "error", err, "message_id", msg.ID)
+
+		if job != nil && !IsPermanent(err) && job.Retries < MaxJobRetries {
+			if !c.retry(ctx, msg, job) {
+				return
 			}
+		} else if !c.deadLetter(ctx, msg, job, err, handler) {
+			return
 		}
 	}
 
-	return nil
+	c.ack(ctx, msg)
 }
 
-// processMessage processes a single message from the stream
-func (c *RedisConsumer) processMessage(ctx context.Context, msg redis.XMessage, handler JobHandler) error {
+// retry schedules a failed job to run again after a backoff delay. It returns
+// false if the job could not be scheduled and must stay pending.
+func (c *RedisConsumer) retry(ctx context.Context, msg redis.XMessage, job *RecipientJob) bool {
+	delay := RetryDelay(job.Retries)
+	job.Retries++
+	if err := scheduleRecipient(ctx, c.client, job, time.Now().Add(delay)); err != nil {
+		c.log.Error("Failed to schedule job retry", "error", err, "message_id", msg.ID)
+		return false
+	}
+	c.log.Info("Job scheduled for retry", "message_id", msg.ID, "recipient_id", job.RecipientID, "retry", job.Retries, "delay", delay)
+	return true
+}
+
+// deadLetter moves a job to the dead-letter stream. It returns false if the
+// job could not be moved and must stay pending.
+func (c *RedisConsumer) deadLetter(ctx context.Context, msg redis.XMessage, job *RecipientJob, cause error, handler JobHandler) bool {
+	retries := 0
+	if job != nil {
+		retries = job.Retries
+	}
+	if err := addDeadLetter(ctx, c.client, msg, retries, cause); err != nil {
+		c.log.Error("Failed to dead-letter message", "error", err, "message_id", msg.ID)
+		return false
+	}
+	c.log.Warn("Job dead-lettered", "error", cause, "message_id", msg.ID, "retries", retries)
+
+	if job != nil {
+		if dh, ok := handler.(DeadLetterHandler); ok {
+			dh.HandleDeadRecipientJob(ctx, job, cause.Error())
+		}
+	}
+	return true
+}
+
+// ack acknowledges a message so that it is not delivered again
+func (c *RedisConsumer) ack(ctx context.Context, msg redis.XMessage) {
+	if err := c.client.XAck(ctx, StreamName, ConsumerGroup, msg.ID).Err(); err != nil {
+		c.log.Error("Failed to ACK message", "error", err, "message_id", msg.ID)
+	}
+}
+
+// decodeJob decodes the recipient job carried by a stream message. Malformed
+// messages return a permanent error.
+func decodeJob(msg redis.XMessage) (*RecipientJob, error) {
 	jobType, ok := msg.Values["type"].(string)
 	if !ok {
-		return fmt.Errorf("invalid message: missing type")
+		return nil, Permanent(fmt.Errorf("invalid message: missing type"))
 	}
 
 	payload, ok := msg.Values["payload"].(string)
 	if !ok {
-		return fmt.Errorf("invalid message: missing payload")
+		return nil, Permanent(fmt.Errorf("invalid message: missing payload"))
 	}
 
 	switch JobType(jobType) {
 	case JobTypeRecipient:
 		var job RecipientJob
 		if err := json.Unmarshal([]byte(payload), &job); err != nil {
-			return fmt.Errorf("failed to unmarshal recipient job: %w", err)
+			return nil, Permanent(fmt.Errorf("failed to unmarshal recipient job: %w", err))
 		}
-		c.log.Debug("Processing recipient job", "campaign_id", job.CampaignID, "recipient_id", job.RecipientID, "message_id", msg.ID)
-		return handler.HandleRecipientJob(ctx, &job)
+		return &job, nil
 
 	default:
-		return fmt.Errorf("unknown job type: %s", jobType)
+		return nil, Permanent(fmt.Errorf("unknown job type: %s", jobType))
 	}
 }
 
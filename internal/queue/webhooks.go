@@ -0,0 +1,286 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zerodha/logf"
+)
+
+const (
+	// WebhookStreamName is the Redis stream for raw inbound Meta webhook payloads
+	WebhookStreamName = "whatomate:webhooks"
+
+	// WebhookConsumerGroup is the consumer group name for webhook workers
+	WebhookConsumerGroup = "webhook-workers"
+
+	// WebhookStreamMaxLen caps the webhook stream; older entries are trimmed approximately
+	WebhookStreamMaxLen = 100000
+
+	// WebhookClaimMinIdleTime is how long a webhook payload may stay unacknowledged
+	// before another worker claims it. Webhook processing is short, so this is
+	// much lower than ClaimMinIdleTime.
+	WebhookClaimMinIdleTime = time.Minute
+)
+
+// WebhookHandlerFunc processes a raw webhook payload. Returning an error leaves
+// the payload pending so that it is retried.
+type WebhookHandlerFunc func(ctx context.Context, payload []byte) error
+
+// WebhookKeyFunc returns the ordering key of a raw webhook payload. Payloads
+// with the same key are processed one at a time in stream order; payloads with
+// an empty key may be processed in any order.
+type WebhookKeyFunc func(payload []byte) string
+
+// EnqueueWebhook adds a raw webhook payload to the webhook stream
+func (q *RedisQueue) EnqueueWebhook(ctx context.Context, payload []byte) error {
+	_, err := q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: WebhookStreamName,
+		MaxLen: WebhookStreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":    string(JobTypeWebhook),
+			"payload": string(payload),
+		},
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to enqueue webhook: %w", err)
+	}
+	return nil
+}
+
+// WebhookConsumer consumes raw webhook payloads from the webhook stream
+type WebhookConsumer struct {
+	client      *redis.Client
+	log         logf.Logger
+	consumerID  string
+	concurrency int
+
+	mu       sync.Mutex
+	inFlight map[string]bool // stream IDs read but not yet handled
+}
+
+// NewWebhookConsumer creates a new webhook consumer that processes up to
+// concurrency payloads at a time. name distinguishes consumers running in the
+// same process.
+func NewWebhookConsumer(client *redis.Client, log logf.Logger, name string, concurrency int) (*WebhookConsumer, error) {
+	hostname, _ := os.Hostname()
+	consumerID := fmt.Sprintf("webhook-%s-%d-%s", hostname, os.Getpid(), name)
+
+	ctx := context.Background()
+	err := client.XGroupCreateMkStream(ctx, WebhookStreamName, WebhookConsumerGroup, "0").Err()
+	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
+		return nil, fmt.Errorf("failed to create webhook consumer group: %w", err)
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &WebhookConsumer{
+		client:      client,
+		log:         log,
+		consumerID:  consumerID,
+		concurrency: concurrency,
+		inFlight:    map[string]bool{},
+	}, nil
+}
+
+// Consume processes webhook payloads until ctx is cancelled, then waits for the
+// payloads being processed. Payloads run concurrently except those sharing a
+// key, which run in order; key may be nil. Payloads left pending by a failed
+// handler or a crashed worker are reclaimed periodically.
+func (c *WebhookConsumer) Consume(ctx context.Context, handler WebhookHandlerFunc, key WebhookKeyFunc) error {
+	c.log.Info("Starting to consume webhooks", "consumer_id", c.consumerID, "concurrency", c.concurrency)
+
+	runner := newKeyedRunner(c.concurrency)
+	defer runner.Wait()
+	dispatch := func(msg redis.XMessage) {
+		c.mu.Lock()
+		if c.inFlight[msg.ID] {
+			// Reclaimed while still waiting behind an earlier payload
+			c.mu.Unlock()
+			return
+		}
+		c.inFlight[msg.ID] = true
+		c.mu.Unlock()
+
+		payloadKey := ""
+		if payload, ok := msg.Values["payload"].(string); ok && key != nil {
+			payloadKey = key([]byte(payload))
+		}
+		runner.Go(payloadKey, func() {
+			c.handleMessage(ctx, msg, handler)
+			c.mu.Lock()
+			delete(c.inFlight, msg.ID)
+			c.mu.Unlock()
+		})
+	}
+
+	lastClaim := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		if time.Since(lastClaim) >= WebhookClaimMinIdleTime {
+			if err := c.claimPendingWebhooks(ctx, dispatch); err != nil {
+				c.log.Warn("Failed to claim pending webhooks", "error", err)
+			}
+			lastClaim = time.Now()
+		}
+
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    WebhookConsumerGroup,
+			Consumer: c.consumerID,
+			Streams:  []string{WebhookStreamName, ">"},
+			Count:    10,
+			Block:    BlockTimeout,
+		}).Result()
+		if err != nil {
+			if err == redis.Nil {
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.log.Error("Failed to read from webhook stream", "error", err)
+			time.Sleep(time.Second) // Back off on error
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				dispatch(msg)
+			}
+		}
+	}
+}
+
+// claimPendingWebhooks claims webhook payloads that have been pending too long
+func (c *WebhookConsumer) claimPendingWebhooks(ctx context.Context, dispatch func(redis.XMessage)) error {
+	pending, err := c.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: WebhookStreamName,
+		Group:  WebhookConsumerGroup,
+		Start:  "-",
+		End:    "+",
+		Count:  100,
+		Idle:   WebhookClaimMinIdleTime,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get pending webhooks: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	c.log.Info("Found stale pending webhooks to claim", "count", len(pending))
+
+	ids := make([]string, len(pending))
+	for i, p := range pending {
+		ids[i] = p.ID
+	}
+	messages, err := c.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   WebhookStreamName,
+		Group:    WebhookConsumerGroup,
+		Consumer: c.consumerID,
+		MinIdle:  WebhookClaimMinIdleTime,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("failed to claim pending webhooks: %w", err)
+	}
+
+	for _, msg := range messages {
+		dispatch(msg)
+	}
+	return nil
+}
+
+// handleMessage runs handler on a stream message and acknowledges it on success
+func (c *WebhookConsumer) handleMessage(ctx context.Context, msg redis.XMessage, handler WebhookHandlerFunc) {
+	payload, ok := msg.Values["payload"].(string)
+	if !ok {
+		c.log.Error("Invalid webhook message: missing payload", "message_id", msg.ID)
+	} else if err := handler(ctx, []byte(payload)); err != nil {
+		c.log.Error("Failed to process webhook", "error", err, "message_id", msg.ID)
+		// Don't ACK failed webhooks - they'll be reclaimed later
+		return
+	}
+
+	if err := c.client.XAck(ctx, WebhookStreamName, WebhookConsumerGroup, msg.ID).Err(); err != nil {
+		c.log.Error("Failed to ACK webhook", "error", err, "message_id", msg.ID)
+	}
+}
+
+// Close closes the consumer connection
+func (c *WebhookConsumer) Close() error {
+	return nil // Redis client is managed externally
+}
+
+// keyedRunner runs functions concurrently up to a limit. Functions with the
+// same non-empty key run one at a time in the order they were added.
+type keyedRunner struct {
+	sem   chan struct{}
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	lanes map[string][]func() // functions waiting behind the running one, by key
+}
+
+func newKeyedRunner(limit int) *keyedRunner {
+	return &keyedRunner{sem: make(chan struct{}, limit), lanes: map[string][]func(){}}
+}
+
+// Go runs fn in the background, or queues it behind the running function with
+// the same key. It blocks while the limit of functions are running.
+func (r *keyedRunner) Go(key string, fn func()) {
+	if key != "" {
+		r.mu.Lock()
+		if waiting, running := r.lanes[key]; running {
+			r.lanes[key] = append(waiting, fn)
+			r.mu.Unlock()
+			return
+		}
+		r.lanes[key] = nil
+		r.mu.Unlock()
+	}
+
+	r.sem <- struct{}{}
+	r.wg.Add(1)
+	go func() {
+		defer func() {
+			<-r.sem
+			r.wg.Done()
+		}()
+		for fn != nil {
+			fn()
+			fn = r.next(key)
+		}
+	}()
+}
+
+// next returns the function waiting behind key, or nil when none is left
+func (r *keyedRunner) next(key string) func() {
+	if key == "" {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	waiting := r.lanes[key]
+	if len(waiting) == 0 {
+		delete(r.lanes, key)
+		return nil
+	}
+	r.lanes[key] = waiting[1:]
+	return waiting[0]
+}
+
+// Wait waits for all functions, including queued ones, to finish
+func (r *keyedRunner) Wait() {
+	r.wg.Wait()
+}
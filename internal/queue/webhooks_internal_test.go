@@ -0,0 +1,62 @@
+package queue
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedRunner(t *testing.T) {
+	r := newKeyedRunner(2)
+
+	var mu sync.Mutex
+	var order []string
+	record := func(s string) func() {
+		return func() {
+			mu.Lock()
+			order = append(order, s)
+			mu.Unlock()
+		}
+	}
+
+	// A slow function only holds up functions with its key
+	release := make(chan struct{})
+	r.Go("alice", func() { <-release })
+	r.Go("alice", record("alice 2"))
+	r.Go("alice", record("alice 3"))
+	r.Go("bob", record("bob 1"))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(order) == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"bob 1"}, order)
+
+	close(release)
+	r.Wait()
+	assert.Equal(t, []string{"bob 1", "alice 2", "alice 3"}, order)
+	assert.Empty(t, r.lanes)
+}
+
+func TestKeyedRunner_Limit(t *testing.T) {
+	r := newKeyedRunner(2)
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	for i := 0; i < 10; i++ {
+		r.Go("", func() {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+		})
+	}
+	r.Wait()
+	assert.Equal(t, 2, peak)
+}
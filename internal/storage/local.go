@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local stores media on the local filesystem
+type Local struct {
+	basePath string
+}
+
+// NewLocal creates a local filesystem storage rooted at basePath
+func NewLocal(basePath string) *Local {
+	if basePath == "" {
+		basePath = "./media"
+	}
+	return &Local{basePath: basePath}
+}
+
+// resolve maps a key to an absolute path, rejecting directory traversal
+func (l *Local) resolve(key string) (string, error) {
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	baseDir, err := filepath.Abs(l.basePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve storage path: %w", err)
+	}
+	fullPath, err := filepath.Abs(filepath.Join(baseDir, filepath.Clean(filepath.FromSlash(key))))
+	if err != nil || !strings.HasPrefix(fullPath, baseDir+string(os.PathSeparator)) {
+		return "", ErrInvalidKey
+	}
+	return fullPath, nil
+}
+
+// Put writes data to the file for key, creating parent directories as needed
+func (l *Local) Put(_ context.Context, key string, data []byte, _ string) error {
+	fullPath, err := l.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create media directory: %w", err)
+	}
+	if err := os.WriteFile(fullPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to save media file: %w", err)
+	}
+	return nil
+}
+
+// Get opens the file for key. Symlinks are rejected.
+func (l *Local) Get(_ context.Context, key string) (io.ReadCloser, int64, error) {
+	fullPath, err := l.resolve(key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, 0, ErrNotFound
+		}
+		return nil, 0, err
+	}
+	if info.Mode()&os.ModeSymlink != 0 || info.IsDir() {
+		return nil, 0, ErrInvalidKey
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+// Delete removes the file for key
+func (l *Local) Delete(_ context.Context, key string) error {
+	fullPath, err := l.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SignedURL is not supported for local storage; media is served through the API
+func (l *Local) SignedURL(_ context.Context, _ string, _ time.Duration) (string, error) {
+	return "", ErrSignedURLNotSupported
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal_PutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocal(t.TempDir())
+
+	require.NoError(t, s.Put(ctx, "images/photo.jpg", []byte("jpeg-bytes"), "image/jpeg"))
+
+	body, size, err := s.Get(ctx, "images/photo.jpg")
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, "jpeg-bytes", string(data))
+	assert.Equal(t, int64(len("jpeg-bytes")), size)
+
+	require.NoError(t, s.Delete(ctx, "images/photo.jpg"))
+	_, _, err = s.Get(ctx, "images/photo.jpg")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting again is a no-op
+	assert.NoError(t, s.Delete(ctx, "images/photo.jpg"))
+}
+
+func TestLocal_RejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocal(t.TempDir())
+
+	for _, key := range []string{"", "../secret.txt", "images/../../secret.txt", "/"} {
+		_, _, err := s.Get(ctx, key)
+		assert.ErrorIs(t, err, ErrInvalidKey, "key %q", key)
+		assert.ErrorIs(t, s.Put(ctx, key, []byte("x"), ""), ErrInvalidKey, "key %q", key)
+	}
+}
+
+func TestLocal_RejectsSymlink(t *testing.T) {
+	base := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(base, "link.txt")))
+
+	_, _, err := NewLocal(base).Get(context.Background(), "link.txt")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestLocal_SignedURLNotSupported(t *testing.T) {
+	_, err := NewLocal(t.TempDir()).SignedURL(context.Background(), "images/photo.jpg", time.Minute)
+	assert.ErrorIs(t, err, ErrSignedURLNotSupported)
+}
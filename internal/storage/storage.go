@@ -0,0 +1,59 @@
+// Package storage provides pluggable backends for media files.
+//
+// Keys are slash-separated relative paths such as "images/<uuid>.jpg". The
+// same key is stored in the database regardless of backend, so switching from
+// local disk to S3 only requires copying the files across.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/shridarpatil/whatomate/internal/config"
+)
+
+var (
+	// ErrNotFound is returned when the requested object does not exist
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrInvalidKey is returned for keys that escape the storage root
+	ErrInvalidKey = errors.New("storage: invalid key")
+	// ErrSignedURLNotSupported is returned by backends that cannot issue signed URLs
+	ErrSignedURLNotSupported = errors.New("storage: signed URLs not supported")
+)
+
+// Storage is implemented by every media storage backend
+type Storage interface {
+	// Put stores data under key, overwriting any existing object
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get opens the object for reading. The caller must close the reader.
+	// The returned size is -1 if unknown.
+	Get(ctx context.Context, key string) (io.ReadCloser, int64, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a time-limited URL that grants read access to the object
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// New creates the storage backend selected by cfg.Type
+func New(cfg *config.StorageConfig) (Storage, error) {
+	switch cfg.Type {
+	case "", "local":
+		return NewLocal(cfg.LocalPath), nil
+	case "s3":
+		return NewS3(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3Key,
+			SecretKey: cfg.S3Secret,
+			PathStyle: cfg.S3PathStyle,
+			Client:    &http.Client{Timeout: 60 * time.Second},
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", cfg.Type)
+	}
+}
@@ -0,0 +1,96 @@
+package templateutil
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/shridarpatil/whatomate/internal/language"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"gorm.io/gorm"
+)
+
+// LanguageVariant returns the approved template with the same name as tmpl in
+// the language closest to lang. Templates are submitted to Meta once per
+// language under a shared name. Returns tmpl when lang is empty, already
+// matches, or has no approved variant that can be sent in its place.
+func LanguageVariant(db *gorm.DB, tmpl *models.Template, lang string) *models.Template {
+	if tmpl == nil || keepsLanguage(tmpl.Language, lang) {
+		return tmpl
+	}
+	return PickLanguageVariant(tmpl, LanguageVariants(db, tmpl), lang)
+}
+
+// LanguageVariants returns the approved templates with the same name as tmpl
+// that can be sent in its place: they take the same body parameters and have
+// the same kind of header. Senders of one template to many contacts load the
+// variants once and pick from them with PickLanguageVariant.
+func LanguageVariants(db *gorm.DB, tmpl *models.Template) []models.Template {
+	if tmpl == nil {
+		return nil
+	}
+	var templates []models.Template
+	if err := db.Where("organization_id = ? AND whats_app_account = ? AND name = ? AND status = ?",
+		tmpl.OrganizationID, tmpl.WhatsAppAccount, tmpl.Name, string(models.TemplateStatusApproved)).
+		Find(&templates).Error; err != nil {
+		return nil
+	}
+
+	variants := templates[:0]
+	for _, t := range templates {
+		if compatibleVariant(tmpl, &t) {
+			variants = append(variants, t)
+		}
+	}
+	return variants
+}
+
+// PickLanguageVariant returns the variant in the language closest to lang, or
+// tmpl when it already suits lang or no variant matches.
+func PickLanguageVariant(tmpl *models.Template, variants []models.Template, lang string) *models.Template {
+	if tmpl == nil || keepsLanguage(tmpl.Language, lang) || len(variants) == 0 {
+		return tmpl
+	}
+
+	languages := make([]string, len(variants))
+	for i, v := range variants {
+		languages[i] = v.Language
+	}
+	i := language.Match(lang, languages)
+	if i < 0 {
+		return tmpl
+	}
+	return &variants[i]
+}
+
+// keepsLanguage reports whether a template in tmplLang already suits a contact
+// speaking lang, so no variant needs to be looked up. The original template is
+// kept when the contact's language is unknown, when both codes are the same,
+// and when the contact's language has no region ("es") and the template is a
+// regional form of it ("es_MX"). A contact in "es_AR" may still get an "es_AR"
+// variant of an "es_MX" template.
+func keepsLanguage(tmplLang, lang string) bool {
+	if strings.TrimSpace(lang) == "" {
+		return true
+	}
+	if language.Base(lang) != language.Base(tmplLang) {
+		return false
+	}
+	sameCode := strings.EqualFold(strings.ReplaceAll(tmplLang, "-", "_"), strings.ReplaceAll(lang, "-", "_"))
+	noRegion := language.Base(lang) == strings.ToLower(strings.TrimSpace(lang))
+	return sameCode || noRegion
+}
+
+// compatibleVariant reports whether variant can be sent with the parameters
+// and header media meant for tmpl
+func compatibleVariant(tmpl, variant *models.Template) bool {
+	if !strings.EqualFold(tmpl.HeaderType, variant.HeaderType) {
+		return false
+	}
+	if !slices.Equal(ExtParamNames(tmpl.BodyContent), ExtParamNames(variant.BodyContent)) {
+		return false
+	}
+	if strings.EqualFold(tmpl.HeaderType, "TEXT") {
+		return slices.Equal(ExtParamNames(tmpl.HeaderContent), ExtParamNames(variant.HeaderContent))
+	}
+	return true
+}
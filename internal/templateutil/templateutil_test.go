@@ -3,6 +3,7 @@ package templateutil
 import (
 	"testing"
 
+	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -150,3 +151,40 @@ func TestReplaceWithJSONBParams_Empty(t *testing.T) {
 	result := ReplaceWithJSONBParams("Hello {{name}}", "Hello {{name}}", map[string]interface{}{})
 	assert.Equal(t, "Hello {{name}}", result)
 }
+
+func TestKeepsLanguage(t *testing.T) {
+	assert.True(t, keepsLanguage("en_US", ""), "unknown contact language")
+	assert.True(t, keepsLanguage("es_MX", "es_MX"))
+	assert.True(t, keepsLanguage("es_MX", "es-mx"), "same code in another form")
+	assert.True(t, keepsLanguage("es_MX", "es"), "contact language without region")
+	assert.False(t, keepsLanguage("es_MX", "es_AR"), "another region may have its own variant")
+	assert.False(t, keepsLanguage("en_US", "hi"))
+}
+
+func TestPickLanguageVariant(t *testing.T) {
+	tmpl := &models.Template{Name: "order_update", Language: "en_US", BodyContent: "Hi {{1}}, order {{2}} shipped"}
+	variants := []models.Template{
+		*tmpl,
+		{Name: "order_update", Language: "hi", BodyContent: "नमस्ते {{1}}, ऑर्डर {{2}} भेजा गया"},
+		{Name: "order_update", Language: "es_MX", BodyContent: "Hola {{1}}, pedido {{2}} enviado"},
+	}
+
+	assert.Equal(t, "hi", PickLanguageVariant(tmpl, variants, "hi").Language)
+	assert.Equal(t, "es_MX", PickLanguageVariant(tmpl, variants, "es_AR").Language, "same base language")
+	assert.Same(t, tmpl, PickLanguageVariant(tmpl, variants, "en"))
+	assert.Same(t, tmpl, PickLanguageVariant(tmpl, variants, "fr"), "no variant")
+	assert.Same(t, tmpl, PickLanguageVariant(tmpl, nil, "hi"))
+}
+
+func TestCompatibleVariant(t *testing.T) {
+	tmpl := &models.Template{HeaderType: "IMAGE", BodyContent: "Hi {{name}}, order {{order_id}} shipped"}
+
+	assert.True(t, compatibleVariant(tmpl, &models.Template{HeaderType: "image", BodyContent: "Hola {{name}}, pedido {{order_id}} enviado"}))
+	assert.False(t, compatibleVariant(tmpl, &models.Template{HeaderType: "VIDEO", BodyContent: "Hola {{name}}, pedido {{order_id}} enviado"}), "other header media")
+	assert.False(t, compatibleVariant(tmpl, &models.Template{HeaderType: "IMAGE", BodyContent: "Hola {{name}}"}), "missing parameter")
+	assert.False(t, compatibleVariant(tmpl, &models.Template{HeaderType: "IMAGE", BodyContent: "Pedido {{order_id}} de {{name}}"}), "parameters reordered")
+
+	text := &models.Template{HeaderType: "TEXT", HeaderContent: "Order {{1}}", BodyContent: "Shipped"}
+	assert.True(t, compatibleVariant(text, &models.Template{HeaderType: "TEXT", HeaderContent: "Pedido {{1}}", BodyContent: "Enviado"}))
+	assert.False(t, compatibleVariant(text, &models.Template{HeaderType: "TEXT", HeaderContent: "Pedido", BodyContent: "Enviado"}))
+}
@@ -22,9 +22,18 @@ type Hub struct {
 	// unregister channel for disconnecting clients
 	unregister chan *Client
 
-	// mutex for thread-safe access to clients map
+	// mutex for thread-safe access to clients map and relay
 	mu sync.RWMutex
 
+	// relay forwards broadcasts to other instances (nil when running standalone)
+	relay Relay
+
+	// outbound channel for broadcasts waiting to be relayed
+	outbound chan BroadcastMessage
+
+	// instanceID identifies this hub in relayed broadcasts
+	instanceID string
+
 	// logger
 	log logf.Logger
 }
@@ -36,6 +45,8 @@ func NewHub(log logf.Logger) *Hub {
 		broadcast:  make(chan BroadcastMessage, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		outbound:   make(chan BroadcastMessage, 256),
+		instanceID: uuid.New().String(),
 		log:        log,
 	}
 }
@@ -168,13 +179,30 @@ func (h *Hub) broadcastMessage(msg BroadcastMessage) {
 	}
 }
 
-// Broadcast sends a message to the broadcast channel
+// Broadcast sends a message to the broadcast channel and, when a relay is
+// enabled, to the other API instances
 func (h *Hub) Broadcast(msg BroadcastMessage) {
 	select {
 	case h.broadcast <- msg:
 	default:
 		h.log.Warn("Broadcast channel full, dropping message")
 	}
+
+	if msg.Local {
+		return
+	}
+	h.mu.RLock()
+	relayed := h.relay != nil
+	h.mu.RUnlock()
+	if !relayed {
+		return
+	}
+
+	select {
+	case h.outbound <- msg:
+	default:
+		h.log.Warn("Relay channel full, dropping message for other instances")
+	}
 }
 
 // BroadcastToOrg sends a message to all clients in an organization
@@ -33,6 +33,13 @@ const (
 	TypeConversationNoteCreated = "conversation_note_created"
 	TypeConversationNoteUpdated = "conversation_note_updated"
 	TypeConversationNoteDeleted = "conversation_note_deleted"
+
+	// Conversation types
+	TypeConversationStatusChanged = "conversation_status_changed"
+
+	// Notification types (sent to the recipient only)
+	TypeNotificationCreated  = "notification_created"
+	TypeNotificationsUpdated = "notifications_updated"
 )
 
 // BroadcastMessage represents a message to be broadcast to clients
@@ -40,6 +47,7 @@ type BroadcastMessage struct {
 	OrgID     uuid.UUID
 	UserID    uuid.UUID // Optional: only send to specific user
 	ContactID uuid.UUID // Optional: only send to users viewing this contact
+	Local     bool      // Optional: do not relay to other instances
 	Message   WSMessage
 }
 
@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// Relay carries broadcasts between API instances so clients connected to
+// any instance receive them. queue.Publisher implements it over Redis pub/sub.
+type Relay interface {
+	PublishWSBroadcast(ctx context.Context, payload []byte) error
+}
+
+// relayEnvelope is the wire format of a relayed broadcast
+type relayEnvelope struct {
+	Origin    string          `json:"origin"`
+	OrgID     uuid.UUID       `json:"org_id"`
+	UserID    uuid.UUID       `json:"user_id"`
+	ContactID uuid.UUID       `json:"contact_id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// EnableRelay forwards every non-local broadcast to other instances through
+// relay until ctx is cancelled. Broadcasts received from other instances must
+// be passed to HandleRelayed.
+func (h *Hub) EnableRelay(ctx context.Context, relay Relay) {
+	h.mu.Lock()
+	h.relay = relay
+	h.mu.Unlock()
+
+	go h.runRelay(ctx, relay)
+}
+
+// runRelay publishes queued outbound broadcasts
+func (h *Hub) runRelay(ctx context.Context, relay Relay) {
+	defer func() {
+		h.mu.Lock()
+		h.relay = nil
+		h.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-h.outbound:
+			payload, err := json.Marshal(msg.Message.Payload)
+			if err != nil {
+				h.log.Error("Failed to marshal relayed broadcast payload", "error", err, "type", msg.Message.Type)
+				continue
+			}
+			data, err := json.Marshal(relayEnvelope{
+				Origin:    h.instanceID,
+				OrgID:     msg.OrgID,
+				UserID:    msg.UserID,
+				ContactID: msg.ContactID,
+				Type:      msg.Message.Type,
+				Payload:   payload,
+			})
+			if err != nil {
+				h.log.Error("Failed to marshal relayed broadcast", "error", err)
+				continue
+			}
+			// Local clients already received the message; a failed publish
+			// only affects clients on other instances.
+			_ = relay.PublishWSBroadcast(ctx, data)
+		}
+	}
+}
+
+// HandleRelayed delivers a broadcast published by another instance to the
+// clients connected to this one. Broadcasts that originated here are ignored.
+func (h *Hub) HandleRelayed(data []byte) {
+	var env relayEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		h.log.Error("Failed to unmarshal relayed broadcast", "error", err)
+		return
+	}
+	if env.Origin == h.instanceID || env.OrgID == uuid.Nil {
+		return
+	}
+
+	var payload any
+	if len(env.Payload) > 0 {
+		payload = env.Payload
+	}
+
+	h.Broadcast(BroadcastMessage{
+		OrgID:     env.OrgID,
+		UserID:    env.UserID,
+		ContactID: env.ContactID,
+		Local:     true,
+		Message:   WSMessage{Type: env.Type, Payload: payload},
+	})
+}
@@ -0,0 +1,147 @@
+package websocket_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRelay is an in-memory stand-in for Redis pub/sub that delivers every
+// published payload to all connected hubs, including the publisher.
+type fakeRelay struct {
+	mu        sync.Mutex
+	hubs      []*websocket.Hub
+	published int
+}
+
+func (f *fakeRelay) PublishWSBroadcast(_ context.Context, payload []byte) error {
+	f.mu.Lock()
+	hubs := append([]*websocket.Hub(nil), f.hubs...)
+	f.published++
+	f.mu.Unlock()
+
+	for _, hub := range hubs {
+		hub.HandleRelayed(payload)
+	}
+	return nil
+}
+
+func (f *fakeRelay) publishCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.published
+}
+
+// newRelayedHubs creates n hubs joined by a fake relay, as if running on n API instances.
+func newRelayedHubs(t *testing.T, n int) ([]*websocket.Hub, *fakeRelay) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	relay := &fakeRelay{}
+	hubs := make([]*websocket.Hub, n)
+	for i := range hubs {
+		hubs[i] = newTestHub(t)
+		hubs[i].EnableRelay(ctx, relay)
+	}
+	relay.hubs = hubs
+	return hubs, relay
+}
+
+func TestHub_Relay_BroadcastToOrgReachesOtherInstances(t *testing.T) {
+	hubs, _ := newRelayedHubs(t, 2)
+	orgID := uuid.New()
+
+	local := newTestClient(hubs[0], uuid.New(), orgID)
+	remote := newTestClient(hubs[1], uuid.New(), orgID)
+	otherOrg := newTestClient(hubs[1], uuid.New(), uuid.New())
+	hubs[0].Register(local)
+	hubs[1].Register(remote)
+	hubs[1].Register(otherOrg)
+	waitForClientCount(t, hubs[0], 1)
+	waitForClientCount(t, hubs[1], 2)
+
+	hubs[0].BroadcastToOrg(orgID, websocket.WSMessage{
+		Type:    websocket.TypeNewMessage,
+		Payload: map[string]any{"content": "hello"},
+	})
+
+	assertReceivesMessage(t, local, websocket.TypeNewMessage)
+
+	select {
+	case data := <-clientSendChan(remote):
+		var msg struct {
+			Type    string         `json:"type"`
+			Payload map[string]any `json:"payload"`
+		}
+		require.NoError(t, json.Unmarshal(data, &msg))
+		assert.Equal(t, websocket.TypeNewMessage, msg.Type)
+		assert.Equal(t, "hello", msg.Payload["content"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for relayed message")
+	}
+
+	// The origin hub ignores its own relayed copy, so the local client gets it once
+	assertNoMessage(t, local)
+	assertNoMessage(t, otherOrg)
+}
+
+func TestHub_Relay_BroadcastToUserPreservesTarget(t *testing.T) {
+	hubs, _ := newRelayedHubs(t, 2)
+	orgID := uuid.New()
+	targetID := uuid.New()
+
+	target := newTestClient(hubs[1], targetID, orgID)
+	bystander := newTestClient(hubs[1], uuid.New(), orgID)
+	hubs[1].Register(target)
+	hubs[1].Register(bystander)
+	waitForClientCount(t, hubs[1], 2)
+
+	hubs[0].BroadcastToUser(orgID, targetID, websocket.WSMessage{Type: websocket.TypeAgentTransferAssign})
+
+	assertReceivesMessage(t, target, websocket.TypeAgentTransferAssign)
+	assertNoMessage(t, bystander)
+}
+
+func TestHub_Relay_LocalBroadcastIsNotRelayed(t *testing.T) {
+	hubs, relay := newRelayedHubs(t, 2)
+	orgID := uuid.New()
+
+	local := newTestClient(hubs[0], uuid.New(), orgID)
+	remote := newTestClient(hubs[1], uuid.New(), orgID)
+	hubs[0].Register(local)
+	hubs[1].Register(remote)
+	waitForClientCount(t, hubs[0], 1)
+	waitForClientCount(t, hubs[1], 1)
+
+	hubs[0].Broadcast(websocket.BroadcastMessage{
+		OrgID:   orgID,
+		Local:   true,
+		Message: websocket.WSMessage{Type: websocket.TypeCampaignStatsUpdate},
+	})
+
+	assertReceivesMessage(t, local, websocket.TypeCampaignStatsUpdate)
+	assertNoMessage(t, remote)
+	assert.Equal(t, 0, relay.publishCount())
+}
+
+func TestHub_HandleRelayed_IgnoresInvalidPayload(t *testing.T) {
+	hub := newTestHub(t)
+	orgID := uuid.New()
+
+	client := newTestClient(hub, uuid.New(), orgID)
+	hub.Register(client)
+	waitForClientCount(t, hub, 1)
+
+	hub.HandleRelayed([]byte("not json"))
+	hub.HandleRelayed([]byte(`{"origin":"other","type":"new_message"}`))
+
+	assertNoMessage(t, client)
+}
@@ -0,0 +1,158 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/shridarpatil/whatomate/internal/models"
+)
+
+const (
+	// throttleKeyPrefix namespaces the Redis keys used for per-account send limits
+	throttleKeyPrefix = "whatomate:throttle:"
+)
+
+// tokenBucketScript takes one token from an account's bucket. The bucket holds
+// up to rate tokens and refills at rate tokens per second. It returns 0 if a
+// token was taken, or the number of milliseconds until one is available.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil then
+	tokens = rate
+	ts = now
+end
+tokens = math.min(rate, tokens + (now - ts) * rate / 1000)
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], 60000)
+return wait
+`)
+
+// dailyCountScript counts a send against an account's daily cap. It returns 1
+// if the send is within the cap and 0 if the cap has been reached.
+var dailyCountScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('EXPIRE', KEYS[1], ARGV[2])
+end
+if n > tonumber(ARGV[1]) then
+	redis.call('DECR', KEYS[1])
+	return 0
+end
+return 1
+`)
+
+// DailyLimitError is returned when an account has reached its daily message cap
+type DailyLimitError struct {
+	ResetAt time.Time
+}
+
+func (e *DailyLimitError) Error() string {
+	return fmt.Sprintf("daily message limit reached, resets at %s", e.ResetAt.Format(time.RFC3339))
+}
+
+// AccountLimiter limits campaign sends per WhatsApp account. Limits are kept in
+// Redis so that they hold across all worker processes.
+type AccountLimiter struct {
+	redis *redis.Client
+
+	// Defaults for accounts that don't set their own limits
+	messagesPerSecond int
+	dailyMessageLimit int
+}
+
+// NewAccountLimiter creates a limiter with the given default limits. A limit of
+// 0 means unlimited.
+func NewAccountLimiter(rdb *redis.Client, messagesPerSecond, dailyMessageLimit int) *AccountLimiter {
+	return &AccountLimiter{
+		redis:             rdb,
+		messagesPerSecond: messagesPerSecond,
+		dailyMessageLimit: dailyMessageLimit,
+	}
+}
+
+// Wait blocks until the account may send another message. It returns a
+// *DailyLimitError if the account's daily cap has been reached.
+func (l *AccountLimiter) Wait(ctx context.Context, account *models.WhatsAppAccount) error {
+	if limit := l.dailyLimit(account); limit > 0 {
+		if err := l.countDaily(ctx, account.ID, limit, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	rate := l.rate(account)
+	if rate <= 0 {
+		return nil
+	}
+	for {
+		wait, err := l.takeToken(ctx, account.ID, rate)
+		if err != nil {
+			return err
+		}
+		if wait == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// rate returns the messages per second allowed for an account
+func (l *AccountLimiter) rate(account *models.WhatsAppAccount) int {
+	if account.MessagesPerSecond > 0 {
+		return account.MessagesPerSecond
+	}
+	return l.messagesPerSecond
+}
+
+// dailyLimit returns the messages per day allowed for an account
+func (l *AccountLimiter) dailyLimit(account *models.WhatsAppAccount) int {
+	if account.DailyMessageLimit > 0 {
+		return account.DailyMessageLimit
+	}
+	return l.dailyMessageLimit
+}
+
+// takeToken takes a token from the account's bucket, returning how long to wait if none is available
+func (l *AccountLimiter) takeToken(ctx context.Context, accountID uuid.UUID, rate int) (time.Duration, error) {
+	key := throttleKeyPrefix + "rate:" + accountID.String()
+	ms, err := tokenBucketScript.Run(ctx, l.redis, []string{key}, rate, time.Now().UnixMilli()).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to take send token: %w", err)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// countDaily counts a send against the account's cap for the current UTC day
+func (l *AccountLimiter) countDaily(ctx context.Context, accountID uuid.UUID, limit int, now time.Time) error {
+	day := now.UTC().Format("2006-01-02")
+	resetAt := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	key := throttleKeyPrefix + "daily:" + accountID.String() + ":" + day
+
+	// Keep the counter a little past midnight so late sends still see it
+	ttl := int64(resetAt.Sub(now).Seconds()) + 3600
+	ok, err := dailyCountScript.Run(ctx, l.redis, []string{key}, limit, strconv.FormatInt(ttl, 10)).Int()
+	if err != nil {
+		return fmt.Errorf("failed to count daily sends: %w", err)
+	}
+	if ok == 0 {
+		return &DailyLimitError{ResetAt: resetAt}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/queue"
+	"github.com/shridarpatil/whatomate/pkg/whatsapp"
+	"github.com/shridarpatil/whatomate/test/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLimiterRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	rdb := testutil.SetupTestRedis(t)
+	if rdb == nil {
+		t.Skip("TEST_REDIS_URL not set, skipping test")
+	}
+	return rdb
+}
+
+func TestAccountLimiter_Rate(t *testing.T) {
+	rdb := testLimiterRedis(t)
+	limiter := NewAccountLimiter(rdb, 2, 0)
+	account := &models.WhatsAppAccount{BaseModel: models.BaseModel{ID: uuid.New()}}
+	ctx := testutil.TestContextWithTimeout(t, 5*time.Second)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.Wait(ctx, account))
+	}
+	// The bucket holds 2 tokens, the third send waits for a refill
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestAccountLimiter_AccountOverridesDefault(t *testing.T) {
+	limiter := NewAccountLimiter(nil, 80, 1000)
+
+	assert.Equal(t, 80, limiter.rate(&models.WhatsAppAccount{}))
+	assert.Equal(t, 1000, limiter.dailyLimit(&models.WhatsAppAccount{}))
+	assert.Equal(t, 10, limiter.rate(&models.WhatsAppAccount{MessagesPerSecond: 10}))
+	assert.Equal(t, 250, limiter.dailyLimit(&models.WhatsAppAccount{DailyMessageLimit: 250}))
+}
+
+func TestAccountLimiter_DailyLimit(t *testing.T) {
+	rdb := testLimiterRedis(t)
+	limiter := NewAccountLimiter(rdb, 0, 2)
+	account := &models.WhatsAppAccount{BaseModel: models.BaseModel{ID: uuid.New()}}
+	ctx := testutil.TestContext(t)
+
+	require.NoError(t, limiter.Wait(ctx, account))
+	require.NoError(t, limiter.Wait(ctx, account))
+
+	err := limiter.Wait(ctx, account)
+	var limitErr *DailyLimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.True(t, limitErr.ResetAt.After(time.Now()))
+	assert.Equal(t, 0, limitErr.ResetAt.Hour())
+}
+
+func TestRateLimitBackoff(t *testing.T) {
+	assert.Equal(t, 5*time.Second, rateLimitBackoff(0))
+	assert.Equal(t, 20*time.Second, rateLimitBackoff(2))
+	assert.Equal(t, 10*time.Minute, rateLimitBackoff(10))
+}
+
+func TestWorker_HandleRecipientJob_RateLimitedIsDeferred(t *testing.T) {
+	w := testWorker(t)
+	if w.Redis == nil {
+		t.Skip("TEST_REDIS_URL not set, skipping test")
+	}
+	w.Queue = queue.NewRedisQueue(w.Redis, w.Log)
+	org, account, _, campaign, recipient := createTestCampaignData(t, w)
+
+	ctx := context.Background()
+	w.Redis.Del(ctx, queue.DelayedSetName)
+	t.Cleanup(func() { w.Redis.Del(context.Background(), queue.DelayedSetName) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"error": map[string]interface{}{
+				"message": "Rate limit hit",
+				"code":    whatsapp.ErrCodeCloudAPIThroughput,
+			},
+		})
+	}))
+	defer server.Close()
+
+	require.NoError(t, w.DB.Model(account).Update("api_version", "v21.0").Error)
+	w.WhatsApp = whatsapp.NewWithBaseURL(w.Log, server.URL)
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+		TemplateParams: recipient.TemplateParams,
+	}
+
+	require.NoError(t, w.HandleRecipientJob(ctx, job))
+
+	// The recipient stays pending and is scheduled again
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusPending, updatedRecipient.Status)
+
+	delayed, err := w.Redis.ZCard(ctx, queue.DelayedSetName).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), delayed)
+
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, w.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Equal(t, 0, updatedCampaign.FailedCount)
+	require.NotNil(t, updatedCampaign.ThrottledUntil)
+	assert.True(t, updatedCampaign.ThrottledUntil.After(time.Now()))
+
+	// Once retries are exhausted the recipient fails as before
+	job.Attempts = maxRateLimitRetries
+	require.NoError(t, w.HandleRecipientJob(ctx, job))
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusFailed, updatedRecipient.Status)
+}
@@ -0,0 +1,54 @@
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/models"
+	"github.com/shridarpatil/whatomate/internal/templateutil"
+	"gorm.io/gorm"
+)
+
+// templateVariantsTTL is how long the language variants of a campaign's
+// template are reused, so variants approved mid-campaign are picked up
+const templateVariantsTTL = 5 * time.Minute
+
+// templateVariantCache holds the language variants of campaign templates, so
+// they are loaded once per campaign instead of once per recipient. The zero
+// value is ready to use.
+type templateVariantCache struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]templateVariantEntry
+}
+
+type templateVariantEntry struct {
+	templateID uuid.UUID
+	variants   []models.Template
+	loadedAt   time.Time
+}
+
+// get returns the variants of a campaign's template, loading them if they
+// aren't cached or have expired
+func (c *templateVariantCache) get(db *gorm.DB, campaignID uuid.UUID, tmpl *models.Template, now time.Time) []models.Template {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[campaignID]; ok && entry.templateID == tmpl.ID && now.Sub(entry.loadedAt) < templateVariantsTTL {
+		return entry.variants
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[uuid.UUID]templateVariantEntry)
+	}
+	// Drop finished campaigns
+	for id, entry := range c.entries {
+		if now.Sub(entry.loadedAt) >= templateVariantsTTL {
+			delete(c.entries, id)
+		}
+	}
+
+	variants := templateutil.LanguageVariants(db, tmpl)
+	c.entries[campaignID] = templateVariantEntry{templateID: tmpl.ID, variants: variants, loadedAt: now}
+	return variants
+}
@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -26,10 +27,26 @@ type Worker struct {
 	WhatsApp  *whatsapp.Client
 	Consumer  *queue.RedisConsumer
 	Publisher *queue.Publisher
+	Queue     *queue.RedisQueue
+	Limiter   *AccountLimiter
+
+	variants templateVariantCache
 }
 
-// Ensure Worker implements JobHandler interface
-var _ queue.JobHandler = (*Worker)(nil)
+const (
+	// promoteInterval is how often delayed recipient jobs are moved back onto the stream
+	promoteInterval = time.Second
+
+	// maxRateLimitRetries is how often a recipient is deferred after Meta rate-limit
+	// errors before it is marked as failed
+	maxRateLimitRetries = 8
+)
+
+// Ensure Worker implements JobHandler and DeadLetterHandler interfaces
+var (
+	_ queue.JobHandler        = (*Worker)(nil)
+	_ queue.DeadLetterHandler = (*Worker)(nil)
+)
 
 // New creates a new Worker instance
 func New(cfg *config.Config, db *gorm.DB, rdb *redis.Client, log logf.Logger) (*Worker, error) {
@@ -48,14 +65,19 @@ func New(cfg *config.Config, db *gorm.DB, rdb *redis.Client, log logf.Logger) (*
 		WhatsApp:  whatsapp.New(log),
 		Consumer:  consumer,
 		Publisher: publisher,
+		Queue:     queue.NewRedisQueue(rdb, log),
+		Limiter:   NewAccountLimiter(rdb, cfg.WhatsApp.MessagesPerSecond, cfg.WhatsApp.DailyMessageLimit),
 	}, nil
 }
 
-
 // Run starts the worker and processes jobs until context is cancelled
 func (w *Worker) Run(ctx context.Context) error {
 	w.Log.Info("Worker starting")
 
+	if w.Queue != nil {
+		go w.promoteDelayedJobs(ctx)
+	}
+
 	err := w.Consumer.Consume(ctx, w)
 	if err != nil && ctx.Err() == nil {
 		return fmt.Errorf("consumer error: %w", err)
@@ -65,12 +87,39 @@ func (w *Worker) Run(ctx context.Context) error {
 	return nil
 }
 
+// promoteDelayedJobs re-enqueues deferred recipient jobs once they are due
+func (w *Worker) promoteDelayedJobs(ctx context.Context) {
+	ticker := time.NewTicker(promoteInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := w.Queue.PromoteDueRecipients(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					w.Log.Error("Failed to promote delayed recipient jobs", "error", err)
+				}
+				continue
+			}
+			if n > 0 {
+				w.Log.Debug("Promoted delayed recipient jobs", "count", n)
+			}
+		}
+	}
+}
+
 // HandleRecipientJob processes a single recipient message job
 func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob) error {
 	// Check if campaign is still active before sending
 	var campaign models.BulkMessageCampaign
 	if err := w.DB.Where("id = ?", job.CampaignID).Preload("Template").First(&campaign).Error; err != nil {
 		w.Log.Error("Failed to load campaign", "error", err, "campaign_id", job.CampaignID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return queue.Permanent(fmt.Errorf("failed to load campaign: %w", err))
+		}
 		return fmt.Errorf("failed to load campaign: %w", err)
 	}
 
@@ -80,6 +129,26 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 		return nil // Not an error, just skip
 	}
 
+	// Campaigns dispatched by the scheduler start out queued; the first job moves them to processing
+	if campaign.Status == models.CampaignStatusQueued {
+		w.DB.Model(&models.BulkMessageCampaign{}).
+			Where("id = ? AND status = ?", job.CampaignID, models.CampaignStatusQueued).
+			Update("status", models.CampaignStatusProcessing)
+	}
+
+	// The contact may have opted out after being added to the campaign
+	suppressed, err := contactutil.IsSuppressed(w.DB, job.OrganizationID, job.PhoneNumber)
+	if err != nil {
+		return fmt.Errorf("failed to check suppression list: %w", err)
+	}
+	if suppressed {
+		w.Log.Info("Recipient is on the suppression list, skipping", "campaign_id", job.CampaignID, "recipient", job.PhoneNumber)
+		w.updateRecipientStatus(job.RecipientID, models.MessageStatusFailed, "", "Contact has opted out")
+		w.incrementCampaignCount(job.CampaignID, "failed_count")
+		w.checkCampaignCompletion(ctx, job.CampaignID, job.OrganizationID)
+		return nil
+	}
+
 	// Get WhatsApp account
 	var account models.WhatsAppAccount
 	if err := w.DB.Where("name = ? AND organization_id = ?", campaign.WhatsAppAccount, job.OrganizationID).First(&account).Error; err != nil {
@@ -105,8 +174,38 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 		TemplateParams: job.TemplateParams,
 	}
 
-	// Send template message
-	waMessageID, err := w.sendTemplateMessage(ctx, &account, campaign.Template, recipient, campaign.HeaderMediaID)
+	// Respect the account's send rate and daily cap
+	if w.Limiter != nil {
+		if err := w.Limiter.Wait(ctx, &account); err != nil {
+			var limitErr *DailyLimitError
+			if errors.As(err, &limitErr) {
+				return w.deferRecipient(ctx, job, limitErr.ResetAt, limitErr.Error())
+			}
+			if ctx.Err() != nil {
+				return err // Shutting down, the job is reclaimed later
+			}
+			// Don't stall campaigns when Redis is briefly unavailable
+			w.Log.Warn("Send rate limiter unavailable, sending without throttling", "error", err, "account", account.Name)
+		}
+	}
+
+	// Send the template in the contact's language when it has been translated
+	template := campaign.Template
+	if template != nil && contact.Language != "" {
+		template = templateutil.PickLanguageVariant(template, w.variants.get(w.DB, campaign.ID, template, time.Now()), contact.Language)
+	}
+	waMessageID, err := w.sendTemplateMessage(ctx, &account, template, recipient, campaign.HeaderMediaID)
+
+	// Meta rejected the send for going too fast; try again later instead of failing it
+	if err != nil && whatsapp.IsRateLimitError(err) && w.Queue != nil && job.Attempts < maxRateLimitRetries {
+		return w.deferRecipient(ctx, job, time.Now().Add(rateLimitBackoff(job.Attempts)), err.Error())
+	}
+
+	// Meta or the network is temporarily unavailable; the consumer retries the job with backoff
+	if err != nil && whatsapp.IsTransientError(err) {
+		w.Log.Warn("Temporary failure sending message", "error", err, "recipient", job.PhoneNumber, "retries", job.Retries)
+		return fmt.Errorf("temporary send failure: %w", err)
+	}
 
 	// Create Message record
 	message := models.Message{
@@ -122,9 +221,9 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 			"recipient_name": job.RecipientName,
 		},
 	}
-	if campaign.Template != nil {
-		message.TemplateName = campaign.Template.Name
-		content := templateutil.ReplaceWithJSONBParams(campaign.Template.BodyContent, campaign.Template.BodyContent, job.TemplateParams)
+	if template != nil {
+		message.TemplateName = template.Name
+		content := templateutil.ReplaceWithJSONBParams(template.BodyContent, template.BodyContent, job.TemplateParams)
 		message.Content = content
 	}
 
@@ -139,6 +238,7 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 		message.Status = models.MessageStatusSent
 		w.updateRecipientStatus(job.RecipientID, models.MessageStatusSent, waMessageID, "")
 		w.incrementCampaignCount(job.CampaignID, "sent_count")
+		w.clearCampaignThrottle(job.CampaignID)
 	}
 
 	// Save message record
@@ -152,6 +252,63 @@ func (w *Worker) HandleRecipientJob(ctx context.Context, job *queue.RecipientJob
 	return nil
 }
 
+// HandleDeadRecipientJob marks the recipient of a dead-lettered job as failed so
+// that the campaign can complete. Requeueing the job resets the recipient.
+func (w *Worker) HandleDeadRecipientJob(ctx context.Context, job *queue.RecipientJob, reason string) {
+	result := w.DB.Model(&models.BulkMessageRecipient{}).
+		Where("id = ? AND status = ?", job.RecipientID, models.MessageStatusPending).
+		Updates(map[string]interface{}{
+			"status":        models.MessageStatusFailed,
+			"error_message": reason,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+	w.incrementCampaignCount(job.CampaignID, "failed_count")
+	w.checkCampaignCompletion(ctx, job.CampaignID, job.OrganizationID)
+}
+
+// deferRecipient puts a recipient job back on the queue to be sent at the given
+// time. The recipient stays pending, so the campaign does not complete meanwhile.
+func (w *Worker) deferRecipient(ctx context.Context, job *queue.RecipientJob, at time.Time, reason string) error {
+	if w.Queue == nil {
+		return fmt.Errorf("cannot defer recipient: %s", reason)
+	}
+
+	job.Attempts++
+	if err := w.Queue.EnqueueRecipientAt(ctx, job, at); err != nil {
+		// Leave the job unacknowledged so that it is reclaimed and tried again
+		return fmt.Errorf("failed to defer recipient: %w", err)
+	}
+
+	w.Log.Info("Recipient deferred by rate limit", "campaign_id", job.CampaignID, "recipient_id", job.RecipientID, "until", at, "reason", reason)
+
+	// Only move throttled_until forward, several recipients are deferred at once
+	result := w.DB.Model(&models.BulkMessageCampaign{}).
+		Where("id = ? AND (throttled_until IS NULL OR throttled_until < ?)", job.CampaignID, at).
+		Update("throttled_until", at)
+	if result.Error == nil && result.RowsAffected > 0 {
+		w.publishCampaignStats(ctx, job.CampaignID, job.OrganizationID)
+	}
+	return nil
+}
+
+// clearCampaignThrottle clears the throttled state of a campaign once sends resume
+func (w *Worker) clearCampaignThrottle(campaignID uuid.UUID) {
+	w.DB.Model(&models.BulkMessageCampaign{}).
+		Where("id = ? AND throttled_until <= ?", campaignID, time.Now()).
+		Update("throttled_until", nil)
+}
+
+// rateLimitBackoff returns the delay before retrying a send that Meta rate limited
+func rateLimitBackoff(attempts int) time.Duration {
+	delay := 5 * time.Second << attempts
+	if delay > 10*time.Minute {
+		delay = 10 * time.Minute
+	}
+	return delay
+}
+
 // updateRecipientStatus updates the recipient's status in the database
 func (w *Worker) updateRecipientStatus(recipientID uuid.UUID, status models.MessageStatus, waMessageID, errorMsg string) {
 	updates := map[string]interface{}{
@@ -189,6 +346,7 @@ func (w *Worker) publishCampaignStats(ctx context.Context, campaignID, organizat
 		DeliveredCount: campaign.DeliveredCount,
 		ReadCount:      campaign.ReadCount,
 		FailedCount:    campaign.FailedCount,
+		ThrottledUntil: campaign.ThrottledUntil,
 	})
 }
 
@@ -207,8 +365,8 @@ func (w *Worker) checkCampaignCompletion(ctx context.Context, campaignID, organi
 			return
 		}
 
-		// Only complete if currently processing
-		if campaign.Status != models.CampaignStatusProcessing {
+		// Only complete if currently processing (or queued, if the status update raced with the last job)
+		if campaign.Status != models.CampaignStatusProcessing && campaign.Status != models.CampaignStatusQueued {
 			return
 		}
 
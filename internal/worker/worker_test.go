@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/shridarpatil/whatomate/internal/contactutil"
 	"github.com/shridarpatil/whatomate/internal/models"
 	"github.com/shridarpatil/whatomate/internal/queue"
 	"github.com/shridarpatil/whatomate/internal/templateutil"
@@ -229,6 +230,39 @@ func TestWorker_HandleRecipientJob_AccountNotFound(t *testing.T) {
 	assert.Contains(t, updatedRecipient.ErrorMessage, "WhatsApp account not found")
 }
 
+func TestWorker_HandleRecipientJob_Suppressed(t *testing.T) {
+	w := testWorker(t)
+	org, _, _, campaign, recipient := createTestCampaignData(t, w)
+
+	_, err := contactutil.Suppress(w.DB, org.ID, "+"+recipient.PhoneNumber, models.SuppressionSourceKeyword, "", nil)
+	require.NoError(t, err)
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+	}
+
+	require.NoError(t, w.HandleRecipientJob(context.Background(), job))
+
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusFailed, updatedRecipient.Status)
+	assert.Contains(t, updatedRecipient.ErrorMessage, "opted out")
+
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, w.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Equal(t, 1, updatedCampaign.FailedCount)
+	assert.Equal(t, 0, updatedCampaign.SentCount)
+	assert.Equal(t, models.CampaignStatusCompleted, updatedCampaign.Status)
+
+	var messageCount int64
+	w.DB.Model(&models.Message{}).Where("organization_id = ?", org.ID).Count(&messageCount)
+	assert.Zero(t, messageCount, "no message should be sent")
+}
+
 func TestWorker_HandleRecipientJob_CampaignNotFound(t *testing.T) {
 	w := testWorker(t)
 
@@ -243,6 +277,7 @@ func TestWorker_HandleRecipientJob_CampaignNotFound(t *testing.T) {
 	err := w.HandleRecipientJob(context.Background(), job)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to load campaign")
+	assert.True(t, queue.IsPermanent(err), "a deleted campaign cannot be fixed by retrying")
 }
 
 // createMinimalCampaignData creates the minimum data needed for campaign tests
@@ -661,6 +696,71 @@ func TestWorker_HandleRecipientJob_WhatsAppError(t *testing.T) {
 	assert.Equal(t, 1, updatedCampaign.FailedCount)
 }
 
+func TestWorker_HandleRecipientJob_TransientErrorIsRetried(t *testing.T) {
+	w := testWorker(t)
+	org, account, _, campaign, recipient := createTestCampaignData(t, w)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = rw.Write([]byte("upstream unavailable"))
+	}))
+	defer server.Close()
+
+	require.NoError(t, w.DB.Model(account).Update("api_version", "v21.0").Error)
+	w.WhatsApp = whatsapp.NewWithBaseURL(w.Log, server.URL)
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+		RecipientName:  recipient.RecipientName,
+		TemplateParams: recipient.TemplateParams,
+	}
+
+	err := w.HandleRecipientJob(context.Background(), job)
+	require.Error(t, err)
+	assert.False(t, queue.IsPermanent(err))
+
+	// The recipient is left pending for the retry
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusPending, updatedRecipient.Status)
+
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, w.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Equal(t, 0, updatedCampaign.FailedCount)
+}
+
+func TestWorker_HandleDeadRecipientJob(t *testing.T) {
+	w := testWorker(t)
+	org, _, _, campaign, recipient := createTestCampaignData(t, w)
+
+	job := &queue.RecipientJob{
+		CampaignID:     campaign.ID,
+		RecipientID:    recipient.ID,
+		OrganizationID: org.ID,
+		PhoneNumber:    recipient.PhoneNumber,
+	}
+
+	w.HandleDeadRecipientJob(context.Background(), job, "temporary send failure")
+
+	var updatedRecipient models.BulkMessageRecipient
+	require.NoError(t, w.DB.First(&updatedRecipient, recipient.ID).Error)
+	assert.Equal(t, models.MessageStatusFailed, updatedRecipient.Status)
+	assert.Equal(t, "temporary send failure", updatedRecipient.ErrorMessage)
+
+	var updatedCampaign models.BulkMessageCampaign
+	require.NoError(t, w.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Equal(t, 1, updatedCampaign.FailedCount)
+	assert.Equal(t, models.CampaignStatusCompleted, updatedCampaign.Status)
+
+	// Dead-lettering the same job twice must not count it twice
+	w.HandleDeadRecipientJob(context.Background(), job, "temporary send failure")
+	require.NoError(t, w.DB.First(&updatedCampaign, campaign.ID).Error)
+	assert.Equal(t, 1, updatedCampaign.FailedCount)
+}
+
 func TestWorker_HandleRecipientJob_CreatesContact(t *testing.T) {
 	w := testWorker(t)
 	org, account, _, campaign, recipient := createTestCampaignData(t, w)
@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ProviderAnthropic is the name of the Anthropic provider
+const ProviderAnthropic = "anthropic"
+
+// AnthropicBaseURL is the default endpoint of the Anthropic provider
+const AnthropicBaseURL = "https://api.anthropic.com/v1"
+
+const anthropicVersion = "2023-06-01"
+
+func init() {
+	Register(ProviderAnthropic, func(cfg Config) (Provider, error) {
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("anthropic needs an API key")
+		}
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = AnthropicBaseURL
+		}
+		return &anthropicProvider{cfg: cfg}, nil
+	})
+}
+
+type anthropicProvider struct {
+	cfg Config
+}
+
+func (p *anthropicProvider) Complete(ctx context.Context, req Request) (string, error) {
+	resp, err := p.CompleteWithTools(ctx, req, nil)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+func (p *anthropicProvider) CompleteWithTools(ctx context.Context, req Request, tools []Tool) (*Response, error) {
+	// max_tokens is required by the Messages API
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 1024
+	}
+	payload := map[string]interface{}{
+		"model":      req.Model,
+		"messages":   anthropicMessages(req.Messages),
+		"max_tokens": maxTokens,
+	}
+	if req.SystemPrompt != "" {
+		payload["system"] = req.SystemPrompt
+	}
+	if req.Temperature > 0 {
+		payload["temperature"] = req.Temperature
+	}
+	if len(tools) > 0 {
+		defs := make([]map[string]interface{}, 0, len(tools))
+		for _, t := range tools {
+			defs = append(defs, map[string]interface{}{
+				"name":         t.Name,
+				"description":  t.Description,
+				"input_schema": toolParameters(t),
+			})
+		}
+		payload["tools"] = defs
+	}
+
+	headers := map[string]string{
+		"x-api-key":         p.cfg.APIKey,
+		"anthropic-version": anthropicVersion,
+	}
+	var result struct {
+		Content []struct {
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			ID    string          `json:"id"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+	}
+	if err := postJSON(ctx, p.cfg.HTTPClient, ProviderAnthropic, endpoint(p.cfg.BaseURL, "/messages"), headers, payload, &result); err != nil {
+		return nil, err
+	}
+
+	resp := &Response{}
+	var texts []string
+	for _, content := range result.Content {
+		switch content.Type {
+		case "text":
+			texts = append(texts, content.Text)
+		case "tool_use":
+			resp.ToolCalls = append(resp.ToolCalls, ToolCall{ID: content.ID, Name: content.Name, Arguments: string(content.Input)})
+		}
+	}
+	if len(texts) == 0 && len(resp.ToolCalls) == 0 {
+		return nil, fmt.Errorf("no text response from anthropic")
+	}
+	resp.Content = strings.TrimSpace(strings.Join(texts, "\n"))
+	return resp, nil
+}
+
+// anthropicMessages converts messages to the Messages API format. Tool calls are
+// tool_use blocks of the assistant, and their results tool_result blocks of the
+// next user message.
+func anthropicMessages(messages []Message) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(messages))
+	for _, m := range messages {
+		switch {
+		case m.Role == RoleTool:
+			block := map[string]interface{}{"type": "tool_result", "tool_use_id": m.ToolCallID, "content": m.Content}
+			// Results of parallel calls go in one user message
+			if n := len(out); n > 0 && out[n-1]["role"] == RoleUser {
+				if blocks, ok := out[n-1]["content"].([]map[string]interface{}); ok {
+					out[n-1]["content"] = append(blocks, block)
+					continue
+				}
+			}
+			out = append(out, map[string]interface{}{"role": RoleUser, "content": []map[string]interface{}{block}})
+		case len(m.ToolCalls) > 0:
+			var blocks []map[string]interface{}
+			if m.Content != "" {
+				blocks = append(blocks, map[string]interface{}{"type": "text", "text": m.Content})
+			}
+			for _, call := range m.ToolCalls {
+				blocks = append(blocks, map[string]interface{}{"type": "tool_use", "id": call.ID, "name": call.Name, "input": toolArguments(call)})
+			}
+			out = append(out, map[string]interface{}{"role": m.Role, "content": blocks})
+		default:
+			out = append(out, map[string]interface{}{"role": m.Role, "content": m.Content})
+		}
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ProviderGoogle is the name of the Google Gemini provider
+const ProviderGoogle = "google"
+
+// GoogleBaseURL is the default endpoint of the Google provider
+const GoogleBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+func init() {
+	Register(ProviderGoogle, func(cfg Config) (Provider, error) {
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("google needs an API key")
+		}
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = GoogleBaseURL
+		}
+		return &googleProvider{cfg: cfg}, nil
+	})
+}
+
+type googleProvider struct {
+	cfg Config
+}
+
+func (p *googleProvider) Complete(ctx context.Context, req Request) (string, error) {
+	resp, err := p.CompleteWithTools(ctx, req, nil)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+func (p *googleProvider) CompleteWithTools(ctx context.Context, req Request, tools []Tool) (*Response, error) {
+	generationConfig := map[string]interface{}{}
+	if req.MaxTokens > 0 {
+		generationConfig["maxOutputTokens"] = req.MaxTokens
+	}
+	if req.Temperature > 0 {
+		generationConfig["temperature"] = req.Temperature
+	}
+	payload := map[string]interface{}{
+		"contents":         googleContents(req.Messages),
+		"generationConfig": generationConfig,
+	}
+	if req.SystemPrompt != "" {
+		payload["systemInstruction"] = map[string]interface{}{
+			"parts": []map[string]string{{"text": req.SystemPrompt}},
+		}
+	}
+	if len(tools) > 0 {
+		decls := make([]map[string]interface{}, 0, len(tools))
+		for _, t := range tools {
+			decl := map[string]interface{}{"name": t.Name, "description": t.Description}
+			// Gemini rejects an object schema without properties
+			if props, _ := t.Parameters["properties"].(map[string]interface{}); len(props) > 0 {
+				decl["parameters"] = toolParameters(t)
+			}
+			decls = append(decls, decl)
+		}
+		payload["tools"] = []map[string]interface{}{{"functionDeclarations": decls}}
+	}
+
+	var result struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text         string `json:"text"`
+					FunctionCall *struct {
+						Name string          `json:"name"`
+						Args json.RawMessage `json:"args"`
+					} `json:"functionCall"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+	}
+	path := "/models/" + url.PathEscape(req.Model) + ":generateContent"
+	headers := map[string]string{"x-goog-api-key": p.cfg.APIKey}
+	if err := postJSON(ctx, p.cfg.HTTPClient, ProviderGoogle, endpoint(p.cfg.BaseURL, path), headers, payload, &result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("no response from google")
+	}
+	resp := &Response{}
+	var texts []string
+	for _, part := range result.Candidates[0].Content.Parts {
+		if part.FunctionCall != nil {
+			// Gemini has no call IDs; results are matched by function name
+			resp.ToolCalls = append(resp.ToolCalls, ToolCall{ID: part.FunctionCall.Name, Name: part.FunctionCall.Name, Arguments: string(part.FunctionCall.Args)})
+		} else if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	resp.Content = strings.TrimSpace(strings.Join(texts, ""))
+	return resp, nil
+}
+
+func (p *googleProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	requests := make([]map[string]interface{}, 0, len(texts))
+	for _, text := range texts {
+		requests = append(requests, map[string]interface{}{
+			"model":   "models/" + model,
+			"content": map[string]interface{}{"parts": []map[string]interface{}{{"text": text}}},
+		})
+	}
+
+	var result struct {
+		Embeddings []struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	}
+	path := "/models/" + url.PathEscape(model) + ":batchEmbedContents"
+	headers := map[string]string{"x-goog-api-key": p.cfg.APIKey}
+	if err := postJSON(ctx, p.cfg.HTTPClient, ProviderGoogle, endpoint(p.cfg.BaseURL, path), headers, map[string]interface{}{"requests": requests}, &result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("google returned %d embeddings for %d texts", len(result.Embeddings), len(texts))
+	}
+	embeddings := make([][]float32, len(texts))
+	for i, e := range result.Embeddings {
+		embeddings[i] = e.Values
+	}
+	return embeddings, nil
+}
+
+// googleContents converts messages to Gemini contents. The assistant is the
+// "model" role, and tool results are functionResponse parts of a user turn.
+func googleContents(messages []Message) []map[string]interface{} {
+	contents := make([]map[string]interface{}, 0, len(messages))
+	for _, m := range messages {
+		switch {
+		case m.Role == RoleTool:
+			part := map[string]interface{}{
+				"functionResponse": map[string]interface{}{
+					"name":     m.ToolName,
+					"response": map[string]interface{}{"content": m.Content},
+				},
+			}
+			// Results of parallel calls go in one turn
+			if n := len(contents); n > 0 && contents[n-1]["role"] == RoleUser {
+				if parts, ok := contents[n-1]["parts"].([]map[string]interface{}); ok {
+					contents[n-1]["parts"] = append(parts, part)
+					continue
+				}
+			}
+			contents = append(contents, map[string]interface{}{"role": RoleUser, "parts": []map[string]interface{}{part}})
+		case m.Role == RoleAssistant:
+			var parts []map[string]interface{}
+			if m.Content != "" || len(m.ToolCalls) == 0 {
+				parts = append(parts, map[string]interface{}{"text": m.Content})
+			}
+			for _, call := range m.ToolCalls {
+				parts = append(parts, map[string]interface{}{
+					"functionCall": map[string]interface{}{"name": call.Name, "args": toolArguments(call)},
+				})
+			}
+			contents = append(contents, map[string]interface{}{"role": "model", "parts": parts})
+		default:
+			contents = append(contents, map[string]interface{}{
+				"role":  m.Role,
+				"parts": []map[string]string{{"text": m.Content}},
+			})
+		}
+	}
+	return contents
+}
@@ -0,0 +1,244 @@
+// Package llm is a small client for chat completion APIs. Providers register
+// themselves by name, so callers pick one from settings without knowing how
+// it talks to its API.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout for a completion request
+const DefaultTimeout = 30 * time.Second
+
+// Message roles
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool" // the result of a tool call
+)
+
+// Message is a turn of the conversation sent to the model
+type Message struct {
+	Role    string
+	Content string
+
+	// ToolCalls are the calls requested by an assistant message
+	ToolCalls []ToolCall
+	// ToolCallID and ToolName identify the call a tool message answers
+	ToolCallID string
+	ToolName   string
+}
+
+// Tool is a function the model may call before it answers
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{} // JSON schema of the arguments object
+}
+
+// ToolCall is a call of a tool requested by the model
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string // JSON object
+}
+
+// Response is the model's reply to a request with tools. It has either
+// content or tool calls whose results the model needs to continue.
+type Response struct {
+	Content   string
+	ToolCalls []ToolCall
+}
+
+// Request is a chat completion request
+type Request struct {
+	Model        string
+	SystemPrompt string
+	Messages     []Message // oldest first, ending with the user's message
+	MaxTokens    int
+	Temperature  float64 // 0 uses the provider's default
+}
+
+// Config holds the connection settings of a provider
+type Config struct {
+	APIKey     string
+	BaseURL    string // overrides the provider's default endpoint
+	HTTPClient *http.Client
+}
+
+// Provider completes chat requests against a model API
+type Provider interface {
+	Complete(ctx context.Context, req Request) (string, error)
+}
+
+// ToolCaller is a provider whose model can call tools
+type ToolCaller interface {
+	Provider
+	CompleteWithTools(ctx context.Context, req Request, tools []Tool) (*Response, error)
+}
+
+// Embedder is a provider that turns texts into embedding vectors for semantic search
+type Embedder interface {
+	Embed(ctx context.Context, model string, texts []string) ([][]float32, error)
+}
+
+// Factory creates a provider, returning an error if the config is incomplete
+type Factory func(cfg Config) (Provider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a provider available under name. It replaces any provider already registered with that name.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// IsRegistered reports whether a provider is registered under name
+func IsRegistered(name string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	_, ok := registry[name]
+	return ok
+}
+
+// Providers returns the names of the registered providers, sorted
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the provider registered under name
+func New(name string, cfg Config) (Provider, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported AI provider: %s", name)
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return factory(cfg)
+}
+
+// toolParameters returns the parameters schema of a tool, which APIs require to be an object
+func toolParameters(tool Tool) map[string]interface{} {
+	if len(tool.Parameters) == 0 {
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	params := make(map[string]interface{}, len(tool.Parameters)+1)
+	for k, v := range tool.Parameters {
+		params[k] = v
+	}
+	params["type"] = "object"
+	return params
+}
+
+// toolArguments decodes the arguments of a tool call, using an empty object if they are invalid
+func toolArguments(call ToolCall) map[string]interface{} {
+	args := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(call.Arguments), &args)
+	return args
+}
+
+// APIError is a non-2xx response from a provider
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s API error (status %d): %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// endpoint joins a base URL and a path, keeping any query string of the base URL
+func endpoint(baseURL, path string) string {
+	base, query, _ := strings.Cut(baseURL, "?")
+	url := strings.TrimRight(base, "/") + path
+	if query != "" {
+		url += "?" + query
+	}
+	return url
+}
+
+// postJSON sends a JSON request and decodes a 2xx JSON response into out.
+// Error responses are returned as an *APIError with the provider's message.
+func postJSON(ctx context.Context, client *http.Client, provider, url string, headers map[string]string, payload, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp struct {
+			Error json.RawMessage `json:"error"`
+		}
+		_ = json.Unmarshal(respBody, &errResp)
+		return &APIError{Provider: provider, StatusCode: resp.StatusCode, Message: errorMessage(errResp.Error, respBody)}
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// errorMessage extracts the message of an error response, which is either
+// {"error": {"message": "..."}} or {"error": "..."} depending on the server
+func errorMessage(raw json.RawMessage, body []byte) string {
+	var nested struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(raw, &nested) == nil && nested.Message != "" {
+		return nested.Message
+	}
+	var flat string
+	if json.Unmarshal(raw, &flat) == nil && flat != "" {
+		return flat
+	}
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > 200 {
+		msg = msg[:200]
+	}
+	return msg
+}
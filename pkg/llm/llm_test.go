@@ -0,0 +1,340 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shridarpatil/whatomate/pkg/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRequest is a conversation with a system prompt, one earlier turn and the user's message.
+var testRequest = llm.Request{
+	Model:        "test-model",
+	SystemPrompt: "Be brief",
+	Messages: []llm.Message{
+		{Role: llm.RoleUser, Content: "Hi"},
+		{Role: llm.RoleAssistant, Content: "Hello!"},
+		{Role: llm.RoleUser, Content: "Opening hours?"},
+	},
+	MaxTokens:   200,
+	Temperature: 0.5,
+}
+
+// newServer returns a test server that checks the request and replies with body
+func newServer(t *testing.T, check func(r *http.Request, payload map[string]any), status int, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var payload map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		check(r, payload)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"anthropic", "google", "openai", "openai_compatible"}, llm.Providers())
+	assert.True(t, llm.IsRegistered(llm.ProviderOpenAICompatible))
+
+	_, err := llm.New("mistral-direct", llm.Config{APIKey: "k"})
+	assert.EqualError(t, err, "unsupported AI provider: mistral-direct")
+
+	for _, name := range []string{llm.ProviderOpenAI, llm.ProviderAnthropic, llm.ProviderGoogle} {
+		_, err := llm.New(name, llm.Config{})
+		assert.Error(t, err, name)
+	}
+
+	_, err = llm.New(llm.ProviderOpenAICompatible, llm.Config{APIKey: "k"})
+	assert.Error(t, err, "base URL is required")
+	_, err = llm.New(llm.ProviderOpenAICompatible, llm.Config{BaseURL: "http://ollama:11434/v1"})
+	assert.NoError(t, err, "API key is optional")
+}
+
+func TestOpenAI_Complete(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t, func(r *http.Request, payload map[string]any) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		assert.Equal(t, "test-model", payload["model"])
+		assert.Equal(t, float64(200), payload["max_tokens"])
+		assert.Equal(t, 0.5, payload["temperature"])
+		messages := payload["messages"].([]any)
+		require.Len(t, messages, 4)
+		assert.Equal(t, map[string]any{"role": "system", "content": "Be brief"}, messages[0])
+		assert.Equal(t, map[string]any{"role": "assistant", "content": "Hello!"}, messages[2])
+	}, http.StatusOK, `{"choices": [{"message": {"content": " 9 to 5. "}}]}`)
+
+	provider, err := llm.New(llm.ProviderOpenAI, llm.Config{APIKey: "test-key", BaseURL: server.URL + "/v1/"})
+	require.NoError(t, err)
+	reply, err := provider.Complete(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.Equal(t, "9 to 5.", reply)
+}
+
+func TestOpenAICompatible_Complete(t *testing.T) {
+	t.Parallel()
+
+	t.Run("without API key", func(t *testing.T) {
+		server := newServer(t, func(r *http.Request, payload map[string]any) {
+			assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+			assert.Empty(t, r.Header.Get("Authorization"))
+		}, http.StatusOK, `{"choices": [{"message": {"content": "ok"}}]}`)
+
+		provider, err := llm.New(llm.ProviderOpenAICompatible, llm.Config{BaseURL: server.URL + "/v1"})
+		require.NoError(t, err)
+		reply, err := provider.Complete(context.Background(), testRequest)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", reply)
+	})
+
+	t.Run("keeps query string of base URL", func(t *testing.T) {
+		server := newServer(t, func(r *http.Request, payload map[string]any) {
+			assert.Equal(t, "/openai/deployments/gpt/chat/completions", r.URL.Path)
+			assert.Equal(t, "2024-06-01", r.URL.Query().Get("api-version"))
+			assert.Equal(t, "Bearer k", r.Header.Get("Authorization"))
+		}, http.StatusOK, `{"choices": [{"message": {"content": "ok"}}]}`)
+
+		provider, err := llm.New(llm.ProviderOpenAICompatible, llm.Config{APIKey: "k", BaseURL: server.URL + "/openai/deployments/gpt?api-version=2024-06-01"})
+		require.NoError(t, err)
+		_, err = provider.Complete(context.Background(), testRequest)
+		require.NoError(t, err)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		server := newServer(t, func(r *http.Request, payload map[string]any) {}, http.StatusNotFound, `{"error": "model \"test-model\" not found"}`)
+
+		provider, err := llm.New(llm.ProviderOpenAICompatible, llm.Config{BaseURL: server.URL})
+		require.NoError(t, err)
+		_, err = provider.Complete(context.Background(), testRequest)
+		var apiErr *llm.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.Equal(t, `model "test-model" not found`, apiErr.Message)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(500 * time.Millisecond):
+			}
+		}))
+		t.Cleanup(server.Close)
+
+		provider, err := llm.New(llm.ProviderOpenAICompatible, llm.Config{BaseURL: server.URL})
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = provider.Complete(ctx, testRequest)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestAnthropic_Complete(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t, func(r *http.Request, payload map[string]any) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.NotEmpty(t, r.Header.Get("anthropic-version"))
+		assert.Equal(t, "Be brief", payload["system"])
+		assert.Len(t, payload["messages"], 3)
+	}, http.StatusOK, `{"content": [{"type": "text", "text": "9 to 5."}]}`)
+
+	provider, err := llm.New(llm.ProviderAnthropic, llm.Config{APIKey: "test-key", BaseURL: server.URL + "/v1"})
+	require.NoError(t, err)
+	reply, err := provider.Complete(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.Equal(t, "9 to 5.", reply)
+}
+
+func TestGoogle_Complete(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t, func(r *http.Request, payload map[string]any) {
+		assert.Equal(t, "/v1beta/models/test-model:generateContent", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-goog-api-key"))
+		assert.Empty(t, r.URL.Query().Get("key"), "the API key is not sent in the URL")
+		contents := payload["contents"].([]any)
+		require.Len(t, contents, 3)
+		assert.Equal(t, "model", contents[1].(map[string]any)["role"])
+		assert.Equal(t, float64(200), payload["generationConfig"].(map[string]any)["maxOutputTokens"])
+	}, http.StatusOK, `{"candidates": [{"content": {"parts": [{"text": "9 to 5."}]}}]}`)
+
+	provider, err := llm.New(llm.ProviderGoogle, llm.Config{APIKey: "test-key", BaseURL: server.URL + "/v1beta"})
+	require.NoError(t, err)
+	reply, err := provider.Complete(context.Background(), testRequest)
+	require.NoError(t, err)
+	assert.Equal(t, "9 to 5.", reply)
+}
+
+func TestGoogle_ErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t, func(r *http.Request, payload map[string]any) {}, http.StatusBadRequest,
+		`{"error": {"code": 400, "message": "API key not valid"}}`)
+
+	provider, err := llm.New(llm.ProviderGoogle, llm.Config{APIKey: "bad", BaseURL: server.URL})
+	require.NoError(t, err)
+	_, err = provider.Complete(context.Background(), testRequest)
+	assert.EqualError(t, err, "google API error (status 400): API key not valid")
+}
+
+// toolRequest is a conversation where the model already looked up an order
+var toolRequest = llm.Request{
+	Model: "test-model",
+	Messages: []llm.Message{
+		{Role: llm.RoleUser, Content: "Where is order 1234?"},
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{ID: "call_1", Name: "order_status", Arguments: `{"order_id": "1234"}`}}},
+		{Role: llm.RoleTool, ToolCallID: "call_1", ToolName: "order_status", Content: `{"status": "shipped"}`},
+	},
+}
+
+var orderTool = llm.Tool{
+	Name:        "order_status",
+	Description: "Looks up an order",
+	Parameters: map[string]interface{}{
+		"properties": map[string]interface{}{"order_id": map[string]interface{}{"type": "string"}},
+		"required":   []interface{}{"order_id"},
+	},
+}
+
+func TestOpenAI_CompleteWithTools(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t, func(r *http.Request, payload map[string]any) {
+		tools := payload["tools"].([]any)
+		require.Len(t, tools, 1)
+		function := tools[0].(map[string]any)["function"].(map[string]any)
+		assert.Equal(t, "order_status", function["name"])
+		assert.Equal(t, "object", function["parameters"].(map[string]any)["type"])
+
+		messages := payload["messages"].([]any)
+		require.Len(t, messages, 3)
+		call := messages[1].(map[string]any)["tool_calls"].([]any)[0].(map[string]any)
+		assert.Equal(t, "call_1", call["id"])
+		assert.Equal(t, `{"order_id": "1234"}`, call["function"].(map[string]any)["arguments"])
+		assert.Equal(t, map[string]any{"role": "tool", "tool_call_id": "call_1", "content": `{"status": "shipped"}`}, messages[2])
+	}, http.StatusOK, `{"choices": [{"message": {"content": null, "tool_calls": [
+		{"id": "call_2", "type": "function", "function": {"name": "order_status", "arguments": "{\"order_id\": \"99\"}"}}
+	]}}]}`)
+
+	provider, err := llm.New(llm.ProviderOpenAI, llm.Config{APIKey: "k", BaseURL: server.URL})
+	require.NoError(t, err)
+	resp, err := provider.(llm.ToolCaller).CompleteWithTools(context.Background(), toolRequest, []llm.Tool{orderTool})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Content)
+	assert.Equal(t, []llm.ToolCall{{ID: "call_2", Name: "order_status", Arguments: `{"order_id": "99"}`}}, resp.ToolCalls)
+}
+
+func TestAnthropic_CompleteWithTools(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t, func(r *http.Request, payload map[string]any) {
+		tools := payload["tools"].([]any)
+		require.Len(t, tools, 1)
+		assert.Equal(t, "order_status", tools[0].(map[string]any)["name"])
+		assert.NotNil(t, tools[0].(map[string]any)["input_schema"])
+
+		messages := payload["messages"].([]any)
+		require.Len(t, messages, 3)
+		use := messages[1].(map[string]any)["content"].([]any)[0].(map[string]any)
+		assert.Equal(t, map[string]any{"type": "tool_use", "id": "call_1", "name": "order_status", "input": map[string]any{"order_id": "1234"}}, use)
+		result := messages[2].(map[string]any)
+		assert.Equal(t, "user", result["role"])
+		assert.Equal(t, "call_1", result["content"].([]any)[0].(map[string]any)["tool_use_id"])
+	}, http.StatusOK, `{"content": [
+		{"type": "text", "text": "Checking."},
+		{"type": "tool_use", "id": "toolu_2", "name": "order_status", "input": {"order_id": "99"}}
+	]}`)
+
+	provider, err := llm.New(llm.ProviderAnthropic, llm.Config{APIKey: "k", BaseURL: server.URL})
+	require.NoError(t, err)
+	resp, err := provider.(llm.ToolCaller).CompleteWithTools(context.Background(), toolRequest, []llm.Tool{orderTool})
+	require.NoError(t, err)
+	assert.Equal(t, "Checking.", resp.Content)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "toolu_2", resp.ToolCalls[0].ID)
+	assert.JSONEq(t, `{"order_id": "99"}`, resp.ToolCalls[0].Arguments)
+}
+
+func TestGoogle_CompleteWithTools(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t, func(r *http.Request, payload map[string]any) {
+		decls := payload["tools"].([]any)[0].(map[string]any)["functionDeclarations"].([]any)
+		require.Len(t, decls, 1)
+		assert.Equal(t, "order_status", decls[0].(map[string]any)["name"])
+
+		contents := payload["contents"].([]any)
+		require.Len(t, contents, 3)
+		call := contents[1].(map[string]any)["parts"].([]any)[0].(map[string]any)["functionCall"].(map[string]any)
+		assert.Equal(t, map[string]any{"order_id": "1234"}, call["args"])
+		response := contents[2].(map[string]any)["parts"].([]any)[0].(map[string]any)["functionResponse"].(map[string]any)
+		assert.Equal(t, "order_status", response["name"])
+	}, http.StatusOK, `{"candidates": [{"content": {"parts": [
+		{"functionCall": {"name": "order_status", "args": {"order_id": "99"}}}
+	]}}]}`)
+
+	provider, err := llm.New(llm.ProviderGoogle, llm.Config{APIKey: "k", BaseURL: server.URL})
+	require.NoError(t, err)
+	resp, err := provider.(llm.ToolCaller).CompleteWithTools(context.Background(), toolRequest, []llm.Tool{orderTool})
+	require.NoError(t, err)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "order_status", resp.ToolCalls[0].Name)
+	assert.JSONEq(t, `{"order_id": "99"}`, resp.ToolCalls[0].Arguments)
+}
+
+func TestOpenAI_Embed(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t, func(r *http.Request, payload map[string]any) {
+		assert.Equal(t, "/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		assert.Equal(t, "text-embedding-3-small", payload["model"])
+		assert.Equal(t, []any{"refunds", "shipping"}, payload["input"])
+	}, http.StatusOK, `{"data": [{"index": 1, "embedding": [0.3, 0.4]}, {"index": 0, "embedding": [0.1, 0.2]}]}`)
+
+	provider, err := llm.New(llm.ProviderOpenAI, llm.Config{APIKey: "sk-test", BaseURL: server.URL})
+	require.NoError(t, err)
+	embedder, ok := provider.(llm.Embedder)
+	require.True(t, ok)
+
+	embeddings, err := embedder.Embed(context.Background(), "text-embedding-3-small", []string{"refunds", "shipping"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, embeddings)
+}
+
+func TestGoogle_Embed(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t, func(r *http.Request, payload map[string]any) {
+		assert.Equal(t, "/models/text-embedding-004:batchEmbedContents", r.URL.Path)
+		requests := payload["requests"].([]any)
+		require.Len(t, requests, 2)
+		first := requests[0].(map[string]any)
+		assert.Equal(t, "models/text-embedding-004", first["model"])
+		assert.Equal(t, map[string]any{"parts": []any{map[string]any{"text": "refunds"}}}, first["content"])
+	}, http.StatusOK, `{"embeddings": [{"values": [0.1, 0.2]}, {"values": [0.3, 0.4]}]}`)
+
+	provider, err := llm.New(llm.ProviderGoogle, llm.Config{APIKey: "key", BaseURL: server.URL})
+	require.NoError(t, err)
+
+	embeddings, err := provider.(llm.Embedder).Embed(context.Background(), "text-embedding-004", []string{"refunds", "shipping"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.1, 0.2}, {0.3, 0.4}}, embeddings)
+}
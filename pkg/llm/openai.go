@@ -0,0 +1,183 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Provider names
+const (
+	ProviderOpenAI           = "openai"
+	ProviderOpenAICompatible = "openai_compatible"
+)
+
+// OpenAIBaseURL is the default endpoint of the OpenAI provider
+const OpenAIBaseURL = "https://api.openai.com/v1"
+
+func init() {
+	Register(ProviderOpenAI, func(cfg Config) (Provider, error) {
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("openai needs an API key")
+		}
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = OpenAIBaseURL
+		}
+		return &openAIProvider{name: ProviderOpenAI, cfg: cfg}, nil
+	})
+
+	// Servers that implement the OpenAI chat completions API, such as vLLM,
+	// Ollama, LiteLLM or Azure OpenAI. The API key is optional.
+	Register(ProviderOpenAICompatible, func(cfg Config) (Provider, error) {
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("openai_compatible needs a base URL")
+		}
+		return &openAIProvider{name: ProviderOpenAICompatible, cfg: cfg}, nil
+	})
+}
+
+type openAIProvider struct {
+	name string
+	cfg  Config
+}
+
+func (p *openAIProvider) Complete(ctx context.Context, req Request) (string, error) {
+	resp, err := p.CompleteWithTools(ctx, req, nil)
+	if err != nil {
+		return "", err
+	}
+	return resp.Content, nil
+}
+
+func (p *openAIProvider) CompleteWithTools(ctx context.Context, req Request, tools []Tool) (*Response, error) {
+	messages := make([]map[string]interface{}, 0, len(req.Messages)+1)
+	if req.SystemPrompt != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": req.SystemPrompt})
+	}
+	for _, m := range req.Messages {
+		messages = append(messages, openAIMessage(m))
+	}
+
+	payload := map[string]interface{}{
+		"model":    req.Model,
+		"messages": messages,
+	}
+	if req.MaxTokens > 0 {
+		payload["max_tokens"] = req.MaxTokens
+	}
+	if req.Temperature > 0 {
+		payload["temperature"] = req.Temperature
+	}
+	if len(tools) > 0 {
+		defs := make([]map[string]interface{}, 0, len(tools))
+		for _, t := range tools {
+			defs = append(defs, map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        t.Name,
+					"description": t.Description,
+					"parameters":  toolParameters(t),
+				},
+			})
+		}
+		payload["tools"] = defs
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content   string `json:"content"`
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := postJSON(ctx, p.cfg.HTTPClient, p.name, endpoint(p.cfg.BaseURL, "/chat/completions"), p.authHeaders(), payload, &result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("no response from %s", p.name)
+	}
+	msg := result.Choices[0].Message
+	resp := &Response{Content: strings.TrimSpace(msg.Content)}
+	for _, call := range msg.ToolCalls {
+		resp.ToolCalls = append(resp.ToolCalls, ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments})
+	}
+	return resp, nil
+}
+
+func (p *openAIProvider) Embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	payload := map[string]interface{}{"model": model, "input": texts}
+	if err := postJSON(ctx, p.cfg.HTTPClient, p.name, endpoint(p.cfg.BaseURL, "/embeddings"), p.authHeaders(), payload, &result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("%s returned %d embeddings for %d texts", p.name, len(result.Data), len(texts))
+	}
+	embeddings := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("%s returned an embedding for unknown input %d", p.name, d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	return embeddings, nil
+}
+
+// openAIMessage converts a message to the chat completions format
+func openAIMessage(m Message) map[string]interface{} {
+	msg := map[string]interface{}{"role": m.Role, "content": m.Content}
+	if m.Role == RoleTool {
+		msg["tool_call_id"] = m.ToolCallID
+	}
+	if len(m.ToolCalls) > 0 {
+		calls := make([]map[string]interface{}, 0, len(m.ToolCalls))
+		for _, call := range m.ToolCalls {
+			calls = append(calls, map[string]interface{}{
+				"id":   call.ID,
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":      call.Name,
+					"arguments": call.Arguments,
+				},
+			})
+		}
+		msg["tool_calls"] = calls
+	}
+	return msg
+}
+
+// authHeaders returns the API key header. Azure OpenAI expects an api-key
+// header instead of a bearer token.
+func (p *openAIProvider) authHeaders() map[string]string {
+	if p.cfg.APIKey == "" {
+		return nil
+	}
+	if isAzureOpenAI(p.cfg.BaseURL) {
+		return map[string]string{"api-key": p.cfg.APIKey}
+	}
+	return map[string]string{"Authorization": "Bearer " + p.cfg.APIKey}
+}
+
+func isAzureOpenAI(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return strings.HasSuffix(host, ".openai.azure.com") || strings.HasSuffix(host, ".cognitiveservices.azure.com")
+}
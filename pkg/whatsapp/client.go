@@ -98,18 +98,16 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body interfa
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		var apiErr MetaAPIError
-		if err := json.Unmarshal(respBody, &apiErr); err == nil && apiErr.Error.Message != "" {
-			errMsg := fmt.Sprintf("API error %d: %s", apiErr.Error.Code, apiErr.Error.Message)
-			if apiErr.Error.ErrorData.Details != "" {
-				errMsg += " - Details: " + apiErr.Error.ErrorData.Details
-			}
-			if apiErr.Error.ErrorUserMsg != "" {
-				errMsg += " - " + apiErr.Error.ErrorUserMsg
-			}
-			return nil, fmt.Errorf("%s", errMsg)
+		apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+		var metaErr MetaAPIError
+		if err := json.Unmarshal(respBody, &metaErr); err == nil && metaErr.Error.Message != "" {
+			apiErr.Code = metaErr.Error.Code
+			apiErr.Subcode = metaErr.Error.ErrorSubcode
+			apiErr.Message = metaErr.Error.Message
+			apiErr.Details = metaErr.Error.ErrorData.Details
+			apiErr.ErrorUserMsg = metaErr.Error.ErrorUserMsg
 		}
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, apiErr
 	}
 
 	return respBody, nil
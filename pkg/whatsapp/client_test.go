@@ -464,6 +464,104 @@ func TestClient_SendDocumentMessage(t *testing.T) {
 	assert.Equal(t, "wamid.doc123", msgID)
 }
 
+func TestClient_ErrorClassification(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantRateLimit bool
+		wantTransient bool
+		wantErr       string
+	}{
+		{
+			name:          "throughput exceeded",
+			status:        http.StatusBadRequest,
+			body:          `{"error":{"message":"Rate limit hit","code":130429}}`,
+			wantRateLimit: true,
+			wantErr:       "API error 130429: Rate limit hit",
+		},
+		{
+			name:          "pair rate limit",
+			status:        http.StatusBadRequest,
+			body:          `{"error":{"message":"Pair rate limit hit","code":131056}}`,
+			wantRateLimit: true,
+			wantErr:       "API error 131056: Pair rate limit hit",
+		},
+		{
+			name:          "too many requests without body",
+			status:        http.StatusTooManyRequests,
+			body:          "slow down",
+			wantRateLimit: true,
+			wantErr:       "API returned status 429: slow down",
+		},
+		{
+			name:          "invalid parameter",
+			status:        http.StatusBadRequest,
+			body:          `{"error":{"message":"Invalid parameter","code":100}}`,
+			wantRateLimit: false,
+			wantErr:       "API error 100: Invalid parameter",
+		},
+		{
+			name:          "service unavailable",
+			status:        http.StatusServiceUnavailable,
+			body:          `{"error":{"message":"Service temporarily unavailable","code":131016}}`,
+			wantTransient: true,
+			wantErr:       "API error 131016: Service temporarily unavailable",
+		},
+		{
+			name:          "gateway error without body",
+			status:        http.StatusBadGateway,
+			body:          "bad gateway",
+			wantTransient: true,
+			wantErr:       "API returned status 502: bad gateway",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := whatsapp.NewWithTimeout(testutil.NopLogger(), 5*time.Second)
+			client.HTTPClient = &http.Client{
+				Transport: &testServerTransport{serverURL: server.URL},
+			}
+
+			_, err := client.SendTextMessage(testutil.TestContext(t), testAccount(server.URL), "1234567890", "Hello")
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Equal(t, tt.wantRateLimit, whatsapp.IsRateLimitError(err))
+			assert.Equal(t, tt.wantTransient, whatsapp.IsTransientError(err))
+		})
+	}
+}
+
+func TestClient_NetworkErrorIsTransient(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	client := whatsapp.NewWithTimeout(testutil.NopLogger(), 5*time.Second)
+	client.HTTPClient = &http.Client{
+		Transport: &testServerTransport{serverURL: server.URL},
+	}
+
+	_, err := client.SendTextMessage(testutil.TestContext(t), testAccount(server.URL), "1234567890", "Hello")
+
+	require.Error(t, err)
+	assert.True(t, whatsapp.IsTransientError(err))
+	assert.False(t, whatsapp.IsRateLimitError(err))
+}
+
 // testServerTransport redirects all requests to the test server
 type testServerTransport struct {
 	serverURL string
@@ -0,0 +1,89 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Meta error codes that indicate a send was throttled rather than rejected.
+// See https://developers.facebook.com/docs/whatsapp/cloud-api/support/error-codes
+const (
+	ErrCodeAPITooManyCalls       = 4
+	ErrCodeRateLimitHit          = 80007
+	ErrCodeCloudAPIThroughput    = 130429
+	ErrCodeSpamRateLimitHit      = 131048
+	ErrCodePairRateLimitHit      = 131056
+	ErrCodeBusinessAccountLimits = 131064
+)
+
+// Meta error codes for temporary failures on Meta's side
+const (
+	ErrCodeAPIUnknown         = 1
+	ErrCodeAPIService         = 2
+	ErrCodeSomethingWentWrong = 131000
+	ErrCodeServiceUnavailable = 131016
+)
+
+// APIError is a non-200 response from the Meta API
+type APIError struct {
+	StatusCode   int
+	Code         int
+	Subcode      int
+	Message      string
+	Details      string
+	ErrorUserMsg string
+	Body         string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+	}
+	msg := fmt.Sprintf("API error %d: %s", e.Code, e.Message)
+	if e.Details != "" {
+		msg += " - Details: " + e.Details
+	}
+	if e.ErrorUserMsg != "" {
+		msg += " - " + e.ErrorUserMsg
+	}
+	return msg
+}
+
+// IsRateLimitError reports whether err is a Meta API error caused by sending
+// too fast or exceeding a messaging limit. Such sends can be retried later.
+func IsRateLimitError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	switch apiErr.Code {
+	case ErrCodeAPITooManyCalls, ErrCodeRateLimitHit, ErrCodeCloudAPIThroughput,
+		ErrCodeSpamRateLimitHit, ErrCodePairRateLimitHit, ErrCodeBusinessAccountLimits:
+		return true
+	}
+	return false
+}
+
+// IsTransientError reports whether err is a temporary failure, such as a
+// network error or Meta being unavailable, as opposed to a rejected message.
+// The same request may succeed when retried.
+func IsTransientError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.StatusCode >= http.StatusInternalServerError {
+			return true
+		}
+		switch apiErr.Code {
+		case ErrCodeAPIUnknown, ErrCodeAPIService, ErrCodeSomethingWentWrong, ErrCodeServiceUnavailable:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -87,14 +87,18 @@ func runMigrations(db *gorm.DB) error {
 		&models.UserOrganization{},
 		&models.Team{},
 		&models.TeamMember{},
+		&models.AgentSkill{},
 		&models.APIKey{},
 		&models.SSOProvider{},
 		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.WebhookDeliveryAttempt{},
 		&models.CustomAction{},
 		&models.UserAvailabilityLog{},
 		// WhatsApp models
 		&models.WhatsAppAccount{},
 		&models.Contact{},
+		&models.ContactSuppression{},
 		&models.Tag{},
 		&models.Message{},
 		&models.Template{},
@@ -104,10 +108,17 @@ func runMigrations(db *gorm.DB) error {
 		&models.KeywordRule{},
 		&models.ChatbotFlow{},
 		&models.ChatbotFlowStep{},
+		&models.ChatbotFlowVersion{},
 		&models.ChatbotSession{},
 		&models.ChatbotSessionMessage{},
 		&models.AIContext{},
+		&models.KnowledgeDocument{},
+		&models.KnowledgeChunk{},
 		&models.AgentTransfer{},
+		&models.Conversation{},
+		&models.CSATSurvey{},
+		&models.ConversationNote{},
+		&models.Notification{},
 		// Bulk message models
 		&models.BulkMessageCampaign{},
 		&models.BulkMessageRecipient{},
@@ -140,15 +151,23 @@ func cleanupTables(db *gorm.DB) {
 		// Chatbot tables
 		"chatbot_session_messages",
 		"chatbot_sessions",
+		"chatbot_flow_versions",
 		"chatbot_flow_steps",
 		"chatbot_flows",
 		"keyword_rules",
 		"chatbot_settings",
 		"ai_contexts",
+		"knowledge_chunks",
+		"knowledge_documents",
+		"notifications",
+		"conversation_notes",
+		"csat_surveys",
 		"agent_transfers",
+		"conversations",
 		// WhatsApp tables
 		"messages",
 		"tags",
+		"contact_suppressions",
 		"contacts",
 		"templates",
 		"whatsapp_flows",
@@ -158,10 +177,13 @@ func cleanupTables(db *gorm.DB) {
 		"custom_roles",
 		"permissions",
 		// Core tables
+		"agent_skills",
 		"team_members",
 		"teams",
 		"api_keys",
 		"sso_providers",
+		"webhook_delivery_attempts",
+		"webhook_deliveries",
 		"webhooks",
 		"custom_actions",
 		"user_availability_logs",
@@ -187,14 +209,22 @@ func TruncateTables(db *gorm.DB) {
 		"notification_rules",
 		"chatbot_session_messages",
 		"chatbot_sessions",
+		"chatbot_flow_versions",
 		"chatbot_flow_steps",
 		"chatbot_flows",
 		"keyword_rules",
 		"chatbot_settings",
 		"ai_contexts",
+		"knowledge_chunks",
+		"knowledge_documents",
+		"notifications",
+		"conversation_notes",
+		"csat_surveys",
 		"agent_transfers",
+		"conversations",
 		"messages",
 		"tags",
+		"contact_suppressions",
 		"contacts",
 		"templates",
 		"whatsapp_flows",
@@ -202,10 +232,13 @@ func TruncateTables(db *gorm.DB) {
 		"role_permissions",
 		"custom_roles",
 		"permissions",
+		"agent_skills",
 		"team_members",
 		"teams",
 		"api_keys",
 		"sso_providers",
+		"webhook_delivery_attempts",
+		"webhook_deliveries",
 		"webhooks",
 		"custom_actions",
 		"user_availability_logs",
@@ -283,8 +283,9 @@ func (m *MockWhatsAppClient) GetMessagesSentTo(phone string) []MockSentMessage {
 
 // MockQueue is a mock implementation of queue.Queue.
 type MockQueue struct {
-	mu   sync.Mutex
-	Jobs []*queue.RecipientJob
+	mu       sync.Mutex
+	Jobs     []*queue.RecipientJob
+	Webhooks [][]byte
 
 	// Configurable behavior
 	EnqueueFunc  func(ctx context.Context, job *queue.RecipientJob) error
@@ -335,6 +336,26 @@ func (m *MockQueue) EnqueueRecipients(ctx context.Context, jobs []*queue.Recipie
 	return nil
 }
 
+// EnqueueWebhook mocks enqueueing a raw webhook payload.
+func (m *MockQueue) EnqueueWebhook(ctx context.Context, payload []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Error != nil {
+		return m.Error
+	}
+
+	m.Webhooks = append(m.Webhooks, append([]byte(nil), payload...))
+	return nil
+}
+
+// WebhookCount returns the number of webhook payloads in the queue.
+func (m *MockQueue) WebhookCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.Webhooks)
+}
+
 // Close is a no-op for the mock.
 func (m *MockQueue) Close() error {
 	return nil
@@ -362,6 +383,7 @@ func (m *MockQueue) Reset() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.Jobs = m.Jobs[:0]
+	m.Webhooks = m.Webhooks[:0]
 	m.Error = nil
 }
 